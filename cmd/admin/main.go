@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const usage = `PeerPoker admin CLI
+
+Usage:
+  admin [flags] <command> [args]
+
+Commands:
+  tables                       List tables hosted by the node
+  dump <table>                 Dump a table's state, audit log and recent hands
+  kick <table> <player>        Remove a player from a table
+  advance <table>              Make the player to act check or fold, or void a
+                               hand stuck outside betting
+  pause <table>                Stop dealing new hands at a table
+  resume <table>               Deal hands at a paused table again
+  unfreeze <table>             Void the hand that froze a table on a chip
+                               mismatch and continue play
+  clients                      Show every WebSocket connection's outbound queue
+  reload-config                Reload log level, rake, action timer, rate limits
+                               and disconnect timeouts without restarting tables
+  disconnects <table>          Show players whose disconnect timers are running
+  snapshot <table>             Write a snapshot of a table to the node's snapshot dir
+  retry-settlement <table>     Resubmit escrow payouts that failed on-chain
+  settle <table>               Settle a table's batched hands on-chain now
+  close-channel <table>        Submit a table's last signed state channel on-chain
+  withdraw <player> <amount> [note]
+                               Pay out a player's cash-outs from the local
+                               ledger (blockchain disabled)
+  insurance [incident]         Show the insurance pool, optionally one incident's payouts
+  insurance-deposit <amount> [note]
+                               Add operator money to the insurance pool
+  compensate <table> <player> <amount> <incident> <hand> <reason>
+                               Pay a player from the insurance pool; pass
+                               -tx-hash if they were already paid manually
+  audit [action]               Show the node's audit log, optionally one action
+  audit-verify                 Check the audit log's hash chain
+  collusion [run]              Show collusion alerts; "run" analyses the hand
+                               histories first
+
+Flags:
+`
+
+var (
+	apiURL  = flag.String("api", envOr("ADMIN_API_URL", "http://localhost:8080"), "Base URL of the node's HTTP API")
+	token   = flag.String("token", os.Getenv("ADMIN_TOKEN"), "Admin API bearer token")
+	actor   = flag.String("as", envOr("USER", "admin"), "Operator name recorded in the audit log")
+	timeout = flag.Duration("timeout", 30*time.Second, "Request timeout")
+	txHash  = flag.String("tx-hash", "", "Reference to a payment made outside the node (insurance-deposit, compensate)")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	client := &http.Client{Timeout: *timeout}
+
+	var err error
+	switch cmd := args[0]; cmd {
+	case "tables":
+		err = run(client, http.MethodGet, "/api/v1/admin/tables", nil)
+	case "dump":
+		err = needArgs(args, 1, func() error {
+			return run(client, http.MethodGet, tablePath(args[1], ""), nil)
+		})
+	case "kick":
+		err = needArgs(args, 2, func() error {
+			return run(client, http.MethodPost, tablePath(args[1], "/kick"), map[string]string{
+				"player_id": args[2],
+			})
+		})
+	case "advance", "pause", "resume":
+		err = needArgs(args, 1, func() error {
+			return run(client, http.MethodPost, tablePath(args[1], "/"+cmd), nil)
+		})
+	case "unfreeze":
+		err = needArgs(args, 1, func() error {
+			return run(client, http.MethodPost, tablePath(args[1], "/unfreeze"), nil)
+		})
+	case "clients":
+		err = run(client, http.MethodGet, "/api/v1/admin/clients", nil)
+	case "reload-config":
+		err = run(client, http.MethodPost, "/api/v1/admin/config/reload", nil)
+	case "disconnects":
+		err = needArgs(args, 1, func() error {
+			return run(client, http.MethodGet, tablePath(args[1], "/disconnects"), nil)
+		})
+	case "snapshot":
+		err = needArgs(args, 1, func() error {
+			return run(client, http.MethodPost, tablePath(args[1], "/snapshot"), nil)
+		})
+	case "retry-settlement":
+		err = needArgs(args, 1, func() error {
+			return run(client, http.MethodPost, tablePath(args[1], "/settlements/retry"), nil)
+		})
+	case "settle":
+		err = needArgs(args, 1, func() error {
+			return run(client, http.MethodPost, tablePath(args[1], "/settlements/settle"), nil)
+		})
+	case "close-channel":
+		err = needArgs(args, 1, func() error {
+			return run(client, http.MethodPost, tablePath(args[1], "/channel/close"), nil)
+		})
+	case "withdraw":
+		err = needArgs(args, 2, func() error {
+			amount, err := strconv.Atoi(args[2])
+			if err != nil {
+				return fmt.Errorf("invalid amount %q", args[2])
+			}
+			return run(client, http.MethodPost, "/api/v1/admin/ledger/withdrawals", map[string]interface{}{
+				"player_id": args[1],
+				"amount":    amount,
+				"note":      strings.Join(args[3:], " "),
+			})
+		})
+	case "insurance":
+		path := "/api/v1/admin/insurance"
+		if len(args) > 1 {
+			path += "?incident=" + url.QueryEscape(args[1])
+		}
+		err = run(client, http.MethodGet, path, nil)
+	case "insurance-deposit":
+		err = needArgs(args, 1, func() error {
+			amount, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid amount %q", args[1])
+			}
+			return run(client, http.MethodPost, "/api/v1/admin/insurance/deposits", map[string]interface{}{
+				"amount":  amount,
+				"note":    strings.Join(args[2:], " "),
+				"tx_hash": *txHash,
+			})
+		})
+	case "compensate":
+		err = needArgs(args, 6, func() error {
+			amount, err := strconv.Atoi(args[3])
+			if err != nil {
+				return fmt.Errorf("invalid amount %q", args[3])
+			}
+			handID, err := strconv.Atoi(args[5])
+			if err != nil {
+				return fmt.Errorf("invalid hand ID %q", args[5])
+			}
+			return run(client, http.MethodPost, "/api/v1/admin/insurance/payouts", map[string]interface{}{
+				"table_id":    args[1],
+				"player_id":   args[2],
+				"amount":      amount,
+				"incident_id": args[4],
+				"hand_id":     handID,
+				"reason":      strings.Join(args[6:], " "),
+				"tx_hash":     *txHash,
+			})
+		})
+	case "audit":
+		path := "/api/v1/audit"
+		if len(args) > 1 {
+			path += "?action=" + url.QueryEscape(args[1])
+		}
+		err = run(client, http.MethodGet, path, nil)
+	case "audit-verify":
+		err = run(client, http.MethodGet, "/api/v1/audit/verify", nil)
+	case "collusion":
+		path := "/api/v1/admin/collusion"
+		if len(args) > 1 && args[1] == "run" {
+			path += "?run=true"
+		}
+		err = run(client, http.MethodGet, path, nil)
+	default:
+		err = fmt.Errorf("unknown command %q", cmd)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run sends a request to the admin API and pretty-prints the JSON response
+func run(client *http.Client, method, path string, body interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, strings.TrimRight(*apiURL, "/")+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("X-Client-ID", *actor)
+	if *token != "" {
+		req.Header.Set("Authorization", "Bearer "+*token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		os.Stdout.Write(data)
+		return nil
+	}
+	out.WriteByte('\n')
+	_, err = out.WriteTo(os.Stdout)
+	return err
+}
+
+// tablePath builds an admin path for a table; IDs are listen addresses like ":3000"
+func tablePath(tableID, suffix string) string {
+	return "/api/v1/admin/tables/" + url.PathEscape(tableID) + suffix
+}
+
+func needArgs(args []string, n int, fn func() error) error {
+	if len(args) < n+1 {
+		return fmt.Errorf("%s needs %d argument(s), see -help", args[0], n)
+	}
+	return fn()
+}
+
+func envOr(key, defaultVal string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return defaultVal
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/bot"
+	"github.com/sirupsen/logrus"
+)
+
+const usage = `PeerPoker bot players
+
+Fills seats for demos and integration tests. Each bot plays one node's seat
+through that node's HTTP API.
+
+Usage:
+  bot [flags] <api-url>=<player-id> [<api-url>=<player-id> ...]
+
+Example:
+  bot -strategy tight http://localhost:8081=:3001 http://localhost:8082=:3002
+
+Flags:
+`
+
+var (
+	strategy  = flag.String("strategy", "call-station", "Bot strategy: "+strings.Join(bot.StrategyNames(), ", "))
+	interval  = flag.Duration("interval", 500*time.Millisecond, "How often each bot polls its table")
+	thinkTime = flag.Duration("think", 0, "Longest random pause before acting")
+	seed      = flag.Int64("seed", 0, "Random seed (0 for the clock); bot i uses seed+i")
+	logLevel  = flag.String("log", "info", "Log level (debug, info, warn, error)")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if level, err := logrus.ParseLevel(*logLevel); err == nil {
+		logrus.SetLevel(level)
+	}
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	bots := make([]*bot.Bot, 0, flag.NArg())
+	for i, arg := range flag.Args() {
+		apiURL, playerID, ok := strings.Cut(arg, "=")
+		if !ok {
+			fmt.Fprintf(os.Stderr, "error: expected <api-url>=<player-id>, got %q\n", arg)
+			os.Exit(2)
+		}
+
+		s, err := bot.NewStrategy(*strategy)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+
+		botSeed := *seed
+		if botSeed != 0 {
+			botSeed += int64(i)
+		}
+		b, err := bot.New(bot.Config{
+			APIURL:    apiURL,
+			PlayerID:  playerID,
+			Strategy:  s,
+			Interval:  *interval,
+			ThinkTime: *thinkTime,
+			Seed:      botSeed,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+		bots = append(bots, b)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var wg sync.WaitGroup
+	for _, b := range bots {
+		wg.Add(1)
+		go func(b *bot.Bot) {
+			defer wg.Done()
+			b.Run(ctx)
+		}(b)
+	}
+	wg.Wait()
+}
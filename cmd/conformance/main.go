@@ -0,0 +1,80 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/conformance"
+)
+
+var (
+	peerURL    = flag.String("peer", "ws://localhost:3000/p2p", "WebSocket URL of the node's peer endpoint")
+	apiURL     = flag.String("api", "http://localhost:8080", "HTTP API base URL (empty skips seating flows)")
+	peerID     = flag.String("id", "", "Peer address the tester identifies as (random by default)")
+	timeout    = flag.Duration("timeout", 5*time.Second, "How long to wait for each expected message")
+	only       = flag.String("run", "", "Only run checks whose name starts with this prefix")
+	jsonOutput = flag.Bool("json", false, "Print the report as JSON")
+)
+
+func main() {
+	flag.Parse()
+
+	id := *peerID
+	if id == "" {
+		suffix := make([]byte, 4)
+		rand.Read(suffix)
+		id = "conformance-" + hex.EncodeToString(suffix)
+	}
+
+	checks := []conformance.Check{}
+	for _, check := range conformance.DefaultChecks() {
+		if strings.HasPrefix(check.Name, *only) {
+			checks = append(checks, check)
+		}
+	}
+
+	report := conformance.Run(conformance.Config{
+		PeerURL: *peerURL,
+		APIURL:  strings.TrimRight(*apiURL, "/"),
+		PeerID:  id,
+		Timeout: *timeout,
+	}, checks)
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		printReport(report)
+	}
+
+	if !report.Passed() {
+		os.Exit(1)
+	}
+}
+
+func printReport(report *conformance.Report) {
+	fmt.Printf("Protocol conformance: %s\n\n", report.Target)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, result := range report.Results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			result.Status, result.Name, result.Duration.Round(time.Millisecond), result.Description)
+		if result.Detail != "" {
+			fmt.Fprintf(w, "\t\t\t  %s\n", result.Detail)
+		}
+	}
+	w.Flush()
+
+	fmt.Printf("\n%d passed, %d failed, %d skipped\n",
+		report.Count(conformance.StatusPass),
+		report.Count(conformance.StatusFail),
+		report.Count(conformance.StatusSkip))
+}
@@ -0,0 +1,153 @@
+//go:build sim
+
+// Command loadtest drives simulated actions through an in-process table as
+// fast as the table will take them, up to a target rate, and prints the
+// table's per-stage latency histograms. It needs the sim build tag:
+//
+//	go run -tags sim ./cmd/loadtest -rate 1000 -duration 30s
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/game"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	rate     = flag.Int("rate", 1000, "Target actions per second")
+	duration = flag.Duration("duration", 10*time.Second, "How long to drive the table")
+	players  = flag.Int("players", 6, "Players at the table, including the local one")
+	seed     = flag.Int64("seed", 1, "Simulation deck seed")
+	budget   = flag.Duration("budget", game.DefaultLatencyBudget, "Latency budget to count actions against")
+)
+
+// Result is what a run reports
+type Result struct {
+	Target   int                `json:"target_per_sec"`
+	Achieved float64            `json:"achieved_per_sec"`
+	Applied  int                `json:"applied"`
+	Rejected int                `json:"rejected"`
+	Idle     int                `json:"idle_ticks"`
+	Hands    int                `json:"hands"`
+	Latency  game.LatencyReport `json:"latency"`
+}
+
+func main() {
+	flag.Parse()
+	if *rate <= 0 || *players < 2 {
+		fmt.Fprintln(os.Stderr, "loadtest: -rate must be positive and -players at least 2")
+		os.Exit(2)
+	}
+	logrus.SetLevel(logrus.WarnLevel)
+
+	const local = "loadtest-0"
+	g := game.NewGame(local, func(data []byte, targets ...string) {}, nil)
+	g.SetEventFunc(func(data []byte, targets ...string) {})
+	g.SetLatencyBudget(*budget)
+	if err := g.EnableSimulation(game.SimConfig{Seed: *seed}); err != nil {
+		fatal(err)
+	}
+
+	g.AddPlayer(local)
+	peers := make([]string, 0, *players-1)
+	for i := 1; i < *players; i++ {
+		peer := fmt.Sprintf("loadtest-%d", i)
+		g.AddPlayer(peer)
+		peers = append(peers, peer)
+	}
+	for _, peer := range peers {
+		if err := g.HandleMessage(peer, message(peer, protocol.TypePlayerReady, protocol.PlayerReadyPayload{PlayerID: peer})); err != nil {
+			fatal(err)
+		}
+	}
+	if err := g.SetPlayerReady(local); err != nil {
+		fatal(err)
+	}
+
+	result := Result{Target: *rate}
+	ticker := time.NewTicker(time.Second / time.Duration(*rate))
+	defer ticker.Stop()
+	deadline := time.After(*duration)
+	started := time.Now()
+
+run:
+	for {
+		select {
+		case <-deadline:
+			break run
+		case <-ticker.C:
+		}
+
+		state := g.PublicState()
+		turn := state.CurrentTurn
+		if turn == "" {
+			// Between hands: readying again deals the next one
+			if g.GetStatus() == game.GameStatusWaiting {
+				g.SetPlayerReady(local)
+				result.Hands++
+			}
+			result.Idle++
+			continue
+		}
+
+		action := pickAction(g.GetTableState(turn).ValidActions)
+		var err error
+		if turn == local {
+			err = g.HandlePlayerAction(local, action, 0)
+		} else {
+			err = g.HandleMessage(turn, message(turn, protocol.TypePlayerAction, protocol.PlayerActionPayload{
+				Action:            action,
+				CurrentGameStatus: state.Status,
+			}))
+		}
+		if err != nil {
+			result.Rejected++
+			continue
+		}
+		result.Applied++
+	}
+
+	elapsed := time.Since(started)
+	result.Achieved = float64(result.Applied) / elapsed.Seconds()
+	result.Latency = g.LatencyReport()
+	g.Close("loadtest finished")
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(result)
+}
+
+// pickAction keeps hands going to showdown: check when possible, else call,
+// else whatever is allowed
+func pickAction(valid []string) string {
+	for _, want := range []string{"check", "call"} {
+		for _, action := range valid {
+			if action == want {
+				return action
+			}
+		}
+	}
+	if len(valid) > 0 {
+		return valid[0]
+	}
+	return "fold"
+}
+
+func message(from string, msgType protocol.MessageType, payload interface{}) *protocol.Message {
+	msg, err := protocol.NewMessage(from, msgType, payload)
+	if err != nil {
+		fatal(err)
+	}
+	return msg
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "loadtest:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+	"github.com/RedPaladin7/peerpoker/internal/server"
+)
+
+// runBackup handles "backup", "backup list" and "backup restore <name>"
+func runBackup(args []string) error {
+	action := "now"
+	if len(args) > 0 && (args[0] == "list" || args[0] == "restore") {
+		action, args = args[0], args[1:]
+	}
+	fs, configPath := commandFlags("backup " + action)
+	fs.Parse(args)
+
+	cfg, err := loadCommandConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	backups, err := server.OpenBackups(cfg)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "list":
+		names, err := backups.ListBackups()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return nil
+
+	case "restore":
+		if fs.NArg() != 1 {
+			return fmt.Errorf("usage: backup restore [-config file] <backup-name>")
+		}
+		tmp, err := os.CreateTemp("", "peerpoker-restore-*.json")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary file: %w", err)
+		}
+		tmp.Close()
+		defer os.Remove(tmp.Name())
+
+		if err := backups.RestoreBackup(fs.Arg(0), tmp.Name()); err != nil {
+			return err
+		}
+		snapshot, err := persistence.LoadSnapshot(tmp.Name())
+		if err != nil {
+			return err
+		}
+		return restoreState(cfg, snapshot)
+
+	default:
+		if cfg.StateDir == "" {
+			return fmt.Errorf("STATE_DIR is not set, there are no state snapshots to back up")
+		}
+		latest, err := persistence.NewSnapshotManager(cfg.StateDir, cfg.StateSnapshotsKept).LatestFile()
+		if err != nil {
+			return err
+		}
+		if latest == "" {
+			return fmt.Errorf("no state snapshots in %s yet", cfg.StateDir)
+		}
+		if err := backups.CreateBackup(latest); err != nil {
+			return err
+		}
+		fmt.Printf("Backed up %s to %s\n", latest, backups.Backend().Name())
+		return nil
+	}
+}
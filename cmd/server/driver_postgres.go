@@ -0,0 +1,6 @@
+//go:build postgres
+
+package main
+
+// Registers the Postgres driver for STORE_DRIVER=postgres
+import _ "github.com/lib/pq"
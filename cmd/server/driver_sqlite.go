@@ -0,0 +1,6 @@
+//go:build sqlite
+
+package main
+
+// Registers the SQLite driver for STORE_DRIVER=sqlite
+import _ "modernc.org/sqlite"
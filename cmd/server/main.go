@@ -4,11 +4,10 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"os/signal"
-	"syscall"
+	"strings"
 
 	"github.com/RedPaladin7/peerpoker/internal/config"
-	"github.com/RedPaladin7/peerpoker/internal/server"
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
 	"github.com/sirupsen/logrus"
 )
 
@@ -27,16 +26,6 @@ Version: %s
 `
 )
 
-var (
-	// Command line flags
-	listenAddr   = flag.String("listen", ":3000", "WebSocket listen address")
-	apiPort      = flag.String("api", "8080", "HTTP API port")
-	peerAddr     = flag.String("peer", "", "Address of peer to connect to")
-	logLevel     = flag.String("log", "info", "Log level (debug, info, warn, error)")
-	showVersion  = flag.Bool("version", false, "Show version information")
-	showHelp     = flag.Bool("help", false, "Show help")
-)
-
 func init() {
 	// Setup logging
 	logrus.SetFormatter(&logrus.TextFormatter{
@@ -47,78 +36,57 @@ func init() {
 	logrus.SetOutput(os.Stdout)
 }
 
-func main() {
-	flag.Parse()
+const usage = `Usage:
+  peerpoker [serve] [flags]        Run the node (the default)
+  peerpoker snapshot restore <file>
+                                   Make a snapshot file the state the node resumes from
+  peerpoker backup [list | restore <name>]
+                                   Back up the latest state snapshot now, list
+                                   backups, or restore one as the state to resume from
+  peerpoker wallet new             Generate a signing or escrow wallet
+  peerpoker wallet import <key>    Check a private key ("-" reads stdin) and
+                                   show its address
+  peerpoker replay <hand-file>     Step through an exported hand history and
+                                   check its commitment
+  peerpoker verify-contracts       Check the configured contracts are deployed
+  peerpoker openapi                Print the HTTP API's OpenAPI document
+
+Commands read the same -config file and environment as serve;
+"peerpoker <command> -help" lists a command's flags.
+`
 
-	// Show version
-	if *showVersion {
-		fmt.Printf("%s v%s\n", appName, appVersion)
-		os.Exit(0)
+func main() {
+	cmd, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
 	}
 
-	// Show help
-	if *showHelp {
+	var err error
+	switch cmd {
+	case "serve":
+		runServe(args)
+	case "snapshot":
+		err = runSnapshot(args)
+	case "backup":
+		err = runBackup(args)
+	case "wallet":
+		err = runWallet(args)
+	case "replay":
+		err = runReplay(args)
+	case "verify-contracts":
+		err = runVerifyContracts(args)
+	case "openapi":
+		err = runOpenAPI(args)
+	case "help":
 		printBanner()
-		flag.Usage()
-		os.Exit(0)
-	}
-
-	// Print banner
-	printBanner()
-
-	// Set log level
-	setLogLevel(*logLevel)
-
-	// Load configuration
-	cfg := loadConfiguration()
-
-	// Override config with command line flags
-	if *listenAddr != ":3000" {
-		cfg.ListenAddr = *listenAddr
-	}
-	if *apiPort != "8080" {
-		cfg.APIPort = *apiPort
-	}
-
-	// Log configuration
-	logrus.WithFields(logrus.Fields{
-		"ws_addr":  cfg.ListenAddr,
-		"api_port": cfg.APIPort,
-	}).Info("Starting server with configuration")
-
-	// Check blockchain status
-	if os.Getenv("BLOCKCHAIN_ENABLED") == "true" {
-		logrus.Info("🔗 Blockchain integration ENABLED")
-		logrus.WithFields(logrus.Fields{
-			"rpc_url":  os.Getenv("BLOCKCHAIN_RPC_URL"),
-			"chain_id": os.Getenv("BLOCKCHAIN_CHAIN_ID"),
-		}).Info("Blockchain configuration")
-	} else {
-		logrus.Warn("⚠️  Blockchain integration DISABLED (running without smart contracts)")
-	}
-
-	// Create server
-	srv := server.NewServer(cfg)
-
-	// Setup graceful shutdown
-	setupGracefulShutdown(srv)
-
-	// Connect to initial peer if specified
-	if *peerAddr != "" {
-		logrus.Infof("Connecting to initial peer: %s", *peerAddr)
-		go func() {
-			if err := srv.ConnectToPeer(*peerAddr); err != nil {
-				logrus.Errorf("Failed to connect to peer %s: %v", *peerAddr, err)
-			} else {
-				logrus.Infof("Successfully connected to peer %s", *peerAddr)
-			}
-		}()
+		fmt.Print(usage)
+	default:
+		err = fmt.Errorf("unknown command %q\n\n%s", cmd, usage)
 	}
 
-	// Start server (blocks until error or shutdown)
-	logrus.Info("🚀 Server starting...")
-	if err := srv.Start(); err != nil {
-		logrus.Fatalf("Server failed to start: %v", err)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
 	}
 }
 
@@ -128,33 +96,31 @@ func printBanner() {
 	fmt.Println()
 }
 
-// setLogLevel sets the logging level
-func setLogLevel(level string) {
-	switch level {
-	case "debug":
-		logrus.SetLevel(logrus.DebugLevel)
-	case "info":
-		logrus.SetLevel(logrus.InfoLevel)
-	case "warn":
-		logrus.SetLevel(logrus.WarnLevel)
-	case "error":
-		logrus.SetLevel(logrus.ErrorLevel)
-	default:
-		logrus.SetLevel(logrus.InfoLevel)
-		logrus.Warnf("Unknown log level '%s', defaulting to 'info'", level)
-	}
-	logrus.Infof("Log level set to: %s", logrus.GetLevel().String())
+// commandFlags returns the flag set for an operations command, with the
+// -config flag every command shares
+func commandFlags(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	configPath := fs.String("config", getEnv("CONFIG_FILE", ""), "Config file (.yaml, .yml or .toml)")
+	return fs, configPath
 }
 
-// loadConfiguration loads configuration from environment
-func loadConfiguration() *config.Config {
-	cfg := &config.Config{
-		ListenAddr: getEnv("WS_PORT", ":3000"),
-		APIPort:    getEnv("API_PORT", "8080"),
+// loadCommandConfig loads the configuration for an operations command and
+// applies its snapshot key, so sealed snapshots can be read and written
+func loadCommandConfig(path string) (*config.Config, error) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
-
-	logrus.Debug("Configuration loaded from environment")
-	return cfg
+	if cfg.SnapshotKey != "" {
+		key, err := persistence.ParseSnapshotKey(cfg.SnapshotKey)
+		if err == nil {
+			err = persistence.SetSnapshotKey(key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot key: %w", err)
+		}
+	}
+	return cfg, nil
 }
 
 // getEnv gets an environment variable with a default value
@@ -165,21 +131,3 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
-
-// setupGracefulShutdown sets up graceful shutdown handling
-func setupGracefulShutdown(srv *server.Server) {
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
-
-	go func() {
-		sig := <-sigChan
-		logrus.Infof("Received signal: %v", sig)
-		logrus.Info("Initiating graceful shutdown...")
-
-		// Stop server
-		srv.Stop()
-
-		logrus.Info("Shutdown complete. Goodbye! 👋")
-		os.Exit(0)
-	}()
-}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+
+	"github.com/RedPaladin7/peerpoker/internal/api"
+)
+
+// runOpenAPI prints the API's OpenAPI document, the same one a running
+// node serves at /api/v1/openapi.json, for client generators
+func runOpenAPI(args []string) error {
+	fs := flag.NewFlagSet("openapi", flag.ExitOnError)
+	fs.Parse(args)
+
+	handler := api.NewHandler(nil, nil, nil)
+	handler.SetVersion(appVersion)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(handler.OpenAPI())
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/RedPaladin7/peerpoker/internal/game"
+)
+
+// runReplay prints a hand history exported from /api/hands/{id} action by
+// action, and checks it still hashes to the commitment taken when the
+// hand finished
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: replay <hand-file>")
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to read hand: %w", err)
+	}
+	var hand game.HandHistory
+	if err := json.Unmarshal(data, &hand); err != nil {
+		return fmt.Errorf("failed to parse hand: %w", err)
+	}
+
+	fmt.Printf("Hand %d (%s), started %s\n", hand.ID, hand.UID, hand.StartedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Seats: %s (dealer %s)\n", strings.Join(hand.Seats, ", "), hand.Dealer)
+
+	street := ""
+	for _, action := range hand.Actions {
+		if action.Street != street {
+			street = action.Street
+			fmt.Printf("\n%s\n", street)
+		}
+		line := fmt.Sprintf("  %3d. %s %s", action.Seq, action.PlayerID, action.Action)
+		if action.Amount > 0 {
+			line += fmt.Sprintf(" %d", action.Amount)
+		}
+		fmt.Println(line)
+	}
+
+	fmt.Println()
+	if len(hand.Board) > 0 {
+		fmt.Printf("Board: %s\n", strings.Join(hand.Board, " "))
+	}
+	if len(hand.SecondBoard) > 0 {
+		fmt.Printf("Second board: %s\n", strings.Join(hand.SecondBoard, " "))
+	}
+	shown := make([]string, 0, len(hand.Shown))
+	for player := range hand.Shown {
+		shown = append(shown, player)
+	}
+	sort.Strings(shown)
+	for _, player := range shown {
+		fmt.Printf("%s shows %s\n", player, strings.Join(hand.Shown[player], " "))
+	}
+	fmt.Printf("Pot %d, rake %d\n", hand.Pot, hand.Rake)
+	winners := make([]string, 0, len(hand.Winnings))
+	for player := range hand.Winnings {
+		winners = append(winners, player)
+	}
+	sort.Strings(winners)
+	for _, player := range winners {
+		fmt.Printf("%s wins %d\n", player, hand.Winnings[player])
+	}
+	if hand.Voided {
+		fmt.Printf("Voided: %s\n", hand.VoidReason)
+	}
+
+	if hand.Commitment == "" {
+		fmt.Println("\nNo commitment recorded; the hand cannot be verified")
+		return nil
+	}
+	computed, err := game.HandCommitment(&hand)
+	if err != nil {
+		return err
+	}
+	if computed != hand.Commitment {
+		return fmt.Errorf("hand does not match its commitment: recorded %s, computed %s", hand.Commitment, computed)
+	}
+	fmt.Printf("\nCommitment %s verified\n", hand.Commitment)
+	return nil
+}
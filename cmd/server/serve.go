@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/config"
+	"github.com/RedPaladin7/peerpoker/internal/logging"
+	"github.com/RedPaladin7/peerpoker/internal/server"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// serve flags, which override the config file and environment
+	serveFlags   = flag.NewFlagSet("serve", flag.ExitOnError)
+	configFile   = serveFlags.String("config", getEnv("CONFIG_FILE", ""), "Config file (.yaml, .yml or .toml)")
+	listenAddr   = serveFlags.String("listen", ":3000", "WebSocket listen address")
+	apiPort      = serveFlags.String("api", "8080", "HTTP API port")
+	peerAddr     = serveFlags.String("peer", "", "Address of peer to connect to")
+	logLevel     = serveFlags.String("log", "info", "Log level (debug, info, warn, error)")
+	logFormat    = serveFlags.String("log-format", logging.FormatText, "Log output format (text, or json for log aggregation)")
+	shutdownWait = serveFlags.Duration("shutdown-timeout", 2*time.Minute, "How long to let the current hand finish before refunding it on shutdown")
+	showVersion  = serveFlags.Bool("version", false, "Show version information")
+	showHelp     = serveFlags.Bool("help", false, "Show help")
+)
+
+// runServe runs the node until SIGINT or SIGTERM
+func runServe(args []string) {
+	serveFlags.Parse(args)
+
+	// Show version
+	if *showVersion {
+		fmt.Printf("%s v%s\n", appName, appVersion)
+		os.Exit(0)
+	}
+
+	// Show help
+	if *showHelp {
+		printBanner()
+		fmt.Print(usage + "\nserve flags:\n")
+		serveFlags.PrintDefaults()
+		os.Exit(0)
+	}
+
+	// Print banner
+	printBanner()
+
+	// Load configuration, failing fast on bad values
+	cfg, err := loadConfiguration()
+	if err != nil {
+		logrus.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Set log level and format
+	setLogLevel(cfg.LogLevel)
+	if err := logging.SetFormat(cfg.LogFormat); err != nil {
+		logrus.Fatalf("Invalid log format: %v", err)
+	}
+	if *configFile != "" {
+		logrus.Infof("Configuration loaded from %s", *configFile)
+	}
+
+	// Log configuration
+	logrus.WithFields(logrus.Fields{
+		"ws_addr":  cfg.ListenAddr,
+		"api_port": cfg.APIPort,
+	}).Info("Starting server with configuration")
+
+	// Check blockchain status
+	if cfg.BlockchainEnabled {
+		logrus.Info("🔗 Blockchain integration ENABLED")
+		logrus.WithFields(logrus.Fields{
+			"rpc_url":  cfg.BlockchainRPCURL,
+			"chain_id": cfg.BlockchainChainID,
+		}).Info("Blockchain configuration")
+	} else {
+		logrus.Warn("⚠️  Blockchain integration DISABLED (running without smart contracts)")
+	}
+
+	// Create server
+	srv := server.NewServer(cfg)
+	srv.SetConfigLoader(loadConfiguration)
+
+	// Stop on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Reload runtime settings on SIGHUP
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+	go func() {
+		for range hangup {
+			if _, err := srv.ReloadConfig("SIGHUP"); err != nil {
+				logrus.Errorf("Config reload failed: %v", err)
+			}
+		}
+	}()
+
+	// Connect to initial peer if specified
+	if *peerAddr != "" {
+		logrus.Infof("Connecting to initial peer: %s", *peerAddr)
+		go func() {
+			if err := srv.ConnectToPeer(*peerAddr); err != nil {
+				logrus.Errorf("Failed to connect to peer %s: %v", *peerAddr, err)
+			} else {
+				logrus.Infof("Successfully connected to peer %s", *peerAddr)
+			}
+		}()
+	}
+
+	// Start server (blocks until error or shutdown)
+	logrus.Info("🚀 Server starting...")
+	runErr := srv.Start(ctx)
+	if runErr != nil {
+		logrus.Errorf("Server failed: %v", runErr)
+	} else {
+		logrus.Info("Received shutdown signal")
+	}
+	stop()
+
+	// Stop server, giving the current hand time to finish
+	logrus.Info("Initiating graceful shutdown...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownWait)
+	defer cancel()
+	if err := srv.Stop(shutdownCtx); err != nil {
+		logrus.Errorf("Shutdown finished with errors: %v", err)
+		runErr = err
+	}
+
+	if runErr != nil {
+		os.Exit(1)
+	}
+	logrus.Info("Shutdown complete. Goodbye! 👋")
+}
+
+// setLogLevel sets the logging level
+func setLogLevel(level string) {
+	switch level {
+	case "debug":
+		logrus.SetLevel(logrus.DebugLevel)
+	case "info":
+		logrus.SetLevel(logrus.InfoLevel)
+	case "warn":
+		logrus.SetLevel(logrus.WarnLevel)
+	case "error":
+		logrus.SetLevel(logrus.ErrorLevel)
+	default:
+		logrus.SetLevel(logrus.InfoLevel)
+		logrus.Warnf("Unknown log level '%s', defaulting to 'info'", level)
+	}
+	logrus.Infof("Log level set to: %s", logrus.GetLevel().String())
+}
+
+// loadConfiguration loads the config file and environment, then applies
+// the command line flags that were given
+func loadConfiguration() (*config.Config, error) {
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	serveFlags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "listen":
+			cfg.ListenAddr = *listenAddr
+		case "api":
+			cfg.APIPort = *apiPort
+		case "log":
+			cfg.LogLevel = *logLevel
+		case "log-format":
+			cfg.LogFormat = *logFormat
+		}
+	})
+	return cfg, cfg.Validate()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/config"
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+	"github.com/RedPaladin7/peerpoker/internal/server"
+)
+
+// runSnapshot handles "snapshot restore <file>"
+func runSnapshot(args []string) error {
+	fs, configPath := commandFlags("snapshot restore")
+	if len(args) == 0 || args[0] != "restore" {
+		return fmt.Errorf("usage: snapshot restore [-config file] <snapshot-file>")
+	}
+	fs.Parse(args[1:])
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: snapshot restore [-config file] <snapshot-file>")
+	}
+
+	cfg, err := loadCommandConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	snapshot, err := persistence.LoadSnapshot(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return restoreState(cfg, snapshot)
+}
+
+// restoreState makes snapshot the newest state snapshot, so the node
+// resumes from it on its next start. The write-ahead log belongs to the
+// state being replaced and is moved aside rather than replayed on top.
+// The node must be stopped first, or it will overwrite the restore.
+func restoreState(cfg *config.Config, snapshot *persistence.GameSnapshot) error {
+	if cfg.StateDir == "" {
+		return fmt.Errorf("STATE_DIR is not set, the node does not resume from snapshots")
+	}
+
+	wal := filepath.Join(cfg.StateDir, server.WALFileName)
+	if _, err := os.Stat(wal); err == nil {
+		aside := fmt.Sprintf("%s.%d.replaced", wal, time.Now().Unix())
+		if err := os.Rename(wal, aside); err != nil {
+			return fmt.Errorf("failed to move the WAL aside: %w", err)
+		}
+		fmt.Printf("Moved the write-ahead log to %s\n", aside)
+	}
+	snapshot.WALSeq = 0
+
+	file, err := persistence.NewSnapshotManager(cfg.StateDir, cfg.StateSnapshotsKept).Save(snapshot)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored snapshot from %s (hand %d, %s, %d players) to %s\n",
+		snapshot.Timestamp.Format(time.RFC3339), snapshot.HandCount, snapshot.GameStatus, len(snapshot.Players), file)
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/RedPaladin7/peerpoker/internal/blockchain"
+	"github.com/RedPaladin7/peerpoker/internal/server"
+)
+
+// runVerifyContracts connects to the configured RPC node and checks every
+// contract address has code deployed, failing if any does not
+func runVerifyContracts(args []string) error {
+	fs, configPath := commandFlags("verify-contracts")
+	fs.Parse(args)
+
+	cfg, err := loadCommandConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if cfg.BlockchainRPCURL == "" || cfg.BlockchainPrivateKey == "" {
+		return fmt.Errorf("BLOCKCHAIN_RPC_URL and BLOCKCHAIN_PRIVATE_KEY must be set")
+	}
+
+	bc, err := blockchain.NewBlockchainClient(server.ChainConfig(cfg))
+	if err != nil {
+		return err
+	}
+	defer bc.Close()
+
+	chainID := bc.ChainID().String()
+	fmt.Printf("Chain ID %s\n", chainID)
+	if cfg.BlockchainChainID != "" && cfg.BlockchainChainID != chainID {
+		return fmt.Errorf("RPC node is on chain %s, but BLOCKCHAIN_CHAIN_ID is %s", chainID, cfg.BlockchainChainID)
+	}
+
+	failed := 0
+	for _, check := range bc.VerifyContracts() {
+		status := "deployed"
+		switch {
+		case check.Error != "":
+			status = check.Error
+			failed++
+		case !check.Deployed:
+			status = "NO CODE"
+			failed++
+		}
+		fmt.Printf("%-16s %s  %s\n", check.Name, check.Address, status)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d contract(s) failed verification", failed)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/RedPaladin7/peerpoker/internal/blockchain"
+)
+
+// runWallet handles "wallet new" and "wallet import <key>". Keys are for
+// SIGNING_PRIVATE_KEY (message signing) or BLOCKCHAIN_PRIVATE_KEY (escrow).
+func runWallet(args []string) error {
+	if len(args) == 0 || (args[0] != "new" && args[0] != "import") {
+		return fmt.Errorf("usage: wallet new [-out file] | wallet import [-out file] <key | ->")
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("wallet "+action, flag.ExitOnError)
+	out := fs.String("out", "", "Write the private key to this file (mode 0600) instead of printing it")
+	fs.Parse(args[1:])
+
+	var wallet *blockchain.Wallet
+	var err error
+	if action == "new" {
+		wallet, err = blockchain.GenerateWallet()
+	} else {
+		if fs.NArg() != 1 {
+			return fmt.Errorf("usage: wallet import [-out file] <key | ->")
+		}
+		key := fs.Arg(0)
+		if key == "-" {
+			if key, err = bufio.NewReader(os.Stdin).ReadString('\n'); err != nil && key == "" {
+				return fmt.Errorf("failed to read key from stdin: %w", err)
+			}
+		}
+		wallet, err = blockchain.LoadWallet(strings.TrimPrefix(strings.TrimSpace(key), "0x"))
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Address: %s\n", wallet.GetAddressHex())
+	if *out != "" {
+		if err := os.WriteFile(*out, []byte(wallet.GetPrivateKeyHex()+"\n"), 0600); err != nil {
+			return fmt.Errorf("failed to write key: %w", err)
+		}
+		fmt.Printf("Private key written to %s\n", *out)
+		return nil
+	}
+	fmt.Printf("Private key: %s\n", wallet.GetPrivateKeyHex())
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/RedPaladin7/peerpoker/pkg/client"
+)
+
+const usage = `PeerPoker terminal client
+
+Plays one node's seat interactively. The table redraws as events arrive;
+type a command and press enter to act.
+
+Usage:
+  tui [flags]
+
+Example:
+  tui -api http://localhost:8081 -ws ws://localhost:3001/ws -player :3001
+
+Flags:
+`
+
+var (
+	apiURL   = flag.String("api", "http://localhost:8080", "Base URL of the node's HTTP API")
+	wsURL    = flag.String("ws", "ws://localhost:3000/ws", "The node's player WebSocket URL")
+	playerID = flag.String("player", ":3000", "The player to act for (the node's listen address)")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	c, err := client.New(client.Config{
+		APIURL:   *apiURL,
+		WSURL:    *wsURL,
+		PlayerID: *playerID,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ui := newScreen(c)
+	c.OnAny(ui.handleEvent)
+	c.OnConnect(func() { ui.setStatus("connected") })
+	c.OnDisconnect(func(err error) { ui.setStatus(fmt.Sprintf("disconnected: %v, reconnecting", err)) })
+
+	go c.Run(ctx)
+	go ui.run(ctx)
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			return
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			quit, err := runCommand(ctx, c, line)
+			if quit {
+				return
+			}
+			if err != nil {
+				ui.setStatus(err.Error())
+			} else {
+				ui.setStatus("")
+			}
+			ui.refresh()
+		}
+	}
+}
+
+// runCommand carries out one line of input; it reports true when the
+// player asked to quit
+func runCommand(ctx context.Context, c *client.Client, line string) (bool, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false, nil
+	}
+
+	amount := func() (int, error) {
+		if len(fields) < 2 {
+			return 0, fmt.Errorf("%s needs an amount", fields[0])
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid amount %q", fields[1])
+		}
+		return n, nil
+	}
+
+	switch strings.ToLower(fields[0]) {
+	case "q", "quit", "exit":
+		return true, nil
+	case "ready":
+		return false, c.Ready(ctx)
+	case "f", "fold":
+		return false, c.Fold(ctx)
+	case "k", "check":
+		return false, c.Check(ctx)
+	case "c", "call":
+		return false, c.Call(ctx)
+	case "a", "allin", "all-in":
+		return false, c.AllIn(ctx)
+	case "b", "bet":
+		n, err := amount()
+		if err != nil {
+			return false, err
+		}
+		return false, c.Bet(ctx, n)
+	case "r", "raise":
+		n, err := amount()
+		if err != nil {
+			return false, err
+		}
+		return false, c.Raise(ctx, n)
+	case "s", "show":
+		return false, c.Show(ctx)
+	case "m", "muck":
+		return false, c.Muck(ctx)
+	case "say", "chat":
+		text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), fields[0]))
+		if text == "" {
+			return false, fmt.Errorf("nothing to say")
+		}
+		return false, c.Chat(ctx, text)
+	default:
+		return false, fmt.Errorf("unknown command %q", fields[0])
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/pkg/client"
+)
+
+// logLines is how much of the hand's action log stays on screen
+const logLines = 8
+
+const commandHelp = "ready | f(old) | k (check) | c(all) | b(et) N | r(aise) N | a(ll-in) | s(how) | m(uck) | say TEXT | q(uit)"
+
+// screen redraws the table whenever an event or command may have
+// changed it
+type screen struct {
+	client *client.Client
+	redraw chan struct{}
+
+	mu     sync.Mutex
+	status string
+	log    []string
+}
+
+func newScreen(c *client.Client) *screen {
+	return &screen{
+		client: c,
+		redraw: make(chan struct{}, 1),
+		status: "connecting",
+	}
+}
+
+func (s *screen) run(ctx context.Context) {
+	s.refresh()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.redraw:
+			s.draw(ctx)
+		}
+	}
+}
+
+// refresh asks for a redraw; bursts of events collapse into one
+func (s *screen) refresh() {
+	select {
+	case s.redraw <- struct{}{}:
+	default:
+	}
+}
+
+func (s *screen) setStatus(status string) {
+	s.mu.Lock()
+	s.status = status
+	s.mu.Unlock()
+	s.refresh()
+}
+
+func (s *screen) addLog(line string) {
+	s.mu.Lock()
+	s.log = append(s.log, time.Now().Format("15:04:05 ")+line)
+	if len(s.log) > logLines {
+		s.log = s.log[len(s.log)-logLines:]
+	}
+	s.mu.Unlock()
+}
+
+// handleEvent notes the events worth reading in the log, then redraws
+func (s *screen) handleEvent(event *client.Event) {
+	switch event.Type {
+	case client.EventNewHand:
+		s.addLog("--- new hand ---")
+	case client.EventPlayerAction:
+		var action client.PlayerActionEvent
+		if client.Decode(event, &action) == nil {
+			line := action.PlayerID + " " + action.Action
+			if action.Amount > 0 {
+				line += fmt.Sprintf(" %d", action.Amount)
+			}
+			s.addLog(line)
+		}
+	case client.EventWinner:
+		var winner client.WinnerEvent
+		if client.Decode(event, &winner) == nil {
+			for _, w := range winner.Winners {
+				line := fmt.Sprintf("%s wins %d", w.PlayerID, w.Amount)
+				if w.HandName != "" {
+					line += " with " + w.HandName
+				}
+				s.addLog(line)
+			}
+		}
+	case client.EventChat:
+		var chat client.ChatEvent
+		if client.Decode(event, &chat) == nil {
+			s.addLog(fmt.Sprintf("<%s> %s", chat.PlayerID, chat.Text))
+		}
+	case client.EventError:
+		var e client.ErrorEvent
+		if client.Decode(event, &e) == nil {
+			s.addLog("error: " + e.Message)
+		}
+	case client.EventPlayerDisconnected, client.EventPlayerReconnected, client.EventPlayerAbandoned, client.EventGameAborted:
+		s.addLog(string(event.Type))
+	}
+	s.refresh()
+}
+
+func (s *screen) draw(ctx context.Context) {
+	table, tableErr := s.client.Table(ctx)
+	players, playersErr := s.client.Players(ctx)
+
+	var b strings.Builder
+	b.WriteString("\033[H\033[2J")
+	fmt.Fprintf(&b, "PeerPoker - %s\n\n", s.client.PlayerID())
+
+	if tableErr != nil {
+		fmt.Fprintf(&b, "  table unavailable: %v\n", tableErr)
+	} else {
+		fmt.Fprintf(&b, "  %s   blinds %d/%d   pot %d\n", table.Status, table.SmallBlind, table.BigBlind, table.Pot)
+		fmt.Fprintf(&b, "  board: %s\n\n", cards(table.CommunityCards))
+	}
+
+	if playersErr == nil {
+		for _, p := range players {
+			marker := "  "
+			if p.IsCurrentTurn {
+				marker = "> "
+			}
+			flags := []string{}
+			if p.IsDealer {
+				flags = append(flags, "D")
+			}
+			if p.IsFolded {
+				flags = append(flags, "folded")
+			}
+			if p.IsAllIn {
+				flags = append(flags, "all-in")
+			}
+			if !p.IsActive {
+				flags = append(flags, "away")
+			}
+			if p.IsReady && table != nil && table.Status == client.StatusWaiting {
+				flags = append(flags, "ready")
+			}
+			me := ""
+			if p.PlayerID == s.client.PlayerID() {
+				me = " (you)"
+			}
+			fmt.Fprintf(&b, "%s%-22s stack %6d  bet %5d  %s\n", marker, p.PlayerID+me, p.Stack, p.CurrentBet, strings.Join(flags, " "))
+		}
+		b.WriteString("\n")
+	}
+
+	if table != nil {
+		fmt.Fprintf(&b, "  your hand: %s   stack %d\n", cards(table.MyHand), table.MyStack)
+		if table.IsMyTurn {
+			fmt.Fprintf(&b, "  YOUR TURN - to call %d, min raise %d, max %d\n", table.AmountToCall, table.MinRaise, table.MaxBet)
+			fmt.Fprintf(&b, "  valid: %s\n", strings.Join(table.ValidActions, ", "))
+		}
+		b.WriteString("\n")
+	}
+
+	s.mu.Lock()
+	for _, line := range s.log {
+		fmt.Fprintf(&b, "  %s\n", line)
+	}
+	status := s.status
+	s.mu.Unlock()
+
+	fmt.Fprintf(&b, "\n  %s\n", commandHelp)
+	if status != "" {
+		fmt.Fprintf(&b, "  [%s]\n", status)
+	}
+	b.WriteString("> ")
+	os.Stdout.WriteString(b.String())
+}
+
+func cards(hand []client.Card) string {
+	if len(hand) == 0 {
+		return "-"
+	}
+	shown := make([]string, len(hand))
+	for i, card := range hand {
+		shown[i] = card.Display
+	}
+	return strings.Join(shown, " ")
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+)
+
+// Get the open vote to abort the session
+func (h *Handler) HandleGetAbortVote(w http.ResponseWriter, r *http.Request) {
+	vote, ok := h.game.AbortVote()
+	if !ok {
+		apiError(w, "No abort vote in progress", http.StatusNotFound)
+		return
+	}
+
+	JSON(w, http.StatusOK, vote)
+}
+
+// Propose or vote on aborting the session with a full refund
+func (h *Handler) HandleVoteAbort(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Approve bool   `json:"approve"`
+		Reason  string `json:"reason,omitempty"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.game.VoteAbort(clientID, req.Approve, req.Reason); err != nil {
+		apiError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status": "voted",
+	}
+	if vote, ok := h.game.AbortVote(); ok {
+		response["vote"] = vote
+	}
+	JSON(w, http.StatusOK, response)
+}
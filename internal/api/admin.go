@@ -0,0 +1,427 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/RedPaladin7/peerpoker/internal/deck"
+	"github.com/RedPaladin7/peerpoker/internal/lobby"
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// Get bot-detection scores (reported for review, never auto-banned)
+func (h *Handler) HandleGetBotScores(w http.ResponseWriter, r *http.Request) {
+	scores := h.game.BotScores()
+
+	flagged := 0
+	for _, score := range scores {
+		if score.Flagged {
+			flagged++
+		}
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"scores":  scores,
+		"total":   len(scores),
+		"flagged": flagged,
+	})
+}
+
+// Get collusion alerts (reported for review, never acted on automatically).
+// ?run=true analyses the hand histories now instead of waiting for the schedule.
+func (h *Handler) HandleGetCollusionAlerts(w http.ResponseWriter, r *http.Request) {
+	if h.collusion == nil {
+		apiError(w, "Collusion analysis is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	fresh := 0
+	if r.URL.Query().Get("run") == "true" {
+		fresh = len(h.collusion.RunOnce())
+	}
+
+	alerts := h.collusion.Alerts()
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"alerts": alerts,
+		"total":  len(alerts),
+		"new":    fresh,
+	})
+}
+
+// Get every WebSocket connection's outbound queue, fullest first
+func (h *Handler) HandleAdminClients(w http.ResponseWriter, r *http.Request) {
+	queues := h.hub.ClientQueues()
+	sort.Slice(queues, func(i, j int) bool {
+		return queues[i].Depth > queues[j].Depth
+	})
+
+	saturated := 0
+	for _, q := range queues {
+		if q.SaturatedSince != nil {
+			saturated++
+		}
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"clients":   queues,
+		"total":     len(queues),
+		"saturated": saturated,
+	})
+}
+
+// Get the hand evaluator engine and its cross-check results
+func (h *Handler) HandleGetEvaluator(w http.ResponseWriter, r *http.Request) {
+	evaluator := h.game.Evaluator()
+
+	response := map[string]interface{}{
+		"engine":    evaluator.Name(),
+		"available": deck.EvaluatorNames(),
+	}
+	if crossCheck, ok := evaluator.(*deck.CrossCheckEvaluator); ok {
+		response["cross_check"] = crossCheck.Stats()
+	}
+	JSON(w, http.StatusOK, response)
+}
+
+// Reload the settings that can change without restarting tables
+func (h *Handler) HandleAdminReloadConfig(w http.ResponseWriter, r *http.Request) {
+	if h.reloader == nil {
+		apiError(w, "Config reload is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	result, err := h.reloader.ReloadConfig(adminActor(r))
+	if err != nil {
+		apiError(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"reload":  result,
+	})
+}
+
+// List every table hosted by this node
+func (h *Handler) HandleAdminListTables(w http.ResponseWriter, r *http.Request) {
+	if h.tables == nil {
+		apiError(w, "Admin endpoints are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	tables := h.tables.Tables()
+	infos := make([]lobby.TableInfo, len(tables))
+	for i, t := range tables {
+		infos[i] = t.Info()
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"tables": infos,
+		"count":  len(infos),
+	})
+}
+
+// Dump a table's full state for inspection
+func (h *Handler) HandleAdminDumpTable(w http.ResponseWriter, r *http.Request) {
+	table, ok := h.adminTable(w, r)
+	if !ok {
+		return
+	}
+	g := table.Game
+
+	response := map[string]interface{}{
+		"table":        table.Info(),
+		"state":        g.Snapshot(),
+		"settlements":  g.FailedSettlements(),
+		"audit":        g.AuditLog(),
+		"recent_hands": g.HandHistories(),
+		"paused":       g.Paused(),
+		"frozen":       g.Frozen(),
+		"disconnects":  g.DisconnectHandler.GetStatus(),
+	}
+	if draw, ok := g.SeatDraw(); ok {
+		response["seat_draw"] = draw
+	}
+	if vote, ok := g.AbortVote(); ok {
+		response["abort_vote"] = vote
+	}
+	if pause, ok := g.PauseStatus(); ok {
+		response["pause"] = pause
+	}
+	if vote, ok := g.PauseVote(); ok {
+		response["pause_vote"] = vote
+	}
+	if batch := g.PendingSettlementBatch(); batch != nil {
+		response["settlement_batch"] = batch
+	}
+
+	JSON(w, http.StatusOK, response)
+}
+
+// Remove a player from a table
+func (h *Handler) HandleAdminKick(w http.ResponseWriter, r *http.Request) {
+	table, ok := h.adminTable(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		PlayerID string `json:"player_id"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.PlayerID == "" {
+		apiError(w, "player_id is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := table.Game.KickPlayer(req.PlayerID, adminActor(r)); err != nil {
+		apiError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"table":  table.ID,
+		"player": req.PlayerID,
+		"actor":  adminActor(r),
+	}).Warn("Player kicked by operator")
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"player":  req.PlayerID,
+	})
+}
+
+// Make the player to act check or fold, or void a hand stuck outside betting
+func (h *Handler) HandleAdminForceAdvance(w http.ResponseWriter, r *http.Request) {
+	table, ok := h.adminTable(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := table.Game.ForceAdvance(adminActor(r))
+	if err != nil {
+		apiError(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"table":  table.ID,
+		"actor":  adminActor(r),
+		"result": result,
+	}).Warn("Hand force-advanced by operator")
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"result":  result,
+		"status":  table.Game.GetStatus().String(),
+	})
+}
+
+// Put a table on hold: no new hands, and the hand in progress frozen with
+// its timers stopped until it resumes or the pause runs out. Only the node
+// of the dealer or the player to act can pause the table alone.
+func (h *Handler) HandleAdminPause(w http.ResponseWriter, r *http.Request) {
+	table, ok := h.adminTable(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason,omitempty"`
+	}
+	if r.ContentLength != 0 {
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+	}
+
+	if err := table.Game.Pause(adminActor(r), req.Reason); err != nil {
+		apiError(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	pause, _ := table.Game.PauseStatus()
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"paused":  true,
+		"pause":   pause,
+	})
+}
+
+// Resume a paused table where it left off
+func (h *Handler) HandleAdminResume(w http.ResponseWriter, r *http.Request) {
+	table, ok := h.adminTable(w, r)
+	if !ok {
+		return
+	}
+
+	if err := table.Game.Resume(adminActor(r)); err != nil {
+		apiError(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"paused":  false,
+	})
+}
+
+// Void the hand that froze a table and let play continue
+func (h *Handler) HandleAdminUnfreeze(w http.ResponseWriter, r *http.Request) {
+	table, ok := h.adminTable(w, r)
+	if !ok {
+		return
+	}
+
+	refunds, err := table.Game.Unfreeze(adminActor(r))
+	if err != nil {
+		apiError(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"refunds": refunds,
+	})
+}
+
+// Get the players whose disconnect timers are running
+func (h *Handler) HandleAdminDisconnects(w http.ResponseWriter, r *http.Request) {
+	table, ok := h.adminTable(w, r)
+	if !ok {
+		return
+	}
+
+	JSON(w, http.StatusOK, table.Game.DisconnectHandler.GetStatus())
+}
+
+// Write a snapshot of a table's state to disk
+func (h *Handler) HandleAdminSnapshot(w http.ResponseWriter, r *http.Request) {
+	table, ok := h.adminTable(w, r)
+	if !ok {
+		return
+	}
+
+	snap := table.Game.Snapshot()
+	snap.Metadata["table_id"] = table.ID
+	snap.Metadata["requested_by"] = adminActor(r)
+
+	path, err := persistence.SaveSnapshotWithTimestamp(snap, h.snapshotDir)
+	if err != nil {
+		logrus.Errorf("Failed to save snapshot for table %s: %v", table.ID, err)
+		apiError(w, "Failed to save snapshot", http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"path":    path,
+	})
+}
+
+// Resubmit escrow payouts that failed on-chain
+func (h *Handler) HandleAdminRetrySettlements(w http.ResponseWriter, r *http.Request) {
+	table, ok := h.adminTable(w, r)
+	if !ok {
+		return
+	}
+
+	settled, remaining, err := table.Game.RetrySettlements(adminActor(r))
+	if err != nil {
+		apiError(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"settled":     settled,
+		"remaining":   remaining,
+		"settlements": table.Game.FailedSettlements(),
+	})
+}
+
+// Settle a table's batched hands on-chain now, or as soon as the hand in
+// progress ends
+func (h *Handler) HandleAdminSettleBatch(w http.ResponseWriter, r *http.Request) {
+	table, ok := h.adminTable(w, r)
+	if !ok {
+		return
+	}
+
+	batch, err := table.Game.SettleBatch(adminActor(r))
+	if err != nil {
+		apiError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if batch == nil {
+		apiError(w, "No hands are waiting to be settled", http.StatusConflict)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"batch":       batch,
+		"deferred":    batch.Settling,
+		"settlements": table.Game.FailedSettlements(),
+	})
+}
+
+// Submit a table's state channel on-chain, ending the session's escrow game
+func (h *Handler) HandleAdminCloseChannel(w http.ResponseWriter, r *http.Request) {
+	table, ok := h.adminTable(w, r)
+	if !ok {
+		return
+	}
+
+	status, _ := table.Game.ChannelStatus()
+	if err := table.Game.CloseChannel(adminActor(r)); err != nil {
+		apiError(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"channel":     status,
+		"settlements": table.Game.FailedSettlements(),
+	})
+}
+
+// Get scheduled backup results and the stored backups
+func (h *Handler) HandleAdminBackups(w http.ResponseWriter, r *http.Request) {
+	if h.backups == nil {
+		apiError(w, "Backups are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	backups, err := h.backups.ListBackups()
+	if err != nil {
+		apiError(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"stats":   h.backups.Stats(),
+		"backups": backups,
+	})
+}
+
+func (h *Handler) adminTable(w http.ResponseWriter, r *http.Request) (*lobby.ManagedTable, bool) {
+	if h.tables == nil {
+		apiError(w, "Admin endpoints are not enabled", http.StatusServiceUnavailable)
+		return nil, false
+	}
+
+	table, ok := h.tables.Get(mux.Vars(r)["id"])
+	if !ok {
+		apiError(w, "Table not found", http.StatusNotFound)
+		return nil, false
+	}
+	return table, true
+}
+
+func adminActor(r *http.Request) string {
+	if id := r.Header.Get("X-Client-ID"); id != "" {
+		return id
+	}
+	return "admin"
+}
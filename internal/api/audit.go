@@ -0,0 +1,81 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+)
+
+// Query the audit log: ?table=&action=&actor=&hand=&after=<seq>&limit=.
+// Without a persistent log, this table's in-memory entries are returned.
+func (h *Handler) HandleGetAudit(w http.ResponseWriter, r *http.Request) {
+	if h.auditLog == nil {
+		entries := h.game.AuditLog()
+		JSON(w, http.StatusOK, map[string]interface{}{
+			"persistent": false,
+			"entries":    entries,
+			"count":      len(entries),
+		})
+		return
+	}
+
+	query := persistence.AuditQuery{
+		TableID: r.URL.Query().Get("table"),
+		Action:  r.URL.Query().Get("action"),
+		Actor:   r.URL.Query().Get("actor"),
+		HandUID: r.URL.Query().Get("hand"),
+	}
+	if raw := r.URL.Query().Get("after"); raw != "" {
+		after, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			apiError(w, "Invalid after", http.StatusBadRequest)
+			return
+		}
+		query.AfterSeq = after
+	}
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 0 {
+			apiError(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		query.Limit = limit
+	}
+
+	records, err := h.auditLog.Query(query)
+	if err != nil {
+		apiError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	seq, hash := h.auditLog.Head()
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"persistent": true,
+		"entries":    records,
+		"count":      len(records),
+		"head_seq":   seq,
+		"head_hash":  hash,
+	})
+}
+
+// Re-check the audit log's hash chain from the first record
+func (h *Handler) HandleVerifyAudit(w http.ResponseWriter, r *http.Request) {
+	if h.auditLog == nil {
+		apiError(w, "Audit log is not persisted", http.StatusServiceUnavailable)
+		return
+	}
+
+	count, err := h.auditLog.Verify()
+	seq, hash := h.auditLog.Head()
+	response := map[string]interface{}{
+		"valid":     err == nil,
+		"records":   count,
+		"head_seq":  seq,
+		"head_hash": hash,
+	}
+	if err != nil {
+		response["error"] = err.Error()
+	}
+	JSON(w, http.StatusOK, response)
+}
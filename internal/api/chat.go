@@ -0,0 +1,72 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Get recent table chat for the caller
+func (h *Handler) HandleGetChat(w http.ResponseWriter, r *http.Request) {
+	clientID := r.Header.Get("X-Client-ID")
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"messages": h.game.ChatHistory(clientID),
+		"muted":    h.game.MutedPlayers(clientID),
+	})
+}
+
+// Post a chat message to the table
+func (h *Handler) HandlePostChat(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Text string `json:"text"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.game.PostChat(clientID, req.Text); err != nil {
+		apiError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]string{"status": "sent"})
+}
+
+// Mute another player's chat for the caller
+func (h *Handler) HandleMuteChat(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		PlayerID string `json:"player_id"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.game.MuteChat(clientID, req.PlayerID); err != nil {
+		apiError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]string{"status": "muted"})
+}
+
+// Unmute a player's chat for the caller
+func (h *Handler) HandleUnmuteChat(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	h.game.UnmuteChat(clientID, mux.Vars(r)["id"])
+	JSON(w, http.StatusOK, map[string]string{"status": "unmuted"})
+}
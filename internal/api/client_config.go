@@ -0,0 +1,12 @@
+package api
+
+import "net/http"
+
+// Get what the embedded web client needs to play this node's seat
+func (h *Handler) HandleGetClientConfig(w http.ResponseWriter, r *http.Request) {
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"player_id": h.tableID,
+		"table_id":  h.tableID,
+		"ws_url":    h.publicWSURL + "/ws",
+	})
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/RedPaladin7/peerpoker/internal/deck"
+)
+
+// DefaultEquityIterations is how many runouts are simulated when the caller does not say
+const DefaultEquityIterations = 10000
+
+// Estimate hand equity by simulation. With no hole cards in the query, the
+// caller's own hand and the current board are used.
+func (h *Handler) HandleGetEquity(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	hole, err := deck.ParseCards(query.Get("hole"))
+	if err != nil {
+		apiError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	board, err := deck.ParseCards(query.Get("board"))
+	if err != nil {
+		apiError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opponents := 1
+	if len(hole) == 0 {
+		clientID := r.Header.Get("X-Client-ID")
+		if clientID == "" {
+			apiError(w, "Client ID or hole cards required", http.StatusBadRequest)
+			return
+		}
+		var ok bool
+		hole, board, opponents, ok = h.game.HandCards(clientID)
+		if !ok {
+			apiError(w, "No hand in progress; pass hole cards", http.StatusConflict)
+			return
+		}
+	}
+
+	if v := query.Get("opponents"); v != "" {
+		if opponents, err = strconv.Atoi(v); err != nil {
+			apiError(w, "invalid opponents", http.StatusBadRequest)
+			return
+		}
+	}
+	iterations := DefaultEquityIterations
+	if v := query.Get("iterations"); v != "" {
+		if iterations, err = strconv.Atoi(v); err != nil {
+			apiError(w, "invalid iterations", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Runs without the game lock; HandCards returned copies
+	equity, err := deck.CalculateEquity(hole, board, opponents, iterations)
+	if err != nil {
+		apiError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"hole":      cardStrings(hole),
+		"board":     cardStrings(board),
+		"opponents": opponents,
+		"equity":    equity,
+	})
+}
+
+func cardStrings(cards []deck.Card) []string {
+	names := make([]string, len(cards))
+	for i, card := range cards {
+		names[i] = card.String()
+	}
+	return names
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// Get a player's escrow: funds locked per game and the balance they can
+// withdraw. Amounts are in wei, or in chips from the local ledger when the
+// blockchain is not enabled.
+func (h *Handler) HandleGetEscrow(w http.ResponseWriter, r *http.Request) {
+	player := mux.Vars(r)["player"]
+	if h.blockchain == nil {
+		h.handleGetLedger(w, player)
+		return
+	}
+
+	if !common.IsHexAddress(player) {
+		apiError(w, "Invalid player address", http.StatusBadRequest)
+		return
+	}
+
+	status, err := h.blockchain.EscrowStatus(common.HexToAddress(player), h.escrow)
+	if err != nil {
+		logrus.Errorf("Failed to read escrow for %s: %v", player, err)
+		apiError(w, "Failed to read escrow from the blockchain", http.StatusBadGateway)
+		return
+	}
+
+	locked := make([]map[string]interface{}, len(status.Locked))
+	for i, lock := range status.Locked {
+		locked[i] = map[string]interface{}{
+			"game_id": fmt.Sprintf("0x%x", lock.GameID),
+			"amount":  lock.Amount.String(),
+			"locks":   lock.Locks,
+			"block":   lock.BlockNumber,
+			"tx_hash": lock.TxHash.Hex(),
+		}
+	}
+
+	response := map[string]interface{}{
+		"player":       status.Player.Hex(),
+		"locked":       locked,
+		"total_locked": status.TotalLocked.String(),
+		"available":    status.Available.String(),
+	}
+	if h.escrow != nil {
+		response["synced_block"] = h.escrow.LastBlock()
+	}
+	JSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) handleGetLedger(w http.ResponseWriter, player string) {
+	if h.store == nil {
+		apiError(w, "Neither blockchain integration nor a store is enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	balance, err := persistence.PlayerLedger(h.store, player)
+	if err != nil {
+		logrus.Errorf("Failed to read ledger for %s: %v", player, err)
+		apiError(w, "Failed to read the ledger", http.StatusInternalServerError)
+		return
+	}
+
+	locked := make([]map[string]interface{}, len(balance.Locked))
+	for i, lock := range balance.Locked {
+		locked[i] = map[string]interface{}{
+			"game_id": lock.TableID,
+			"amount":  strconv.Itoa(lock.Amount),
+			"locks":   lock.BuyIns,
+			"at":      lock.At,
+		}
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"player":       balance.PlayerID,
+		"locked":       locked,
+		"total_locked": strconv.Itoa(balance.TotalLocked),
+		"available":    strconv.Itoa(balance.Available),
+		"ledger":       true,
+	})
+}
+
+// Pay out part of a player's available balance in the local ledger
+func (h *Handler) HandleLedgerWithdrawal(w http.ResponseWriter, r *http.Request) {
+	if h.blockchain != nil {
+		apiError(w, "Withdrawals are made from the escrow contract", http.StatusConflict)
+		return
+	}
+	if h.store == nil {
+		apiError(w, "Store is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		PlayerID string `json:"player_id"`
+		Amount   int    `json:"amount"`
+		Note     string `json:"note"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.PlayerID == "" {
+		apiError(w, "player_id is required", http.StatusBadRequest)
+		return
+	}
+	if !validAmount(w, "amount", req.Amount) {
+		return
+	}
+
+	balance, err := persistence.WithdrawFromLedger(h.store, req.PlayerID, req.Amount, adminActor(r), req.Note)
+	if err != nil {
+		apiError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"player": req.PlayerID,
+		"amount": req.Amount,
+		"actor":  adminActor(r),
+	}).Warn("Ledger withdrawal paid by operator")
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"success":   true,
+		"player":    req.PlayerID,
+		"amount":    req.Amount,
+		"available": balance.Available,
+	})
+}
+
+// Get the session's state channel: the last stacks every player signed and
+// the state still collecting signatures
+func (h *Handler) HandleGetChannel(w http.ResponseWriter, r *http.Request) {
+	status, open := h.game.ChannelStatus()
+	if !open {
+		JSON(w, http.StatusOK, map[string]interface{}{
+			"enabled": h.game.TableConfig().StateChannel,
+			"open":    false,
+		})
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"enabled": true,
+		"open":    true,
+		"channel": status,
+	})
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// sseKeepAlive keeps idle proxies from closing the stream
+const sseKeepAlive = 15 * time.Second
+
+// Stream public table events as Server-Sent Events
+func (h *Handler) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	// The stream outlives the server's write timeout
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logrus.Debugf("Could not clear write deadline for event stream: %v", err)
+	}
+
+	events, unsubscribe := h.hub.SubscribeEvents()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	if err := rc.Flush(); err != nil {
+		apiError(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Start with the current table so followers don't wait for the next change
+	if initial, err := protocol.NewEvent(protocol.EventGameStateUpdate, h.game.PublicState()); err == nil {
+		if data, err := json.Marshal(initial); err == nil {
+			writeSSE(w, data)
+			rc.Flush()
+		}
+	}
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case data, ok := <-events:
+			if !ok {
+				return
+			}
+			writeSSE(w, data)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeSSE writes one event, named by its protocol type
+func writeSSE(w http.ResponseWriter, data []byte) {
+	if eventType := protocol.PeekType(data); eventType != "" {
+		fmt.Fprintf(w, "event: %s\n", eventType)
+	}
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
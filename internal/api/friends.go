@@ -0,0 +1,224 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/RedPaladin7/peerpoker/internal/friends"
+	"github.com/RedPaladin7/peerpoker/internal/presence"
+	"github.com/gorilla/mux"
+)
+
+// FriendResponse is a friend with their current presence
+type FriendResponse struct {
+	PlayerID string             `json:"player_id"`
+	Presence *presence.Presence `json:"presence,omitempty"`
+}
+
+// InvitationResponse is an invitation with the inviter's current presence
+type InvitationResponse struct {
+	friends.Invitation
+	FromPresence *presence.Presence `json:"from_presence,omitempty"`
+}
+
+// friendsClient checks the service is enabled and returns the calling player
+func (h *Handler) friendsClient(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if h.friends == nil {
+		apiError(w, "Friends are not enabled", http.StatusServiceUnavailable)
+		return "", false
+	}
+
+	return requireClientID(w, r)
+}
+
+func (h *Handler) presenceOf(playerID string) *presence.Presence {
+	if h.presence == nil {
+		return nil
+	}
+	p := h.presence.Get(playerID)
+	return &p
+}
+
+// Get friends, pending requests and privacy settings
+func (h *Handler) HandleGetFriends(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := h.friendsClient(w, r)
+	if !ok {
+		return
+	}
+
+	list := h.friends.Friends(clientID)
+	response := make([]FriendResponse, 0, len(list))
+	for _, friend := range list {
+		response = append(response, FriendResponse{
+			PlayerID: friend,
+			Presence: h.presenceOf(friend),
+		})
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"friends":       response,
+		"requests":      h.friends.PendingRequests(clientID),
+		"invite_policy": h.friends.InvitePolicy(clientID),
+	})
+}
+
+// Send a friend request
+func (h *Handler) HandleSendFriendRequest(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := h.friendsClient(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		PlayerID string `json:"player_id"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.friends.SendRequest(clientID, req.PlayerID); err != nil {
+		apiError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"status":  "requested",
+		"friends": h.friends.AreFriends(clientID, req.PlayerID),
+	})
+}
+
+// Accept a friend request
+func (h *Handler) HandleAcceptFriendRequest(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := h.friendsClient(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.friends.Accept(clientID, mux.Vars(r)["id"]); err != nil {
+		apiError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]string{"status": "accepted"})
+}
+
+// Decline a friend request
+func (h *Handler) HandleDeclineFriendRequest(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := h.friendsClient(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.friends.Decline(clientID, mux.Vars(r)["id"]); err != nil {
+		apiError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]string{"status": "declined"})
+}
+
+// Remove a friend
+func (h *Handler) HandleRemoveFriend(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := h.friendsClient(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.friends.Remove(clientID, mux.Vars(r)["id"]); err != nil {
+		apiError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]string{"status": "removed"})
+}
+
+// Change who may send the caller table invitations
+func (h *Handler) HandleSetInvitePolicy(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := h.friendsClient(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		InvitePolicy string `json:"invite_policy"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	policy, err := friends.ParseInvitePolicy(req.InvitePolicy)
+	if err != nil {
+		apiError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.friends.SetInvitePolicy(clientID, policy); err != nil {
+		apiError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]string{"invite_policy": string(policy)})
+}
+
+// Get pending table invitations for the caller
+func (h *Handler) HandleGetInvitations(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := h.friendsClient(w, r)
+	if !ok {
+		return
+	}
+
+	invitations := h.friends.Invitations(clientID)
+	response := make([]InvitationResponse, 0, len(invitations))
+	for _, inv := range invitations {
+		response = append(response, InvitationResponse{
+			Invitation:   inv,
+			FromPresence: h.presenceOf(inv.From),
+		})
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"invitations": response,
+		"count":       len(response),
+	})
+}
+
+// Invite a player to this table
+func (h *Handler) HandleInvite(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := h.friendsClient(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		PlayerID string `json:"player_id"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	inv, err := h.friends.Invite(clientID, req.PlayerID, h.tableID, h.publicWSURL+"/ws")
+	if err != nil {
+		apiError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	JSON(w, http.StatusCreated, map[string]interface{}{
+		"invitation":  inv,
+		"to_presence": h.presenceOf(req.PlayerID),
+	})
+}
+
+// Accept or decline an invitation; either way it is removed
+func (h *Handler) HandleDismissInvitation(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := h.friendsClient(w, r)
+	if !ok {
+		return
+	}
+
+	inv, err := h.friends.DismissInvitation(clientID, mux.Vars(r)["id"])
+	if err != nil {
+		apiError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	JSON(w, http.StatusOK, inv)
+}
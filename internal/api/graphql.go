@@ -0,0 +1,231 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/sirupsen/logrus"
+)
+
+// graphQLSchema exposes the same tables, stored hands and settlements as
+// the REST API. Hole cards and key material are never part of it.
+const graphQLSchema = `
+schema {
+	query: Query
+	subscription: Subscription
+}
+
+type Query {
+	tables: [Table!]!
+	table(id: ID!): Table
+	player(id: ID!): Player
+	hand(tableId: ID!, id: Int!): Hand
+	settlements(tableId: ID!, handId: Int!): [Settlement!]!
+}
+
+type Subscription {
+	# The node's public table events, optionally only the given types
+	events(types: [String!]): Event!
+}
+
+type Table {
+	id: ID!
+	name: String!
+	variant: String!
+	smallBlind: Int!
+	bigBlind: Int!
+	ante: Int!
+	maxSeats: Int!
+	seated: Int!
+	waitlist: Int!
+	status: String!
+	pot: Int!
+	highestBet: Int!
+	currentTurn: String
+	board: [String!]!
+	players: [Seat!]!
+	# This session's hands, newest first
+	hands(limit: Int = 20): [Hand!]!
+}
+
+type Seat {
+	playerId: ID!
+	seat: Int!
+	stack: Int!
+	currentBet: Int!
+	active: Boolean!
+	folded: Boolean!
+	allIn: Boolean!
+	dealer: Boolean!
+	currentTurn: Boolean!
+	player: Player
+}
+
+type Player {
+	id: ID!
+	stack: Int!
+	handsPlayed: Int!
+	lastSeen: String!
+	# Stored hands the player was dealt into, across tables, newest first
+	hands(limit: Int = 20): [Hand!]!
+}
+
+type Hand {
+	tableId: ID!
+	id: Int!
+	uid: String!
+	startedAt: String!
+	endedAt: String
+	dealer: String!
+	pot: Int!
+	players: [String!]!
+	winnings: [Winning!]!
+	voided: Boolean!
+	voidReason: String
+	actions: [Action!]!
+	settlements: [Settlement!]!
+}
+
+type Winning {
+	playerId: ID!
+	amount: Int!
+}
+
+type Action {
+	seq: Int!
+	playerId: ID!
+	action: String!
+	amount: Int!
+	street: String!
+}
+
+type Settlement {
+	tableId: ID!
+	handId: Int!
+	kind: String!
+	gameId: String!
+	winners: [String!]!
+	amounts: [Int!]!
+	txHash: String
+	error: String
+	at: String!
+}
+
+type Event {
+	type: String!
+	timestamp: String!
+	handUid: String
+	seq: Int
+	# The event's payload as JSON
+	data: String!
+}
+`
+
+// graphQLMaxDepth stops deeply nested queries (table -> seat -> player ->
+// hands -> ...) from fanning out into thousands of store reads
+const graphQLMaxDepth = 8
+
+// graphQLRequest is a query over HTTP, as POST JSON or GET parameters
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// schema parses the GraphQL schema against its resolvers once
+func (h *Handler) schema() (*graphql.Schema, error) {
+	h.graphQLOnce.Do(func() {
+		h.graphQL, h.graphQLErr = graphql.ParseSchema(graphQLSchema, &graphQLResolver{h: h},
+			graphql.MaxDepth(graphQLMaxDepth))
+	})
+	return h.graphQL, h.graphQLErr
+}
+
+// Run a GraphQL query; subscriptions stream as server-sent events when the
+// request accepts text/event-stream
+func (h *Handler) HandleGraphQL(w http.ResponseWriter, r *http.Request) {
+	schema, err := h.schema()
+	if err != nil {
+		logrus.Errorf("GraphQL schema is invalid: %v", err)
+		apiError(w, "GraphQL is unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	var req graphQLRequest
+	if r.Method == http.MethodGet {
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if vars := r.URL.Query().Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				WriteErrorCode(w, http.StatusBadRequest, ErrCodeInvalidJSON, "Invalid variables")
+				return
+			}
+		}
+	} else if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Query == "" {
+		apiError(w, "Missing query", http.StatusBadRequest)
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		h.streamGraphQL(w, r, schema, req)
+		return
+	}
+
+	JSON(w, http.StatusOK, schema.Exec(r.Context(), req.Query, req.OperationName, req.Variables))
+}
+
+// streamGraphQL sends each subscription result as an SSE "next" event and
+// a final "complete" when the subscription ends
+func (h *Handler) streamGraphQL(w http.ResponseWriter, r *http.Request, schema *graphql.Schema, req graphQLRequest) {
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logrus.Debugf("Could not clear write deadline for GraphQL stream: %v", err)
+	}
+
+	results, err := schema.Subscribe(r.Context(), req.Query, req.OperationName, req.Variables)
+	if err != nil {
+		apiError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	if err := rc.Flush(); err != nil {
+		apiError(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case result, ok := <-results:
+			if !ok {
+				fmt.Fprint(w, "event: complete\ndata:\n\n")
+				rc.Flush()
+				return
+			}
+			data, err := json.Marshal(result)
+			if err != nil {
+				logrus.Warnf("Failed to encode GraphQL result: %v", err)
+				continue
+			}
+			fmt.Fprintf(w, "event: next\ndata: %s\n\n", data)
+			rc.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			rc.Flush()
+		}
+	}
+}
@@ -0,0 +1,404 @@
+package api
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/game"
+	"github.com/RedPaladin7/peerpoker/internal/lobby"
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// graphQLResolver resolves Query and Subscription fields from the
+// handler's TableManager, Store and hub
+type graphQLResolver struct {
+	h *Handler
+}
+
+func (r *graphQLResolver) Tables() []*tableResolver {
+	if r.h.tables == nil {
+		return []*tableResolver{}
+	}
+	tables := r.h.tables.Tables()
+	resolvers := make([]*tableResolver, len(tables))
+	for i, t := range tables {
+		resolvers[i] = &tableResolver{h: r.h, table: t}
+	}
+	return resolvers
+}
+
+func (r *graphQLResolver) Table(args struct{ ID graphql.ID }) *tableResolver {
+	if r.h.tables == nil {
+		return nil
+	}
+	t, ok := r.h.tables.Get(string(args.ID))
+	if !ok {
+		return nil
+	}
+	return &tableResolver{h: r.h, table: t}
+}
+
+func (r *graphQLResolver) Player(args struct{ ID graphql.ID }) (*playerResolver, error) {
+	return r.h.graphQLPlayer(string(args.ID))
+}
+
+func (r *graphQLResolver) Hand(args struct {
+	TableID graphql.ID
+	ID      int32
+}) (*handResolver, error) {
+	tableID, id := string(args.TableID), int(args.ID)
+	if r.h.store != nil {
+		hand, ok, err := r.h.store.GetHand(tableID, id)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return &handResolver{h: r.h, record: hand}, nil
+		}
+	}
+
+	// Without a store, or for a hand not yet written, fall back to the
+	// table's in-memory history
+	if r.h.tables == nil {
+		return nil, nil
+	}
+	t, ok := r.h.tables.Get(tableID)
+	if !ok {
+		return nil, nil
+	}
+	history, ok := t.Game.GetHandHistory(id)
+	if !ok {
+		return nil, nil
+	}
+	return historyResolver(r.h, tableID, history), nil
+}
+
+func (r *graphQLResolver) Settlements(args struct {
+	TableID graphql.ID
+	HandID  int32
+}) ([]*settlementResolver, error) {
+	return r.h.graphQLSettlements(string(args.TableID), int(args.HandID))
+}
+
+// Events streams the hub's public events until the subscriber goes away
+func (r *graphQLResolver) Events(ctx context.Context, args struct{ Types *[]string }) (<-chan *eventResolver, error) {
+	wanted := map[string]bool{}
+	if args.Types != nil {
+		for _, t := range *args.Types {
+			wanted[t] = true
+		}
+	}
+
+	raw, unsubscribe := r.h.hub.SubscribeEvents()
+	out := make(chan *eventResolver)
+	go func() {
+		defer unsubscribe()
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case data, ok := <-raw:
+				if !ok {
+					return
+				}
+				event, err := protocol.DecodeEvent(data)
+				if err != nil {
+					continue
+				}
+				if len(wanted) > 0 && !wanted[string(event.Type)] {
+					continue
+				}
+				select {
+				case out <- &eventResolver{event: *event}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (h *Handler) graphQLPlayer(playerID string) (*playerResolver, error) {
+	if h.store == nil {
+		return nil, nil
+	}
+	player, ok, err := h.store.GetPlayer(playerID)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &playerResolver{h: h, record: player}, nil
+}
+
+func (h *Handler) graphQLSettlements(tableID string, handID int) ([]*settlementResolver, error) {
+	if h.store == nil {
+		return []*settlementResolver{}, nil
+	}
+	settlements, err := h.store.Settlements(tableID, handID)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*settlementResolver, len(settlements))
+	for i, s := range settlements {
+		resolvers[i] = &settlementResolver{record: s}
+	}
+	return resolvers, nil
+}
+
+func limitArg(limit *int32, def int) int {
+	if limit == nil || *limit <= 0 {
+		return def
+	}
+	return int(*limit)
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+type tableResolver struct {
+	h     *Handler
+	table *lobby.ManagedTable
+}
+
+func (t *tableResolver) ID() graphql.ID    { return graphql.ID(t.table.ID) }
+func (t *tableResolver) Name() string      { return t.table.Settings.Name }
+func (t *tableResolver) Variant() string   { return t.table.Settings.Variant }
+func (t *tableResolver) SmallBlind() int32 { return int32(t.table.Settings.SmallBlind) }
+func (t *tableResolver) BigBlind() int32   { return int32(t.table.Settings.BigBlind) }
+func (t *tableResolver) Ante() int32       { return int32(t.table.Settings.Ante) }
+func (t *tableResolver) MaxSeats() int32   { return int32(t.table.Settings.MaxSeats) }
+func (t *tableResolver) Seated() int32     { return int32(t.table.Info().Seated) }
+func (t *tableResolver) Waitlist() int32   { return int32(t.table.Info().Waitlist) }
+func (t *tableResolver) Status() string    { return t.table.Info().Status }
+func (t *tableResolver) Pot() int32        { return int32(t.table.Game.PublicState().Pot) }
+func (t *tableResolver) HighestBet() int32 { return int32(t.table.Game.PublicState().HighestBet) }
+
+func (t *tableResolver) CurrentTurn() *string {
+	return optionalString(t.table.Game.PublicState().CurrentTurn)
+}
+
+func (t *tableResolver) Board() []string {
+	cards := t.table.Game.PublicState().CommunityCards
+	board := make([]string, len(cards))
+	for i, c := range cards {
+		board[i] = c.Display
+	}
+	return board
+}
+
+func (t *tableResolver) Players() []*seatResolver {
+	players := t.table.Game.PublicState().Players
+	seats := make([]*seatResolver, len(players))
+	for i, p := range players {
+		seats[i] = &seatResolver{h: t.h, player: p}
+	}
+	return seats
+}
+
+func (t *tableResolver) Hands(args struct{ Limit *int32 }) []*handResolver {
+	histories := t.table.Game.HandHistories()
+	if limit := limitArg(args.Limit, 20); len(histories) > limit {
+		histories = histories[:limit]
+	}
+	hands := make([]*handResolver, len(histories))
+	for i, history := range histories {
+		hands[i] = historyResolver(t.h, t.table.ID, history)
+	}
+	return hands
+}
+
+type seatResolver struct {
+	h      *Handler
+	player protocol.PlayerData
+}
+
+func (s *seatResolver) PlayerID() graphql.ID { return graphql.ID(s.player.PlayerID) }
+func (s *seatResolver) Seat() int32          { return int32(s.player.Seat) }
+func (s *seatResolver) Stack() int32         { return int32(s.player.Stack) }
+func (s *seatResolver) CurrentBet() int32    { return int32(s.player.CurrentBet) }
+func (s *seatResolver) Active() bool         { return s.player.IsActive }
+func (s *seatResolver) Folded() bool         { return s.player.IsFolded }
+func (s *seatResolver) AllIn() bool          { return s.player.IsAllIn }
+func (s *seatResolver) Dealer() bool         { return s.player.IsDealer }
+func (s *seatResolver) CurrentTurn() bool    { return s.player.IsCurrentTurn }
+
+func (s *seatResolver) Player() (*playerResolver, error) {
+	return s.h.graphQLPlayer(s.player.PlayerID)
+}
+
+type playerResolver struct {
+	h      *Handler
+	record persistence.PlayerRecord
+}
+
+func (p *playerResolver) ID() graphql.ID     { return graphql.ID(p.record.PlayerID) }
+func (p *playerResolver) Stack() int32       { return int32(p.record.Stack) }
+func (p *playerResolver) HandsPlayed() int32 { return int32(p.record.HandsPlayed) }
+func (p *playerResolver) LastSeen() string   { return p.record.LastSeen.Format(time.RFC3339) }
+
+func (p *playerResolver) Hands(args struct{ Limit *int32 }) ([]*handResolver, error) {
+	hands, err := p.h.store.HandsForPlayer(p.record.PlayerID, limitArg(args.Limit, 20))
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*handResolver, len(hands))
+	for i, hand := range hands {
+		resolvers[i] = &handResolver{h: p.h, record: hand}
+	}
+	return resolvers, nil
+}
+
+// handResolver serves a stored hand, or one from a table's in-memory
+// history, in which case history carries its actions
+type handResolver struct {
+	h       *Handler
+	record  persistence.HandRecord
+	history *game.HandHistory
+}
+
+func historyResolver(h *Handler, tableID string, history game.HandHistory) *handResolver {
+	return &handResolver{
+		h: h,
+		record: persistence.HandRecord{
+			TableID:    tableID,
+			HandID:     history.ID,
+			HandUID:    history.UID,
+			StartedAt:  history.StartedAt,
+			EndedAt:    history.EndedAt,
+			Dealer:     history.Dealer,
+			Pot:        history.Pot,
+			Players:    history.Seats,
+			Winnings:   history.Winnings,
+			Voided:     history.Voided,
+			VoidReason: history.VoidReason,
+		},
+		history: &history,
+	}
+}
+
+func (h *handResolver) TableID() graphql.ID { return graphql.ID(h.record.TableID) }
+func (h *handResolver) ID() int32           { return int32(h.record.HandID) }
+func (h *handResolver) UID() string         { return h.record.HandUID }
+func (h *handResolver) StartedAt() string   { return h.record.StartedAt.Format(time.RFC3339) }
+func (h *handResolver) Dealer() string      { return h.record.Dealer }
+func (h *handResolver) Pot() int32          { return int32(h.record.Pot) }
+func (h *handResolver) Players() []string   { return h.record.Players }
+func (h *handResolver) Voided() bool        { return h.record.Voided }
+func (h *handResolver) VoidReason() *string { return optionalString(h.record.VoidReason) }
+
+func (h *handResolver) EndedAt() *string {
+	if h.record.EndedAt.IsZero() {
+		return nil
+	}
+	ended := h.record.EndedAt.Format(time.RFC3339)
+	return &ended
+}
+
+func (h *handResolver) Winnings() []*winningResolver {
+	players := make([]string, 0, len(h.record.Winnings))
+	for player := range h.record.Winnings {
+		players = append(players, player)
+	}
+	sort.Strings(players)
+
+	winnings := make([]*winningResolver, len(players))
+	for i, player := range players {
+		winnings[i] = &winningResolver{playerID: player, amount: h.record.Winnings[player]}
+	}
+	return winnings
+}
+
+func (h *handResolver) Actions() ([]*actionResolver, error) {
+	if h.history != nil {
+		actions := make([]*actionResolver, len(h.history.Actions))
+		for i, a := range h.history.Actions {
+			actions[i] = &actionResolver{seq: a.Seq, playerID: a.PlayerID, action: a.Action, amount: a.Amount, street: a.Street}
+		}
+		return actions, nil
+	}
+	if h.h.store == nil {
+		return []*actionResolver{}, nil
+	}
+
+	records, err := h.h.store.HandActions(h.record.TableID, h.record.HandID)
+	if err != nil {
+		return nil, err
+	}
+	actions := make([]*actionResolver, len(records))
+	for i, a := range records {
+		actions[i] = &actionResolver{seq: a.Seq, playerID: a.PlayerID, action: a.Action, amount: a.Amount, street: a.Street}
+	}
+	return actions, nil
+}
+
+func (h *handResolver) Settlements() ([]*settlementResolver, error) {
+	return h.h.graphQLSettlements(h.record.TableID, h.record.HandID)
+}
+
+type winningResolver struct {
+	playerID string
+	amount   int
+}
+
+func (w *winningResolver) PlayerID() graphql.ID { return graphql.ID(w.playerID) }
+func (w *winningResolver) Amount() int32        { return int32(w.amount) }
+
+type actionResolver struct {
+	seq      int
+	playerID string
+	action   string
+	amount   int
+	street   string
+}
+
+func (a *actionResolver) Seq() int32           { return int32(a.seq) }
+func (a *actionResolver) PlayerID() graphql.ID { return graphql.ID(a.playerID) }
+func (a *actionResolver) Action() string       { return a.action }
+func (a *actionResolver) Amount() int32        { return int32(a.amount) }
+func (a *actionResolver) Street() string       { return a.street }
+
+type settlementResolver struct {
+	record persistence.SettlementRecord
+}
+
+func (s *settlementResolver) TableID() graphql.ID { return graphql.ID(s.record.TableID) }
+func (s *settlementResolver) HandID() int32       { return int32(s.record.HandID) }
+func (s *settlementResolver) Kind() string        { return s.record.Kind }
+func (s *settlementResolver) GameID() string      { return s.record.GameID }
+func (s *settlementResolver) Winners() []string   { return s.record.Winners }
+func (s *settlementResolver) TxHash() *string     { return optionalString(s.record.TxHash) }
+func (s *settlementResolver) Error() *string      { return optionalString(s.record.Error) }
+func (s *settlementResolver) At() string          { return s.record.At.Format(time.RFC3339) }
+
+func (s *settlementResolver) Amounts() []int32 {
+	amounts := make([]int32, len(s.record.Amounts))
+	for i, a := range s.record.Amounts {
+		amounts[i] = int32(a)
+	}
+	return amounts
+}
+
+type eventResolver struct {
+	event protocol.Event
+}
+
+func (e *eventResolver) Type() string      { return string(e.event.Type) }
+func (e *eventResolver) Timestamp() string { return e.event.Timestamp.Format(time.RFC3339) }
+func (e *eventResolver) HandUID() *string  { return optionalString(e.event.HandUID) }
+func (e *eventResolver) Data() string      { return string(e.event.Data) }
+
+func (e *eventResolver) Seq() *int32 {
+	if e.event.Seq == 0 {
+		return nil
+	}
+	seq := int32(e.event.Seq)
+	return &seq
+}
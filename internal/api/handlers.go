@@ -3,8 +3,18 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"sync"
+	"time"
 
+	"github.com/RedPaladin7/peerpoker/internal/blockchain"
+	"github.com/RedPaladin7/peerpoker/internal/detection"
+	"github.com/RedPaladin7/peerpoker/internal/friends"
 	"github.com/RedPaladin7/peerpoker/internal/game"
+	"github.com/RedPaladin7/peerpoker/internal/insurance"
+	"github.com/RedPaladin7/peerpoker/internal/lobby"
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+	"github.com/RedPaladin7/peerpoker/internal/presence"
+	graphql "github.com/graph-gophers/graphql-go"
 	"github.com/sirupsen/logrus"
 )
 
@@ -12,6 +22,38 @@ type Handler struct {
 	game        *game.Game
 	peerManager PeerManager
 	hub         Hub
+	presence    *presence.Service
+	friends     *friends.Service
+	lobby       *lobby.Lobby
+	tables      *lobby.TableManager
+	snapshotDir string
+	adminToken  string
+	pprof       bool
+	insurance   *insurance.Fund
+	store       persistence.Store
+	backups     *persistence.BackupManager
+	auditLog    *persistence.AuditLog
+	collusion   *detection.CollusionMonitor
+	blockchain  *blockchain.BlockchainClient
+	escrow      *blockchain.FundsLockedCache
+	chainHealth *blockchain.HealthMonitor
+	bootstrap   Bootstrapper
+	reloader    ConfigReloader
+	actionLimit *RateLimiter
+	readLimit   *RateLimiter
+	tableID     string
+	publicWSURL string
+	version     string
+
+	// Connected peers /readyz requires
+	readyMinPeers int
+
+	openAPIOnce sync.Once
+	openAPI     map[string]interface{}
+
+	graphQLOnce sync.Once
+	graphQL     *graphql.Schema
+	graphQLErr  error
 }
 
 type PeerManager interface {
@@ -23,6 +65,66 @@ type Hub interface {
 	ClientCount() int
 	GetClientIDs() []string
 	Broadcast(data []byte, targets ...string)
+	SubscribeEvents() (<-chan []byte, func())
+	SubscriberCount() int
+	SpectatorCount() int
+	ClientQueues() []ClientQueue
+	PeerLinks() []PeerLink
+	Running() bool
+}
+
+// ClientQueue is a WebSocket connection's outbound queue: how full it is,
+// and how many messages were queued, dropped to a full buffer, or replaced
+// by a newer state update
+type ClientQueue struct {
+	ClientID       string     `json:"client_id"`
+	Peer           bool       `json:"peer,omitempty"`
+	Spectator      bool       `json:"spectator,omitempty"`
+	Depth          int        `json:"depth"`
+	Capacity       int        `json:"capacity"`
+	HighWater      int        `json:"high_water"`
+	Enqueued       uint64     `json:"enqueued"`
+	Dropped        uint64     `json:"dropped"`
+	Coalesced      uint64     `json:"coalesced"`
+	SaturatedSince *time.Time `json:"saturated_since,omitempty"`
+}
+
+// PeerLink is a connected peer's latency from application-level pings.
+// RTTMs is 0 until the peer answers its first ping.
+type PeerLink struct {
+	PeerID    string    `json:"peer_id"`
+	RTTMs     float64   `json:"rtt_ms"`
+	LastSeen  time.Time `json:"last_seen"`
+	PingsSent uint64    `json:"pings_sent"`
+	Pongs     uint64    `json:"pongs"`
+}
+
+// BootstrapPeer is a configured bootstrap node: whether we are connected
+// to it, and when we dial it next if not
+type BootstrapPeer struct {
+	Addr           string     `json:"addr"`
+	Connected      bool       `json:"connected"`
+	ConnectedSince *time.Time `json:"connected_since,omitempty"`
+	Attempts       int        `json:"attempts"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextAttempt    *time.Time `json:"next_attempt,omitempty"`
+}
+
+// Bootstrapper reports on the bootstrap peers the node keeps connected to
+type Bootstrapper interface {
+	BootstrapPeers() []BootstrapPeer
+}
+
+// ConfigReload reports the settings a reload changed, and those that
+// differ from the running config but need a restart
+type ConfigReload struct {
+	Applied []string `json:"applied"`
+	Restart []string `json:"restart_required"`
+}
+
+// ConfigReloader reloads the node's configuration
+type ConfigReloader interface {
+	ReloadConfig(actor string) (ConfigReload, error)
 }
 
 func NewHandler(g *game.Game, pm PeerManager, hub Hub) *Handler {
@@ -33,7 +135,111 @@ func NewHandler(g *game.Game, pm PeerManager, hub Hub) *Handler {
 	}
 }
 
-// Health check endpoint
+// SetPresence enables the presence endpoints
+func (h *Handler) SetPresence(svc *presence.Service) {
+	h.presence = svc
+}
+
+// SetTable sets the table this node serves and its public WebSocket base URL
+func (h *Handler) SetTable(tableID, publicWSURL string) {
+	h.tableID = tableID
+	h.publicWSURL = publicWSURL
+}
+
+// SetVersion sets the node version reported in the OpenAPI document
+func (h *Handler) SetVersion(version string) {
+	h.version = version
+}
+
+// SetFriends enables friends and table invitations
+func (h *Handler) SetFriends(svc *friends.Service) {
+	h.friends = svc
+}
+
+// SetLobby enables table listing and quick-seat
+func (h *Handler) SetLobby(l *lobby.Lobby) {
+	h.lobby = l
+}
+
+// SetAdmin enables the table inspection endpoints used by cmd/admin
+func (h *Handler) SetAdmin(tables *lobby.TableManager, snapshotDir string) {
+	h.tables = tables
+	h.snapshotDir = snapshotDir
+}
+
+// SetAdminToken sets the bearer token operator endpoints require; without
+// one they only answer local requests
+func (h *Handler) SetAdminToken(token string) {
+	h.adminToken = token
+}
+
+// SetInsurance enables the insurance fund endpoints
+func (h *Handler) SetInsurance(fund *insurance.Fund) {
+	h.insurance = fund
+}
+
+// SetBackups enables the backup status endpoint
+func (h *Handler) SetBackups(backups *persistence.BackupManager) {
+	h.backups = backups
+}
+
+// SetRateLimits limits state-changing requests and reads separately, per
+// IP and per client. Calling it again changes the limits in place.
+func (h *Handler) SetRateLimits(actions, reads RateLimit) {
+	if h.actionLimit != nil && h.readLimit != nil {
+		h.actionLimit.SetLimit(actions)
+		h.readLimit.SetLimit(reads)
+		return
+	}
+	h.actionLimit = NewRateLimiter(actions)
+	h.readLimit = NewRateLimiter(reads)
+}
+
+// SetAuditLog serves the persistent audit log on /api/audit
+func (h *Handler) SetAuditLog(log *persistence.AuditLog) {
+	h.auditLog = log
+}
+
+// SetCollusionMonitor serves collusion alerts on the admin API
+func (h *Handler) SetCollusionMonitor(monitor *detection.CollusionMonitor) {
+	h.collusion = monitor
+}
+
+// SetEscrow enables the escrow endpoints, reading the PotManager through bc
+// and the games each player has locked funds in from cache
+func (h *Handler) SetEscrow(bc *blockchain.BlockchainClient, cache *blockchain.FundsLockedCache) {
+	h.blockchain = bc
+	h.escrow = cache
+}
+
+// SetBootstrap reports bootstrap peers with the peer list
+func (h *Handler) SetBootstrap(b Bootstrapper) {
+	h.bootstrap = b
+}
+
+// SetConfigReloader enables reloading the configuration through the admin API
+func (h *Handler) SetConfigReloader(reloader ConfigReloader) {
+	h.reloader = reloader
+}
+
+// SetChainHealth reports the chain circuit breaker in the health check
+func (h *Handler) SetChainHealth(monitor *blockchain.HealthMonitor) {
+	h.chainHealth = monitor
+}
+
+// SetReadyMinPeers sets how many peers must be connected for /readyz to
+// report the node ready
+func (h *Handler) SetReadyMinPeers(n int) {
+	h.readyMinPeers = n
+}
+
+// SetStore enables queries over stored hands and actions
+func (h *Handler) SetStore(store persistence.Store) {
+	h.store = store
+}
+
+// Node summary: game, connections and chain circuit breaker. Orchestrators
+// should probe /healthz and /readyz instead.
 func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
 	response := map[string]interface{}{
 		"status":      "healthy",
@@ -41,15 +247,23 @@ func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
 		"players":     h.game.PlayerCount(),
 		"peers":       h.peerManager.PeerCount(),
 		"ws_clients":  h.hub.ClientCount(),
+		"subscribers": h.hub.SubscriberCount(),
+		"spectators":  h.hub.SpectatorCount(),
+	}
+	if h.chainHealth != nil {
+		health := h.chainHealth.Health()
+		response["blockchain"] = health
+		if health.Tripped {
+			response["status"] = "degraded"
+		}
 	}
 	JSON(w, http.StatusOK, response)
 }
 
 // Get table state for a specific client
 func (h *Handler) HandleGetTable(w http.ResponseWriter, r *http.Request) {
-	clientID := r.Header.Get("X-Client-ID")
-	if clientID == "" {
-		http.Error(w, "Client ID required", http.StatusBadRequest)
+	clientID, ok := requireClientID(w, r)
+	if !ok {
 		return
 	}
 
@@ -70,9 +284,8 @@ func (h *Handler) HandleGetPlayers(w http.ResponseWriter, r *http.Request) {
 
 // Handle player action (fold, check, call, bet, raise)
 func (h *Handler) HandlePlayerAction(w http.ResponseWriter, r *http.Request) {
-	clientID := r.Header.Get("X-Client-ID")
-	if clientID == "" {
-		http.Error(w, "Client ID required", http.StatusBadRequest)
+	clientID, ok := requireClientID(w, r)
+	if !ok {
 		return
 	}
 
@@ -81,29 +294,45 @@ func (h *Handler) HandlePlayerAction(w http.ResponseWriter, r *http.Request) {
 		Value  int    `json:"value,omitempty"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	if err := h.game.HandlePlayerAction(clientID, req.Action, req.Value); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !validAmount(w, "value", req.Value) {
+		return
+	}
+
+	if !h.submitAction(w, r, clientID, req.Action, req.Value) {
 		return
 	}
 
 	JSON(w, http.StatusOK, map[string]string{"status": "success"})
 }
 
+// submitAction applies an action for the caller, at most once per
+// Idempotency-Key in a hand. A repeated key answers as the original request
+// did, with an Idempotent-Replayed header.
+func (h *Handler) submitAction(w http.ResponseWriter, r *http.Request, clientID, action string, value int) bool {
+	replayed, err := h.game.HandlePlayerActionWithKey(clientID, r.Header.Get("Idempotency-Key"), action, value)
+	if err != nil {
+		WriteErrorCode(w, http.StatusBadRequest, ErrCodeActionRejected, err.Error())
+		return false
+	}
+	if replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
+	return true
+}
+
 // Set player ready
 func (h *Handler) HandlePlayerReady(w http.ResponseWriter, r *http.Request) {
-	clientID := r.Header.Get("X-Client-ID")
-	if clientID == "" {
-		http.Error(w, "Client ID required", http.StatusBadRequest)
+	clientID, ok := requireClientID(w, r)
+	if !ok {
 		return
 	}
 
 	if err := h.game.SetPlayerReady(clientID); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apiError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -115,14 +344,12 @@ func (h *Handler) HandlePlayerReady(w http.ResponseWriter, r *http.Request) {
 
 // Handle fold action
 func (h *Handler) HandleFold(w http.ResponseWriter, r *http.Request) {
-	clientID := r.Header.Get("X-Client-ID")
-	if clientID == "" {
-		http.Error(w, "Client ID required", http.StatusBadRequest)
+	clientID, ok := requireClientID(w, r)
+	if !ok {
 		return
 	}
 
-	if err := h.game.HandlePlayerAction(clientID, "fold", 0); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !h.submitAction(w, r, clientID, "fold", 0) {
 		return
 	}
 
@@ -134,14 +361,12 @@ func (h *Handler) HandleFold(w http.ResponseWriter, r *http.Request) {
 
 // Handle check action
 func (h *Handler) HandleCheck(w http.ResponseWriter, r *http.Request) {
-	clientID := r.Header.Get("X-Client-ID")
-	if clientID == "" {
-		http.Error(w, "Client ID required", http.StatusBadRequest)
+	clientID, ok := requireClientID(w, r)
+	if !ok {
 		return
 	}
 
-	if err := h.game.HandlePlayerAction(clientID, "check", 0); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !h.submitAction(w, r, clientID, "check", 0) {
 		return
 	}
 
@@ -153,14 +378,12 @@ func (h *Handler) HandleCheck(w http.ResponseWriter, r *http.Request) {
 
 // Handle call action
 func (h *Handler) HandleCall(w http.ResponseWriter, r *http.Request) {
-	clientID := r.Header.Get("X-Client-ID")
-	if clientID == "" {
-		http.Error(w, "Client ID required", http.StatusBadRequest)
+	clientID, ok := requireClientID(w, r)
+	if !ok {
 		return
 	}
 
-	if err := h.game.HandlePlayerAction(clientID, "call", 0); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !h.submitAction(w, r, clientID, "call", 0) {
 		return
 	}
 
@@ -172,9 +395,8 @@ func (h *Handler) HandleCall(w http.ResponseWriter, r *http.Request) {
 
 // Handle bet action
 func (h *Handler) HandleBet(w http.ResponseWriter, r *http.Request) {
-	clientID := r.Header.Get("X-Client-ID")
-	if clientID == "" {
-		http.Error(w, "Client ID required", http.StatusBadRequest)
+	clientID, ok := requireClientID(w, r)
+	if !ok {
 		return
 	}
 
@@ -182,13 +404,15 @@ func (h *Handler) HandleBet(w http.ResponseWriter, r *http.Request) {
 		Value int `json:"value"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if !validAmount(w, "value", req.Value) {
 		return
 	}
 
-	if err := h.game.HandlePlayerAction(clientID, "bet", req.Value); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !h.submitAction(w, r, clientID, "bet", req.Value) {
 		return
 	}
 
@@ -201,9 +425,8 @@ func (h *Handler) HandleBet(w http.ResponseWriter, r *http.Request) {
 
 // Handle raise action
 func (h *Handler) HandleRaise(w http.ResponseWriter, r *http.Request) {
-	clientID := r.Header.Get("X-Client-ID")
-	if clientID == "" {
-		http.Error(w, "Client ID required", http.StatusBadRequest)
+	clientID, ok := requireClientID(w, r)
+	if !ok {
 		return
 	}
 
@@ -211,13 +434,15 @@ func (h *Handler) HandleRaise(w http.ResponseWriter, r *http.Request) {
 		Value int `json:"value"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	if err := h.game.HandlePlayerAction(clientID, "raise", req.Value); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !validAmount(w, "value", req.Value) {
+		return
+	}
+
+	if !h.submitAction(w, r, clientID, "raise", req.Value) {
 		return
 	}
 
@@ -228,12 +453,224 @@ func (h *Handler) HandleRaise(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// Handle all-in action; the amount is the player's whole stack
+func (h *Handler) HandleAllIn(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	if !h.submitAction(w, r, clientID, "all_in", 0) {
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]string{
+		"status": "all_in",
+		"player": clientID,
+	})
+}
+
+// Show the caller's hand at the showdown
+func (h *Handler) HandleShow(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.game.ShowHand(clientID); err != nil {
+		WriteErrorCode(w, http.StatusBadRequest, ErrCodeActionRejected, err.Error())
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]string{
+		"status": "show",
+		"player": clientID,
+	})
+}
+
+// Muck the caller's hand at the showdown
+func (h *Handler) HandleMuck(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.game.MuckHand(clientID); err != nil {
+		WriteErrorCode(w, http.StatusBadRequest, ErrCodeActionRejected, err.Error())
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]string{
+		"status": "muck",
+		"player": clientID,
+	})
+}
+
+// Get whether the table allows straddles and whether the caller straddles
+func (h *Handler) HandleGetStraddle(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"allowed": h.game.TableConfig().Straddle,
+		"enabled": h.game.Straddling(clientID),
+		"amount":  2 * game.BigBlind,
+	})
+}
+
+// Opt in or out of straddling when left of the big blind
+func (h *Handler) HandleSetStraddle(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Enabled bool `json:"enabled"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.game.SetStraddle(clientID, req.Enabled); err != nil {
+		apiError(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"enabled": req.Enabled,
+	})
+}
+
+// Get the rake rules, total rake collected and rake per recent hand
+func (h *Handler) HandleGetRake(w http.ResponseWriter, r *http.Request) {
+	JSON(w, http.StatusOK, h.game.Rake())
+}
+
+// Get the open run-it-twice offer, if any
+func (h *Handler) HandleGetRunItTwice(w http.ResponseWriter, r *http.Request) {
+	players, votes, expiresAt, ok := h.game.RunItTwiceOffer()
+	if !ok {
+		JSON(w, http.StatusOK, map[string]interface{}{
+			"allowed": h.game.TableConfig().RunItTwice,
+			"open":    false,
+		})
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"allowed":    true,
+		"open":       true,
+		"players":    players,
+		"votes":      votes,
+		"expires_at": expiresAt,
+	})
+}
+
+// Accept or decline running the rest of the board twice
+func (h *Handler) HandleRunItTwice(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Accept bool `json:"accept"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.game.VoteRunItTwice(clientID, req.Accept); err != nil {
+		apiError(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"player": clientID,
+		"accept": req.Accept,
+	})
+}
+
+// Get the rabbit hunt waiting on shares, if any; the board itself is sent
+// in the rabbit_hunt event
+func (h *Handler) HandleGetRabbitHunt(w http.ResponseWriter, r *http.Request) {
+	handUID, players, shared, expiresAt, ok := h.game.RabbitHunt()
+	if !ok {
+		JSON(w, http.StatusOK, map[string]interface{}{
+			"allowed": h.game.TableConfig().RabbitHunt,
+			"open":    false,
+		})
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"allowed":    true,
+		"open":       true,
+		"hand_uid":   handUID,
+		"players":    players,
+		"shared":     shared,
+		"expires_at": expiresAt,
+	})
+}
+
+// Get whether the caller is sitting out and the blinds they owe
+func (h *Handler) HandleGetSitOut(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	sittingOut, waitForBB, owed := h.game.SittingOut(clientID)
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"sitting_out": sittingOut,
+		"wait_for_bb": waitForBB,
+		"owed_blinds": owed,
+	})
+}
+
+// Sit out of the deal, or come back and either post missed blinds or wait for the big blind
+func (h *Handler) HandleSitOut(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		SittingOut bool `json:"sitting_out"`
+		WaitForBB  bool `json:"wait_for_bb"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.game.SitOut(clientID, req.SittingOut, req.WaitForBB); err != nil {
+		apiError(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	sittingOut, waitForBB, owed := h.game.SittingOut(clientID)
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"sitting_out": sittingOut,
+		"wait_for_bb": waitForBB,
+		"owed_blinds": owed,
+	})
+}
+
 // Get connected peers
 func (h *Handler) HandleGetPeers(w http.ResponseWriter, r *http.Request) {
 	peerIDs := h.peerManager.GetAllPeerIDs()
+	links := h.hub.PeerLinks()
 	response := map[string]interface{}{
-		"peers": peerIDs,
-		"count": len(peerIDs),
+		"peers":   peerIDs,
+		"count":   len(peerIDs),
+		"latency": links,
+		"relayed": h.game.RelayRoutes(),
+	}
+	if h.bootstrap != nil {
+		response["bootstrap"] = h.bootstrap.BootstrapPeers()
 	}
 	JSON(w, http.StatusOK, response)
 }
@@ -244,13 +681,12 @@ func (h *Handler) HandleConnectPeer(w http.ResponseWriter, r *http.Request) {
 		PeerAddr string `json:"peer_addr"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	if req.PeerAddr == "" {
-		http.Error(w, "peer_addr is required", http.StatusBadRequest)
+		apiError(w, "peer_addr is required", http.StatusBadRequest)
 		return
 	}
 
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/RedPaladin7/peerpoker/internal/game"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultPlayerHandsLimit caps a player's hand list unless ?limit is given
+const DefaultPlayerHandsLimit = 50
+
+// List recorded hands, newest first
+func (h *Handler) HandleGetHands(w http.ResponseWriter, r *http.Request) {
+	hands := h.game.HandHistories()
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"hands": hands,
+		"count": len(hands),
+	})
+}
+
+// Get a single recorded hand, by number or unique ID
+func (h *Handler) HandleGetHand(w http.ResponseWriter, r *http.Request) {
+	// Hands are addressed by number or by unique ID
+	var hand game.HandHistory
+	var ok bool
+	if id, err := strconv.Atoi(mux.Vars(r)["id"]); err == nil {
+		hand, ok = h.game.GetHandHistory(id)
+	} else {
+		hand, ok = h.game.HandHistoryByUID(mux.Vars(r)["id"])
+	}
+	if !ok {
+		apiError(w, "Hand not found", http.StatusNotFound)
+		return
+	}
+
+	JSON(w, http.StatusOK, hand)
+}
+
+// Prove a recorded hand unchanged: recompute its commitment and compare it
+// with the hash taken when it finished and the one anchored on-chain
+func (h *Handler) HandleGetHandProof(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		hand, ok := h.game.HandHistoryByUID(mux.Vars(r)["id"])
+		if !ok {
+			apiError(w, "Hand not found", http.StatusNotFound)
+			return
+		}
+		id = hand.ID
+	}
+
+	proof, err := h.game.HandProof(id)
+	if err != nil {
+		apiError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	JSON(w, http.StatusOK, proof)
+}
+
+// Get a hand's shuffle audit: each player's key commitment and the deck
+// fingerprint after their pass, plus the keys and permutations, replayed
+// and checked, once the hand is over if the table publishes them
+func (h *Handler) HandleGetShuffleAudit(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		hand, ok := h.game.HandHistoryByUID(mux.Vars(r)["id"])
+		if !ok {
+			apiError(w, "Hand not found", http.StatusNotFound)
+			return
+		}
+		id = hand.ID
+	}
+
+	audit, err := h.game.ShuffleAudit(id)
+	if err != nil {
+		apiError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	JSON(w, http.StatusOK, audit)
+}
+
+// Replay a hand: the table as it stood after one step (0 once the blinds
+// are posted, then one per action, then the result), for hand replayers
+// and disputes
+func (h *Handler) HandleGetHandReplay(w http.ResponseWriter, r *http.Request) {
+	var hand game.HandHistory
+	var ok bool
+	if id, err := strconv.Atoi(mux.Vars(r)["id"]); err == nil {
+		hand, ok = h.game.GetHandHistory(id)
+	} else {
+		hand, ok = h.game.HandHistoryByUID(mux.Vars(r)["id"])
+	}
+	if !ok {
+		apiError(w, "Hand not found", http.StatusNotFound)
+		return
+	}
+
+	step := 0
+	if raw := r.URL.Query().Get("step"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			apiError(w, "Invalid step", http.StatusBadRequest)
+			return
+		}
+		step = n
+	}
+
+	steps, err := game.ReplayHand(hand)
+	if err != nil {
+		apiError(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if step >= len(steps) {
+		apiError(w, fmt.Sprintf("Hand has steps 0 to %d", len(steps)-1), http.StatusNotFound)
+		return
+	}
+	JSON(w, http.StatusOK, steps[step])
+}
+
+// List the stored hands a player was dealt into, across every table
+// sharing the store
+func (h *Handler) HandleGetPlayerHands(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		apiError(w, "Hand storage is not enabled", http.StatusNotFound)
+		return
+	}
+
+	limit := DefaultPlayerHandsLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			apiError(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	playerID := mux.Vars(r)["id"]
+	hands, err := h.store.HandsForPlayer(playerID, limit)
+	if err != nil {
+		logrus.Errorf("Failed to query hands for %s: %v", playerID, err)
+		apiError(w, "Failed to load hands", http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"player_id": playerID,
+		"hands":     hands,
+		"count":     len(hands),
+	})
+}
+
+// List the stored betting actions of one of this table's hands
+func (h *Handler) HandleGetHandActions(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		apiError(w, "Hand storage is not enabled", http.StatusNotFound)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		apiError(w, "Invalid hand ID", http.StatusBadRequest)
+		return
+	}
+
+	actions, err := h.store.HandActions(h.tableID, id)
+	if err != nil {
+		logrus.Errorf("Failed to query actions for hand %d: %v", id, err)
+		apiError(w, "Failed to load actions", http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"hand_id": id,
+		"actions": actions,
+	})
+}
+
+// Get the table-start seat draw so players can verify it
+func (h *Handler) HandleGetSeatDraw(w http.ResponseWriter, r *http.Request) {
+	draw, ok := h.game.SeatDraw()
+	if !ok {
+		apiError(w, "Seats have not been drawn yet", http.StatusNotFound)
+		return
+	}
+
+	JSON(w, http.StatusOK, draw)
+}
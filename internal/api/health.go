@@ -0,0 +1,113 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Component states in a readiness report. A disabled component is not
+// configured on this node and does not hold readiness back.
+const (
+	ComponentOK       = "ok"
+	ComponentFailing  = "failing"
+	ComponentDisabled = "disabled"
+)
+
+// ComponentStatus is one dependency's part of a readiness report
+type ComponentStatus struct {
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Readiness reports whether the node can serve a table, and why not
+type Readiness struct {
+	Ready      bool                       `json:"ready"`
+	Components map[string]ComponentStatus `json:"components"`
+	CheckedAt  time.Time                  `json:"checked_at"`
+}
+
+// Liveness probe: answers as long as the process can serve HTTP, without
+// touching the game or any dependency, so a slow dependency never gets the
+// node restarted
+func (h *Handler) HandleLiveness(w http.ResponseWriter, r *http.Request) {
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"status":  "alive",
+		"version": h.version,
+	})
+}
+
+// Readiness probe: the blockchain is reachable, the store is writable, the
+// WebSocket hub is running and enough peers are connected. Answers 503
+// with the failing components otherwise.
+func (h *Handler) HandleReadiness(w http.ResponseWriter, r *http.Request) {
+	readiness := h.Readiness()
+	status := http.StatusOK
+	if !readiness.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	JSON(w, status, readiness)
+}
+
+// Readiness checks each of the node's dependencies
+func (h *Handler) Readiness() Readiness {
+	components := map[string]ComponentStatus{
+		"blockchain":  h.blockchainStatus(),
+		"persistence": h.persistenceStatus(),
+		"hub":         h.hubStatus(),
+		"peers":       h.peersStatus(),
+	}
+
+	ready := true
+	for _, c := range components {
+		if c.Status == ComponentFailing {
+			ready = false
+		}
+	}
+	return Readiness{Ready: ready, Components: components, CheckedAt: time.Now()}
+}
+
+func (h *Handler) blockchainStatus() ComponentStatus {
+	// The health monitor has already probed the chain; without one, ask it
+	// directly
+	if h.chainHealth != nil {
+		health := h.chainHealth.Health()
+		if health.Tripped {
+			return ComponentStatus{Status: ComponentFailing, Detail: health.Reason}
+		}
+		return ComponentStatus{Status: ComponentOK, Detail: fmt.Sprintf("block %d", health.BlockNumber)}
+	}
+	if h.blockchain == nil {
+		return ComponentStatus{Status: ComponentDisabled}
+	}
+	if _, err := h.blockchain.GetMyBalance(); err != nil {
+		return ComponentStatus{Status: ComponentFailing, Detail: err.Error()}
+	}
+	return ComponentStatus{Status: ComponentOK}
+}
+
+func (h *Handler) persistenceStatus() ComponentStatus {
+	if h.store == nil {
+		return ComponentStatus{Status: ComponentDisabled}
+	}
+	if err := h.store.Ping(); err != nil {
+		return ComponentStatus{Status: ComponentFailing, Detail: err.Error()}
+	}
+	return ComponentStatus{Status: ComponentOK}
+}
+
+func (h *Handler) hubStatus() ComponentStatus {
+	if !h.hub.Running() {
+		return ComponentStatus{Status: ComponentFailing, Detail: "event loop is not running"}
+	}
+	return ComponentStatus{Status: ComponentOK, Detail: fmt.Sprintf("%d connections", h.hub.ClientCount())}
+}
+
+func (h *Handler) peersStatus() ComponentStatus {
+	peers := h.peerManager.PeerCount()
+	detail := fmt.Sprintf("%d connected, %d required", peers, h.readyMinPeers)
+	if peers < h.readyMinPeers {
+		return ComponentStatus{Status: ComponentFailing, Detail: detail}
+	}
+	return ComponentStatus{Status: ComponentOK, Detail: detail}
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/RedPaladin7/peerpoker/internal/game"
+)
+
+// Get the table's host, whether they are at the table, who they may kick,
+// and any open vote to use their powers
+func (h *Handler) HandleGetHost(w http.ResponseWriter, r *http.Request) {
+	response := map[string]interface{}{
+		"host": h.game.Host(),
+	}
+	if vote, ok := h.game.HostVote(); ok {
+		response["vote"] = vote
+	}
+	JSON(w, http.StatusOK, response)
+}
+
+// Kick an unresponsive player, change the table config between hands or
+// close the table, as its host
+func (h *Handler) HandleHostAction(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	var req game.HostAction
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.game.HostAct(clientID, req); err != nil {
+		apiError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"status": "done",
+		"action": req.Action,
+	})
+}
+
+// Propose or vote on using a host power while the host is away; a majority
+// of seated players carries the vote
+func (h *Handler) HandleVoteHostAction(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		game.HostAction
+		Approve bool `json:"approve"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.game.VoteHostAction(clientID, req.HostAction, req.Approve); err != nil {
+		apiError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status": "voted",
+	}
+	if vote, ok := h.game.HostVote(); ok {
+		response["vote"] = vote
+	}
+	JSON(w, http.StatusOK, response)
+}
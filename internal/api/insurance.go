@@ -0,0 +1,119 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/RedPaladin7/peerpoker/internal/game"
+	"github.com/RedPaladin7/peerpoker/internal/insurance"
+	"github.com/RedPaladin7/peerpoker/internal/lobby"
+)
+
+// Get the insurance pool balance, deposits and payouts (filter with ?incident= and ?hand=)
+func (h *Handler) HandleGetInsurance(w http.ResponseWriter, r *http.Request) {
+	if h.insurance == nil {
+		apiError(w, "Insurance fund is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	handID := 0
+	if hand := r.URL.Query().Get("hand"); hand != "" {
+		id, err := strconv.Atoi(hand)
+		if err != nil {
+			apiError(w, "Invalid hand ID", http.StatusBadRequest)
+			return
+		}
+		handID = id
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"summary":  h.insurance.Summary(),
+		"deposits": h.insurance.Deposits(),
+		"payouts":  h.insurance.Payouts(r.URL.Query().Get("incident"), handID),
+	})
+}
+
+// Add operator money to the insurance pool
+func (h *Handler) HandleInsuranceDeposit(w http.ResponseWriter, r *http.Request) {
+	if h.insurance == nil {
+		apiError(w, "Insurance fund is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		Amount int    `json:"amount"`
+		Note   string `json:"note"`
+		TxHash string `json:"tx_hash"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if !validAmount(w, "amount", req.Amount) {
+		return
+	}
+
+	deposit, err := h.insurance.Deposit(req.Amount, adminActor(r), req.Note, req.TxHash)
+	if err != nil {
+		apiError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"deposit": deposit,
+		"summary": h.insurance.Summary(),
+	})
+}
+
+// Compensate a player from the insurance pool for an incident in a given hand
+func (h *Handler) HandleInsurancePayout(w http.ResponseWriter, r *http.Request) {
+	if h.insurance == nil {
+		apiError(w, "Insurance fund is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var claim insurance.Claim
+	if !decodeJSON(w, r, &claim) {
+		return
+	}
+	if err := claim.Validate(); err != nil {
+		apiError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Payouts are linked to the table's hand history when the table is known
+	var table *lobby.ManagedTable
+	if claim.TableID != "" {
+		if h.tables == nil {
+			apiError(w, "Admin endpoints are not enabled", http.StatusServiceUnavailable)
+			return
+		}
+		t, ok := h.tables.Get(claim.TableID)
+		if !ok {
+			apiError(w, "Table not found", http.StatusNotFound)
+			return
+		}
+		table = t
+	}
+
+	payout, err := h.insurance.Compensate(claim, adminActor(r))
+	if err != nil {
+		apiError(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	if table != nil {
+		table.Game.RecordCompensation(payout.HandID, game.Compensation{
+			PayoutID:   payout.ID,
+			PlayerID:   payout.PlayerID,
+			Amount:     payout.Amount,
+			IncidentID: payout.IncidentID,
+			TxHash:     payout.TxHash,
+		}, adminActor(r))
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"payout":  payout,
+		"summary": h.insurance.Summary(),
+	})
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/RedPaladin7/peerpoker/internal/lobby"
+	"github.com/gorilla/mux"
+)
+
+// List tables, optionally filtered by variant, stakes and open seats
+func (h *Handler) HandleGetLobby(w http.ResponseWriter, r *http.Request) {
+	if h.lobby == nil {
+		apiError(w, "Lobby is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := lobby.Filter{
+		Variant:  query.Get("variant"),
+		OpenOnly: query.Get("open") == "true",
+	}
+	filter.MinBigBlind, _ = strconv.Atoi(query.Get("min_big_blind"))
+	filter.MaxBigBlind, _ = strconv.Atoi(query.Get("max_big_blind"))
+	filter.MaxBuyIn, _ = strconv.Atoi(query.Get("max_buy_in"))
+
+	tables := h.lobby.Tables(filter)
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"tables": tables,
+		"count":  len(tables),
+	})
+}
+
+// Seat the caller at the first open table matching their preferences
+func (h *Handler) HandleQuickSeat(w http.ResponseWriter, r *http.Request) {
+	if h.lobby == nil {
+		apiError(w, "Lobby is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	var filter lobby.Filter
+	if r.ContentLength != 0 {
+		if !decodeJSON(w, r, &filter) {
+			return
+		}
+	}
+
+	table, err := h.lobby.QuickSeat(clientID, filter)
+	if err != nil {
+		apiError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"status": "seated",
+		"table":  table,
+	})
+}
+
+// List the players waiting for a seat at a table
+func (h *Handler) HandleGetWaitlist(w http.ResponseWriter, r *http.Request) {
+	if h.lobby == nil {
+		apiError(w, "Lobby is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	waitlist, err := h.lobby.Waitlist(mux.Vars(r)["id"])
+	if err != nil {
+		apiError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"waitlist": waitlist,
+		"count":    len(waitlist),
+	})
+}
+
+// Join the waitlist for a full table
+func (h *Handler) HandleJoinWaitlist(w http.ResponseWriter, r *http.Request) {
+	if h.lobby == nil {
+		apiError(w, "Lobby is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	position, err := h.lobby.JoinWaitlist(clientID, mux.Vars(r)["id"])
+	if err != nil {
+		apiError(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"status":   "waiting",
+		"position": position,
+	})
+}
+
+// Leave a table's waitlist
+func (h *Handler) HandleLeaveWaitlist(w http.ResponseWriter, r *http.Request) {
+	if h.lobby == nil {
+		apiError(w, "Lobby is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.lobby.LeaveWaitlist(clientID, mux.Vars(r)["id"]); err != nil {
+		apiError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]string{"status": "left"})
+}
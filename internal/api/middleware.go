@@ -1,7 +1,10 @@
 package api
 
 import (
+	"crypto/subtle"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -12,8 +15,8 @@ func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Client-ID")
-		w.Header().Set("Access-Control-Expose-Headers", "X-Client-ID")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Client-ID, Idempotency-Key, API-Version")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Client-ID, Retry-After, Idempotent-Replayed, API-Version, Deprecation, Sunset, Link, Warning")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -58,7 +61,7 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 					"path":   r.URL.Path,
 				}).Error("Panic recovered")
 
-				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				apiError(w, "Internal server error", http.StatusInternalServerError)
 			}
 		}()
 
@@ -75,13 +78,45 @@ func AuthMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// RateLimitMiddleware implements basic rate limiting (future use)
-func RateLimitMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// TODO: Implement rate limiting
-		// For now, just pass through
-		next.ServeHTTP(w, r)
-	})
+// AdminAuthMiddleware guards operator endpoints. Requests must carry
+// "Authorization: Bearer <token>"; with no token configured, only requests
+// from the node's own machine are let through.
+func AdminAuthMiddleware(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				if !isLoopback(r.RemoteAddr) {
+					apiError(w, "Admin endpoints are only available locally", http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			auth := r.Header.Get("Authorization")
+			given := strings.TrimPrefix(auth, "Bearer ")
+			if given == auth || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				logrus.WithFields(logrus.Fields{
+					"path":   r.URL.Path,
+					"remote": r.RemoteAddr,
+				}).Warn("Rejected admin request")
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				apiError(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
 }
 
 // loggingResponseWriter wraps http.ResponseWriter to capture status code
@@ -94,3 +129,8 @@ func (lrw *loggingResponseWriter) WriteHeader(code int) {
 	lrw.statusCode = code
 	lrw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap lets http.ResponseController reach the underlying writer (flush, deadlines)
+func (lrw *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return lrw.ResponseWriter
+}
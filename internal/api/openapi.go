@@ -0,0 +1,415 @@
+package api
+
+//go:generate go run ./openapigen
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/RedPaladin7/peerpoker/internal/game"
+	"github.com/RedPaladin7/peerpoker/internal/presence"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/gorilla/mux"
+)
+
+// handlerDoc is what openapigen reads off a handler's definition
+type handlerDoc struct {
+	Description string
+	Query       []string
+	Body        interface{} // zero value of the type the JSON body decodes into
+}
+
+// handlerResponses documents response bodies. Most handlers answer with
+// ad hoc maps, so only those returning a defined type are listed.
+var handlerResponses = map[string]interface{}{
+	"HandleGetTable": game.TableStateResponse{},
+	"HandleGetPlayers": struct {
+		Players []game.PlayerStateResponse `json:"players"`
+	}{},
+	"HandleGetHands": struct {
+		Hands []game.HandHistory `json:"hands"`
+		Count int                `json:"count"`
+	}{},
+	"HandleGetSession": struct {
+		Players []game.SessionAccount `json:"players"`
+	}{},
+	"HandleGetHand":           game.HandHistory{},
+	"HandleGetHandProof":      game.HandProof{},
+	"HandleGetShuffleAudit":   game.ShuffleAudit{},
+	"HandleGetHandReplay":     game.ReplayStep{},
+	"HandleGetRake":           game.RakeReport{},
+	"HandleGetPlayerSession":  game.SessionAccount{},
+	"HandleGetSeatDraw":       game.SeatDraw{},
+	"HandleGetPlayerPresence": presence.Presence{},
+	"HandleSetAway":           presence.Presence{},
+	"HandleGetProfile":        protocol.PlayerProfile{},
+	"HandlePutProfile":        protocol.PlayerProfile{},
+	"HandleAdminReloadConfig": ConfigReload{},
+	"HandleAdminLatency":      game.LatencyReport{},
+	"HandleReadiness":         Readiness{},
+}
+
+var templateParam = regexp.MustCompile(`\{([^}:]+)(:[^}]+)?\}`)
+
+// OpenAPI builds an OpenAPI 3 document for every versioned route the
+// handler serves. Paths and methods come from the router itself, so
+// nothing routed can be missing; descriptions, query parameters and
+// request bodies come from the handlers' definitions via openapigen.
+func (h *Handler) OpenAPI() map[string]interface{} {
+	router, ok := h.Routes().(*mux.Router)
+	if !ok {
+		return nil
+	}
+
+	schemas := newSchemaRegistry()
+	errorRef := schemas.ref(reflect.TypeOf(ErrorResponse{}))
+	paths := map[string]map[string]interface{}{}
+	operationIDs := map[string]int{}
+
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		name := handlerName(route.GetHandler())
+		if name == "" {
+			return nil
+		}
+
+		path := templateParam.ReplaceAllString(template, "{$1}")
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+		for _, method := range methods {
+			if method == http.MethodOptions {
+				continue
+			}
+			op := h.operation(name, path, method, schemas, errorRef)
+			id := lowerFirst(strings.TrimPrefix(name, "Handle"))
+			if operationIDs[id]++; operationIDs[id] > 1 {
+				id += upperFirst(strings.ToLower(method))
+			}
+			op["operationId"] = id
+			paths[path][strings.ToLower(method)] = op
+		}
+		return nil
+	})
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "PeerPoker node API",
+			"version":     h.version,
+			"description": "HTTP API of a PeerPoker node. Player requests identify the player with the X-Client-ID header; live events stream over the node's WebSocket. Unversioned /api paths still work but are deprecated.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas.defs,
+			"securitySchemes": map[string]interface{}{
+				"clientId": map[string]interface{}{
+					"type": "apiKey",
+					"in":   "header",
+					"name": "X-Client-ID",
+				},
+				"adminToken": map[string]interface{}{
+					"type":         "http",
+					"scheme":       "bearer",
+					"description":  "ADMIN_TOKEN; without one, admin routes answer loopback requests only",
+					"bearerFormat": "opaque",
+				},
+			},
+		},
+	}
+}
+
+func (h *Handler) operation(name, path, method string, schemas *schemaRegistry, errorRef map[string]interface{}) map[string]interface{} {
+	doc := handlerDocs[name]
+	op := map[string]interface{}{
+		"summary": summary(name),
+		"tags":    []string{tag(path)},
+	}
+	if doc.Description != "" {
+		op["description"] = doc.Description
+	}
+
+	if isAdminPath(path) {
+		op["security"] = []map[string][]string{{"adminToken": {}}}
+	} else {
+		op["security"] = []map[string][]string{{"clientId": {}}, {}}
+	}
+
+	var params []map[string]interface{}
+	for _, match := range templateParam.FindAllStringSubmatch(path, -1) {
+		params = append(params, map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]string{"type": "string"},
+		})
+	}
+	for _, q := range doc.Query {
+		params = append(params, map[string]interface{}{
+			"name":   q,
+			"in":     "query",
+			"schema": map[string]string{"type": "string"},
+		})
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	if doc.Body != nil && method != http.MethodGet {
+		op["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": schemas.schema(reflect.TypeOf(doc.Body))},
+			},
+		}
+	}
+
+	success := map[string]interface{}{"description": "OK"}
+	body := map[string]interface{}{"type": "object"}
+	if resp, ok := handlerResponses[name]; ok {
+		body = schemas.schema(reflect.TypeOf(resp))
+	}
+	success["content"] = map[string]interface{}{"application/json": map[string]interface{}{"schema": body}}
+
+	op["responses"] = map[string]interface{}{
+		"200": success,
+		"default": map[string]interface{}{
+			"description": "Error",
+			"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": errorRef}},
+		},
+	}
+	return op
+}
+
+// handlerName recovers the method name of a route's handler,
+// e.g. HandleGetTable
+func handlerName(handler http.Handler) string {
+	fn, ok := handler.(http.HandlerFunc)
+	if !ok {
+		return ""
+	}
+	full := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	name := strings.TrimSuffix(full[strings.LastIndex(full, ".")+1:], "-fm")
+	if !strings.HasPrefix(name, "Handle") {
+		return ""
+	}
+	return name
+}
+
+// summary turns HandleGetHandProof into "Get hand proof"
+func summary(name string) string {
+	name = strings.TrimPrefix(name, "Handle")
+	var words []string
+	start := 0
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			words = append(words, strings.ToLower(name[start:i]))
+			start = i
+		}
+	}
+	words = append(words, strings.ToLower(name[start:]))
+	words[0] = upperFirst(words[0])
+	return strings.Join(words, " ")
+}
+
+// tag groups a path by its first segment after /api/<version>
+func tag(path string) string {
+	first, _, _ := strings.Cut(strings.TrimPrefix(versionRelative(path), "/"), "/")
+	if first == "" {
+		return "general"
+	}
+	return first
+}
+
+func isAdminPath(path string) bool {
+	rel := versionRelative(path)
+	return strings.HasPrefix(rel, "/admin") || strings.HasPrefix(rel, "/audit")
+}
+
+// versionRelative strips /api/<version> from a path
+func versionRelative(path string) string {
+	rest := strings.TrimPrefix(path, "/api")
+	for _, v := range apiVersions {
+		if rest == "/"+v.Name || strings.HasPrefix(rest, "/"+v.Name+"/") {
+			return strings.TrimPrefix(rest, "/"+v.Name)
+		}
+	}
+	return rest
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// schemaRegistry turns Go types into JSON Schema, collecting named structs
+// under components/schemas
+type schemaRegistry struct {
+	defs  map[string]interface{}
+	names map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		defs:  map[string]interface{}{},
+		names: map[reflect.Type]string{},
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (s *schemaRegistry) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// []byte is base64, except json.RawMessage which is any JSON
+			if t.Name() == "RawMessage" {
+				return map[string]interface{}{}
+			}
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return s.ref(t)
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// ref registers a named struct once and points at it
+func (s *schemaRegistry) ref(t reflect.Type) map[string]interface{} {
+	name, ok := s.names[t]
+	if !ok {
+		name = t.Name()
+		if _, taken := s.defs[name]; taken {
+			name = upperFirst(t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]) + name
+		}
+		s.names[t] = name
+		s.defs[name] = map[string]interface{}{} // placeholder for recursive types
+		s.defs[name] = s.object(t)
+	}
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+func (s *schemaRegistry) object(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	s.fields(t, properties, &required)
+
+	obj := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		obj["required"] = required
+	}
+	return obj
+}
+
+// fields adds t's JSON fields, flattening embedded structs the way
+// encoding/json does
+func (s *schemaRegistry) fields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.fields(embedded, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = s.schema(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// Serve the OpenAPI document for this API
+func (h *Handler) HandleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	// Routes do not change at runtime, so the document is built once
+	h.openAPIOnce.Do(func() {
+		h.openAPI = h.OpenAPI()
+	})
+	JSON(w, http.StatusOK, h.openAPI)
+}
+
+// Serve a Swagger UI page for the OpenAPI document
+func (h *Handler) HandleSwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUIPage))
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>PeerPoker API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: '/api/v1/openapi.json', dom_id: '#swagger-ui' });
+  </script>
+</body>
+</html>
+`
@@ -0,0 +1,249 @@
+// Command openapigen reads the api package's handlers and writes
+// openapi_handlers.go: each handler's doc comment, the query parameters it
+// reads and the type its JSON body decodes into. The OpenAPI document is
+// built from that and the live router, so run `go generate ./internal/api`
+// after adding or changing a handler.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const output = "openapi_handlers.go"
+
+type handler struct {
+	name        string
+	description string
+	query       []string
+	body        string // Go expression for a zero value of the body type
+}
+
+func main() {
+	dir := "."
+	if len(os.Args) > 1 {
+		dir = os.Args[1]
+	}
+
+	fset := token.NewFileSet()
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var handlers []handler
+	imports := map[string]string{} // package name -> import path, for body types
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") || filepath.Base(path) == output {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fileImports := importNames(file)
+
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || !isHandler(fn) {
+				continue
+			}
+			h := handler{
+				name:        fn.Name.Name,
+				description: strings.Join(strings.Fields(fn.Doc.Text()), " "),
+				query:       queryParams(fn),
+			}
+			if expr := bodyType(fn); expr != nil {
+				h.body = typeString(fset, expr) + "{}"
+				for _, pkg := range packagesIn(expr) {
+					imports[pkg] = fileImports[pkg]
+				}
+			}
+			handlers = append(handlers, h)
+		}
+	}
+	sort.Slice(handlers, func(i, j int) bool { return handlers[i].name < handlers[j].name })
+
+	src, err := render(handlers, imports)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, output), src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// isHandler matches func (h *Handler) HandleX(w http.ResponseWriter, r *http.Request)
+func isHandler(fn *ast.FuncDecl) bool {
+	if fn.Recv == nil || len(fn.Recv.List) != 1 || !strings.HasPrefix(fn.Name.Name, "Handle") {
+		return false
+	}
+	star, ok := fn.Recv.List[0].Type.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	recv, ok := star.X.(*ast.Ident)
+	return ok && recv.Name == "Handler" && fn.Type.Params.NumFields() == 2
+}
+
+// queryParams collects the literal names passed to r.URL.Query().Get
+func queryParams(fn *ast.FuncDecl) []string {
+	seen := map[string]bool{}
+	var params []string
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Get" {
+			return true
+		}
+		inner, ok := sel.X.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if query, ok := inner.Fun.(*ast.SelectorExpr); !ok || query.Sel.Name != "Query" {
+			return true
+		}
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		name, err := strconv.Unquote(lit.Value)
+		if err == nil && !seen[name] {
+			seen[name] = true
+			params = append(params, name)
+		}
+		return true
+	})
+	return params
+}
+
+// bodyType finds the variable passed to decodeJSON(w, r, &v) and returns
+// the type it was declared with
+func bodyType(fn *ast.FuncDecl) ast.Expr {
+	var target string
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || target != "" {
+			return target == ""
+		}
+		if ident, ok := call.Fun.(*ast.Ident); !ok || ident.Name != "decodeJSON" || len(call.Args) != 3 {
+			return true
+		}
+		if unary, ok := call.Args[2].(*ast.UnaryExpr); ok && unary.Op == token.AND {
+			if ident, ok := unary.X.(*ast.Ident); ok {
+				target = ident.Name
+			}
+		}
+		return true
+	})
+	if target == "" {
+		return nil
+	}
+
+	var typ ast.Expr
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok || typ != nil {
+			return typ == nil
+		}
+		for _, name := range spec.Names {
+			if name.Name == target {
+				typ = spec.Type
+			}
+		}
+		return true
+	})
+	return typ
+}
+
+func typeString(fset *token.FileSet, expr ast.Expr) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, fset, expr)
+	return buf.String()
+}
+
+// packagesIn lists the packages a type expression refers to
+func packagesIn(expr ast.Expr) []string {
+	var pkgs []string
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				pkgs = append(pkgs, ident.Name)
+			}
+		}
+		return true
+	})
+	return pkgs
+}
+
+func importNames(file *ast.File) map[string]string {
+	names := map[string]string{}
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := filepath.Base(path)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		names[name] = path
+	}
+	return names
+}
+
+func render(handlers []handler, imports map[string]string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by openapigen from the handler definitions. DO NOT EDIT.\n\npackage api\n\n")
+
+	if len(imports) > 0 {
+		names := make([]string, 0, len(imports))
+		for name := range imports {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		buf.WriteString("import (\n")
+		for _, name := range names {
+			path := imports[name]
+			if filepath.Base(path) == name {
+				fmt.Fprintf(&buf, "\t%q\n", path)
+			} else {
+				fmt.Fprintf(&buf, "\t%s %q\n", name, path)
+			}
+		}
+		buf.WriteString(")\n\n")
+	}
+
+	buf.WriteString("var handlerDocs = map[string]handlerDoc{\n")
+	for _, h := range handlers {
+		fmt.Fprintf(&buf, "\t%q: {\n", h.name)
+		if h.description != "" {
+			fmt.Fprintf(&buf, "\t\tDescription: %q,\n", h.description)
+		}
+		if len(h.query) > 0 {
+			quoted := make([]string, len(h.query))
+			for i, q := range h.query {
+				quoted[i] = strconv.Quote(q)
+			}
+			fmt.Fprintf(&buf, "\t\tQuery: []string{%s},\n", strings.Join(quoted, ", "))
+		}
+		if h.body != "" {
+			fmt.Fprintf(&buf, "\t\tBody: %s,\n", h.body)
+		}
+		buf.WriteString("\t},\n")
+	}
+	buf.WriteString("}\n")
+
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+)
+
+// Get whether the table is paused and any open vote to pause or resume it
+func (h *Handler) HandleGetPause(w http.ResponseWriter, r *http.Request) {
+	response := map[string]interface{}{
+		"paused": false,
+	}
+	if pause, ok := h.game.PauseStatus(); ok {
+		// The snapshot is for operators; players see the table as it is
+		pause.Snapshot = nil
+		response["paused"] = true
+		response["pause"] = pause
+	}
+	if vote, ok := h.game.PauseVote(); ok {
+		response["vote"] = vote
+	}
+	JSON(w, http.StatusOK, response)
+}
+
+// Propose or vote on pausing the table, or resuming it; a majority of
+// seated players carries the vote
+func (h *Handler) HandleVotePause(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Action  string `json:"action"` // pause or resume
+		Approve bool   `json:"approve"`
+		Reason  string `json:"reason,omitempty"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Action != "pause" && req.Action != "resume" {
+		apiError(w, "action must be pause or resume", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.game.VotePause(clientID, req.Action == "pause", req.Approve, req.Reason); err != nil {
+		apiError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := map[string]interface{}{
+		"status": "voted",
+		"paused": h.game.Paused(),
+	}
+	if vote, ok := h.game.PauseVote(); ok {
+		response["vote"] = vote
+	}
+	JSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Get presence for a comma-separated list of players, or everyone online
+func (h *Handler) HandleGetPresence(w http.ResponseWriter, r *http.Request) {
+	if h.presence == nil {
+		apiError(w, "Presence is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	players := r.URL.Query().Get("players")
+	if players == "" {
+		online := h.presence.Online()
+		JSON(w, http.StatusOK, map[string]interface{}{
+			"online": online,
+			"count":  len(online),
+		})
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"presence": h.presence.GetMany(strings.Split(players, ",")),
+	})
+}
+
+// Get presence for a single player
+func (h *Handler) HandleGetPlayerPresence(w http.ResponseWriter, r *http.Request) {
+	if h.presence == nil {
+		apiError(w, "Presence is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	JSON(w, http.StatusOK, h.presence.Get(mux.Vars(r)["id"]))
+}
+
+// Mark the calling player as away until their next activity
+func (h *Handler) HandleSetAway(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	if h.presence == nil {
+		apiError(w, "Presence is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	h.presence.SetAway(clientID)
+	JSON(w, http.StatusOK, h.presence.Get(clientID))
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+)
+
+// Get this node's player profile
+func (h *Handler) HandleGetProfile(w http.ResponseWriter, r *http.Request) {
+	p, ok := h.game.Profile()
+	if !ok {
+		apiError(w, "No profile set", http.StatusNotFound)
+		return
+	}
+	JSON(w, http.StatusOK, p)
+}
+
+// Set this node's player's display name, avatar and preferred stakes, and
+// show them to the table in place of the player's address
+func (h *Handler) HandlePutProfile(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+	if clientID != h.tableID {
+		apiError(w, "Only this node's player can set its profile", http.StatusForbidden)
+		return
+	}
+
+	var req protocol.PlayerProfile
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	p, err := h.game.SetProfile(req)
+	if err != nil {
+		apiError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	JSON(w, http.StatusOK, p)
+}
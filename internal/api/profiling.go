@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/gorilla/mux"
+)
+
+// SetProfiling serves the runtime profiler under /debug/pprof. Profiles
+// expose the process's internals, so they sit behind the admin token.
+func (h *Handler) SetProfiling(enabled bool) {
+	h.pprof = enabled
+}
+
+// profilingRoutes registers net/http/pprof on r when profiling is enabled
+func (h *Handler) profilingRoutes(r *mux.Router) {
+	if !h.pprof {
+		return
+	}
+	debug := r.PathPrefix("/debug/pprof").Subrouter()
+	debug.Use(AdminAuthMiddleware(h.adminToken))
+	debug.HandleFunc("/cmdline", pprof.Cmdline).Methods("GET")
+	debug.HandleFunc("/profile", pprof.Profile).Methods("GET")
+	debug.HandleFunc("/symbol", pprof.Symbol).Methods("GET", "POST")
+	debug.HandleFunc("/trace", pprof.Trace).Methods("GET")
+	for _, name := range []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"} {
+		debug.Handle("/"+name, pprof.Handler(name)).Methods("GET")
+	}
+	debug.HandleFunc("/", pprof.Index).Methods("GET")
+}
+
+// Per-stage latency histograms for a table: queueing on its loop,
+// validation, state changes, broadcast, persistence and shuffling
+func (h *Handler) HandleAdminLatency(w http.ResponseWriter, r *http.Request) {
+	table, ok := h.adminTable(w, r)
+	if !ok {
+		return
+	}
+	JSON(w, http.StatusOK, table.Game.LatencyReport())
+}
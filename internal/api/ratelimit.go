@@ -0,0 +1,162 @@
+package api
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RateLimit is a token bucket budget: RPS tokens are added every second up
+// to Burst. A zero RPS disables the limit.
+type RateLimit struct {
+	RPS   float64
+	Burst int
+}
+
+// Enabled reports whether the limit restricts anything
+func (l RateLimit) Enabled() bool {
+	return l.RPS > 0
+}
+
+// idleBucketTTL is how long an untouched bucket is kept; by then it has
+// refilled, so dropping it changes nothing
+const idleBucketTTL = 10 * time.Minute
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter keeps one token bucket per key (an IP, a client ID)
+type RateLimiter struct {
+	limit     RateLimit
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+// NewRateLimiter creates a limiter; a burst below one is raised to one
+func NewRateLimiter(limit RateLimit) *RateLimiter {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return &RateLimiter{
+		limit:     limit,
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// SetLimit changes the budget in place. Buckets keep their tokens, capped
+// at the new burst.
+func (rl *RateLimiter) SetLimit(limit RateLimit) {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.limit = limit
+	for _, bucket := range rl.buckets {
+		bucket.tokens = math.Min(bucket.tokens, float64(limit.Burst))
+	}
+}
+
+// Allow takes a token from key's bucket. When the bucket is empty it
+// returns false and how long until the next token.
+func (rl *RateLimiter) Allow(key string) (bool, time.Duration) {
+	if rl == nil {
+		return true, 0
+	}
+
+	// SetLimit may change the limit at any time, so it is read under the lock
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if !rl.limit.Enabled() {
+		return true, 0
+	}
+
+	now := time.Now()
+	rl.sweep(now)
+
+	bucket, ok := rl.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(rl.limit.Burst), last: now}
+		rl.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(float64(rl.limit.Burst), bucket.tokens+now.Sub(bucket.last).Seconds()*rl.limit.RPS)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / rl.limit.RPS * float64(time.Second))
+		return false, wait
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// sweep drops idle buckets so one-off clients do not accumulate
+func (rl *RateLimiter) sweep(now time.Time) {
+	if now.Sub(rl.lastSweep) < idleBucketTTL {
+		return
+	}
+	rl.lastSweep = now
+	for key, bucket := range rl.buckets {
+		if now.Sub(bucket.last) > idleBucketTTL {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+// RateLimitMiddleware limits requests per IP and per client ID (the
+// X-Client-ID header). Requests that change state draw from the action
+// budget, reads from the read budget; either limiter may be nil.
+func RateLimitMiddleware(actions, reads *RateLimiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			limiter, budget := reads, "read"
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				limiter, budget = actions, "action"
+			}
+
+			keys := []string{"ip:" + clientIP(r.RemoteAddr)}
+			if clientID := r.Header.Get("X-Client-ID"); clientID != "" {
+				keys = append(keys, "client:"+clientID)
+			}
+
+			for _, key := range keys {
+				if ok, wait := limiter.Allow(key); !ok {
+					logrus.WithFields(logrus.Fields{
+						"key":    key,
+						"budget": budget,
+						"path":   r.URL.Path,
+					}).Debug("Rate limited request")
+					w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+					apiError(w, "Too many requests", http.StatusTooManyRequests)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func clientIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+)
+
+// Add chips to the caller's stack between hands, optionally backed by a
+// FundsLocked transaction
+func (h *Handler) HandleRebuy(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Amount int    `json:"amount"`
+		TxHash string `json:"tx_hash"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if !validAmount(w, "amount", req.Amount) {
+		return
+	}
+
+	stack, err := h.game.Rebuy(clientID, req.Amount, req.TxHash)
+	if err != nil {
+		apiError(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	minBuyIn, maxBuyIn := h.game.BuyInLimits()
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"success":    true,
+		"amount":     req.Amount,
+		"stack":      stack,
+		"min_buy_in": minBuyIn,
+		"max_buy_in": maxBuyIn,
+	})
+}
@@ -33,6 +33,14 @@ func WriteError(w http.ResponseWriter, status int, err error) {
 	})
 }
 
+// WriteErrorCode writes an error response with a machine-readable code
+func WriteErrorCode(w http.ResponseWriter, status int, code, message string) {
+	WriteJSON(w, status, ErrorResponse{
+		Error: message,
+		Code:  code,
+	})
+}
+
 // WriteSuccess writes a success response
 func WriteSuccess(w http.ResponseWriter, message string, data interface{}) {
 	WriteJSON(w, http.StatusOK, SuccessResponse{
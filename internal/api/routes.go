@@ -13,26 +13,179 @@ func (h *Handler) Routes() http.Handler {
 	r.Use(CORSMiddleware)
 	r.Use(LoggingMiddleware)
 	r.Use(RecoveryMiddleware)
+	r.Use(ValidationMiddleware)
+	r.Use(RateLimitMiddleware(h.actionLimit, h.readLimit))
 
+	versions := make(map[string]*mux.Router, len(apiVersions))
+	for _, v := range apiVersions {
+		sub := r.PathPrefix("/api/" + v.Name).Subrouter()
+		sub.Use(versionMiddleware(v))
+		h.versionRoutes(v.Name, sub)
+		versions[v.Name] = sub
+	}
+
+	// Orchestrator probes, unversioned like any health check
+	r.HandleFunc("/healthz", h.HandleLiveness).Methods("GET", "OPTIONS")
+	r.HandleFunc("/readyz", h.HandleReadiness).Methods("GET", "OPTIONS")
+
+	// Runtime profiler, when enabled
+	h.profilingRoutes(r)
+
+	// GraphQL evolves through its schema rather than API versions
+	r.HandleFunc("/graphql", h.HandleGraphQL).Methods("GET", "POST", "OPTIONS")
+
+	// Unversioned paths predate versioning; they are served by the version
+	// the API-Version header asks for, or the default one
+	r.PathPrefix("/api/").Handler(unversionedAPI(versions))
+
+	return r
+}
+
+// routesV1 registers the v1 API; paths are relative to /api/v1
+func (h *Handler) routesV1(r *mux.Router) {
 	// Health check
-	r.HandleFunc("/api/health", h.HandleHealth).Methods("GET", "OPTIONS")
+	r.HandleFunc("/health", h.HandleHealth).Methods("GET", "OPTIONS")
+
+	// API description
+	r.HandleFunc("/openapi.json", h.HandleOpenAPI).Methods("GET", "OPTIONS")
+	r.HandleFunc("/docs", h.HandleSwaggerUI).Methods("GET", "OPTIONS")
+
+	// Bootstrap for the embedded web client
+	r.HandleFunc("/client-config", h.HandleGetClientConfig).Methods("GET", "OPTIONS")
 
 	// Game state endpoints
-	r.HandleFunc("/api/table", h.HandleGetTable).Methods("GET", "OPTIONS")
-	r.HandleFunc("/api/players", h.HandleGetPlayers).Methods("GET", "OPTIONS")
+	r.HandleFunc("/table", h.HandleGetTable).Methods("GET", "OPTIONS")
+	r.HandleFunc("/players", h.HandleGetPlayers).Methods("GET", "OPTIONS")
+	r.HandleFunc("/seat-draw", h.HandleGetSeatDraw).Methods("GET", "OPTIONS")
+	r.HandleFunc("/rake", h.HandleGetRake).Methods("GET", "OPTIONS")
+	r.HandleFunc("/session", h.HandleGetSession).Methods("GET", "OPTIONS")
+	r.HandleFunc("/players/{id}/session", h.HandleGetPlayerSession).Methods("GET", "OPTIONS")
+	r.HandleFunc("/events", h.HandleEvents).Methods("GET", "OPTIONS")
+	r.HandleFunc("/spectate/{table}", h.HandleSpectate).Methods("GET", "OPTIONS")
+
+	// Hand history
+	r.HandleFunc("/hands", h.HandleGetHands).Methods("GET", "OPTIONS")
+	r.HandleFunc("/hands/{id}", h.HandleGetHand).Methods("GET", "OPTIONS")
+	r.HandleFunc("/hands/{id}/actions", h.HandleGetHandActions).Methods("GET", "OPTIONS")
+	r.HandleFunc("/hands/{id}/proof", h.HandleGetHandProof).Methods("GET", "OPTIONS")
+	r.HandleFunc("/hands/{id}/shuffle-audit", h.HandleGetShuffleAudit).Methods("GET", "OPTIONS")
+	r.HandleFunc("/hands/{id}/replay", h.HandleGetHandReplay).Methods("GET", "OPTIONS")
+	r.HandleFunc("/players/{id}/hands", h.HandleGetPlayerHands).Methods("GET", "OPTIONS")
+
+	// Odds for training UIs and bots
+	r.HandleFunc("/equity", h.HandleGetEquity).Methods("GET", "OPTIONS")
 
 	// Player actions
-	r.HandleFunc("/api/ready", h.HandlePlayerReady).Methods("POST", "OPTIONS")
-	r.HandleFunc("/api/fold", h.HandleFold).Methods("POST", "OPTIONS")
-	r.HandleFunc("/api/check", h.HandleCheck).Methods("POST", "OPTIONS")
-	r.HandleFunc("/api/call", h.HandleCall).Methods("POST", "OPTIONS")
-	r.HandleFunc("/api/bet", h.HandleBet).Methods("POST", "OPTIONS")
-	r.HandleFunc("/api/raise", h.HandleRaise).Methods("POST", "OPTIONS")
-	r.HandleFunc("/api/action", h.HandlePlayerAction).Methods("POST", "OPTIONS")
+	r.HandleFunc("/ready", h.HandlePlayerReady).Methods("POST", "OPTIONS")
+	r.HandleFunc("/fold", h.HandleFold).Methods("POST", "OPTIONS")
+	r.HandleFunc("/check", h.HandleCheck).Methods("POST", "OPTIONS")
+	r.HandleFunc("/call", h.HandleCall).Methods("POST", "OPTIONS")
+	r.HandleFunc("/bet", h.HandleBet).Methods("POST", "OPTIONS")
+	r.HandleFunc("/raise", h.HandleRaise).Methods("POST", "OPTIONS")
+	r.HandleFunc("/all-in", h.HandleAllIn).Methods("POST", "OPTIONS")
+	r.HandleFunc("/show", h.HandleShow).Methods("POST", "OPTIONS")
+	r.HandleFunc("/muck", h.HandleMuck).Methods("POST", "OPTIONS")
+	r.HandleFunc("/action", h.HandlePlayerAction).Methods("POST", "OPTIONS")
+	r.HandleFunc("/straddle", h.HandleGetStraddle).Methods("GET", "OPTIONS")
+	r.HandleFunc("/straddle", h.HandleSetStraddle).Methods("POST", "OPTIONS")
+	r.HandleFunc("/run-it-twice", h.HandleGetRunItTwice).Methods("GET", "OPTIONS")
+	r.HandleFunc("/run-it-twice", h.HandleRunItTwice).Methods("POST", "OPTIONS")
+	r.HandleFunc("/rabbit-hunt", h.HandleGetRabbitHunt).Methods("GET", "OPTIONS")
+	r.HandleFunc("/sit-out", h.HandleGetSitOut).Methods("GET", "OPTIONS")
+	r.HandleFunc("/sit-out", h.HandleSitOut).Methods("POST", "OPTIONS")
+
+	// Table chat
+	r.HandleFunc("/chat", h.HandleGetChat).Methods("GET", "OPTIONS")
+	r.HandleFunc("/chat", h.HandlePostChat).Methods("POST", "OPTIONS")
+	r.HandleFunc("/chat/mute", h.HandleMuteChat).Methods("POST", "OPTIONS")
+	r.HandleFunc("/chat/mute/{id}", h.HandleUnmuteChat).Methods("DELETE", "OPTIONS")
+
+	// Unanimous abort with refund
+	r.HandleFunc("/abort", h.HandleGetAbortVote).Methods("GET", "OPTIONS")
+	r.HandleFunc("/abort", h.HandleVoteAbort).Methods("POST", "OPTIONS")
+
+	// Pausing the table by majority vote
+	r.HandleFunc("/pause", h.HandleGetPause).Methods("GET", "OPTIONS")
+	r.HandleFunc("/pause", h.HandleVotePause).Methods("POST", "OPTIONS")
+
+	// The table host's powers, and votes to use them while the host is away
+	r.HandleFunc("/host", h.HandleGetHost).Methods("GET", "OPTIONS")
+	r.HandleFunc("/host/actions", h.HandleHostAction).Methods("POST", "OPTIONS")
+	r.HandleFunc("/host/vote", h.HandleVoteHostAction).Methods("POST", "OPTIONS")
+
+	// On-chain escrow
+	r.HandleFunc("/escrow/{player}", h.HandleGetEscrow).Methods("GET", "OPTIONS")
+	r.HandleFunc("/channel", h.HandleGetChannel).Methods("GET", "OPTIONS")
 
 	// Peer management
-	r.HandleFunc("/api/peers", h.HandleGetPeers).Methods("GET", "OPTIONS")
-	r.HandleFunc("/api/peers/connect", h.HandleConnectPeer).Methods("POST", "OPTIONS")
+	r.HandleFunc("/peers", h.HandleGetPeers).Methods("GET", "OPTIONS")
+	r.HandleFunc("/peers/connect", h.HandleConnectPeer).Methods("POST", "OPTIONS")
 
-	return r
+	// Seat selection
+	r.HandleFunc("/seats", h.HandleGetSeats).Methods("GET", "OPTIONS")
+	r.HandleFunc("/seat", h.HandleTakeSeat).Methods("POST", "OPTIONS")
+	r.HandleFunc("/seat", h.HandleLeaveSeat).Methods("DELETE", "OPTIONS")
+
+	// Rebuys and add-ons between hands
+	r.HandleFunc("/rebuy", h.HandleRebuy).Methods("POST", "OPTIONS")
+
+	// Lobby and matchmaking
+	r.HandleFunc("/lobby", h.HandleGetLobby).Methods("GET", "OPTIONS")
+	r.HandleFunc("/lobby/quick-seat", h.HandleQuickSeat).Methods("POST", "OPTIONS")
+	r.HandleFunc("/lobby/tables/{id}/waitlist", h.HandleGetWaitlist).Methods("GET", "OPTIONS")
+	r.HandleFunc("/lobby/tables/{id}/waitlist", h.HandleJoinWaitlist).Methods("POST", "OPTIONS")
+	r.HandleFunc("/lobby/tables/{id}/waitlist", h.HandleLeaveWaitlist).Methods("DELETE", "OPTIONS")
+
+	// Presence
+	r.HandleFunc("/presence", h.HandleGetPresence).Methods("GET", "OPTIONS")
+	r.HandleFunc("/presence/away", h.HandleSetAway).Methods("POST", "OPTIONS")
+	r.HandleFunc("/presence/{id}", h.HandleGetPlayerPresence).Methods("GET", "OPTIONS")
+
+	// This node's player profile
+	r.HandleFunc("/profile", h.HandleGetProfile).Methods("GET", "OPTIONS")
+	r.HandleFunc("/profile", h.HandlePutProfile).Methods("PUT", "OPTIONS")
+
+	// Friends and table invitations
+	r.HandleFunc("/friends", h.HandleGetFriends).Methods("GET", "OPTIONS")
+	r.HandleFunc("/friends/requests", h.HandleSendFriendRequest).Methods("POST", "OPTIONS")
+	r.HandleFunc("/friends/requests/{id}/accept", h.HandleAcceptFriendRequest).Methods("POST", "OPTIONS")
+	r.HandleFunc("/friends/requests/{id}/decline", h.HandleDeclineFriendRequest).Methods("POST", "OPTIONS")
+	r.HandleFunc("/friends/privacy", h.HandleSetInvitePolicy).Methods("PUT", "OPTIONS")
+	r.HandleFunc("/friends/{id}", h.HandleRemoveFriend).Methods("DELETE", "OPTIONS")
+	r.HandleFunc("/invitations", h.HandleGetInvitations).Methods("GET", "OPTIONS")
+	r.HandleFunc("/invitations", h.HandleInvite).Methods("POST", "OPTIONS")
+	r.HandleFunc("/invitations/{id}/dismiss", h.HandleDismissInvitation).Methods("POST", "OPTIONS")
+
+	// Audit log of privileged operations, for operators
+	audit := r.PathPrefix("/audit").Subrouter()
+	audit.Use(AdminAuthMiddleware(h.adminToken))
+	audit.HandleFunc("", h.HandleGetAudit).Methods("GET", "OPTIONS")
+	audit.HandleFunc("/verify", h.HandleVerifyAudit).Methods("GET", "OPTIONS")
+
+	// Admin / operator endpoints
+	admin := r.PathPrefix("/admin").Subrouter()
+	admin.Use(AdminAuthMiddleware(h.adminToken))
+	admin.HandleFunc("/bot-scores", h.HandleGetBotScores).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/collusion", h.HandleGetCollusionAlerts).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/clients", h.HandleAdminClients).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/evaluator", h.HandleGetEvaluator).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/config/reload", h.HandleAdminReloadConfig).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/tables", h.HandleAdminListTables).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/tables/{id}", h.HandleAdminDumpTable).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/tables/{id}/kick", h.HandleAdminKick).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/tables/{id}/advance", h.HandleAdminForceAdvance).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/tables/{id}/pause", h.HandleAdminPause).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/tables/{id}/resume", h.HandleAdminResume).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/tables/{id}/unfreeze", h.HandleAdminUnfreeze).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/tables/{id}/disconnects", h.HandleAdminDisconnects).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/tables/{id}/latency", h.HandleAdminLatency).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/tables/{id}/snapshot", h.HandleAdminSnapshot).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/tables/{id}/settlements/retry", h.HandleAdminRetrySettlements).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/tables/{id}/settlements/settle", h.HandleAdminSettleBatch).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/tables/{id}/channel/close", h.HandleAdminCloseChannel).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/ledger/withdrawals", h.HandleLedgerWithdrawal).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/backups", h.HandleAdminBackups).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/insurance", h.HandleGetInsurance).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/insurance/deposits", h.HandleInsuranceDeposit).Methods("POST", "OPTIONS")
+	admin.HandleFunc("/insurance/payouts", h.HandleInsurancePayout).Methods("POST", "OPTIONS")
 }
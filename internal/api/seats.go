@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+)
+
+// List every seat with its occupant or reservation
+func (h *Handler) HandleGetSeats(w http.ResponseWriter, r *http.Request) {
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"seats":               h.game.Seats(),
+		"reservation_seconds": int(h.game.SeatHold().Seconds()),
+	})
+}
+
+// Reserve a seat, or sit down in it
+func (h *Handler) HandleTakeSeat(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Seat    int  `json:"seat"`
+		Reserve bool `json:"reserve"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.Reserve {
+		expiresAt, err := h.game.ReserveSeat(clientID, req.Seat)
+		if err != nil {
+			apiError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		JSON(w, http.StatusOK, map[string]interface{}{
+			"status":         "reserved",
+			"seat":           req.Seat,
+			"reserved_until": expiresAt,
+		})
+		return
+	}
+
+	if err := h.game.TakeSeat(clientID, req.Seat); err != nil {
+		apiError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"status": "seated",
+		"seat":   req.Seat,
+	})
+}
+
+// Give up the caller's seat or reservation
+func (h *Handler) HandleLeaveSeat(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := requireClientID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.game.LeaveSeat(clientID); err != nil {
+		apiError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]string{"status": "left"})
+}
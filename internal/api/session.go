@@ -0,0 +1,24 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Get every player's session accounting: buy-in, rebuys and net result
+func (h *Handler) HandleGetSession(w http.ResponseWriter, r *http.Request) {
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"players": h.game.SessionAccounts(),
+	})
+}
+
+// Get one player's session accounting
+func (h *Handler) HandleGetPlayerSession(w http.ResponseWriter, r *http.Request) {
+	account, ok := h.game.SessionAccount(mux.Vars(r)["id"])
+	if !ok {
+		apiError(w, "Player not found", http.StatusNotFound)
+		return
+	}
+	JSON(w, http.StatusOK, account)
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Get what a spectator needs to follow a table: public state and stream URLs
+func (h *Handler) HandleSpectate(w http.ResponseWriter, r *http.Request) {
+	tableID := mux.Vars(r)["table"]
+	if tableID != h.tableID {
+		apiError(w, "Table not found", http.StatusNotFound)
+		return
+	}
+
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"table_id":   h.tableID,
+		"state":      h.game.PublicState(),
+		"spectators": h.hub.SpectatorCount(),
+		"ws_url":     h.publicWSURL + "/ws/spectate",
+		"events_url": "/api/v1/events",
+	})
+}
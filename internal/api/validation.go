@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+)
+
+// maxRequestBodyBytes caps request bodies; no endpoint takes more than a
+// few hundred bytes
+const maxRequestBodyBytes = 64 << 10
+
+// Error codes returned in ErrorResponse.Code
+const (
+	ErrCodeInvalidRequest   = "INVALID_REQUEST"
+	ErrCodeInvalidJSON      = "INVALID_JSON"
+	ErrCodeBodyTooLarge     = "BODY_TOO_LARGE"
+	ErrCodeClientIDRequired = "CLIENT_ID_REQUIRED"
+	ErrCodeInvalidClientID  = "INVALID_CLIENT_ID"
+	ErrCodeInvalidAmount    = "INVALID_AMOUNT"
+	ErrCodeActionRejected   = "ACTION_REJECTED"
+	ErrCodeUnauthorized     = "UNAUTHORIZED"
+	ErrCodeForbidden        = "FORBIDDEN"
+	ErrCodeNotFound         = "NOT_FOUND"
+	ErrCodeConflict         = "CONFLICT"
+	ErrCodeRateLimited      = "RATE_LIMITED"
+	ErrCodeUnavailable      = "UNAVAILABLE"
+	ErrCodeInternal         = "INTERNAL_ERROR"
+
+	ErrCodeUnsupportedVersion = "UNSUPPORTED_API_VERSION"
+)
+
+// statusErrorCodes gives the code for errors that have no more specific one
+var statusErrorCodes = map[int]string{
+	http.StatusBadRequest:            ErrCodeInvalidRequest,
+	http.StatusUnauthorized:          ErrCodeUnauthorized,
+	http.StatusForbidden:             ErrCodeForbidden,
+	http.StatusNotFound:              ErrCodeNotFound,
+	http.StatusConflict:              ErrCodeConflict,
+	http.StatusRequestEntityTooLarge: ErrCodeBodyTooLarge,
+	http.StatusTooManyRequests:       ErrCodeRateLimited,
+	http.StatusServiceUnavailable:    ErrCodeUnavailable,
+	http.StatusInternalServerError:   ErrCodeInternal,
+}
+
+// ValidationMiddleware caps request bodies and rejects malformed client IDs
+// before any handler sees them
+func ValidationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if clientID := r.Header.Get("X-Client-ID"); clientID != "" {
+			if err := protocol.ValidatePlayerID(clientID); err != nil {
+				WriteErrorCode(w, http.StatusBadRequest, ErrCodeInvalidClientID, err.Error())
+				return
+			}
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// apiError writes an ErrorResponse with the code for status; it takes the
+// same arguments as http.Error
+func apiError(w http.ResponseWriter, message string, status int) {
+	code, ok := statusErrorCodes[status]
+	if !ok {
+		code = ErrCodeInvalidRequest
+	}
+	WriteErrorCode(w, status, code, message)
+}
+
+// requireClientID returns the caller's X-Client-ID, answering 400 when it
+// is missing. ValidationMiddleware has already checked its format.
+func requireClientID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	clientID := r.Header.Get("X-Client-ID")
+	if clientID == "" {
+		WriteErrorCode(w, http.StatusBadRequest, ErrCodeClientIDRequired, "Client ID required")
+		return "", false
+	}
+	return clientID, true
+}
+
+// decodeJSON strictly decodes a request body into v: unknown fields,
+// trailing data and oversized bodies are rejected
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(v)
+	if err == nil && decoder.Decode(&struct{}{}) != io.EOF {
+		err = fmt.Errorf("request body must hold a single JSON object")
+	}
+	if err == nil {
+		return true
+	}
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		WriteErrorCode(w, http.StatusRequestEntityTooLarge, ErrCodeBodyTooLarge,
+			fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit))
+		return false
+	}
+	WriteJSON(w, http.StatusBadRequest, ErrorResponse{
+		Error:   "Invalid request body",
+		Code:    ErrCodeInvalidJSON,
+		Details: err.Error(),
+	})
+	return false
+}
+
+// validAmount checks a chip amount from a request, answering 400 when it
+// is negative or too large
+func validAmount(w http.ResponseWriter, field string, amount int) bool {
+	if err := protocol.ValidateChipAmount(amount); err != nil {
+		WriteErrorCode(w, http.StatusBadRequest, ErrCodeInvalidAmount, fmt.Sprintf("%s: %v", field, err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// APIVersionHeader reports the API version that served a response. A
+// client may send it to pick the version behind unversioned paths; on a
+// versioned path it must agree with the path.
+const APIVersionHeader = "API-Version"
+
+// apiVersion is one version of the HTTP API, served under /api/<Name>.
+// Breaking changes to response shapes go in a new version, so clients
+// pinned to an old one keep working until its sunset.
+type apiVersion struct {
+	Name string
+
+	// A deprecated version still works but says so in every response,
+	// along with when it stops being served if that is decided
+	Deprecated bool
+	Sunset     time.Time
+}
+
+var apiVersions = []apiVersion{
+	{Name: "v1"},
+}
+
+// versionRoutes registers a version's endpoints
+func (h *Handler) versionRoutes(version string, r *mux.Router) {
+	switch version {
+	case "v1":
+		h.routesV1(r)
+	}
+}
+
+// defaultAPIVersion serves unversioned paths that do not ask for one
+const defaultAPIVersion = "v1"
+
+// latestAPIVersion is the version deprecation notices point clients to
+func latestAPIVersion() string {
+	return apiVersions[len(apiVersions)-1].Name
+}
+
+// normalizeVersion accepts "1" as well as "v1"
+func normalizeVersion(v string) string {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if v != "" && !strings.HasPrefix(v, "v") {
+		v = "v" + v
+	}
+	return v
+}
+
+// versionMiddleware tags responses with the version serving them and
+// warns when that version is deprecated
+func versionMiddleware(v apiVersion) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if asked := r.Header.Get(APIVersionHeader); asked != "" && normalizeVersion(asked) != v.Name {
+				WriteErrorCode(w, http.StatusBadRequest, ErrCodeUnsupportedVersion,
+					fmt.Sprintf("%s header asks for %s, but the path is for %s", APIVersionHeader, asked, v.Name))
+				return
+			}
+
+			w.Header().Set(APIVersionHeader, v.Name)
+			if v.Deprecated {
+				w.Header().Set("Deprecation", "true")
+				if !v.Sunset.IsZero() {
+					w.Header().Set("Sunset", v.Sunset.UTC().Format(http.TimeFormat))
+				}
+				latest := latestAPIVersion()
+				successor := "/api/" + latest + strings.TrimPrefix(r.URL.Path, "/api/"+v.Name)
+				w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+				w.Header().Add("Warning", fmt.Sprintf(`299 - "API %s is deprecated; use %s"`, v.Name, latest))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// unversionedAPI serves /api/<path> as /api/<version>/<path>, marking the
+// response deprecated so clients move to versioned paths before the
+// default version changes under them
+func unversionedAPI(versions map[string]*mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.TrimPrefix(r.URL.Path, "/api")
+		first, _, _ := strings.Cut(strings.TrimPrefix(rest, "/"), "/")
+		if _, versioned := versions[first]; versioned {
+			// A versioned path that no route matched
+			apiError(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		version := defaultAPIVersion
+		if asked := r.Header.Get(APIVersionHeader); asked != "" {
+			version = normalizeVersion(asked)
+		}
+		router, ok := versions[version]
+		if !ok {
+			supported := make([]string, len(apiVersions))
+			for i, v := range apiVersions {
+				supported[i] = v.Name
+			}
+			WriteErrorCode(w, http.StatusBadRequest, ErrCodeUnsupportedVersion,
+				fmt.Sprintf("Unsupported API version %q; supported: %s", r.Header.Get(APIVersionHeader), strings.Join(supported, ", ")))
+			return
+		}
+
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", fmt.Sprintf(`</api/%s%s>; rel="successor-version"`, version, rest))
+		w.Header().Add("Warning", fmt.Sprintf(`299 - "Unversioned API paths are deprecated; use /api/%s%s"`, version, rest))
+
+		versioned := r.Clone(r.Context())
+		versioned.URL.Path = "/api/" + version + rest
+		if r.URL.RawPath != "" {
+			versioned.URL.RawPath = "/api/" + version + strings.TrimPrefix(r.URL.RawPath, "/api")
+		}
+		router.ServeHTTP(w, versioned)
+	})
+}
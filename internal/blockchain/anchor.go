@@ -0,0 +1,19 @@
+package blockchain
+
+import (
+	"fmt"
+)
+
+// AnchorHand would record a hand's commitment hash on-chain, keyed by the
+// hand's unique ID, so the hand history could later be proven unchanged.
+// PokerTable.sol has no anchor storage yet, so it returns ErrNotSupported.
+func (bc *BlockchainClient) AnchorHand(handUID string, commitment [32]byte) (*TxReceipt, error) {
+	return nil, fmt.Errorf("anchoring hand %s: %w", handUID, ErrNotSupported)
+}
+
+// HandAnchor would return the commitment anchored for a hand. Without
+// anchor storage in the contracts it returns ErrNotSupported rather than an
+// empty anchor.
+func (bc *BlockchainClient) HandAnchor(handUID string) ([32]byte, error) {
+	return [32]byte{}, fmt.Errorf("reading anchor for hand %s: %w", handUID, ErrNotSupported)
+}
@@ -0,0 +1,53 @@
+package blockchain
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/sirupsen/logrus"
+)
+
+// SettlementDigest is what players sign to attest a payout: the keccak256 of
+// the game ID followed by each winner's address and 32-byte amount, packed
+// the way the contract rebuilds it
+func SettlementDigest(gameID [32]byte, winners []common.Address, amounts []*big.Int) []byte {
+	data := make([]byte, 0, 32+len(winners)*(common.AddressLength+32))
+	data = append(data, gameID[:]...)
+	for i, winner := range winners {
+		data = append(data, winner.Bytes()...)
+		data = append(data, common.LeftPadBytes(amounts[i].Bytes(), 32)...)
+	}
+	return crypto.Keccak256(data)
+}
+
+// EndGameWithAttestations ends the game like EndGame, passing the players'
+// signatures over SettlementDigest so the contract pays out only what every
+// player agreed to. The signatures are checked here, but the contracts have
+// no function to take them, so it returns ErrNotSupported.
+func (bc *BlockchainClient) EndGameWithAttestations(gameID [32]byte, winners []common.Address, amounts []*big.Int, signers []common.Address, signatures [][]byte) (*TxReceipt, error) {
+	bc.log().WithFields(logrus.Fields{
+		"game_id":      fmt.Sprintf("0x%x", gameID),
+		"winners":      len(winners),
+		"total_payout": sumAmounts(amounts).String(),
+		"attestations": len(signatures),
+	}).Info("Ending game on blockchain with attestations")
+
+	if len(winners) != len(amounts) {
+		return nil, fmt.Errorf("winners and amounts length mismatch")
+	}
+	if len(signers) != len(signatures) {
+		return nil, fmt.Errorf("signers and signatures length mismatch")
+	}
+
+	digest := SettlementDigest(gameID, winners, amounts)
+	for i, signer := range signers {
+		if !VerifySignature(digest, signatures[i], signer) {
+			return nil, fmt.Errorf("attestation from %s does not match the payout", signer.Hex())
+		}
+	}
+
+	// PokerTable.sol has no endGame that checks signatures yet
+	return nil, fmt.Errorf("attested settlement: %w", ErrNotSupported)
+}
@@ -0,0 +1,102 @@
+package blockchain
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultChallengeWindow is how long a submitted channel state can be
+// challenged when the dispute contract does not say
+const DefaultChallengeWindow = time.Hour
+
+// ChannelSubmission is the channel state the dispute contract holds for a game
+type ChannelSubmission struct {
+	Seq       uint64
+	Submitter common.Address
+	Deadline  time.Time // end of the challenge window
+	Finalized bool
+}
+
+// ChannelStateDigest is what players sign to agree the stacks after a hand:
+// the keccak256 of the game ID, the 8-byte sequence number, then each
+// player's address and 32-byte stack, packed the way the contract rebuilds it
+func ChannelStateDigest(gameID [32]byte, seq uint64, players []common.Address, stacks []*big.Int) []byte {
+	data := make([]byte, 0, 40+len(players)*(common.AddressLength+32))
+	data = append(data, gameID[:]...)
+	var seqBytes [8]byte
+	binary.BigEndian.PutUint64(seqBytes[:], seq)
+	data = append(data, seqBytes[:]...)
+	for i, player := range players {
+		data = append(data, player.Bytes()...)
+		data = append(data, common.LeftPadBytes(stacks[i].Bytes(), 32)...)
+	}
+	return crypto.Keccak256(data)
+}
+
+func checkChannelState(gameID [32]byte, seq uint64, players []common.Address, stacks []*big.Int, signers []common.Address, signatures [][]byte) error {
+	if len(players) != len(stacks) {
+		return fmt.Errorf("players and stacks length mismatch")
+	}
+	if len(signers) != len(signatures) {
+		return fmt.Errorf("signers and signatures length mismatch")
+	}
+	digest := ChannelStateDigest(gameID, seq, players, stacks)
+	for i, signer := range signers {
+		if !VerifySignature(digest, signatures[i], signer) {
+			return fmt.Errorf("signature from %s does not match channel state %d", signer.Hex(), seq)
+		}
+	}
+	return nil
+}
+
+// SubmitChannelState would hand a signed channel state to the dispute
+// contract, opening a challenge window in which a higher-sequence state can
+// replace it. The contracts have no dispute resolver yet, so after checking
+// the signatures it returns ErrNotSupported.
+func (bc *BlockchainClient) SubmitChannelState(gameID [32]byte, seq uint64, players []common.Address, stacks []*big.Int, signers []common.Address, signatures [][]byte) (*TxReceipt, error) {
+	bc.log().WithFields(logrus.Fields{
+		"game_id":    fmt.Sprintf("0x%x", gameID),
+		"seq":        seq,
+		"players":    len(players),
+		"signatures": len(signatures),
+	}).Info("Submitting channel state to dispute contract")
+
+	if err := checkChannelState(gameID, seq, players, stacks, signers, signatures); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("submitting channel state %d: %w", seq, ErrNotSupported)
+}
+
+// ChallengeChannelState would replace a submitted channel state with a
+// higher-sequence one while the challenge window is open. Like
+// SubmitChannelState it returns ErrNotSupported once the state checks out.
+func (bc *BlockchainClient) ChallengeChannelState(gameID [32]byte, seq uint64, players []common.Address, stacks []*big.Int, signers []common.Address, signatures [][]byte) (*TxReceipt, error) {
+	bc.log().WithFields(logrus.Fields{
+		"game_id": fmt.Sprintf("0x%x", gameID),
+		"seq":     seq,
+	}).Info("Challenging channel state in dispute contract")
+
+	if err := checkChannelState(gameID, seq, players, stacks, signers, signatures); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("challenging channel state %d: %w", seq, ErrNotSupported)
+}
+
+// FinalizeChannelState would pay out the submitted channel state once its
+// challenge window has closed. It returns ErrNotSupported.
+func (bc *BlockchainClient) FinalizeChannelState(gameID [32]byte) (*TxReceipt, error) {
+	return nil, fmt.Errorf("finalizing channel state: %w", ErrNotSupported)
+}
+
+// GetChannelSubmission would return the channel state submitted for a game.
+// With no dispute contract to read it returns ErrNotSupported rather than
+// reporting that nothing was submitted.
+func (bc *BlockchainClient) GetChannelSubmission(gameID [32]byte) (*ChannelSubmission, error) {
+	return nil, fmt.Errorf("reading channel submission: %w", ErrNotSupported)
+}
@@ -27,6 +27,9 @@ type BlockchainClient struct {
 	potManager      *PotManager
 	playerRegistry  *PlayerRegistry
 	disputeResolver *DisputeResolver
+
+	// Carries the caller's correlation fields (see WithLogger)
+	logger *logrus.Entry
 }
 
 type Config struct {
@@ -38,6 +41,24 @@ type Config struct {
 	DisputeResolverAddress  string
 }
 
+// WithLogger returns a client logging through entry, e.g. a table's logger
+// tagged with its hand. It shares the connection and keys with bc.
+func (bc *BlockchainClient) WithLogger(entry *logrus.Entry) *BlockchainClient {
+	if bc == nil {
+		return nil
+	}
+	scoped := *bc
+	scoped.logger = entry
+	return &scoped
+}
+
+func (bc *BlockchainClient) log() *logrus.Entry {
+	if bc.logger == nil {
+		return logrus.NewEntry(logrus.StandardLogger())
+	}
+	return bc.logger
+}
+
 func NewBlockchainClient(cfg *Config) (*BlockchainClient, error) {
 	client, err := ethclient.Dial(cfg.RPCURL)
 	if err != nil {
@@ -29,11 +29,11 @@ type GameInfo struct {
 	Status      uint8
 }
 
-// CreateGame creates a new poker game on-chain
-func (bc *BlockchainClient) CreateGame(buyIn, smallBlind, bigBlind *big.Int, maxPlayers uint8) ([32]byte, error) {
+// CreateGame creates a new poker game on-chain. The receipt is nil until bindings are generated.
+func (bc *BlockchainClient) CreateGame(buyIn, smallBlind, bigBlind *big.Int, maxPlayers uint8) ([32]byte, *TxReceipt, error) {
 	var gameID [32]byte
 
-	logrus.WithFields(logrus.Fields{
+	bc.log().WithFields(logrus.Fields{
 		"buy_in":      buyIn.String(),
 		"small_blind": smallBlind.String(),
 		"big_blind":   bigBlind.String(),
@@ -42,18 +42,18 @@ func (bc *BlockchainClient) CreateGame(buyIn, smallBlind, bigBlind *big.Int, max
 
 	auth, err := bc.GetTransactor()
 	if err != nil {
-		return gameID, fmt.Errorf("failed to get transactor: %w", err)
+		return gameID, nil, fmt.Errorf("failed to get transactor: %w", err)
 	}
 
 	// Call contract (will work once bindings are generated)
 	// tx, err := bc.pokerTable.CreateGame(auth, buyIn, smallBlind, bigBlind, big.NewInt(int64(maxPlayers)))
 	// if err != nil {
-	//     return gameID, fmt.Errorf("failed to create game: %w", err)
+	//     return gameID, nil, fmt.Errorf("failed to create game: %w", err)
 	// }
 	//
 	// receipt, err := bind.WaitMined(context.Background(), bc.client, tx)
 	// if err != nil {
-	//     return gameID, fmt.Errorf("transaction failed: %w", err)
+	//     return gameID, nil, fmt.Errorf("transaction failed: %w", err)
 	// }
 	//
 	// // Parse GameCreated event
@@ -61,19 +61,19 @@ func (bc *BlockchainClient) CreateGame(buyIn, smallBlind, bigBlind *big.Int, max
 	//     event, err := bc.pokerTable.ParseGameCreated(*log)
 	//     if err == nil {
 	//         logrus.WithField("game_id", fmt.Sprintf("0x%x", event.GameId)).Info("Game created successfully")
-	//         return event.GameId, nil
+	//         return event.GameId, NewTxReceipt(receipt), nil
 	//     }
 	// }
 
-	logrus.Info("CreateGame called (bindings not generated yet)")
+	bc.log().Info("CreateGame called (bindings not generated yet)")
 	// Return mock game ID for testing without blockchain
 	gameID = GenerateGameID(bc.publicAddress, int64(1), buyIn)
-	return gameID, nil
+	return gameID, nil, nil
 }
 
 // JoinGame joins an existing game with buy-in
 func (bc *BlockchainClient) JoinGame(gameID [32]byte, buyInAmount *big.Int) error {
-	logrus.WithFields(logrus.Fields{
+	bc.log().WithFields(logrus.Fields{
 		"game_id": fmt.Sprintf("0x%x", gameID),
 		"buy_in":  buyInAmount.String(),
 	}).Info("Joining game on blockchain")
@@ -98,13 +98,13 @@ func (bc *BlockchainClient) JoinGame(gameID [32]byte, buyInAmount *big.Int) erro
 	//
 	// logrus.Info("Joined game successfully")
 
-	logrus.Info("JoinGame called (bindings not generated yet)")
+	bc.log().Info("JoinGame called (bindings not generated yet)")
 	return nil
 }
 
 // VerifyBuyIn verifies that a player has locked funds for the game
 func (bc *BlockchainClient) VerifyBuyIn(gameID [32]byte, playerAddr common.Address) (bool, error) {
-	logrus.WithFields(logrus.Fields{
+	bc.log().WithFields(logrus.Fields{
 		"game_id": fmt.Sprintf("0x%x", gameID),
 		"player":  playerAddr.Hex(),
 	}).Debug("Verifying buy-in")
@@ -119,72 +119,98 @@ func (bc *BlockchainClient) VerifyBuyIn(gameID [32]byte, playerAddr common.Addre
 	// return isInGame, nil
 
 	_ = callOpts // Suppress unused variable warning
-	logrus.Debug("VerifyBuyIn called (bindings not generated yet)")
+	bc.log().Debug("VerifyBuyIn called (bindings not generated yet)")
 	return true, nil // Placeholder - allows game to proceed without blockchain
 }
 
 // StartGame starts the game on-chain
-func (bc *BlockchainClient) StartGame(gameID [32]byte) error {
-	logrus.WithFields(logrus.Fields{
+func (bc *BlockchainClient) StartGame(gameID [32]byte) (*TxReceipt, error) {
+	bc.log().WithFields(logrus.Fields{
 		"game_id": fmt.Sprintf("0x%x", gameID),
 	}).Info("Starting game on blockchain")
 
 	auth, err := bc.GetTransactor()
 	if err != nil {
-		return fmt.Errorf("failed to get transactor: %w", err)
+		return nil, fmt.Errorf("failed to get transactor: %w", err)
 	}
 
 	// Call contract (will work once bindings are generated)
 	// tx, err := bc.pokerTable.StartGame(auth, gameID)
 	// if err != nil {
-	//     return fmt.Errorf("failed to start game: %w", err)
+	//     return nil, fmt.Errorf("failed to start game: %w", err)
 	// }
 	//
-	// _, err = bind.WaitMined(context.Background(), bc.client, tx)
+	// receipt, err := bind.WaitMined(context.Background(), bc.client, tx)
 	// if err != nil {
-	//     return fmt.Errorf("transaction failed: %w", err)
+	//     return nil, fmt.Errorf("transaction failed: %w", err)
 	// }
 	//
 	// logrus.Info("Game started successfully")
+	// return NewTxReceipt(receipt), nil
 
 	_ = auth // Suppress unused variable warning
-	logrus.Info("StartGame called (bindings not generated yet)")
-	return nil
+	bc.log().Info("StartGame called (bindings not generated yet)")
+	return nil, nil
 }
 
 // EndGame ends the game and distributes winnings
-func (bc *BlockchainClient) EndGame(gameID [32]byte, winners []common.Address, amounts []*big.Int) error {
-	logrus.WithFields(logrus.Fields{
+func (bc *BlockchainClient) EndGame(gameID [32]byte, winners []common.Address, amounts []*big.Int) (*TxReceipt, error) {
+	bc.log().WithFields(logrus.Fields{
 		"game_id":      fmt.Sprintf("0x%x", gameID),
 		"winners":      len(winners),
 		"total_payout": sumAmounts(amounts).String(),
 	}).Info("Ending game on blockchain")
 
 	if len(winners) != len(amounts) {
-		return fmt.Errorf("winners and amounts length mismatch")
+		return nil, fmt.Errorf("winners and amounts length mismatch")
 	}
 
 	auth, err := bc.GetTransactor()
 	if err != nil {
-		return fmt.Errorf("failed to get transactor: %w", err)
+		return nil, fmt.Errorf("failed to get transactor: %w", err)
 	}
 
 	// Call contract (will work once bindings are generated)
 	// tx, err := bc.pokerTable.EndGame(auth, gameID, winners, amounts)
 	// if err != nil {
-	//     return fmt.Errorf("failed to end game: %w", err)
+	//     return nil, fmt.Errorf("failed to end game: %w", err)
 	// }
 	//
 	// receipt, err := bind.WaitMined(context.Background(), bc.client, tx)
 	// if err != nil {
-	//     return fmt.Errorf("transaction failed: %w", err)
+	//     return nil, fmt.Errorf("transaction failed: %w", err)
 	// }
 	//
 	// logrus.WithField("tx_hash", receipt.TxHash.Hex()).Info("Game ended successfully")
+	// return NewTxReceipt(receipt), nil
 
 	_ = auth // Suppress unused variable warning
-	logrus.Info("EndGame called (bindings not generated yet)")
-	return nil
+	bc.log().Info("EndGame called (bindings not generated yet)")
+	return nil, nil
+}
+
+// SettleBatch settles several hands of a game in one transaction: each
+// player's net result across the hands is credited or debited from what they
+// have locked in the game. The contracts have no function for it yet, so
+// after checking the batch it returns ErrNotSupported.
+func (bc *BlockchainClient) SettleBatch(gameID [32]byte, players []common.Address, deltas []*big.Int) (*TxReceipt, error) {
+	bc.log().WithFields(logrus.Fields{
+		"game_id":   fmt.Sprintf("0x%x", gameID),
+		"players":   len(players),
+		"net_total": sumAmounts(deltas).String(),
+	}).Info("Settling batch on blockchain")
+
+	if len(players) != len(deltas) {
+		return nil, fmt.Errorf("players and deltas length mismatch")
+	}
+	// Chips only change hands; the total can fall short of zero by the rake
+	if sumAmounts(deltas).Sign() > 0 {
+		return nil, fmt.Errorf("batch pays out more than it collects")
+	}
+
+	// PokerTable.sol has no batch settlement yet; the batch is kept as a
+	// failed settlement rather than reported settled
+	return nil, fmt.Errorf("batch settlement: %w", ErrNotSupported)
 }
 
 // NEW: EndGameWithPenalty ends game with penalty applied to abandoned player
@@ -193,8 +219,8 @@ func (bc *BlockchainClient) EndGameWithPenalty(
 	abandonedPlayer common.Address,
 	winners []common.Address,
 	amounts []*big.Int,
-) error {
-	logrus.WithFields(logrus.Fields{
+) (*TxReceipt, error) {
+	bc.log().WithFields(logrus.Fields{
 		"game_id":          gameID,
 		"abandoned_player": abandonedPlayer.Hex(),
 		"winners":          len(winners),
@@ -203,11 +229,11 @@ func (bc *BlockchainClient) EndGameWithPenalty(
 
 	// Validate inputs
 	if len(winners) != len(amounts) {
-		return fmt.Errorf("winners and amounts length mismatch")
+		return nil, fmt.Errorf("winners and amounts length mismatch")
 	}
 
 	if len(winners) == 0 {
-		return fmt.Errorf("no winners specified")
+		return nil, fmt.Errorf("no winners specified")
 	}
 
 	// Convert gameID string to [32]byte
@@ -217,26 +243,26 @@ func (bc *BlockchainClient) EndGameWithPenalty(
 
 	auth, err := bc.GetTransactor()
 	if err != nil {
-		return fmt.Errorf("failed to get transactor: %w", err)
+		return nil, fmt.Errorf("failed to get transactor: %w", err)
 	}
 
 	// Log penalty details
-	logrus.Info("📝 Penalty transaction details:")
-	logrus.Infof("  - Game ID: %s", gameID)
-	logrus.Infof("  - Abandoned Player: %s", abandonedPlayer.Hex())
-	logrus.Infof("  - Number of Winners: %d", len(winners))
+	bc.log().Info("📝 Penalty transaction details:")
+	bc.log().Infof("  - Game ID: %s", gameID)
+	bc.log().Infof("  - Abandoned Player: %s", abandonedPlayer.Hex())
+	bc.log().Infof("  - Number of Winners: %d", len(winners))
 
 	totalPayout := big.NewInt(0)
 	for i, winner := range winners {
-		logrus.Infof("    Winner %d: %s -> %s wei", i+1, winner.Hex(), amounts[i].String())
+		bc.log().Infof("    Winner %d: %s -> %s wei", i+1, winner.Hex(), amounts[i].String())
 		totalPayout.Add(totalPayout, amounts[i])
 	}
-	logrus.Infof("  - Total Payout: %s wei", totalPayout.String())
+	bc.log().Infof("  - Total Payout: %s wei", totalPayout.String())
 
 	// Call contract (will work once bindings are generated)
 	// tx, err := bc.pokerTable.EndGameWithPenalty(auth, gameIDBytes, abandonedPlayer, winners, amounts)
 	// if err != nil {
-	//     return fmt.Errorf("failed to call endGameWithPenalty: %w", err)
+	//     return nil, fmt.Errorf("failed to call endGameWithPenalty: %w", err)
 	// }
 	//
 	// logrus.WithField("tx_hash", tx.Hash().Hex()).Info("Transaction submitted, waiting for confirmation...")
@@ -246,11 +272,11 @@ func (bc *BlockchainClient) EndGameWithPenalty(
 	//
 	// receipt, err := bind.WaitMined(ctx, bc.client, tx)
 	// if err != nil {
-	//     return fmt.Errorf("transaction failed: %w", err)
+	//     return nil, fmt.Errorf("transaction failed: %w", err)
 	// }
 	//
 	// if receipt.Status != 1 {
-	//     return fmt.Errorf("transaction reverted")
+	//     return nil, fmt.Errorf("transaction reverted")
 	// }
 	//
 	// logrus.WithFields(logrus.Fields{
@@ -258,13 +284,14 @@ func (bc *BlockchainClient) EndGameWithPenalty(
 	//     "gas_used": receipt.GasUsed,
 	//     "block":    receipt.BlockNumber.Uint64(),
 	// }).Info("✅ Penalty transaction confirmed")
+	// return NewTxReceipt(receipt), nil
 
 	// Simulate blockchain delay for testing
 	_ = auth // Suppress unused variable warning
 	time.Sleep(1 * time.Second)
-	logrus.Info("✅ EndGameWithPenalty called (bindings not generated yet)")
+	bc.log().Info("✅ EndGameWithPenalty called (bindings not generated yet)")
 
-	return nil
+	return nil, nil
 }
 
 // GetGameInfo retrieves game information from the blockchain
@@ -289,7 +316,7 @@ func (bc *BlockchainClient) GetGameInfo(gameID [32]byte) (*GameInfo, error) {
 	// }, nil
 
 	_ = callOpts // Suppress unused variable warning
-	logrus.Debug("GetGameInfo called (bindings not generated yet)")
+	bc.log().Debug("GetGameInfo called (bindings not generated yet)")
 
 	// Return mock data for testing
 	return &GameInfo{
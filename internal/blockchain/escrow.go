@@ -0,0 +1,231 @@
+package blockchain
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+)
+
+// EscrowLock is what a player has locked in one game
+type EscrowLock struct {
+	GameID      [32]byte
+	Amount      *big.Int
+	Locks       int // FundsLocked events behind the amount, more than one after rebuys
+	BlockNumber uint64
+	TxHash      common.Hash // the most recent lock
+}
+
+// EscrowStatus is a player's escrow: what is locked in each game and what
+// they can withdraw now
+type EscrowStatus struct {
+	Player      common.Address
+	Locked      []EscrowLock
+	TotalLocked *big.Int
+	Available   *big.Int
+}
+
+// FundsLockedCache keeps every FundsLocked event seen, so escrow queries
+// need not scan the chain's logs each time
+type FundsLockedCache struct {
+	mu        sync.RWMutex
+	locks     map[common.Address]map[[32]byte]*EscrowLock
+	seen      map[common.Hash]map[common.Address]bool
+	ended     map[[32]byte]bool
+	lastBlock uint64
+}
+
+// NewFundsLockedCache creates an empty cache
+func NewFundsLockedCache() *FundsLockedCache {
+	return &FundsLockedCache{
+		locks: make(map[common.Address]map[[32]byte]*EscrowLock),
+		seen:  make(map[common.Hash]map[common.Address]bool),
+		ended: make(map[[32]byte]bool),
+	}
+}
+
+// Add records a FundsLocked event, reporting false for one already recorded
+func (c *FundsLockedCache) Add(event *FundsLockedEvent) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.seen[event.TxHash][event.Player] {
+		return false
+	}
+	if c.seen[event.TxHash] == nil {
+		c.seen[event.TxHash] = make(map[common.Address]bool)
+	}
+	c.seen[event.TxHash][event.Player] = true
+	if c.ended[event.GameID] {
+		return true // read out of order; the funds are already released
+	}
+
+	if c.locks[event.Player] == nil {
+		c.locks[event.Player] = make(map[[32]byte]*EscrowLock)
+	}
+	lock, ok := c.locks[event.Player][event.GameID]
+	if !ok {
+		lock = &EscrowLock{GameID: event.GameID, Amount: big.NewInt(0)}
+		c.locks[event.Player][event.GameID] = lock
+	}
+	lock.Amount.Add(lock.Amount, event.Amount)
+	lock.Locks++
+	if event.BlockNumber >= lock.BlockNumber {
+		lock.BlockNumber = event.BlockNumber
+		lock.TxHash = event.TxHash
+	}
+	if event.BlockNumber > c.lastBlock {
+		c.lastBlock = event.BlockNumber
+	}
+	return true
+}
+
+// End records a GameEnded event, dropping the game's locks since the
+// PotManager released them. It reports false for a game already ended.
+func (c *FundsLockedCache) End(event *GameEndedEvent) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ended[event.GameID] {
+		return false
+	}
+	c.ended[event.GameID] = true
+	for _, games := range c.locks {
+		delete(games, event.GameID)
+	}
+	if event.BlockNumber > c.lastBlock {
+		c.lastBlock = event.BlockNumber
+	}
+	return true
+}
+
+// Locked returns the player's cached locks, one per game, most recent first
+func (c *FundsLockedCache) Locked(player common.Address) []EscrowLock {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	locks := make([]EscrowLock, 0, len(c.locks[player]))
+	for _, lock := range c.locks[player] {
+		copied := *lock
+		copied.Amount = new(big.Int).Set(lock.Amount)
+		locks = append(locks, copied)
+	}
+	sort.Slice(locks, func(i, j int) bool {
+		return locks[i].BlockNumber > locks[j].BlockNumber
+	})
+	return locks
+}
+
+// LastBlock returns the newest block a cached event came from
+func (c *FundsLockedCache) LastBlock() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lastBlock
+}
+
+// Load fills the cache with the FundsLocked events since fromBlock
+func (c *FundsLockedCache) Load(el *EventListener, fromBlock *big.Int) error {
+	events, err := el.GetFundsLockedEvents(fromBlock)
+	if err != nil {
+		return err
+	}
+	for i := range events {
+		c.Add(&events[i])
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"from_block": fromBlock.String(),
+		"events":     len(events),
+	}).Info("Loaded FundsLocked events into escrow cache")
+	return nil
+}
+
+// Watch adds FundsLocked events to the cache as the listener sees them,
+// until ctx is done
+func (c *FundsLockedCache) Watch(ctx context.Context, el *EventListener) {
+	ch := make(chan interface{}, 10)
+	el.Subscribe("FundsLocked", ch)
+
+	go func() {
+		for {
+			select {
+			case event := <-ch:
+				if fundsLocked, ok := event.(*FundsLockedEvent); ok {
+					c.Add(fundsLocked)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// LockedFunds returns what the PotManager holds for a player in one game,
+// or nil when it cannot say
+func (bc *BlockchainClient) LockedFunds(gameID [32]byte, player common.Address) (*big.Int, error) {
+	callOpts := bc.GetCallOpts()
+
+	// Call contract (will work once bindings are generated)
+	// amount, err := bc.potManager.LockedFunds(callOpts, gameID, player)
+	// if err != nil {
+	//     return nil, fmt.Errorf("failed to get locked funds: %w", err)
+	// }
+	// return amount, nil
+
+	_ = callOpts // Suppress unused variable warning
+	logrus.Debug("LockedFunds called (bindings not generated yet)")
+	return nil, nil
+}
+
+// AvailableBalance returns what a player can withdraw from the PotManager now
+func (bc *BlockchainClient) AvailableBalance(player common.Address) (*big.Int, error) {
+	callOpts := bc.GetCallOpts()
+
+	// Call contract (will work once bindings are generated)
+	// balance, err := bc.potManager.Balances(callOpts, player)
+	// if err != nil {
+	//     return nil, fmt.Errorf("failed to get available balance: %w", err)
+	// }
+	// return balance, nil
+
+	_ = callOpts // Suppress unused variable warning
+	logrus.Debug("AvailableBalance called (bindings not generated yet)")
+	return big.NewInt(0), nil
+}
+
+// EscrowStatus reads a player's escrow from the PotManager. The games to
+// ask about come from the cached FundsLocked events; where the contract
+// cannot report a game's locked amount, the cached total is used.
+func (bc *BlockchainClient) EscrowStatus(player common.Address, cache *FundsLockedCache) (*EscrowStatus, error) {
+	status := &EscrowStatus{
+		Player:      player,
+		Locked:      []EscrowLock{},
+		TotalLocked: big.NewInt(0),
+	}
+
+	if cache != nil {
+		for _, lock := range cache.Locked(player) {
+			amount, err := bc.LockedFunds(lock.GameID, player)
+			if err != nil {
+				return nil, err
+			}
+			if amount != nil {
+				lock.Amount = amount
+			}
+			if lock.Amount.Sign() == 0 {
+				continue // released when the game ended
+			}
+			status.Locked = append(status.Locked, lock)
+			status.TotalLocked.Add(status.TotalLocked, lock.Amount)
+		}
+	}
+
+	var err error
+	if status.Available, err = bc.AvailableBalance(player); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
@@ -311,7 +311,11 @@ func (el *EventListener) parseGameEndedEvent(vLog types.Log) *GameEndedEvent {
 
 // parseFundsLockedEvent parses a FundsLocked event
 func (el *EventListener) parseFundsLockedEvent(vLog types.Log) *FundsLockedEvent {
-	if len(vLog.Topics) < 2 {
+	return parseFundsLocked(vLog)
+}
+
+func parseFundsLocked(vLog types.Log) *FundsLockedEvent {
+	if len(vLog.Topics) < 3 {
 		return nil
 	}
 
@@ -423,6 +427,72 @@ func (el *EventListener) GetGameCreatedEvents(fromBlock *big.Int) ([]GameCreated
 	return events, nil
 }
 
+// GetFundsLockedEvents retrieves all FundsLocked events
+func (el *EventListener) GetFundsLockedEvents(fromBlock *big.Int) ([]FundsLockedEvent, error) {
+	logs, err := el.GetPastEvents(fromBlock, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []FundsLockedEvent{}
+	fundsLockedSig := crypto.Keccak256Hash([]byte("FundsLocked(bytes32,address,uint256)"))
+
+	for _, vLog := range logs {
+		if len(vLog.Topics) > 0 && vLog.Topics[0] == fundsLockedSig {
+			if event := parseFundsLocked(vLog); event != nil {
+				events = append(events, *event)
+			}
+		}
+	}
+
+	return events, nil
+}
+
+// Backfill is what the escrow contracts logged between two blocks
+type Backfill struct {
+	FundsLocked []FundsLockedEvent
+	GameEnded   []GameEndedEvent
+	ToBlock     uint64 // the chain head when read; the next backfill starts after it
+}
+
+// Backfill reads the FundsLocked and GameEnded events from fromBlock up
+// to the current head, oldest first
+func (el *EventListener) Backfill(fromBlock uint64) (*Backfill, error) {
+	head, err := el.bc.client.BlockNumber(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block number: %w", err)
+	}
+
+	backfill := &Backfill{ToBlock: head}
+	if fromBlock > head {
+		return backfill, nil
+	}
+
+	logs, err := el.GetPastEvents(new(big.Int).SetUint64(fromBlock), new(big.Int).SetUint64(head))
+	if err != nil {
+		return nil, err
+	}
+
+	fundsLockedSig := crypto.Keccak256Hash([]byte("FundsLocked(bytes32,address,uint256)"))
+	gameEndedSig := crypto.Keccak256Hash([]byte("GameEnded(bytes32,address[],uint256[])"))
+	for _, vLog := range logs {
+		if len(vLog.Topics) == 0 {
+			continue
+		}
+		switch vLog.Topics[0] {
+		case fundsLockedSig:
+			if event := parseFundsLocked(vLog); event != nil {
+				backfill.FundsLocked = append(backfill.FundsLocked, *event)
+			}
+		case gameEndedSig:
+			if event := el.parseGameEndedEvent(vLog); event != nil {
+				backfill.GameEnded = append(backfill.GameEnded, *event)
+			}
+		}
+	}
+	return backfill, nil
+}
+
 // getPokerTableABI returns a simplified ABI for the PokerTable contract
 func getPokerTableABI() string {
 	return `[
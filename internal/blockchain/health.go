@@ -0,0 +1,201 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ChainHealth is what the last health check saw of the chain. While the
+// breaker is tripped no new on-chain games are created.
+type ChainHealth struct {
+	Tripped     bool          `json:"tripped"`
+	Reason      string        `json:"reason,omitempty"`
+	TrippedAt   time.Time     `json:"tripped_at,omitempty"`
+	RPCLatency  time.Duration `json:"rpc_latency_ns"`
+	BlockNumber uint64        `json:"block_number"`
+	BlockSeenAt time.Time     `json:"block_seen_at"` // when BlockNumber was first seen
+	Balance     *big.Int      `json:"balance,omitempty"`
+	MinBalance  *big.Int      `json:"min_balance,omitempty"`
+	CheckedAt   time.Time     `json:"checked_at"`
+}
+
+func (h ChainHealth) copy() ChainHealth {
+	if h.Balance != nil {
+		h.Balance = new(big.Int).Set(h.Balance)
+	}
+	if h.MinBalance != nil {
+		h.MinBalance = new(big.Int).Set(h.MinBalance)
+	}
+	return h
+}
+
+// HealthMonitor checks the RPC node, block progression and the node
+// wallet's balance on a schedule, tripping a circuit breaker when the chain
+// cannot be reached, stops producing blocks, or the wallet cannot pay for gas
+type HealthMonitor struct {
+	bc         *BlockchainClient
+	interval   time.Duration
+	stallAfter time.Duration
+	minBalance *big.Int
+	onChange   func(ChainHealth)
+
+	mu     sync.RWMutex
+	health ChainHealth
+	stop   chan struct{}
+}
+
+// NewHealthMonitor creates a monitor that checks every interval. A chain
+// whose head has not moved for stallAfter counts as unreachable.
+func NewHealthMonitor(bc *BlockchainClient, interval, stallAfter time.Duration, minBalance *big.Int) *HealthMonitor {
+	return &HealthMonitor{
+		bc:         bc,
+		interval:   interval,
+		stallAfter: stallAfter,
+		minBalance: minBalance,
+	}
+}
+
+// OnChange sets the function called when the breaker trips or resets
+func (hm *HealthMonitor) OnChange(fn func(ChainHealth)) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	hm.onChange = fn
+}
+
+// Start checks the chain now and then every interval until Stop
+func (hm *HealthMonitor) Start() error {
+	if hm.interval <= 0 {
+		return fmt.Errorf("invalid chain health interval %v", hm.interval)
+	}
+
+	hm.mu.Lock()
+	if hm.stop != nil {
+		hm.mu.Unlock()
+		return nil
+	}
+	hm.stop = make(chan struct{})
+	stop := hm.stop
+	hm.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(hm.interval)
+		defer ticker.Stop()
+
+		hm.Check()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				hm.Check()
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop ends scheduled checks
+func (hm *HealthMonitor) Stop() {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	if hm.stop != nil {
+		close(hm.stop)
+		hm.stop = nil
+	}
+}
+
+// Health returns the result of the last check
+func (hm *HealthMonitor) Health() ChainHealth {
+	hm.mu.RLock()
+	defer hm.mu.RUnlock()
+	return hm.health.copy()
+}
+
+// Tripped reports whether on-chain games are blocked, and why
+func (hm *HealthMonitor) Tripped() (bool, string) {
+	hm.mu.RLock()
+	defer hm.mu.RUnlock()
+	return hm.health.Tripped, hm.health.Reason
+}
+
+// Check probes the chain once, trips or resets the breaker, and returns
+// what it saw
+func (hm *HealthMonitor) Check() ChainHealth {
+	ctx, cancel := context.WithTimeout(context.Background(), hm.interval)
+	defer cancel()
+
+	start := time.Now()
+	header, headerErr := hm.bc.client.HeaderByNumber(ctx, nil)
+	latency := time.Since(start)
+
+	var balance *big.Int
+	var balanceErr error
+	if headerErr == nil {
+		balance, balanceErr = hm.bc.client.BalanceAt(ctx, hm.bc.publicAddress, nil)
+	}
+
+	hm.mu.Lock()
+	prev := hm.health
+	health := prev
+	health.CheckedAt = time.Now()
+	health.RPCLatency = latency
+	health.MinBalance = hm.minBalance
+	health.Reason = ""
+
+	switch {
+	case headerErr != nil:
+		health.Reason = fmt.Sprintf("RPC node unreachable: %v", headerErr)
+	case balanceErr != nil:
+		health.Reason = fmt.Sprintf("failed to read wallet balance: %v", balanceErr)
+	}
+	if headerErr == nil {
+		if block := header.Number.Uint64(); block != health.BlockNumber || health.BlockSeenAt.IsZero() {
+			health.BlockNumber = block
+			health.BlockSeenAt = health.CheckedAt
+		} else if hm.stallAfter > 0 && health.CheckedAt.Sub(health.BlockSeenAt) > hm.stallAfter {
+			health.Reason = fmt.Sprintf("no new block since %d for %s", block, health.CheckedAt.Sub(health.BlockSeenAt).Round(time.Second))
+		}
+	}
+	if balanceErr == nil && balance != nil {
+		health.Balance = balance
+		if health.Reason == "" && hm.minBalance != nil && balance.Cmp(hm.minBalance) < 0 {
+			health.Reason = fmt.Sprintf("wallet balance %s wei is below the %s wei gas threshold", balance, hm.minBalance)
+		}
+	}
+
+	health.Tripped = health.Reason != ""
+	if health.Tripped && !prev.Tripped {
+		health.TrippedAt = health.CheckedAt
+	}
+	if !health.Tripped {
+		health.TrippedAt = time.Time{}
+	}
+	hm.health = health
+	onChange := hm.onChange
+	hm.mu.Unlock()
+
+	fields := logrus.Fields{
+		"block":      health.BlockNumber,
+		"latency_ms": latency.Milliseconds(),
+		"balance":    health.Balance,
+	}
+	switch {
+	case health.Tripped && !prev.Tripped:
+		logrus.WithFields(fields).Errorf("🚨 Chain circuit breaker tripped: %s", health.Reason)
+	case !health.Tripped && prev.Tripped:
+		logrus.WithFields(fields).Info("Chain circuit breaker reset")
+	default:
+		logrus.WithFields(fields).Debug("Chain health checked")
+	}
+
+	if health.Tripped != prev.Tripped && onChange != nil {
+		onChange(health.copy())
+	}
+	return health.copy()
+}
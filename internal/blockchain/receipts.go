@@ -0,0 +1,58 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// TxReceipt is the part of a mined transaction worth keeping in hand history
+type TxReceipt struct {
+	TxHash      string            `json:"tx_hash"`
+	BlockNumber uint64            `json:"block_number"`
+	GasUsed     uint64            `json:"gas_used"`
+	Status      TransactionStatus `json:"status"`
+}
+
+// NewTxReceipt summarizes a go-ethereum receipt
+func NewTxReceipt(receipt *types.Receipt) *TxReceipt {
+	if receipt == nil {
+		return nil
+	}
+
+	status := TxStatusFailed
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		status = TxStatusConfirmed
+	}
+
+	r := &TxReceipt{
+		TxHash:  receipt.TxHash.Hex(),
+		GasUsed: receipt.GasUsed,
+		Status:  status,
+	}
+	if receipt.BlockNumber != nil {
+		r.BlockNumber = receipt.BlockNumber.Uint64()
+	}
+	return r
+}
+
+// Confirmations returns how many blocks deep a transaction is (0 while pending)
+func (bc *BlockchainClient) Confirmations(txHash common.Hash) (uint64, error) {
+	receipt, err := bc.client.TransactionReceipt(context.Background(), txHash)
+	if err != nil {
+		return 0, nil
+	}
+
+	head, err := bc.client.BlockNumber(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get block number: %w", err)
+	}
+
+	mined := receipt.BlockNumber.Uint64()
+	if head < mined {
+		return 0, nil
+	}
+	return head - mined + 1, nil
+}
@@ -13,6 +13,9 @@ import (
 
 type TransactionStatus string
 
+// TransferTimeout is how long Transfer waits for a plain value transfer to be mined
+const TransferTimeout = 2 * time.Minute
+
 const (
 	TxStatusPending   TransactionStatus = "pending"
 	TxStatusConfirmed TransactionStatus = "confirmed"
@@ -107,6 +110,20 @@ func (bc *BlockchainClient) WaitForTransaction(txHash common.Hash, timeout time.
 	}
 }
 
+// Transfer sends value from the node's wallet to an address and waits for it to be mined
+func (bc *BlockchainClient) Transfer(to common.Address, value *big.Int) (*TxReceipt, error) {
+	tx, err := bc.SendTransaction(to, value, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := bc.WaitForTransaction(tx.Hash(), TransferTimeout)
+	if err != nil {
+		return NewTxReceipt(receipt), fmt.Errorf("transfer %s: %w", tx.Hash().Hex(), err)
+	}
+	return NewTxReceipt(receipt), nil
+}
+
 // GetTransactionStatus gets the status of a transaction
 func (bc *BlockchainClient) GetTransactionStatus(txHash common.Hash) (TransactionStatus, error) {
 	receipt, err := bc.client.TransactionReceipt(context.Background(), txHash)
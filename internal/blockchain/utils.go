@@ -2,6 +2,7 @@ package blockchain
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/big"
 	"strings"
@@ -10,6 +11,10 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
+// ErrNotSupported is returned by calls the deployed contracts have no
+// function for, so callers never mistake them for a settled transaction
+var ErrNotSupported = errors.New("not supported by the deployed contracts")
+
 // GenerateGameID generates a unique game ID from parameters
 func GenerateGameID(creator common.Address, timestamp int64, buyIn *big.Int) [32]byte {
 	data := append(creator.Bytes(), big.NewInt(timestamp).Bytes()...)
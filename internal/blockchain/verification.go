@@ -6,6 +6,8 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/sirupsen/logrus"
 )
 
@@ -149,3 +151,65 @@ func (bc *BlockchainClient) CheckGameExists(gameID [32]byte) (bool, error) {
 
 	return exists, nil
 }
+
+// FindFundsLocked returns the FundsLocked event emitted by a mined transaction
+func (bc *BlockchainClient) FindFundsLocked(txHash common.Hash) (*FundsLockedEvent, error) {
+	receipt, err := bc.client.TransactionReceipt(context.Background(), txHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt for %s: %w", txHash.Hex(), err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("transaction %s failed", txHash.Hex())
+	}
+
+	fundsLockedSig := crypto.Keccak256Hash([]byte("FundsLocked(bytes32,address,uint256)"))
+	for _, vLog := range receipt.Logs {
+		if vLog.Address != bc.pokerTableAddress || len(vLog.Topics) == 0 || vLog.Topics[0] != fundsLockedSig {
+			continue
+		}
+		if event := parseFundsLocked(*vLog); event != nil {
+			return event, nil
+		}
+	}
+	return nil, fmt.Errorf("transaction %s did not lock funds", txHash.Hex())
+}
+
+// ContractCheck reports whether a configured contract has code on chain
+type ContractCheck struct {
+	Name     string `json:"name"`
+	Address  string `json:"address"`
+	Deployed bool   `json:"deployed"`
+	Error    string `json:"error,omitempty"`
+}
+
+// VerifyContracts checks every configured contract address for deployed code
+func (bc *BlockchainClient) VerifyContracts() []ContractCheck {
+	contracts := []struct {
+		name    string
+		address common.Address
+	}{
+		{"PokerTable", bc.pokerTableAddress},
+		{"PotManager", bc.potManagerAddress},
+		{"PlayerRegistry", bc.playerRegistryAddress},
+		{"DisputeResolver", bc.disputeResolverAddress},
+	}
+
+	checks := make([]ContractCheck, 0, len(contracts))
+	for _, contract := range contracts {
+		check := ContractCheck{Name: contract.name, Address: contract.address.Hex()}
+		if contract.address == (common.Address{}) {
+			check.Error = "address not configured"
+		} else if deployed, err := bc.VerifyContractDeployment(contract.address); err != nil {
+			check.Error = err.Error()
+		} else {
+			check.Deployed = deployed
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// ChainID returns the ID of the chain the client is connected to
+func (bc *BlockchainClient) ChainID() *big.Int {
+	return new(big.Int).Set(bc.chainID)
+}
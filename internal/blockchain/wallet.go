@@ -107,6 +107,14 @@ func VerifySignature(message, signature []byte, address common.Address) bool {
 	) && recoveryID < 2 && recoveredAddr == address
 }
 
+// VerifyHexSignature verifies a signature against a hex-encoded address
+func VerifyHexSignature(message, signature []byte, address string) bool {
+	if !IsValidAddress(address) {
+		return false
+	}
+	return VerifySignature(message, signature, common.HexToAddress(address))
+}
+
 // WeiToEth converts wei to eth
 func WeiToEth(wei *big.Int) *big.Float {
 	return new(big.Float).Quo(
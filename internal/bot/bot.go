@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/game"
+	"github.com/RedPaladin7/peerpoker/pkg/client"
+	"github.com/sirupsen/logrus"
+)
+
+// Config describes one bot player
+type Config struct {
+	APIURL    string        // base URL of the node's HTTP API
+	PlayerID  string        // the player the bot acts for (the node's listen address)
+	Strategy  Strategy      // how the bot plays
+	Interval  time.Duration // how often the table is polled
+	ThinkTime time.Duration // longest pause before acting, so play looks human-paced
+	Seed      int64         // random seed; 0 picks one from the clock
+}
+
+// Bot plays a seat through a node's HTTP API, the same way a player's client
+// does: it readies up, polls the table, and acts when it is its turn
+type Bot struct {
+	cfg Config
+	api *client.Client
+	rng *rand.Rand
+}
+
+// New creates a bot; call Run to start playing
+func New(cfg Config) (*Bot, error) {
+	if cfg.APIURL == "" || cfg.PlayerID == "" {
+		return nil, fmt.Errorf("a bot needs an API URL and a player ID")
+	}
+	if cfg.Strategy == nil {
+		return nil, fmt.Errorf("a bot needs a strategy")
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 500 * time.Millisecond
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+	api, err := client.New(client.Config{APIURL: cfg.APIURL, PlayerID: cfg.PlayerID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Bot{
+		cfg: cfg,
+		api: api,
+		rng: rand.New(rand.NewSource(cfg.Seed)),
+	}, nil
+}
+
+// Run plays until the context is cancelled. Request errors are logged and
+// retried on the next poll, so a restarting node does not stop the bot.
+func (b *Bot) Run(ctx context.Context) error {
+	logrus.WithFields(logrus.Fields{
+		"player":   b.cfg.PlayerID,
+		"strategy": b.cfg.Strategy.Name(),
+		"api":      b.cfg.APIURL,
+	}).Info("Bot started")
+
+	ticker := time.NewTicker(b.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.WithField("player", b.cfg.PlayerID).Info("Bot stopped")
+			return ctx.Err()
+		case <-ticker.C:
+			if err := b.step(ctx); err != nil {
+				logrus.WithField("player", b.cfg.PlayerID).Warnf("Bot step failed: %v", err)
+			}
+		}
+	}
+}
+
+// step readies up when needed and takes the bot's turn if it is up
+func (b *Bot) step(ctx context.Context) error {
+	table, err := b.api.Table(ctx)
+	if err != nil {
+		return err
+	}
+
+	if table.Status == game.GameStatusWaiting.String() {
+		return b.readyUp(ctx)
+	}
+	if !table.IsMyTurn || len(table.ValidActions) == 0 {
+		return nil
+	}
+
+	if b.cfg.ThinkTime > 0 {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Duration(b.rng.Int63n(int64(b.cfg.ThinkTime)))):
+		}
+	}
+
+	// Bots always table their hand at the showdown
+	if has(*table, "show") {
+		return b.api.Show(ctx)
+	}
+
+	decision := b.cfg.Strategy.Decide(*table, b.rng)
+	logrus.WithFields(logrus.Fields{
+		"player": b.cfg.PlayerID,
+		"action": decision.Action,
+		"value":  decision.Value,
+	}).Info("Bot acting")
+
+	return b.api.Act(ctx, decision.Action, decision.Value)
+}
+
+// readyUp marks the bot ready unless the table already lists it as ready,
+// which it stops doing after a session is aborted
+func (b *Bot) readyUp(ctx context.Context) error {
+	players, err := b.api.Players(ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range players {
+		if p.PlayerID == b.cfg.PlayerID && p.IsReady {
+			return nil
+		}
+	}
+
+	if err := b.api.Ready(ctx); err != nil {
+		return fmt.Errorf("failed to ready up: %w", err)
+	}
+	logrus.WithField("player", b.cfg.PlayerID).Info("Bot is ready")
+	return nil
+}
@@ -0,0 +1,183 @@
+package bot
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/RedPaladin7/peerpoker/internal/deck"
+	"github.com/RedPaladin7/peerpoker/internal/game"
+)
+
+// Decision is the action a bot sends for its turn; Value is the total bet
+// for bets and raises, as the action API expects
+type Decision struct {
+	Action string
+	Value  int
+}
+
+// Strategy picks an action from the bot's view of the table
+type Strategy interface {
+	Name() string
+	Decide(table game.TableStateResponse, rng *rand.Rand) Decision
+}
+
+var strategies = map[string]func() Strategy{
+	"random":       func() Strategy { return randomStrategy{} },
+	"tight":        func() Strategy { return tightStrategy{} },
+	"call-station": func() Strategy { return callStationStrategy{} },
+}
+
+// NewStrategy returns a strategy by name
+func NewStrategy(name string) (Strategy, error) {
+	factory, ok := strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown bot strategy %q (available: %v)", name, StrategyNames())
+	}
+	return factory(), nil
+}
+
+// StrategyNames lists the built-in strategies
+func StrategyNames() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// randomStrategy picks any valid action, sizing bets anywhere up to all-in
+type randomStrategy struct{}
+
+func (randomStrategy) Name() string {
+	return "random"
+}
+
+func (randomStrategy) Decide(table game.TableStateResponse, rng *rand.Rand) Decision {
+	action := table.ValidActions[rng.Intn(len(table.ValidActions))]
+	if action != "bet" && action != "raise" {
+		return Decision{Action: action}
+	}
+
+	minBet := table.MinRaise
+	value := minBet
+	if table.MaxBet > minBet {
+		value += rng.Intn(table.MaxBet - minBet + 1)
+	}
+	return Decision{Action: action, Value: value}
+}
+
+// tightStrategy only plays strong starting hands, bets made hands and folds
+// the rest when facing a bet
+type tightStrategy struct{}
+
+func (tightStrategy) Name() string {
+	return "tight"
+}
+
+func (tightStrategy) Decide(table game.TableStateResponse, rng *rand.Rand) Decision {
+	hole := toCards(table.MyHand)
+	board := toCards(table.CommunityCards)
+
+	strength := preflopStrength(hole)
+	if len(board) >= 3 {
+		if value, ok := deck.EvaluateBestHandValue(hole, board); ok {
+			strength = postflopStrength(value)
+		}
+	}
+
+	switch {
+	case strength >= 2 && (has(table, "raise") || has(table, "bet")):
+		// Value bet: three times the minimum, capped by the stack
+		value := table.MinRaise * 3
+		if value > table.MaxBet {
+			value = table.MaxBet
+		}
+		if has(table, "raise") {
+			return Decision{Action: "raise", Value: value}
+		}
+		return Decision{Action: "bet", Value: value}
+	case has(table, "check"):
+		return Decision{Action: "check"}
+	case strength >= 1 && has(table, "call"):
+		return Decision{Action: "call"}
+	default:
+		return Decision{Action: "fold"}
+	}
+}
+
+// callStationStrategy never folds and never raises
+type callStationStrategy struct{}
+
+func (callStationStrategy) Name() string {
+	return "call-station"
+}
+
+func (callStationStrategy) Decide(table game.TableStateResponse, rng *rand.Rand) Decision {
+	switch {
+	case has(table, "check"):
+		return Decision{Action: "check"}
+	case has(table, "call"):
+		return Decision{Action: "call"}
+	default:
+		return Decision{Action: "fold"}
+	}
+}
+
+// preflopStrength rates hole cards: 2 for premium pairs and big aces, 1 for
+// playable hands, 0 for the rest
+func preflopStrength(hole []deck.Card) int {
+	if len(hole) != 2 {
+		return 0
+	}
+	high, low := hole[0].Value, hole[1].Value
+	if low > high {
+		high, low = low, high
+	}
+
+	switch {
+	case high == low && high >= 10, high == 14 && low >= 12:
+		return 2
+	case high == low && high >= 7, high >= 12 && low >= 10, hole[0].Suit == hole[1].Suit && high == 14:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// postflopStrength rates a made hand: 2 for two pair or better, 1 for a pair
+func postflopStrength(value deck.HandValue) int {
+	switch {
+	case value.Category >= deck.TwoPair:
+		return 2
+	case value.Category == deck.OnePair:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func toCards(cards []game.CardResponse) []deck.Card {
+	suits := map[string]deck.Suit{
+		deck.Hearts.String():   deck.Hearts,
+		deck.Diamonds.String(): deck.Diamonds,
+		deck.Clubs.String():    deck.Clubs,
+		deck.Spades.String():   deck.Spades,
+	}
+
+	result := make([]deck.Card, 0, len(cards))
+	for _, card := range cards {
+		result = append(result, deck.NewCard(suits[card.Suit], card.Value))
+	}
+	return result
+}
+
+func has(table game.TableStateResponse, action string) bool {
+	for _, valid := range table.ValidActions {
+		if valid == action {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
@@ -14,7 +16,246 @@ type Config struct {
 	InitialPeer   string
 	ReadTimeout   int
 	WriteTimeout  int
-	PingInterval  int
+	PingInterval  int // seconds between pings to peers (0 to disable)
+
+	// Seconds a peer may go unheard before it is disconnected (0 never)
+	PeerTimeout int
+
+	// Connected peers needed before /readyz reports the node ready
+	ReadyMinPeers int
+
+	// Peers kept connected at all times (INITIAL_PEER is the first), and
+	// the longest wait between attempts to redial one (seconds)
+	BootstrapPeers   []string
+	PeerReconnectMax int
+
+	// How peers connect: websocket (the /p2p endpoint), tcp, or libp2p
+	// (built with -tags libp2p), and where the latter two listen (a
+	// host:port, or a multiaddr for libp2p)
+	P2PTransport  string
+	P2PListenAddr string
+
+	// Log level (debug, info, warn, error) and format (text or json)
+	LogLevel  string
+	LogFormat string
+
+	// Serve pprof under /debug/pprof (admin token required), and how long
+	// an action may take before it is logged as slow (ms, 0 never logs)
+	Pprof               bool
+	ActionLatencyBudget int
+
+	// Protocol message signing
+	SignMessages          bool
+	RequireSignedMessages bool
+	SigningKey            string
+
+	// TLS for the API and WebSocket listeners when EnableHTTPS is set. With
+	// autocert domains the certificate comes from Let's Encrypt instead of
+	// the cert/key files; a peer CA turns on mutual TLS between peers.
+	TLSCertFile         string
+	TLSKeyFile          string
+	TLSAutocertDomains  []string
+	TLSAutocertCacheDir string
+	PeerCAFile          string
+
+	// Bearer token for the /api/admin and /api/audit endpoints; empty
+	// allows local requests only
+	AdminToken string
+
+	// Hash-chained log of privileged operations; empty keeps the audit
+	// trail in memory only
+	AuditLogFile string
+
+	// Token bucket rate limits per IP and per client (0 RPS disables):
+	// API actions, API reads and WebSocket messages from players
+	RateLimitActionRPS   int
+	RateLimitActionBurst int
+	RateLimitReadRPS     int
+	RateLimitReadBurst   int
+	RateLimitWSRPS       int
+	RateLimitWSBurst     int
+
+	// Seconds a client's WebSocket send buffer may stay saturated before
+	// it is disconnected (0 never disconnects)
+	WSSlowClientTimeout int
+
+	// Tables where bots are prohibited run the bot detector
+	AllowBots bool
+
+	// How often hand histories are analysed for collusion (seconds, 0 to
+	// disable), the chips one player must lose to another before it counts
+	// as chip dumping, and how often a player must face another's bets
+	// before folding to them counts as soft play
+	CollusionInterval    int
+	CollusionMinTransfer int
+	CollusionMinFaced    int
+
+	// Smart contract integration: the RPC node, the node wallet's key and
+	// the deployed contract addresses
+	BlockchainEnabled       bool
+	BlockchainRPCURL        string
+	BlockchainChainID       string
+	BlockchainPrivateKey    string
+	ContractPokerTable      string
+	ContractPotManager      string
+	ContractPlayerRegistry  string
+	ContractDisputeResolver string
+
+	// Block to start reading escrow events from for the escrow endpoints;
+	// the deployment block saves scanning the whole chain. With a store,
+	// later boots resume after the last block recorded in its ledger.
+	EscrowFromBlock int
+
+	// How often the chain's RPC node, block progression and the node
+	// wallet are checked (seconds, 0 to disable), how long the head may
+	// stand still before the chain counts as unreachable (seconds), and
+	// the wallet balance below which gas can no longer be paid (wei). The
+	// circuit breaker blocks new on-chain games while any check fails.
+	ChainHealthInterval int
+	ChainStallTimeout   int
+	ChainMinBalance     int
+
+	// Offer the protobuf encoding to peers (browser clients always use JSON)
+	BinaryP2P bool
+
+	// Forward messages between peers that cannot dial each other, for a
+	// publicly reachable node
+	RelayEnabled bool
+
+	// Player presence
+	PresenceFile      string
+	PresenceAwayAfter int // seconds
+
+	// Friends and table invitations
+	FriendsFile string
+	PublicWSURL string // base URL used in invitation join links
+
+	// Player profiles, kept by signing address
+	ProfilesFile string
+
+	// Serve the embedded web client at / on the API port
+	WebUI bool
+
+	// State snapshots written when hand logic panics
+	IncidentDir string
+
+	// Snapshots taken on demand through the admin API
+	SnapshotDir string
+
+	// Hex-encoded 32-byte key that encrypts and authenticates snapshots
+	// (empty writes plaintext snapshots with a SHA-256 checksum)
+	SnapshotKey string
+
+	// Rolling state snapshots written after every change and restored at
+	// boot (empty disables them), and how many are kept
+	StateDir           string
+	StateSnapshotsKept int
+
+	// Write-ahead log of hand events kept in the state directory, and how
+	// many logged events trigger a snapshot mid-hand
+	StateWAL         bool
+	WALSnapshotEvery int
+
+	// Record store for players, hands, actions and settlements: "file"
+	// (StoreSource is a directory), or "sqlite" or "postgres" (StoreSource
+	// is a data source name); empty disables it
+	StoreDriver string
+	StoreSource string
+
+	// Scheduled backups of the latest state snapshot: "local" (BackupDir)
+	// or "s3" (any S3-compatible store, e.g. MinIO); empty disables them
+	BackupTarget      string
+	BackupDir         string
+	BackupInterval    int // seconds
+	BackupKeep        int
+	BackupMaxAgeHours int // 0 keeps backups regardless of age
+	BackupCompress    bool
+	BackupS3Endpoint  string
+	BackupS3Region    string
+	BackupS3Bucket    string
+	BackupS3Prefix    string
+	BackupS3AccessKey string
+	BackupS3SecretKey string
+
+	// Retention for admin snapshots and backups: keep the newest
+	// RetentionKeepLast plus one per hour, day and week for the given
+	// number of periods; all zero disables it
+	RetentionKeepLast   int
+	RetentionKeepHourly int
+	RetentionKeepDaily  int
+	RetentionKeepWeekly int
+	RetentionInterval   int // seconds
+
+	// Operator-funded pool used to compensate players after incidents
+	InsuranceFundFile string
+
+	// Name shown in the lobby
+	TableName string
+
+	// How long a reserved seat is held for a player (seconds)
+	SeatReservationSeconds int
+
+	// Seconds a hosted table may sit empty before it is torn down (0 keeps
+	// idle tables); the node's own table is always kept
+	TableIdleTimeout int
+
+	// Game variant: TEXAS_HOLDEM, or OMAHA_HI_LO for split hi-lo pots
+	GameVariant string
+
+	// Ante posted by every player before the blinds (0 for none), whether
+	// players may straddle, whether all-in boards may be run twice, and
+	// whether the rest of the board is shown after a hand ends early
+	Ante            int
+	AllowStraddle   bool
+	AllowRunItTwice bool
+	RabbitHunt      bool
+
+	// Seconds a player has to act (0 for no limit) and the extra seconds
+	// each player can draw on per session before they are folded
+	ActionSeconds   int
+	TimeBankSeconds int
+
+	// Seconds a player who drops mid-hand has to reconnect before the hand
+	// is aborted and they are penalised
+	DisconnectTimeout int
+
+	// Rake: percent of each pot, the most taken from one hand (0 for no
+	// cap), whether hands ending before the flop are raked, and the escrow
+	// address rake is paid to on-chain
+	RakePercent      int
+	RakeCap          int
+	RakeNoFlopNoDrop bool
+	RakeRecipient    string
+
+	// Hands netted into each on-chain settlement (1 settles every hand, -1
+	// the whole session)
+	SettlementBatchSize int
+
+	// Settle each session through signed off-chain stacks, submitting only
+	// the final state on-chain (needs SIGN_MESSAGES)
+	StateChannel bool
+
+	// Rebuy limits, in chips, and whether rebuys must reference a
+	// FundsLocked transaction
+	MinBuyIn                int
+	MaxBuyIn                int
+	RebuyRequireFundsLocked bool
+
+	// Publish each hand's shuffle keys and permutations when it ends, so
+	// anyone can replay the shuffle; this reveals every card, mucked hands
+	// included
+	ShuffleAuditPublish bool
+
+	// Hand evaluator engine, and an optional reference engine to cross-check
+	// a percentage of showdown evaluations against
+	Evaluator             string
+	EvaluatorCrossCheck   string
+	EvaluatorCheckPercent int
+
+	// Deterministic simulation dealing for tests and load tools; only
+	// honoured by binaries built with the sim tag
+	SimMode bool
+	SimSeed int
 }
 
 func (c *Config) GetWSAddr() string {
@@ -36,31 +277,209 @@ func LoadFromEnv() *Config {
 		ReadTimeout:  getEnvInt("READ_TIMEOUT", 60),
 		WriteTimeout: getEnvInt("WRITE_TIMEOUT", 10),
 		PingInterval: getEnvInt("PING_INTERVAL", 30),
+		PeerTimeout:  getEnvInt("PEER_TIMEOUT", 90),
+
+		ReadyMinPeers: getEnvInt("READY_MIN_PEERS", 0),
+
+		BootstrapPeers:   getEnvList("BOOTSTRAP_PEERS"),
+		PeerReconnectMax: getEnvInt("PEER_RECONNECT_MAX", 300),
+
+		P2PTransport:  getEnv("P2P_TRANSPORT", "websocket"),
+		P2PListenAddr: getEnv("P2P_LISTEN_ADDR", ":4000"),
+
+		LogLevel:  getEnv("LOG_LEVEL", "info"),
+		LogFormat: getEnv("LOG_FORMAT", "text"),
+
+		Pprof:               getEnvBool("PPROF_ENABLED", false),
+		ActionLatencyBudget: getEnvInt("ACTION_LATENCY_BUDGET_MS", 100),
+
+		SignMessages:          getEnvBool("SIGN_MESSAGES", false),
+		RequireSignedMessages: getEnvBool("REQUIRE_SIGNED_MESSAGES", false),
+		SigningKey:            getEnv("SIGNING_PRIVATE_KEY", ""),
+
+		TLSCertFile:         getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:          getEnv("TLS_KEY_FILE", ""),
+		TLSAutocertDomains:  getEnvList("TLS_AUTOCERT_DOMAINS"),
+		TLSAutocertCacheDir: getEnv("TLS_AUTOCERT_CACHE_DIR", "data/autocert"),
+		PeerCAFile:          getEnv("PEER_CA_FILE", ""),
+
+		AdminToken: getEnv("ADMIN_TOKEN", ""),
+
+		AuditLogFile: getEnv("AUDIT_LOG_FILE", "data/audit.log"),
+
+		RateLimitActionRPS:   getEnvInt("RATE_LIMIT_ACTION_RPS", 5),
+		RateLimitActionBurst: getEnvInt("RATE_LIMIT_ACTION_BURST", 10),
+		RateLimitReadRPS:     getEnvInt("RATE_LIMIT_READ_RPS", 20),
+		RateLimitReadBurst:   getEnvInt("RATE_LIMIT_READ_BURST", 40),
+		RateLimitWSRPS:       getEnvInt("RATE_LIMIT_WS_RPS", 10),
+		RateLimitWSBurst:     getEnvInt("RATE_LIMIT_WS_BURST", 20),
+
+		WSSlowClientTimeout: getEnvInt("WS_SLOW_CLIENT_TIMEOUT", 10),
+
+		AllowBots: getEnvBool("ALLOW_BOTS", false),
+
+		CollusionInterval:    getEnvInt("COLLUSION_INTERVAL", 300),
+		CollusionMinTransfer: getEnvInt("COLLUSION_MIN_TRANSFER", 0),
+		CollusionMinFaced:    getEnvInt("COLLUSION_MIN_FACED", 0),
+
+		BlockchainEnabled:       getEnvBool("BLOCKCHAIN_ENABLED", false),
+		BlockchainRPCURL:        getEnv("BLOCKCHAIN_RPC_URL", ""),
+		BlockchainChainID:       getEnv("BLOCKCHAIN_CHAIN_ID", ""),
+		BlockchainPrivateKey:    getEnv("BLOCKCHAIN_PRIVATE_KEY", ""),
+		ContractPokerTable:      getEnv("CONTRACT_POKER_TABLE", ""),
+		ContractPotManager:      getEnv("CONTRACT_POT_MANAGER", ""),
+		ContractPlayerRegistry:  getEnv("CONTRACT_PLAYER_REGISTRY", ""),
+		ContractDisputeResolver: getEnv("CONTRACT_DISPUTE_RESOLVER", ""),
+
+		EscrowFromBlock: getEnvInt("ESCROW_FROM_BLOCK", 0),
+
+		ChainHealthInterval: getEnvInt("CHAIN_HEALTH_INTERVAL", 30),
+		ChainStallTimeout:   getEnvInt("CHAIN_STALL_TIMEOUT", 300),
+		ChainMinBalance:     getEnvInt("CHAIN_MIN_BALANCE", 10000000000000000),
+
+		BinaryP2P: getEnvBool("BINARY_P2P", true),
+
+		RelayEnabled: getEnvBool("RELAY_ENABLED", false),
+
+		PresenceFile:      getEnv("PRESENCE_FILE", "data/presence.json"),
+		PresenceAwayAfter: getEnvInt("PRESENCE_AWAY_AFTER", 300),
+
+		FriendsFile: getEnv("FRIENDS_FILE", "data/friends.json"),
+		PublicWSURL: getEnv("PUBLIC_WS_URL", ""),
+
+		ProfilesFile: getEnv("PROFILES_FILE", "data/profiles.json"),
+
+		WebUI: getEnvBool("WEB_UI", true),
+
+		IncidentDir: getEnv("INCIDENT_DIR", "data/incidents"),
+		SnapshotDir: getEnv("SNAPSHOT_DIR", "data/snapshots"),
+
+		SnapshotKey: getEnv("SNAPSHOT_KEY", ""),
+
+		StateDir:           getEnv("STATE_DIR", "data/state"),
+		StateSnapshotsKept: getEnvInt("STATE_SNAPSHOTS_KEPT", 10),
+		StateWAL:           getEnvBool("STATE_WAL", true),
+		WALSnapshotEvery:   getEnvInt("WAL_SNAPSHOT_EVERY", 50),
+
+		StoreDriver: getEnv("STORE_DRIVER", "file"),
+		StoreSource: getEnv("STORE_SOURCE", "data/store"),
+
+		BackupTarget:      getEnv("BACKUP_TARGET", ""),
+		BackupDir:         getEnv("BACKUP_DIR", "data/backups"),
+		BackupInterval:    getEnvInt("BACKUP_INTERVAL", 3600),
+		BackupKeep:        getEnvInt("BACKUP_KEEP", 24),
+		BackupMaxAgeHours: getEnvInt("BACKUP_MAX_AGE_HOURS", 0),
+		BackupCompress:    getEnvBool("BACKUP_COMPRESS", true),
+		BackupS3Endpoint:  getEnv("BACKUP_S3_ENDPOINT", ""),
+		BackupS3Region:    getEnv("BACKUP_S3_REGION", "us-east-1"),
+		BackupS3Bucket:    getEnv("BACKUP_S3_BUCKET", ""),
+		BackupS3Prefix:    getEnv("BACKUP_S3_PREFIX", "peerpoker/"),
+		BackupS3AccessKey: getEnv("BACKUP_S3_ACCESS_KEY", ""),
+		BackupS3SecretKey: getEnv("BACKUP_S3_SECRET_KEY", ""),
+
+		RetentionKeepLast:   getEnvInt("RETENTION_KEEP_LAST", 0),
+		RetentionKeepHourly: getEnvInt("RETENTION_KEEP_HOURLY", 0),
+		RetentionKeepDaily:  getEnvInt("RETENTION_KEEP_DAILY", 0),
+		RetentionKeepWeekly: getEnvInt("RETENTION_KEEP_WEEKLY", 0),
+		RetentionInterval:   getEnvInt("RETENTION_INTERVAL", 3600),
+
+		InsuranceFundFile: getEnv("INSURANCE_FUND_FILE", "data/insurance.json"),
+
+		SeatReservationSeconds: getEnvInt("SEAT_RESERVATION_SECONDS", 60),
+		TableIdleTimeout:       getEnvInt("TABLE_IDLE_TIMEOUT", 1800),
+
+		GameVariant: getEnv("GAME_VARIANT", "TEXAS_HOLDEM"),
+
+		Ante:            getEnvInt("ANTE", 0),
+		AllowStraddle:   getEnvBool("ALLOW_STRADDLE", false),
+		AllowRunItTwice: getEnvBool("ALLOW_RUN_IT_TWICE", false),
+		RabbitHunt:      getEnvBool("RABBIT_HUNT", false),
+
+		ActionSeconds:   getEnvInt("ACTION_SECONDS", 0),
+		TimeBankSeconds: getEnvInt("TIME_BANK_SECONDS", 60),
+
+		DisconnectTimeout: getEnvInt("DISCONNECT_TIMEOUT", 300),
+
+		RakePercent:      getEnvInt("RAKE_PERCENT", 0),
+		RakeCap:          getEnvInt("RAKE_CAP", 0),
+		RakeNoFlopNoDrop: getEnvBool("RAKE_NO_FLOP_NO_DROP", true),
+		RakeRecipient:    getEnv("RAKE_RECIPIENT", ""),
+
+		SettlementBatchSize: getEnvInt("SETTLEMENT_BATCH_SIZE", 1),
+		StateChannel:        getEnvBool("STATE_CHANNEL", false),
+
+		MinBuyIn:                getEnvInt("MIN_BUY_IN", 400),
+		MaxBuyIn:                getEnvInt("MAX_BUY_IN", 2000),
+		RebuyRequireFundsLocked: getEnvBool("REBUY_REQUIRE_FUNDS_LOCKED", false),
+
+		ShuffleAuditPublish: getEnvBool("SHUFFLE_AUDIT_PUBLISH", false),
+
+		Evaluator:             getEnv("HAND_EVALUATOR", "default"),
+		EvaluatorCrossCheck:   getEnv("HAND_EVALUATOR_CROSSCHECK", ""),
+		EvaluatorCheckPercent: getEnvInt("HAND_EVALUATOR_CHECK_PERCENT", 10),
+
+		SimMode: getEnvBool("SIM_MODE", false),
+		SimSeed: getEnvInt("SIM_SEED", 1),
+	}
+	cfg.TableName = getEnv("TABLE_NAME", "Table "+cfg.WSPort)
+	if cfg.InitialPeer != "" {
+		cfg.BootstrapPeers = append([]string{cfg.InitialPeer}, cfg.BootstrapPeers...)
+	}
+	if cfg.PublicWSURL == "" {
+		scheme := "ws"
+		if cfg.EnableHTTPS {
+			scheme = "wss"
+		}
+		cfg.PublicWSURL = scheme + "://localhost:" + cfg.WSPort
 	}
 	return cfg
 }
 
-func getEnv(key, defaultVal string) string {
+// lookupEnv reads a variable from the environment, falling back to the
+// config file loaded by Load
+func lookupEnv(key string) string {
 	if val := os.Getenv(key); val != "" {
 		return val
 	}
+	return fileValues[key]
+}
+
+func getEnv(key, defaultVal string) string {
+	if val := lookupEnv(key); val != "" {
+		return val
+	}
 	return defaultVal
 }
 
 func getEnvInt(key string, defaultVal int) int {
-	if val := os.Getenv(key); val != "" {
-		if intVal, err := strconv.Atoi(val); err == nil {
+	if val := lookupEnv(key); val != "" {
+		intVal, err := strconv.Atoi(val)
+		if err == nil {
 			return intVal
 		}
+		badValues = append(badValues, fmt.Errorf("%s: %q is not a whole number", key, val))
 	}
 	return defaultVal
 }
 
 func getEnvBool(key string, defaultVal bool) bool {
-	if val := os.Getenv(key); val != "" {
-		if boolVal, err := strconv.ParseBool(val); err == nil {
+	if val := lookupEnv(key); val != "" {
+		boolVal, err := strconv.ParseBool(val)
+		if err == nil {
 			return boolVal
 		}
+		badValues = append(badValues, fmt.Errorf("%s: %q is not true or false", key, val))
 	}
 	return defaultVal
 }
+
+// getEnvList splits a comma-separated variable, dropping empty entries
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(lookupEnv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
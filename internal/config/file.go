@@ -0,0 +1,198 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// fileSections lists the variables each section of a config file may set.
+// A key is the lowercased name of its environment variable, e.g.
+//
+//	server:
+//	  ws_port: 3000
+//	  bootstrap_peers: [ws://a:3000, ws://b:3000]
+//	table:
+//	  max_players: 9
+var fileSections = map[string][]string{
+	"server": {
+		"POKER_VERSION", "WS_PORT", "API_PORT", "PUBLIC_WS_URL", "WEB_UI", "LOG_LEVEL", "LOG_FORMAT",
+		"PPROF_ENABLED", "ACTION_LATENCY_BUDGET_MS",
+		"READ_TIMEOUT", "WRITE_TIMEOUT", "WS_SLOW_CLIENT_TIMEOUT",
+		"INITIAL_PEER", "BOOTSTRAP_PEERS", "PEER_RECONNECT_MAX", "PING_INTERVAL", "PEER_TIMEOUT", "READY_MIN_PEERS",
+		"P2P_TRANSPORT", "P2P_LISTEN_ADDR", "BINARY_P2P", "RELAY_ENABLED",
+		"PRESENCE_AWAY_AFTER", "SIM_MODE", "SIM_SEED",
+	},
+	"table": {
+		"TABLE_NAME", "MAX_PLAYERS", "SEAT_RESERVATION_SECONDS", "TABLE_IDLE_TIMEOUT", "GAME_VARIANT",
+		"ANTE", "ALLOW_STRADDLE", "ALLOW_RUN_IT_TWICE", "RABBIT_HUNT",
+		"ACTION_SECONDS", "TIME_BANK_SECONDS", "DISCONNECT_TIMEOUT",
+		"RAKE_PERCENT", "RAKE_CAP", "RAKE_NO_FLOP_NO_DROP",
+		"MIN_BUY_IN", "MAX_BUY_IN", "REBUY_REQUIRE_FUNDS_LOCKED", "SHUFFLE_AUDIT_PUBLISH",
+		"HAND_EVALUATOR", "HAND_EVALUATOR_CROSSCHECK", "HAND_EVALUATOR_CHECK_PERCENT",
+	},
+	"blockchain": {
+		"BLOCKCHAIN_ENABLED", "BLOCKCHAIN_RPC_URL", "BLOCKCHAIN_CHAIN_ID", "BLOCKCHAIN_PRIVATE_KEY",
+		"CONTRACT_POKER_TABLE", "CONTRACT_POT_MANAGER", "CONTRACT_PLAYER_REGISTRY", "CONTRACT_DISPUTE_RESOLVER",
+		"ESCROW_FROM_BLOCK", "CHAIN_HEALTH_INTERVAL", "CHAIN_STALL_TIMEOUT", "CHAIN_MIN_BALANCE",
+		"RAKE_RECIPIENT", "SETTLEMENT_BATCH_SIZE", "STATE_CHANNEL",
+	},
+	"persistence": {
+		"STATE_DIR", "STATE_SNAPSHOTS_KEPT", "STATE_WAL", "WAL_SNAPSHOT_EVERY",
+		"STORE_DRIVER", "STORE_SOURCE", "INCIDENT_DIR", "SNAPSHOT_DIR",
+		"PRESENCE_FILE", "FRIENDS_FILE", "PROFILES_FILE", "INSURANCE_FUND_FILE",
+		"BACKUP_TARGET", "BACKUP_DIR", "BACKUP_INTERVAL", "BACKUP_KEEP", "BACKUP_MAX_AGE_HOURS", "BACKUP_COMPRESS",
+		"BACKUP_S3_ENDPOINT", "BACKUP_S3_REGION", "BACKUP_S3_BUCKET", "BACKUP_S3_PREFIX",
+		"BACKUP_S3_ACCESS_KEY", "BACKUP_S3_SECRET_KEY",
+		"RETENTION_KEEP_LAST", "RETENTION_KEEP_HOURLY", "RETENTION_KEEP_DAILY", "RETENTION_KEEP_WEEKLY", "RETENTION_INTERVAL",
+	},
+	"security": {
+		"ENABLE_HTTPS", "TLS_CERT_FILE", "TLS_KEY_FILE", "TLS_AUTOCERT_DOMAINS", "TLS_AUTOCERT_CACHE_DIR", "PEER_CA_FILE",
+		"SIGN_MESSAGES", "REQUIRE_SIGNED_MESSAGES", "SIGNING_PRIVATE_KEY", "SNAPSHOT_KEY",
+		"ADMIN_TOKEN", "AUDIT_LOG_FILE",
+		"RATE_LIMIT_ACTION_RPS", "RATE_LIMIT_ACTION_BURST", "RATE_LIMIT_READ_RPS", "RATE_LIMIT_READ_BURST",
+		"RATE_LIMIT_WS_RPS", "RATE_LIMIT_WS_BURST",
+		"ALLOW_BOTS", "COLLUSION_INTERVAL", "COLLUSION_MIN_TRANSFER", "COLLUSION_MIN_FACED",
+	},
+}
+
+var (
+	// fileValues holds the variables set by the config file, which the
+	// environment overrides
+	fileValues map[string]string
+
+	// badValues collects variables that failed to parse during a load
+	badValues []error
+)
+
+// Load reads the config file at path (YAML or TOML by extension; empty for
+// none), lets environment variables override it, and validates the result
+func Load(path string) (*Config, error) {
+	values := map[string]string{}
+	if path != "" {
+		var err error
+		if values, err = readFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	fileValues = values
+	badValues = nil
+	cfg := LoadFromEnv()
+	problems := badValues
+	badValues = nil
+
+	if err := cfg.Validate(); err != nil {
+		problems = append(problems, err)
+	}
+	if len(problems) > 0 {
+		return nil, joinErrors(problems)
+	}
+	return cfg, nil
+}
+
+// readFile flattens a config file into environment variable values
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var sections map[string]map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &sections)
+	case ".toml":
+		err = toml.Unmarshal(data, &sections)
+	default:
+		return nil, fmt.Errorf("config file %s must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	var problems []error
+	for section, entries := range sections {
+		allowed, ok := fileSections[section]
+		if !ok {
+			problems = append(problems, fmt.Errorf("unknown section %q", section))
+			continue
+		}
+		for key, raw := range entries {
+			env := strings.ToUpper(key)
+			if !oneOf(env, allowed...) {
+				problems = append(problems, fmt.Errorf("%s: unknown key %q", section, key))
+				continue
+			}
+			value, err := formatValue(raw)
+			if err != nil {
+				problems = append(problems, fmt.Errorf("%s.%s: %w", section, key, err))
+				continue
+			}
+			values[env] = value
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, joinErrors(problems))
+	}
+	return values, nil
+}
+
+// formatValue renders a file value the way it would be written in the
+// environment; lists become comma-separated
+func formatValue(raw interface{}) (string, error) {
+	switch v := raw.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		if v != float64(int64(v)) {
+			return "", fmt.Errorf("%v is not a whole number", v)
+		}
+		return strconv.FormatInt(int64(v), 10), nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := formatValue(item)
+			if err != nil {
+				return "", err
+			}
+			if strings.Contains(s, ",") {
+				return "", fmt.Errorf("list item %q contains a comma", s)
+			}
+			items = append(items, s)
+		}
+		return strings.Join(items, ","), nil
+	case nil:
+		return "", nil
+	default:
+		return "", fmt.Errorf("unsupported value %v", raw)
+	}
+}
+
+// joinErrors reports every problem at once, sorted so the output is stable
+func joinErrors(errs []error) error {
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	sort.Strings(msgs)
+	return fmt.Errorf("%d problems:\n  %s", len(msgs), strings.Join(msgs, "\n  "))
+}
@@ -0,0 +1,60 @@
+package config
+
+import "reflect"
+
+// reloadable lists the settings a running node can change without
+// restarting its tables
+var reloadable = map[string]bool{
+	"LogLevel":             true,
+	"LogFormat":            true,
+	"ActionLatencyBudget":  true,
+	"RakePercent":          true,
+	"RakeCap":              true,
+	"RakeNoFlopNoDrop":     true,
+	"ActionSeconds":        true,
+	"TimeBankSeconds":      true,
+	"DisconnectTimeout":    true,
+	"RateLimitActionRPS":   true,
+	"RateLimitActionBurst": true,
+	"RateLimitReadRPS":     true,
+	"RateLimitReadBurst":   true,
+	"RateLimitWSRPS":       true,
+	"RateLimitWSBurst":     true,
+	"WSSlowClientTimeout":  true,
+	"PingInterval":         true,
+	"PeerTimeout":          true,
+}
+
+// Changes lists the settings that differ between a running config and a
+// newly loaded one, split into those that can be applied now and those
+// that only take effect after a restart
+func Changes(running, loaded *Config) (apply, restart []string) {
+	a := reflect.ValueOf(running).Elem()
+	b := reflect.ValueOf(loaded).Elem()
+	for i := 0; i < a.NumField(); i++ {
+		if reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			continue
+		}
+		name := a.Type().Field(i).Name
+		if reloadable[name] {
+			apply = append(apply, name)
+		} else {
+			restart = append(restart, name)
+		}
+	}
+	return apply, restart
+}
+
+// WithReloadable returns a copy of c with the reloadable settings taken
+// from loaded and everything else left as it is
+func (c *Config) WithReloadable(loaded *Config) *Config {
+	merged := *c
+	dst := reflect.ValueOf(&merged).Elem()
+	src := reflect.ValueOf(loaded).Elem()
+	for i := 0; i < dst.NumField(); i++ {
+		if reloadable[dst.Type().Field(i).Name] {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+	return &merged
+}
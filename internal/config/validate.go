@@ -0,0 +1,125 @@
+package config
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+// Validate reports every setting that would stop the node from running
+// correctly, so a bad deployment fails at startup instead of mid-session
+func (c *Config) Validate() error {
+	var problems []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(validPort(c.WSPort), "WS_PORT: %q is not a port", c.WSPort)
+	check(validPort(c.APIPort), "API_PORT: %q is not a port", c.APIPort)
+	check(oneOf(c.LogLevel, "debug", "info", "warn", "error"), "LOG_LEVEL: %q is not debug, info, warn or error", c.LogLevel)
+	check(oneOf(c.LogFormat, "text", "json"), "LOG_FORMAT: %q is not text or json", c.LogFormat)
+	check(oneOf(c.P2PTransport, "websocket", "tcp", "libp2p"), "P2P_TRANSPORT: %q is not websocket, tcp or libp2p", c.P2PTransport)
+
+	for name, value := range map[string]int{
+		"READ_TIMEOUT":             c.ReadTimeout,
+		"WRITE_TIMEOUT":            c.WriteTimeout,
+		"PING_INTERVAL":            c.PingInterval,
+		"PEER_TIMEOUT":             c.PeerTimeout,
+		"READY_MIN_PEERS":          c.ReadyMinPeers,
+		"PEER_RECONNECT_MAX":       c.PeerReconnectMax,
+		"WS_SLOW_CLIENT_TIMEOUT":   c.WSSlowClientTimeout,
+		"PRESENCE_AWAY_AFTER":      c.PresenceAwayAfter,
+		"COLLUSION_INTERVAL":       c.CollusionInterval,
+		"CHAIN_HEALTH_INTERVAL":    c.ChainHealthInterval,
+		"CHAIN_STALL_TIMEOUT":      c.ChainStallTimeout,
+		"ESCROW_FROM_BLOCK":        c.EscrowFromBlock,
+		"STATE_SNAPSHOTS_KEPT":     c.StateSnapshotsKept,
+		"WAL_SNAPSHOT_EVERY":       c.WALSnapshotEvery,
+		"BACKUP_INTERVAL":          c.BackupInterval,
+		"BACKUP_KEEP":              c.BackupKeep,
+		"BACKUP_MAX_AGE_HOURS":     c.BackupMaxAgeHours,
+		"RETENTION_INTERVAL":       c.RetentionInterval,
+		"SEAT_RESERVATION_SECONDS": c.SeatReservationSeconds,
+		"ACTION_LATENCY_BUDGET_MS": c.ActionLatencyBudget,
+		"TABLE_IDLE_TIMEOUT":       c.TableIdleTimeout,
+		"ANTE":                     c.Ante,
+		"ACTION_SECONDS":           c.ActionSeconds,
+		"TIME_BANK_SECONDS":        c.TimeBankSeconds,
+		"RAKE_CAP":                 c.RakeCap,
+	} {
+		check(value >= 0, "%s: %d is negative", name, value)
+	}
+	if c.PingInterval > 0 && c.PeerTimeout > 0 {
+		check(c.PeerTimeout > c.PingInterval, "PEER_TIMEOUT: %ds must be longer than PING_INTERVAL (%ds)", c.PeerTimeout, c.PingInterval)
+	}
+
+	// Table
+	check(c.MaxPlayers >= 2 && c.MaxPlayers <= 10, "MAX_PLAYERS: %d is not between 2 and 10", c.MaxPlayers)
+	check(oneOf(c.GameVariant, "TEXAS_HOLDEM", "OMAHA_HI_LO"), "GAME_VARIANT: %q is not TEXAS_HOLDEM or OMAHA_HI_LO", c.GameVariant)
+	check(c.RakePercent >= 0 && c.RakePercent <= 100, "RAKE_PERCENT: %d is not between 0 and 100", c.RakePercent)
+	check(c.EvaluatorCheckPercent >= 0 && c.EvaluatorCheckPercent <= 100, "HAND_EVALUATOR_CHECK_PERCENT: %d is not between 0 and 100", c.EvaluatorCheckPercent)
+	check(c.DisconnectTimeout > 0, "DISCONNECT_TIMEOUT: %d must be positive", c.DisconnectTimeout)
+	check(c.MinBuyIn > 0, "MIN_BUY_IN: %d must be positive", c.MinBuyIn)
+	check(c.MaxBuyIn >= c.MinBuyIn, "MAX_BUY_IN: %d is below MIN_BUY_IN (%d)", c.MaxBuyIn, c.MinBuyIn)
+
+	// Blockchain
+	if c.BlockchainEnabled {
+		check(c.BlockchainRPCURL != "", "BLOCKCHAIN_RPC_URL: required when BLOCKCHAIN_ENABLED is set")
+		check(c.BlockchainPrivateKey != "", "BLOCKCHAIN_PRIVATE_KEY: required when BLOCKCHAIN_ENABLED is set")
+	}
+	if c.BlockchainChainID != "" {
+		_, err := strconv.ParseUint(c.BlockchainChainID, 10, 64)
+		check(err == nil, "BLOCKCHAIN_CHAIN_ID: %q is not a chain ID", c.BlockchainChainID)
+	}
+	check(c.SettlementBatchSize >= 1 || c.SettlementBatchSize == -1, "SETTLEMENT_BATCH_SIZE: %d is not positive or -1", c.SettlementBatchSize)
+	check(!c.StateChannel || c.SignMessages, "STATE_CHANNEL: needs SIGN_MESSAGES")
+
+	// Persistence
+	check(oneOf(c.StoreDriver, "", "file", "sqlite", "postgres"), "STORE_DRIVER: %q is not file, sqlite or postgres", c.StoreDriver)
+	check(c.StoreDriver == "" || c.StoreSource != "", "STORE_SOURCE: required with STORE_DRIVER %q", c.StoreDriver)
+	check(oneOf(c.BackupTarget, "", "local", "s3"), "BACKUP_TARGET: %q is not local or s3", c.BackupTarget)
+	check(c.BackupTarget != "local" || c.BackupDir != "", "BACKUP_DIR: required for local backups")
+	check(c.BackupTarget != "s3" || c.BackupS3Bucket != "", "BACKUP_S3_BUCKET: required for s3 backups")
+
+	// Security
+	if c.EnableHTTPS {
+		check(len(c.TLSAutocertDomains) > 0 || (c.TLSCertFile != "" && c.TLSKeyFile != ""),
+			"ENABLE_HTTPS: needs TLS_CERT_FILE and TLS_KEY_FILE, or TLS_AUTOCERT_DOMAINS")
+	}
+	check(!c.RequireSignedMessages || c.SignMessages, "REQUIRE_SIGNED_MESSAGES: needs SIGN_MESSAGES")
+	if c.SnapshotKey != "" {
+		key, err := hex.DecodeString(c.SnapshotKey)
+		check(err == nil && len(key) == 32, "SNAPSHOT_KEY: must be 64 hex characters")
+	}
+	for name, value := range map[string]int{
+		"RATE_LIMIT_ACTION_RPS":   c.RateLimitActionRPS,
+		"RATE_LIMIT_ACTION_BURST": c.RateLimitActionBurst,
+		"RATE_LIMIT_READ_RPS":     c.RateLimitReadRPS,
+		"RATE_LIMIT_READ_BURST":   c.RateLimitReadBurst,
+		"RATE_LIMIT_WS_RPS":       c.RateLimitWSRPS,
+		"RATE_LIMIT_WS_BURST":     c.RateLimitWSBurst,
+	} {
+		check(value >= 0, "%s: %d is negative", name, value)
+	}
+
+	if len(problems) > 0 {
+		return joinErrors(problems)
+	}
+	return nil
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+func oneOf(value string, options ...string) bool {
+	for _, option := range options {
+		if value == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,512 @@
+package conformance
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/RedPaladin7/peerpoker/internal/transport"
+)
+
+// DefaultChecks is the full suite, in the order the flows build on each other
+func DefaultChecks() []Check {
+	return []Check{
+		{"handshake/greeting", "Node opens negotiation with a well-formed handshake", checkGreeting},
+		{"handshake/negotiate", "Node answers a compatible peer handshake", checkNegotiate},
+		{"handshake/version-mismatch", "Node rejects an incompatible major version", checkVersionMismatch},
+		{"handshake/variant-mismatch", "Node rejects a peer for another game variant", checkVariantMismatch},
+		{"encoding/binary", "Node switches to protobuf frames once binary encoding is negotiated", checkBinaryEncoding},
+		{"encoding/chunking", "Node reassembles a message sent as chunks", checkChunking},
+		{"liveness/ping", "Node answers a ping with a pong", checkPing},
+		{"seating/ready", "Node relays a seated peer's ready message", checkReady},
+		{"seating/seat-draw", "Node completes the commit-reveal seat draw", checkSeatDraw},
+		{"shuffle/enc-deck", "Node adds its layer to an encrypted deck", checkShuffle},
+		{"action/fold", "Node applies a peer's action on its turn", checkFold},
+		{"reveal/get-rpc", "Node decrypts cards on request", checkReveal},
+	}
+}
+
+// handshake is the tester's own handshake
+func (s *Session) handshake(id string, caps protocol.Capability) protocol.HandshakePayload {
+	return protocol.HandshakePayload{
+		Version:      protocol.ProtocolVersion,
+		GameVariant:  protocol.GameVariantTexasHoldem,
+		ListenAddr:   id,
+		Capabilities: protocol.CapVariantTexasHoldem | caps,
+	}
+}
+
+// connect opens a fresh peer connection and consumes the node's greeting
+func (s *Session) connect(suffix string) (*peerConn, protocol.HandshakePayload, error) {
+	var greeting protocol.HandshakePayload
+
+	p, err := dialPeer(s.cfg, s.cfg.PeerID+suffix)
+	if err != nil {
+		return nil, greeting, err
+	}
+
+	msg, _, err := p.expect(s.cfg.Timeout, protocol.TypeHandshake)
+	if err != nil {
+		p.close()
+		return nil, greeting, fmt.Errorf("no greeting: %w", err)
+	}
+	if err := json.Unmarshal(msg.Payload, &greeting); err != nil {
+		p.close()
+		return nil, greeting, fmt.Errorf("malformed greeting: %w", err)
+	}
+	return p, greeting, nil
+}
+
+func checkGreeting(s *Session) error {
+	p, greeting, err := s.connect("")
+	if err != nil {
+		return err
+	}
+	s.greeting = p
+
+	if _, _, err := protocol.ParseProtocolVersion(greeting.Version); err != nil {
+		return err
+	}
+	if err := protocol.ValidateGameVariant(greeting.GameVariant); err != nil {
+		return err
+	}
+	variantCap, _ := protocol.VariantCapability(greeting.GameVariant)
+	if !greeting.Capabilities.Has(variantCap) {
+		return fmt.Errorf("node plays %s but does not advertise it (capabilities %s)",
+			greeting.GameVariant, greeting.Capabilities)
+	}
+	if greeting.ListenAddr == "" {
+		return fmt.Errorf("greeting has no listen address")
+	}
+	return nil
+}
+
+func checkNegotiate(s *Session) error {
+	if s.greeting == nil {
+		return skip("no greeting connection")
+	}
+
+	if err := s.greeting.send(protocol.TypeHandshake, s.handshake(s.greeting.id, 0)); err != nil {
+		return err
+	}
+
+	msg, _, err := s.greeting.expect(s.cfg.Timeout, protocol.TypeHandshake)
+	if err != nil {
+		return err
+	}
+
+	var reply protocol.HandshakePayload
+	if err := json.Unmarshal(msg.Payload, &reply); err != nil {
+		return fmt.Errorf("malformed handshake reply: %w", err)
+	}
+	if reply.GameVariant != protocol.GameVariantTexasHoldem {
+		return fmt.Errorf("reply advertises variant %s", reply.GameVariant)
+	}
+	return nil
+}
+
+// expectRejection sends a handshake on a fresh connection and expects an error code back
+func (s *Session) expectRejection(suffix string, hs protocol.HandshakePayload, code string) error {
+	p, _, err := s.connect(suffix)
+	if err != nil {
+		return err
+	}
+	defer p.close()
+
+	hs.ListenAddr = p.id
+	if err := p.send(protocol.TypeHandshake, hs); err != nil {
+		return err
+	}
+
+	msg, _, err := p.expect(s.cfg.Timeout, protocol.TypeError, protocol.TypeHandshake)
+	if err != nil {
+		return err
+	}
+	if msg.Type != protocol.TypeError {
+		return fmt.Errorf("node accepted the handshake")
+	}
+
+	var payload protocol.ErrorPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		return fmt.Errorf("malformed error payload: %w", err)
+	}
+	if payload.Code != code {
+		return fmt.Errorf("expected error %s, got %s (%s)", code, payload.Code, payload.Message)
+	}
+	return nil
+}
+
+func checkVersionMismatch(s *Session) error {
+	hs := s.handshake("", 0)
+	hs.Version = "99.0"
+	return s.expectRejection("-version", hs, protocol.ErrCodeIncompatibleVersion)
+}
+
+func checkVariantMismatch(s *Session) error {
+	hs := s.handshake("", 0)
+	hs.GameVariant = protocol.GameVariantOmaha
+	hs.Capabilities = protocol.CapVariantOmaha
+	return s.expectRejection("-variant", hs, protocol.ErrCodeUnsupportedVariant)
+}
+
+func checkBinaryEncoding(s *Session) error {
+	p, greeting, err := s.connect("-binary")
+	if err != nil {
+		return err
+	}
+	defer p.close()
+
+	if !greeting.Capabilities.Has(protocol.CapBinaryEncoding) {
+		return skip("node does not advertise binary encoding")
+	}
+
+	p.encoding = protocol.EncodingProtobuf
+	if err := p.send(protocol.TypeHandshake, s.handshake(p.id, protocol.CapBinaryEncoding)); err != nil {
+		return err
+	}
+
+	_, encoding, err := p.expect(s.cfg.Timeout, protocol.TypeHandshake)
+	if err != nil {
+		return err
+	}
+	if encoding != protocol.EncodingProtobuf {
+		return fmt.Errorf("handshake reply arrived as %s", encoding)
+	}
+	return nil
+}
+
+func checkChunking(s *Session) error {
+	p, _, err := s.connect("-chunked")
+	if err != nil {
+		return err
+	}
+	defer p.close()
+
+	msg, err := protocol.NewMessage(p.id, protocol.TypeHandshake, s.handshake(p.id, 0))
+	if err != nil {
+		return err
+	}
+	data, err := protocol.EncodeMessage(msg, protocol.EncodingJSON)
+	if err != nil {
+		return err
+	}
+
+	chunks, err := transport.SplitMessage(data, protocol.EncodingJSON)
+	if err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		if err := p.send(protocol.TypeChunk, chunk.Payload); err != nil {
+			return err
+		}
+	}
+
+	if _, _, err := p.expect(s.cfg.Timeout, protocol.TypeHandshake); err != nil {
+		return fmt.Errorf("handshake sent in %d chunk(s) was not answered: %w", len(chunks), err)
+	}
+	return nil
+}
+
+func checkPing(s *Session) error {
+	if s.greeting == nil {
+		return skip("no greeting connection")
+	}
+
+	sent := time.Now().UnixNano()
+	if err := s.greeting.send(protocol.TypePing, protocol.PingPayload{Timestamp: sent}); err != nil {
+		return err
+	}
+
+	msg, _, err := s.greeting.expect(s.cfg.Timeout, protocol.TypePong)
+	if err != nil {
+		return err
+	}
+
+	var pong protocol.PongPayload
+	if err := json.Unmarshal(msg.Payload, &pong); err != nil {
+		return fmt.Errorf("malformed pong: %w", err)
+	}
+	if pong.PingTimestamp != sent {
+		return fmt.Errorf("pong echoes %d, expected %d", pong.PingTimestamp, sent)
+	}
+	return nil
+}
+
+func checkReady(s *Session) error {
+	if s.greeting == nil {
+		return skip("no greeting connection")
+	}
+	if s.cfg.APIURL == "" {
+		return skip("no API URL given; seating needs the HTTP API")
+	}
+
+	if err := s.apiCall(http.MethodPost, "/api/v1/lobby/quick-seat", nil, nil); err != nil {
+		return fmt.Errorf("could not take a seat: %w", err)
+	}
+	s.seated = true
+
+	err := s.greeting.send(protocol.TypePlayerReady, protocol.PlayerReadyPayload{PlayerID: s.cfg.PeerID})
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(s.cfg.Timeout)
+	for time.Now().Before(deadline) {
+		msg, _, err := s.greeting.expect(time.Until(deadline), protocol.TypePlayerReady)
+		if err != nil {
+			return err
+		}
+
+		var payload protocol.PlayerReadyPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return fmt.Errorf("malformed player_ready: %w", err)
+		}
+		if payload.PlayerID == s.cfg.PeerID {
+			s.ready = true
+			return nil
+		}
+	}
+	return fmt.Errorf("node did not relay our ready message")
+}
+
+// seatDrawResult mirrors the node's /api/seat-draw response
+type seatDrawResult struct {
+	Beacon string            `json:"beacon"`
+	Seeds  map[string]string `json:"seeds"`
+	Seats  []string          `json:"seats"`
+}
+
+func checkSeatDraw(s *Session) error {
+	if !s.ready {
+		return skip("tester is not seated and ready")
+	}
+	p := s.greeting
+
+	msg, _, err := p.expect(s.cfg.Timeout, protocol.TypeSeatDrawCommit)
+	if err != nil {
+		return skip("node did not start a seat draw (is its own player ready?): %v", err)
+	}
+
+	var commit protocol.SeatDrawCommitPayload
+	if err := json.Unmarshal(msg.Payload, &commit); err != nil {
+		return fmt.Errorf("malformed commitment: %w", err)
+	}
+	commitment, err := hex.DecodeString(commit.Commitment)
+	if err != nil || len(commitment) != sha256.Size {
+		return fmt.Errorf("commitment %q is not a hex SHA-256", commit.Commitment)
+	}
+
+	seed := make([]byte, 32)
+	if _, err := rand.Read(seed); err != nil {
+		return err
+	}
+	ours := sha256.Sum256(seed)
+	if err := p.send(protocol.TypeSeatDrawCommit, protocol.SeatDrawCommitPayload{
+		Commitment: hex.EncodeToString(ours[:]),
+	}); err != nil {
+		return err
+	}
+
+	// The node must not reveal before it has our commitment
+	msg, _, err = p.expect(s.cfg.Timeout, protocol.TypeSeatDrawReveal)
+	if err != nil {
+		return err
+	}
+
+	var reveal protocol.SeatDrawRevealPayload
+	if err := json.Unmarshal(msg.Payload, &reveal); err != nil {
+		return fmt.Errorf("malformed reveal: %w", err)
+	}
+	nodeSeed, err := hex.DecodeString(reveal.Seed)
+	if err != nil {
+		return fmt.Errorf("revealed seed is not hex")
+	}
+	if sum := sha256.Sum256(nodeSeed); !bytes.Equal(sum[:], commitment) {
+		return fmt.Errorf("revealed seed does not match the node's commitment")
+	}
+
+	if err := p.send(protocol.TypeSeatDrawReveal, protocol.SeatDrawRevealPayload{
+		Seed: hex.EncodeToString(seed),
+	}); err != nil {
+		return err
+	}
+
+	// Give the node a moment to finish, then check its published beacon
+	var draw seatDrawResult
+	deadline := time.Now().Add(s.cfg.Timeout)
+	for {
+		err = s.apiCall(http.MethodGet, "/api/v1/seat-draw", nil, &draw)
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	if err != nil {
+		return fmt.Errorf("seat draw did not complete: %w", err)
+	}
+	s.drawDone = true
+
+	if draw.Seeds[s.cfg.PeerID] != hex.EncodeToString(seed) {
+		return fmt.Errorf("published draw does not include our seed")
+	}
+
+	addrs := make([]string, 0, len(draw.Seeds))
+	for addr := range draw.Seeds {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	h := sha256.New()
+	for _, addr := range addrs {
+		seed, err := hex.DecodeString(draw.Seeds[addr])
+		if err != nil {
+			return fmt.Errorf("published seed for %s is not hex", addr)
+		}
+		h.Write(seed)
+	}
+	if beacon := hex.EncodeToString(h.Sum(nil)); beacon != draw.Beacon {
+		return fmt.Errorf("beacon %s does not match the revealed seeds (expected %s)", draw.Beacon, beacon)
+	}
+	return nil
+}
+
+// randomDeck builds a deck of opaque ciphertexts; the node only has to re-encrypt them
+func randomDeck(n int) ([][]byte, error) {
+	deck := make([][]byte, n)
+	for i := range deck {
+		deck[i] = make([]byte, 32)
+		if _, err := rand.Read(deck[i]); err != nil {
+			return nil, err
+		}
+	}
+	return deck, nil
+}
+
+func checkShuffle(s *Session) error {
+	if s.greeting == nil {
+		return skip("no greeting connection")
+	}
+
+	deck, err := randomDeck(52)
+	if err != nil {
+		return err
+	}
+	if err := s.greeting.send(protocol.TypeEncDeck, protocol.EncDeckPayload{Deck: deck}); err != nil {
+		return err
+	}
+
+	msg, _, err := s.greeting.expect(s.cfg.Timeout, protocol.TypeShuffleStatus)
+	if err != nil {
+		return err
+	}
+
+	var status protocol.ShuffleStatusPayload
+	if err := json.Unmarshal(msg.Payload, &status); err != nil {
+		return fmt.Errorf("malformed shuffle_status: %w", err)
+	}
+	if len(status.Deck) != len(deck) {
+		return fmt.Errorf("shuffled deck has %d cards, sent %d", len(status.Deck), len(deck))
+	}
+	return nil
+}
+
+// tableState mirrors the fields of the node's /api/table response used here
+type tableState struct {
+	Status       string   `json:"status"`
+	IsMyTurn     bool     `json:"is_my_turn"`
+	ValidActions []string `json:"valid_actions"`
+}
+
+type playerList struct {
+	Players []struct {
+		PlayerID string `json:"player_id"`
+		IsFolded bool   `json:"is_folded"`
+	} `json:"players"`
+}
+
+func checkFold(s *Session) error {
+	if !s.seated || !s.drawDone {
+		return skip("no hand in progress with the tester seated")
+	}
+
+	var table tableState
+	deadline := time.Now().Add(3 * s.cfg.Timeout)
+	for {
+		if err := s.apiCall(http.MethodGet, "/api/v1/table", nil, &table); err != nil {
+			return err
+		}
+		if table.IsMyTurn || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	if !table.IsMyTurn {
+		return skip("action never reached the tester")
+	}
+
+	if err := s.greeting.send(protocol.TypePlayerAction, protocol.PlayerActionPayload{
+		Action:            protocol.ActionFold,
+		CurrentGameStatus: table.Status,
+	}); err != nil {
+		return err
+	}
+
+	deadline = time.Now().Add(s.cfg.Timeout)
+	for time.Now().Before(deadline) {
+		var players playerList
+		if err := s.apiCall(http.MethodGet, "/api/v1/players", nil, &players); err != nil {
+			return err
+		}
+		for _, player := range players.Players {
+			if player.PlayerID == s.cfg.PeerID && player.IsFolded {
+				return nil
+			}
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	return fmt.Errorf("node did not apply the fold")
+}
+
+func checkReveal(s *Session) error {
+	if s.greeting == nil {
+		return skip("no greeting connection")
+	}
+
+	cards, err := randomDeck(2)
+	if err != nil {
+		return err
+	}
+	indices := []int{0, 1}
+	if err := s.greeting.send(protocol.TypeGetRPC, protocol.GetRPCPayload{
+		CardIndices:   indices,
+		EncryptedData: cards,
+		OriginalOwner: s.cfg.PeerID,
+	}); err != nil {
+		return err
+	}
+
+	msg, _, err := s.greeting.expect(s.cfg.Timeout, protocol.TypeRPCResponse)
+	if err != nil {
+		return err
+	}
+
+	var resp protocol.RPCResponsePayload
+	if err := json.Unmarshal(msg.Payload, &resp); err != nil {
+		return fmt.Errorf("malformed rpc_response: %w", err)
+	}
+	if len(resp.CardIndices) != len(indices) || len(resp.DecryptedData) != len(indices) {
+		return fmt.Errorf("rpc_response covers %d cards, requested %d", len(resp.DecryptedData), len(indices))
+	}
+	for i, idx := range indices {
+		if resp.CardIndices[i] != idx {
+			return fmt.Errorf("rpc_response card indices %v, requested %v", resp.CardIndices, indices)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package conformance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/gorilla/websocket"
+)
+
+// peerConn is the tester's side of a peer connection
+type peerConn struct {
+	id       string
+	conn     *websocket.Conn
+	encoding protocol.Encoding
+}
+
+func dialPeer(cfg Config, id string) (*peerConn, error) {
+	dialer := websocket.Dialer{HandshakeTimeout: cfg.Timeout}
+
+	header := http.Header{}
+	header.Set("X-Client-ID", id)
+
+	conn, _, err := dialer.Dial(cfg.PeerURL, header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %w", cfg.PeerURL, err)
+	}
+
+	return &peerConn{
+		id:       id,
+		conn:     conn,
+		encoding: protocol.EncodingJSON,
+	}, nil
+}
+
+// send writes a message in the connection's current encoding
+func (p *peerConn) send(msgType protocol.MessageType, payload interface{}) error {
+	msg, err := protocol.NewMessage(p.id, msgType, payload)
+	if err != nil {
+		return err
+	}
+
+	data, err := protocol.EncodeMessage(msg, p.encoding)
+	if err != nil {
+		return err
+	}
+
+	frameType := websocket.TextMessage
+	if p.encoding == protocol.EncodingProtobuf {
+		frameType = websocket.BinaryMessage
+	}
+	return p.conn.WriteMessage(frameType, data)
+}
+
+// next reads one message, reporting the encoding it arrived in
+func (p *peerConn) next(deadline time.Time) (*protocol.Message, protocol.Encoding, error) {
+	p.conn.SetReadDeadline(deadline)
+
+	frameType, data, err := p.conn.ReadMessage()
+	if err != nil {
+		return nil, "", err
+	}
+
+	encoding := protocol.EncodingJSON
+	if frameType == websocket.BinaryMessage {
+		encoding = protocol.EncodingProtobuf
+	}
+
+	msg, err := protocol.DecodeMessage(data, encoding)
+	if err != nil {
+		return nil, encoding, fmt.Errorf("undecodable %s frame: %w", encoding, err)
+	}
+	return msg, encoding, nil
+}
+
+// expect waits for a message of one of the given types, skipping anything
+// else. An unexpected protocol error from the node fails immediately.
+func (p *peerConn) expect(timeout time.Duration, types ...protocol.MessageType) (*protocol.Message, protocol.Encoding, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		msg, encoding, err := p.next(deadline)
+		if err != nil {
+			if netErr, ok := err.(interface{ Timeout() bool }); ok && netErr.Timeout() {
+				return nil, "", fmt.Errorf("no %v within %s", types, timeout)
+			}
+			return nil, "", err
+		}
+
+		for _, t := range types {
+			if msg.Type == t {
+				return msg, encoding, nil
+			}
+		}
+
+		if msg.Type == protocol.TypeError {
+			var payload protocol.ErrorPayload
+			json.Unmarshal(msg.Payload, &payload)
+			return nil, "", fmt.Errorf("node sent error %s: %s", payload.Code, payload.Message)
+		}
+	}
+}
+
+func (p *peerConn) close() {
+	p.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(time.Second))
+	p.conn.Close()
+}
+
+// apiCall makes a request to the node's HTTP API as the tester
+func (s *Session) apiCall(method, path string, body, out interface{}) error {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, s.cfg.APIURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Client-ID", s.cfg.PeerID)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := &http.Client{Timeout: s.cfg.Timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var msg bytes.Buffer
+		msg.ReadFrom(resp.Body)
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(msg.Bytes()))
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// Package conformance checks that a node speaks the peer protocol correctly.
+//
+// The tester connects to a node's /p2p endpoint as an ordinary peer and drives
+// the handshake, seating, shuffle, action and reveal flows, reporting which
+// behaviors pass or fail. It only relies on the wire protocol (plus the public
+// HTTP API for seating), so it can be pointed at any implementation.
+package conformance
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Status is the outcome of a single check
+type Status string
+
+const (
+	StatusPass Status = "PASS"
+	StatusFail Status = "FAIL"
+	StatusSkip Status = "SKIP"
+)
+
+// Config describes the node under test
+type Config struct {
+	PeerURL string        // WebSocket URL of the node's peer endpoint, e.g. ws://host:3000/p2p
+	APIURL  string        // HTTP API base URL; flows that need seating are skipped when empty
+	PeerID  string        // Address the tester identifies itself as
+	Timeout time.Duration // How long to wait for each expected message
+}
+
+// Result is the outcome of one protocol behavior
+type Result struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Status      Status        `json:"status"`
+	Detail      string        `json:"detail,omitempty"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// Report is the outcome of a full run
+type Report struct {
+	Target    string    `json:"target"`
+	StartedAt time.Time `json:"started_at"`
+	Results   []Result  `json:"results"`
+}
+
+// Count returns how many checks ended with the given status
+func (r *Report) Count(status Status) int {
+	n := 0
+	for _, result := range r.Results {
+		if result.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Passed reports whether no check failed
+func (r *Report) Passed() bool {
+	return r.Count(StatusFail) == 0
+}
+
+// Check is a single protocol behavior
+type Check struct {
+	Name        string
+	Description string
+	Run         func(s *Session) error
+}
+
+// errSkip marks a check that could not be exercised against this node
+type errSkip struct {
+	reason string
+}
+
+func (e errSkip) Error() string {
+	return e.reason
+}
+
+func skip(format string, args ...interface{}) error {
+	return errSkip{reason: fmt.Sprintf(format, args...)}
+}
+
+// Session is shared state for one run; checks run in order and later flows
+// build on the seat taken by earlier ones
+type Session struct {
+	cfg Config
+
+	greeting *peerConn // connection opened by the handshake checks
+	seated   bool
+	ready    bool
+	drawDone bool
+}
+
+// Run executes every check against the node and returns the report
+func Run(cfg Config, checks []Check) *Report {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+
+	report := &Report{
+		Target:    cfg.PeerURL,
+		StartedAt: time.Now(),
+	}
+
+	s := &Session{cfg: cfg}
+	defer s.close()
+
+	for _, check := range checks {
+		start := time.Now()
+		err := check.Run(s)
+
+		result := Result{
+			Name:        check.Name,
+			Description: check.Description,
+			Status:      StatusPass,
+			Duration:    time.Since(start),
+		}
+
+		var skipped errSkip
+		switch {
+		case errors.As(err, &skipped):
+			result.Status = StatusSkip
+			result.Detail = skipped.reason
+		case err != nil:
+			result.Status = StatusFail
+			result.Detail = err.Error()
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+func (s *Session) close() {
+	if s.greeting != nil {
+		s.greeting.close()
+	}
+}
@@ -1,6 +1,7 @@
 package crypto
 
 import (
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"math/big"
@@ -22,7 +23,15 @@ func (ck *CardKeys) Serialize() SerializedKeys {
 	}
 }
 
-// DeserializeKeys converts SerializedKeys back to CardKeys
+// Commitment hashes the encryption key and prime, binding a player to their
+// keys before any card is dealt without revealing them
+func (ck *CardKeys) Commitment() string {
+	sum := sha256.Sum256([]byte(ck.Prime.Text(16) + ":" + ck.EncKey.Text(16)))
+	return hex.EncodeToString(sum[:])
+}
+
+// DeserializeKeys converts SerializedKeys back to CardKeys, rejecting keys
+// that fail Validate
 func DeserializeKeys(sk SerializedKeys) (*CardKeys, error) {
 	encKey := new(big.Int)
 	if _, ok := encKey.SetString(sk.EncKey, 16); !ok {
@@ -39,11 +48,15 @@ func DeserializeKeys(sk SerializedKeys) (*CardKeys, error) {
 		return nil, fmt.Errorf("invalid prime format")
 	}
 
-	return &CardKeys{
+	keys := &CardKeys{
 		EncKey: encKey,
 		DecKey: decKey,
 		Prime:  prime,
-	}, nil
+	}
+	if err := keys.Validate(); err != nil {
+		return nil, err
+	}
+	return keys, nil
 }
 
 // ToHex converts a byte slice to hex string
@@ -0,0 +1,101 @@
+package crypto
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+// Keys and ciphertexts arrive from peers, so validating or decrypting them
+// may fail but must never panic or run away. Plain go test runs each target
+// over its seeds; go test -fuzz explores from them.
+
+// seedKeys generates real card keys for seeds
+func seedKeys(f *testing.F) *CardKeys {
+	f.Helper()
+	keys, err := GenerateCardKeys()
+	if err != nil {
+		f.Fatalf("failed to generate seed keys: %v", err)
+	}
+	return keys
+}
+
+// packKeyInput lays out a prime, a key pair and a ciphertext as FuzzDecrypt
+// reads them, each prefixed by a length byte
+func packKeyInput(parts ...[]byte) []byte {
+	var data []byte
+	for _, part := range parts {
+		data = append(data, byte(len(part)))
+		data = append(data, part...)
+	}
+	return data
+}
+
+// FuzzDecrypt splits the input into a prime, a key pair and a ciphertext,
+// each prefixed by a length byte, and decrypts with them whether or not
+// they validate
+func FuzzDecrypt(f *testing.F) {
+	keys := seedKeys(f)
+	card := keys.Encrypt([]byte{12})
+	f.Add(packKeyInput(keys.Prime.Bytes(), keys.EncKey.Bytes(), keys.DecKey.Bytes(), card))
+	f.Add(packKeyInput(keys.Prime.Bytes(), keys.DecKey.Bytes(), keys.EncKey.Bytes(), []byte{}))
+	f.Add(packKeyInput([]byte{0}, []byte{1}, []byte{1}, []byte{1}))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var parts [4][]byte
+		for i := range parts {
+			if len(data) == 0 {
+				return
+			}
+			n := int(data[0])
+			data = data[1:]
+			if n > len(data) {
+				n = len(data)
+			}
+			parts[i], data = data[:n], data[n:]
+		}
+
+		keys := &CardKeys{
+			Prime:  new(big.Int).SetBytes(parts[0]),
+			EncKey: new(big.Int).SetBytes(parts[1]),
+			DecKey: new(big.Int).SetBytes(parts[2]),
+		}
+		keys.Decrypt(parts[3])
+		keys.DecryptCard(parts[3])
+		if keys.Validate() != nil {
+			return
+		}
+		keys.Encrypt(parts[3])
+	})
+}
+
+// FuzzDeserializeKeys parses serialized keys as a shuffle audit carries them
+func FuzzDeserializeKeys(f *testing.F) {
+	keys := seedKeys(f)
+	for _, sk := range []SerializedKeys{
+		keys.Serialize(),
+		{EncKey: keys.EncKey.Text(16), DecKey: keys.EncKey.Text(16), Prime: keys.Prime.Text(16)},
+		{EncKey: "zz", DecKey: "", Prime: "7"},
+	} {
+		data, err := json.Marshal(sk)
+		if err != nil {
+			f.Fatalf("failed to encode seed keys: %v", err)
+		}
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var sk SerializedKeys
+		if err := json.Unmarshal(data, &sk); err != nil {
+			return
+		}
+		keys, err := DeserializeKeys(sk)
+		if err != nil {
+			return
+		}
+		if err := keys.Validate(); err != nil {
+			t.Fatalf("deserialized keys do not validate: %v", err)
+		}
+		keys.Decrypt(keys.Encrypt([]byte{1}))
+	})
+}
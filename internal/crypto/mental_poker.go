@@ -6,6 +6,10 @@ import (
 	"math/big"
 )
 
+// MaxPrimeBits bounds the prime a peer's keys may use, so revealed keys
+// cannot make every card decryption arbitrarily slow
+const MaxPrimeBits = 4096
+
 // CardKeys represents encryption/decryption keys for mental poker
 type CardKeys struct {
 	EncKey *big.Int
@@ -70,8 +74,23 @@ func generateRandomKey(prime *big.Int) (*big.Int, error) {
 	return nil, fmt.Errorf("failed to generate coprime key after %d attempts", maxAttempts)
 }
 
-// Encrypt encrypts a byte array using the encryption key
+// usable reports whether the keys can be exponentiated with: set, and of
+// bounded size. Keys from peers are checked in full by Validate.
+func (ck *CardKeys) usable() bool {
+	if ck == nil || ck.EncKey == nil || ck.DecKey == nil || ck.Prime == nil {
+		return false
+	}
+	return ck.Prime.Sign() > 0 && ck.Prime.BitLen() <= MaxPrimeBits &&
+		ck.EncKey.BitLen() <= MaxPrimeBits && ck.DecKey.BitLen() <= MaxPrimeBits
+}
+
+// Encrypt encrypts a byte array using the encryption key. Unusable keys
+// encrypt nothing.
 func (ck *CardKeys) Encrypt(data []byte) []byte {
+	if !ck.usable() {
+		return nil
+	}
+
 	// Convert bytes to big.Int
 	plaintext := new(big.Int).SetBytes(data)
 
@@ -81,8 +100,13 @@ func (ck *CardKeys) Encrypt(data []byte) []byte {
 	return ciphertext.Bytes()
 }
 
-// Decrypt decrypts a byte array using the decryption key
+// Decrypt decrypts a byte array using the decryption key. Unusable keys
+// decrypt nothing, which callers treat as an unreadable card.
 func (ck *CardKeys) Decrypt(data []byte) []byte {
+	if !ck.usable() {
+		return nil
+	}
+
 	// Convert bytes to big.Int
 	ciphertext := new(big.Int).SetBytes(data)
 
@@ -119,11 +143,54 @@ func (ck *CardKeys) Clone() *CardKeys {
 	}
 }
 
-// Validate checks if the keys are valid
+// Zero overwrites the secret keys in place, leaving them unusable. Copies
+// made earlier, e.g. by Serialize, are not reached.
+func (ck *CardKeys) Zero() {
+	for _, key := range []*big.Int{ck.EncKey, ck.DecKey} {
+		if key == nil {
+			continue
+		}
+		words := key.Bits()
+		for i := range words {
+			words[i] = 0
+		}
+		key.SetInt64(0)
+	}
+}
+
+// DecryptCard decrypts a card received from a peer, rejecting input that
+// cannot be a ciphertext under these keys
+func (ck *CardKeys) DecryptCard(data []byte) ([]byte, error) {
+	if !ck.usable() {
+		return nil, fmt.Errorf("keys are not initialized")
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty ciphertext")
+	}
+	if new(big.Int).SetBytes(data).Cmp(ck.Prime) >= 0 {
+		return nil, fmt.Errorf("ciphertext is not below the prime")
+	}
+	return ck.Decrypt(data), nil
+}
+
+// Validate checks if the keys are valid. Keys revealed by peers are
+// untrusted, so this must hold before they are used.
 func (ck *CardKeys) Validate() error {
-	if ck.EncKey == nil || ck.DecKey == nil || ck.Prime == nil {
+	if ck == nil || ck.EncKey == nil || ck.DecKey == nil || ck.Prime == nil {
 		return fmt.Errorf("keys are not initialized")
 	}
+	if ck.Prime.BitLen() > MaxPrimeBits {
+		return fmt.Errorf("prime exceeds %d bits", MaxPrimeBits)
+	}
+	if ck.Prime.Cmp(big.NewInt(3)) <= 0 {
+		return fmt.Errorf("prime is too small")
+	}
+	upper := new(big.Int).Sub(ck.Prime, big.NewInt(1))
+	for _, key := range []*big.Int{ck.EncKey, ck.DecKey} {
+		if key.Sign() <= 0 || key.Cmp(upper) >= 0 {
+			return fmt.Errorf("key out of range")
+		}
+	}
 
 	// Verify that encKey * decKey ≡ 1 (mod prime-1)
 	phiN := new(big.Int).Sub(ck.Prime, big.NewInt(1))
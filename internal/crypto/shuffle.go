@@ -2,6 +2,9 @@ package crypto
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"math/big"
 )
 
@@ -62,6 +65,19 @@ func ApplyPermutation(deck [][]byte, permutation []int) [][]byte {
 	return shuffled
 }
 
+// DeckFingerprint hashes a deck in order. Each card is length-prefixed, so
+// no two different decks share an encoding.
+func DeckFingerprint(deck [][]byte) string {
+	h := sha256.New()
+	var length [4]byte
+	for _, card := range deck {
+		binary.BigEndian.PutUint32(length[:], uint32(len(card)))
+		h.Write(length[:])
+		h.Write(card)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 // VerifyShuffle checks if a deck has been properly shuffled (not in original order)
 func VerifyShuffle(original, shuffled [][]byte) bool {
 	if len(original) != len(shuffled) {
@@ -1,6 +1,10 @@
 package deck
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 // Suit represents a card suit
 type Suit int
@@ -128,3 +132,63 @@ func (c Card) Compare(other Card) int {
 	}
 	return 0
 }
+
+// ParseCard parses a card written as value then suit letter, e.g. "As",
+// "Td" or "10h"
+func ParseCard(s string) (Card, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return Card{}, fmt.Errorf("invalid card %q", s)
+	}
+
+	var suit Suit
+	switch strings.ToLower(s[len(s)-1:]) {
+	case "h":
+		suit = Hearts
+	case "d":
+		suit = Diamonds
+	case "c":
+		suit = Clubs
+	case "s":
+		suit = Spades
+	default:
+		return Card{}, fmt.Errorf("invalid suit in card %q", s)
+	}
+
+	var value int
+	switch strings.ToUpper(s[:len(s)-1]) {
+	case "A":
+		value = 14
+	case "K":
+		value = 13
+	case "Q":
+		value = 12
+	case "J":
+		value = 11
+	case "T", "10":
+		value = 10
+	default:
+		v, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil || v < 2 || v > 9 {
+			return Card{}, fmt.Errorf("invalid value in card %q", s)
+		}
+		value = v
+	}
+	return NewCard(suit, value), nil
+}
+
+// ParseCards parses a comma-separated list of cards; an empty string is no cards
+func ParseCards(s string) ([]Card, error) {
+	cards := []Card{}
+	if strings.TrimSpace(s) == "" {
+		return cards, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		card, err := ParseCard(part)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
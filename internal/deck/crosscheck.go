@@ -0,0 +1,155 @@
+package deck
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CrossCheckMismatch records a sampled hand the two engines disagreed on
+type CrossCheckMismatch struct {
+	Time      time.Time `json:"time"`
+	Reason    string    `json:"reason"`
+	Cards     []string  `json:"cards"`
+	Previous  []string  `json:"previous,omitempty"`
+	Primary   string    `json:"primary"`
+	Reference string    `json:"reference"`
+}
+
+// CrossCheckStats summarizes a cross-check run
+type CrossCheckStats struct {
+	Primary      string              `json:"primary"`
+	Reference    string              `json:"reference"`
+	SampleRate   float64             `json:"sample_rate"`
+	Samples      int64               `json:"samples"`
+	Mismatches   int64               `json:"mismatches"`
+	LastMismatch *CrossCheckMismatch `json:"last_mismatch,omitempty"`
+}
+
+// crossSample is the last hand scored by both engines
+type crossSample struct {
+	cards     []string
+	primary   int32
+	reference int32
+}
+
+// CrossCheckEvaluator answers with its primary engine and, on a sample of
+// calls, re-scores the hand with a reference engine. Raw ranks differ between
+// engines, so it compares hand categories and the ordering of consecutive
+// samples (players at the same showdown are scored back to back).
+type CrossCheckEvaluator struct {
+	primary   HandEvaluator
+	reference HandEvaluator
+	rate      float64
+
+	mu    sync.Mutex
+	rng   *rand.Rand
+	prev  *crossSample
+	stats CrossCheckStats
+}
+
+// NewCrossCheckEvaluator samples the given fraction of evaluations (0..1)
+func NewCrossCheckEvaluator(primary, reference HandEvaluator, rate float64) *CrossCheckEvaluator {
+	return &CrossCheckEvaluator{
+		primary:   primary,
+		reference: reference,
+		rate:      rate,
+		rng:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		stats: CrossCheckStats{
+			Primary:    primary.Name(),
+			Reference:  reference.Name(),
+			SampleRate: rate,
+		},
+	}
+}
+
+func (c *CrossCheckEvaluator) Name() string {
+	return c.primary.Name()
+}
+
+func (c *CrossCheckEvaluator) EvaluateBestHand(holeCards, communityCards []Card) (int32, string) {
+	rank, name := c.primary.EvaluateBestHand(holeCards, communityCards)
+
+	c.mu.Lock()
+	sampled := c.rng.Float64() < c.rate
+	c.mu.Unlock()
+
+	if sampled {
+		refRank, refName := c.reference.EvaluateBestHand(holeCards, communityCards)
+		c.record(cardNames(holeCards, communityCards), rank, name, refRank, refName)
+	}
+	return rank, name
+}
+
+// Stats returns the cross-check counters
+func (c *CrossCheckEvaluator) Stats() CrossCheckStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	if stats.LastMismatch != nil {
+		last := *stats.LastMismatch
+		stats.LastMismatch = &last
+	}
+	return stats
+}
+
+func (c *CrossCheckEvaluator) record(cards []string, rank int32, name string, refRank int32, refName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.Samples++
+	prev := c.prev
+	c.prev = &crossSample{cards: cards, primary: rank, reference: refRank}
+
+	mismatch := &CrossCheckMismatch{
+		Time:      time.Now(),
+		Cards:     cards,
+		Primary:   name,
+		Reference: refName,
+	}
+	switch {
+	case name != refName:
+		mismatch.Reason = "category"
+	case prev != nil && compareRanks(rank, prev.primary) != compareRanks(refRank, prev.reference):
+		mismatch.Reason = "ordering"
+		mismatch.Previous = prev.cards
+	default:
+		return
+	}
+
+	c.stats.Mismatches++
+	c.stats.LastMismatch = mismatch
+
+	logrus.WithFields(logrus.Fields{
+		"reason":    mismatch.Reason,
+		"cards":     cards,
+		"previous":  mismatch.Previous,
+		"primary":   name,
+		"reference": refName,
+	}).Warnf("Hand evaluators %s and %s disagree", c.stats.Primary, c.stats.Reference)
+}
+
+func compareRanks(a, b int32) int {
+	switch {
+	case a > b:
+		return 1
+	case a < b:
+		return -1
+	default:
+		return 0
+	}
+}
+
+func cardNames(holeCards, communityCards []Card) []string {
+	names := make([]string, 0, len(holeCards)+len(communityCards))
+	for _, card := range holeCards {
+		names = append(names, card.String())
+	}
+	for _, card := range communityCards {
+		names = append(names, card.String())
+	}
+	return names
+}
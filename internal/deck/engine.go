@@ -0,0 +1,75 @@
+package deck
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// HandEvaluator scores the best five-card hand available from hole and board
+// cards. Ranks are only comparable between hands scored by the same engine;
+// higher is stronger.
+type HandEvaluator interface {
+	Name() string
+	EvaluateBestHand(holeCards, communityCards []Card) (int32, string)
+}
+
+// EvaluatorFactory builds an evaluator engine
+type EvaluatorFactory func() (HandEvaluator, error)
+
+var (
+	enginesMu sync.RWMutex
+	engines   = map[string]EvaluatorFactory{
+		"default": func() (HandEvaluator, error) { return DefaultEvaluator(), nil },
+		"lookup":  func() (HandEvaluator, error) { return NewLookupEvaluator(), nil },
+	}
+)
+
+// RegisterEvaluator makes an engine available by name. External engines (for
+// example a cgo binding built behind its own build tag) register from init.
+func RegisterEvaluator(name string, factory EvaluatorFactory) {
+	enginesMu.Lock()
+	defer enginesMu.Unlock()
+	engines[name] = factory
+}
+
+// NewEvaluator builds a registered engine by name
+func NewEvaluator(name string) (HandEvaluator, error) {
+	enginesMu.RLock()
+	factory, ok := engines[name]
+	enginesMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown hand evaluator %q (available: %v)", name, EvaluatorNames())
+	}
+	return factory()
+}
+
+// EvaluatorNames lists the registered engines
+func EvaluatorNames() []string {
+	enginesMu.RLock()
+	defer enginesMu.RUnlock()
+
+	names := make([]string, 0, len(engines))
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// defaultEvaluator wraps the built-in combinatorial evaluator
+type defaultEvaluator struct{}
+
+// DefaultEvaluator returns the built-in evaluator (see EvaluateBestHand)
+func DefaultEvaluator() HandEvaluator {
+	return defaultEvaluator{}
+}
+
+func (defaultEvaluator) Name() string {
+	return "default"
+}
+
+func (defaultEvaluator) EvaluateBestHand(holeCards, communityCards []Card) (int32, string) {
+	return EvaluateBestHand(holeCards, communityCards)
+}
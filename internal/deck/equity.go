@@ -0,0 +1,114 @@
+package deck
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Limits on an equity calculation
+const (
+	MaxEquityOpponents  = 9
+	MaxEquityIterations = 200000
+)
+
+// Equity is a hand's estimated share of the pot against random opponent hands
+type Equity struct {
+	Win        float64 `json:"win"`    // fraction of runouts won outright
+	Tie        float64 `json:"tie"`    // fraction of runouts split
+	Lose       float64 `json:"lose"`   // fraction of runouts lost
+	Equity     float64 `json:"equity"` // expected share of the pot, counting split pots
+	Iterations int     `json:"iterations"`
+}
+
+// CalculateEquity estimates the equity of two hole cards on a board of 0, 3,
+// 4 or 5 cards against numOpponents random hands, by Monte Carlo simulation
+// of the unseen cards. It is CPU-bound and holds no locks, so callers should
+// copy any game state they need before calling it.
+func CalculateEquity(hole, board []Card, numOpponents, iterations int) (Equity, error) {
+	if len(hole) != 2 {
+		return Equity{}, fmt.Errorf("need exactly 2 hole cards, got %d", len(hole))
+	}
+	switch len(board) {
+	case 0, 3, 4, 5:
+	default:
+		return Equity{}, fmt.Errorf("board must have 0, 3, 4 or 5 cards, got %d", len(board))
+	}
+	if numOpponents < 1 || numOpponents > MaxEquityOpponents {
+		return Equity{}, fmt.Errorf("opponents must be between 1 and %d", MaxEquityOpponents)
+	}
+	if iterations < 1 || iterations > MaxEquityIterations {
+		return Equity{}, fmt.Errorf("iterations must be between 1 and %d", MaxEquityIterations)
+	}
+
+	// The unseen cards are everything not in the hand or on the board
+	known := make(map[Card]bool, len(hole)+len(board))
+	for _, card := range append(append([]Card{}, hole...), board...) {
+		if !card.IsValid() {
+			return Equity{}, fmt.Errorf("invalid card %v", card)
+		}
+		if known[card] {
+			return Equity{}, fmt.Errorf("card %s appears twice", card)
+		}
+		known[card] = true
+	}
+	unseen := make([]Card, 0, 52-len(known))
+	for _, card := range NewDeck().Cards {
+		if !known[card] {
+			unseen = append(unseen, card)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	missing := 5 - len(board)
+	needed := missing + 2*numOpponents
+	fullBoard := make([]Card, 5)
+	copy(fullBoard, board)
+
+	var wins, ties, losses int
+	var share float64
+	for i := 0; i < iterations; i++ {
+		// Partial shuffle: only the cards this runout needs
+		for j := 0; j < needed; j++ {
+			k := j + rng.Intn(len(unseen)-j)
+			unseen[j], unseen[k] = unseen[k], unseen[j]
+		}
+		copy(fullBoard[len(board):], unseen[:missing])
+
+		mine, _ := EvaluateBestHandValue(hole, fullBoard)
+		beaten, tied := false, 0
+		for opp := 0; opp < numOpponents; opp++ {
+			start := missing + 2*opp
+			theirs, _ := EvaluateBestHandValue(unseen[start:start+2], fullBoard)
+			switch mine.Compare(theirs) {
+			case -1:
+				beaten = true
+			case 0:
+				tied++
+			}
+			if beaten {
+				break
+			}
+		}
+
+		switch {
+		case beaten:
+			losses++
+		case tied > 0:
+			ties++
+			share += 1 / float64(tied+1)
+		default:
+			wins++
+			share++
+		}
+	}
+
+	n := float64(iterations)
+	return Equity{
+		Win:        float64(wins) / n,
+		Tie:        float64(ties) / n,
+		Lose:       float64(losses) / n,
+		Equity:     share / n,
+		Iterations: iterations,
+	}, nil
+}
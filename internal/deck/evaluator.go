@@ -47,38 +47,83 @@ func (hr HandRank) String() string {
 	}
 }
 
-// EvaluateBestHand finds the best 5-card hand from hole cards and community cards
+// InvalidRank is returned when there are too few cards to make a hand
+const InvalidRank int32 = -1
+
+// HandValue is a five-card hand's category followed by the card values that
+// break ties within it, most significant first. Unused kickers are zero.
+type HandValue struct {
+	Category HandRank
+	Kickers  [5]int
+}
+
+// Compare orders two hands lexicographically by category, then kickers. It
+// returns 1 if v beats other, -1 if it loses and 0 on a split.
+func (v HandValue) Compare(other HandValue) int {
+	if v.Category != other.Category {
+		if v.Category > other.Category {
+			return 1
+		}
+		return -1
+	}
+	for i := range v.Kickers {
+		if v.Kickers[i] != other.Kickers[i] {
+			if v.Kickers[i] > other.Kickers[i] {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+// Rank packs the hand into an int32 that orders exactly like Compare: four
+// bits per kicker under the category.
+func (v HandValue) Rank() int32 {
+	rank := int32(v.Category)
+	for _, kicker := range v.Kickers {
+		rank = rank<<4 | int32(kicker)
+	}
+	return rank
+}
+
+func (v HandValue) String() string {
+	return v.Category.String()
+}
+
+// EvaluateBestHand finds the best 5-card hand from hole cards and community
+// cards. Higher ranks are stronger.
 func EvaluateBestHand(holeCards, communityCards []Card) (int32, string) {
-	allCards := append(holeCards, communityCards...)
-	
-	if len(allCards) < 5 {
+	best, ok := EvaluateBestHandValue(holeCards, communityCards)
+	if !ok {
 		// Not enough cards to make a hand
-		return 999999, "Invalid Hand"
+		return InvalidRank, "Invalid Hand"
+	}
+	return best.Rank(), best.String()
+}
+
+// EvaluateBestHandValue finds the best 5-card hand, or false when there are
+// fewer than five cards
+func EvaluateBestHandValue(holeCards, communityCards []Card) (HandValue, bool) {
+	allCards := make([]Card, 0, len(holeCards)+len(communityCards))
+	allCards = append(allCards, holeCards...)
+	allCards = append(allCards, communityCards...)
+	if len(allCards) < 5 {
+		return HandValue{}, false
 	}
 
 	// Generate all possible 5-card combinations
-	combinations := generateCombinations(allCards, 5)
-	
-	bestRank := int32(999999)
-	bestHandName := "High Card"
-
-	for _, combo := range combinations {
-		rank, handName := evaluateFiveCardHand(combo)
-		if rank < bestRank {
-			bestRank = rank
-			bestHandName = handName
+	var best HandValue
+	for i, combo := range generateCombinations(allCards, 5) {
+		if value := evaluateFiveCardHand(combo); i == 0 || value.Compare(best) > 0 {
+			best = value
 		}
 	}
-
-	return bestRank, bestHandName
+	return best, true
 }
 
 // evaluateFiveCardHand evaluates a specific 5-card hand
-func evaluateFiveCardHand(cards []Card) (int32, string) {
-	if len(cards) != 5 {
-		return 999999, "Invalid Hand"
-	}
-
+func evaluateFiveCardHand(cards []Card) HandValue {
 	// Sort cards by value (descending)
 	sorted := make([]Card, len(cards))
 	copy(sorted, cards)
@@ -90,69 +135,39 @@ func evaluateFiveCardHand(cards []Card) (int32, string) {
 	isStraight, straightHigh := checkStraight(sorted)
 	valueCounts := getValueCounts(sorted)
 
-	// Royal Flush
-	if isFlush && isStraight && straightHigh == 14 {
-		return int32(RoyalFlush)*1000000 + int32(straightHigh), "Royal Flush"
-	}
-
-	// Straight Flush
-	if isFlush && isStraight {
-		return int32(StraightFlush)*1000000 + int32(straightHigh), "Straight Flush"
-	}
-
-	// Four of a Kind
-	if valueCounts[0].count == 4 {
-		rank := int32(FourOfAKind)*1000000 + int32(valueCounts[0].value)*1000 + int32(valueCounts[1].value)
-		return rank, "Four of a Kind"
-	}
-
-	// Full House
-	if valueCounts[0].count == 3 && valueCounts[1].count == 2 {
-		rank := int32(FullHouse)*1000000 + int32(valueCounts[0].value)*1000 + int32(valueCounts[1].value)
-		return rank, "Full House"
-	}
-
-	// Flush
-	if isFlush {
-		rank := int32(Flush) * 1000000
-		for i, card := range sorted {
-			rank += int32(card.Value) * int32(1000/(i+1))
-		}
-		return rank, "Flush"
-	}
-
-	// Straight
-	if isStraight {
-		return int32(Straight)*1000000 + int32(straightHigh), "Straight"
-	}
-
-	// Three of a Kind
-	if valueCounts[0].count == 3 {
-		rank := int32(ThreeOfAKind)*1000000 + int32(valueCounts[0].value)*1000 + 
-			int32(valueCounts[1].value)*10 + int32(valueCounts[2].value)
-		return rank, "Three of a Kind"
-	}
-
-	// Two Pair
-	if valueCounts[0].count == 2 && valueCounts[1].count == 2 {
-		rank := int32(TwoPair)*1000000 + int32(valueCounts[0].value)*1000 + 
-			int32(valueCounts[1].value)*10 + int32(valueCounts[2].value)
-		return rank, "Two Pair"
-	}
-
-	// One Pair
-	if valueCounts[0].count == 2 {
-		rank := int32(OnePair)*1000000 + int32(valueCounts[0].value)*1000 + 
-			int32(valueCounts[1].value)*100 + int32(valueCounts[2].value)*10 + int32(valueCounts[3].value)
-		return rank, "One Pair"
+	switch {
+	case isFlush && isStraight && straightHigh == 14:
+		return HandValue{Category: RoyalFlush, Kickers: [5]int{straightHigh}}
+	case isFlush && isStraight:
+		return HandValue{Category: StraightFlush, Kickers: [5]int{straightHigh}}
+	case valueCounts[0].count == 4:
+		return groupedHand(FourOfAKind, valueCounts)
+	case valueCounts[0].count == 3 && valueCounts[1].count == 2:
+		return groupedHand(FullHouse, valueCounts)
+	case isFlush:
+		return groupedHand(Flush, valueCounts)
+	case isStraight:
+		// The wheel plays five-high
+		return HandValue{Category: Straight, Kickers: [5]int{straightHigh}}
+	case valueCounts[0].count == 3:
+		return groupedHand(ThreeOfAKind, valueCounts)
+	case valueCounts[0].count == 2 && valueCounts[1].count == 2:
+		return groupedHand(TwoPair, valueCounts)
+	case valueCounts[0].count == 2:
+		return groupedHand(OnePair, valueCounts)
+	default:
+		return groupedHand(HighCard, valueCounts)
 	}
+}
 
-	// High Card
-	rank := int32(HighCard) * 1000000
-	for i, card := range sorted {
-		rank += int32(card.Value) * int32(10000/(i+1))
+// groupedHand lists each distinct value once, biggest group first and then
+// highest value, which is the tie-break order for every non-straight hand
+func groupedHand(category HandRank, counts []valueCount) HandValue {
+	value := HandValue{Category: category}
+	for i, vc := range counts {
+		value.Kickers[i] = vc.value
 	}
-	return rank, "High Card"
+	return value
 }
 
 type valueCount struct {
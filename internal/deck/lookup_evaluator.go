@@ -0,0 +1,249 @@
+package deck
+
+import "sync"
+
+// rankPrimes maps card values 2..14 to primes so a product identifies a multiset of ranks
+var rankPrimes = [15]uint32{0, 0, 2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41}
+
+// lookupEntry is one of the 7462 distinct five-card hand classes
+type lookupEntry struct {
+	rank     int32
+	category HandRank
+}
+
+// lookupTables index every hand class: flushes by rank bitmask, everything
+// else by the product of its rank primes
+type lookupTables struct {
+	flush  map[uint16]lookupEntry
+	unique map[uint32]lookupEntry
+}
+
+var (
+	tablesOnce sync.Once
+	tables     *lookupTables
+)
+
+// lookupEvaluator is a table-driven evaluator. Its tables are built
+// independently of the default evaluator, which makes it a useful reference
+// for cross-checking.
+type lookupEvaluator struct {
+	tables *lookupTables
+}
+
+// NewLookupEvaluator returns the table-driven evaluator, building its tables on first use
+func NewLookupEvaluator() HandEvaluator {
+	tablesOnce.Do(func() {
+		tables = buildLookupTables()
+	})
+	return &lookupEvaluator{tables: tables}
+}
+
+func (e *lookupEvaluator) Name() string {
+	return "lookup"
+}
+
+func (e *lookupEvaluator) EvaluateBestHand(holeCards, communityCards []Card) (int32, string) {
+	cards := make([]Card, 0, len(holeCards)+len(communityCards))
+	cards = append(cards, holeCards...)
+	cards = append(cards, communityCards...)
+	if len(cards) < 5 {
+		return InvalidRank, "Invalid Hand"
+	}
+
+	best := lookupEntry{rank: InvalidRank}
+	var idx [5]int
+	var choose func(start, depth int)
+	choose = func(start, depth int) {
+		if depth == 5 {
+			if entry := e.evaluateFive(cards, idx); entry.rank > best.rank {
+				best = entry
+			}
+			return
+		}
+		for i := start; i <= len(cards)-(5-depth); i++ {
+			idx[depth] = i
+			choose(i+1, depth+1)
+		}
+	}
+	choose(0, 0)
+
+	if best.category == StraightFlush && best.rank == topStraightFlushRank {
+		return best.rank, RoyalFlush.String()
+	}
+	return best.rank, best.category.String()
+}
+
+func (e *lookupEvaluator) evaluateFive(cards []Card, idx [5]int) lookupEntry {
+	suited := true
+	var mask uint16
+	product := uint32(1)
+	for i, j := range idx {
+		card := cards[j]
+		if card.Value < 2 || card.Value > 14 {
+			return lookupEntry{rank: InvalidRank}
+		}
+		if i > 0 && card.Suit != cards[idx[0]].Suit {
+			suited = false
+		}
+		mask |= 1 << uint(card.Value-2)
+		product *= rankPrimes[card.Value]
+	}
+
+	if suited {
+		if entry, ok := e.tables.flush[mask]; ok {
+			return entry
+		}
+	}
+	if entry, ok := e.tables.unique[product]; ok {
+		return entry
+	}
+	return lookupEntry{rank: InvalidRank}
+}
+
+// handClassCount is the number of distinct five-card hand classes
+const handClassCount = 7462
+
+// topStraightFlushRank is the rank of an ace-high straight flush
+const topStraightFlushRank = handClassCount
+
+// buildLookupTables enumerates every hand class from strongest to weakest and
+// gives each a rank, strongest first
+func buildLookupTables() *lookupTables {
+	t := &lookupTables{
+		flush:  make(map[uint16]lookupEntry),
+		unique: make(map[uint32]lookupEntry),
+	}
+	next := int32(handClassCount)
+	addFlush := func(ranks []int, category HandRank) {
+		t.flush[rankMask(ranks)] = lookupEntry{rank: next, category: category}
+		next--
+	}
+	addUnique := func(ranks []int, category HandRank) {
+		t.unique[rankProduct(ranks)] = lookupEntry{rank: next, category: category}
+		next--
+	}
+
+	straights := straightRanks()
+	distinct := descendingSets(5, 14, nil)
+	nonStraight := make([][]int, 0, len(distinct))
+	for _, ranks := range distinct {
+		if !isStraightMask(rankMask(ranks)) {
+			nonStraight = append(nonStraight, ranks)
+		}
+	}
+
+	for _, ranks := range straights {
+		addFlush(ranks, StraightFlush)
+	}
+	for quad := 14; quad >= 2; quad-- {
+		for kicker := 14; kicker >= 2; kicker-- {
+			if kicker != quad {
+				addUnique([]int{quad, quad, quad, quad, kicker}, FourOfAKind)
+			}
+		}
+	}
+	for trips := 14; trips >= 2; trips-- {
+		for pair := 14; pair >= 2; pair-- {
+			if pair != trips {
+				addUnique([]int{trips, trips, trips, pair, pair}, FullHouse)
+			}
+		}
+	}
+	for _, ranks := range nonStraight {
+		addFlush(ranks, Flush)
+	}
+	for _, ranks := range straights {
+		addUnique(ranks, Straight)
+	}
+	for trips := 14; trips >= 2; trips-- {
+		for _, kickers := range descendingSets(2, 14, []int{trips}) {
+			addUnique(append([]int{trips, trips, trips}, kickers...), ThreeOfAKind)
+		}
+	}
+	for high := 14; high >= 2; high-- {
+		for low := high - 1; low >= 2; low-- {
+			for kicker := 14; kicker >= 2; kicker-- {
+				if kicker != high && kicker != low {
+					addUnique([]int{high, high, low, low, kicker}, TwoPair)
+				}
+			}
+		}
+	}
+	for pair := 14; pair >= 2; pair-- {
+		for _, kickers := range descendingSets(3, 14, []int{pair}) {
+			addUnique(append([]int{pair, pair}, kickers...), OnePair)
+		}
+	}
+	for _, ranks := range nonStraight {
+		addUnique(ranks, HighCard)
+	}
+
+	return t
+}
+
+// straightRanks lists the ten straights from ace-high down to the wheel
+func straightRanks() [][]int {
+	straights := make([][]int, 0, 10)
+	for high := 14; high >= 6; high-- {
+		straights = append(straights, []int{high, high - 1, high - 2, high - 3, high - 4})
+	}
+	return append(straights, []int{5, 4, 3, 2, 14})
+}
+
+// descendingSets lists every set of n distinct ranks (excluding some) in
+// descending lexicographic order, each sorted high to low
+func descendingSets(n, from int, exclude []int) [][]int {
+	excluded := func(rank int) bool {
+		for _, x := range exclude {
+			if x == rank {
+				return true
+			}
+		}
+		return false
+	}
+
+	var sets [][]int
+	var current []int
+	var pick func(from int)
+	pick = func(from int) {
+		if len(current) == n {
+			sets = append(sets, append([]int{}, current...))
+			return
+		}
+		for rank := from; rank >= 2; rank-- {
+			if excluded(rank) {
+				continue
+			}
+			current = append(current, rank)
+			pick(rank - 1)
+			current = current[:len(current)-1]
+		}
+	}
+	pick(from)
+	return sets
+}
+
+func rankMask(ranks []int) uint16 {
+	var mask uint16
+	for _, rank := range ranks {
+		mask |= 1 << uint(rank-2)
+	}
+	return mask
+}
+
+func rankProduct(ranks []int) uint32 {
+	product := uint32(1)
+	for _, rank := range ranks {
+		product *= rankPrimes[rank]
+	}
+	return product
+}
+
+func isStraightMask(mask uint16) bool {
+	for _, ranks := range straightRanks() {
+		if rankMask(ranks) == mask {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package deck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LowQualifier is the highest card a low hand may hold (eight-or-better)
+const LowQualifier = 8
+
+// LowHand is a qualifying ace-to-five low: five distinct values no higher
+// than eight, aces counting as one, highest first. Straights and flushes do
+// not count against a low.
+type LowHand struct {
+	Values [5]int
+}
+
+// Compare returns 1 if v is the better (lower) low, -1 if worse and 0 on a split
+func (v LowHand) Compare(other LowHand) int {
+	for i := range v.Values {
+		if v.Values[i] != other.Values[i] {
+			if v.Values[i] < other.Values[i] {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+// Rank packs the low into an int32 where higher is better, like high ranks
+func (v LowHand) Rank() int32 {
+	rank := int32(0)
+	for _, value := range v.Values {
+		rank = rank<<4 | int32(LowQualifier+1-value)
+	}
+	return rank
+}
+
+// String names the low by its cards, e.g. "8-6-4-2-A low"
+func (v LowHand) String() string {
+	names := make([]string, len(v.Values))
+	for i, value := range v.Values {
+		if value == 1 {
+			names[i] = "A"
+		} else {
+			names[i] = fmt.Sprintf("%d", value)
+		}
+	}
+	return strings.Join(names, "-") + " low"
+}
+
+// EvaluateOmahaHigh finds the best high hand using exactly two hole cards
+// and exactly three board cards, as Omaha requires
+func EvaluateOmahaHigh(holeCards, communityCards []Card) (HandValue, bool) {
+	var best HandValue
+	found := false
+	forEachOmahaHand(holeCards, communityCards, func(hand []Card) {
+		if value := evaluateFiveCardHand(hand); !found || value.Compare(best) > 0 {
+			best = value
+			found = true
+		}
+	})
+	return best, found
+}
+
+// EvaluateOmahaLow finds the best eight-or-better low using exactly two hole
+// cards and three board cards, or false when no low qualifies
+func EvaluateOmahaLow(holeCards, communityCards []Card) (LowHand, bool) {
+	var best LowHand
+	found := false
+	forEachOmahaHand(holeCards, communityCards, func(hand []Card) {
+		if value, ok := evaluateLow(hand); ok && (!found || value.Compare(best) > 0) {
+			best = value
+			found = true
+		}
+	})
+	return best, found
+}
+
+// forEachOmahaHand calls fn with every two-hole, three-board five-card hand
+func forEachOmahaHand(holeCards, communityCards []Card, fn func([]Card)) {
+	if len(holeCards) < 2 || len(communityCards) < 3 {
+		return
+	}
+
+	hand := make([]Card, 5)
+	for _, hole := range generateCombinations(holeCards, 2) {
+		for _, board := range generateCombinations(communityCards, 3) {
+			copy(hand, hole)
+			copy(hand[2:], board)
+			fn(hand)
+		}
+	}
+}
+
+// evaluateLow scores five cards as an ace-to-five low if they qualify
+func evaluateLow(cards []Card) (LowHand, bool) {
+	values := make([]int, 0, len(cards))
+	seen := make(map[int]bool, len(cards))
+	for _, card := range cards {
+		value := card.Value
+		if value == 14 {
+			value = 1
+		}
+		if value > LowQualifier || seen[value] {
+			return LowHand{}, false
+		}
+		seen[value] = true
+		values = append(values, value)
+	}
+	if len(values) != 5 {
+		return LowHand{}, false
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(values)))
+	var low LowHand
+	copy(low.Values[:], values)
+	return low, true
+}
@@ -0,0 +1,246 @@
+package detection
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultMinSamples is how many decisions we need before scoring a player
+	DefaultMinSamples = 30
+	// DefaultFlagThreshold is the score at which a player is flagged for review
+	DefaultFlagThreshold = 0.75
+
+	maxTrackedDecisions = 500
+)
+
+// BotScore summarises how automated a player's play looks
+type BotScore struct {
+	PlayerID        string  `json:"player_id"`
+	Samples         int     `json:"samples"`
+	MeanDecisionMs  float64 `json:"mean_decision_ms"`
+	DecisionTimeCV  float64 `json:"decision_time_cv"`
+	ActionEntropy   float64 `json:"action_entropy"`
+	BetSizeEntropy  float64 `json:"bet_size_entropy"`
+	Score           float64 `json:"score"`
+	Flagged         bool    `json:"flagged"`
+	LastObservation string  `json:"last_observation,omitempty"`
+}
+
+// BotDetector scores players on decision-time regularity and action entropy.
+// It only reports; enforcement is left to operators via the admin API.
+type BotDetector struct {
+	profiles      map[string]*actionProfile
+	minSamples    int
+	flagThreshold float64
+	mu            sync.RWMutex
+}
+
+type actionProfile struct {
+	decisionTimes []time.Duration
+	actionCounts  map[string]int
+	betBuckets    map[int]int
+	betSamples    int
+	total         int
+	flagged       bool
+	lastSeen      time.Time
+}
+
+// NewBotDetector creates a detector with the given thresholds (zero values use defaults)
+func NewBotDetector(minSamples int, flagThreshold float64) *BotDetector {
+	if minSamples <= 0 {
+		minSamples = DefaultMinSamples
+	}
+	if flagThreshold <= 0 {
+		flagThreshold = DefaultFlagThreshold
+	}
+
+	return &BotDetector{
+		profiles:      make(map[string]*actionProfile),
+		minSamples:    minSamples,
+		flagThreshold: flagThreshold,
+	}
+}
+
+// RecordAction records one decision. betToPot is the bet/raise size relative
+// to the pot (0 for non-betting actions).
+func (bd *BotDetector) RecordAction(playerID, action string, decisionTime time.Duration, betToPot float64) {
+	bd.mu.Lock()
+	defer bd.mu.Unlock()
+
+	profile, ok := bd.profiles[playerID]
+	if !ok {
+		profile = &actionProfile{
+			actionCounts: make(map[string]int),
+			betBuckets:   make(map[int]int),
+		}
+		bd.profiles[playerID] = profile
+	}
+
+	profile.decisionTimes = append(profile.decisionTimes, decisionTime)
+	if len(profile.decisionTimes) > maxTrackedDecisions {
+		profile.decisionTimes = profile.decisionTimes[len(profile.decisionTimes)-maxTrackedDecisions:]
+	}
+
+	profile.actionCounts[action]++
+	if betToPot > 0 {
+		// Bucket sizing to 10% of pot so near-identical sizes collapse together
+		profile.betBuckets[int(math.Round(betToPot*10))]++
+		profile.betSamples++
+	}
+	profile.total++
+	profile.lastSeen = time.Now()
+
+	score := bd.scoreLocked(playerID, profile)
+	if score.Flagged && !profile.flagged {
+		logrus.WithFields(logrus.Fields{
+			"player":  playerID,
+			"score":   score.Score,
+			"samples": score.Samples,
+			"mean_ms": score.MeanDecisionMs,
+			"time_cv": score.DecisionTimeCV,
+			"entropy": score.ActionEntropy,
+		}).Warn("🤖 Player flagged for likely automated play")
+	}
+	profile.flagged = score.Flagged
+}
+
+// Score returns the current score for a player
+func (bd *BotDetector) Score(playerID string) (BotScore, bool) {
+	bd.mu.RLock()
+	defer bd.mu.RUnlock()
+
+	profile, ok := bd.profiles[playerID]
+	if !ok {
+		return BotScore{}, false
+	}
+	return bd.scoreLocked(playerID, profile), true
+}
+
+// Scores returns scores for every observed player, highest first
+func (bd *BotDetector) Scores() []BotScore {
+	bd.mu.RLock()
+	defer bd.mu.RUnlock()
+
+	scores := make([]BotScore, 0, len(bd.profiles))
+	for playerID, profile := range bd.profiles {
+		scores = append(scores, bd.scoreLocked(playerID, profile))
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		return scores[i].Score > scores[j].Score
+	})
+	return scores
+}
+
+// Reset forgets everything recorded for a player
+func (bd *BotDetector) Reset(playerID string) {
+	bd.mu.Lock()
+	defer bd.mu.Unlock()
+	delete(bd.profiles, playerID)
+}
+
+func (bd *BotDetector) scoreLocked(playerID string, profile *actionProfile) BotScore {
+	mean, cv := durationStats(profile.decisionTimes)
+	actionCounts := make([]int, 0, len(profile.actionCounts))
+	for _, count := range profile.actionCounts {
+		actionCounts = append(actionCounts, count)
+	}
+	betCounts := make([]int, 0, len(profile.betBuckets))
+	for _, count := range profile.betBuckets {
+		betCounts = append(betCounts, count)
+	}
+
+	actionEntropy := normalizedEntropy(actionCounts, profile.total)
+	betEntropy := normalizedEntropy(betCounts, profile.betSamples)
+
+	score := BotScore{
+		PlayerID:       playerID,
+		Samples:        profile.total,
+		MeanDecisionMs: float64(mean) / float64(time.Millisecond),
+		DecisionTimeCV: cv,
+		ActionEntropy:  actionEntropy,
+		BetSizeEntropy: betEntropy,
+	}
+	if !profile.lastSeen.IsZero() {
+		score.LastObservation = profile.lastSeen.Format(time.RFC3339)
+	}
+
+	if profile.total < bd.minSamples {
+		return score
+	}
+
+	// Humans show widely varying think times; scripts are metronomic
+	timingScore := clamp(1 - cv/0.5)
+
+	// Sub-second decisions on every street are rare for humans
+	speedScore := clamp(1 - (score.MeanDecisionMs-300)/1700)
+
+	// Near-deterministic action choice and identical sizing are bot tells
+	entropyScore := clamp(1 - actionEntropy/0.6)
+	sizingScore := 0.0
+	if profile.betSamples >= bd.minSamples/3 {
+		sizingScore = clamp(1 - betEntropy/0.5)
+	}
+
+	score.Score = 0.4*timingScore + 0.2*speedScore + 0.25*entropyScore + 0.15*sizingScore
+	score.Flagged = score.Score >= bd.flagThreshold
+	return score
+}
+
+// durationStats returns the mean and coefficient of variation of the samples
+func durationStats(samples []time.Duration) (time.Duration, float64) {
+	if len(samples) == 0 {
+		return 0, 0
+	}
+
+	var sum float64
+	for _, d := range samples {
+		sum += float64(d)
+	}
+	mean := sum / float64(len(samples))
+	if mean == 0 {
+		return 0, 0
+	}
+
+	var variance float64
+	for _, d := range samples {
+		diff := float64(d) - mean
+		variance += diff * diff
+	}
+	variance /= float64(len(samples))
+
+	return time.Duration(mean), math.Sqrt(variance) / mean
+}
+
+// normalizedEntropy returns Shannon entropy scaled to [0, 1] by the number of observed categories
+func normalizedEntropy(counts []int, total int) float64 {
+	if total == 0 || len(counts) < 2 {
+		return 0
+	}
+
+	var entropy float64
+	for _, count := range counts {
+		if count == 0 {
+			continue
+		}
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy / math.Log2(float64(len(counts)))
+}
+
+func clamp(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
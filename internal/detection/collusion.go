@@ -0,0 +1,402 @@
+package detection
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Kinds of collusion alert
+const (
+	AlertChipDumping   = "chip_dumping"
+	AlertSoftPlay      = "soft_play"
+	AlertSharedAddress = "shared_address"
+)
+
+const (
+	// DefaultMinTransfer is the fewest chips one player must lose to another
+	// before the flow is looked at as chip dumping
+	DefaultMinTransfer = 1000
+	// DefaultMinFaced is how often a player must face another's bets before
+	// their folding to that player is judged
+	DefaultMinFaced = 10
+)
+
+// HandAction is one betting action in a finished hand, in the order played
+type HandAction struct {
+	PlayerID string
+	Action   string
+	Street   string
+}
+
+// HandSummary is what the collusion analysis needs to know about one finished hand
+type HandSummary struct {
+	HandUID  string
+	Players  []string
+	Bets     map[string]int // chips each player put in the pot
+	Winnings map[string]int // chips each player took from it
+	Actions  []HandAction
+}
+
+// CollusionAlert is a pattern between players worth an operator's review
+type CollusionAlert struct {
+	Kind       string    `json:"kind"`
+	Players    []string  `json:"players"`
+	Hands      int       `json:"hands,omitempty"`
+	Score      float64   `json:"score"`
+	Detail     string    `json:"detail"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+func (a CollusionAlert) key() string {
+	return a.Kind + ":" + strings.Join(a.Players, ",")
+}
+
+// CollusionAnalyzer looks for players working together across hand
+// histories. Like BotDetector it only reports; what to do about an alert is
+// left to operators.
+type CollusionAnalyzer struct {
+	minTransfer int
+	minFaced    int
+}
+
+// NewCollusionAnalyzer creates an analyzer with the given thresholds (zero values use defaults)
+func NewCollusionAnalyzer(minTransfer, minFaced int) *CollusionAnalyzer {
+	if minTransfer <= 0 {
+		minTransfer = DefaultMinTransfer
+	}
+	if minFaced <= 0 {
+		minFaced = DefaultMinFaced
+	}
+	return &CollusionAnalyzer{minTransfer: minTransfer, minFaced: minFaced}
+}
+
+// Analyze returns every pattern found in the hands. addresses maps each
+// player to the network addresses they have been seen at.
+func (ca *CollusionAnalyzer) Analyze(hands []HandSummary, addresses map[string][]string) []CollusionAlert {
+	alerts := ca.chipDumping(hands)
+	alerts = append(alerts, ca.softPlay(hands)...)
+	alerts = append(alerts, sharedAddresses(addresses)...)
+
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Score > alerts[j].Score
+	})
+	return alerts
+}
+
+// chipDumping flags one-way chip flows: a player who loses most of what
+// they lose to one opponent, who rarely loses anything back
+func (ca *CollusionAnalyzer) chipDumping(hands []HandSummary) []CollusionAlert {
+	transfers := make(map[string]map[string]int)
+	losses := make(map[string]int)
+	together := make(map[string]int)
+
+	for _, hand := range hands {
+		gains := make(map[string]int)
+		totalGain := 0
+		for _, addr := range hand.Players {
+			if won := hand.Winnings[addr] - hand.Bets[addr]; won > 0 {
+				gains[addr] = won
+				totalGain += won
+			}
+		}
+		if totalGain == 0 {
+			continue
+		}
+
+		for _, loser := range hand.Players {
+			loss := hand.Bets[loser] - hand.Winnings[loser]
+			if loss <= 0 {
+				continue
+			}
+			losses[loser] += loss
+			if transfers[loser] == nil {
+				transfers[loser] = make(map[string]int)
+			}
+			// A loss is shared out between the winners by what they gained
+			for winner, gain := range gains {
+				transfers[loser][winner] += loss * gain / totalGain
+			}
+		}
+
+		for i, a := range hand.Players {
+			for _, b := range hand.Players[i+1:] {
+				together[pairKey(a, b)]++
+			}
+		}
+	}
+
+	alerts := []CollusionAlert{}
+	for from, to := range transfers {
+		for winner, amount := range to {
+			back := transfers[winner][from]
+			if amount < ca.minTransfer || back*5 > amount {
+				continue
+			}
+			share := float64(amount) / float64(losses[from])
+			if share < 0.6 {
+				continue
+			}
+			alerts = append(alerts, CollusionAlert{
+				Kind:    AlertChipDumping,
+				Players: []string{from, winner},
+				Hands:   together[pairKey(from, winner)],
+				Score:   clamp(share * (1 - float64(back)/float64(amount))),
+				Detail: fmt.Sprintf("%s lost %d chips to %s (%.0f%% of their losses) and won back %d",
+					from, amount, winner, share*100, back),
+			})
+		}
+	}
+	return alerts
+}
+
+// softPlay flags a player who nearly always folds to one opponent's bets
+// while playing on against everyone else's
+func (ca *CollusionAnalyzer) softPlay(hands []HandSummary) []CollusionAlert {
+	faced := make(map[string]map[string]int)
+	folded := make(map[string]map[string]int)
+
+	for _, hand := range hands {
+		aggressor, street := "", ""
+		for _, action := range hand.Actions {
+			if action.Street != street {
+				aggressor, street = "", action.Street
+			}
+			switch action.Action {
+			case "bet", "raise", "all_in":
+				aggressor = action.PlayerID
+				continue
+			}
+			if aggressor == "" || aggressor == action.PlayerID {
+				continue
+			}
+
+			if faced[action.PlayerID] == nil {
+				faced[action.PlayerID] = make(map[string]int)
+				folded[action.PlayerID] = make(map[string]int)
+			}
+			faced[action.PlayerID][aggressor]++
+			if action.Action == "fold" {
+				folded[action.PlayerID][aggressor]++
+			}
+		}
+	}
+
+	alerts := []CollusionAlert{}
+	for player, byAggressor := range faced {
+		totalFaced, totalFolded := 0, 0
+		for aggressor, count := range byAggressor {
+			totalFaced += count
+			totalFolded += folded[player][aggressor]
+		}
+
+		for aggressor, count := range byAggressor {
+			if count < ca.minFaced {
+				continue
+			}
+			rate := float64(folded[player][aggressor]) / float64(count)
+			othersFaced := totalFaced - count
+			if rate < 0.9 || othersFaced < ca.minFaced {
+				continue
+			}
+			othersRate := float64(totalFolded-folded[player][aggressor]) / float64(othersFaced)
+			if rate-othersRate < 0.4 {
+				continue
+			}
+			alerts = append(alerts, CollusionAlert{
+				Kind:    AlertSoftPlay,
+				Players: []string{player, aggressor},
+				Hands:   count,
+				Score:   clamp(rate - othersRate),
+				Detail: fmt.Sprintf("%s folded to %s's bets %.0f%% of the time, and to everyone else's %.0f%%",
+					player, aggressor, rate*100, othersRate*100),
+			})
+		}
+	}
+	return alerts
+}
+
+// sharedAddresses flags players seen at the same host. Loopback hosts are
+// skipped, since every node of a table run locally shares one.
+func sharedAddresses(addresses map[string][]string) []CollusionAlert {
+	byHost := make(map[string]map[string]bool)
+	for player, addrs := range addresses {
+		for _, addr := range addrs {
+			host := addressHost(addr)
+			if host == "" {
+				continue
+			}
+			if byHost[host] == nil {
+				byHost[host] = make(map[string]bool)
+			}
+			byHost[host][player] = true
+		}
+	}
+
+	alerts := []CollusionAlert{}
+	for host, players := range byHost {
+		if len(players) < 2 {
+			continue
+		}
+		list := make([]string, 0, len(players))
+		for player := range players {
+			list = append(list, player)
+		}
+		sort.Strings(list)
+		alerts = append(alerts, CollusionAlert{
+			Kind:    AlertSharedAddress,
+			Players: list,
+			Score:   0.5,
+			Detail:  fmt.Sprintf("%d players connect from %s", len(list), host),
+		})
+	}
+	return alerts
+}
+
+func addressHost(addr string) string {
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+	if host == "" || host == "localhost" {
+		return ""
+	}
+	if ip := net.ParseIP(host); ip != nil && (ip.IsLoopback() || ip.IsUnspecified()) {
+		return ""
+	}
+	return host
+}
+
+func pairKey(a, b string) string {
+	if a > b {
+		a, b = b, a
+	}
+	return a + "|" + b
+}
+
+// CollusionMonitor runs the analyzer over fresh hand histories on a
+// schedule and reports each pattern the first time it is found
+type CollusionMonitor struct {
+	analyzer *CollusionAnalyzer
+	interval time.Duration
+	source   func() ([]HandSummary, map[string][]string)
+	onAlert  func(CollusionAlert)
+
+	mu     sync.Mutex
+	alerts map[string]CollusionAlert
+	stop   chan struct{}
+}
+
+// NewCollusionMonitor creates a monitor that reads hands and addresses from source every interval
+func NewCollusionMonitor(analyzer *CollusionAnalyzer, interval time.Duration, source func() ([]HandSummary, map[string][]string)) *CollusionMonitor {
+	return &CollusionMonitor{
+		analyzer: analyzer,
+		interval: interval,
+		source:   source,
+		alerts:   make(map[string]CollusionAlert),
+	}
+}
+
+// OnAlert sets the function called with each new alert
+func (cm *CollusionMonitor) OnAlert(fn func(CollusionAlert)) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.onAlert = fn
+}
+
+// Start runs the analysis every interval until Stop
+func (cm *CollusionMonitor) Start() error {
+	if cm.interval <= 0 {
+		return fmt.Errorf("invalid collusion analysis interval %v", cm.interval)
+	}
+
+	cm.mu.Lock()
+	if cm.stop != nil {
+		cm.mu.Unlock()
+		return nil
+	}
+	cm.stop = make(chan struct{})
+	stop := cm.stop
+	cm.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(cm.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				cm.RunOnce()
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop ends scheduled analysis runs
+func (cm *CollusionMonitor) Stop() {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if cm.stop != nil {
+		close(cm.stop)
+		cm.stop = nil
+	}
+}
+
+// RunOnce analyzes the current histories and returns the alerts not seen before
+func (cm *CollusionMonitor) RunOnce() []CollusionAlert {
+	hands, addresses := cm.source()
+	found := cm.analyzer.Analyze(hands, addresses)
+
+	cm.mu.Lock()
+	fresh := []CollusionAlert{}
+	now := time.Now()
+	for _, alert := range found {
+		key := alert.key()
+		if previous, ok := cm.alerts[key]; ok {
+			alert.DetectedAt = previous.DetectedAt
+			cm.alerts[key] = alert
+			continue
+		}
+		alert.DetectedAt = now
+		cm.alerts[key] = alert
+		fresh = append(fresh, alert)
+	}
+	onAlert := cm.onAlert
+	cm.mu.Unlock()
+
+	for _, alert := range fresh {
+		logrus.WithFields(logrus.Fields{
+			"kind":    alert.Kind,
+			"players": alert.Players,
+			"score":   alert.Score,
+		}).Warn("🕵️ Possible collusion flagged for review")
+		if onAlert != nil {
+			onAlert(alert)
+		}
+	}
+	return fresh
+}
+
+// Alerts returns every pattern found so far, highest score first
+func (cm *CollusionMonitor) Alerts() []CollusionAlert {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	alerts := make([]CollusionAlert, 0, len(cm.alerts))
+	for _, alert := range cm.alerts {
+		alerts = append(alerts, alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Score > alerts[j].Score
+	})
+	return alerts
+}
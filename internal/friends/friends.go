@@ -0,0 +1,299 @@
+package friends
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// InvitePolicy controls who may invite a player to a table
+type InvitePolicy string
+
+const (
+	InviteEveryone InvitePolicy = "everyone"
+	InviteFriends  InvitePolicy = "friends"
+	InviteNobody   InvitePolicy = "nobody"
+)
+
+// ParseInvitePolicy validates an invite policy name
+func ParseInvitePolicy(policy string) (InvitePolicy, error) {
+	switch InvitePolicy(policy) {
+	case InviteEveryone, InviteFriends, InviteNobody:
+		return InvitePolicy(policy), nil
+	default:
+		return "", fmt.Errorf("invalid invite policy: %s", policy)
+	}
+}
+
+// FriendRequest is a pending friendship awaiting the recipient's answer
+type FriendRequest struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// state is everything persisted to disk
+type state struct {
+	Friends  map[string][]string     `json:"friends"`
+	Requests []FriendRequest         `json:"requests"`
+	Policies map[string]InvitePolicy `json:"policies"`
+}
+
+// Service manages friend relationships, privacy settings and table invitations
+type Service struct {
+	friends  map[string]map[string]bool
+	requests map[string]map[string]FriendRequest // recipient -> sender -> request
+	policies map[string]InvitePolicy
+
+	invitations map[string]*Invitation
+	notify      Notifier
+	inviteTTL   time.Duration
+
+	path string
+	mu   sync.RWMutex
+}
+
+// NewService creates a friends service persisted to path (empty for memory only)
+func NewService(path string, notify Notifier) *Service {
+	s := &Service{
+		friends:     make(map[string]map[string]bool),
+		requests:    make(map[string]map[string]FriendRequest),
+		policies:    make(map[string]InvitePolicy),
+		invitations: make(map[string]*Invitation),
+		notify:      notify,
+		inviteTTL:   DefaultInviteTTL,
+		path:        path,
+	}
+
+	if err := s.load(); err != nil {
+		logrus.Warnf("Failed to load friends: %v", err)
+	}
+	return s
+}
+
+// SendRequest asks another player to become friends. If they already asked
+// us, the friendship is accepted immediately.
+func (s *Service) SendRequest(from, to string) error {
+	if from == "" || to == "" || from == to {
+		return fmt.Errorf("invalid friend request")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.friends[from][to] {
+		return fmt.Errorf("already friends with %s", to)
+	}
+
+	if _, ok := s.requests[from][to]; ok {
+		s.acceptLocked(from, to)
+		return s.saveLocked()
+	}
+
+	if s.requests[to] == nil {
+		s.requests[to] = make(map[string]FriendRequest)
+	}
+	s.requests[to][from] = FriendRequest{From: from, To: to, CreatedAt: time.Now()}
+	return s.saveLocked()
+}
+
+// Accept accepts a pending request from another player
+func (s *Service) Accept(player, from string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.requests[player][from]; !ok {
+		return fmt.Errorf("no friend request from %s", from)
+	}
+
+	s.acceptLocked(player, from)
+	return s.saveLocked()
+}
+
+// Decline rejects a pending request from another player
+func (s *Service) Decline(player, from string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.requests[player][from]; !ok {
+		return fmt.Errorf("no friend request from %s", from)
+	}
+
+	delete(s.requests[player], from)
+	return s.saveLocked()
+}
+
+// Remove ends a friendship in both directions
+func (s *Service) Remove(player, friend string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.friends[player][friend] {
+		return fmt.Errorf("not friends with %s", friend)
+	}
+
+	delete(s.friends[player], friend)
+	delete(s.friends[friend], player)
+	return s.saveLocked()
+}
+
+// Friends returns a player's friends, sorted
+func (s *Service) Friends(player string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]string, 0, len(s.friends[player]))
+	for friend := range s.friends[player] {
+		list = append(list, friend)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// AreFriends reports whether two players are friends
+func (s *Service) AreFriends(a, b string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.friends[a][b]
+}
+
+// PendingRequests returns requests waiting for a player's answer
+func (s *Service) PendingRequests(player string) []FriendRequest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]FriendRequest, 0, len(s.requests[player]))
+	for _, req := range s.requests[player] {
+		list = append(list, req)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list
+}
+
+// InvitePolicy returns who may invite a player (friends only by default)
+func (s *Service) InvitePolicy(player string) InvitePolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.policyLocked(player)
+}
+
+// SetInvitePolicy changes who may invite a player
+func (s *Service) SetInvitePolicy(player string, policy InvitePolicy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.policies[player] = policy
+	return s.saveLocked()
+}
+
+func (s *Service) policyLocked(player string) InvitePolicy {
+	if policy, ok := s.policies[player]; ok {
+		return policy
+	}
+	return InviteFriends
+}
+
+func (s *Service) acceptLocked(player, from string) {
+	delete(s.requests[player], from)
+	delete(s.requests[from], player)
+
+	if s.friends[player] == nil {
+		s.friends[player] = make(map[string]bool)
+	}
+	if s.friends[from] == nil {
+		s.friends[from] = make(map[string]bool)
+	}
+	s.friends[player][from] = true
+	s.friends[from][player] = true
+
+	logrus.WithFields(logrus.Fields{
+		"player": player,
+		"friend": from,
+	}).Info("Friendship accepted")
+}
+
+func (s *Service) load() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read friends file: %w", err)
+	}
+
+	var saved state
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("failed to unmarshal friends: %w", err)
+	}
+
+	for player, list := range saved.Friends {
+		s.friends[player] = make(map[string]bool, len(list))
+		for _, friend := range list {
+			s.friends[player][friend] = true
+		}
+	}
+	for _, req := range saved.Requests {
+		if s.requests[req.To] == nil {
+			s.requests[req.To] = make(map[string]FriendRequest)
+		}
+		s.requests[req.To][req.From] = req
+	}
+	for player, policy := range saved.Policies {
+		s.policies[player] = policy
+	}
+	return nil
+}
+
+func (s *Service) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	saved := state{
+		Friends:  make(map[string][]string, len(s.friends)),
+		Requests: []FriendRequest{},
+		Policies: s.policies,
+	}
+	for player, friends := range s.friends {
+		for friend := range friends {
+			saved.Friends[player] = append(saved.Friends[player], friend)
+		}
+	}
+	for _, pending := range s.requests {
+		for _, req := range pending {
+			saved.Requests = append(saved.Requests, req)
+		}
+	}
+
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return fmt.Errorf("failed to marshal friends: %w", err)
+	}
+
+	if dir := filepath.Dir(s.path); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write friends: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to replace friends file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package friends
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultInviteTTL is how long a table invitation stays valid
+const DefaultInviteTTL = 10 * time.Minute
+
+// Invitation asks a player to join a specific table
+type Invitation struct {
+	ID        string    `json:"id"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	TableID   string    `json:"table_id"`
+	JoinLink  string    `json:"join_link"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Notifier delivers an invitation to its recipient (e.g. over their WebSocket)
+type Notifier func(inv Invitation)
+
+// Invite sends a table invitation, subject to the recipient's invite policy
+func (s *Service) Invite(from, to, tableID, joinLink string) (*Invitation, error) {
+	if from == "" || to == "" || from == to {
+		return nil, fmt.Errorf("invalid invitation")
+	}
+
+	s.mu.Lock()
+
+	switch s.policyLocked(to) {
+	case InviteNobody:
+		s.mu.Unlock()
+		return nil, fmt.Errorf("%s is not accepting invitations", to)
+	case InviteFriends:
+		if !s.friends[to][from] {
+			s.mu.Unlock()
+			return nil, fmt.Errorf("%s only accepts invitations from friends", to)
+		}
+	}
+
+	s.expireInvitationsLocked()
+
+	id, err := newInvitationID()
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+
+	now := time.Now()
+	inv := &Invitation{
+		ID:        id,
+		From:      from,
+		To:        to,
+		TableID:   tableID,
+		JoinLink:  joinLink,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.inviteTTL),
+	}
+	s.invitations[id] = inv
+	notify := s.notify
+	s.mu.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"from":  from,
+		"to":    to,
+		"table": tableID,
+	}).Info("Table invitation sent")
+
+	if notify != nil {
+		notify(*inv)
+	}
+	return inv, nil
+}
+
+// Invitations returns the unexpired invitations addressed to a player
+func (s *Service) Invitations(player string) []Invitation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expireInvitationsLocked()
+
+	list := make([]Invitation, 0)
+	for _, inv := range s.invitations {
+		if inv.To == player {
+			list = append(list, *inv)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list
+}
+
+// DismissInvitation removes an invitation once its recipient accepts or declines it
+func (s *Service) DismissInvitation(player, id string) (*Invitation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inv, ok := s.invitations[id]
+	if !ok || inv.To != player {
+		return nil, fmt.Errorf("invitation not found: %s", id)
+	}
+
+	delete(s.invitations, id)
+	return inv, nil
+}
+
+func (s *Service) expireInvitationsLocked() {
+	now := time.Now()
+	for id, inv := range s.invitations {
+		if now.After(inv.ExpiresAt) {
+			delete(s.invitations, id)
+		}
+	}
+}
+
+func newInvitationID() (string, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate invitation ID: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
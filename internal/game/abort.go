@@ -0,0 +1,276 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// AbortVoteTimeout is how long a vote to abort stays open
+const AbortVoteTimeout = 2 * time.Minute
+
+// abortVote is an open vote to void the session
+type abortVote struct {
+	reason    string
+	proposer  string
+	startedAt time.Time
+	approvals map[string]bool
+}
+
+// AbortVoteStatus describes an open vote to abort
+type AbortVoteStatus struct {
+	Reason    string    `json:"reason"`
+	Proposer  string    `json:"proposer"`
+	StartedAt time.Time `json:"started_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Approved  []string  `json:"approved"`
+	Pending   []string  `json:"pending"`
+}
+
+// VoteAbort records a seated player's vote to void the current hand and
+// refund the session. A single "no" cancels the vote; once every seated
+// player approves, contributions are returned and escrow is refunded.
+func (g *Game) VoteAbort(playerID string, approve bool, reason string) error {
+	return g.exec("abort vote", func() error {
+		if err := g.recordAbortVote(playerID, approve, reason); err != nil {
+			return err
+		}
+
+		g.sendToPlayers(protocol.TypeAbortVote, protocol.AbortVotePayload{
+			Approve: approve,
+			Reason:  reason,
+		}, g.getOtherPlayers()...)
+
+		g.checkAbortVote()
+		return nil
+	})
+}
+
+// AbortVote returns the open vote to abort, if any
+func (g *Game) AbortVote() (AbortVoteStatus, bool) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	vote := g.activeAbortVote()
+	if vote == nil {
+		return AbortVoteStatus{}, false
+	}
+
+	approved, pending := g.abortVoteTally(vote)
+	return AbortVoteStatus{
+		Reason:    vote.reason,
+		Proposer:  vote.proposer,
+		StartedAt: vote.startedAt,
+		ExpiresAt: vote.startedAt.Add(AbortVoteTimeout),
+		Approved:  approved,
+		Pending:   pending,
+	}, true
+}
+
+func (g *Game) handleMessageAbortVote(from string, payload protocol.AbortVotePayload) error {
+	return g.exec("abort vote message from "+from, func() error {
+		if err := g.recordAbortVote(from, payload.Approve, payload.Reason); err != nil {
+			return err
+		}
+
+		g.checkAbortVote()
+		return nil
+	})
+}
+
+func (g *Game) recordAbortVote(playerID string, approve bool, reason string) error {
+	state, ok := g.playerStates[playerID]
+	if !ok || !state.IsReady {
+		return fmt.Errorf("player %s is not seated", playerID)
+	}
+
+	vote := g.activeAbortVote()
+	if vote == nil {
+		if !approve {
+			return fmt.Errorf("no abort vote in progress")
+		}
+		if reason == "" {
+			return fmt.Errorf("a reason is required to propose an abort")
+		}
+		vote = &abortVote{
+			reason:    reason,
+			proposer:  playerID,
+			startedAt: time.Now(),
+			approvals: make(map[string]bool),
+		}
+		g.pendingAbort = vote
+
+		g.audit("abort_proposed", playerID, map[string]interface{}{
+			"reason": reason,
+		})
+	}
+
+	g.log().WithFields(logrus.Fields{
+		"player":  playerID,
+		"approve": approve,
+		"reason":  vote.reason,
+	}).Info("Abort vote received")
+
+	if !approve {
+		g.pendingAbort = nil
+		g.audit("abort_rejected", playerID, map[string]interface{}{
+			"reason": vote.reason,
+		})
+		g.publishEvent(protocol.EventAbortVote, protocol.AbortVoteEvent{
+			PlayerID: playerID,
+			Approve:  false,
+			Reason:   vote.reason,
+			Approved: []string{},
+			Pending:  []string{},
+		})
+		return nil
+	}
+
+	vote.approvals[playerID] = true
+	approved, pending := g.abortVoteTally(vote)
+	g.publishEvent(protocol.EventAbortVote, protocol.AbortVoteEvent{
+		PlayerID: playerID,
+		Approve:  true,
+		Reason:   vote.reason,
+		Approved: approved,
+		Pending:  pending,
+	})
+	return nil
+}
+
+// activeAbortVote returns the open vote, discarding it once expired
+func (g *Game) activeAbortVote() *abortVote {
+	if g.pendingAbort == nil {
+		return nil
+	}
+	if time.Since(g.pendingAbort.startedAt) > AbortVoteTimeout {
+		return nil
+	}
+	return g.pendingAbort
+}
+
+func (g *Game) abortVoteTally(vote *abortVote) (approved, pending []string) {
+	approved = make([]string, 0)
+	pending = make([]string, 0)
+	for _, addr := range g.getReadyPlayers() {
+		if vote.approvals[addr] {
+			approved = append(approved, addr)
+		} else {
+			pending = append(pending, addr)
+		}
+	}
+	sort.Strings(approved)
+	sort.Strings(pending)
+	return approved, pending
+}
+
+// checkAbortVote aborts the session once every seated player has approved
+func (g *Game) checkAbortVote() {
+	vote := g.activeAbortVote()
+	if vote == nil {
+		return
+	}
+	if _, pending := g.abortVoteTally(vote); len(pending) > 0 {
+		return
+	}
+
+	g.pendingAbort = nil
+	g.abortSession(vote.reason)
+}
+
+// voidHand returns every player's contribution to the current hand
+func (g *Game) voidHand(reason string) map[string]int {
+	refunds := make(map[string]int)
+	for addr, state := range g.playerStates {
+		if state.TotalBetThisHand > 0 {
+			refunds[addr] = state.TotalBetThisHand
+			state.Stack += state.TotalBetThisHand
+		}
+		state.CurrentRoundBet = 0
+		state.TotalBetThisHand = 0
+		state.IsAllIn = false
+		state.IsFolded = false
+	}
+
+	g.currentPot = 0
+	g.highestBet = 0
+	g.fullRaiseTo = 0
+	g.pot = NewPotLedger()
+	g.communityCards = g.communityCards[:0]
+	g.myHand = g.myHand[:0]
+	g.runOffer = nil
+	g.showdown = nil
+	g.secondBoard = nil
+	g.secondRunHands = nil
+
+	if len(g.handHistory) > 0 {
+		hand := g.handHistory[len(g.handHistory)-1]
+		if hand.EndedAt.IsZero() {
+			hand.EndedAt = time.Now()
+			hand.Voided = true
+			hand.VoidReason = reason
+			g.storeHand(hand)
+		}
+	}
+
+	g.log().WithFields(logrus.Fields{
+		"reason":  reason,
+		"refunds": refunds,
+	}).Warn("Hand voided")
+	return refunds
+}
+
+// abortSession voids the current hand and refunds escrow in proportion to stacks
+func (g *Game) abortSession(reason string) {
+	g.log().WithField("reason", reason).Warn("Aborting session by unanimous vote")
+
+	refunds := g.voidHand(reason)
+
+	players := g.getReadyPlayers()
+	sort.Strings(players)
+	amounts := make([]int, len(players))
+	stacks := make(map[string]int, len(players))
+	for i, addr := range players {
+		amounts[i] = g.playerStates[addr].Stack
+		stacks[addr] = amounts[i]
+	}
+
+	gameID := ""
+	if g.blockchainEnabled && g.blockchainGameID != [32]byte{} {
+		gameID = fmt.Sprintf("0x%x", g.blockchainGameID)
+		if g.channel != nil {
+			// The last signed stacks are the refund
+			g.closeChannel("session aborted")
+		} else {
+			g.flushSettlementBatch()
+			g.distributeWinningsOnChain(ChainTxRefund, players, amounts)
+		}
+		g.blockchainGameID = [32]byte{}
+	}
+
+	g.audit("session_aborted", "table", map[string]interface{}{
+		"reason":       reason,
+		"players":      players,
+		"hand_refunds": refunds,
+		"stacks":       stacks,
+		"game_id":      gameID,
+	})
+
+	// Everyone has to ready up again before a new session starts
+	for _, state := range g.playerStates {
+		state.IsReady = false
+	}
+	g.resetBlindPositions()
+	g.setStatus(GameStatusWaiting)
+
+	g.publishEvent(protocol.EventGameAborted, protocol.GameAbortedEvent{
+		GameID:           gameID,
+		RemainingPlayers: players,
+		Timestamp:        formatEventTime(time.Now()),
+		Reason:           reason,
+	})
+	g.publishStateUpdate()
+}
@@ -2,9 +2,10 @@ package game
 
 import (
 	"fmt"
+	"time"
 
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
 	"github.com/RedPaladin7/peerpoker/internal/protocol"
-	"github.com/sirupsen/logrus"
 )
 
 type PlayerAction int
@@ -15,6 +16,7 @@ const (
 	PlayerActionCall
 	PlayerActionBet
 	PlayerActionRaise
+	PlayerActionAllIn
 )
 
 func (pa PlayerAction) String() string {
@@ -29,6 +31,8 @@ func (pa PlayerAction) String() string {
 		return "bet"
 	case PlayerActionRaise:
 		return "raise"
+	case PlayerActionAllIn:
+		return "all_in"
 	default:
 		return "unknown"
 	}
@@ -46,6 +50,8 @@ func ParsePlayerAction(action string) (PlayerAction, error) {
 		return PlayerActionBet, nil
 	case "raise":
 		return PlayerActionRaise, nil
+	case "all_in":
+		return PlayerActionAllIn, nil
 	default:
 		return 0, fmt.Errorf("invalid action: %s", action)
 	}
@@ -53,9 +59,15 @@ func ParsePlayerAction(action string) (PlayerAction, error) {
 
 // HandlePlayerAction processes a player action
 func (g *Game) HandlePlayerAction(clientID, actionStr string, value int) error {
-	g.lock.Lock()
-	defer g.lock.Unlock()
+	return g.exec("player action", func() error {
+		return g.handlePlayerAction(clientID, "", actionStr, value)
+	})
+}
 
+// handlePlayerAction validates and applies an action. actionID, if any, is
+// passed on to peers so they can drop repeats too.
+func (g *Game) handlePlayerAction(clientID, actionID, actionStr string, value int) error {
+	start := time.Now()
 	action, err := ParsePlayerAction(actionStr)
 	if err != nil {
 		return err
@@ -66,10 +78,27 @@ func (g *Game) HandlePlayerAction(clientID, actionStr string, value int) error {
 		return fmt.Errorf("player %s not found", clientID)
 	}
 
+	if g.frozen != "" {
+		return fmt.Errorf("table is frozen: %s", g.frozen)
+	}
+	if g.pause != nil {
+		return fmt.Errorf("table is paused: %s", g.pause.reason)
+	}
+
+	// No betting while the players decide whether to run it twice, or
+	// once hands are being shown
+	if g.runOffer != nil {
+		return fmt.Errorf("waiting for the run it twice decision")
+	}
+	if g.showdown != nil {
+		return fmt.Errorf("hands are being shown")
+	}
+
 	// Check if it's this player's turn
 	if myState.RotationID != g.currentPlayerTurn {
 		return fmt.Errorf("it is not your turn")
 	}
+	delete(g.timedOut, clientID)
 
 	// Validate action
 	validActions := g.getValidActions(clientID)
@@ -91,54 +120,95 @@ func (g *Game) HandlePlayerAction(clientID, actionStr string, value int) error {
 		if value < BigBlind {
 			return fmt.Errorf("bet must be at least the big blind (%d)", BigBlind)
 		}
-		if value > myState.Stack {
-			return fmt.Errorf("bet (%d) exceeds your stack (%d)", value, myState.Stack)
+		if value > g.maxBetTo(myState) {
+			return fmt.Errorf("bet (%d) exceeds your stack (%d)", value, g.maxBetTo(myState))
 		}
-		g.lastRaiseAmount = value
 
 	case PlayerActionRaise:
-		minRaise := g.highestBet + g.lastRaiseAmount
-		if value < minRaise {
+		if minRaise := g.minRaiseTo(); value < minRaise {
 			return fmt.Errorf("raise must be at least %d", minRaise)
 		}
-		if value > myState.Stack {
-			return fmt.Errorf("raise (%d) exceeds your stack (%d)", value, myState.Stack)
+		if value > g.maxBetTo(myState) {
+			return fmt.Errorf("raise (%d) exceeds your stack (%d)", value, g.maxBetTo(myState))
 		}
-		g.lastRaiseAmount = value - g.highestBet
 
 	case PlayerActionCall:
 		amountNeeded := g.highestBet - myState.CurrentRoundBet
 		if amountNeeded > myState.Stack {
-			logrus.Infof("Call will be all-in for %d", myState.Stack)
+			g.log().Infof("Call will be all-in for %d", myState.Stack)
 		}
+
+	case PlayerActionAllIn:
+		// The amount is the player's whole stack, whatever was sent
+		value = g.maxBetTo(myState)
 	}
 
-	// Handle fold - reveal keys to other players
-	if action == PlayerActionFold {
-		g.sendToPlayers(protocol.TypeRevealKeys, protocol.RevealKeysPayload{
-			EncryptionKey: g.deckKeys.EncKey.String(),
-			DecryptionKey: g.deckKeys.DecKey.String(),
-			Prime:         g.deckKeys.Prime.String(),
-		}, g.getOtherPlayers()...)
-		myState.IsFolded = true
+	g.actionValidated(start)
+
+	// Feed the bot detector before state changes so bet sizing is relative to the pot faced
+	if g.botDetector != nil {
+		betToPot := 0.0
+		if (action == PlayerActionBet || action == PlayerActionRaise || action == PlayerActionAllIn) && g.currentPot > 0 {
+			betToPot = float64(value) / float64(g.currentPot)
+		}
+		g.botDetector.RecordAction(clientID, action.String(), time.Since(g.turnStartedAt), betToPot)
 	}
 
-	// Update state
-	g.updatePlayerState(clientID, action, value)
+	g.logWAL(persistence.WALAction, walAction{Player: clientID, Action: actionStr, Value: value})
+	g.storeAction(clientID, actionStr, value)
+
+	// Update state, and stop before telling anyone if chips went astray
+	g.applyPlayerAction(clientID, action, value)
+	if err := g.checkChips(); err != nil {
+		g.freeze(action.String()+" by "+clientID, err)
+		return fmt.Errorf("table is frozen: %w", err)
+	}
 
 	// Broadcast action to other players
 	g.sendToPlayers(protocol.TypePlayerAction, protocol.PlayerActionPayload{
 		Action:            actionStr,
 		Value:             value,
 		CurrentGameStatus: g.currentStatus.String(),
+		ActionID:          actionID,
 	}, g.getOtherPlayers()...)
 
+	// Handle fold - hand over our layer of the board cards still to come,
+	// after the fold itself so the other players know we are out. Our keys
+	// follow once the hand is over. Another player's fold, forced by a timer
+	// or an admin, is theirs to share.
+	if action == PlayerActionFold && clientID == g.listenAddr {
+		g.sendFoldShares(clientID)
+	}
+
+	g.publishEvent(protocol.EventPlayerAction, protocol.PlayerActionEvent{
+		PlayerID: clientID,
+		Action:   actionStr,
+		Amount:   value,
+		NewPot:   g.currentPot,
+		NewStack: myState.Stack,
+	})
+
 	// Advance turn
 	g.advanceTurnAndCheckRoundEnd()
+	g.turnStartedAt = time.Now()
 
+	g.publishStateUpdate()
+	g.publishTurnChange()
+
+	g.actionApplied(clientID, action, start)
 	return nil
 }
 
+// applyPlayerAction applies a validated action to the betting state; the
+// caller advances the turn
+func (g *Game) applyPlayerAction(clientID string, action PlayerAction, value int) {
+	g.updatePlayerState(clientID, action, value)
+
+	state := g.playerStates[clientID]
+	state.ActedThisRound = true
+	state.ActedAtRaiseTo = g.fullRaiseTo
+}
+
 // Get valid actions for a player
 func (g *Game) getValidActions(clientID string) []PlayerAction {
 	state, ok := g.playerStates[clientID]
@@ -158,13 +228,9 @@ func (g *Game) getValidActions(clientID string) []PlayerAction {
 		actions = append(actions, PlayerActionCall)
 	}
 
-	// Bet or Raise
-	minRaise := g.highestBet + g.lastRaiseAmount
-	if g.highestBet == 0 {
-		minRaise = BigBlind
-	}
-
-	if state.Stack > (minRaise - state.CurrentRoundBet) {
+	// Bet or Raise, only while the action is open to this player
+	canRaise := g.canRaise(state) && g.maxBetTo(state) > g.highestBet
+	if canRaise && g.maxBetTo(state) >= g.minRaiseTo() {
 		if g.highestBet == 0 {
 			actions = append(actions, PlayerActionBet)
 		} else {
@@ -172,5 +238,10 @@ func (g *Game) getValidActions(clientID string) []PlayerAction {
 		}
 	}
 
+	// All-in, unless it would raise a bet the player may only call
+	if state.Stack > 0 && (canRaise || g.maxBetTo(state) <= g.highestBet) {
+		actions = append(actions, PlayerActionAllIn)
+	}
+
 	return actions
 }
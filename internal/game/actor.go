@@ -0,0 +1,70 @@
+package game
+
+import (
+	"errors"
+	"time"
+)
+
+// The table is an actor: every change to its state runs on loop(), one
+// command at a time in the order they were sent, whether it comes from a
+// peer, the API, a timer or the disconnect handler. The loop holds the
+// write lock while a command runs, so readers can still take the read lock
+// from any goroutine and see the table between commands.
+//
+// Code running on the loop must not send it another command; call the
+// unexported, lock-free helpers instead.
+
+// ErrTableClosed is returned for commands sent to a table after Close
+var ErrTableClosed = errors.New("table is closed")
+
+// command is a unit of work for the loop. op names it in panic incidents.
+type command struct {
+	op   string
+	run  func() error
+	done chan error
+	sent time.Time
+}
+
+// loop runs the table's commands until Close
+func (g *Game) loop() {
+	for {
+		select {
+		case <-g.ctx.Done():
+			g.log().Debug("Table loop stopped")
+			return
+		case cmd := <-g.commands:
+			cmd.done <- g.runCommand(cmd)
+		}
+	}
+}
+
+// runCommand runs a command with the write lock held and publishes the
+// table's new view. A panic in it voids the hand in progress rather than
+// killing the loop.
+func (g *Game) runCommand(cmd command) (err error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.latency.stages[StageQueue].Observe(time.Since(cmd.sent))
+	defer g.refreshView()
+	defer g.recoverPanic(cmd.op, &err)
+	return cmd.run()
+}
+
+// exec runs fn on the loop and waits for its result
+func (g *Game) exec(op string, fn func() error) error {
+	cmd := command{op: op, run: fn, done: make(chan error, 1), sent: time.Now()}
+	select {
+	case g.commands <- cmd:
+	case <-g.ctx.Done():
+		return ErrTableClosed
+	}
+	return <-cmd.done
+}
+
+// do runs fn on the loop and waits for it to finish
+func (g *Game) do(op string, fn func()) {
+	g.exec(op, func() error {
+		fn()
+		return nil
+	})
+}
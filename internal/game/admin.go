@@ -0,0 +1,90 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// ForceAdvance unsticks the current hand on an operator's behalf. In a
+// betting round the player to act checks if they can and folds otherwise;
+// a hand stuck outside betting (a shuffle, deal or showdown reveal that
+// never completed) is voided and every bet returned. It describes what was
+// done.
+func (g *Game) ForceAdvance(actor string) (result string, err error) {
+	err = g.exec("force advance", func() error {
+		result, err = g.forceAdvance(actor)
+		return err
+	})
+	return result, err
+}
+
+func (g *Game) forceAdvance(actor string) (result string, err error) {
+	if g.currentStatus == GameStatusWaiting {
+		return "", fmt.Errorf("no hand in progress")
+	}
+	if g.runOffer != nil {
+		return "", fmt.Errorf("waiting for the run it twice decision, which times out on its own")
+	}
+	if g.pause != nil && g.isBettingRound() {
+		return "", fmt.Errorf("table is paused; resume it first")
+	}
+
+	if !g.isBettingRound() {
+		status := g.currentStatus.String()
+		g.abandonHand("forced by operator during "+status, actor)
+		return fmt.Sprintf("voided the hand stuck in %s and returned all bets", status), nil
+	}
+
+	player := g.currentTurnPlayer()
+	action := g.timeoutAction(player)
+
+	g.audit("turn_forced", actor, map[string]interface{}{
+		"player": player,
+		"action": action.String(),
+		"status": g.currentStatus.String(),
+	})
+	g.log().WithFields(logrus.Fields{
+		"actor":  actor,
+		"player": player,
+		"action": action.String(),
+	}).Warn("Operator forced a turn")
+
+	// Our own player's forced action goes to peers like any other; a remote
+	// player's is applied here only, as a kick would be
+	if player == g.listenAddr {
+		if err := g.handlePlayerAction(player, "", action.String(), 0); err != nil {
+			return "", err
+		}
+	} else {
+		g.forceRemoteAction(player, action)
+	}
+
+	return fmt.Sprintf("%s was made to %s", player, action), nil
+}
+
+func (g *Game) forceRemoteAction(player string, action PlayerAction) {
+	g.logWAL(persistence.WALAction, walAction{Player: player, Action: action.String()})
+	g.storeAction(player, action.String(), 0)
+
+	g.applyPlayerAction(player, action, 0)
+	if err := g.checkChips(); err != nil {
+		g.freeze("forced "+action.String()+" for "+player, err)
+		return
+	}
+	g.publishEvent(protocol.EventPlayerAction, protocol.PlayerActionEvent{
+		PlayerID: player,
+		Action:   action.String(),
+		NewPot:   g.currentPot,
+		NewStack: g.playerStates[player].Stack,
+	})
+
+	g.advanceTurnAndCheckRoundEnd()
+	g.turnStartedAt = time.Now()
+
+	g.publishStateUpdate()
+	g.publishTurnChange()
+}
@@ -0,0 +1,167 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/RedPaladin7/peerpoker/internal/blockchain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ChainTxAnchor is the escrow transaction recording a hand's commitment
+const ChainTxAnchor = "anchor"
+
+// handCommitment is the part of a hand history its anchored hash covers:
+// who played and with what, every action, the board and the hands shown,
+// and the result.
+// Maps marshal with sorted keys, so the encoding is canonical.
+type handCommitment struct {
+	UID         string              `json:"uid"`
+	ID          int                 `json:"id"`
+	Seats       []string            `json:"seats"`
+	Dealer      string              `json:"dealer"`
+	Start       []HandSeat          `json:"start,omitempty"`
+	Actions     []HandAction        `json:"actions"`
+	Board       []string            `json:"board"`
+	SecondBoard []string            `json:"second_board,omitempty"`
+	Shown       map[string][]string `json:"shown,omitempty"`
+	Bets        map[string]int      `json:"bets"`
+	Winnings    map[string]int      `json:"winnings"`
+	Pot         int                 `json:"pot"`
+	Rake        int                 `json:"rake"`
+}
+
+// HandProof shows whether a recorded hand still matches the commitment
+// anchored when it finished
+type HandProof struct {
+	HandID     int             `json:"hand_id"`
+	UID        string          `json:"uid"`
+	Record     json.RawMessage `json:"record"`     // the canonical record hashed
+	Commitment string          `json:"commitment"` // hash taken when the hand finished
+	Computed   string          `json:"computed"`   // hash of the record as it is now
+	Valid      bool            `json:"valid"`
+
+	// The anchoring transaction and, when the chain can be read, the hash
+	// stored there
+	Anchor   *ChainTx `json:"anchor,omitempty"`
+	OnChain  string   `json:"on_chain,omitempty"`
+	Anchored bool     `json:"anchored"`
+}
+
+func canonicalHand(hand *HandHistory) ([]byte, error) {
+	return json.Marshal(handCommitment{
+		UID:         hand.UID,
+		ID:          hand.ID,
+		Seats:       hand.Seats,
+		Dealer:      hand.Dealer,
+		Start:       hand.Start,
+		Actions:     hand.Actions,
+		Board:       hand.Board,
+		SecondBoard: hand.SecondBoard,
+		Shown:       hand.Shown,
+		Bets:        hand.Bets,
+		Winnings:    hand.Winnings,
+		Pot:         hand.Pot,
+		Rake:        hand.Rake,
+	})
+}
+
+// HandCommitment recomputes the commitment of a hand history, e.g. one
+// exported from /api/hands, for comparison with the one it carries
+func HandCommitment(hand *HandHistory) (string, error) {
+	record, err := canonicalHand(hand)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode hand %d: %w", hand.ID, err)
+	}
+	return crypto.Keccak256Hash(record).Hex(), nil
+}
+
+// commitHand hashes a finished hand's history and anchors the hash on-chain
+func (g *Game) commitHand(hand *HandHistory) {
+	record, err := canonicalHand(hand)
+	if err != nil {
+		g.log().Warnf("Failed to encode hand %d for its commitment: %v", hand.ID, err)
+		return
+	}
+	commitment := crypto.Keccak256Hash(record)
+	hand.Commitment = commitment.Hex()
+
+	if !g.blockchainEnabled {
+		return
+	}
+
+	receipt, err := g.chain().AnchorHand(hand.UID, commitment)
+	if errors.Is(err, blockchain.ErrNotSupported) {
+		g.log().Debugf("Hand %d commitment kept off-chain: %v", hand.ID, err)
+		return
+	}
+	attachChainTx(hand, fmt.Sprintf("0x%x", g.blockchainGameID), newChainTx(ChainTxAnchor, receipt, err))
+	details := map[string]interface{}{
+		"hand_id":    hand.ID,
+		"commitment": hand.Commitment,
+	}
+	if receipt != nil {
+		details["tx_hash"] = receipt.TxHash
+	}
+	if err != nil {
+		details["error"] = err.Error()
+		g.log().Errorf("Failed to anchor hand %d: %v", hand.ID, err)
+	}
+	g.audit("hand_anchored", "table", details)
+}
+
+// HandProof recomputes a recorded hand's commitment and checks it against
+// the one taken when the hand finished and, if readable, the one on-chain
+func (g *Game) HandProof(id int) (HandProof, error) {
+	g.lock.RLock()
+	hand := g.handByID(id)
+	if hand == nil {
+		g.lock.RUnlock()
+		return HandProof{}, fmt.Errorf("hand %d not found", id)
+	}
+	if hand.Commitment == "" {
+		g.lock.RUnlock()
+		return HandProof{}, fmt.Errorf("hand %d has no commitment", id)
+	}
+
+	record, err := canonicalHand(hand)
+	if err != nil {
+		g.lock.RUnlock()
+		return HandProof{}, fmt.Errorf("failed to encode hand %d: %w", id, err)
+	}
+	proof := HandProof{
+		HandID:     hand.ID,
+		UID:        hand.UID,
+		Record:     record,
+		Commitment: hand.Commitment,
+		Computed:   crypto.Keccak256Hash(record).Hex(),
+	}
+	proof.Valid = proof.Computed == proof.Commitment
+	if hand.Chain != nil {
+		for i := range hand.Chain.Transactions {
+			if hand.Chain.Transactions[i].Kind == ChainTxAnchor {
+				tx := hand.Chain.Transactions[i]
+				proof.Anchor = &tx
+			}
+		}
+	}
+	g.lock.RUnlock()
+
+	// Read the chain without holding the game lock
+	if g.blockchainEnabled {
+		anchored, err := g.blockchain.HandAnchor(proof.UID)
+		switch {
+		case errors.Is(err, blockchain.ErrNotSupported):
+			// Nothing is anchored, so the proof rests on the local commitment
+		case err != nil:
+			g.log().Warnf("Failed to read anchor for hand %d: %v", id, err)
+		case anchored != [32]byte{}:
+			proof.OnChain = common.Hash(anchored).Hex()
+			proof.Anchored = true
+			proof.Valid = proof.Valid && proof.OnChain == proof.Commitment
+		}
+	}
+	return proof, nil
+}
@@ -0,0 +1,281 @@
+package game
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/blockchain"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+)
+
+// AttestationTimeout is how long a payout waits for every player's
+// signature before it is kept as a failed settlement instead of submitted
+const AttestationTimeout = 30 * time.Second
+
+// Attestation is a player's signature over a hand's escrow payout
+type Attestation struct {
+	PlayerID  string `json:"player_id"`
+	Signer    string `json:"signer"`
+	Signature string `json:"signature"` // hex
+}
+
+// pendingSettlement is a payout collecting attestations before it goes
+// on-chain. It carries the hand's IDs because the table deals on while it waits.
+type pendingSettlement struct {
+	kind         string
+	handID       int
+	handUID      string
+	gameID       [32]byte
+	seq          uint64 // channel states only
+	winners      []string
+	amounts      []int
+	digest       []byte
+	attesters    map[string]bool
+	attestations map[string]Attestation
+}
+
+// receivedAttestation is a verified signature that may arrive before this
+// node has worked out the payout itself
+type receivedAttestation struct {
+	Attestation
+	digest []byte
+}
+
+// distributeWinningsOnChain sends the payout to the escrow contract. With
+// message signing on, the players left in the hand must first sign the
+// payout, so the contract need not take this node's word for it.
+func (g *Game) distributeWinningsOnChain(kind string, winners []string, amounts []int) {
+	p := &pendingSettlement{
+		kind:         kind,
+		handID:       g.currentHandID(),
+		handUID:      g.handUID,
+		gameID:       g.blockchainGameID,
+		winners:      append([]string{}, winners...),
+		amounts:      append([]int{}, amounts...),
+		attesters:    g.settlementAttesters(kind, winners),
+		attestations: make(map[string]Attestation),
+	}
+	p.digest = blockchain.SettlementDigest(p.gameID, toAddresses(p.winners), toWei(p.amounts))
+
+	g.log().WithFields(logrus.Fields{
+		"game_id":  fmt.Sprintf("0x%x", p.gameID),
+		"hand_uid": p.handUID,
+		"winners":  len(winners),
+	}).Info("Distributing winnings on blockchain...")
+
+	if g.msgSigner == nil {
+		g.submitSettlement(p)
+		return
+	}
+
+	if p.attesters[g.listenAddr] {
+		signature, err := g.msgSigner.SignData(p.digest)
+		if err != nil {
+			g.log().Errorf("Failed to attest payout: %v", err)
+		} else {
+			p.attestations[g.listenAddr] = Attestation{
+				PlayerID:  g.listenAddr,
+				Signer:    g.msgSigner.Address(),
+				Signature: hex.EncodeToString(signature),
+			}
+			g.sendToPlayers(protocol.TypeResultAttestation, protocol.ResultAttestationPayload{
+				HandUID:   p.handUID,
+				GameID:    fmt.Sprintf("0x%x", p.gameID),
+				Winners:   p.winners,
+				Amounts:   p.amounts,
+				Signature: hex.EncodeToString(signature),
+			}, g.getOtherPlayers()...)
+		}
+	}
+
+	// Attestations that arrived before we settled count if they signed the same payout
+	for playerID, received := range g.earlyAttestations[p.handUID] {
+		if p.attesters[playerID] && string(received.digest) == string(p.digest) {
+			p.attestations[playerID] = received.Attestation
+		}
+	}
+	delete(g.earlyAttestations, p.handUID)
+
+	g.pendingSettlements[p.handUID] = p
+	if g.checkAttestations(p) {
+		return
+	}
+
+	g.afterFunc(AttestationTimeout, "attestation timeout", func() {
+		if g.pendingSettlements[p.handUID] != p {
+			return
+		}
+		delete(g.pendingSettlements, p.handUID)
+
+		missing := p.missingAttesters()
+		g.log().WithFields(logrus.Fields{
+			"hand_uid": p.handUID,
+			"missing":  missing,
+		}).Warn("Payout not attested by every player, holding it back")
+		g.recordFailedSettlement(p, fmt.Errorf("missing attestations from %v", missing))
+	})
+}
+
+// settlementAttesters are the players who must sign a payout: everyone
+// refunded, or everyone who reached the end of the hand without folding
+func (g *Game) settlementAttesters(kind string, winners []string) map[string]bool {
+	attesters := make(map[string]bool)
+	if kind == ChainTxRefund {
+		for _, addr := range winners {
+			attesters[addr] = true
+		}
+		return attesters
+	}
+
+	for addr, state := range g.playerStates {
+		if g.inHand(addr) && !state.IsFolded {
+			attesters[addr] = true
+		}
+	}
+	return attesters
+}
+
+func (g *Game) handleMessageResultAttestation(from string, payload protocol.ResultAttestationPayload) error {
+	return g.exec("result attestation message from "+from, func() error {
+		if !g.blockchainEnabled || g.msgVerifier == nil {
+			return fmt.Errorf("payout attestation from %s, but this node does not settle on-chain with signed messages", from)
+		}
+		signer, ok := g.msgVerifier.SenderFor(from)
+		if !ok {
+			return fmt.Errorf("payout attestation from %s before any signed message", from)
+		}
+		if len(payload.Winners) != len(payload.Amounts) {
+			return fmt.Errorf("payout attestation from %s has %d winners and %d amounts", from, len(payload.Winners), len(payload.Amounts))
+		}
+
+		gameID, err := blockchain.HexToGameID(payload.GameID)
+		if err != nil {
+			return fmt.Errorf("payout attestation from %s: %w", from, err)
+		}
+		signature, err := hex.DecodeString(payload.Signature)
+		if err != nil {
+			return fmt.Errorf("payout attestation from %s: bad signature encoding", from)
+		}
+		digest := blockchain.SettlementDigest(gameID, toAddresses(payload.Winners), toWei(payload.Amounts))
+		if !blockchain.VerifySignature(digest, signature, common.HexToAddress(signer)) {
+			return fmt.Errorf("payout attestation from %s is not signed by %s", from, signer)
+		}
+
+		attestation := Attestation{
+			PlayerID:  from,
+			Signer:    signer,
+			Signature: payload.Signature,
+		}
+
+		p, ok := g.pendingSettlements[payload.HandUID]
+		if !ok {
+			if g.earlyAttestations[payload.HandUID] == nil {
+				g.earlyAttestations[payload.HandUID] = make(map[string]receivedAttestation)
+			}
+			g.earlyAttestations[payload.HandUID][from] = receivedAttestation{Attestation: attestation, digest: digest}
+			return nil
+		}
+
+		if !p.attesters[from] {
+			return fmt.Errorf("player %s does not attest the payout for hand %s", from, p.handUID)
+		}
+		if string(digest) != string(p.digest) {
+			g.log().WithFields(logrus.Fields{
+				"player":   from,
+				"hand_uid": p.handUID,
+			}).Warn("Player attested a different payout")
+			return fmt.Errorf("player %s attested a different payout for hand %s", from, p.handUID)
+		}
+
+		p.attestations[from] = attestation
+		g.checkAttestations(p)
+		return nil
+	})
+}
+
+// checkAttestations submits the payout once every player has signed it
+func (g *Game) checkAttestations(p *pendingSettlement) bool {
+	if len(p.missingAttesters()) > 0 {
+		return false
+	}
+	delete(g.pendingSettlements, p.handUID)
+	g.submitSettlement(p)
+	return true
+}
+
+func (p *pendingSettlement) missingAttesters() []string {
+	missing := []string{}
+	for addr := range p.attesters {
+		if _, ok := p.attestations[addr]; !ok {
+			missing = append(missing, addr)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+func (p *pendingSettlement) attestationList() []Attestation {
+	list := make([]Attestation, 0, len(p.attestations))
+	for _, attestation := range p.attestations {
+		list = append(list, attestation)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].PlayerID < list[j].PlayerID
+	})
+	return list
+}
+
+// submitSettlement sends a payout on-chain, with its attestations if it has any
+func (g *Game) submitSettlement(p *pendingSettlement) {
+	attestations := p.attestationList()
+	receipt, err := g.endGame(p.gameID, p.winners, p.amounts, attestations)
+
+	if hand := g.handByID(p.handID); hand != nil {
+		attachChainTx(hand, fmt.Sprintf("0x%x", p.gameID), newChainTx(p.kind, receipt, err))
+		hand.Attestations = attestations
+	}
+	g.storeSettlement(p.handID, p.handUID, p.kind, p.gameID, p.winners, p.amounts, receipt, err)
+	g.auditSettlement(p.kind, p.gameID, p.winners, p.amounts, receipt, err)
+	if err != nil {
+		g.log().Errorf("Failed to distribute winnings on blockchain: %v", err)
+		g.log().Warn("Winnings distributed in-game only (blockchain transaction failed)")
+		g.recordFailedSettlement(p, err)
+		return
+	}
+
+	g.log().WithFields(logrus.Fields{
+		"game_id":      fmt.Sprintf("0x%x", p.gameID),
+		"winners":      len(p.winners),
+		"attestations": len(attestations),
+	}).Info("✅ Winnings distributed on blockchain successfully")
+}
+
+// endGame settles on-chain, passing the players' signatures when there are any
+func (g *Game) endGame(gameID [32]byte, winners []string, amounts []int, attestations []Attestation) (*blockchain.TxReceipt, error) {
+	if len(attestations) == 0 {
+		return g.chain().EndGame(gameID, toAddresses(winners), toWei(amounts))
+	}
+
+	signers := make([]common.Address, len(attestations))
+	signatures := make([][]byte, len(attestations))
+	for i, attestation := range attestations {
+		signature, err := hex.DecodeString(attestation.Signature)
+		if err != nil {
+			return nil, fmt.Errorf("attestation from %s: bad signature encoding", attestation.PlayerID)
+		}
+		signers[i] = common.HexToAddress(attestation.Signer)
+		signatures[i] = signature
+	}
+	receipt, err := g.chain().EndGameWithAttestations(gameID, toAddresses(winners), toWei(amounts), signers, signatures)
+	if errors.Is(err, blockchain.ErrNotSupported) {
+		// The signatures stay with the hand; the contract just cannot check them
+		g.log().Warn("Contracts cannot check payout attestations, settling without them")
+		return g.chain().EndGame(gameID, toAddresses(winners), toWei(amounts))
+	}
+	return receipt, err
+}
@@ -0,0 +1,83 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/blockchain"
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+)
+
+// maxAuditEntries bounds the in-memory audit log
+const maxAuditEntries = 1000
+
+// AuditEntry records a privileged or money-moving operation. Seq and Hash
+// place it in the persistent audit log when one is set.
+type AuditEntry struct {
+	Time    time.Time              `json:"time"`
+	Action  string                 `json:"action"`
+	Actor   string                 `json:"actor"`
+	HandUID string                 `json:"hand_uid,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+	Seq     uint64                 `json:"seq,omitempty"`
+	Hash    string                 `json:"hash,omitempty"`
+}
+
+// SetAuditLog also records audit entries in a hash-chained log on disk,
+// under tableID
+func (g *Game) SetAuditLog(log *persistence.AuditLog, tableID string) {
+	g.do("set audit log", func() {
+		g.auditStore = log
+		g.auditTableID = tableID
+	})
+}
+
+// AuditLog returns the recorded audit entries, oldest first
+func (g *Game) AuditLog() []AuditEntry {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return append([]AuditEntry{}, g.auditLog...)
+}
+
+// auditSettlement records an escrow payout submitted on-chain
+func (g *Game) auditSettlement(kind string, gameID [32]byte, winners []string, amounts []int, receipt *blockchain.TxReceipt, err error) {
+	details := map[string]interface{}{
+		"kind":    kind,
+		"game_id": fmt.Sprintf("0x%x", gameID),
+		"winners": winners,
+		"amounts": amounts,
+	}
+	if receipt != nil {
+		details["tx_hash"] = receipt.TxHash
+	}
+	if err != nil {
+		details["error"] = err.Error()
+		g.audit("settlement_failed", "table", details)
+		return
+	}
+	g.audit("settlement_submitted", "table", details)
+}
+
+func (g *Game) audit(action, actor string, details map[string]interface{}) {
+	entry := AuditEntry{
+		Time:    time.Now(),
+		Action:  action,
+		Actor:   actor,
+		HandUID: g.handUID,
+		Details: details,
+	}
+	if g.auditStore != nil {
+		record, err := g.auditStore.Append(g.auditTableID, action, actor, g.handUID, details)
+		if err != nil {
+			g.log().Errorf("Failed to write audit record %s: %v", action, err)
+		} else {
+			entry.Seq = record.Seq
+			entry.Hash = record.Hash
+		}
+	}
+
+	g.auditLog = append(g.auditLog, entry)
+	if len(g.auditLog) > maxAuditEntries {
+		g.auditLog = g.auditLog[len(g.auditLog)-maxAuditEntries:]
+	}
+}
@@ -1,23 +1,37 @@
 package game
 
 import (
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
 	"github.com/sirupsen/logrus"
 )
 
 // advanceTurnAndCheckRoundEnd advances to the next player and checks if round is over
 func (g *Game) advanceTurnAndCheckRoundEnd() {
 	if g.checkRoundEnd() {
-		g.advanceToNextRound()
+		g.endBettingRound()
 		return
 	}
 
 	g.incNextPlayer()
 
 	if g.checkRoundEnd() {
-		g.advanceToNextRound()
+		g.endBettingRound()
 	}
 }
 
+// endBettingRound moves to the next street, or runs out the rest of the
+// board when nobody is left to bet before the river
+func (g *Game) endBettingRound() {
+	switch g.currentStatus {
+	case GameStatusPreFlop, GameStatusFlop, GameStatusTurn:
+		if g.bettingClosed() {
+			g.beginRunout()
+			return
+		}
+	}
+	g.advanceToNextRound()
+}
+
 // incNextPlayer moves to the next active player
 func (g *Game) incNextPlayer() {
 	startID := g.currentPlayerTurn
@@ -38,7 +52,7 @@ func (g *Game) incNextPlayer() {
 		attempts++
 	}
 
-	logrus.Warn("No active players found who can act")
+	g.log().Warn("No active players found who can act")
 }
 
 // checkRoundEnd checks if the betting round is complete
@@ -62,57 +76,47 @@ func (g *Game) checkRoundEnd() bool {
 
 	// All remaining players are all-in
 	if canActCount == 0 {
-		logrus.Info("All remaining players are all-in, advancing to showdown")
+		g.log().Info("All remaining players are all-in, advancing to showdown")
 		return true
 	}
 
-	// Only one player can act and they've matched the bet
-	if canActCount == 1 {
-		allMatched := true
-		for _, state := range g.playerStates {
-			if state.IsActive && !state.IsFolded && !state.IsAllIn {
-				if state.CurrentRoundBet < g.highestBet {
-					allMatched = false
-					break
-				}
-			}
+	// The round closes once everyone who can still act has acted and
+	// matched the highest bet. A lone player who can act has nobody to bet
+	// against, so matching is enough for them.
+	for addr, state := range g.playerStates {
+		if !state.IsActive || state.IsFolded || state.IsAllIn || !g.inHand(addr) {
+			continue
 		}
-		if allMatched {
-			return true
+		if state.CurrentRoundBet < g.highestBet {
+			return false
 		}
-	}
-
-	// All players have matched the highest bet
-	allMatchedOrOut := true
-	for _, state := range g.playerStates {
-		if state.IsActive && !state.IsFolded && !state.IsAllIn {
-			if state.CurrentRoundBet < g.highestBet {
-				allMatchedOrOut = false
-				break
-			}
+		if !state.ActedThisRound && canActCount > 1 {
+			return false
 		}
 	}
-
-	if allMatchedOrOut {
-		// Check if we're back to the last raiser
-		nextToAct := g.getNextActivePlayerID(g.lastRaiserID)
-		if g.currentPlayerTurn == nextToAct {
-			return true
-		}
-	}
-
-	return false
+	return true
 }
 
 // advanceToNextRound moves to the next betting round
 func (g *Game) advanceToNextRound() {
-	logrus.Infof("=== Advancing from %s ===", g.currentStatus.String())
+	g.log().Infof("=== Advancing from %s ===", g.currentStatus.String())
+
+	// Whoever bet or raised last on the river shows first
+	aggressor := -1
+	if g.currentStatus == GameStatusRiver && g.fullRaiseTo > 0 {
+		aggressor = g.lastRaiserID
+	}
 
 	// Reset betting for new round
 	for _, state := range g.playerStates {
 		state.CurrentRoundBet = 0
+		state.ActedThisRound = false
+		state.ActedAtRaiseTo = 0
 	}
 	g.highestBet = 0
+	g.fullRaiseTo = 0
+	g.lastRaiseAmount = BigBlind
+	g.pot.EndStreet()
 
 	switch g.currentStatus {
 	case GameStatusDealing:
@@ -133,7 +137,13 @@ func (g *Game) advanceToNextRound() {
 
 	case GameStatusRiver:
 		g.setStatus(GameStatusShowdown)
-		g.ResolveWinner()
+		// Hands are shown in turn, except after an all-in, when they are
+		// all turned up at once
+		if g.bettingClosed() {
+			g.resolveWinner()
+		} else {
+			g.beginShowdown(aggressor)
+		}
 
 	case GameStatusShowdown:
 		g.resetHandState()
@@ -142,10 +152,10 @@ func (g *Game) advanceToNextRound() {
 
 // dealFlop deals the flop (3 community cards)
 func (g *Game) dealFlop() {
-	logrus.Info("Dealing flop (3 cards)...")
+	g.log().Info("Dealing flop (3 cards)...")
 	g.dealCommunityCards(3)
 	
-	logrus.Infof("Flop: %v", g.communityCards)
+	g.log().Infof("Flop: %v", g.communityCards)
 	
 	// Reset turn to first active player after dealer
 	g.currentPlayerTurn = g.getNextActivePlayerID(g.currentDealerID)
@@ -156,10 +166,10 @@ func (g *Game) dealFlop() {
 
 // dealTurn deals the turn (4th community card)
 func (g *Game) dealTurn() {
-	logrus.Info("Dealing turn (1 card)...")
+	g.log().Info("Dealing turn (1 card)...")
 	g.dealCommunityCards(1)
 	
-	logrus.Infof("Turn: %s", g.communityCards[3].String())
+	g.log().Infof("Turn: %s", g.communityCards[3].String())
 	
 	// Reset turn to first active player after dealer
 	g.currentPlayerTurn = g.getNextActivePlayerID(g.currentDealerID)
@@ -170,10 +180,10 @@ func (g *Game) dealTurn() {
 
 // dealRiver deals the river (5th community card)
 func (g *Game) dealRiver() {
-	logrus.Info("Dealing river (1 card)...")
+	g.log().Info("Dealing river (1 card)...")
 	g.dealCommunityCards(1)
 	
-	logrus.Infof("River: %s", g.communityCards[4].String())
+	g.log().Infof("River: %s", g.communityCards[4].String())
 	
 	// Reset turn to first active player after dealer
 	g.currentPlayerTurn = g.getNextActivePlayerID(g.currentDealerID)
@@ -193,10 +203,95 @@ func (g *Game) broadcastCommunityCards(stage string) {
 		}
 	}
 
-	// Send to all players via protocol
-	// This will be picked up by the frontend
-	logrus.WithFields(logrus.Fields{
+	g.log().WithFields(logrus.Fields{
 		"stage": stage,
 		"cards": len(cards),
 	}).Info("Broadcasting community cards")
+
+	// The full board so far, so clients never have to merge stages
+	g.publishEvent(protocol.EventCommunityCard, protocol.CommunityCardEvent{
+		Stage: stage,
+		Cards: toCardData(g.communityCards),
+	})
+}
+
+// amountToCall is what a player must put in to call, capped at their stack
+func (g *Game) amountToCall(state *PlayerState) int {
+	amount := g.highestBet - state.CurrentRoundBet
+	if amount < 0 {
+		return 0
+	}
+	if amount > state.Stack {
+		return state.Stack
+	}
+	return amount
+}
+
+// minRaiseTo is the smallest total a bet or raise may bring a player's
+// round bet to: the last full raise again on top of the bet it made.
+// Incomplete all-in raises above that bet don't move it.
+func (g *Game) minRaiseTo() int {
+	return g.fullRaiseTo + g.lastRaiseAmount
+}
+
+// raiseBetTo makes a player's round bet the one to match. Only a full raise
+// (at least the last raise's size, or the big blind to open) sets the next
+// minimum and reopens the betting; a short all-in just has to be called.
+func (g *Game) raiseBetTo(state *PlayerState) {
+	g.highestBet = state.CurrentRoundBet
+
+	raise := state.CurrentRoundBet - g.fullRaiseTo
+	if raise < g.lastRaiseAmount {
+		g.log().Infof("Incomplete raise to %d does not reopen the betting", state.CurrentRoundBet)
+		return
+	}
+	g.lastRaiseAmount = raise
+	g.fullRaiseTo = state.CurrentRoundBet
+	g.lastRaiserID = state.RotationID
+}
+
+// canRaise reports whether the betting is open to a player: they have not
+// acted this round, or someone made a full raise since they did
+func (g *Game) canRaise(state *PlayerState) bool {
+	return !state.ActedThisRound || state.ActedAtRaiseTo < g.fullRaiseTo
+}
+
+// maxBetTo is the largest total a player's round bet can reach: everything
+// they have behind plus what they already put in this round
+func (g *Game) maxBetTo(state *PlayerState) int {
+	return state.Stack + state.CurrentRoundBet
+}
+
+// effectiveStack is the most a player can win or lose against the deepest
+// opponent still in the hand, counting this round's bets
+func (g *Game) effectiveStack(clientID string) int {
+	state, ok := g.playerStates[clientID]
+	if !ok {
+		return 0
+	}
+
+	deepest := 0
+	for addr, other := range g.playerStates {
+		if addr == clientID || !g.inHand(addr) || other.IsFolded {
+			continue
+		}
+		if other.Stack+other.CurrentRoundBet > deepest {
+			deepest = other.Stack + other.CurrentRoundBet
+		}
+	}
+
+	if mine := g.maxBetTo(state); mine < deepest {
+		return mine
+	}
+	return deepest
+}
+
+// potOdds is the share of the final pot a call would make up; zero when
+// there is nothing to call
+func (g *Game) potOdds(state *PlayerState) float64 {
+	call := g.amountToCall(state)
+	if call == 0 {
+		return 0
+	}
+	return float64(call) / float64(g.currentPot+call)
 }
@@ -0,0 +1,343 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// SitOut takes a player out of the deal, or brings them back. A returning
+// player who missed blinds either posts them next hand (the big blind live,
+// the small blind dead) or, with waitForBB, stays out until the big blind
+// reaches their seat. Requests made during a hand apply to the next one.
+func (g *Game) SitOut(playerID string, sittingOut, waitForBB bool) error {
+	return g.exec("sit out", func() error {
+		if err := g.requestSitOut(playerID, sittingOut, waitForBB); err != nil {
+			return err
+		}
+
+		g.sendToPlayers(protocol.TypeSitOut, protocol.SitOutPayload{
+			SittingOut: sittingOut,
+			WaitForBB:  waitForBB,
+		}, g.getOtherPlayers()...)
+		return nil
+	})
+}
+
+// SittingOut reports whether a player is out of the deal and the blinds they owe
+func (g *Game) SittingOut(playerID string) (sittingOut, waitForBB bool, owed int) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	state, ok := g.playerStates[playerID]
+	if !ok {
+		return false, false, 0
+	}
+	if req, ok := g.sitOutRequests[playerID]; ok {
+		return req.SittingOut || (req.WaitForBB && owedBlinds(state) > 0), req.WaitForBB, owedBlinds(state)
+	}
+	return state.SittingOut, state.WaitForBB, owedBlinds(state)
+}
+
+func (g *Game) handleMessageSitOut(from string, payload protocol.SitOutPayload) error {
+	return g.exec("sit out message from "+from, func() error {
+		return g.requestSitOut(from, payload.SittingOut, payload.WaitForBB)
+	})
+}
+
+func (g *Game) requestSitOut(playerID string, sittingOut, waitForBB bool) error {
+	state, ok := g.playerStates[playerID]
+	if !ok || !state.IsActive {
+		return fmt.Errorf("player %s is not at the table", playerID)
+	}
+
+	req := protocol.SitOutPayload{SittingOut: sittingOut, WaitForBB: waitForBB}
+	if g.currentStatus != GameStatusWaiting {
+		g.sitOutRequests[playerID] = req
+		g.log().WithField("player", playerID).Info("Sit out request applies from the next hand")
+		return nil
+	}
+
+	g.applySitOut(playerID, req)
+	g.publishStateUpdate()
+	return nil
+}
+
+// applySitOutRequests applies sit out and sit in requests made during the last hand
+func (g *Game) applySitOutRequests() {
+	for addr, req := range g.sitOutRequests {
+		if _, ok := g.playerStates[addr]; ok {
+			g.applySitOut(addr, req)
+		}
+	}
+	g.sitOutRequests = make(map[string]protocol.SitOutPayload)
+}
+
+func (g *Game) applySitOut(playerID string, req protocol.SitOutPayload) {
+	state := g.playerStates[playerID]
+	owed := owedBlinds(state)
+
+	state.WaitForBB = !req.SittingOut && req.WaitForBB && owed > 0
+	state.SittingOut = req.SittingOut || state.WaitForBB
+
+	g.log().WithFields(logrus.Fields{
+		"player":      playerID,
+		"sitting_out": state.SittingOut,
+		"wait_for_bb": state.WaitForBB,
+		"owed":        owed,
+	}).Info("Player sit out status changed")
+
+	g.publishEvent(protocol.EventSitOut, protocol.SitOutEvent{
+		PlayerID:   playerID,
+		SittingOut: state.SittingOut,
+		WaitForBB:  state.WaitForBB,
+		OwedBlinds: owed,
+	})
+}
+
+// admitBigBlind deals in a player waiting for the big blind once it reaches
+// their seat. players must be ordered by seat.
+func (g *Game) admitBigBlind(players []string) []string {
+	if g.bigBlindSeat == 0 {
+		return players
+	}
+
+	candidates := append([]string{}, players...)
+	for addr, state := range g.playerStates {
+		if state.WaitForBB && state.IsReady && state.IsActive && state.Seat != 0 {
+			candidates = append(candidates, addr)
+		}
+	}
+	if len(candidates) == len(players) {
+		return players
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return g.playerStates[candidates[i]].Seat < g.playerStates[candidates[j]].Seat
+	})
+
+	next := candidates[g.nextSeatIndex(candidates, g.bigBlindSeat)]
+	state := g.playerStates[next]
+	if !state.WaitForBB {
+		return players
+	}
+
+	state.SittingOut = false
+	state.WaitForBB = false
+	g.log().Infof("Player %s is dealt back in on the big blind", next)
+
+	players = append(players, next)
+	sort.Slice(players, func(i, j int) bool {
+		return g.playerStates[players[i]].Seat < g.playerStates[players[j]].Seat
+	})
+	return players
+}
+
+// placeButton places the button and returns the blinds' rotation IDs (-1 for
+// a dead small blind). After the first hand the big blind always moves to the
+// next dealt-in player after the last big blind, so sitting out cannot skip
+// it; the small blind and button follow onto the last hand's seats even when
+// those are now empty. players must be ordered by seat, with rotation IDs set.
+func (g *Game) placeButton(players []string, drawn bool) (sbID, bbID int) {
+	if button, ok := g.drawnButton(); drawn && ok {
+		// First hand after the draw: the drawn button deals, or the next
+		// drawn player still seated
+		g.currentDealerID = g.playerStates[button].RotationID
+		sbID, bbID = g.liveBlinds(len(players))
+	} else if g.bigBlindSeat == 0 {
+		g.advanceDealer()
+		sbID, bbID = g.liveBlinds(len(players))
+	} else {
+		sbID, bbID = g.movingBlinds(players)
+	}
+
+	if !g.deadButton {
+		g.buttonSeat = g.playerStates[g.rotationMap[g.currentDealerID]].Seat
+	}
+	if sbID >= 0 {
+		g.smallBlindSeat = g.playerStates[g.rotationMap[sbID]].Seat
+	}
+	g.bigBlindSeat = g.playerStates[g.rotationMap[bbID]].Seat
+	return sbID, bbID
+}
+
+// liveBlinds puts the blinds on the players after the button
+func (g *Game) liveBlinds(count int) (sbID, bbID int) {
+	g.deadButton = false
+	if count == 2 {
+		// Heads-up: dealer posts small blind
+		sbID = g.currentDealerID
+		return sbID, g.getNextPlayerID(sbID)
+	}
+	// Multi-way: small blind is left of dealer
+	sbID = g.getNextActivePlayerID(g.currentDealerID)
+	return sbID, g.getNextActivePlayerID(sbID)
+}
+
+// movingBlinds moves the big blind one player on and marks the seated
+// players it passed as owing blinds
+func (g *Game) movingBlinds(players []string) (sbID, bbID int) {
+	prevSB, prevBB := g.smallBlindSeat, g.bigBlindSeat
+	bbAddr := players[g.nextSeatIndex(players, prevBB)]
+	bbID = g.playerStates[bbAddr].RotationID
+	g.markMissedBlinds(prevBB, g.playerStates[bbAddr].Seat)
+
+	if len(players) == 2 {
+		// Heads-up: the other player has the button and the small blind
+		sbID = g.getNextPlayerID(bbID)
+		g.currentDealerID = sbID
+		g.deadButton = false
+		return sbID, bbID
+	}
+
+	// The small blind goes to last hand's big blind, and is dead if they left
+	sbID = -1
+	if addr, ok := g.playerAtSeat(players, prevBB); ok && addr != bbAddr {
+		sbID = g.playerStates[addr].RotationID
+	}
+	g.smallBlindSeat = prevBB
+
+	// The button goes to last hand's small blind seat; when that seat is
+	// empty the player before it acts last
+	addr, ok := g.playerAtSeat(players, prevSB)
+	g.buttonSeat = prevSB
+	g.deadButton = !ok
+	switch {
+	case ok && addr == bbAddr:
+		// A newcomer sat between the blinds; the button skips back past them
+		first := bbID
+		if sbID >= 0 {
+			first = sbID
+		}
+		g.currentDealerID = (first + len(players) - 1) % len(players)
+		g.buttonSeat = g.playerStates[g.rotationMap[g.currentDealerID]].Seat
+		g.deadButton = false
+	case !ok:
+		addr = players[(g.nextSeatIndex(players, prevSB)+len(players)-1)%len(players)]
+		g.currentDealerID = g.playerStates[addr].RotationID
+	default:
+		g.currentDealerID = g.playerStates[addr].RotationID
+	}
+
+	if sbID < 0 || g.deadButton {
+		g.log().WithFields(logrus.Fields{
+			"button_seat":      prevSB,
+			"dead_button":      g.deadButton,
+			"dead_small_blind": sbID < 0,
+		}).Info("Dead button rules applied")
+	}
+	return sbID, bbID
+}
+
+// markMissedBlinds flags the seated players left out of the deal whose seat
+// the big blind passed (from, to] or the small blind landed on
+func (g *Game) markMissedBlinds(from, to int) {
+	for addr, state := range g.playerStates {
+		if state.Seat == 0 || g.rotationMap[state.RotationID] == addr {
+			continue
+		}
+		switch {
+		case seatBetween(state.Seat, from, to):
+			state.MissedBigBlind = true
+			state.MissedSmallBlind = true
+		case state.Seat == from:
+			state.MissedSmallBlind = true
+		default:
+			continue
+		}
+		g.log().WithFields(logrus.Fields{
+			"player": addr,
+			"owed":   owedBlinds(state),
+		}).Info("Player missed blinds while sitting out")
+	}
+}
+
+// postMissedBlinds collects blinds owed by returning players: the big blind
+// live and the small blind dead. Posting a blind in position settles them.
+func (g *Game) postMissedBlinds(sbID, bbID int) map[string]int {
+	posted := make(map[string]int)
+	for id := 0; id < g.nextRotationID; id++ {
+		addr := g.rotationMap[id]
+		state := g.playerStates[addr]
+		if !state.MissedBigBlind && !state.MissedSmallBlind {
+			continue
+		}
+
+		if id != sbID && id != bbID {
+			before := state.TotalBetThisHand
+			if state.MissedBigBlind {
+				g.updatePlayerState(addr, PlayerActionBet, BigBlind)
+			}
+			if state.MissedSmallBlind && state.Stack > 0 {
+				dead := SmallBlind
+				if dead >= state.Stack {
+					dead = state.Stack
+					state.IsAllIn = true
+				}
+				state.Stack -= dead
+				state.TotalBetThisHand += dead
+				g.pot.Add(addr, dead)
+				g.currentPot += dead
+			}
+			posted[addr] = state.TotalBetThisHand - before
+			g.log().Infof("Player %s posted missed blinds: %d", addr, posted[addr])
+		}
+
+		state.MissedBigBlind = false
+		state.MissedSmallBlind = false
+	}
+	return posted
+}
+
+// resetBlindPositions forgets the button and blinds, and any blinds owed
+func (g *Game) resetBlindPositions() {
+	g.buttonSeat, g.smallBlindSeat, g.bigBlindSeat = 0, 0, 0
+	g.deadButton = false
+	for _, state := range g.playerStates {
+		if state.WaitForBB {
+			state.SittingOut = false
+			state.WaitForBB = false
+		}
+		state.MissedBigBlind = false
+		state.MissedSmallBlind = false
+	}
+}
+
+// nextSeatIndex returns the index of the first player seated after seat, wrapping around
+func (g *Game) nextSeatIndex(players []string, seat int) int {
+	for i, addr := range players {
+		if g.playerStates[addr].Seat > seat {
+			return i
+		}
+	}
+	return 0
+}
+
+func (g *Game) playerAtSeat(players []string, seat int) (string, bool) {
+	for _, addr := range players {
+		if g.playerStates[addr].Seat == seat {
+			return addr, true
+		}
+	}
+	return "", false
+}
+
+// seatBetween reports whether seat lies after from and up to to, going round the table
+func seatBetween(seat, from, to int) bool {
+	if from < to {
+		return seat > from && seat <= to
+	}
+	return seat > from || seat <= to
+}
+
+func owedBlinds(state *PlayerState) int {
+	owed := 0
+	if state.MissedBigBlind {
+		owed += BigBlind
+	}
+	if state.MissedSmallBlind {
+		owed += SmallBlind
+	}
+	return owed
+}
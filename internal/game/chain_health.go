@@ -0,0 +1,37 @@
+package game
+
+import (
+	"github.com/RedPaladin7/peerpoker/internal/blockchain"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+)
+
+// ReportChainHealth trips or resets the chain circuit breaker. While it is
+// tripped new hands are dealt without creating an on-chain game; hands
+// already escrowed still settle (and retry) as usual.
+func (g *Game) ReportChainHealth(health blockchain.ChainHealth) {
+	g.do("report chain health", func() {
+		if health.Tripped == (g.chainBreaker != "") {
+			g.chainBreaker = health.Reason
+			return
+		}
+		g.chainBreaker = health.Reason
+
+		action := "chain_breaker_reset"
+		message := "The blockchain is reachable again; new hands are escrowed on-chain"
+		if health.Tripped {
+			action = "chain_breaker_tripped"
+			message = "The blockchain is unavailable; new hands are not escrowed on-chain"
+		}
+		g.audit(action, "table", map[string]interface{}{
+			"reason":  health.Reason,
+			"block":   health.BlockNumber,
+			"balance": health.Balance.String(),
+		})
+
+		g.publishEvent(protocol.EventChainHealth, protocol.ChainHealthEvent{
+			Tripped: health.Tripped,
+			Reason:  health.Reason,
+			Message: message,
+		})
+	})
+}
@@ -0,0 +1,460 @@
+package game
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/blockchain"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+)
+
+// ChannelSignTimeout is how long the stacks after a hand wait for every
+// player's signature. A player still silent after it is taken to have gone,
+// and the last state everyone signed is submitted on-chain.
+const ChannelSignTimeout = 30 * time.Second
+
+// Kinds of escrow transaction for a state channel
+const (
+	ChainTxChannelSubmit   = "channel_submit"
+	ChainTxChannelFinalize = "channel_finalize"
+)
+
+// ChannelState is the session's stacks after a hand, as signed off-chain by
+// the players. Only the highest-sequence state everyone signed goes on-chain.
+type ChannelState struct {
+	GameID     string        `json:"game_id"`
+	Seq        uint64        `json:"seq"`
+	HandID     int           `json:"hand_id"`
+	HandUID    string        `json:"hand_uid"`
+	Players    []string      `json:"players"`
+	Stacks     []int         `json:"stacks"`
+	Signatures []Attestation `json:"signatures"`
+}
+
+// ChannelStatus is the state channel as a player sees it
+type ChannelStatus struct {
+	GameID  string        `json:"game_id"`
+	Seq     uint64        `json:"seq"`
+	Latest  *ChannelState `json:"latest,omitempty"`  // highest state everyone signed
+	Pending *ChannelState `json:"pending,omitempty"` // state still collecting signatures
+	Missing []string      `json:"missing,omitempty"` // players yet to sign it
+}
+
+// stateChannel settles a session's escrow game from signed stacks instead
+// of a transaction per hand
+type stateChannel struct {
+	gameID  [32]byte
+	seq     uint64
+	latest  *ChannelState
+	pending *pendingChannelState
+
+	// Signatures for states this node has not reached yet, by sequence number
+	early map[uint64]map[string]receivedAttestation
+}
+
+type pendingChannelState struct {
+	state      ChannelState
+	digest     []byte
+	signers    map[string]bool
+	signatures map[string]Attestation
+}
+
+func newStateChannel(gameID [32]byte) *stateChannel {
+	return &stateChannel{
+		gameID: gameID,
+		early:  make(map[uint64]map[string]receivedAttestation),
+	}
+}
+
+// usingStateChannel reports whether hands settle through signed channel
+// states. The states must be signed, so it needs message signing.
+func (g *Game) usingStateChannel() bool {
+	return g.tableConfig.StateChannel && g.msgSigner != nil
+}
+
+// advanceChannel signs the stacks after a finished hand as the channel's
+// next state and sends the signature to the other players
+func (g *Game) advanceChannel() {
+	if g.channel != nil && g.channel.gameID != g.blockchainGameID {
+		g.closeChannel("new escrow game")
+	}
+	if g.channel == nil {
+		g.channel = newStateChannel(g.blockchainGameID)
+	}
+	c := g.channel
+
+	players := make([]string, 0, len(g.playerStates))
+	for addr := range g.playerStates {
+		players = append(players, addr)
+	}
+	sort.Strings(players)
+	stacks := make([]int, len(players))
+	signers := make(map[string]bool, len(players))
+	for i, addr := range players {
+		stacks[i] = g.playerStates[addr].Stack
+		signers[addr] = true
+	}
+
+	c.seq++
+	p := &pendingChannelState{
+		state: ChannelState{
+			GameID:  fmt.Sprintf("0x%x", c.gameID),
+			Seq:     c.seq,
+			HandID:  g.currentHandID(),
+			HandUID: g.handUID,
+			Players: players,
+			Stacks:  stacks,
+		},
+		digest:     blockchain.ChannelStateDigest(c.gameID, c.seq, toAddresses(players), toWei(stacks)),
+		signers:    signers,
+		signatures: make(map[string]Attestation),
+	}
+	c.pending = p
+
+	signature, err := g.msgSigner.SignData(p.digest)
+	if err != nil {
+		g.log().Errorf("Failed to sign channel state %d: %v", c.seq, err)
+	} else {
+		p.signatures[g.listenAddr] = Attestation{
+			PlayerID:  g.listenAddr,
+			Signer:    g.msgSigner.Address(),
+			Signature: hex.EncodeToString(signature),
+		}
+		g.sendToPlayers(protocol.TypeChannelState, protocol.ChannelStatePayload{
+			GameID:    p.state.GameID,
+			Seq:       p.state.Seq,
+			HandUID:   p.state.HandUID,
+			Players:   players,
+			Stacks:    stacks,
+			Signature: hex.EncodeToString(signature),
+		}, g.getOtherPlayers()...)
+	}
+
+	// Signatures that arrived before this node finished the hand count if
+	// they signed the same stacks
+	for playerID, received := range c.early[c.seq] {
+		if p.signers[playerID] && string(received.digest) == string(p.digest) {
+			p.signatures[playerID] = received.Attestation
+		}
+	}
+	for seq := range c.early {
+		if seq <= c.seq {
+			delete(c.early, seq)
+		}
+	}
+
+	if g.checkChannelState(c) {
+		return
+	}
+
+	g.afterFunc(ChannelSignTimeout, "channel sign timeout", func() {
+		if g.channel != c || c.pending != p {
+			return
+		}
+
+		g.log().WithFields(logrus.Fields{
+			"seq":     p.state.Seq,
+			"missing": p.missingSigners(),
+		}).Warn("Players went silent on the state channel, settling the last signed state")
+		g.closeChannel("players silent")
+	})
+}
+
+// checkChannelState makes the pending state the latest once everyone has signed it
+func (g *Game) checkChannelState(c *stateChannel) bool {
+	p := c.pending
+	if p == nil || len(p.missingSigners()) > 0 {
+		return false
+	}
+
+	state := p.state
+	state.Signatures = make([]Attestation, 0, len(p.signatures))
+	for _, signature := range p.signatures {
+		state.Signatures = append(state.Signatures, signature)
+	}
+	sort.Slice(state.Signatures, func(i, j int) bool {
+		return state.Signatures[i].PlayerID < state.Signatures[j].PlayerID
+	})
+	c.latest = &state
+	c.pending = nil
+
+	g.log().WithFields(logrus.Fields{
+		"game_id": state.GameID,
+		"seq":     state.Seq,
+	}).Debug("Channel state signed by every player")
+	return true
+}
+
+func (s *ChannelState) signatureMap() map[string]Attestation {
+	signatures := make(map[string]Attestation, len(s.Signatures))
+	for _, signature := range s.Signatures {
+		signatures[signature.PlayerID] = signature
+	}
+	return signatures
+}
+
+func (p *pendingChannelState) missingSigners() []string {
+	missing := []string{}
+	for addr := range p.signers {
+		if _, ok := p.signatures[addr]; !ok {
+			missing = append(missing, addr)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+func (g *Game) handleMessageChannelState(from string, payload protocol.ChannelStatePayload) error {
+	return g.exec("channel state message from "+from, func() error {
+		if !g.blockchainEnabled || g.msgVerifier == nil {
+			return fmt.Errorf("channel state from %s, but this node does not settle on-chain with signed messages", from)
+		}
+		signer, ok := g.msgVerifier.SenderFor(from)
+		if !ok {
+			return fmt.Errorf("channel state from %s before any signed message", from)
+		}
+		if len(payload.Players) != len(payload.Stacks) {
+			return fmt.Errorf("channel state from %s has %d players and %d stacks", from, len(payload.Players), len(payload.Stacks))
+		}
+
+		gameID, err := blockchain.HexToGameID(payload.GameID)
+		if err != nil {
+			return fmt.Errorf("channel state from %s: %w", from, err)
+		}
+		signature, err := hex.DecodeString(payload.Signature)
+		if err != nil {
+			return fmt.Errorf("channel state from %s: bad signature encoding", from)
+		}
+		digest := blockchain.ChannelStateDigest(gameID, payload.Seq, toAddresses(payload.Players), toWei(payload.Stacks))
+		if !blockchain.VerifySignature(digest, signature, common.HexToAddress(signer)) {
+			return fmt.Errorf("channel state from %s is not signed by %s", from, signer)
+		}
+
+		attestation := Attestation{
+			PlayerID:  from,
+			Signer:    signer,
+			Signature: payload.Signature,
+		}
+
+		c := g.channel
+		if c == nil || c.gameID != gameID {
+			if gameID != g.blockchainGameID {
+				return fmt.Errorf("channel state from %s is for another escrow game", from)
+			}
+			if c != nil {
+				return nil // this node settles its last channel when the hand ends
+			}
+			c = newStateChannel(gameID)
+			g.channel = c
+		}
+		if payload.Seq > c.seq {
+			// This node has not finished the hand yet
+			if c.early[payload.Seq] == nil {
+				c.early[payload.Seq] = make(map[string]receivedAttestation)
+			}
+			c.early[payload.Seq][from] = receivedAttestation{Attestation: attestation, digest: digest}
+			return nil
+		}
+
+		p := c.pending
+		if p == nil || p.state.Seq != payload.Seq {
+			return nil // a state already settled, or superseded
+		}
+		if !p.signers[from] {
+			return fmt.Errorf("player %s is not part of channel state %d", from, payload.Seq)
+		}
+		if string(digest) != string(p.digest) {
+			g.log().WithFields(logrus.Fields{
+				"player": from,
+				"seq":    payload.Seq,
+			}).Warn("Player signed different channel stacks")
+			return fmt.Errorf("player %s signed different stacks for channel state %d", from, payload.Seq)
+		}
+
+		p.signatures[from] = attestation
+		g.checkChannelState(c)
+		return nil
+	})
+}
+
+// closeChannel submits the highest state everyone signed to the dispute
+// contract, or challenges a lower one already there, and finalizes it once
+// the challenge window has passed
+func (g *Game) closeChannel(reason string) {
+	c := g.channel
+	if c == nil {
+		return
+	}
+	g.channel = nil
+
+	state := c.latest
+	if state == nil {
+		g.log().WithField("reason", reason).Error("State channel closed before any state was signed by every player")
+		g.audit("channel_closed", "table", map[string]interface{}{
+			"game_id": fmt.Sprintf("0x%x", c.gameID),
+			"reason":  reason,
+			"error":   "no fully signed state",
+		})
+		return
+	}
+
+	receipt, err := g.submitChannelState(c.gameID, state)
+	if hand := g.handByID(state.HandID); hand != nil {
+		attachChainTx(hand, state.GameID, newChainTx(ChainTxChannelSubmit, receipt, err))
+	}
+	details := map[string]interface{}{
+		"game_id": state.GameID,
+		"seq":     state.Seq,
+		"players": state.Players,
+		"stacks":  state.Stacks,
+		"reason":  reason,
+	}
+	if receipt != nil {
+		details["tx_hash"] = receipt.TxHash
+	}
+	if err != nil {
+		details["error"] = err.Error()
+		g.log().Errorf("Failed to submit channel state %d: %v", state.Seq, err)
+		g.recordFailedSettlement(&pendingSettlement{
+			kind:    ChainTxChannelSubmit,
+			handID:  state.HandID,
+			handUID: state.HandUID,
+			gameID:  c.gameID,
+			seq:     state.Seq,
+			winners: state.Players,
+			amounts: state.Stacks,
+
+			attestations: state.signatureMap(),
+		}, err)
+	}
+	g.audit("channel_closed", "table", details)
+	if err != nil {
+		return
+	}
+
+	g.log().WithFields(logrus.Fields{
+		"game_id": state.GameID,
+		"seq":     state.Seq,
+		"reason":  reason,
+	}).Info("✅ Channel state submitted, challenge window open")
+	g.scheduleChannelFinalize(c.gameID, state)
+}
+
+// submitChannelState puts a signed state before the dispute contract. A
+// lower state someone else submitted is challenged; a state at least as
+// high is left to stand.
+func (g *Game) submitChannelState(gameID [32]byte, state *ChannelState) (*blockchain.TxReceipt, error) {
+	signers := make([]common.Address, len(state.Signatures))
+	signatures := make([][]byte, len(state.Signatures))
+	for i, attestation := range state.Signatures {
+		signature, err := hex.DecodeString(attestation.Signature)
+		if err != nil {
+			return nil, fmt.Errorf("channel signature from %s: bad signature encoding", attestation.PlayerID)
+		}
+		signers[i] = common.HexToAddress(attestation.Signer)
+		signatures[i] = signature
+	}
+	players, stacks := toAddresses(state.Players), toWei(state.Stacks)
+
+	submitted, err := g.blockchain.GetChannelSubmission(gameID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read channel submission: %w", err)
+	}
+	switch {
+	case submitted == nil:
+		return g.chain().SubmitChannelState(gameID, state.Seq, players, stacks, signers, signatures)
+	case submitted.Seq < state.Seq:
+		g.log().WithFields(logrus.Fields{
+			"submitted": submitted.Seq,
+			"ours":      state.Seq,
+		}).Warn("Stale channel state submitted on-chain, challenging it")
+		return g.chain().ChallengeChannelState(gameID, state.Seq, players, stacks, signers, signatures)
+	default:
+		return nil, nil
+	}
+}
+
+// scheduleChannelFinalize pays out the submitted state when its challenge
+// window closes. Callers are on the loop; the finalize call itself runs off
+// it.
+func (g *Game) scheduleChannelFinalize(gameID [32]byte, state *ChannelState) {
+	window := blockchain.DefaultChallengeWindow
+	if submitted, err := g.blockchain.GetChannelSubmission(gameID); err == nil && submitted != nil && !submitted.Deadline.IsZero() {
+		window = time.Until(submitted.Deadline)
+	}
+
+	g.afterFunc(window, "channel finalize wait", func() {
+		go func() {
+			receipt, err := g.chain().FinalizeChannelState(gameID)
+
+			g.do("channel finalize", func() {
+				if hand := g.handByID(state.HandID); hand != nil {
+					attachChainTx(hand, state.GameID, newChainTx(ChainTxChannelFinalize, receipt, err))
+				}
+				details := map[string]interface{}{
+					"game_id": state.GameID,
+					"seq":     state.Seq,
+				}
+				if receipt != nil {
+					details["tx_hash"] = receipt.TxHash
+				}
+				if err != nil {
+					details["error"] = err.Error()
+					g.log().Errorf("Failed to finalize channel state %d: %v", state.Seq, err)
+				}
+				g.audit("channel_finalized", "table", details)
+			})
+		}()
+	})
+}
+
+// ChannelStatus returns the state channel, if hands are being settled through one
+func (g *Game) ChannelStatus() (ChannelStatus, bool) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	c := g.channel
+	if c == nil {
+		return ChannelStatus{}, false
+	}
+	status := ChannelStatus{
+		GameID: fmt.Sprintf("0x%x", c.gameID),
+		Seq:    c.seq,
+		Latest: c.latest,
+	}
+	if c.pending != nil {
+		pending := c.pending.state
+		status.Pending = &pending
+		status.Missing = c.pending.missingSigners()
+	}
+	return status, true
+}
+
+// CloseChannel settles the session's state channel on-chain, as when the
+// session ends
+func (g *Game) CloseChannel(actor string) error {
+	return g.exec("close channel", func() error {
+		if g.channel == nil {
+			return fmt.Errorf("no state channel is open")
+		}
+		if g.currentStatus != GameStatusWaiting {
+			return fmt.Errorf("cannot close the state channel during a hand")
+		}
+
+		g.audit("channel_close_requested", actor, map[string]interface{}{
+			"game_id": fmt.Sprintf("0x%x", g.channel.gameID),
+			"seq":     g.channel.seq,
+		})
+		gameID := g.channel.gameID
+		g.closeChannel("closed by " + actor)
+		// The channel's escrow game is settled; the next hand opens a new one
+		if g.blockchainGameID == gameID {
+			g.blockchainGameID = [32]byte{}
+		}
+		return nil
+	})
+}
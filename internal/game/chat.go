@@ -0,0 +1,192 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+)
+
+const (
+	ChatMaxLength   = 280
+	ChatHistorySize = 50
+
+	// Flood protection: a burst of chatBurst messages, then one per chatRefill
+	chatBurst  = 5
+	chatRefill = 2 * time.Second
+)
+
+// ChatMessage is a message posted to the table chat
+type ChatMessage struct {
+	PlayerID string    `json:"player_id"`
+	Text     string    `json:"text"`
+	SentAt   time.Time `json:"sent_at"`
+}
+
+// chatRoom holds per-table chat state
+type chatRoom struct {
+	history []ChatMessage
+	buckets map[string]*chatBucket
+	mutes   map[string]map[string]bool // player -> players they muted
+}
+
+type chatBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newChatRoom() *chatRoom {
+	return &chatRoom{
+		buckets: make(map[string]*chatBucket),
+		mutes:   make(map[string]map[string]bool),
+	}
+}
+
+// allow takes a token from the player's bucket, refilling by elapsed time
+func (c *chatRoom) allow(playerID string, now time.Time) bool {
+	b, ok := c.buckets[playerID]
+	if !ok {
+		b = &chatBucket{tokens: chatBurst, last: now}
+		c.buckets[playerID] = b
+	}
+
+	b.tokens += float64(now.Sub(b.last)) / float64(chatRefill)
+	if b.tokens > chatBurst {
+		b.tokens = chatBurst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// PostChat sends a chat message from a local player to the table
+func (g *Game) PostChat(playerID, text string) error {
+	return g.exec("post chat", func() error {
+		msg, err := g.acceptChat(playerID, text)
+		if err != nil {
+			return err
+		}
+
+		g.sendToPlayers(protocol.TypeChat, protocol.ChatPayload{Text: msg.Text}, g.getOtherPlayers()...)
+		return nil
+	})
+}
+
+// ChatHistory returns recent chat messages, hiding players the viewer muted
+func (g *Game) ChatHistory(viewer string) []ChatMessage {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	messages := make([]ChatMessage, 0, len(g.chat.history))
+	for _, msg := range g.chat.history {
+		if !g.chat.mutes[viewer][msg.PlayerID] {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// MuteChat hides another player's chat from a player
+func (g *Game) MuteChat(playerID, target string) error {
+	return g.exec("mute chat", func() error {
+		if _, ok := g.playerStates[target]; !ok || target == playerID {
+			return fmt.Errorf("cannot mute %s", target)
+		}
+
+		if g.chat.mutes[playerID] == nil {
+			g.chat.mutes[playerID] = make(map[string]bool)
+		}
+		g.chat.mutes[playerID][target] = true
+		return nil
+	})
+}
+
+// UnmuteChat shows a previously muted player's chat again
+func (g *Game) UnmuteChat(playerID, target string) {
+	g.do("unmute chat", func() {
+		delete(g.chat.mutes[playerID], target)
+	})
+}
+
+// MutedPlayers returns the players a player has muted
+func (g *Game) MutedPlayers(playerID string) []string {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	muted := make([]string, 0, len(g.chat.mutes[playerID]))
+	for target := range g.chat.mutes[playerID] {
+		muted = append(muted, target)
+	}
+	return muted
+}
+
+func (g *Game) handleMessageChat(from string, payload protocol.ChatPayload) error {
+	return g.exec("chat message from "+from, func() error {
+		_, err := g.acceptChat(from, payload.Text)
+		return err
+	})
+}
+
+// acceptChat validates and rate limits a message, records it and delivers it to clients
+func (g *Game) acceptChat(playerID, text string) (ChatMessage, error) {
+	if _, ok := g.playerStates[playerID]; !ok {
+		return ChatMessage{}, fmt.Errorf("player %s is not at this table", playerID)
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return ChatMessage{}, fmt.Errorf("empty chat message")
+	}
+	if utf8.RuneCountInString(text) > ChatMaxLength {
+		return ChatMessage{}, fmt.Errorf("chat message exceeds %d characters", ChatMaxLength)
+	}
+
+	now := time.Now()
+	if !g.chat.allow(playerID, now) {
+		g.log().WithField("player", playerID).Warn("Chat message dropped by flood protection")
+		return ChatMessage{}, fmt.Errorf("sending messages too quickly")
+	}
+
+	msg := ChatMessage{PlayerID: playerID, Text: text, SentAt: now}
+	g.chat.history = append(g.chat.history, msg)
+	if len(g.chat.history) > ChatHistorySize {
+		g.chat.history = g.chat.history[len(g.chat.history)-ChatHistorySize:]
+	}
+
+	event := protocol.ChatEvent{
+		PlayerID:  playerID,
+		Text:      text,
+		Timestamp: formatEventTime(now),
+	}
+	g.publishEvent(protocol.EventChat, event, g.chatRecipients(playerID)...)
+	return msg, nil
+}
+
+// chatRecipients returns nil (everyone) unless someone muted the sender, in
+// which case it lists every other player explicitly
+func (g *Game) chatRecipients(sender string) []string {
+	muted := false
+	for _, targets := range g.chat.mutes {
+		if targets[sender] {
+			muted = true
+			break
+		}
+	}
+	if !muted {
+		return nil
+	}
+
+	recipients := make([]string, 0, len(g.playerStates))
+	for addr := range g.playerStates {
+		if !g.chat.mutes[addr][sender] {
+			recipients = append(recipients, addr)
+		}
+	}
+	return recipients
+}
@@ -0,0 +1,57 @@
+package game
+
+import (
+	"github.com/RedPaladin7/peerpoker/internal/detection"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+)
+
+// CollusionHands summarises the finished hands in the history for
+// collusion analysis, oldest first. Voided hands moved no chips and are left out.
+func (g *Game) CollusionHands() []detection.HandSummary {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	hands := make([]detection.HandSummary, 0, len(g.handHistory))
+	for _, hand := range g.handHistory {
+		if hand.Voided || hand.EndedAt.IsZero() {
+			continue
+		}
+
+		summary := detection.HandSummary{
+			HandUID:  hand.UID,
+			Players:  append([]string{}, hand.Seats...),
+			Bets:     hand.Bets,
+			Winnings: hand.Winnings,
+			Actions:  make([]detection.HandAction, len(hand.Actions)),
+		}
+		for i, action := range hand.Actions {
+			summary.Actions[i] = detection.HandAction{
+				PlayerID: action.PlayerID,
+				Action:   action.Action,
+				Street:   action.Street,
+			}
+		}
+		hands = append(hands, summary)
+	}
+	return hands
+}
+
+// ReportCollusion audits a collusion alert and tells the players at the
+// table that it is under review
+func (g *Game) ReportCollusion(alert detection.CollusionAlert) {
+	g.do("report collusion", func() {
+		g.audit("collusion_flagged", "table", map[string]interface{}{
+			"kind":    alert.Kind,
+			"players": alert.Players,
+			"hands":   alert.Hands,
+			"score":   alert.Score,
+			"detail":  alert.Detail,
+		})
+
+		g.publishEvent(protocol.EventSuspiciousActivity, protocol.SuspiciousActivityEvent{
+			Kind:    alert.Kind,
+			Players: alert.Players,
+			Message: "Play between these players has been flagged for review",
+		})
+	})
+}
@@ -10,7 +10,8 @@ import (
 )
 
 const (
-	// DisconnectTimeout is how long to wait before declaring player abandoned
+	// DisconnectTimeout is how long to wait before declaring player abandoned,
+	// unless changed with SetTimeout
 	DisconnectTimeout = 5 * time.Minute
 )
 
@@ -19,8 +20,8 @@ type DisconnectHandler struct {
 	game              *Game
 	disconnectTimers  map[string]*time.Timer
 	reconnectChannels map[string]chan bool
+	timeout           time.Duration
 	mu                sync.RWMutex
-	logger            *logrus.Logger
 }
 
 // NewDisconnectHandler creates a new disconnect handler
@@ -29,10 +30,30 @@ func NewDisconnectHandler(game *Game) *DisconnectHandler {
 		game:              game,
 		disconnectTimers:  make(map[string]*time.Timer),
 		reconnectChannels: make(map[string]chan bool),
-		logger:            game.Logger,
+		timeout:           DisconnectTimeout,
 	}
 }
 
+// SetTimeout changes how long disconnected players have to reconnect.
+// Timers already running keep their original deadline.
+func (dh *DisconnectHandler) SetTimeout(timeout time.Duration) {
+	dh.mu.Lock()
+	defer dh.mu.Unlock()
+	dh.timeout = timeout
+}
+
+// Timeout returns how long disconnected players have to reconnect
+func (dh *DisconnectHandler) Timeout() time.Duration {
+	dh.mu.RLock()
+	defer dh.mu.RUnlock()
+	return dh.timeout
+}
+
+// logger returns the table's logger, tagged with the current hand
+func (dh *DisconnectHandler) logger() *logrus.Entry {
+	return dh.game.log()
+}
+
 // HandleDisconnect handles a player disconnection with timeout
 func (dh *DisconnectHandler) HandleDisconnect(ctx context.Context, playerID string) error {
 	dh.mu.Lock()
@@ -43,14 +64,17 @@ func (dh *DisconnectHandler) HandleDisconnect(ctx context.Context, playerID stri
 		return fmt.Errorf("disconnect already being handled for player %s", playerID)
 	}
 
-	// Create reconnect channel
-	dh.reconnectChannels[playerID] = make(chan bool, 1)
+	// Create reconnect channel, kept here so the wait below does not read
+	// the map without the lock
+	reconnected := make(chan bool, 1)
+	dh.reconnectChannels[playerID] = reconnected
+	timeout := dh.timeout
 	dh.mu.Unlock()
 
-	dh.logger.Warnf("⚠️  Player %s disconnected. Starting %v timeout...", playerID, DisconnectTimeout)
+	dh.game.playerLog(playerID).Warnf("⚠️  Player %s disconnected. Starting %v timeout...", playerID, timeout)
 
 	// Start timeout timer
-	timer := time.NewTimer(DisconnectTimeout)
+	timer := time.NewTimer(timeout)
 	dh.mu.Lock()
 	dh.disconnectTimers[playerID] = timer
 	dh.mu.Unlock()
@@ -58,10 +82,10 @@ func (dh *DisconnectHandler) HandleDisconnect(ctx context.Context, playerID stri
 	select {
 	case <-timer.C:
 		// Timeout reached - player abandoned game
-		dh.logger.Errorf("❌ Player %s abandoned game (timeout reached)", playerID)
+		dh.game.playerLog(playerID).Errorf("❌ Player %s abandoned game (timeout reached)", playerID)
 		return dh.handleAbandon(playerID)
 
-	case <-dh.reconnectChannels[playerID]:
+	case <-reconnected:
 		// Player reconnected in time
 		timer.Stop()
 		dh.mu.Lock()
@@ -69,7 +93,7 @@ func (dh *DisconnectHandler) HandleDisconnect(ctx context.Context, playerID stri
 		delete(dh.reconnectChannels, playerID)
 		dh.mu.Unlock()
 
-		dh.logger.Infof("✅ Player %s reconnected successfully", playerID)
+		dh.game.playerLog(playerID).Infof("✅ Player %s reconnected successfully", playerID)
 		return nil
 
 	case <-ctx.Done():
@@ -92,7 +116,7 @@ func (dh *DisconnectHandler) HandleReconnect(playerID string) error {
 	// Signal reconnection
 	select {
 	case ch <- true:
-		dh.logger.Infof("Signaled reconnection for player %s", playerID)
+		dh.game.playerLog(playerID).Infof("Signaled reconnection for player %s", playerID)
 	default:
 		// Channel already closed or full
 	}
@@ -108,72 +132,39 @@ func (dh *DisconnectHandler) handleAbandon(playerID string) error {
 	delete(dh.reconnectChannels, playerID)
 	dh.mu.Unlock()
 
-	// Mark player as abandoned
-	player := dh.game.GetPlayer(playerID)
-	if player == nil {
+	if dh.game.GetPlayer(playerID) == nil {
 		return fmt.Errorf("player %s not found", playerID)
 	}
-	player.Status = PlayerAbandoned
 
-	dh.logger.Warnf("💀 Player %s abandoned. Aborting game and applying penalty...", playerID)
+	dh.game.playerLog(playerID).Warnf("💀 Player %s abandoned. Aborting game and applying penalty...", playerID)
 
 	// Abort game and distribute penalty
 	return dh.abortGameWithPenalty(playerID)
 }
 
-// abortGameWithPenalty aborts the game and penalizes the abandoned player
+// abortGameWithPenalty aborts the game and penalizes the abandoned player:
+// what is left of their session's chips goes to the players still seated
 func (dh *DisconnectHandler) abortGameWithPenalty(abandonedPlayerID string) error {
-	dh.logger.Warnf("🚫 Aborting game. Applying penalty to %s", abandonedPlayerID)
-
-	abandonedPlayer := dh.game.GetPlayer(abandonedPlayerID)
-	if abandonedPlayer == nil {
-		return fmt.Errorf("player %s not found", abandonedPlayerID)
-	}
+	dh.game.playerLog(abandonedPlayerID).Warnf("🚫 Aborting game. Applying penalty to %s", abandonedPlayerID)
 
-	// Get remaining active players
-	remainingPlayers := make([]*Player, 0)
-	for _, p := range dh.game.Players {
-		if p.ID != abandonedPlayerID && p.Status == PlayerActive {
-			remainingPlayers = append(remainingPlayers, p)
-		}
+	account, remaining, shares, stacks, err := dh.game.forfeitSession(abandonedPlayerID)
+	if err != nil {
+		return err
 	}
 
-	if len(remainingPlayers) == 0 {
-		return fmt.Errorf("no remaining players to distribute penalty")
+	dh.logger().Infof("💰 Distributing %d chips penalty from %s to %d remaining players",
+		account.Stack, abandonedPlayerID, len(remaining))
+	for _, addr := range remaining {
+		dh.logger().Infof("  → Player %s: +%d (penalty) = %d total", addr, shares[addr], stacks[addr])
 	}
-
-	// Calculate penalty distribution (split abandoned player's buy-in)
-	penaltyPerPlayer := abandonedPlayer.BuyIn / len(remainingPlayers)
-	remainder := abandonedPlayer.BuyIn % len(remainingPlayers)
-
-	dh.logger.Infof("💰 Distributing %d chips penalty from %s to %d remaining players",
-		abandonedPlayer.BuyIn, abandonedPlayerID, len(remainingPlayers))
-
-	// Each remaining player gets their buy-in back + share of penalty
-	for i, p := range remainingPlayers {
-		// Refund original buy-in + penalty share
-		p.Stack = p.BuyIn + penaltyPerPlayer
-		if i == 0 {
-			p.Stack += remainder // Give remainder to first player
-		}
-		dh.logger.Infof("  → Player %s: %d chips (buy-in) + %d (penalty) = %d total",
-			p.ID, p.BuyIn, penaltyPerPlayer, p.Stack)
-	}
-
-	// Abandoned player gets nothing (loses entire buy-in)
-	abandonedPlayer.Stack = 0
-	dh.logger.Errorf("  → Player %s: 0 chips (PENALTY - lost %d)", abandonedPlayerID, abandonedPlayer.BuyIn)
-
-	// Mark game as aborted
-	dh.game.Status = GameAborted
+	dh.logger().Errorf("  → Player %s: 0 chips (PENALTY - lost %d of %d invested)", abandonedPlayerID, account.Stack, account.Invested)
 
 	// Submit to blockchain
-	if dh.game.BlockchainClient != nil {
-		dh.logger.Info("📝 Submitting penalty to blockchain...")
-		return dh.game.EndGameWithPenalty(abandonedPlayerID, remainingPlayers)
+	if err := dh.game.EndGameWithPenalty(abandonedPlayerID, remaining); err != nil {
+		return err
 	}
 
-	dh.logger.Info("✅ Game aborted successfully. Penalty applied.")
+	dh.logger().Info("✅ Game aborted successfully. Penalty applied.")
 	return nil
 }
 
@@ -190,5 +181,6 @@ func (dh *DisconnectHandler) GetStatus() map[string]interface{} {
 	return map[string]interface{}{
 		"active_disconnect_timers": activeTimers,
 		"num_timers":               len(activeTimers),
+		"timeout":                  dh.timeout.String(),
 	}
 }
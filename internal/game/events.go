@@ -0,0 +1,257 @@
+package game
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/deck"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+)
+
+// SetEventFunc sets where client-facing table events are published.
+// Events go to players' WebSockets only, never to peers.
+func (g *Game) SetEventFunc(fn BroadcastFunc) {
+	g.do("set event func", func() {
+		g.eventFunc = fn
+	})
+}
+
+// publishEvent wraps data in a protocol.Event and sends it to clients (all when no targets)
+func (g *Game) publishEvent(eventType protocol.EventType, data interface{}, targets ...string) {
+	if g.eventFunc == nil {
+		return
+	}
+	defer g.timeStage(StageBroadcast, time.Now())
+
+	event, err := protocol.NewEvent(eventType, data)
+	if err != nil {
+		g.log().Errorf("Failed to build %s event: %v", eventType, err)
+		return
+	}
+	event.HandUID = g.handUID
+	event.Seq = g.handActionSeq
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		g.log().Errorf("Failed to encode %s event: %v", eventType, err)
+		return
+	}
+
+	g.eventFunc(payload, targets...)
+}
+
+// PublicState returns the table state anyone may see (no hole cards), as of
+// the latest view
+func (g *Game) PublicState() protocol.GameStateUpdateEvent {
+	return g.View().Public
+}
+
+func (g *Game) publicState() protocol.GameStateUpdateEvent {
+	return protocol.GameStateUpdateEvent{
+		Status:         g.status().String(),
+		Pot:            g.currentPot,
+		HighestBet:     g.highestBet,
+		CurrentTurn:    g.currentTurnPlayer(),
+		CommunityCards: toCardData(g.communityCards),
+		Players:        g.publicPlayerData(),
+		Version:        g.stateVersion,
+	}
+}
+
+// publishStateUpdate sends the public table state to every client, with a
+// diff against the previous update so clients can animate chip movements.
+// Each update gets the next version; clients that asked for deltas are sent
+// only what changed since the version they hold (see server/state_delta.go).
+func (g *Game) publishStateUpdate() {
+	g.stateVersion++
+	state := g.publicState()
+	if g.lastPublished != nil {
+		state.Diff = g.stateDiff(*g.lastPublished, state)
+	}
+
+	published := state
+	published.Diff = nil
+	g.lastPublished = &published
+	g.potAwards = nil
+	g.buyIns = make(map[string]int)
+
+	g.publishEvent(protocol.EventGameStateUpdate, state)
+
+	// Every state change is published, so this is where it is persisted too
+	g.persistState()
+}
+
+// publishTurnChange tells clients whose turn it is and what they may do,
+// and starts the player's action timer
+func (g *Game) publishTurnChange() {
+	if !g.isBettingRound() {
+		g.stopTurnClock()
+		return
+	}
+
+	addr := g.currentTurnPlayer()
+	if addr == "" {
+		g.stopTurnClock()
+		return
+	}
+
+	g.publishTurnEvent(addr, g.startTurnClock(addr))
+}
+
+// publishTurnEvent sends the turn change for addr with the time left on
+// their clock, which is nil without an action timer
+func (g *Game) publishTurnEvent(addr string, clock *turnClock) {
+	validActions := g.getValidActions(addr)
+	actions := make([]string, len(validActions))
+	for i, action := range validActions {
+		actions[i] = action.String()
+	}
+
+	event := protocol.TurnChangeEvent{
+		PlayerID:     addr,
+		RotationID:   g.currentPlayerTurn,
+		ValidActions: actions,
+		TimeBank:     int(g.timeBankLeft(addr) / time.Second),
+	}
+	if clock != nil {
+		event.TimeRemaining = clock.remaining()
+		event.UsingTimeBank = clock.usingBank
+	}
+	g.publishEvent(protocol.EventTurnChange, event)
+}
+
+// publishHandResult announces showdown hands (if any) and each winner's winnings
+func (g *Game) publishHandResult(winnings map[string]int, pot int, hands []PlayerHand) {
+	if len(hands) > 0 {
+		showdown := protocol.ShowdownEvent{Results: showdownResults(hands)}
+		if len(g.secondRunHands) > 0 {
+			showdown.Runs = []protocol.ShowdownRunResult{
+				{Board: toCardData(g.communityCards), Results: showdown.Results},
+				{Board: toCardData(g.secondBoard), Results: showdownResults(g.secondRunHands)},
+			}
+		}
+		g.publishEvent(protocol.EventShowdown, showdown)
+	}
+
+	handNames := make(map[string]string, len(hands))
+	lowNames := make(map[string]string, len(hands))
+	for _, ph := range hands {
+		handNames[ph.Addr] = ph.HandName
+		if ph.HasLow {
+			lowNames[ph.Addr] = ph.Low.String()
+		}
+	}
+
+	winners := make([]protocol.WinnerData, 0)
+	for addr, won := range winnings {
+		winners = append(winners, protocol.WinnerData{
+			PlayerID: addr,
+			Amount:   won,
+			HandName: handNames[addr],
+			NewStack: g.playerStates[addr].Stack,
+			LowHand:  lowNames[addr],
+			LowWon:   g.lowWinnings[addr],
+		})
+	}
+
+	g.publishEvent(protocol.EventWinner, protocol.WinnerEvent{
+		Winners: winners,
+		Pot:     pot,
+	})
+}
+
+func showdownResults(hands []PlayerHand) []protocol.ShowdownPlayerResult {
+	results := make([]protocol.ShowdownPlayerResult, len(hands))
+	for i, ph := range hands {
+		results[i] = protocol.ShowdownPlayerResult{
+			PlayerID: ph.Addr,
+			Hand:     toCardData(ph.Hand),
+			HandRank: ph.HandName,
+			Rank:     ph.Rank,
+		}
+		if ph.HasLow {
+			results[i].LowHand = ph.Low.String()
+		}
+	}
+	return results
+}
+
+// snapshotStacks records every player's stack before pots are awarded
+func (g *Game) snapshotStacks() map[string]int {
+	stacks := make(map[string]int, len(g.playerStates))
+	for addr, state := range g.playerStates {
+		stacks[addr] = state.Stack
+	}
+	return stacks
+}
+
+// handWinnings returns what each player gained since snapshotStacks
+func (g *Game) handWinnings(stacksBefore map[string]int) map[string]int {
+	winnings := make(map[string]int)
+	for addr, before := range stacksBefore {
+		if won := g.playerStates[addr].Stack - before; won > 0 {
+			winnings[addr] = won
+		}
+	}
+	return winnings
+}
+
+func (g *Game) currentTurnPlayer() string {
+	if !g.isBettingRound() {
+		return ""
+	}
+	return g.rotationMap[g.currentPlayerTurn]
+}
+
+func (g *Game) isBettingRound() bool {
+	switch g.currentStatus {
+	case GameStatusPreFlop, GameStatusFlop, GameStatusTurn, GameStatusRiver:
+		return true
+	default:
+		return false
+	}
+}
+
+// publicPlayerData returns every seated player's public state in rotation order
+func (g *Game) publicPlayerData() []protocol.PlayerData {
+	players := make([]protocol.PlayerData, 0, len(g.playerStates))
+	for i := 0; i < g.nextRotationID; i++ {
+		addr, ok := g.rotationMap[i]
+		if !ok {
+			continue
+		}
+		state, ok := g.playerStates[addr]
+		if !ok {
+			continue
+		}
+
+		players = append(players, protocol.PlayerData{
+			PlayerID:      addr,
+			Seat:          state.Seat,
+			Stack:         state.Stack,
+			CurrentBet:    state.CurrentRoundBet,
+			IsActive:      state.IsActive,
+			IsFolded:      state.IsFolded,
+			IsAllIn:       state.IsAllIn,
+			IsDealer:      state.RotationID == g.currentDealerID,
+			IsCurrentTurn: g.isBettingRound() && state.RotationID == g.currentPlayerTurn,
+		})
+	}
+	return players
+}
+
+func toCardData(cards []deck.Card) []protocol.CardData {
+	data := make([]protocol.CardData, len(cards))
+	for i, card := range cards {
+		data[i] = protocol.CardData{
+			Suit:    card.Suit.String(),
+			Value:   card.Value,
+			Display: card.String(),
+		}
+	}
+	return data
+}
+
+func formatEventTime(t time.Time) string {
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,186 @@
+package game
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/RedPaladin7/peerpoker/internal/crypto"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+)
+
+// A player who folds still holds a layer of every card left in the deck.
+// Rather than revealing their keys mid-hand, which would show everyone the
+// cards they folded, they hand over their layer of the board cards still to
+// come. The keys follow once the hand is over, and each player checks them
+// against the shares.
+
+// foldShares are a folded player's partial decryptions for one hand, with
+// the encrypted cards they were taken from
+type foldShares struct {
+	epoch  int
+	cards  map[int][]byte // deck index -> encrypted card
+	shares map[int][]byte // deck index -> card with the player's layer off
+	keys   *crypto.CardKeys
+}
+
+// liveBoardIndices are the deck indices of every board card still to be
+// dealt, a second run included
+func (g *Game) liveBoardIndices() []int {
+	start := g.boardStart() + len(g.communityCards)
+	end := g.boardStart() + 5 + 5
+	if end > len(g.currentDeck) {
+		end = len(g.currentDeck)
+	}
+
+	var indices []int
+	for i := start; i < end; i++ {
+		indices = append(indices, i)
+	}
+	return indices
+}
+
+// sendFoldShares takes our layer off each live board card and sends the
+// results to the other players
+func (g *Game) sendFoldShares(clientID string) {
+	if g.deckKeys == nil || g.sim != nil {
+		return
+	}
+
+	indices := g.liveBoardIndices()
+	decrypted := make([][]byte, len(indices))
+	for i, idx := range indices {
+		decrypted[i] = g.deckKeys.Decrypt(g.currentDeck[idx])
+	}
+
+	g.audit("fold_shares_sent", g.listenAddr, map[string]interface{}{
+		"player":       clientID,
+		"to":           g.getOtherPlayers(),
+		"key_epoch":    g.keyEpoch,
+		"card_indices": indices,
+	})
+	g.sendToPlayers(protocol.TypeFoldShares, protocol.FoldSharesPayload{
+		KeyEpoch:      g.keyEpoch,
+		CardIndices:   indices,
+		DecryptedData: decrypted,
+	}, g.getOtherPlayers()...)
+}
+
+// foldShareFor is where decrypting a board card starts: the encrypted card,
+// or a folded player's share of it when we hold no key of theirs, along with
+// that player. A share only takes off one layer of the card as dealt, so it
+// is no use when a second player's layer is missing too.
+func (g *Game) foldShareFor(cardIdx int) ([]byte, string) {
+	var shared string
+	for addr, shares := range g.foldShares {
+		if _, ok := g.revealedKeys[addr]; ok || shares.shares[cardIdx] == nil {
+			continue
+		}
+		if shared != "" {
+			g.log().Warnf("Board card %d is missing the layers of %s and %s", cardIdx, shared, addr)
+			return nil, ""
+		}
+		shared = addr
+	}
+	if shared == "" {
+		return g.currentDeck[cardIdx], ""
+	}
+	return g.foldShares[shared].shares[cardIdx], shared
+}
+
+// handleMessageFoldShares takes a folded player's shares for the hand in
+// progress. They must cover exactly the live board cards: anything else
+// would be a request for a hole card's layer, or a short answer.
+func (g *Game) handleMessageFoldShares(from string, payload protocol.FoldSharesPayload) error {
+	return g.exec("fold shares message from "+from, func() error {
+		if g.keyEpoch == 0 {
+			// The fold ended the hand, and no more cards are coming
+			return nil
+		}
+		if payload.KeyEpoch != g.keyEpoch {
+			return fmt.Errorf("shares from %s are for hand %d, not the hand in progress (%d)", from, payload.KeyEpoch, g.keyEpoch)
+		}
+		state, ok := g.playerStates[from]
+		if !ok || !state.IsFolded {
+			return fmt.Errorf("shares sent by %s, who has not folded", from)
+		}
+		if _, ok := g.foldShares[from]; ok {
+			return fmt.Errorf("duplicate shares from %s", from)
+		}
+		if !sameIndices(payload.CardIndices, g.liveBoardIndices()) {
+			return fmt.Errorf("shares from %s do not cover the live board cards", from)
+		}
+		if len(payload.DecryptedData) != len(payload.CardIndices) {
+			return fmt.Errorf("shares from %s have %d cards for %d indices", from, len(payload.DecryptedData), len(payload.CardIndices))
+		}
+
+		shares := &foldShares{
+			epoch:  payload.KeyEpoch,
+			cards:  make(map[int][]byte, len(payload.CardIndices)),
+			shares: make(map[int][]byte, len(payload.CardIndices)),
+		}
+		for i, idx := range payload.CardIndices {
+			shares.cards[idx] = g.currentDeck[idx]
+			shares.shares[idx] = payload.DecryptedData[i]
+		}
+		g.foldShares[from] = shares
+		g.log().Debugf("Took %d shares from %s for hand %d", len(payload.CardIndices), from, payload.KeyEpoch)
+		return nil
+	})
+}
+
+// closeFoldShares ends the hand's share exchange: our keys go out if we
+// folded, and each folded player's shares wait for theirs. Shares still
+// waiting from the hand before are given up on.
+func (g *Game) closeFoldShares() {
+	if state := g.playerStates[g.listenAddr]; state != nil && state.IsFolded && g.deckKeys != nil && g.sim == nil {
+		g.audit("keys_revealed", g.listenAddr, map[string]interface{}{
+			"reason":    "fold",
+			"to":        g.getOtherPlayers(),
+			"key_epoch": g.keyEpoch,
+		})
+		g.sendToPlayers(protocol.TypeRevealKeys, g.revealKeysPayload(), g.getOtherPlayers()...)
+	}
+
+	for addr, shares := range g.unverifiedShares {
+		g.log().Warnf("%s never revealed their keys for hand %d", addr, shares.epoch)
+		g.audit("fold_shares_unverified", addr, map[string]interface{}{
+			"key_epoch": shares.epoch,
+		})
+	}
+
+	g.unverifiedShares = make(map[string]*foldShares)
+	for addr, shares := range g.foldShares {
+		if shares.keys != nil {
+			g.verifyFoldShares(addr, shares)
+			continue
+		}
+		g.unverifiedShares[addr] = shares
+	}
+	g.foldShares = make(map[string]*foldShares)
+}
+
+// verifyFoldShares checks a folded player's shares against the keys they
+// revealed after the hand, then zeroes the keys
+func (g *Game) verifyFoldShares(addr string, shares *foldShares) {
+	defer shares.keys.Zero()
+
+	var mismatched []int
+	for idx, card := range shares.cards {
+		if !bytes.Equal(shares.keys.Decrypt(card), shares.shares[idx]) {
+			mismatched = append(mismatched, idx)
+		}
+	}
+
+	if len(mismatched) > 0 {
+		g.log().Warnf("%s's shares for hand %d do not match their keys at %v", addr, shares.epoch, mismatched)
+		g.audit("fold_shares_mismatch", addr, map[string]interface{}{
+			"key_epoch":    shares.epoch,
+			"card_indices": mismatched,
+		})
+		return
+	}
+	g.audit("fold_shares_verified", addr, map[string]interface{}{
+		"key_epoch": shares.epoch,
+		"cards":     len(shares.cards),
+	})
+}
@@ -5,12 +5,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
-	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/RedPaladin7/peerpoker/internal/blockchain"
 	"github.com/RedPaladin7/peerpoker/internal/crypto"
 	"github.com/RedPaladin7/peerpoker/internal/deck"
+	"github.com/RedPaladin7/peerpoker/internal/detection"
+	"github.com/RedPaladin7/peerpoker/internal/logging"
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+	"github.com/RedPaladin7/peerpoker/internal/profile"
 	"github.com/RedPaladin7/peerpoker/internal/protocol"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/sirupsen/logrus"
@@ -35,23 +40,222 @@ type Game struct {
 	highestBet         int
 	lastRaiserID       int
 	lastRaiseAmount    int
-
-	// Deck and cards
-	deckKeys         *crypto.CardKeys
-	foldedPlayerKeys map[string]*crypto.CardKeys
-	revealedKeys     map[string]*crypto.CardKeys
-	currentDeck      [][]byte
-	myHand           []deck.Card
-	communityCards   []deck.Card
-
-	// Side pots
-	sidePots []SidePot
+	fullRaiseTo        int // the bet the last full raise made (see betting.go)
+
+	// Deck and cards. deckKeys are this node's keys for the hand in
+	// progress, made for hand keyEpoch (see hand_keys.go)
+	deckKeys       *crypto.CardKeys
+	keyEpoch       int
+	revealedKeys   map[string]*crypto.CardKeys
+	currentDeck    [][]byte
+	myHand         []deck.Card
+	communityCards []deck.Card
+
+	// Folded players' partial decryptions this hand, and last hand's
+	// waiting on the keys that check them (see fold_shares.go)
+	foldShares       map[string]*foldShares
+	unverifiedShares map[string]*foldShares
+
+	// Publish each hand's full shuffle audit when it ends (see shuffle_audit.go)
+	publishShuffleAudit bool
+
+	// Chips in the pot this hand, by player and street (see pot.go), and
+	// who still has to show or muck at the showdown (see showdown_order.go)
+	pot      *PotLedger
+	showdown *showdownOrder
 
 	// Blockchain integration
 	blockchain        *blockchain.BlockchainClient
 	blockchainGameID  [32]byte
 	blockchainEnabled bool
 
+	// Signed protocol messages (nil when signing is disabled)
+	msgSigner   *protocol.MessageSigner
+	msgVerifier *protocol.MessageVerifier
+
+	// Players' profiles by signing address (nil when profiles are off)
+	profiles *profile.Store
+
+	// Bot detection (nil on tables where bots are allowed)
+	botDetector   *detection.BotDetector
+	turnStartedAt time.Time
+
+	// Negotiated protocol sessions keyed by peer
+	peerSessions   map[string]*protocol.NegotiatedSession
+	binaryEncoding bool
+
+	// Relaying between peers (see relay.go): whether we offer it, and the
+	// peers we reach through a relay. Routes have their own lock since
+	// every send consults them.
+	relay       bool
+	relayRoutes map[string]string
+	relayMu     sync.Mutex
+
+	// Client-facing event stream (see events.go), the state the last
+	// update diffed against (see state_diff.go) and that update's version
+	eventFunc     BroadcastFunc
+	lastPublished *protocol.GameStateUpdateEvent
+	stateVersion  uint64
+	potAwards     []potAward
+	buyIns        map[string]int
+
+	// Table-start seat draw and hand history
+	seatDraw      *SeatDraw
+	pendingDraw   *seatDrawRound
+	buttonPending bool
+	handCount     int
+	handHistory   []*HandHistory
+	nextHandChain []ChainTx
+
+	// Unanimous abort vote
+	pendingAbort *abortVote
+
+	// Escrow payouts that failed on-chain (see settlement.go)
+	failedSettlements []*FailedSettlement
+	settlementCount   int
+
+	// Payouts waiting on player attestations, and attestations received
+	// before this node settled the hand, by hand UID (see attestation.go)
+	pendingSettlements map[string]*pendingSettlement
+	earlyAttestations  map[string]map[string]receivedAttestation
+
+	// Hands whose net result waits to be settled together (see settlement_batch.go)
+	settlementBatch *SettlementBatch
+
+	// Off-chain signed session stacks (see channel.go)
+	channel *stateChannel
+
+	// Why the chain circuit breaker is tripped, empty while new hands may
+	// be escrowed on-chain (see chain_health.go)
+	chainBreaker string
+
+	// Numbered seats and reservations (see seats.go)
+	maxSeats         int
+	seatHold         time.Duration
+	seatReservations map[int]*seatReservation
+	waitlist         []WaitlistEntry
+
+	// Rebuy limits and the FundsLocked transactions already credited (see rebuy.go)
+	minBuyIn       int
+	maxBuyIn       int
+	rebuyNeedsLock bool
+	rebuyTxs       map[common.Hash]bool
+
+	// Per-table betting options (see table_config.go)
+	tableConfig TableConfig
+	straddlers  map[string]bool
+
+	// Seats holding the button and blinds last hand, so they move on even
+	// when players sit out, and sit out changes waiting for the next hand
+	// (see blinds.go)
+	buttonSeat     int
+	smallBlindSeat int
+	bigBlindSeat   int
+	deadButton     bool
+	sitOutRequests map[string]protocol.SitOutPayload
+
+	// Run-it-twice offer for an all-in runout, and the second run's board
+	// and hands (see run_it_twice.go)
+	runOffer       *runItTwiceOffer
+	secondBoard    []deck.Card
+	secondRunHands []PlayerHand
+
+	// Undealt board being decrypted after a hand ended early (see rabbit_hunt.go)
+	rabbit *rabbitHunt
+
+	// The action timer for the player to act, each player's time bank left
+	// this session, and who ran out of time on their last turn (see
+	// time_bank.go)
+	turnClock *turnClock
+	timeBanks map[string]time.Duration
+	timedOut  map[string]bool
+
+	// Chips won with the low half of hi-lo pots this hand
+	lowWinnings map[string]int
+
+	// Deterministic plaintext dealing for tests and load tools (see sim.go)
+	sim *simulation
+
+	// Hand evaluator engine used at showdown
+	evaluator deck.HandEvaluator
+
+	// Where panic snapshots go (see recovery.go), and rolling state
+	// snapshots for restore after a restart (see persist.go)
+	incidentDir string
+	snapshots   *persistence.SnapshotManager
+
+	// Write-ahead log of hand events between snapshots, and replay state
+	// used while recovering from it (see wal.go)
+	wal              *persistence.WAL
+	walSnapshotEvery int
+	walSinceSnapshot int
+	walCovered       bool
+	replaying        bool
+	replayDeal       *dealSecrets
+
+	// Record store shared with other nodes and tooling (see store.go)
+	store        persistence.Store
+	storeTableID string
+
+	// Unique ID of the hand in progress or just finished, and the sequence
+	// number of its latest action; both tag events, records and snapshots
+	handUID       string
+	handActionSeq int
+
+	// Table logger, and the same tagged with handUID (see logging.go)
+	tableLog atomic.Value
+	handLog  atomic.Value
+
+	// The latest *GameView, replaced after every command (see view.go)
+	view atomic.Value
+
+	// Stage timings and the latency budget (see latency.go)
+	latency *latencyStats
+
+	chat *chatRoom
+
+	// No new hands are dealt once the node is shutting down (see
+	// shutdown.go), or while the table is paused, which also holds the
+	// hand in progress; pausedFor is how long it has been held in all
+	// (see pause.go)
+	pause     *tablePause
+	pauseVote *pauseVote
+	pausedFor time.Duration
+	closing   bool
+
+	// The player who opened the table, and the open vote to use the host's
+	// powers while they are away (see host.go)
+	host     string
+	hostVote *hostVote
+
+	// Chips in play when the hand began, and why the table is frozen after
+	// that count or the pots stopped adding up (see invariants.go)
+	handChips int
+	frozen    string
+
+	// Rake taken from the current hand and over the table's life (see rake.go)
+	handRake      int
+	rakeCollected int
+
+	// Audit trail of privileged operations, kept in memory and optionally
+	// in a hash-chained log on disk (see audit.go)
+	auditLog     []AuditEntry
+	auditStore   *persistence.AuditLog
+	auditTableID string
+
+	// Actions applied this hand under an idempotency key, by player and key
+	// (see idempotency.go)
+	appliedActions map[string]appliedAction
+
+	// The table's lifetime, ended by Close, and since when it has had no
+	// players (see lifecycle.go)
+	ctx        context.Context
+	cancel     context.CancelFunc
+	emptySince time.Time
+
+	// Every change to the table runs on loop() (see actor.go)
+	commands chan command
+
 	// NEW: Disconnect handling
 	DisconnectHandler *DisconnectHandler
 }
@@ -65,45 +269,67 @@ type SidePot struct {
 }
 
 func NewGame(addr string, broadcast BroadcastFunc, bc *blockchain.BlockchainClient) *Game {
-	keys, _ := crypto.GenerateCardKeys()
 	g := &Game{
 		listenAddr:       addr,
 		broadcastFunc:    broadcast,
 		playerStates:     make(map[string]*PlayerState),
 		rotationMap:      make(map[int]string),
 		currentStatus:    GameStatusWaiting,
-		deckKeys:         keys,
-		foldedPlayerKeys: make(map[string]*crypto.CardKeys),
+		foldShares:       make(map[string]*foldShares),
+		unverifiedShares: make(map[string]*foldShares),
 		revealedKeys:     make(map[string]*crypto.CardKeys),
 		myHand:           make([]deck.Card, 0, 2),
 		communityCards:   make([]deck.Card, 0, 5),
-		sidePots:         []SidePot{},
+		pot:              NewPotLedger(),
 		blockchain:       bc,
 		blockchainEnabled: bc != nil,
+		peerSessions:     make(map[string]*protocol.NegotiatedSession),
+		relayRoutes:      make(map[string]string),
+		chat:             newChatRoom(),
+		maxSeats:         protocol.DefaultMaxPlayers,
+		seatHold:         DefaultSeatHold,
+		seatReservations: make(map[int]*seatReservation),
+		minBuyIn:         DefaultMinBuyIn,
+		maxBuyIn:         DefaultMaxBuyIn,
+		rebuyTxs:         make(map[common.Hash]bool),
+		buyIns:           make(map[string]int),
+		straddlers:       make(map[string]bool),
+		sitOutRequests:   make(map[string]protocol.SitOutPayload),
+		appliedActions:   make(map[string]appliedAction),
+		timeBanks:        make(map[string]time.Duration),
+		timedOut:         make(map[string]bool),
+
+		pendingSettlements: make(map[string]*pendingSettlement),
+		earlyAttestations:  make(map[string]map[string]receivedAttestation),
+		lowWinnings:      make(map[string]int),
+		evaluator:        deck.DefaultEvaluator(),
+		latency:          newLatencyStats(),
 	}
 
+	g.SetLogger(logging.ForTable(addr))
+
 	// NEW: Initialize disconnect handler
 	g.DisconnectHandler = NewDisconnectHandler(g)
 
+	g.ctx, g.cancel = context.WithCancel(context.Background())
+	g.emptySince = time.Now()
+	g.commands = make(chan command)
+	g.refreshView()
+
 	go g.loop()
 	return g
 }
 
-func (g *Game) loop() {
-	// Background processing if needed
-	// Can be used for timeouts, periodic state sync, etc.
-}
-
 // GetStatus returns the current game status
 func (g *Game) GetStatus() GameStatus {
 	g.lock.RLock()
 	defer g.lock.RUnlock()
-	return g.currentStatus
+	return g.status()
 }
 
 func (g *Game) setStatus(status GameStatus) {
 	g.currentStatus = status
-	logrus.Infof("Game status changed to: %s", status.String())
+	g.log().Infof("Game status changed to: %s", status.String())
 }
 
 // PlayerCount returns the number of players
@@ -126,11 +352,12 @@ func (g *Game) ActivePlayerCount() int {
 	return count
 }
 
-// GetAllPlayers returns all player states
+// GetAllPlayers returns all player states, as of the latest view
 func (g *Game) GetAllPlayers() []PlayerStateResponse {
-	g.lock.RLock()
-	defer g.lock.RUnlock()
+	return g.View().Players
+}
 
+func (g *Game) allPlayers() []PlayerStateResponse {
 	players := make([]PlayerStateResponse, 0)
 	for i := 0; i < g.nextRotationID; i++ {
 		addr, ok := g.rotationMap[i]
@@ -146,6 +373,7 @@ func (g *Game) GetAllPlayers() []PlayerStateResponse {
 		players = append(players, PlayerStateResponse{
 			PlayerID:      state.ListenAddr,
 			RotationID:    state.RotationID,
+			Seat:          state.Seat,
 			Stack:         state.Stack,
 			CurrentBet:    state.CurrentRoundBet,
 			IsActive:      state.IsActive,
@@ -154,20 +382,23 @@ func (g *Game) GetAllPlayers() []PlayerStateResponse {
 			IsReady:       state.IsReady,
 			IsDealer:      state.RotationID == g.currentDealerID,
 			IsCurrentTurn: state.RotationID == g.currentPlayerTurn,
+			Profile:       g.profileOf(state.ListenAddr),
 		})
 	}
 	return players
 }
 
-// GetTableState returns the table state for a specific client
+// GetTableState returns the table state for a specific client, as of the
+// latest view
 func (g *Game) GetTableState(clientID string) TableStateResponse {
-	g.lock.RLock()
-	defer g.lock.RUnlock()
+	return g.View().TableState(clientID)
+}
 
+func (g *Game) tableState(clientID string) TableStateResponse {
 	myState, exists := g.playerStates[clientID]
 	if !exists {
 		return TableStateResponse{
-			Status: g.currentStatus.String(),
+			Status: g.status().String(),
 		}
 	}
 
@@ -176,6 +407,11 @@ func (g *Game) GetTableState(clientID string) TableStateResponse {
 	for i, action := range validActions {
 		actionStrings[i] = action.String()
 	}
+	isMyTurn := myState.RotationID == g.currentPlayerTurn
+	if g.showdown != nil {
+		actionStrings = append([]string{}, g.showdownActions(clientID)...)
+		isMyTurn = len(actionStrings) > 0
+	}
 
 	myHandResp := make([]CardResponse, 0)
 	if len(g.myHand) > 0 {
@@ -198,20 +434,19 @@ func (g *Game) GetTableState(clientID string) TableStateResponse {
 		}
 	}
 
-	minRaise := g.highestBet + g.lastRaiseAmount
-	if g.highestBet == 0 {
-		minRaise = BigBlind
-	}
-
 	return TableStateResponse{
-		Status:          g.currentStatus.String(),
+		Status:          g.status().String(),
 		MyHand:          myHandResp,
 		CommunityCards:  communityCardResp,
 		Pot:             g.currentPot,
 		HighestBet:      g.highestBet,
-		MinRaise:        minRaise,
+		MinRaise:        g.minRaiseTo(),
+		AmountToCall:    g.amountToCall(myState),
+		PotOdds:         g.potOdds(myState),
+		EffectiveStack:  g.effectiveStack(clientID),
+		MaxBet:          g.maxBetTo(myState),
 		ValidActions:    actionStrings,
-		IsMyTurn:        myState.RotationID == g.currentPlayerTurn,
+		IsMyTurn:        isMyTurn,
 		MyStack:         myState.Stack,
 		CurrentTurnID:   g.currentPlayerTurn,
 		MyPlayerID:      myState.RotationID,
@@ -221,9 +456,92 @@ func (g *Game) GetTableState(clientID string) TableStateResponse {
 	}
 }
 
+// EnableMessageSigning signs outbound messages and verifies the envelopes of inbound ones
+func (g *Game) EnableMessageSigning(signer protocol.Signer, verify protocol.VerifyFunc, requireSigned bool) {
+	g.do("enable message signing", func() {
+		g.msgSigner = protocol.NewMessageSigner(signer)
+		g.msgVerifier = protocol.NewMessageVerifier(verify, requireSigned)
+
+		g.log().WithFields(logrus.Fields{
+			"address":        signer.GetAddressHex(),
+			"require_signed": requireSigned,
+		}).Info("Protocol message signing enabled")
+	})
+}
+
+// EnableBotDetection starts scoring player decisions for automated play
+func (g *Game) EnableBotDetection(detector *detection.BotDetector) {
+	g.do("enable bot detection", func() {
+		g.botDetector = detector
+	})
+}
+
+// SetEvaluator swaps the hand evaluator engine used at showdown
+func (g *Game) SetEvaluator(evaluator deck.HandEvaluator) {
+	g.do("set evaluator", func() {
+		g.evaluator = evaluator
+	})
+}
+
+// Evaluator returns the hand evaluator engine in use
+func (g *Game) Evaluator() deck.HandEvaluator {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.evaluator
+}
+
+// HandCards returns copies of a player's hole cards and the board, and how
+// many opponents are still in the hand, so odds can be worked out off the lock
+func (g *Game) HandCards(playerID string) (hole, board []deck.Card, opponents int, ok bool) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if !g.inHand(playerID) || len(g.myHand) == 0 {
+		return nil, nil, 0, false
+	}
+	for addr, state := range g.playerStates {
+		if addr != playerID && g.inHand(addr) && !state.IsFolded {
+			opponents++
+		}
+	}
+	hole = append([]deck.Card{}, g.myHand...)
+	board = append([]deck.Card{}, g.communityCards...)
+	return hole, board, opponents, true
+}
+
+// BotScores returns bot-detection scores for every observed player
+func (g *Game) BotScores() []detection.BotScore {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if g.botDetector == nil {
+		return []detection.BotScore{}
+	}
+	return g.botDetector.Scores()
+}
+
 // HandleMessage processes incoming messages
 func (g *Game) HandleMessage(from string, msg *protocol.Message) error {
+	// Messages arrive off the loop; the verifier locks for itself, but is
+	// set on the loop, so it is read under the lock
+	g.lock.RLock()
+	verifier := g.msgVerifier
+	g.lock.RUnlock()
+
+	if verifier != nil {
+		if err := verifier.Verify(from, msg); err != nil {
+			g.log().Warnf("Rejected message from %s: %v", from, err)
+			return err
+		}
+	}
+
 	switch msg.Type {
+	case protocol.TypeHandshake:
+		var payload protocol.HandshakePayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageHandshake(from, payload)
 	case protocol.TypePlayerReady:
 		return g.handleMessageReady(from)
 	case protocol.TypePlayerAction:
@@ -232,30 +550,277 @@ func (g *Game) HandleMessage(from string, msg *protocol.Message) error {
 			return err
 		}
 		return g.handleMessagePlayerAction(from, payload)
+	case protocol.TypeSeatDrawCommit:
+		var payload protocol.SeatDrawCommitPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageSeatDrawCommit(from, payload)
+	case protocol.TypeSeatDrawReveal:
+		var payload protocol.SeatDrawRevealPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageSeatDrawReveal(from, payload)
+	case protocol.TypeRevealKeys:
+		var payload protocol.RevealKeysPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageRevealKeys(from, payload)
+	case protocol.TypeFoldShares:
+		var payload protocol.FoldSharesPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageFoldShares(from, payload)
+	case protocol.TypeAbortVote:
+		var payload protocol.AbortVotePayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageAbortVote(from, payload)
+	case protocol.TypeTablePause:
+		var payload protocol.TablePausePayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageTablePause(from, payload)
+	case protocol.TypePauseVote:
+		var payload protocol.PauseVotePayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessagePauseVote(from, payload)
+	case protocol.TypeHostAction:
+		var payload protocol.HostActionPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageHostAction(from, payload)
+	case protocol.TypeHostVote:
+		var payload protocol.HostVotePayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageHostVote(from, payload)
+	case protocol.TypeProfile:
+		var payload protocol.PlayerProfile
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageProfile(from, payload)
+	case protocol.TypeChat:
+		var payload protocol.ChatPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageChat(from, payload)
+	case protocol.TypeSeat:
+		var payload protocol.SeatPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageSeat(from, payload)
+	case protocol.TypeRebuy:
+		var payload protocol.RebuyPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageRebuy(from, payload)
+	case protocol.TypeStraddle:
+		var payload protocol.StraddlePayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageStraddle(from, payload)
+	case protocol.TypeRunItTwice:
+		var payload protocol.RunItTwicePayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageRunItTwice(from, payload)
+	case protocol.TypeSitOut:
+		var payload protocol.SitOutPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageSitOut(from, payload)
+	case protocol.TypeShowHand:
+		var payload protocol.ShowHandPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageShowdownChoice(from, payload.HandUID, true)
+	case protocol.TypeMuckHand:
+		var payload protocol.MuckHandPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageShowdownChoice(from, payload.HandUID, false)
+	case protocol.TypeResultAttestation:
+		var payload protocol.ResultAttestationPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageResultAttestation(from, payload)
+	case protocol.TypeChannelState:
+		var payload protocol.ChannelStatePayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageChannelState(from, payload)
+	case protocol.TypeRabbitHunt:
+		var payload protocol.RabbitHuntPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageRabbitHunt(from, payload)
 	case protocol.TypePeerList:
-		// Handle peer discovery
-		return nil
+		var payload protocol.PeerListPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessagePeerList(from, payload)
+	case protocol.TypeRelay:
+		var payload protocol.RelayPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return g.handleMessageRelay(from, payload)
 	case protocol.TypeGameState:
 		// Handle game state sync
 		return nil
 	default:
-		logrus.Warnf("Unhandled message type: %s from %s", msg.Type, from)
+		g.log().Warnf("Unhandled message type: %s from %s", msg.Type, from)
 	}
 	return nil
 }
 
+// LocalHandshake returns the handshake this node advertises to peers
+func (g *Game) LocalHandshake() protocol.HandshakePayload {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.localHandshake()
+}
+
+func (g *Game) localHandshake() protocol.HandshakePayload {
+	variant := g.tableConfig.Variant()
+	capabilities, _ := protocol.VariantCapability(variant)
+	capabilities |= protocol.CapRelay
+	if g.msgSigner != nil {
+		capabilities |= protocol.CapSignedMessages
+	}
+	if g.binaryEncoding {
+		capabilities |= protocol.CapBinaryEncoding
+	}
+
+	return protocol.HandshakePayload{
+		Version:      protocol.ProtocolVersion,
+		GameVariant:  variant,
+		ListenAddr:   g.listenAddr,
+		Capabilities: capabilities,
+		Relay:        g.relay,
+		Host:         g.host,
+		Profile:      g.profileOf(g.listenAddr),
+	}
+}
+
+// EnableBinaryEncoding advertises the protobuf wire encoding to peers
+func (g *Game) EnableBinaryEncoding() {
+	g.do("enable binary encoding", func() {
+		g.binaryEncoding = true
+	})
+}
+
+// PeerEncoding returns the wire encoding negotiated with a peer (JSON until negotiated)
+func (g *Game) PeerEncoding(peer string) protocol.Encoding {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	if session, ok := g.peerSessions[peer]; ok {
+		return session.Encoding()
+	}
+	return protocol.EncodingJSON
+}
+
+// SendHandshake opens protocol negotiation with a newly connected peer
+func (g *Game) SendHandshake(peer string) error {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.sendToPlayers(protocol.TypeHandshake, g.localHandshake(), peer)
+}
+
+// PeerSession returns the negotiated session with a peer, if any
+func (g *Game) PeerSession(peer string) (*protocol.NegotiatedSession, bool) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	session, ok := g.peerSessions[peer]
+	return session, ok
+}
+
+func (g *Game) handleMessageHandshake(from string, payload protocol.HandshakePayload) error {
+	return g.exec("handshake message from "+from, func() error {
+		requireSigned := g.msgVerifier != nil && g.msgVerifier.RequiresSigned()
+		local := g.localHandshake()
+
+		session, rejection := protocol.NegotiateHandshake(local, payload, requireSigned)
+		if rejection != nil {
+			g.log().WithFields(logrus.Fields{
+				"peer":    from,
+				"version": payload.Version,
+				"variant": payload.GameVariant,
+				"code":    rejection.Code,
+			}).Warnf("Rejected peer handshake: %s", rejection.Message)
+
+			g.sendToPlayers(protocol.TypeError, rejection, from)
+			return fmt.Errorf("%s: %s", rejection.Code, rejection.Message)
+		}
+
+		_, known := g.peerSessions[from]
+		g.peerSessions[from] = session
+		g.learnHost(from, payload.Host)
+		if payload.Profile != nil {
+			if err := g.keepProfile(from, *payload.Profile); err != nil {
+				g.log().WithField("peer", from).Warnf("Ignored handshake profile: %v", err)
+			}
+		}
+
+		g.log().WithFields(logrus.Fields{
+			"peer":         from,
+			"version":      payload.Version,
+			"capabilities": session.Capabilities.String(),
+		}).Info("Peer handshake negotiated")
+
+		// Answer first contact so the peer can run the same negotiation
+		if !known {
+			if err := g.sendToPlayers(protocol.TypeHandshake, local, from); err != nil {
+				return err
+			}
+			return g.announceRelayPeers(from)
+		}
+		return nil
+	})
+}
+
 func (g *Game) handleMessageReady(from string) error {
-	logrus.Infof("Player %s is ready", from)
+	g.log().Infof("Player %s is ready", from)
 	return g.SetPlayerReady(from)
 }
 
 func (g *Game) handleMessagePlayerAction(from string, payload protocol.PlayerActionPayload) error {
-	logrus.WithFields(logrus.Fields{
+	g.log().WithFields(logrus.Fields{
 		"from":   from,
 		"action": payload.Action,
 		"value":  payload.Value,
 	}).Info("Received player action")
-	return g.HandlePlayerAction(from, payload.Action, payload.Value)
+	replayed, err := g.HandlePlayerActionWithKey(from, payload.ActionID, payload.Action, payload.Value)
+	if replayed {
+		g.log().WithFields(logrus.Fields{
+			"from":      from,
+			"action_id": payload.ActionID,
+		}).Info("Ignored repeated player action")
+	}
+	return err
 }
 
 // Broadcast sends data to specified targets
@@ -267,20 +832,40 @@ func (g *Game) broadcast(data []byte, targets ...string) {
 
 // Send message to other players
 func (g *Game) sendToPlayers(msgType protocol.MessageType, payload interface{}, targets ...string) error {
-	msg, err := protocol.NewMessage(g.listenAddr, msgType, payload)
+	defer g.timeStage(StageBroadcast, time.Now())
+
+	data, err := g.encodeMessage(msgType, payload)
 	if err != nil {
 		return err
 	}
 
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return err
+	if len(targets) > 0 {
+		// Peers behind a relay get the message wrapped, through the relay
+		if targets = g.sendRelayed(data, targets); len(targets) == 0 {
+			return nil
+		}
 	}
 
 	g.broadcast(data, targets...)
 	return nil
 }
 
+// encodeMessage builds and signs a message from this node
+func (g *Game) encodeMessage(msgType protocol.MessageType, payload interface{}) ([]byte, error) {
+	msg, err := protocol.NewMessage(g.listenAddr, msgType, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.msgSigner != nil {
+		if err := g.msgSigner.Sign(msg); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(msg)
+}
+
 // Get other players (excluding self)
 func (g *Game) getOtherPlayers() []string {
 	others := make([]string, 0)
@@ -303,11 +888,11 @@ func (g *Game) getReadyPlayers() []string {
 	return ready
 }
 
-// Get ready and active players
+// Get ready and active players who are not sitting out
 func (g *Game) getReadyActivePlayers() []string {
 	ready := make([]string, 0)
 	for addr, state := range g.playerStates {
-		if state.IsReady && state.IsActive {
+		if state.IsReady && state.IsActive && !state.SittingOut {
 			ready = append(ready, addr)
 		}
 	}
@@ -363,27 +948,48 @@ func (g *Game) advanceDealer() {
 	}
 }
 
-// StartNewHand starts a new poker hand
-func (g *Game) StartNewHand() {
+// startNewHand starts a new poker hand. Like every mutation it runs on the
+// table's loop.
+func (g *Game) startNewHand() {
+	if g.pause != nil || g.closing || g.frozen != "" {
+		g.setStatus(GameStatusWaiting)
+		g.log().Info("Table is paused, closing or frozen, not starting a hand")
+		return
+	}
+
+	g.applySitOutRequests()
 	activeReadyPlayers := g.getReadyActivePlayers()
 	if len(activeReadyPlayers) < 2 {
 		g.setStatus(GameStatusWaiting)
-		logrus.Warn("Not enough players to start a hand")
+		g.log().Warn("Not enough players to start a hand")
+		return
+	}
+
+	// Seats and button are drawn once, before the first hand
+	if g.seatDraw == nil && g.sim != nil {
+		g.seatDraw = g.sim.seatDraw(activeReadyPlayers)
+		g.buttonPending = true
+	}
+	if g.seatDraw == nil {
+		g.beginSeatDraw(activeReadyPlayers)
 		return
 	}
 
 	// Blockchain: Create game on-chain
-	if g.blockchainEnabled && g.blockchainGameID == [32]byte{} {
+	if g.blockchainEnabled && g.blockchainGameID == [32]byte{} && g.chainBreaker != "" {
+		g.log().Warnf("Chain circuit breaker tripped (%s); hand will not be escrowed on-chain", g.chainBreaker)
+	} else if g.blockchainEnabled && g.blockchainGameID == [32]byte{} {
 		buyIn := big.NewInt(int64(1000)) // Default 1000 wei buy-in
 		smallBlind := big.NewInt(int64(SmallBlind))
 		bigBlind := big.NewInt(int64(BigBlind))
-		gameID, err := g.blockchain.CreateGame(buyIn, smallBlind, bigBlind, uint8(len(activeReadyPlayers)))
+		gameID, receipt, err := g.chain().CreateGame(buyIn, smallBlind, bigBlind, uint8(len(activeReadyPlayers)))
+		g.nextHandChain = append(g.nextHandChain, newChainTx(ChainTxCreate, receipt, err))
 		if err != nil {
-			logrus.Errorf("Failed to create game on blockchain: %v", err)
+			g.log().Errorf("Failed to create game on blockchain: %v", err)
 			// Continue without blockchain if it fails
 		} else {
 			g.blockchainGameID = gameID
-			logrus.WithField("game_id", fmt.Sprintf("0x%x", gameID)).Info("Blockchain game created")
+			g.log().WithField("game_id", fmt.Sprintf("0x%x", gameID)).Info("Blockchain game created")
 		}
 	}
 
@@ -394,17 +1000,17 @@ func (g *Game) StartNewHand() {
 			addr := common.HexToAddress(playerAddr)
 			verified, err := g.blockchain.VerifyBuyIn(g.blockchainGameID, addr)
 			if err != nil || !verified {
-				logrus.Warnf("Player %s buy-in not verified: %v", playerAddr, err)
+				g.log().Warnf("Player %s buy-in not verified: %v", playerAddr, err)
 				allVerified = false
 			}
 		}
 		if !allVerified {
-			logrus.Warn("Not all players have verified buy-ins, but continuing game...")
+			g.log().Warn("Not all players have verified buy-ins, but continuing game...")
 			// In production, you might want to reject game start here
 		}
 	}
 
-	logrus.Info("=== Starting new hand ===")
+	g.log().Info("=== Starting new hand ===")
 
 	// Reset state
 	g.rotationMap = make(map[int]string)
@@ -414,12 +1020,12 @@ func (g *Game) StartNewHand() {
 	g.lastRaiseAmount = BigBlind
 	g.currentPot = 0
 	g.highestBet = 0
-	g.sidePots = []SidePot{}
-	g.revealedKeys = make(map[string]*crypto.CardKeys)
-	g.foldedPlayerKeys = make(map[string]*crypto.CardKeys)
+	g.fullRaiseTo = 0
+	g.pot = NewPotLedger()
+	g.retireDeckKeys()
 
-	// Assign rotation IDs
-	sort.Strings(activeReadyPlayers)
+	// Assign rotation IDs in seat order
+	activeReadyPlayers = g.admitBigBlind(g.assignSeats(activeReadyPlayers))
 	for _, addr := range activeReadyPlayers {
 		state := g.playerStates[addr]
 		state.RotationID = g.nextRotationID
@@ -427,23 +1033,43 @@ func (g *Game) StartNewHand() {
 		state.CurrentRoundBet = 0
 		state.TotalBetThisHand = 0
 		state.IsAllIn = false
+		state.ActedThisRound = false
+		state.ActedAtRaiseTo = 0
 		g.rotationMap[state.RotationID] = addr
 		g.nextRotationID++
 	}
 
-	// Advance dealer
-	g.advanceDealer()
+	drawn := g.buttonPending
+	g.buttonPending = false
+	sbID, bbID := g.placeButton(activeReadyPlayers, drawn)
+	g.beginHandHistory(activeReadyPlayers, drawn)
 
 	// Post blinds
-	g.postBlinds()
+	g.startChipCount()
+	g.postBlinds(sbID, bbID)
+	if err := g.checkChips(); err != nil {
+		g.freeze("posting blinds", err)
+		return
+	}
+	g.recordHandStart(activeReadyPlayers)
+
+	g.publishEvent(protocol.EventNewHand, protocol.NewHandEvent{
+		DealerID:    g.currentDealerID,
+		SmallBlind:  SmallBlind,
+		BigBlind:    BigBlind,
+		Ante:        g.tableConfig.Ante,
+		PlayerCount: len(activeReadyPlayers),
+		Players:     activeReadyPlayers,
+	})
 
 	// Blockchain: Start game on-chain
 	if g.blockchainEnabled && g.blockchainGameID != [32]byte{} {
-		err := g.blockchain.StartGame(g.blockchainGameID)
+		receipt, err := g.chain().StartGame(g.blockchainGameID)
+		g.recordChainTx(newChainTx(ChainTxStart, receipt, err))
 		if err != nil {
-			logrus.Errorf("Failed to start game on blockchain: %v", err)
+			g.log().Errorf("Failed to start game on blockchain: %v", err)
 		} else {
-			logrus.Info("Game started on blockchain")
+			g.log().Info("Game started on blockchain")
 		}
 	}
 
@@ -452,42 +1078,52 @@ func (g *Game) StartNewHand() {
 
 	// Start shuffle and deal
 	g.InitiateShuffleAndDeal()
+
+	g.publishStateUpdate()
+	g.publishTurnChange()
 }
 
-// Post blinds
-func (g *Game) postBlinds() {
+// Post blinds; sbID is -1 when the small blind is dead
+func (g *Game) postBlinds(sbID, bbID int) {
+	antes := g.postAntes()
 	activeCount := len(g.getReadyActivePlayers())
-	if activeCount == 2 {
-		// Heads-up: dealer posts small blind
-		sbID := g.currentDealerID
-		sbAddr := g.rotationMap[sbID]
+
+	sbAddr, sbAmount := "", 0
+	if sbID >= 0 {
+		sbAddr = g.rotationMap[sbID]
 		g.updatePlayerState(sbAddr, PlayerActionBet, SmallBlind)
-		logrus.Infof("Player %s (dealer) posted small blind: %d", sbAddr, SmallBlind)
+		sbAmount = g.playerStates[sbAddr].CurrentRoundBet
+		g.log().Infof("Player %s posted small blind: %d", sbAddr, SmallBlind)
+	}
 
-		bbID := g.getNextPlayerID(sbID)
-		bbAddr := g.rotationMap[bbID]
-		g.updatePlayerState(bbAddr, PlayerActionBet, BigBlind)
-		logrus.Infof("Player %s posted big blind: %d", bbAddr, BigBlind)
+	bbAddr := g.rotationMap[bbID]
+	g.updatePlayerState(bbAddr, PlayerActionBet, BigBlind)
+	g.log().Infof("Player %s posted big blind: %d", bbAddr, BigBlind)
+	missed := g.postMissedBlinds(sbID, bbID)
 
+	if activeCount == 2 {
 		g.currentPlayerTurn = sbID
-		g.lastRaiserID = bbID
 	} else {
-		// Multi-way: small blind is left of dealer
-		sbID := g.getNextActivePlayerID(g.currentDealerID)
-		sbAddr := g.rotationMap[sbID]
-		g.updatePlayerState(sbAddr, PlayerActionBet, SmallBlind)
-		logrus.Infof("Player %s posted small blind: %d", sbAddr, SmallBlind)
-
-		bbID := g.getNextActivePlayerID(sbID)
-		bbAddr := g.rotationMap[bbID]
-		g.updatePlayerState(bbAddr, PlayerActionBet, BigBlind)
-		logrus.Infof("Player %s posted big blind: %d", bbAddr, BigBlind)
-
 		g.currentPlayerTurn = g.getNextActivePlayerID(bbID)
-		g.lastRaiserID = bbID
 	}
-
+	g.lastRaiserID = bbID
 	g.lastRaiseAmount = BigBlind
+	straddleAddr, straddleAmount := g.postStraddle(bbID)
+
+	g.publishEvent(protocol.EventBlindsPosted, protocol.BlindsPostedEvent{
+		SmallBlindPlayer: sbAddr,
+		BigBlindPlayer:   bbAddr,
+		SmallBlindAmount: sbAmount,
+		BigBlindAmount:   g.playerStates[bbAddr].CurrentRoundBet,
+		ButtonSeat:       g.buttonSeat,
+		DeadButton:       g.deadButton,
+		DeadSmallBlind:   sbID < 0,
+		MissedBlinds:     missed,
+		Ante:             g.tableConfig.Ante,
+		Antes:            antes,
+		StraddlePlayer:   straddleAddr,
+		StraddleAmount:   straddleAmount,
+	})
 }
 
 // Update player state based on action
@@ -497,24 +1133,25 @@ func (g *Game) updatePlayerState(addr string, action PlayerAction, value int) {
 	switch action {
 	case PlayerActionFold:
 		state.IsFolded = true
+		g.pot.Fold(addr)
 
-	case PlayerActionBet, PlayerActionRaise:
+	case PlayerActionBet, PlayerActionRaise, PlayerActionAllIn:
 		actualBet := value
-		if actualBet > state.Stack {
-			actualBet = state.Stack
+		if action == PlayerActionAllIn || actualBet >= g.maxBetTo(state) {
+			actualBet = g.maxBetTo(state)
 			state.IsAllIn = true
-			logrus.Infof("Player %s is ALL-IN!", addr)
+			g.log().Infof("Player %s is ALL-IN!", addr)
 		}
 
 		amountToAdd := actualBet - state.CurrentRoundBet
 		state.CurrentRoundBet = actualBet
 		state.TotalBetThisHand += amountToAdd
+		g.pot.Add(addr, amountToAdd)
 		g.currentPot += amountToAdd
 		state.Stack -= amountToAdd
 
 		if state.CurrentRoundBet > g.highestBet {
-			g.highestBet = state.CurrentRoundBet
-			g.lastRaiserID = state.RotationID
+			g.raiseBetTo(state)
 		}
 
 	case PlayerActionCall:
@@ -523,11 +1160,12 @@ func (g *Game) updatePlayerState(addr string, action PlayerAction, value int) {
 		if actualCall > state.Stack {
 			actualCall = state.Stack
 			state.IsAllIn = true
-			logrus.Infof("Player %s is ALL-IN!", addr)
+			g.log().Infof("Player %s is ALL-IN!", addr)
 		}
 
 		state.CurrentRoundBet += actualCall
 		state.TotalBetThisHand += actualCall
+		g.pot.Add(addr, actualCall)
 		g.currentPot += actualCall
 		state.Stack -= actualCall
 
@@ -538,50 +1176,60 @@ func (g *Game) updatePlayerState(addr string, action PlayerAction, value int) {
 
 // NEW: MonitorPlayerConnection monitors a player's connection
 func (g *Game) MonitorPlayerConnection(playerID string) {
-	g.lock.Lock()
-	defer g.lock.Unlock()
+	g.do("monitor player connection", func() {
+		g.log().Warnf("⚠️  Monitoring disconnect for player %s", playerID)
 
-	logrus.Warnf("⚠️  Monitoring disconnect for player %s", playerID)
+		// Check if player exists
+		state, exists := g.playerStates[playerID]
+		if !exists {
+			g.log().Warnf("Player %s not found in game", playerID)
+			return
+		}
 
-	// Check if player exists
-	state, exists := g.playerStates[playerID]
-	if !exists {
-		logrus.Warnf("Player %s not found in game", playerID)
-		return
-	}
+		// Only handle disconnect if game is active
+		if g.currentStatus != GameStatusInProgress && g.currentStatus != GameStatusDealing {
+			g.log().Infof("Game not active, ignoring disconnect for %s", playerID)
+			return
+		}
 
-	// Only handle disconnect if game is active
-	if g.currentStatus != GameStatusInProgress && g.currentStatus != GameStatusDealing {
-		logrus.Infof("Game not active, ignoring disconnect for %s", playerID)
-		return
-	}
+		// Mark player as potentially disconnected
+		state.IsActive = false
 
-	// Mark player as potentially disconnected
-	state.IsActive = false
+		g.publishEvent(protocol.EventPlayerDisconnected, protocol.PlayerDisconnectedEvent{
+			PlayerID:  playerID,
+			Timestamp: formatEventTime(time.Now()),
+			Timeout:   g.DisconnectHandler.Timeout().String(),
+			Message:   fmt.Sprintf("Player %s disconnected", playerID),
+		})
 
-	// Run disconnect handler in goroutine
-	go func() {
-		ctx := context.Background()
-		if err := g.DisconnectHandler.HandleDisconnect(ctx, playerID); err != nil {
-			logrus.Errorf("Error handling disconnect for player %s: %v", playerID, err)
-		}
-	}()
+		// Run disconnect handler in goroutine
+		go func() {
+			if err := g.DisconnectHandler.HandleDisconnect(g.ctx, playerID); err != nil {
+				g.log().Errorf("Error handling disconnect for player %s: %v", playerID, err)
+			}
+		}()
+	})
 }
 
 // NEW: NotifyPlayerReconnected notifies that a player reconnected
 func (g *Game) NotifyPlayerReconnected(playerID string) error {
-	g.lock.Lock()
-	defer g.lock.Unlock()
+	return g.exec("notify player reconnected", func() error {
+		g.log().Infof("✅ Player %s reconnected", playerID)
 
-	logrus.Infof("✅ Player %s reconnected", playerID)
+		// Restore player to active state
+		state, exists := g.playerStates[playerID]
+		if exists {
+			state.IsActive = true
+		}
 
-	// Restore player to active state
-	state, exists := g.playerStates[playerID]
-	if exists {
-		state.IsActive = true
-	}
+		g.publishEvent(protocol.EventPlayerReconnected, protocol.PlayerReconnectedEvent{
+			PlayerID:  playerID,
+			Timestamp: formatEventTime(time.Now()),
+			Message:   fmt.Sprintf("Player %s reconnected", playerID),
+		})
 
-	return g.DisconnectHandler.HandleReconnect(playerID)
+		return g.DisconnectHandler.HandleReconnect(playerID)
+	})
 }
 
 // NEW: GetPlayer returns a player state by address
@@ -591,54 +1239,66 @@ func (g *Game) GetPlayer(playerID string) *PlayerState {
 	return g.playerStates[playerID]
 }
 
-// NEW: EndGameWithPenalty ends game with penalty to abandoned player
-func (g *Game) EndGameWithPenalty(abandonedPlayerID string, remainingPlayers []*PlayerState) error {
-	logrus.Warnf("💀 Ending game with penalty. Abandoned player: %s", abandonedPlayerID)
-
-	abandonedPlayer := g.GetPlayer(abandonedPlayerID)
-	if abandonedPlayer == nil {
-		return fmt.Errorf("abandoned player %s not found", abandonedPlayerID)
-	}
+// NEW: EndGameWithPenalty ends game with penalty to abandoned player. The
+// abandoned player has already left the table (see forfeitSession).
+func (g *Game) EndGameWithPenalty(abandonedPlayerID string, remainingPlayers []string) error {
+	g.log().Warnf("💀 Ending game with penalty. Abandoned player: %s", abandonedPlayerID)
 
 	// Prepare data for blockchain
 	winners := make([]common.Address, 0)
 	amounts := make([]*big.Int, 0)
+	var submit bool
+	var gameIDStr string
 
-	for _, player := range remainingPlayers {
-		if player.Stack > 0 {
-			// Convert player address string to common.Address
-			winners = append(winners, common.HexToAddress(player.ListenAddr))
-			
-			// Convert chips to wei (assuming 1 chip = 0.001 ETH = 10^15 wei)
-			amountWei := big.NewInt(int64(player.Stack))
-			amountWei.Mul(amountWei, big.NewInt(1000000000000000)) // multiply by 10^15 wei
-			amounts = append(amounts, amountWei)
+	g.do("penalty", func() {
+		g.audit("penalty_applied", "table", map[string]interface{}{
+			"abandoned": abandonedPlayerID,
+			"remaining": len(remainingPlayers),
+		})
+
+		for _, addr := range remainingPlayers {
+			player, ok := g.playerStates[addr]
+			if ok && player.Stack > 0 {
+				// Convert player address string to common.Address
+				winners = append(winners, common.HexToAddress(player.ListenAddr))
+
+				// Convert chips to wei (assuming 1 chip = 0.001 ETH = 10^15 wei)
+				amountWei := big.NewInt(int64(player.Stack))
+				amountWei.Mul(amountWei, big.NewInt(1000000000000000)) // multiply by 10^15 wei
+				amounts = append(amounts, amountWei)
+			}
 		}
-	}
 
-	// Submit to blockchain if enabled
-	if g.blockchainEnabled && g.blockchain != nil {
-		logrus.Info("📝 Submitting penalty transaction to blockchain...")
+		submit = g.blockchainEnabled && g.blockchain != nil
+		gameIDStr = fmt.Sprintf("%x", g.blockchainGameID[:])
+	})
+
+	// Submit to blockchain if enabled, off the table's loop
+	if submit {
+		g.log().Info("📝 Submitting penalty transaction to blockchain...")
 
-		gameIDStr := fmt.Sprintf("%x", g.blockchainGameID[:])
-		
-		err := g.blockchain.EndGameWithPenalty(
+		receipt, err := g.chain().EndGameWithPenalty(
 			gameIDStr,
-			common.HexToAddress(abandonedPlayer.ListenAddr),
+			common.HexToAddress(abandonedPlayerID),
 			winners,
 			amounts,
 		)
+		g.do("penalty", func() {
+			g.recordChainTx(newChainTx(ChainTxPenalty, receipt, err))
+		})
 
 		if err != nil {
-			logrus.Errorf("Blockchain penalty submission failed: %v", err)
+			g.log().Errorf("Blockchain penalty submission failed: %v", err)
 			return fmt.Errorf("blockchain penalty submission failed: %w", err)
 		}
 
-		logrus.Info("✅ Blockchain penalty transaction successful")
+		g.log().Info("✅ Blockchain penalty transaction successful")
 	}
 
 	// Update game status
-	g.setStatus(GameStatusFinished)
+	g.do("penalty", func() {
+		g.setStatus(GameStatusFinished)
+	})
 
 	return nil
 }
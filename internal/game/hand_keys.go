@@ -0,0 +1,99 @@
+package game
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/RedPaladin7/peerpoker/internal/crypto"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+)
+
+// Deck keys last one hand. A key revealed after its owner folded, or
+// published with the shuffle audit, then says nothing about later decks.
+
+// rotateDeckKeys generates this node's keys for the hand about to be
+// dealt, numbered by the hand they belong to
+func (g *Game) rotateDeckKeys() error {
+	keys, err := crypto.GenerateCardKeys()
+	if err != nil {
+		return fmt.Errorf("failed to generate deck keys: %w", err)
+	}
+	g.retireDeckKeys()
+	g.deckKeys = keys
+	g.keyEpoch = g.handCount
+	return nil
+}
+
+// retireDeckKeys zeroes every key the finished hand used: ours and those
+// other players revealed. Called once the hand's cards are all known.
+func (g *Game) retireDeckKeys() {
+	if g.deckKeys != nil {
+		g.deckKeys.Zero()
+		g.deckKeys = nil
+	}
+	for _, keys := range g.revealedKeys {
+		keys.Zero()
+	}
+	g.revealedKeys = make(map[string]*crypto.CardKeys)
+	g.keyEpoch = 0
+}
+
+// revealKeysPayload is our keys for this hand, as sent after the hand by
+// a player who folded
+func (g *Game) revealKeysPayload() protocol.RevealKeysPayload {
+	return protocol.RevealKeysPayload{
+		EncryptionKey: g.deckKeys.EncKey.String(),
+		DecryptionKey: g.deckKeys.DecKey.String(),
+		Prime:         g.deckKeys.Prime.String(),
+		KeyEpoch:      g.keyEpoch,
+	}
+}
+
+// handleMessageRevealKeys takes a folded player's keys for the hand they
+// folded, to check the shares they sent during it. Keys arriving while that
+// hand is still ending here are held until it has.
+func (g *Game) handleMessageRevealKeys(from string, payload protocol.RevealKeysPayload) error {
+	return g.exec("reveal keys message from "+from, func() error {
+		keys, err := parseRevealedKeys(payload)
+		if err != nil {
+			return fmt.Errorf("keys from %s: %w", from, err)
+		}
+
+		if shares, ok := g.foldShares[from]; ok && shares.epoch == payload.KeyEpoch {
+			if shares.keys != nil {
+				keys.Zero()
+				return fmt.Errorf("duplicate key reveal from %s", from)
+			}
+			shares.keys = keys
+			return nil
+		}
+
+		shares, ok := g.unverifiedShares[from]
+		if !ok || shares.epoch != payload.KeyEpoch {
+			keys.Zero()
+			return fmt.Errorf("keys from %s are for hand %d, which they did not fold", from, payload.KeyEpoch)
+		}
+		delete(g.unverifiedShares, from)
+		shares.keys = keys
+		g.verifyFoldShares(from, shares)
+		return nil
+	})
+}
+
+func parseRevealedKeys(payload protocol.RevealKeysPayload) (*crypto.CardKeys, error) {
+	keys := &crypto.CardKeys{
+		EncKey: new(big.Int),
+		DecKey: new(big.Int),
+		Prime:  new(big.Int),
+	}
+	_, encOK := keys.EncKey.SetString(payload.EncryptionKey, 10)
+	_, decOK := keys.DecKey.SetString(payload.DecryptionKey, 10)
+	_, primeOK := keys.Prime.SetString(payload.Prime, 10)
+	if !encOK || !decOK || !primeOK {
+		return nil, fmt.Errorf("malformed keys")
+	}
+	if err := keys.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid keys: %w", err)
+	}
+	return keys, nil
+}
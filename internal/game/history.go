@@ -0,0 +1,339 @@
+package game
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/blockchain"
+	"github.com/RedPaladin7/peerpoker/internal/deck"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// maxHandHistory bounds how many finished hands are kept in memory
+const maxHandHistory = 100
+
+// HandHistory records how a hand was seated and how it ended
+type HandHistory struct {
+	ID        int            `json:"id"`  // the table's hand number
+	UID       string         `json:"uid"` // unique across tables and restarts
+	StartedAt time.Time      `json:"started_at"`
+	EndedAt   time.Time      `json:"ended_at,omitempty"`
+	Seats     []string       `json:"seats"`
+	Dealer    string         `json:"dealer"`
+	Pot       int            `json:"pot"`
+	Rake      int            `json:"rake,omitempty"`
+	Bets      map[string]int `json:"bets,omitempty"`
+	Winnings  map[string]int `json:"winnings,omitempty"`
+	SeatDraw  *SeatDraw      `json:"seat_draw,omitempty"`
+
+	// Each player's stack and forced bets as the hand began, which a replay
+	// starts from (see replay.go)
+	Start []HandSeat `json:"start,omitempty"`
+
+	Actions []HandAction `json:"actions,omitempty"`
+
+	// The board (both boards if it was run twice) and the hole cards shown
+	// at the showdown
+	Board       []string            `json:"board,omitempty"`
+	SecondBoard []string            `json:"second_board,omitempty"`
+	Shown       map[string][]string `json:"shown,omitempty"`
+
+	// Hash of the finished hand, anchored on-chain (see anchor.go)
+	Commitment string `json:"commitment,omitempty"`
+
+	Voided     bool   `json:"voided,omitempty"`
+	VoidReason string `json:"void_reason,omitempty"`
+
+	Chain *ChainRecord `json:"chain,omitempty"`
+
+	// Player signatures over the hand's escrow payout (see attestation.go)
+	Attestations []Attestation `json:"attestations,omitempty"`
+
+	Compensations []Compensation `json:"compensations,omitempty"`
+
+	// How the deck was shuffled, served on its own (see shuffle_audit.go)
+	shuffle *shuffleRecord
+}
+
+// HandAction is one betting action in a hand, numbered within the hand
+type HandAction struct {
+	Seq      int    `json:"seq"`
+	PlayerID string `json:"player_id"`
+	Action   string `json:"action"`
+	Amount   int    `json:"amount,omitempty"`
+	Street   string `json:"street"`
+}
+
+// HandSeat is a player as a hand began: their stack before the antes and
+// blinds, what they posted, and how much of it counts as a bet this street
+type HandSeat struct {
+	PlayerID string `json:"player_id"`
+	Stack    int    `json:"stack"`
+	Posted   int    `json:"posted,omitempty"`
+	Bet      int    `json:"bet,omitempty"`
+}
+
+// Compensation is an insurance payout made to a player for a hand
+type Compensation struct {
+	PayoutID   int    `json:"payout_id"`
+	PlayerID   string `json:"player_id"`
+	Amount     int    `json:"amount"`
+	IncidentID string `json:"incident_id"`
+	TxHash     string `json:"tx_hash,omitempty"`
+}
+
+// Kinds of escrow transaction linked to a hand
+const (
+	ChainTxCreate  = "create"
+	ChainTxStart   = "start"
+	ChainTxSettle  = "settle"
+	ChainTxRefund  = "refund"
+	ChainTxPenalty = "penalty"
+)
+
+// ChainRecord links a hand to its on-chain escrow game
+type ChainRecord struct {
+	GameID       string    `json:"game_id"`
+	Transactions []ChainTx `json:"transactions"`
+}
+
+// ChainTx is one escrow transaction and its settlement receipt
+type ChainTx struct {
+	Kind          string                `json:"kind"`
+	SubmittedAt   time.Time             `json:"submitted_at"`
+	Receipt       *blockchain.TxReceipt `json:"receipt,omitempty"`
+	Confirmations uint64                `json:"confirmations"`
+	Error         string                `json:"error,omitempty"`
+}
+
+func newChainTx(kind string, receipt *blockchain.TxReceipt, err error) ChainTx {
+	tx := ChainTx{
+		Kind:        kind,
+		SubmittedAt: time.Now(),
+		Receipt:     receipt,
+	}
+	if err != nil {
+		tx.Error = err.Error()
+	}
+	return tx
+}
+
+// HandHistories returns recorded hands, newest first
+func (g *Game) HandHistories() []HandHistory {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	hands := make([]HandHistory, 0, len(g.handHistory))
+	for i := len(g.handHistory) - 1; i >= 0; i-- {
+		hands = append(hands, *g.handHistory[i])
+	}
+	return hands
+}
+
+// GetHandHistory returns a recorded hand by ID, with current confirmation
+// counts for its escrow transactions
+func (g *Game) GetHandHistory(id int) (HandHistory, bool) {
+	g.lock.RLock()
+	found := g.handByID(id)
+	if found == nil {
+		g.lock.RUnlock()
+		return HandHistory{}, false
+	}
+
+	hand := *found
+	hand.Compensations = append([]Compensation(nil), found.Compensations...)
+	if found.Chain != nil {
+		hand.Chain = &ChainRecord{
+			GameID:       found.Chain.GameID,
+			Transactions: append([]ChainTx{}, found.Chain.Transactions...),
+		}
+	}
+	g.lock.RUnlock()
+
+	// Query the chain without holding the game lock
+	if hand.Chain != nil && g.blockchainEnabled {
+		for i, tx := range hand.Chain.Transactions {
+			if tx.Receipt == nil {
+				continue
+			}
+			confirmations, err := g.blockchain.Confirmations(common.HexToHash(tx.Receipt.TxHash))
+			if err != nil {
+				g.log().Warnf("Failed to get confirmations for %s: %v", tx.Receipt.TxHash, err)
+				continue
+			}
+			hand.Chain.Transactions[i].Confirmations = confirmations
+		}
+	}
+	return hand, true
+}
+
+// HandHistoryByUID returns a recorded hand by its unique ID
+func (g *Game) HandHistoryByUID(uid string) (HandHistory, bool) {
+	g.lock.RLock()
+	id := 0
+	for _, hand := range g.handHistory {
+		if hand.UID == uid {
+			id = hand.ID
+			break
+		}
+	}
+	g.lock.RUnlock()
+
+	if id == 0 {
+		return HandHistory{}, false
+	}
+	return g.GetHandHistory(id)
+}
+
+// newHandUID returns a random 128-bit hand ID
+func newHandUID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(id)
+}
+
+// beginHandHistory records the seating of a new hand
+func (g *Game) beginHandHistory(seats []string, drawn bool) {
+	g.handCount++
+	g.handUID = newHandUID()
+	g.setHandLogger()
+	hand := &HandHistory{
+		ID:        g.handCount,
+		UID:       g.handUID,
+		StartedAt: time.Now(),
+		Seats:     append([]string{}, seats...),
+		Dealer:    g.rotationMap[g.currentDealerID],
+	}
+	// The draw is recorded on the hand it seated
+	if drawn {
+		hand.SeatDraw = g.seatDraw
+	}
+
+	if g.blockchainEnabled && g.blockchainGameID != [32]byte{} {
+		hand.Chain = &ChainRecord{
+			GameID:       fmt.Sprintf("0x%x", g.blockchainGameID),
+			Transactions: g.nextHandChain,
+		}
+	}
+	g.nextHandChain = nil
+	g.handActionSeq = 0
+	g.handRake = 0
+	g.appliedActions = make(map[string]appliedAction)
+
+	g.handHistory = append(g.handHistory, hand)
+	if len(g.handHistory) > maxHandHistory {
+		g.handHistory = g.handHistory[len(g.handHistory)-maxHandHistory:]
+	}
+}
+
+// recordHandStart records the stacks and forced bets the hand began with,
+// once the blinds are posted
+func (g *Game) recordHandStart(seats []string) {
+	if len(g.handHistory) == 0 {
+		return
+	}
+	hand := g.handHistory[len(g.handHistory)-1]
+	hand.Start = make([]HandSeat, 0, len(seats))
+	for _, addr := range seats {
+		state := g.playerStates[addr]
+		hand.Start = append(hand.Start, HandSeat{
+			PlayerID: addr,
+			Stack:    state.Stack + state.TotalBetThisHand,
+			Posted:   state.TotalBetThisHand,
+			Bet:      state.CurrentRoundBet,
+		})
+	}
+}
+
+// recordHandResult closes the current hand's history and announces the result
+func (g *Game) recordHandResult(stacksBefore map[string]int, pot int, hands []PlayerHand) {
+	winnings := g.handWinnings(stacksBefore)
+
+	if len(g.handHistory) > 0 {
+		hand := g.handHistory[len(g.handHistory)-1]
+		hand.EndedAt = time.Now()
+		hand.Pot = pot
+		hand.Rake = g.handRake
+		hand.Winnings = winnings
+		hand.Bets = make(map[string]int)
+		for addr := range stacksBefore {
+			if bet := g.playerStates[addr].TotalBetThisHand; bet > 0 {
+				hand.Bets[addr] = bet
+			}
+		}
+		hand.Board = cardStrings(g.communityCards)
+		hand.SecondBoard = cardStrings(g.secondBoard)
+		if len(hands) > 0 {
+			hand.Shown = make(map[string][]string, len(hands))
+			for _, shown := range hands {
+				hand.Shown[shown.Addr] = cardStrings(shown.Hand)
+			}
+		}
+		g.commitHand(hand)
+		g.storeHand(hand)
+		g.publishShuffle(hand)
+	}
+	g.recordLedgerHand(stacksBefore)
+
+	g.publishHandResult(winnings, pot, hands)
+}
+
+func cardStrings(cards []deck.Card) []string {
+	if len(cards) == 0 {
+		return nil
+	}
+	out := make([]string, len(cards))
+	for i, card := range cards {
+		out[i] = card.String()
+	}
+	return out
+}
+
+// recordChainTx links an escrow transaction to the current (or just finished) hand
+func (g *Game) recordChainTx(tx ChainTx) {
+	if len(g.handHistory) == 0 {
+		g.nextHandChain = append(g.nextHandChain, tx)
+		return
+	}
+
+	attachChainTx(g.handHistory[len(g.handHistory)-1], fmt.Sprintf("0x%x", g.blockchainGameID), tx)
+}
+
+// RecordCompensation audits an insurance payout and links it to the hand it
+// compensates for, if that hand is still in the history
+func (g *Game) RecordCompensation(handID int, c Compensation, actor string) {
+	g.do("record compensation", func() {
+		if hand := g.handByID(handID); hand != nil {
+			hand.Compensations = append(hand.Compensations, c)
+		}
+
+		g.audit("player_compensated", actor, map[string]interface{}{
+			"payout_id":   c.PayoutID,
+			"player":      c.PlayerID,
+			"amount":      c.Amount,
+			"incident_id": c.IncidentID,
+			"hand_id":     handID,
+			"tx_hash":     c.TxHash,
+		})
+	})
+}
+
+func attachChainTx(hand *HandHistory, gameID string, tx ChainTx) {
+	if hand.Chain == nil {
+		hand.Chain = &ChainRecord{GameID: gameID}
+	}
+	hand.Chain.Transactions = append(hand.Chain.Transactions, tx)
+}
+
+func (g *Game) handByID(id int) *HandHistory {
+	for _, hand := range g.handHistory {
+		if hand.ID == id {
+			return hand
+		}
+	}
+	return nil
+}
@@ -0,0 +1,472 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// HostVoteTimeout is how long a vote to use a host power stays open
+const HostVoteTimeout = 2 * time.Minute
+
+// HostAction is one of the host's powers: kicking an unresponsive player,
+// changing the table config between hands, or closing the table. While the
+// host is away a majority of seated players can use them by vote.
+type HostAction struct {
+	Action   string       `json:"action"`              // protocol.HostAction*
+	PlayerID string       `json:"player_id,omitempty"` // the player to kick
+	Config   *TableConfig `json:"config,omitempty"`    // the new config
+}
+
+func (a HostAction) same(other HostAction) bool {
+	if a.Action != other.Action || a.PlayerID != other.PlayerID {
+		return false
+	}
+	if a.Config == nil || other.Config == nil {
+		return a.Config == other.Config
+	}
+	return *a.Config == *other.Config
+}
+
+func (a HostAction) payload() (protocol.HostActionPayload, error) {
+	payload := protocol.HostActionPayload{
+		Action:   a.Action,
+		PlayerID: a.PlayerID,
+	}
+	if a.Config != nil {
+		data, err := json.Marshal(a.Config)
+		if err != nil {
+			return payload, err
+		}
+		payload.Config = data
+	}
+	return payload, nil
+}
+
+func hostActionFromPayload(payload protocol.HostActionPayload) (HostAction, error) {
+	action := HostAction{
+		Action:   payload.Action,
+		PlayerID: payload.PlayerID,
+	}
+	if len(payload.Config) > 0 {
+		var cfg TableConfig
+		if err := json.Unmarshal(payload.Config, &cfg); err != nil {
+			return action, fmt.Errorf("invalid table config: %w", err)
+		}
+		action.Config = &cfg
+	}
+	return action, nil
+}
+
+// HostStatus describes the table's host, and the players they may kick
+// with the reason why
+type HostStatus struct {
+	Host         string            `json:"host"`
+	Present      bool              `json:"present"`
+	Unresponsive map[string]string `json:"unresponsive"`
+}
+
+// hostVote is an open vote to use a host power
+type hostVote struct {
+	action    HostAction
+	proposer  string
+	startedAt time.Time
+	votes     map[string]bool
+}
+
+// HostVoteStatus describes an open vote to use a host power. It passes
+// once Needed seated players approve; a player facing a kick has no vote.
+type HostVoteStatus struct {
+	HostAction
+	Proposer  string    `json:"proposer"`
+	StartedAt time.Time `json:"started_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Needed    int       `json:"needed"`
+	Approved  []string  `json:"approved"`
+	Rejected  []string  `json:"rejected"`
+	Pending   []string  `json:"pending"`
+}
+
+// SetHost makes a player the table's host. The node that opened the table
+// names its own player, and peers learn it from the handshake.
+func (g *Game) SetHost(playerID string) {
+	g.do("set host", func() {
+		g.host = playerID
+		g.log().WithField("host", playerID).Info("Table host set")
+	})
+}
+
+// learnHost takes the host from a peer's handshake when this node has none
+func (g *Game) learnHost(peer, host string) {
+	if host == "" || host == g.host {
+		return
+	}
+	if g.host != "" {
+		g.log().WithFields(logrus.Fields{
+			"peer":      peer,
+			"host":      g.host,
+			"peer_host": host,
+		}).Warn("Peer names a different table host")
+		return
+	}
+
+	g.host = host
+	g.log().WithFields(logrus.Fields{
+		"peer": peer,
+		"host": host,
+	}).Info("Table host learned from peer")
+}
+
+// Host returns the table's host, whether they are at the table, and who
+// they may kick
+func (g *Game) Host() HostStatus {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	status := HostStatus{
+		Host:         g.host,
+		Present:      g.hostPresent(),
+		Unresponsive: make(map[string]string),
+	}
+	for addr := range g.playerStates {
+		if reason := g.unresponsive(addr); reason != "" {
+			status.Unresponsive[addr] = reason
+		}
+	}
+	return status
+}
+
+// hostPresent reports whether the host is seated and connected
+func (g *Game) hostPresent() bool {
+	state, ok := g.playerStates[g.host]
+	return ok && state.IsActive
+}
+
+// unresponsive says why a seated player may be kicked: they dropped, ran
+// out of time on their last turn, or are sitting out. It is "" for anyone
+// else.
+func (g *Game) unresponsive(addr string) string {
+	state, ok := g.playerStates[addr]
+	switch {
+	case !ok || (!state.IsReady && state.Seat == 0):
+		return ""
+	case !state.IsActive:
+		return "disconnected"
+	case g.timedOut[addr]:
+		return "timed out"
+	case state.SittingOut:
+		return "sitting out"
+	}
+	return ""
+}
+
+// HostAct uses one of the host's powers on their behalf, here and at every
+// peer. Only the host's own node can do this.
+func (g *Game) HostAct(playerID string, action HostAction) error {
+	return g.exec("host action", func() error {
+		if g.host == "" || playerID != g.host {
+			return fmt.Errorf("only the table host can %s", action.Action)
+		}
+		if g.host != g.listenAddr {
+			return fmt.Errorf("the host acts from their own node")
+		}
+		if action.Action == protocol.HostActionKick && action.PlayerID == playerID {
+			return fmt.Errorf("the host cannot kick themselves")
+		}
+		if err := g.checkHostAction(action); err != nil {
+			return err
+		}
+		if err := g.checkKick(action); err != nil {
+			return err
+		}
+
+		payload, err := action.payload()
+		if err != nil {
+			return err
+		}
+		g.sendToPlayers(protocol.TypeHostAction, payload, g.getOtherPlayers()...)
+		g.applyHostAction(playerID, action)
+		return nil
+	})
+}
+
+// VoteHostAction records a seated player's vote to use a host power while
+// the host is away. The first approval opens the vote, and a majority of
+// seated players carries it.
+func (g *Game) VoteHostAction(playerID string, action HostAction, approve bool) error {
+	return g.exec("host vote", func() error {
+		if err := g.recordHostVote(playerID, action, approve); err != nil {
+			return err
+		}
+
+		payload, err := action.payload()
+		if err != nil {
+			return err
+		}
+		g.sendToPlayers(protocol.TypeHostVote, protocol.HostVotePayload{
+			HostActionPayload: payload,
+			Approve:           approve,
+		}, g.getOtherPlayers()...)
+
+		g.checkHostVote()
+		return nil
+	})
+}
+
+// HostVote returns the open vote to use a host power, if any
+func (g *Game) HostVote() (HostVoteStatus, bool) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	vote := g.activeHostVote()
+	if vote == nil {
+		return HostVoteStatus{}, false
+	}
+
+	approved, rejected, pending := g.hostVoteTally(vote)
+	return HostVoteStatus{
+		HostAction: vote.action,
+		Proposer:   vote.proposer,
+		StartedAt:  vote.startedAt,
+		ExpiresAt:  vote.startedAt.Add(HostVoteTimeout),
+		Needed:     majority(len(approved) + len(rejected) + len(pending)),
+		Approved:   approved,
+		Rejected:   rejected,
+		Pending:    pending,
+	}, true
+}
+
+// handleMessageHostAction applies a power the host used at their node. The
+// host judges who is unresponsive, so a kick is not checked again here.
+func (g *Game) handleMessageHostAction(from string, payload protocol.HostActionPayload) error {
+	return g.exec("host action message from "+from, func() error {
+		if g.host == "" || from != g.host {
+			return fmt.Errorf("%s is not the table host", from)
+		}
+
+		action, err := hostActionFromPayload(payload)
+		if err != nil {
+			return err
+		}
+		if err := g.checkHostAction(action); err != nil {
+			return err
+		}
+		g.applyHostAction(from, action)
+		return nil
+	})
+}
+
+func (g *Game) handleMessageHostVote(from string, payload protocol.HostVotePayload) error {
+	return g.exec("host vote message from "+from, func() error {
+		action, err := hostActionFromPayload(payload.HostActionPayload)
+		if err != nil {
+			return err
+		}
+		if err := g.recordHostVote(from, action, payload.Approve); err != nil {
+			return err
+		}
+
+		g.checkHostVote()
+		return nil
+	})
+}
+
+// checkHostAction checks that a host power can be used as asked
+func (g *Game) checkHostAction(action HostAction) error {
+	switch action.Action {
+	case protocol.HostActionKick:
+		state, ok := g.playerStates[action.PlayerID]
+		if !ok || (!state.IsReady && state.Seat == 0) {
+			return fmt.Errorf("player %s is not seated", action.PlayerID)
+		}
+	case protocol.HostActionConfig:
+		if action.Config == nil {
+			return fmt.Errorf("a table config is required")
+		}
+		if g.currentStatus != GameStatusWaiting {
+			return fmt.Errorf("the table config can only change between hands")
+		}
+		return action.Config.validate()
+	case protocol.HostActionClose:
+	default:
+		return fmt.Errorf("unknown host action %q", action.Action)
+	}
+	return nil
+}
+
+// checkKick limits kicks to unresponsive players
+func (g *Game) checkKick(action HostAction) error {
+	if action.Action == protocol.HostActionKick && g.unresponsive(action.PlayerID) == "" {
+		return fmt.Errorf("player %s is responsive; only unresponsive players can be kicked", action.PlayerID)
+	}
+	return nil
+}
+
+func (g *Game) applyHostAction(by string, action HostAction) {
+	event := protocol.HostActionEvent{
+		By:       by,
+		Action:   action.Action,
+		PlayerID: action.PlayerID,
+	}
+	g.log().WithFields(logrus.Fields{
+		"by":     by,
+		"action": action.Action,
+		"player": action.PlayerID,
+	}).Warn("Host power used")
+
+	switch action.Action {
+	case protocol.HostActionKick:
+		event.Reason = g.unresponsive(action.PlayerID)
+		g.audit("player_kicked", by, map[string]interface{}{
+			"player": action.PlayerID,
+			"reason": event.Reason,
+		})
+		g.publishEvent(protocol.EventHostAction, event)
+		g.removePlayer(action.PlayerID, "kicked")
+
+	case protocol.HostActionConfig:
+		g.audit("table_config_changed", by, map[string]interface{}{
+			"config": *action.Config,
+		})
+		g.setTableConfig(*action.Config)
+		g.publishEvent(protocol.EventHostAction, event)
+		g.publishStateUpdate()
+
+	case protocol.HostActionClose:
+		g.publishEvent(protocol.EventHostAction, event)
+		g.close(by)
+	}
+}
+
+func (g *Game) recordHostVote(playerID string, action HostAction, approve bool) error {
+	state, ok := g.playerStates[playerID]
+	if !ok || !state.IsReady {
+		return fmt.Errorf("player %s is not seated", playerID)
+	}
+	if action.Action == protocol.HostActionKick && action.PlayerID == playerID {
+		return fmt.Errorf("player %s cannot vote on their own kick", playerID)
+	}
+
+	vote := g.activeHostVote()
+	if vote == nil {
+		if !approve {
+			return fmt.Errorf("no host vote in progress")
+		}
+		if g.hostPresent() {
+			return fmt.Errorf("the host %s is at the table", g.host)
+		}
+		if err := g.checkHostAction(action); err != nil {
+			return err
+		}
+		if err := g.checkKick(action); err != nil {
+			return err
+		}
+		vote = &hostVote{
+			action:    action,
+			proposer:  playerID,
+			startedAt: time.Now(),
+			votes:     make(map[string]bool),
+		}
+		g.hostVote = vote
+
+		g.audit("host_vote_proposed", playerID, map[string]interface{}{
+			"action": action.Action,
+			"player": action.PlayerID,
+		})
+	} else if !vote.action.same(action) {
+		return fmt.Errorf("a vote to %s is already in progress", vote.action.Action)
+	}
+
+	vote.votes[playerID] = approve
+	g.log().WithFields(logrus.Fields{
+		"player":  playerID,
+		"action":  vote.action.Action,
+		"approve": approve,
+	}).Info("Host vote received")
+
+	approved, rejected, pending := g.hostVoteTally(vote)
+	g.publishEvent(protocol.EventHostVote, protocol.HostVoteEvent{
+		PlayerID: playerID,
+		Action:   vote.action.Action,
+		Target:   vote.action.PlayerID,
+		Approve:  approve,
+		Approved: approved,
+		Rejected: rejected,
+		Pending:  pending,
+	})
+	return nil
+}
+
+// activeHostVote returns the open vote, discarding it once expired
+func (g *Game) activeHostVote() *hostVote {
+	if g.hostVote == nil {
+		return nil
+	}
+	if time.Since(g.hostVote.startedAt) > HostVoteTimeout {
+		return nil
+	}
+	return g.hostVote
+}
+
+func (g *Game) hostVoteTally(vote *hostVote) (approved, rejected, pending []string) {
+	approved = make([]string, 0)
+	rejected = make([]string, 0)
+	pending = make([]string, 0)
+	for _, addr := range g.getReadyPlayers() {
+		if vote.action.Action == protocol.HostActionKick && addr == vote.action.PlayerID {
+			continue
+		}
+		approve, voted := vote.votes[addr]
+		switch {
+		case !voted:
+			pending = append(pending, addr)
+		case approve:
+			approved = append(approved, addr)
+		default:
+			rejected = append(rejected, addr)
+		}
+	}
+	sort.Strings(approved)
+	sort.Strings(rejected)
+	sort.Strings(pending)
+	return approved, rejected, pending
+}
+
+// checkHostVote uses the power voted on once a majority approves, and drops
+// the vote once a majority can no longer be reached
+func (g *Game) checkHostVote() {
+	vote := g.activeHostVote()
+	if vote == nil {
+		return
+	}
+
+	approved, rejected, pending := g.hostVoteTally(vote)
+	needed := majority(len(approved) + len(rejected) + len(pending))
+	switch {
+	case len(approved) >= needed:
+		g.hostVote = nil
+		g.audit("host_vote_passed", "table", map[string]interface{}{
+			"action":   vote.action.Action,
+			"player":   vote.action.PlayerID,
+			"approved": approved,
+		})
+		// The table may have moved on while the vote was open
+		if err := g.checkHostAction(vote.action); err != nil {
+			g.log().Warnf("Host vote passed but cannot be carried out: %v", err)
+			return
+		}
+		g.applyHostAction("vote", vote.action)
+
+	case len(approved)+len(pending) < needed:
+		g.hostVote = nil
+		g.audit("host_vote_failed", "table", map[string]interface{}{
+			"action":   vote.action.Action,
+			"player":   vote.action.PlayerID,
+			"rejected": rejected,
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package game
+
+import "fmt"
+
+// MaxActionIDLength bounds idempotency keys and action message IDs
+const MaxActionIDLength = 128
+
+// appliedAction is an action applied under an idempotency key, kept so a
+// retry with the same key can be recognised
+type appliedAction struct {
+	action string
+	value  int
+}
+
+// HandlePlayerActionWithKey applies an action at most once per player and
+// key within a hand. A retry with a key that was already applied returns
+// replayed=true without touching the table; reusing a key for a different
+// action is an error. Rejected actions are not recorded, so retrying one is
+// evaluated afresh. An empty key behaves like HandlePlayerAction.
+func (g *Game) HandlePlayerActionWithKey(clientID, actionID, actionStr string, value int) (replayed bool, err error) {
+	err = g.exec("player action", func() error {
+		replayed, err = g.handlePlayerActionWithKey(clientID, actionID, actionStr, value)
+		return err
+	})
+	return replayed, err
+}
+
+func (g *Game) handlePlayerActionWithKey(clientID, actionID, actionStr string, value int) (replayed bool, err error) {
+	if actionID == "" {
+		return false, g.handlePlayerAction(clientID, "", actionStr, value)
+	}
+	if len(actionID) > MaxActionIDLength {
+		return false, fmt.Errorf("action ID longer than %d characters", MaxActionIDLength)
+	}
+
+	key := clientID + "\x00" + actionID
+	if applied, ok := g.appliedActions[key]; ok {
+		if applied.action != actionStr || applied.value != value {
+			return false, fmt.Errorf("action ID %q was already used for %s %d this hand", actionID, applied.action, applied.value)
+		}
+		return true, nil
+	}
+
+	if err := g.handlePlayerAction(clientID, actionID, actionStr, value); err != nil {
+		return false, err
+	}
+	g.appliedActions[key] = appliedAction{action: actionStr, value: value}
+	return false, nil
+}
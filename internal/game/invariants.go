@@ -0,0 +1,139 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrCodeInvariantViolation tags the EventError sent when a table freezes
+const ErrCodeInvariantViolation = "INVARIANT_VIOLATION"
+
+// chipsInPlay is every chip the players dealt in hold, behind or in the pot
+func (g *Game) chipsInPlay() int {
+	total := g.currentPot
+	for _, addr := range g.rotationMap {
+		if state, ok := g.playerStates[addr]; ok {
+			total += state.Stack
+		}
+	}
+	return total
+}
+
+// startChipCount records the chips in play as a hand begins; betting only
+// moves them between stacks and the pot until the showdown
+func (g *Game) startChipCount() {
+	g.handChips = g.chipsInPlay()
+}
+
+// checkChips asserts that no chips appeared or vanished this hand and that
+// the pot is exactly what the players put in
+func (g *Game) checkChips() error {
+	if g.currentStatus == GameStatusWaiting || g.handChips == 0 {
+		return nil
+	}
+
+	if total := g.chipsInPlay(); total != g.handChips {
+		return fmt.Errorf("stacks and pot hold %d chips, the hand started with %d", total, g.handChips)
+	}
+
+	contributed := 0
+	for _, addr := range g.rotationMap {
+		if state, ok := g.playerStates[addr]; ok {
+			contributed += state.TotalBetThisHand
+		}
+	}
+	if contributed != g.currentPot {
+		return fmt.Errorf("players put %d chips in, the pot holds %d", contributed, g.currentPot)
+	}
+	if ledger := g.pot.Total(); ledger != g.currentPot {
+		return fmt.Errorf("pot ledger holds %d chips, the pot holds %d", ledger, g.currentPot)
+	}
+	return nil
+}
+
+// checkPots asserts that the side pots add up to the whole pot
+func (g *Game) checkPots(pots []SidePot) error {
+	total := 0
+	for _, pot := range pots {
+		total += pot.Amount
+	}
+	if total != g.currentPot {
+		return fmt.Errorf("side pots hold %d chips, the pot holds %d", total, g.currentPot)
+	}
+	return nil
+}
+
+// freeze stops all play at the table after an invariant broke. Paying out
+// from a corrupted pot is worse than stopping, so the table waits for an
+// operator to look at the incident snapshot and Unfreeze it.
+func (g *Game) freeze(operation string, violation error) {
+	if g.frozen != "" {
+		return
+	}
+	g.frozen = violation.Error()
+
+	incidentID := newIncidentID()
+	g.log().WithFields(logrus.Fields{
+		"incident":  incidentID,
+		"operation": operation,
+		"violation": violation,
+	}).Error("Chip invariant violated, table frozen")
+
+	snapshotFile := g.saveIncidentSnapshot(incidentID, map[string]interface{}{
+		"operation": operation,
+		"violation": violation.Error(),
+	})
+	g.audit("table_frozen", "table", map[string]interface{}{
+		"incident_id": incidentID,
+		"operation":   operation,
+		"violation":   violation.Error(),
+		"snapshot":    snapshotFile,
+	})
+
+	g.publishEvent(protocol.EventError, protocol.ErrorEvent{
+		Code:    ErrCodeInvariantViolation,
+		Message: "The table is frozen until an operator reviews it (incident " + incidentID + ")",
+		Details: violation.Error(),
+	})
+	g.publishStateUpdate()
+}
+
+// Frozen returns why the table is frozen, or "" when it is not
+func (g *Game) Frozen() string {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.frozen
+}
+
+// Unfreeze voids the hand that broke an invariant, returning what each
+// player put in, and lets play continue
+func (g *Game) Unfreeze(actor string) (refunds map[string]int, err error) {
+	err = g.exec("unfreeze", func() error {
+		refunds, err = g.unfreeze(actor)
+		return err
+	})
+	return refunds, err
+}
+
+func (g *Game) unfreeze(actor string) (refunds map[string]int, err error) {
+	if g.frozen == "" {
+		return nil, fmt.Errorf("table is not frozen")
+	}
+
+	if g.currentStatus != GameStatusWaiting {
+		refunds = g.abandonHand("table frozen: "+g.frozen, actor)
+	}
+	g.audit("table_unfrozen", actor, map[string]interface{}{
+		"violation": g.frozen,
+	})
+	g.frozen = ""
+	g.log().WithField("actor", actor).Warn("Table unfrozen by operator")
+
+	if g.pause == nil && len(g.getReadyActivePlayers()) >= 2 {
+		g.startNewHand()
+	}
+	g.publishStateUpdate()
+	return refunds, nil
+}
@@ -0,0 +1,122 @@
+package game
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// Stages timed into the table's latency histograms. An action's stages add
+// up to its total: mutate is whatever validation, broadcast and
+// persistence leave over.
+const (
+	StageQueue     = "queue"     // a command waiting for the loop
+	StageValidate  = "validate"  // checking an action is allowed
+	StageMutate    = "mutate"    // applying it to the table
+	StageBroadcast = "broadcast" // encoding and sending messages and events
+	StagePersist   = "persist"   // the WAL, the store and snapshots
+	StageShuffle   = "shuffle"   // one player's pass over the deck
+	StageAction    = "action"    // an action from start to finish
+)
+
+var latencyStages = []string{
+	StageQueue, StageValidate, StageMutate, StageBroadcast, StagePersist, StageShuffle, StageAction,
+}
+
+// DefaultLatencyBudget is how long an action may take before it is logged
+const DefaultLatencyBudget = 100 * time.Millisecond
+
+// LatencyReport is the table's stage histograms and how many actions went
+// over the budget
+type LatencyReport struct {
+	BudgetMs   float64                              `json:"budget_ms"`
+	OverBudget uint64                               `json:"over_budget"`
+	Stages     map[string]metrics.HistogramSnapshot `json:"stages"`
+}
+
+// latencyStats times the table's work. The histograms and counters are
+// safe anywhere; trace belongs to the loop.
+type latencyStats struct {
+	stages     map[string]*metrics.Histogram
+	budget     int64 // time.Duration
+	overBudget uint64
+
+	// The stages of the action being applied, if any
+	trace map[string]time.Duration
+}
+
+func newLatencyStats() *latencyStats {
+	stages := make(map[string]*metrics.Histogram, len(latencyStages))
+	for _, stage := range latencyStages {
+		stages[stage] = metrics.NewHistogram(metrics.DefaultLatencyBuckets)
+	}
+	return &latencyStats{stages: stages, budget: int64(DefaultLatencyBudget)}
+}
+
+// SetLatencyBudget sets how long an action may take before it is logged as
+// slow; 0 never logs
+func (g *Game) SetLatencyBudget(budget time.Duration) {
+	atomic.StoreInt64(&g.latency.budget, int64(budget))
+}
+
+// LatencyReport returns the table's latency histograms
+func (g *Game) LatencyReport() LatencyReport {
+	report := LatencyReport{
+		BudgetMs:   float64(atomic.LoadInt64(&g.latency.budget)) / float64(time.Millisecond),
+		OverBudget: atomic.LoadUint64(&g.latency.overBudget),
+		Stages:     make(map[string]metrics.HistogramSnapshot, len(latencyStages)),
+	}
+	for stage, h := range g.latency.stages {
+		report.Stages[stage] = h.Snapshot()
+	}
+	return report
+}
+
+// timeStage records the time since start against a stage, and against the
+// action being applied. Deferred at the top of the function being timed.
+func (g *Game) timeStage(stage string, start time.Time) {
+	d := time.Since(start)
+	g.latency.stages[stage].Observe(d)
+	if g.latency.trace != nil {
+		g.latency.trace[stage] += d
+	}
+}
+
+// actionValidated starts timing an action's effects once it has been
+// checked, begun at start
+func (g *Game) actionValidated(start time.Time) {
+	g.latency.trace = make(map[string]time.Duration)
+	g.timeStage(StageValidate, start)
+}
+
+// actionApplied finishes timing an action, logging it if it went over the
+// budget
+func (g *Game) actionApplied(playerID string, action PlayerAction, start time.Time) {
+	total := time.Since(start)
+	trace := g.latency.trace
+	g.latency.trace = nil
+
+	mutate := total - trace[StageValidate] - trace[StageBroadcast] - trace[StagePersist]
+	if mutate < 0 {
+		mutate = 0
+	}
+	g.latency.stages[StageMutate].Observe(mutate)
+	g.latency.stages[StageAction].Observe(total)
+
+	budget := time.Duration(atomic.LoadInt64(&g.latency.budget))
+	if budget <= 0 || total <= budget {
+		return
+	}
+	atomic.AddUint64(&g.latency.overBudget, 1)
+	g.playerLog(playerID).WithFields(logrus.Fields{
+		"action":       action.String(),
+		"total_ms":     total.Milliseconds(),
+		"validate_ms":  trace[StageValidate].Milliseconds(),
+		"mutate_ms":    mutate.Milliseconds(),
+		"broadcast_ms": trace[StageBroadcast].Milliseconds(),
+		"persist_ms":   trace[StagePersist].Milliseconds(),
+		"budget_ms":    budget.Milliseconds(),
+	}).Warn("Action went over its latency budget")
+}
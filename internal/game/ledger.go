@@ -0,0 +1,38 @@
+package game
+
+import (
+	"time"
+
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+)
+
+// recordLedger notes a movement of a player's chips in the store's local
+// escrow ledger. The ledger stands in for the chain, so it is only kept
+// while the blockchain is not enabled.
+func (g *Game) recordLedger(kind, playerID string, amount int) {
+	if g.blockchainEnabled || g.store == nil || g.replaying || amount == 0 {
+		return
+	}
+
+	entry := persistence.LedgerEntry{
+		TableID:  g.storeTableID,
+		PlayerID: playerID,
+		Kind:     kind,
+		Amount:   amount,
+		At:       time.Now(),
+	}
+	if kind == persistence.LedgerHand {
+		entry.HandID = g.currentHandID()
+		entry.HandUID = g.handUID
+	}
+	if err := g.store.AppendLedgerEntry(entry); err != nil {
+		g.log().Warnf("Failed to record %s of %d for %s in ledger: %v", kind, amount, playerID, err)
+	}
+}
+
+// recordLedgerHand moves each player's locked chips by their net result
+func (g *Game) recordLedgerHand(stacksBefore map[string]int) {
+	for addr, before := range stacksBefore {
+		g.recordLedger(persistence.LedgerHand, addr, g.playerStates[addr].Stack-before)
+	}
+}
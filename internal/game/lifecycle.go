@@ -0,0 +1,72 @@
+package game
+
+import (
+	"time"
+)
+
+// noteOccupancy starts the idle clock when the last player leaves, and
+// stops it when one sits down
+func (g *Game) noteOccupancy() {
+	for _, state := range g.playerStates {
+		if state.IsActive {
+			g.emptySince = time.Time{}
+			return
+		}
+	}
+	if g.emptySince.IsZero() {
+		g.emptySince = time.Now()
+	}
+}
+
+// IdleSince returns since when the table has had no players, and false if
+// it has any or is still playing a hand
+func (g *Game) IdleSince() (time.Time, bool) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if g.emptySince.IsZero() || g.currentStatus != GameStatusWaiting {
+		return time.Time{}, false
+	}
+	return g.emptySince, true
+}
+
+// Closed reports whether the table has been torn down
+func (g *Game) Closed() bool {
+	return g.ctx.Err() != nil
+}
+
+// Close tears the table down for good: any hand in progress is voided, an
+// open settlement batch is submitted, the state is written out and the
+// table's goroutines and timers are stopped. Hands are stored as they end,
+// so the history is complete once it returns.
+func (g *Game) Close(actor string) {
+	// A closed table's loop takes no more commands, so this runs once
+	g.do("close", func() {
+		g.close(actor)
+	})
+}
+
+func (g *Game) close(actor string) {
+	g.closing = true
+
+	if g.currentStatus != GameStatusWaiting {
+		g.abandonHand("table closed", actor)
+	}
+	g.stopTurnClock()
+	g.runOffer = nil
+	if g.rabbit != nil {
+		g.rabbit.zeroKeys()
+		g.rabbit = nil
+	}
+	g.flushSettlementBatch()
+	g.retireDeckKeys()
+	g.persistState()
+
+	g.audit("table_closed", actor, map[string]interface{}{
+		"hands": g.handCount,
+	})
+	g.log().Info("Table closed")
+
+	// Ends the loop and any disconnect timers still waiting
+	g.cancel()
+}
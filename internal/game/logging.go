@@ -0,0 +1,51 @@
+package game
+
+import (
+	"github.com/RedPaladin7/peerpoker/internal/blockchain"
+	"github.com/RedPaladin7/peerpoker/internal/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// The table's logger carries its table ID and, from the first deal on, the
+// UID of the hand in progress or just finished. Both live in atomics so
+// code running without the game lock can log too.
+
+// SetLogger makes entry, usually logging.ForTable, the table's logger
+func (g *Game) SetLogger(entry *logrus.Entry) {
+	g.tableLog.Store(entry)
+
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	g.setHandLogger()
+}
+
+// log returns the table's logger, tagged with the current hand
+func (g *Game) log() *logrus.Entry {
+	if entry, ok := g.handLog.Load().(*logrus.Entry); ok {
+		return entry
+	}
+	return logging.Default()
+}
+
+// playerLog returns the table's logger tagged with a player
+func (g *Game) playerLog(playerID string) *logrus.Entry {
+	return g.log().WithField(logging.FieldPlayer, playerID)
+}
+
+// setHandLogger tags the table's logger with the current hand UID. Call
+// with the lock held whenever handUID changes.
+func (g *Game) setHandLogger() {
+	base, ok := g.tableLog.Load().(*logrus.Entry)
+	if !ok {
+		base = logging.Default()
+	}
+	if g.handUID != "" {
+		base = base.WithField(logging.FieldHand, g.handUID)
+	}
+	g.handLog.Store(base)
+}
+
+// chain returns the blockchain client logging through the table's logger
+func (g *Game) chain() *blockchain.BlockchainClient {
+	return g.blockchain.WithLogger(g.log())
+}
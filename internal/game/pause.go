@@ -0,0 +1,505 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// PauseVoteTimeout is how long a vote to pause or resume the table stays open
+const PauseVoteTimeout = 2 * time.Minute
+
+// MaxPause is how long a pause one node's operator called may hold the
+// table before it resumes on its own. A pause the table voted for lasts
+// until it votes to resume.
+const MaxPause = 10 * time.Minute
+
+// tablePause is a table on hold. No hands are dealt, and a hand in progress
+// is frozen where it stands: actions are refused and its timers stop until
+// the table resumes.
+type tablePause struct {
+	by       string // the operator or peer who paused, or "vote"
+	node     string // the node whose operator paused, "" after a vote
+	reason   string
+	since    time.Time
+	snapshot *persistence.GameSnapshot
+
+	// Hand timers that came due during the pause (see afterFunc)
+	held []func()
+}
+
+// PauseStatus describes a paused table. Status is what the hand was doing
+// when it was paused, and Snapshot the table as it stood.
+type PauseStatus struct {
+	By       string                    `json:"by"`
+	Node     string                    `json:"node,omitempty"`
+	Reason   string                    `json:"reason"`
+	Since    time.Time                 `json:"since"`
+	Status   string                    `json:"status"`
+	Snapshot *persistence.GameSnapshot `json:"snapshot,omitempty"`
+}
+
+// pauseVote is an open vote to pause the table, or to resume it
+type pauseVote struct {
+	pause     bool
+	reason    string
+	proposer  string
+	startedAt time.Time
+	votes     map[string]bool
+}
+
+func (v *pauseVote) action() string {
+	if v.pause {
+		return "pause"
+	}
+	return "resume"
+}
+
+// PauseVoteStatus describes an open vote to pause or resume the table. It
+// passes once Needed seated players approve.
+type PauseVoteStatus struct {
+	Pause     bool      `json:"pause"`
+	Reason    string    `json:"reason"`
+	Proposer  string    `json:"proposer"`
+	StartedAt time.Time `json:"started_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Needed    int       `json:"needed"`
+	Approved  []string  `json:"approved"`
+	Rejected  []string  `json:"rejected"`
+	Pending   []string  `json:"pending"`
+}
+
+// Pause puts the table on hold on an operator's behalf: no new hands, and a
+// hand in progress frozen until Resume or for MaxPause at most. Peers are
+// told, so the hand is held at every seat. Only the dealer's node or the
+// node of the player to act may pause alone; anyone else proposes a vote.
+func (g *Game) Pause(actor, reason string) error {
+	return g.exec("pause", func() error {
+		if g.pause != nil {
+			return fmt.Errorf("table is already paused")
+		}
+		if err := g.mayPause(g.listenAddr); err != nil {
+			return err
+		}
+		if reason == "" {
+			reason = "paused by operator"
+		}
+
+		g.pauseTable(actor, g.listenAddr, reason)
+		g.sendToPlayers(protocol.TypeTablePause, protocol.TablePausePayload{
+			Paused: true,
+			Reason: reason,
+		}, g.getOtherPlayers()...)
+		return nil
+	})
+}
+
+// Resume ends a pause this node's operator started, at every seat. A pause
+// started elsewhere ends from there or by vote.
+func (g *Game) Resume(actor string) error {
+	return g.exec("resume", func() error {
+		if g.pause == nil {
+			return fmt.Errorf("table is not paused")
+		}
+		if g.pause.node == "" {
+			return fmt.Errorf("table was paused by vote; it resumes by vote")
+		}
+		if g.pause.node != g.listenAddr {
+			return fmt.Errorf("table was paused from %s; it resumes from there or by vote", g.pause.node)
+		}
+
+		g.resumeTable(actor)
+		g.sendToPlayers(protocol.TypeTablePause, protocol.TablePausePayload{
+			Paused: false,
+		}, g.getOtherPlayers()...)
+		return nil
+	})
+}
+
+// Paused reports whether the table is on hold
+func (g *Game) Paused() bool {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.pause != nil
+}
+
+// PauseStatus returns the pause the table is under, if any
+func (g *Game) PauseStatus() (PauseStatus, bool) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if g.pause == nil {
+		return PauseStatus{}, false
+	}
+	return PauseStatus{
+		By:       g.pause.by,
+		Node:     g.pause.node,
+		Reason:   g.pause.reason,
+		Since:    g.pause.since,
+		Status:   g.currentStatus.String(),
+		Snapshot: g.pause.snapshot,
+	}, true
+}
+
+// VotePause records a seated player's vote to pause the table (pause) or to
+// resume it. The first approval opens the vote, and a majority of seated
+// players carries it.
+func (g *Game) VotePause(playerID string, pause, approve bool, reason string) error {
+	return g.exec("pause vote", func() error {
+		if err := g.recordPauseVote(playerID, pause, approve, reason); err != nil {
+			return err
+		}
+
+		g.sendToPlayers(protocol.TypePauseVote, protocol.PauseVotePayload{
+			Pause:   pause,
+			Approve: approve,
+			Reason:  reason,
+		}, g.getOtherPlayers()...)
+
+		g.checkPauseVote()
+		return nil
+	})
+}
+
+// PauseVote returns the open vote to pause or resume the table, if any
+func (g *Game) PauseVote() (PauseVoteStatus, bool) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	vote := g.activePauseVote()
+	if vote == nil {
+		return PauseVoteStatus{}, false
+	}
+
+	approved, rejected, pending := g.pauseVoteTally(vote)
+	return PauseVoteStatus{
+		Pause:     vote.pause,
+		Reason:    vote.reason,
+		Proposer:  vote.proposer,
+		StartedAt: vote.startedAt,
+		ExpiresAt: vote.startedAt.Add(PauseVoteTimeout),
+		Needed:    majority(len(g.getReadyPlayers())),
+		Approved:  approved,
+		Rejected:  rejected,
+		Pending:   pending,
+	}, true
+}
+
+// handleMessageTablePause holds the table when a peer's operator pauses it,
+// and lets it go when the same peer resumes. The peer must be the dealer or
+// the player to act, as Pause requires of our own operator.
+func (g *Game) handleMessageTablePause(from string, payload protocol.TablePausePayload) error {
+	return g.exec("table pause message from "+from, func() error {
+		state, ok := g.playerStates[from]
+		if !ok || !state.IsReady {
+			return fmt.Errorf("player %s is not seated", from)
+		}
+
+		if payload.Paused {
+			if g.pause != nil {
+				return fmt.Errorf("table is already paused")
+			}
+			if err := g.mayPause(from); err != nil {
+				return err
+			}
+			reason := payload.Reason
+			if reason == "" {
+				reason = "paused by " + from
+			}
+			g.pauseTable(from, from, reason)
+			return nil
+		}
+
+		if g.pause == nil {
+			return fmt.Errorf("table is not paused")
+		}
+		if g.pause.node != from {
+			return fmt.Errorf("%s did not pause the table", from)
+		}
+		g.resumeTable(from)
+		return nil
+	})
+}
+
+// mayPause checks that a player may pause the table without a vote: they
+// are seated and either hold the button or are the player to act
+func (g *Game) mayPause(playerID string) error {
+	state, ok := g.playerStates[playerID]
+	if !ok || !state.IsReady {
+		return fmt.Errorf("player %s is not seated", playerID)
+	}
+	if g.rotationMap[g.currentDealerID] == playerID || g.currentTurnPlayer() == playerID {
+		return nil
+	}
+	return fmt.Errorf("only the dealer or the player to act may pause the table; others vote to pause")
+}
+
+func (g *Game) handleMessagePauseVote(from string, payload protocol.PauseVotePayload) error {
+	return g.exec("pause vote message from "+from, func() error {
+		if err := g.recordPauseVote(from, payload.Pause, payload.Approve, payload.Reason); err != nil {
+			return err
+		}
+
+		g.checkPauseVote()
+		return nil
+	})
+}
+
+func (g *Game) recordPauseVote(playerID string, pause, approve bool, reason string) error {
+	state, ok := g.playerStates[playerID]
+	if !ok || !state.IsReady {
+		return fmt.Errorf("player %s is not seated", playerID)
+	}
+
+	vote := g.activePauseVote()
+	if vote == nil {
+		if !approve {
+			return fmt.Errorf("no pause vote in progress")
+		}
+		if pause && g.pause != nil {
+			return fmt.Errorf("table is already paused")
+		}
+		if !pause && g.pause == nil {
+			return fmt.Errorf("table is not paused")
+		}
+		if pause && reason == "" {
+			return fmt.Errorf("a reason is required to propose a pause")
+		}
+		vote = &pauseVote{
+			pause:     pause,
+			reason:    reason,
+			proposer:  playerID,
+			startedAt: time.Now(),
+			votes:     make(map[string]bool),
+		}
+		g.pauseVote = vote
+
+		g.audit("pause_proposed", playerID, map[string]interface{}{
+			"pause":  pause,
+			"reason": reason,
+		})
+	} else if vote.pause != pause {
+		return fmt.Errorf("a vote to %s is already in progress", vote.action())
+	}
+
+	vote.votes[playerID] = approve
+	g.log().WithFields(logrus.Fields{
+		"player":  playerID,
+		"vote":    vote.action(),
+		"approve": approve,
+	}).Info("Pause vote received")
+
+	approved, rejected, pending := g.pauseVoteTally(vote)
+	g.publishEvent(protocol.EventPauseVote, protocol.PauseVoteEvent{
+		PlayerID: playerID,
+		Pause:    vote.pause,
+		Approve:  approve,
+		Reason:   vote.reason,
+		Approved: approved,
+		Rejected: rejected,
+		Pending:  pending,
+	})
+	return nil
+}
+
+// activePauseVote returns the open vote, discarding it once expired
+func (g *Game) activePauseVote() *pauseVote {
+	if g.pauseVote == nil {
+		return nil
+	}
+	if time.Since(g.pauseVote.startedAt) > PauseVoteTimeout {
+		return nil
+	}
+	return g.pauseVote
+}
+
+func (g *Game) pauseVoteTally(vote *pauseVote) (approved, rejected, pending []string) {
+	approved = make([]string, 0)
+	rejected = make([]string, 0)
+	pending = make([]string, 0)
+	for _, addr := range g.getReadyPlayers() {
+		approve, voted := vote.votes[addr]
+		switch {
+		case !voted:
+			pending = append(pending, addr)
+		case approve:
+			approved = append(approved, addr)
+		default:
+			rejected = append(rejected, addr)
+		}
+	}
+	sort.Strings(approved)
+	sort.Strings(rejected)
+	sort.Strings(pending)
+	return approved, rejected, pending
+}
+
+// majority is how many of n seated players carry a vote
+func majority(n int) int {
+	return n/2 + 1
+}
+
+// checkPauseVote pauses or resumes the table once a majority approves, and
+// drops the vote once a majority can no longer be reached
+func (g *Game) checkPauseVote() {
+	vote := g.activePauseVote()
+	if vote == nil {
+		return
+	}
+
+	approved, rejected, pending := g.pauseVoteTally(vote)
+	needed := majority(len(approved) + len(rejected) + len(pending))
+	switch {
+	case len(approved) >= needed:
+		g.pauseVote = nil
+		g.audit("pause_vote_passed", "table", map[string]interface{}{
+			"vote":     vote.action(),
+			"reason":   vote.reason,
+			"approved": approved,
+		})
+		if vote.pause && g.pause == nil {
+			g.pauseTable("vote", "", vote.reason)
+		} else if !vote.pause && g.pause != nil {
+			g.resumeTable("vote")
+		}
+
+	case len(approved)+len(pending) < needed:
+		g.pauseVote = nil
+		g.audit("pause_vote_failed", "table", map[string]interface{}{
+			"vote":     vote.action(),
+			"reason":   vote.reason,
+			"rejected": rejected,
+		})
+	}
+}
+
+// pauseTable puts the table on hold and snapshots it as it stands
+func (g *Game) pauseTable(by, node, reason string) {
+	g.pauseVote = nil
+	g.pause = &tablePause{
+		by:     by,
+		node:   node,
+		reason: reason,
+		since:  time.Now(),
+	}
+
+	snap := g.snapshot()
+	snap.Metadata["paused_by"] = by
+	snap.Metadata["pause_reason"] = reason
+	g.pause.snapshot = snap
+	if g.snapshots != nil {
+		if _, err := g.snapshots.Save(g.stateSnapshot()); err != nil {
+			g.log().Warnf("Failed to save state snapshot: %v", err)
+		}
+	}
+
+	g.audit("table_paused", by, map[string]interface{}{
+		"reason": reason,
+		"status": g.currentStatus.String(),
+	})
+	g.log().WithFields(logrus.Fields{
+		"by":     by,
+		"reason": reason,
+		"status": g.currentStatus.String(),
+	}).Warn("Table paused")
+
+	g.publishEvent(protocol.EventTablePaused, protocol.TablePausedEvent{
+		By:        by,
+		Reason:    reason,
+		Status:    g.currentStatus.String(),
+		Timestamp: formatEventTime(g.pause.since),
+	})
+	g.publishStateUpdate()
+
+	// An operator's pause runs out on wall-clock time, which the pause
+	// itself does not stop
+	if node != "" {
+		pause := g.pause
+		time.AfterFunc(MaxPause, func() {
+			g.do("pause limit", func() {
+				if g.pause == pause {
+					g.log().WithField("by", pause.by).Warn("Pause reached its limit, resuming the table")
+					g.resumeTable("pause limit")
+				}
+			})
+		})
+	}
+}
+
+// resumeTable lets play carry on: the player to act gets back the time they
+// had when the pause began, timers held by the pause run down again, and a
+// hand is dealt if the table was waiting for one
+func (g *Game) resumeTable(by string) {
+	pause := g.pause
+	g.pause = nil
+	g.pauseVote = nil
+
+	paused := time.Since(pause.since)
+	g.pausedFor += paused
+	if clock := g.turnClock; clock != nil {
+		clock.started = clock.started.Add(paused)
+		clock.deadline = clock.deadline.Add(paused)
+	}
+	for _, check := range pause.held {
+		check()
+	}
+
+	g.audit("table_resumed", by, map[string]interface{}{
+		"paused_seconds": int(paused / time.Second),
+	})
+	g.log().WithFields(logrus.Fields{
+		"by":     by,
+		"paused": paused,
+	}).Info("Table resumed")
+
+	g.publishEvent(protocol.EventTableResumed, protocol.TableResumedEvent{
+		By:            by,
+		PausedSeconds: int(paused / time.Second),
+		Timestamp:     formatEventTime(time.Now()),
+	})
+
+	if g.currentStatus == GameStatusWaiting && len(g.getReadyActivePlayers()) >= 2 {
+		g.startNewHand()
+	} else if clock := g.turnClock; clock != nil {
+		g.publishTurnEvent(clock.player, clock)
+	}
+	g.publishStateUpdate()
+}
+
+// status is the status the table reports: PAUSED while on hold, whatever
+// the hand underneath is doing
+func (g *Game) status() GameStatus {
+	if g.pause != nil {
+		return GameStatusPaused
+	}
+	return g.currentStatus
+}
+
+// afterFunc runs fn on the loop once the table has been in play for d, like
+// time.AfterFunc with the clock stopped while the table is paused. Hand
+// timers use it so a pause costs nobody their time. Callers are on the loop.
+func (g *Game) afterFunc(d time.Duration, op string, fn func()) {
+	start, pausedAtStart := time.Now(), g.pausedFor
+	if g.pause != nil {
+		pausedAtStart += time.Since(g.pause.since)
+	}
+
+	var check func()
+	check = func() {
+		if g.pause != nil {
+			g.pause.held = append(g.pause.held, check)
+			return
+		}
+		ran := time.Since(start) - (g.pausedFor - pausedAtStart)
+		if ran < d {
+			time.AfterFunc(d-ran, func() { g.do(op, check) })
+			return
+		}
+		fn()
+	}
+	time.AfterFunc(d, func() { g.do(op, check) })
+}
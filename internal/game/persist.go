@@ -0,0 +1,264 @@
+package game
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/blockchain"
+	"github.com/RedPaladin7/peerpoker/internal/crypto"
+	"github.com/RedPaladin7/peerpoker/internal/deck"
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+	"github.com/sirupsen/logrus"
+)
+
+// SetSnapshotManager turns on state snapshots after every change, so the
+// table can be restored when the node restarts
+func (g *Game) SetSnapshotManager(snapshots *persistence.SnapshotManager) {
+	g.do("set snapshot manager", func() {
+		g.snapshots = snapshots
+	})
+}
+
+// StateSnapshot captures everything needed to restore the table, including
+// this node's deck keys and hole cards. Those are sealed with the session
+// key when the snapshot is written.
+func (g *Game) StateSnapshot() *persistence.GameSnapshot {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.stateSnapshot()
+}
+
+func (g *Game) stateSnapshot() *persistence.GameSnapshot {
+	snap := g.snapshot()
+
+	snap.Deck = make([][]byte, len(g.currentDeck))
+	for i, card := range g.currentDeck {
+		snap.Deck[i] = append([]byte{}, card...)
+	}
+	snap.Hand = make([]byte, len(g.myHand))
+	for i, card := range g.myHand {
+		snap.Hand[i] = card.ToByte()
+	}
+	snap.DeckKeys = g.deckKeys
+	snap.RevealedKeys = copyKeys(g.revealedKeys)
+	if g.wal != nil {
+		snap.WALSeq = g.wal.LastSeq()
+	}
+	return snap
+}
+
+// persistState writes a state snapshot when snapshots or a store are on. Callers
+// hold the lock; the snapshot is built under it and written straight away
+// so snapshots on disk follow the order of state changes. Changes already
+// in the write-ahead log are only snapshotted every so often mid-hand.
+func (g *Game) persistState() {
+	if (g.snapshots == nil && g.store == nil) || g.replaying {
+		return
+	}
+	defer g.timeStage(StagePersist, time.Now())
+
+	covered := g.walCovered
+	g.walCovered = false
+	if g.wal != nil && covered && g.currentStatus != GameStatusWaiting && g.walSinceSnapshot < g.walSnapshotEvery {
+		return
+	}
+
+	snap := g.stateSnapshot()
+	g.storeSnapshot(snap)
+	if g.snapshots == nil {
+		return
+	}
+	if _, err := g.snapshots.Save(snap); err != nil {
+		g.log().Warnf("Failed to save state snapshot: %v", err)
+		return
+	}
+
+	if g.wal != nil && g.walSinceSnapshot > 0 {
+		g.walSinceSnapshot = 0
+		if err := g.wal.Compact(snap.WALSeq); err != nil {
+			g.log().Warnf("Failed to compact WAL: %v", err)
+		}
+	}
+}
+
+// Restore loads a state snapshot into a fresh game, before any peers have
+// connected. Players come back as they were and pick up their seats when
+// they reconnect.
+func (g *Game) Restore(snap *persistence.GameSnapshot) error {
+	return g.exec("restore", func() error {
+		if len(g.playerStates) > 0 {
+			return fmt.Errorf("cannot restore into a table that already has players")
+		}
+
+		status, ok := ParseGameStatus(snap.GameStatus)
+		if !ok {
+			return fmt.Errorf("unknown game status in snapshot: %q", snap.GameStatus)
+		}
+
+		var seatDraw *SeatDraw
+		if len(snap.SeatDraw) > 0 {
+			seatDraw = &SeatDraw{}
+			if err := json.Unmarshal(snap.SeatDraw, seatDraw); err != nil {
+				return fmt.Errorf("invalid seat draw in snapshot: %w", err)
+			}
+			// Draws from before commitments were kept, and simulated ones,
+			// carry no proof
+			if len(seatDraw.Commitments) > 0 {
+				if err := VerifySeatDraw(*seatDraw); err != nil {
+					return fmt.Errorf("invalid seat draw in snapshot: %w", err)
+				}
+			}
+		}
+
+		var batch *SettlementBatch
+		if len(snap.SettlementBatch) > 0 {
+			batch = &SettlementBatch{}
+			if err := json.Unmarshal(snap.SettlementBatch, batch); err != nil {
+				return fmt.Errorf("invalid settlement batch in snapshot: %w", err)
+			}
+			id, err := blockchain.HexToGameID(batch.GameID)
+			if err != nil {
+				return fmt.Errorf("invalid settlement batch in snapshot: %w", err)
+			}
+			batch.gameID = id
+		}
+
+		var channel *stateChannel
+		if len(snap.ChannelState) > 0 {
+			state := &ChannelState{}
+			if err := json.Unmarshal(snap.ChannelState, state); err != nil {
+				return fmt.Errorf("invalid channel state in snapshot: %w", err)
+			}
+			id, err := blockchain.HexToGameID(state.GameID)
+			if err != nil {
+				return fmt.Errorf("invalid channel state in snapshot: %w", err)
+			}
+			channel = newStateChannel(id)
+			channel.seq = state.Seq
+			channel.latest = state
+		}
+
+		var chainGameID [32]byte
+		if snap.ChainGameID != "" {
+			id, err := hex.DecodeString(snap.ChainGameID)
+			if err != nil || len(id) != len(chainGameID) {
+				return fmt.Errorf("invalid chain game ID in snapshot: %q", snap.ChainGameID)
+			}
+			copy(chainGameID[:], id)
+		}
+
+		for _, p := range snap.Players {
+			g.playerStates[p.PlayerID] = &PlayerState{
+				ListenAddr:       p.PlayerID,
+				RotationID:       p.RotationID,
+				Seat:             p.Seat,
+				IsReady:          p.IsReady,
+				IsActive:         p.IsActive,
+				IsFolded:         p.IsFolded,
+				CurrentRoundBet:  p.CurrentBet,
+				IsAllIn:          p.IsAllIn,
+				Stack:            p.Stack,
+				TotalBetThisHand: p.TotalBetThisHand,
+				SittingOut:       p.SittingOut,
+				WaitForBB:        p.WaitForBB,
+				MissedSmallBlind: p.MissedSmallBlind,
+				MissedBigBlind:   p.MissedBigBlind,
+				ActedThisRound:   p.ActedThisRound,
+				ActedAtRaiseTo:   p.ActedAtRaiseTo,
+				BuyIn:            p.BuyIn,
+				Rebuys:           p.Rebuys,
+				RebuyCount:       p.RebuyCount,
+			}
+			if p.BuyIn == 0 {
+				// Snapshots from before session accounting: the session is
+				// counted from what the player has now
+				g.playerStates[p.PlayerID].BuyIn = p.Stack + p.TotalBetThisHand
+			}
+		}
+
+		g.rotationMap = make(map[int]string, len(snap.RotationMap))
+		for id, addr := range snap.RotationMap {
+			g.rotationMap[id] = addr
+		}
+		g.nextRotationID = snap.NextRotationID
+		g.currentDealerID = snap.DealerID
+		g.currentPlayerTurn = snap.CurrentTurn
+		g.currentPot = snap.CurrentPot
+		g.highestBet = snap.HighestBet
+		g.lastRaiserID = snap.LastRaiserID
+		g.lastRaiseAmount = snap.LastRaiseAmount
+		g.fullRaiseTo = snap.FullRaiseTo
+		if g.fullRaiseTo == 0 {
+			// Snapshots from before incomplete raises were tracked
+			g.fullRaiseTo = snap.HighestBet
+		}
+
+		// Pots are worked out again from what each player put in
+		g.rebuildPotLedger()
+
+		g.currentDeck = snap.Deck
+		g.communityCards = cardsFromBytes(snap.CommunityCards)
+		g.myHand = cardsFromBytes(snap.Hand)
+		if snap.DeckKeys != nil {
+			g.deckKeys = snap.DeckKeys
+			g.keyEpoch = snap.HandCount
+		}
+		g.revealedKeys = copyKeys(snap.RevealedKeys)
+
+		g.handCount = snap.HandCount
+		g.handUID = snap.HandUID
+		g.setHandLogger()
+		g.handActionSeq = snap.HandActionSeq
+		g.handChips = g.chipsInPlay() // counted again from the restored stacks
+		g.rakeCollected = snap.RakeCollected
+		g.buttonSeat = snap.ButtonSeat
+		g.smallBlindSeat = snap.SmallBlindSeat
+		g.bigBlindSeat = snap.BigBlindSeat
+		g.deadButton = snap.DeadButton
+		g.seatDraw = seatDraw
+		g.blockchainGameID = chainGameID
+		g.settlementBatch = batch
+		g.channel = channel
+
+		g.setStatus(status)
+		g.turnStartedAt = time.Now()
+
+		if snap.SecretsRedacted && status != GameStatusWaiting {
+			// The hand's cards were sealed by an earlier run of the node and
+			// are gone with it; the hand cannot be finished
+			g.abandonHand("hand secrets unavailable after restart", "table")
+		}
+
+		g.log().WithFields(logrus.Fields{
+			"status":   status.String(),
+			"players":  len(snap.Players),
+			"pot":      snap.CurrentPot,
+			"taken_at": snap.Timestamp,
+		}).Info("Restored table state from snapshot")
+
+		g.audit("state_restored", "table", map[string]interface{}{
+			"status":   status.String(),
+			"players":  len(snap.Players),
+			"taken_at": snap.Timestamp,
+		})
+		return nil
+	})
+}
+
+func copyKeys(keys map[string]*crypto.CardKeys) map[string]*crypto.CardKeys {
+	copied := make(map[string]*crypto.CardKeys, len(keys))
+	for addr, k := range keys {
+		copied[addr] = k
+	}
+	return copied
+}
+
+func cardsFromBytes(data []byte) []deck.Card {
+	cards := make([]deck.Card, len(data))
+	for i, b := range data {
+		cards[i] = deck.NewCardFromByte(b)
+	}
+	return cards
+}
@@ -2,14 +2,15 @@ package game
 
 import (
 	"fmt"
-	"sort"
 
-	"github.com/sirupsen/logrus"
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
 )
 
 type PlayerState struct {
 	ListenAddr       string
 	RotationID       int
+	Seat             int // 1-based; 0 until chosen or assigned
 	IsReady          bool
 	IsActive         bool
 	IsFolded         bool
@@ -17,11 +18,30 @@ type PlayerState struct {
 	IsAllIn          bool
 	Stack            int
 	TotalBetThisHand int
+
+	// Whether the player has acted this betting round, and the full raise
+	// they last acted on; posting a blind is not acting
+	ActedThisRound bool
+	ActedAtRaiseTo int
+
+	// Out of the deal by choice, or until the big blind comes round, and
+	// the blinds missed meanwhile (see blinds.go)
+	SittingOut       bool
+	WaitForBB        bool
+	MissedSmallBlind bool
+	MissedBigBlind   bool
+
+	// Session accounting: the chips brought to the table on joining, and
+	// those added since by rebuys and add-ons (see session.go)
+	BuyIn      int
+	Rebuys     int
+	RebuyCount int
 }
 
 type PlayerStateResponse struct {
 	PlayerID      string `json:"player_id"`
 	RotationID    int    `json:"rotation_id"`
+	Seat          int    `json:"seat"`
 	Stack         int    `json:"stack"`
 	CurrentBet    int    `json:"current_bet"`
 	IsActive      bool   `json:"is_active"`
@@ -30,6 +50,9 @@ type PlayerStateResponse struct {
 	IsReady       bool   `json:"is_ready"`
 	IsDealer      bool   `json:"is_dealer"`
 	IsCurrentTurn bool   `json:"is_current_turn"`
+
+	// The player's profile, when their signing address has one
+	Profile *protocol.PlayerProfile `json:"profile,omitempty"`
 }
 
 type TableStateResponse struct {
@@ -39,6 +62,10 @@ type TableStateResponse struct {
 	Pot            int            `json:"pot"`
 	HighestBet     int            `json:"highest_bet"`
 	MinRaise       int            `json:"min_raise"`
+	AmountToCall   int            `json:"amount_to_call"`
+	PotOdds        float64        `json:"pot_odds"` // call / (pot + call)
+	EffectiveStack int            `json:"effective_stack"`
+	MaxBet         int            `json:"max_bet"`
 	ValidActions   []string       `json:"valid_actions"`
 	IsMyTurn       bool           `json:"is_my_turn"`
 	MyStack        int            `json:"my_stack"`
@@ -57,12 +84,19 @@ type CardResponse struct {
 
 // AddPlayer adds a new player to the game
 func (g *Game) AddPlayer(addr string) {
-	g.lock.Lock()
-	defer g.lock.Unlock()
+	g.do("add player", func() {
+		g.addPlayer(addr)
+	})
+}
 
-	if _, exists := g.playerStates[addr]; exists {
-		g.playerStates[addr].IsActive = true
-		logrus.Infof("Player %s reconnected", addr)
+func (g *Game) addPlayer(addr string) {
+	if state, exists := g.playerStates[addr]; exists {
+		if !state.IsActive {
+			g.recordLedger(persistence.LedgerBuyIn, addr, state.Stack)
+		}
+		state.IsActive = true
+		g.noteOccupancy()
+		g.log().Infof("Player %s reconnected", addr)
 		return
 	}
 
@@ -70,187 +104,107 @@ func (g *Game) AddPlayer(addr string) {
 		ListenAddr: addr,
 		IsActive:   true,
 		Stack:      1000,
+		BuyIn:      1000,
 	}
 
-	logrus.Infof("Player %s added to game", addr)
+	g.recordLedger(persistence.LedgerBuyIn, addr, g.playerStates[addr].Stack)
+	g.noteOccupancy()
+
+	g.log().Infof("Player %s added to game", addr)
+
+	joined := protocol.PlayerJoinedEvent{
+		PlayerID: addr,
+		Stack:    g.playerStates[addr].Stack,
+		Profile:  g.profileOf(addr),
+	}
+	if joined.Profile != nil {
+		joined.PlayerName = joined.Profile.Name
+	}
+	g.publishEvent(protocol.EventPlayerJoined, joined)
 }
 
 // RemovePlayer removes a player from the game
 func (g *Game) RemovePlayer(addr string) {
-	g.lock.Lock()
-	defer g.lock.Unlock()
+	g.do("remove player", func() {
+		g.removePlayer(addr, "removed")
+	})
+}
+
+// KickPlayer removes a player on an operator's behalf
+func (g *Game) KickPlayer(addr, actor string) error {
+	return g.exec("kick player", func() error {
+		if _, ok := g.playerStates[addr]; !ok {
+			return fmt.Errorf("player %s not found", addr)
+		}
+
+		g.audit("player_kicked", actor, map[string]interface{}{
+			"player": addr,
+		})
+		g.removePlayer(addr, "kicked")
+		return nil
+	})
+}
 
+func (g *Game) removePlayer(addr, reason string) {
 	if state, ok := g.playerStates[addr]; ok {
+		if state.IsActive {
+			g.recordLedger(persistence.LedgerCashOut, addr, state.Stack)
+		}
 		state.IsActive = false
 		state.IsFolded = true
-		logrus.Infof("Player %s removed from game", addr)
+		g.pot.Fold(addr)
+		state.IsReady = false
+		state.Seat = 0
+		delete(g.straddlers, addr)
+		delete(g.sitOutRequests, addr)
+		delete(g.timeBanks, addr)
+		delete(g.timedOut, addr)
+		g.noteOccupancy()
+		g.log().Infof("Player %s %s from game", addr, reason)
+
+		g.publishEvent(protocol.EventPlayerLeft, protocol.PlayerLeftEvent{
+			PlayerID: addr,
+			Reason:   reason,
+		})
 
 		// Check if we need to end the hand
 		if g.currentStatus != GameStatusWaiting {
 			g.checkRoundEnd()
 		}
+		g.seatFromWaitlist()
+		g.publishStateUpdate()
 	}
 }
 
 // SetPlayerReady marks a player as ready
 func (g *Game) SetPlayerReady(addr string) error {
-	g.lock.Lock()
-	defer g.lock.Unlock()
-
-	state, ok := g.playerStates[addr]
-	if !ok {
-		return fmt.Errorf("player %s not found", addr)
-	}
-
-	if !state.IsReady {
-		state.RotationID = g.nextRotationID
-		g.rotationMap[state.RotationID] = addr
-		g.nextRotationID++
-		state.IsReady = true
-		logrus.Infof("Player %s is ready (Rotation ID: %d)", addr, state.RotationID)
-	}
-
-	// Broadcast ready status
-	g.sendToPlayers(protocol.TypePlayerReady, protocol.PlayerReadyPayload{
-		PlayerID: addr,
-	}, g.getOtherPlayers()...)
-
-	// Check if we can start the game
-	if len(g.getReadyPlayers()) >= 2 && g.currentStatus == GameStatusWaiting {
-		g.StartNewHand()
-	}
-
-	return nil
-}
-
-// StartNewHand starts a new poker hand
-func (g *Game) StartNewHand() {
-	activeReadyPlayers := g.getReadyActivePlayers()
-	if len(activeReadyPlayers) < 2 {
-		g.setStatus(GameStatusWaiting)
-		logrus.Warn("Not enough players to start a hand")
-		return
-	}
-
-	logrus.Info("=== Starting new hand ===")
-
-	// Reset state
-	g.rotationMap = make(map[int]string)
-	g.nextRotationID = 0
-	g.myHand = make([]deck.Card, 0, 2)
-	g.communityCards = make([]deck.Card, 0, 5)
-	g.lastRaiseAmount = BigBlind
-	g.currentPot = 0
-	g.highestBet = 0
-	g.sidePots = []SidePot{}
-	g.revealedKeys = make(map[string]*crypto.CardKeys)
-	g.foldedPlayerKeys = make(map[string]*crypto.CardKeys)
-
-	// Assign rotation IDs
-	sort.Strings(activeReadyPlayers)
-	for _, addr := range activeReadyPlayers {
-		state := g.playerStates[addr]
-		state.RotationID = g.nextRotationID
-		state.IsFolded = false
-		state.CurrentRoundBet = 0
-		state.TotalBetThisHand = 0
-		state.IsAllIn = false
-		g.rotationMap[state.RotationID] = addr
-		g.nextRotationID++
-	}
-
-	// Advance dealer
-	g.advanceDealer()
-
-	// Post blinds
-	g.postBlinds()
-
-	// Set status
-	g.setStatus(GameStatusDealing)
-
-	// Start shuffle and deal
-	g.InitiateShuffleAndDeal()
-}
-
-// Post blinds
-func (g *Game) postBlinds() {
-	activeCount := len(g.getReadyActivePlayers())
-
-	if activeCount == 2 {
-		// Heads-up: dealer posts small blind
-		sbID := g.currentDealerID
-		sbAddr := g.rotationMap[sbID]
-		g.updatePlayerState(sbAddr, PlayerActionBet, SmallBlind)
-		logrus.Infof("Player %s (dealer) posted small blind: %d", sbAddr, SmallBlind)
-
-		bbID := g.getNextPlayerID(sbID)
-		bbAddr := g.rotationMap[bbID]
-		g.updatePlayerState(bbAddr, PlayerActionBet, BigBlind)
-		logrus.Infof("Player %s posted big blind: %d", bbAddr, BigBlind)
-
-		g.currentPlayerTurn = sbID
-		g.lastRaiserID = bbID
-	} else {
-		// Multi-way: small blind is left of dealer
-		sbID := g.getNextActivePlayerID(g.currentDealerID)
-		sbAddr := g.rotationMap[sbID]
-		g.updatePlayerState(sbAddr, PlayerActionBet, SmallBlind)
-		logrus.Infof("Player %s posted small blind: %d", sbAddr, SmallBlind)
-
-		bbID := g.getNextActivePlayerID(sbID)
-		bbAddr := g.rotationMap[bbID]
-		g.updatePlayerState(bbAddr, PlayerActionBet, BigBlind)
-		logrus.Infof("Player %s posted big blind: %d", bbAddr, BigBlind)
-
-		g.currentPlayerTurn = g.getNextActivePlayerID(bbID)
-		g.lastRaiserID = bbID
-	}
-
-	g.lastRaiseAmount = BigBlind
-}
-
-// Update player state based on action
-func (g *Game) updatePlayerState(addr string, action PlayerAction, value int) {
-	state := g.playerStates[addr]
-
-	switch action {
-	case PlayerActionFold:
-		state.IsFolded = true
-
-	case PlayerActionBet, PlayerActionRaise:
-		actualBet := value
-		if actualBet > state.Stack {
-			actualBet = state.Stack
-			state.IsAllIn = true
-			logrus.Infof("Player %s is ALL-IN!", addr)
+	return g.exec("player ready", func() error {
+		state, ok := g.playerStates[addr]
+		if !ok {
+			return fmt.Errorf("player %s not found", addr)
 		}
-
-		amountToAdd := actualBet - state.CurrentRoundBet
-		state.CurrentRoundBet = actualBet
-		state.TotalBetThisHand += amountToAdd
-		g.currentPot += amountToAdd
-		state.Stack -= amountToAdd
-
-		if state.CurrentRoundBet > g.highestBet {
-			g.highestBet = state.CurrentRoundBet
-			g.lastRaiserID = state.RotationID
+		if g.closing && !state.IsReady {
+			return errTableClosing
 		}
 
-	case PlayerActionCall:
-		amountNeeded := g.highestBet - state.CurrentRoundBet
-		actualCall := amountNeeded
-		if actualCall > state.Stack {
-			actualCall = state.Stack
-			state.IsAllIn = true
-			logrus.Infof("Player %s is ALL-IN!", addr)
+		if !state.IsReady {
+			state.RotationID = g.nextRotationID
+			g.rotationMap[state.RotationID] = addr
+			g.nextRotationID++
+			state.IsReady = true
+			g.log().Infof("Player %s is ready (Rotation ID: %d)", addr, state.RotationID)
 		}
 
-		state.CurrentRoundBet += actualCall
-		state.TotalBetThisHand += actualCall
-		g.currentPot += actualCall
-		state.Stack -= actualCall
+		// Broadcast ready status
+		g.sendToPlayers(protocol.TypePlayerReady, protocol.PlayerReadyPayload{
+			PlayerID: addr,
+		}, g.getOtherPlayers()...)
 
-	case PlayerActionCheck:
-		// No state change
-	}
+		// Check if we can start the game
+		if len(g.getReadyPlayers()) >= 2 && g.currentStatus == GameStatusWaiting {
+			g.startNewHand()
+		}
+
+		return nil
+	})
 }
@@ -3,68 +3,162 @@ package game
 import (
 	"sort"
 
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
 	"github.com/sirupsen/logrus"
 )
 
-// calculateSidePots calculates all side pots for the hand
-func (g *Game) calculateSidePots() []SidePot {
-	type PlayerContribution struct {
-		Addr   string
-		Amount int
+// PotLedger records every chip put into the pot this hand, by player and
+// street. Chips stay in the ledger when their owner folds or leaves, so
+// dead money ends up in the pots it was bet into.
+type PotLedger struct {
+	contributed map[string]int
+	folded      map[string]bool
+	streets     []map[string]int // the last entry is the street being bet
+}
+
+// NewPotLedger returns an empty ledger for a new hand
+func NewPotLedger() *PotLedger {
+	return &PotLedger{
+		contributed: make(map[string]int),
+		folded:      make(map[string]bool),
+		streets:     []map[string]int{make(map[string]int)},
 	}
+}
 
-	contributions := []PlayerContribution{}
-	for addr, state := range g.playerStates {
-		if state.IsActive && state.TotalBetThisHand > 0 {
-			contributions = append(contributions, PlayerContribution{
-				Addr:   addr,
-				Amount: state.TotalBetThisHand,
-			})
-		}
+// Add puts a player's chips into the pot on the current street
+func (l *PotLedger) Add(player string, amount int) {
+	if amount <= 0 {
+		return
 	}
+	l.contributed[player] += amount
+	l.streets[len(l.streets)-1][player] += amount
+}
 
-	// Sort by contribution amount
-	sort.Slice(contributions, func(i, j int) bool {
-		return contributions[i].Amount < contributions[j].Amount
-	})
+// Fold gives up a player's claim to the pot; their chips stay in it
+func (l *PotLedger) Fold(player string) {
+	l.folded[player] = true
+}
 
-	pots := []SidePot{}
-	previousCap := 0
+// EndStreet closes the betting on the current street
+func (l *PotLedger) EndStreet() {
+	l.streets = append(l.streets, make(map[string]int))
+}
 
-	for i, contrib := range contributions {
-		if contrib.Amount <= previousCap {
-			continue
-		}
+// Total is every chip in the pot
+func (l *PotLedger) Total() int {
+	total := 0
+	for _, amount := range l.contributed {
+		total += amount
+	}
+	return total
+}
 
-		cap := contrib.Amount
-		eligible := []string{}
-		potAmount := 0
+// Contributed returns what a player has put in this hand
+func (l *PotLedger) Contributed(player string) int {
+	return l.contributed[player]
+}
 
-		// All players from this position onwards are eligible
-		for j := i; j < len(contributions); j++ {
-			eligible = append(eligible, contributions[j].Addr)
-			potAmount += (cap - previousCap)
-		}
+// Street returns what each player put in on a street, counting from 0
+// for the first betting round
+func (l *PotLedger) Street(n int) map[string]int {
+	if n < 0 || n >= len(l.streets) {
+		return map[string]int{}
+	}
+	street := make(map[string]int, len(l.streets[n]))
+	for player, amount := range l.streets[n] {
+		street[player] = amount
+	}
+	return street
+}
 
-		if potAmount > 0 {
-			pots = append(pots, SidePot{
-				Amount:          potAmount,
-				Cap:             cap,
-				EligiblePlayers: eligible,
-			})
+// Pots splits the ledger into a main pot and side pots. Each pot is capped
+// at what a live player put in, and only live players who covered the cap
+// may win it; folded players' chips fill the pots up to their amount. Chips
+// above every live player's total, which nobody can win, join the last pot.
+func (l *PotLedger) Pots() []SidePot {
+	levels := []int{}
+	seen := make(map[int]bool)
+	for player, amount := range l.contributed {
+		if !l.folded[player] && amount > 0 && !seen[amount] {
+			seen[amount] = true
+			levels = append(levels, amount)
 		}
+	}
+	sort.Ints(levels)
 
+	pots := []SidePot{}
+	previousCap := 0
+	for _, cap := range levels {
+		pot := SidePot{Cap: cap, EligiblePlayers: []string{}}
+		for player, amount := range l.contributed {
+			pot.Amount += clampChips(amount, previousCap, cap)
+			if !l.folded[player] && amount >= cap {
+				pot.EligiblePlayers = append(pot.EligiblePlayers, player)
+			}
+		}
+		sort.Strings(pot.EligiblePlayers)
+
+		// A level only the same players reach adds to the pot below it
+		if n := len(pots); n > 0 && len(pots[n-1].EligiblePlayers) == len(pot.EligiblePlayers) {
+			pots[n-1].Amount += pot.Amount
+			pots[n-1].Cap = cap
+		} else {
+			pots = append(pots, pot)
+		}
 		previousCap = cap
 	}
 
+	dead := 0
+	for _, amount := range l.contributed {
+		if amount > previousCap {
+			dead += amount - previousCap
+		}
+	}
+	if dead > 0 {
+		if len(pots) == 0 {
+			pots = append(pots, SidePot{EligiblePlayers: []string{}})
+		}
+		pots[len(pots)-1].Amount += dead
+	}
+
 	return pots
 }
 
-// distributePot distributes a pot among winners
-func (g *Game) distributePot(amount int, winners []*PlayerHand, potNum int) {
+// clampChips is the part of a contribution that falls between two caps
+func clampChips(amount, lower, upper int) int {
+	if amount <= lower {
+		return 0
+	}
+	if amount > upper {
+		amount = upper
+	}
+	return amount - lower
+}
+
+// rebuildPotLedger fills a ledger from players' hand totals, for a hand
+// restored from a snapshot; the split by street is lost
+func (g *Game) rebuildPotLedger() {
+	g.pot = NewPotLedger()
+	for addr, state := range g.playerStates {
+		g.pot.Add(addr, state.TotalBetThisHand)
+		if state.IsFolded {
+			g.pot.Fold(addr)
+		}
+	}
+}
+
+// calculateSidePots calculates all side pots for the hand
+func (g *Game) calculateSidePots() []SidePot {
+	return g.pot.Pots()
+}
+
+// distributePot distributes a pot among winners, returning what each was
+// credited in winners' order
+func (g *Game) distributePot(amount int, winners []*PlayerHand, potNum int) []int {
 	share := amount / len(winners)
 	remainder := amount % len(winners)
 
+	credited := make([]int, len(winners))
 	for i, winner := range winners {
 		winAmount := share
 		if i == 0 {
@@ -73,8 +167,10 @@ func (g *Game) distributePot(amount int, winners []*PlayerHand, potNum int) {
 
 		state := g.playerStates[winner.Addr]
 		state.Stack += winAmount
+		credited[i] = winAmount
+		g.recordPotAward(winner.Addr, potNum, winAmount)
 
-		logrus.WithFields(logrus.Fields{
+		g.log().WithFields(logrus.Fields{
 			"pot":       potNum,
 			"player":    winner.Addr,
 			"hand":      winner.HandName,
@@ -83,4 +179,14 @@ func (g *Game) distributePot(amount int, winners []*PlayerHand, potNum int) {
 			"new_stack": state.Stack,
 		}).Info("Pot distributed")
 	}
+	return credited
+}
+
+// recordPotAward notes winnings for the next state diff (pot 0 is the main pot)
+func (g *Game) recordPotAward(addr string, potNum, amount int) {
+	if potNum == 0 {
+		potNum = 1
+	}
+	g.potAwards = append(g.potAwards, potAward{addr: addr, pot: potNum, amount: amount})
+	g.logWAL(persistence.WALPayout, walPayout{Player: addr, Pot: potNum, Amount: amount})
 }
@@ -0,0 +1,106 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/RedPaladin7/peerpoker/internal/profile"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+)
+
+// SetProfiles keeps players' profiles in a store, so tables show them in
+// place of addresses. Profiles need message signing: each is kept under the
+// address that signed it.
+func (g *Game) SetProfiles(store *profile.Store) {
+	g.do("set profiles", func() {
+		g.profiles = store
+	})
+}
+
+// Profile returns the local player's profile, if they have one
+func (g *Game) Profile() (protocol.PlayerProfile, bool) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	if p := g.profileOf(g.listenAddr); p != nil {
+		return *p, true
+	}
+	return protocol.PlayerProfile{}, false
+}
+
+// SetProfile saves the local player's profile under their signing address
+// and sends it to the table
+func (g *Game) SetProfile(p protocol.PlayerProfile) (protocol.PlayerProfile, error) {
+	var saved protocol.PlayerProfile
+	err := g.exec("set profile", func() error {
+		if g.profiles == nil {
+			return fmt.Errorf("profiles are not enabled on this node")
+		}
+		if g.msgSigner == nil {
+			return fmt.Errorf("profiles need message signing to tie them to an address")
+		}
+
+		address := g.msgSigner.Address()
+		if err := g.profiles.Put(address, p); err != nil {
+			return err
+		}
+		saved, _ = g.profiles.Get(address)
+
+		g.log().WithField("address", address).Infof("Profile set to %q", saved.Name)
+		if err := g.sendToPlayers(protocol.TypeProfile, saved); err != nil {
+			g.log().Warnf("Failed to send profile: %v", err)
+		}
+		g.publishStateUpdate()
+		return nil
+	})
+	return saved, err
+}
+
+func (g *Game) handleMessageProfile(from string, payload protocol.PlayerProfile) error {
+	return g.exec("profile message from "+from, func() error {
+		if err := g.keepProfile(from, payload); err != nil {
+			return err
+		}
+		g.publishStateUpdate()
+		return nil
+	})
+}
+
+// keepProfile stores a peer's profile under the address that signed the
+// peer's messages. Unsigned profiles could claim anyone's, so are dropped.
+func (g *Game) keepProfile(peer string, p protocol.PlayerProfile) error {
+	if g.profiles == nil {
+		return nil
+	}
+	if g.msgVerifier == nil {
+		return fmt.Errorf("profile from %s is not signed", peer)
+	}
+	address, ok := g.msgVerifier.SenderFor(peer)
+	if !ok {
+		return fmt.Errorf("profile from %s is not signed", peer)
+	}
+	return g.profiles.Put(address, p)
+}
+
+// profileOf returns a player's profile, looked up by the address that signs
+// their messages: ours for the local player, the verified one for peers
+func (g *Game) profileOf(playerID string) *protocol.PlayerProfile {
+	if g.profiles == nil {
+		return nil
+	}
+
+	var address string
+	switch {
+	case playerID == g.listenAddr && g.msgSigner != nil:
+		address = g.msgSigner.Address()
+	case g.msgVerifier != nil:
+		address, _ = g.msgVerifier.SenderFor(playerID)
+	}
+	if address == "" {
+		return nil
+	}
+
+	p, ok := g.profiles.Get(address)
+	if !ok {
+		return nil
+	}
+	return &p
+}
@@ -0,0 +1,216 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/crypto"
+	"github.com/RedPaladin7/peerpoker/internal/deck"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// RabbitHuntTimeout is how long the players dealt into a hand have to help
+// decrypt the undealt board; a hunt still missing a share by then is dropped
+const RabbitHuntTimeout = 10 * time.Second
+
+// rabbitHunt holds what is needed to decrypt the rest of the board after a
+// hand ended early. The deck and keys are copied because the table moves on
+// to the next hand, zeroing its keys, while the hunt is open.
+type rabbitHunt struct {
+	handUID   string
+	deck      [][]byte
+	keys      []*crypto.CardKeys
+	own       *crypto.CardKeys
+	board     []deck.Card
+	indices   []int
+	players   map[string]bool
+	shares    map[string]bool
+	expiresAt time.Time
+}
+
+// RabbitHunt returns the open hunt's hand, the players asked and who has
+// shared so far, if one is waiting on shares
+func (g *Game) RabbitHunt() (handUID string, players []string, shared []string, expiresAt time.Time, ok bool) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	hunt := g.rabbit
+	if hunt == nil {
+		return "", nil, nil, time.Time{}, false
+	}
+	for id := 0; id < g.nextRotationID; id++ {
+		addr := g.rotationMap[id]
+		if hunt.players[addr] {
+			players = append(players, addr)
+			if hunt.shares[addr] {
+				shared = append(shared, addr)
+			}
+		}
+	}
+	return hunt.handUID, players, shared, hunt.expiresAt, true
+}
+
+// offerRabbitHunt starts decrypting the board cards a hand never reached,
+// when the table allows it. Called as a hand is won uncontested, before the
+// hand state is reset.
+func (g *Game) offerRabbitHunt() {
+	if !g.tableConfig.RabbitHunt || len(g.communityCards) >= 5 || len(g.currentDeck) == 0 {
+		return
+	}
+
+	dealtIn := g.getReadyActivePlayers()
+	start := g.boardStart() + len(g.communityCards)
+	end := g.boardStart() + 5
+	if end > len(g.currentDeck) {
+		g.log().Warn("Not enough cards in deck for a rabbit hunt")
+		return
+	}
+
+	hunt := &rabbitHunt{
+		handUID:   g.handUID,
+		deck:      append([][]byte{}, g.currentDeck...),
+		board:     append([]deck.Card{}, g.communityCards...),
+		players:   make(map[string]bool, len(dealtIn)),
+		shares:    make(map[string]bool, len(dealtIn)),
+		expiresAt: time.Now().Add(RabbitHuntTimeout),
+	}
+	for idx := start; idx < end; idx++ {
+		hunt.indices = append(hunt.indices, idx)
+	}
+	for _, keys := range g.revealedKeys {
+		hunt.keys = append(hunt.keys, keys.Clone())
+	}
+	if g.deckKeys != nil {
+		hunt.own = g.deckKeys.Clone()
+	}
+	for _, addr := range dealtIn {
+		hunt.players[addr] = true
+	}
+	g.rabbit = hunt
+
+	g.log().WithFields(logrus.Fields{
+		"hand":    hunt.handUID,
+		"indices": hunt.indices,
+	}).Info("Rabbit hunting the undealt board")
+
+	// Our share goes out straight away; the board is shown once every
+	// player dealt in has sent theirs
+	if hunt.players[g.listenAddr] {
+		g.sendToPlayers(protocol.TypeRabbitHunt, protocol.RabbitHuntPayload{
+			HandUID:     hunt.handUID,
+			CardIndices: hunt.indices,
+		}, g.getOtherPlayers()...)
+		hunt.shares[g.listenAddr] = true
+	}
+	g.checkRabbitHunt()
+
+	if g.rabbit != hunt {
+		return
+	}
+	g.afterFunc(RabbitHuntTimeout, "rabbit hunt timeout", func() {
+		if g.rabbit == hunt {
+			g.log().WithField("hand", hunt.handUID).Info("Rabbit hunt expired before every player shared")
+			g.rabbit = nil
+			hunt.zeroKeys()
+		}
+	})
+}
+
+func (g *Game) handleMessageRabbitHunt(from string, payload protocol.RabbitHuntPayload) error {
+	return g.exec("rabbit hunt message from "+from, func() error {
+		hunt := g.rabbit
+		if hunt == nil || hunt.handUID != payload.HandUID {
+			return fmt.Errorf("no rabbit hunt open for hand %s", payload.HandUID)
+		}
+		if !hunt.players[from] {
+			return fmt.Errorf("player %s was not dealt into hand %s", from, payload.HandUID)
+		}
+		if !sameIndices(hunt.indices, payload.CardIndices) {
+			return fmt.Errorf("rabbit hunt share from %s covers cards %v, expected %v", from, payload.CardIndices, hunt.indices)
+		}
+
+		hunt.shares[from] = true
+		g.checkRabbitHunt()
+		return nil
+	})
+}
+
+// checkRabbitHunt decrypts and shows the undealt board once every player has shared
+func (g *Game) checkRabbitHunt() {
+	hunt := g.rabbit
+	for addr := range hunt.players {
+		if !hunt.shares[addr] {
+			return
+		}
+	}
+	g.rabbit = nil
+	defer hunt.zeroKeys()
+
+	rabbit := make([]deck.Card, 0, len(hunt.indices))
+	for _, idx := range hunt.indices {
+		card, ok := g.decryptRabbitCard(hunt, idx)
+		if !ok {
+			g.log().WithField("index", idx).Warn("Failed to decrypt rabbit hunt card")
+			return
+		}
+		rabbit = append(rabbit, card)
+	}
+
+	g.log().WithFields(logrus.Fields{
+		"hand":   hunt.handUID,
+		"rabbit": rabbit,
+	}).Info("Rabbit hunt revealed")
+
+	g.publishEvent(protocol.EventRabbitHunt, protocol.RabbitHuntEvent{
+		HandUID: hunt.handUID,
+		Board:   toCardData(hunt.board),
+		Rabbit:  toCardData(rabbit),
+	})
+}
+
+// decryptRabbitCard decrypts a card from the hunt's copy of the deck, the
+// same way decryptBoardCard does for the live one
+func (g *Game) decryptRabbitCard(hunt *rabbitHunt, idx int) (deck.Card, bool) {
+	if idx >= len(hunt.deck) || len(hunt.deck[idx]) == 0 {
+		return deck.Card{}, false
+	}
+	if g.sim != nil {
+		return deck.NewCardFromByte(hunt.deck[idx][0]), true
+	}
+
+	decrypted := hunt.deck[idx]
+	for _, keys := range hunt.keys {
+		decrypted = keys.Decrypt(decrypted)
+	}
+	if hunt.own != nil {
+		decrypted = hunt.own.Decrypt(decrypted)
+	}
+
+	if len(decrypted) == 0 {
+		return deck.Card{}, false
+	}
+	return deck.NewCardFromByte(decrypted[0]), true
+}
+
+// zeroKeys wipes the hunt's copies of the hand's keys once it is over
+func (hunt *rabbitHunt) zeroKeys() {
+	for _, keys := range hunt.keys {
+		keys.Zero()
+	}
+	if hunt.own != nil {
+		hunt.own.Zero()
+	}
+}
+
+func sameIndices(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
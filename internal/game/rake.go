@@ -0,0 +1,130 @@
+package game
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/RedPaladin7/peerpoker/internal/blockchain"
+	"github.com/sirupsen/logrus"
+)
+
+// RakeConfig is the house's cut of each pot. Every node at the table must
+// use the same rules, or their stacks drift apart.
+type RakeConfig struct {
+	Percent      int  `json:"percent"`         // percent of the pot, 0 for no rake
+	Cap          int  `json:"cap"`             // most taken from one hand, 0 for no cap
+	NoFlopNoDrop bool `json:"no_flop_no_drop"` // no rake from hands that end before the flop
+
+	// Escrow address the rake is paid to in on-chain settlement; without
+	// one it stays in escrow
+	Recipient string `json:"recipient,omitempty"`
+}
+
+// Enabled reports whether any rake is taken
+func (cfg RakeConfig) Enabled() bool {
+	return cfg.Percent > 0
+}
+
+func (cfg RakeConfig) validate() error {
+	if cfg.Percent < 0 || cfg.Percent > 100 {
+		return fmt.Errorf("rake percent %d must be between 0 and 100", cfg.Percent)
+	}
+	if cfg.Cap < 0 {
+		return fmt.Errorf("rake cap cannot be negative")
+	}
+	if cfg.Recipient != "" && !blockchain.IsValidAddress(cfg.Recipient) {
+		return fmt.Errorf("invalid rake recipient: %s", cfg.Recipient)
+	}
+	return nil
+}
+
+// amount is the rake on a pot, computed the same way as the escrow
+// contract's platform fee
+func (cfg RakeConfig) amount(pot int, sawFlop bool) int {
+	if !cfg.Enabled() || pot <= 0 || (cfg.NoFlopNoDrop && !sawFlop) {
+		return 0
+	}
+
+	rake := int(blockchain.CalculatePlatformFee(big.NewInt(int64(pot)), cfg.Percent).Int64())
+	if cfg.Cap > 0 && rake > cfg.Cap {
+		rake = cfg.Cap
+	}
+	return rake
+}
+
+// RakeReport is the rake taken at this table
+type RakeReport struct {
+	Config    RakeConfig `json:"config"`
+	Collected int        `json:"collected"`
+	Hands     []HandRake `json:"hands"`
+}
+
+// HandRake is the rake taken from one hand
+type HandRake struct {
+	HandID int `json:"hand_id"`
+	Pot    int `json:"pot"`
+	Rake   int `json:"rake"`
+}
+
+// Rake reports the rake rules, the total collected and the rake of each
+// recent hand, newest first
+func (g *Game) Rake() RakeReport {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	report := RakeReport{
+		Config:    g.tableConfig.Rake,
+		Collected: g.rakeCollected,
+		Hands:     []HandRake{},
+	}
+	for i := len(g.handHistory) - 1; i >= 0; i-- {
+		hand := g.handHistory[i]
+		if hand.Rake > 0 {
+			report.Hands = append(report.Hands, HandRake{HandID: hand.ID, Pot: hand.Pot, Rake: hand.Rake})
+		}
+	}
+	return report
+}
+
+// collectRake takes the rake out of the pots about to be awarded, from the
+// main pot first, and returns it
+func (g *Game) collectRake(pots []SidePot) int {
+	total := 0
+	for _, pot := range pots {
+		total += pot.Amount
+	}
+
+	rake := g.tableConfig.Rake.amount(total, len(g.communityCards) > 0)
+	if rake == 0 {
+		return 0
+	}
+
+	remaining := rake
+	for i := range pots {
+		take := remaining
+		if take > pots[i].Amount {
+			take = pots[i].Amount
+		}
+		pots[i].Amount -= take
+		remaining -= take
+	}
+
+	g.currentPot -= rake
+	g.handRake = rake
+	g.rakeCollected += rake
+
+	g.log().WithFields(logrus.Fields{
+		"pot":       total,
+		"rake":      rake,
+		"collected": g.rakeCollected,
+	}).Info("Rake taken")
+	return rake
+}
+
+// rakePayout adds the rake recipient to an on-chain settlement
+func (g *Game) rakePayout(winners []string, amounts []int) ([]string, []int) {
+	if g.handRake == 0 || g.tableConfig.Rake.Recipient == "" {
+		return winners, amounts
+	}
+	return append(winners, g.tableConfig.Rake.Recipient), append(amounts, g.handRake)
+}
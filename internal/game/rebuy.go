@@ -0,0 +1,173 @@
+package game
+
+import (
+	"fmt"
+	"math/big"
+
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+)
+
+// Default buy-in limits: a busted player must bring at least the minimum,
+// and no rebuy or add-on may take a stack past the maximum
+const (
+	DefaultMinBuyIn = 20 * BigBlind
+	DefaultMaxBuyIn = 100 * BigBlind
+)
+
+// SetBuyInLimits sets the minimum buy-in and maximum stack for rebuys, and
+// whether each rebuy must reference funds locked on-chain
+func (g *Game) SetBuyInLimits(minBuyIn, maxBuyIn int, requireLocked bool) {
+	g.do("set buy in limits", func() {
+		g.minBuyIn = minBuyIn
+		g.maxBuyIn = maxBuyIn
+		g.rebuyNeedsLock = requireLocked
+	})
+}
+
+// BuyInLimits returns the minimum buy-in and maximum stack
+func (g *Game) BuyInLimits() (minBuyIn, maxBuyIn int) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.minBuyIn, g.maxBuyIn
+}
+
+// Rebuy adds chips to a busted or short-stacked player between hands. With
+// a tx hash, the chips must be backed by a FundsLocked event for the player.
+func (g *Game) Rebuy(playerID string, amount int, txHash string) (int, error) {
+	stack, err := g.processRebuy(playerID, amount, txHash)
+	if err != nil {
+		return 0, err
+	}
+
+	g.do("rebuy", func() {
+		g.sendToPlayers(protocol.TypeRebuy, protocol.RebuyPayload{
+			Amount: amount,
+			TxHash: txHash,
+		}, g.getOtherPlayers()...)
+	})
+	return stack, nil
+}
+
+func (g *Game) handleMessageRebuy(from string, payload protocol.RebuyPayload) error {
+	_, err := g.processRebuy(from, payload.Amount, payload.TxHash)
+	return err
+}
+
+// processRebuy checks the request, verifies locked funds off the table's
+// loop so other commands are not held up, then checks again and credits the
+// stack on it
+func (g *Game) processRebuy(playerID string, amount int, txHash string) (int, error) {
+	g.lock.RLock()
+	err := g.checkRebuy(playerID, amount, txHash)
+	g.lock.RUnlock()
+	if err != nil {
+		return 0, err
+	}
+
+	if txHash != "" {
+		if err := g.verifyFundsLocked(playerID, amount, txHash); err != nil {
+			return 0, err
+		}
+	}
+
+	var stack int
+	err = g.exec("rebuy", func() error {
+		if err := g.checkRebuy(playerID, amount, txHash); err != nil {
+			return err
+		}
+		stack = g.creditRebuy(playerID, amount, txHash)
+		return nil
+	})
+	return stack, err
+}
+
+func (g *Game) checkRebuy(playerID string, amount int, txHash string) error {
+	state, ok := g.playerStates[playerID]
+	if !ok || !state.IsActive {
+		return fmt.Errorf("player %s is not at the table", playerID)
+	}
+	if g.inHand(playerID) {
+		return fmt.Errorf("rebuys are only allowed between hands")
+	}
+	if amount <= 0 {
+		return fmt.Errorf("rebuy amount must be positive")
+	}
+	if state.Stack == 0 && amount < g.minBuyIn {
+		return fmt.Errorf("a rebuy must be at least %d chips", g.minBuyIn)
+	}
+	if g.maxBuyIn > 0 && state.Stack+amount > g.maxBuyIn {
+		return fmt.Errorf("stack would exceed the table maximum of %d chips", g.maxBuyIn)
+	}
+
+	if txHash == "" {
+		if g.rebuyNeedsLock {
+			return fmt.Errorf("rebuys must reference a FundsLocked transaction")
+		}
+		return nil
+	}
+	if !g.blockchainEnabled {
+		return fmt.Errorf("blockchain is not enabled; cannot verify locked funds")
+	}
+	if g.rebuyTxs[common.HexToHash(txHash)] {
+		return fmt.Errorf("transaction %s was already used for a rebuy", txHash)
+	}
+	return nil
+}
+
+// verifyFundsLocked checks the transaction locked at least amount for the player
+func (g *Game) verifyFundsLocked(playerID string, amount int, txHash string) error {
+	event, err := g.blockchain.FindFundsLocked(common.HexToHash(txHash))
+	if err != nil {
+		return fmt.Errorf("failed to verify locked funds: %w", err)
+	}
+	if event.Player != common.HexToAddress(playerID) {
+		return fmt.Errorf("transaction %s locked funds for %s, not %s", txHash, event.Player.Hex(), playerID)
+	}
+	if event.Amount.Cmp(big.NewInt(int64(amount))) < 0 {
+		return fmt.Errorf("transaction %s locked %s, less than the %d requested", txHash, event.Amount, amount)
+	}
+	return nil
+}
+
+func (g *Game) creditRebuy(playerID string, amount int, txHash string) int {
+	state := g.playerStates[playerID]
+	addOn := state.Stack > 0
+	state.Stack += amount
+	state.Rebuys += amount
+	state.RebuyCount++
+	if g.inHand(playerID) {
+		g.handChips += amount
+	}
+	g.buyIns[playerID] += amount
+	g.recordLedger(persistence.LedgerBuyIn, playerID, amount)
+	if txHash != "" {
+		g.rebuyTxs[common.HexToHash(txHash)] = true
+	}
+
+	g.log().WithFields(logrus.Fields{
+		"player": playerID,
+		"amount": amount,
+		"stack":  state.Stack,
+		"add_on": addOn,
+	}).Info("Player rebought")
+
+	g.audit("rebuy", playerID, map[string]interface{}{
+		"amount":  amount,
+		"stack":   state.Stack,
+		"add_on":  addOn,
+		"tx_hash": txHash,
+	})
+
+	g.publishEvent(protocol.EventRebuy, protocol.RebuyEvent{
+		PlayerID: playerID,
+		Amount:   amount,
+		NewStack: state.Stack,
+		AddOn:    addOn,
+		TxHash:   txHash,
+	})
+	g.publishStateUpdate()
+	return state.Stack
+}
@@ -0,0 +1,213 @@
+package game
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// SetIncidentDir sets where state snapshots are written when hand logic panics
+func (g *Game) SetIncidentDir(dir string) {
+	g.do("set incident dir", func() {
+		g.incidentDir = dir
+	})
+}
+
+// Snapshot captures the current table state, without the deck or any of
+// this node's hand secrets
+func (g *Game) Snapshot() *persistence.GameSnapshot {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.snapshot()
+}
+
+func (g *Game) snapshot() *persistence.GameSnapshot {
+	players := make([]persistence.PlayerSnapshot, 0, len(g.playerStates))
+	for addr, state := range g.playerStates {
+		players = append(players, persistence.PlayerSnapshot{
+			PlayerID:         addr,
+			RotationID:       state.RotationID,
+			Seat:             state.Seat,
+			Stack:            state.Stack,
+			CurrentBet:       state.CurrentRoundBet,
+			TotalBetThisHand: state.TotalBetThisHand,
+			IsActive:         state.IsActive,
+			IsFolded:         state.IsFolded,
+			IsAllIn:          state.IsAllIn,
+			IsReady:          state.IsReady,
+			SittingOut:       state.SittingOut,
+			WaitForBB:        state.WaitForBB,
+			MissedSmallBlind: state.MissedSmallBlind,
+			MissedBigBlind:   state.MissedBigBlind,
+			ActedThisRound:   state.ActedThisRound,
+			ActedAtRaiseTo:   state.ActedAtRaiseTo,
+			BuyIn:            state.BuyIn,
+			Rebuys:           state.Rebuys,
+			RebuyCount:       state.RebuyCount,
+		})
+	}
+
+	board := make([]byte, len(g.communityCards))
+	for i, card := range g.communityCards {
+		board[i] = card.ToByte()
+	}
+
+	rotationMap := make(map[int]string, len(g.rotationMap))
+	for id, addr := range g.rotationMap {
+		rotationMap[id] = addr
+	}
+
+	sidePots := g.pot.Pots()
+	pots := make([]persistence.PotSnapshot, len(sidePots))
+	for i, pot := range sidePots {
+		pots[i] = persistence.PotSnapshot{
+			Amount:   pot.Amount,
+			Cap:      pot.Cap,
+			Eligible: append([]string{}, pot.EligiblePlayers...),
+		}
+	}
+
+	var seatDraw json.RawMessage
+	if g.seatDraw != nil {
+		seatDraw, _ = json.Marshal(g.seatDraw)
+	}
+
+	var batch json.RawMessage
+	if g.settlementBatch != nil {
+		batch, _ = json.Marshal(g.settlementBatch)
+	}
+
+	var channelState json.RawMessage
+	if g.channel != nil && g.channel.latest != nil {
+		channelState, _ = json.Marshal(g.channel.latest)
+	}
+
+	chainGameID := ""
+	if g.blockchainGameID != [32]byte{} {
+		chainGameID = hex.EncodeToString(g.blockchainGameID[:])
+	}
+
+	return &persistence.GameSnapshot{
+		Timestamp:       time.Now(),
+		Version:         protocol.ProtocolVersion,
+		GameStatus:      g.currentStatus.String(),
+		CurrentPot:      g.currentPot,
+		HighestBet:      g.highestBet,
+		DealerID:        g.currentDealerID,
+		CurrentTurn:     g.currentPlayerTurn,
+		Players:         players,
+		CommunityCards:  board,
+		Metadata:        map[string]interface{}{},
+		RotationMap:     rotationMap,
+		NextRotationID:  g.nextRotationID,
+		LastRaiserID:    g.lastRaiserID,
+		LastRaiseAmount: g.lastRaiseAmount,
+		FullRaiseTo:     g.fullRaiseTo,
+		SidePots:        pots,
+		HandCount:       g.handCount,
+		HandUID:         g.handUID,
+		HandActionSeq:   g.handActionSeq,
+		RakeCollected:   g.rakeCollected,
+		ButtonSeat:      g.buttonSeat,
+		SmallBlindSeat:  g.smallBlindSeat,
+		BigBlindSeat:    g.bigBlindSeat,
+		DeadButton:      g.deadButton,
+		SeatDraw:        seatDraw,
+		ChainGameID:     chainGameID,
+		SettlementBatch: batch,
+		ChannelState:    channelState,
+	}
+}
+
+// recoverPanic is deferred by the loop around every command, with the game
+// lock held. A panic there would otherwise kill the loop and leave escrowed
+// chips stranded mid-hand; instead the state is snapshotted, the hand is
+// voided with contributions refunded, and the table keeps running.
+func (g *Game) recoverPanic(operation string, errp *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	stack := debug.Stack()
+
+	incidentID := newIncidentID()
+	g.log().WithFields(logrus.Fields{
+		"incident":  incidentID,
+		"operation": operation,
+		"panic":     r,
+		"stack":     string(stack),
+	}).Error("Recovered from panic in hand logic")
+
+	snapshotFile := g.saveIncidentSnapshot(incidentID, map[string]interface{}{
+		"operation": operation,
+		"panic":     fmt.Sprint(r),
+	})
+
+	handID := 0
+	if len(g.handHistory) > 0 {
+		handID = g.handHistory[len(g.handHistory)-1].ID
+	}
+
+	refunds := g.voidHand("internal error (incident " + incidentID + ")")
+	g.pendingDraw = nil
+	g.setStatus(GameStatusWaiting)
+
+	g.audit("hand_voided", "table", map[string]interface{}{
+		"incident_id": incidentID,
+		"operation":   operation,
+		"panic":       fmt.Sprint(r),
+		"hand_id":     handID,
+		"refunds":     refunds,
+		"snapshot":    snapshotFile,
+	})
+
+	g.publishEvent(protocol.EventIncident, protocol.IncidentEvent{
+		IncidentID: incidentID,
+		HandID:     handID,
+		Message:    "The hand was voided after an internal error; all bets were returned",
+		Refunds:    refunds,
+		Timestamp:  formatEventTime(time.Now()),
+	})
+	g.publishStateUpdate()
+
+	if errp != nil {
+		*errp = fmt.Errorf("internal error during %s (incident %s)", operation, incidentID)
+	}
+}
+
+// saveIncidentSnapshot writes the table state to the incident directory, if
+// one is set, and returns the file written
+func (g *Game) saveIncidentSnapshot(incidentID string, metadata map[string]interface{}) string {
+	if g.incidentDir == "" {
+		return ""
+	}
+
+	snap := g.snapshot()
+	snap.Metadata["incident_id"] = incidentID
+	for key, value := range metadata {
+		snap.Metadata[key] = value
+	}
+
+	snapshotFile := filepath.Join(g.incidentDir, fmt.Sprintf("incident_%s.json", incidentID))
+	if err := persistence.SaveSnapshot(snap, snapshotFile); err != nil {
+		g.log().Errorf("Failed to save incident snapshot: %v", err)
+		return ""
+	}
+	return snapshotFile
+}
+
+func newIncidentID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,212 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// Peers behind NAT cannot dial each other, but can both dial a publicly
+// reachable node. A node with relay enabled says so in its handshake,
+// tells each peer that can take relayed messages about the others, and
+// forwards relay messages between them. Inner messages must carry their
+// sender's signature, so a relay can drop messages but not forge them, and
+// relaying only works between nodes that sign.
+
+// EnableRelay offers to forward messages between this node's peers
+func (g *Game) EnableRelay() {
+	g.do("enable relay", func() {
+		g.relay = true
+	})
+}
+
+// RelayRoutes returns the peers reached through a relay, mapped to the relay
+func (g *Game) RelayRoutes() map[string]string {
+	g.relayMu.Lock()
+	defer g.relayMu.Unlock()
+
+	routes := make(map[string]string, len(g.relayRoutes))
+	for peer, relay := range g.relayRoutes {
+		routes[peer] = relay
+	}
+	return routes
+}
+
+func (g *Game) relayRoute(peer string) (string, bool) {
+	g.relayMu.Lock()
+	defer g.relayMu.Unlock()
+	relay, ok := g.relayRoutes[peer]
+	return relay, ok
+}
+
+func (g *Game) setRelayRoute(peer, relay string) {
+	g.relayMu.Lock()
+	defer g.relayMu.Unlock()
+	g.relayRoutes[peer] = relay
+}
+
+// sendRelayed wraps an encoded message for each target reached through a
+// relay and sends it there. It returns the targets to send to directly.
+func (g *Game) sendRelayed(data []byte, targets []string) []string {
+	direct := make([]string, 0, len(targets))
+	for _, target := range targets {
+		relay, ok := g.relayRoute(target)
+		if !ok {
+			direct = append(direct, target)
+			continue
+		}
+
+		wrapped, err := g.encodeMessage(protocol.TypeRelay, protocol.RelayPayload{To: target, Message: data})
+		if err != nil {
+			g.log().Errorf("Failed to wrap message for %s: %v", target, err)
+			continue
+		}
+		g.broadcast(wrapped, relay)
+	}
+	return direct
+}
+
+func (g *Game) handleMessageRelay(from string, payload protocol.RelayPayload) error {
+	if payload.To != g.listenAddr {
+		return g.forwardRelay(from, payload)
+	}
+
+	inner, err := protocol.DecodeMessage(payload.Message, protocol.EncodingJSON)
+	if err != nil {
+		return fmt.Errorf("malformed relayed message from %s: %w", payload.From, err)
+	}
+	if inner.Type == protocol.TypeRelay {
+		return fmt.Errorf("nested relay message from %s", payload.From)
+	}
+	if payload.From == "" || payload.From == g.listenAddr || payload.From == from {
+		return fmt.Errorf("relayed message from %s has invalid sender %q", from, payload.From)
+	}
+
+	g.lock.RLock()
+	session, ok := g.peerSessions[from]
+	_, known := g.peerSessions[payload.From]
+	verifier := g.msgVerifier
+	g.lock.RUnlock()
+	if !ok || !session.Relay {
+		return fmt.Errorf("%s relayed a message without offering relay", from)
+	}
+
+	// A relay only speaks for the peers reached through it: not one
+	// connected to us directly or through another relay, and one we have
+	// not heard from only to open with a handshake
+	relay, routed := g.relayRoute(payload.From)
+	switch {
+	case routed && relay != from:
+		return fmt.Errorf("%s relayed a message from %s, who is reached through %s", from, payload.From, relay)
+	case !routed && known:
+		return fmt.Errorf("%s relayed a message from %s, who is connected directly", from, payload.From)
+	case !routed && inner.Type != protocol.TypeHandshake:
+		return fmt.Errorf("%s relayed a %s message from unknown peer %s", from, inner.Type, payload.From)
+	}
+
+	// The inner message must be signed by its sender, not by the relay;
+	// HandleMessage then holds the sender to the address it signs with
+	if verifier == nil || !inner.IsSigned() {
+		return fmt.Errorf("unsigned message relayed from %s by %s", payload.From, from)
+	}
+	if signer, ok := verifier.SenderFor(from); ok && signer == inner.Sender {
+		return fmt.Errorf("%s signed a message it relayed as %s", from, payload.From)
+	}
+
+	// Answers go back the way the message came
+	if !routed {
+		g.setRelayRoute(payload.From, from)
+	}
+	return g.HandleMessage(payload.From, inner)
+}
+
+// forwardRelay passes a relay message from one directly connected peer to
+// another
+func (g *Game) forwardRelay(from string, payload protocol.RelayPayload) error {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if !g.relay {
+		return fmt.Errorf("relay requested by %s but not enabled", from)
+	}
+	sender, ok := g.peerSessions[from]
+	if !ok || !sender.Capabilities.Has(protocol.CapRelay) {
+		return fmt.Errorf("relay requested by %s without negotiating it", from)
+	}
+	target, ok := g.peerSessions[payload.To]
+	if !ok || !target.Capabilities.Has(protocol.CapRelay) {
+		return fmt.Errorf("cannot relay from %s to %s: not a relay peer", from, payload.To)
+	}
+	if _, routed := g.relayRoute(payload.To); routed {
+		return fmt.Errorf("cannot relay from %s to %s: not connected directly", from, payload.To)
+	}
+
+	g.log().WithFields(logrus.Fields{
+		"from": from,
+		"to":   payload.To,
+		"size": len(payload.Message),
+	}).Debug("Relaying peer message")
+
+	payload.From = from
+	return g.sendToPlayers(protocol.TypeRelay, payload, payload.To)
+}
+
+// announceRelayPeers tells a newly negotiated peer which of our other
+// peers it can reach through us
+func (g *Game) announceRelayPeers(peer string) error {
+	session := g.peerSessions[peer]
+	if !g.relay || session == nil || !session.Capabilities.Has(protocol.CapRelay) {
+		return nil
+	}
+	if _, routed := g.relayRoute(peer); routed {
+		return nil
+	}
+
+	peers := make([]string, 0)
+	for id, other := range g.peerSessions {
+		if id == peer || !other.Capabilities.Has(protocol.CapRelay) {
+			continue
+		}
+		if _, routed := g.relayRoute(id); routed {
+			continue
+		}
+		peers = append(peers, id)
+	}
+	if len(peers) == 0 {
+		return nil
+	}
+	return g.sendToPlayers(protocol.TypePeerList, protocol.PeerListPayload{Peers: peers}, peer)
+}
+
+// handleMessagePeerList opens negotiation, through the relay, with the
+// peers a relay announced that we are not connected to
+func (g *Game) handleMessagePeerList(from string, payload protocol.PeerListPayload) error {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	session, ok := g.peerSessions[from]
+	if !ok || !session.Relay {
+		return nil
+	}
+
+	local := g.localHandshake()
+	for _, peer := range payload.Peers {
+		if peer == g.listenAddr || peer == from {
+			continue
+		}
+		if _, known := g.peerSessions[peer]; known {
+			continue
+		}
+
+		g.setRelayRoute(peer, from)
+		g.log().WithFields(logrus.Fields{
+			"peer":  peer,
+			"relay": from,
+		}).Info("Reaching peer through relay")
+		if err := g.sendToPlayers(protocol.TypeHandshake, local, peer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
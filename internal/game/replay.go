@@ -0,0 +1,207 @@
+package game
+
+import "fmt"
+
+// ReplayStep is a recorded hand as it stood after one step: step 0 is the
+// hand once the blinds are posted, each action is a step, and the last
+// step pays out the result
+type ReplayStep struct {
+	Step       int            `json:"step"`
+	Steps      int            `json:"steps"` // the hand's last step
+	Action     *HandAction    `json:"action,omitempty"`
+	Street     string         `json:"street"`
+	Board      []string       `json:"board"`
+	Pot        int            `json:"pot"`
+	HighestBet int            `json:"highest_bet"`
+	Players    []ReplayPlayer `json:"players"`
+	Result     bool           `json:"result"`
+}
+
+// ReplayPlayer is one player at a step of a replay. Hole cards only appear
+// on the result step, for hands shown at the showdown.
+type ReplayPlayer struct {
+	PlayerID string   `json:"player_id"`
+	Stack    int      `json:"stack"`
+	Bet      int      `json:"bet"`       // this street
+	TotalBet int      `json:"total_bet"` // this hand
+	Folded   bool     `json:"folded,omitempty"`
+	AllIn    bool     `json:"all_in,omitempty"`
+	Won      int      `json:"won,omitempty"`
+	Cards    []string `json:"cards,omitempty"`
+}
+
+// boardCards is how much of the board is dealt on each street
+var boardCards = map[string]int{
+	GameStatusPreFlop.String(): 0,
+	GameStatusFlop.String():    3,
+	GameStatusTurn.String():    4,
+	GameStatusRiver.String():   5,
+}
+
+// ReplayHand rebuilds a recorded hand step by step, in seat order, from the
+// stacks it began with and its actions. Hands recorded before starting
+// stacks were kept cannot be replayed.
+func ReplayHand(hand HandHistory) ([]ReplayStep, error) {
+	if len(hand.Start) == 0 {
+		return nil, fmt.Errorf("hand %d has no starting stacks to replay from", hand.ID)
+	}
+
+	r := newReplay(hand)
+	steps := []ReplayStep{r.step(0, nil)}
+	for i := range hand.Actions {
+		action := hand.Actions[i]
+		if err := r.apply(action); err != nil {
+			return nil, fmt.Errorf("hand %d, action %d: %w", hand.ID, action.Seq, err)
+		}
+		steps = append(steps, r.step(i+1, &action))
+	}
+
+	// A voided hand's bets were refunded rather than won
+	if !hand.EndedAt.IsZero() && !hand.Voided {
+		steps = append(steps, r.result(len(steps)))
+	}
+
+	for i := range steps {
+		steps[i].Steps = len(steps) - 1
+	}
+	return steps, nil
+}
+
+// replay is the betting state of a hand being replayed
+type replay struct {
+	hand       HandHistory
+	players    []*ReplayPlayer
+	byID       map[string]*ReplayPlayer
+	street     string
+	pot        int
+	highestBet int
+}
+
+func newReplay(hand HandHistory) *replay {
+	r := &replay{
+		hand:   hand,
+		byID:   make(map[string]*ReplayPlayer, len(hand.Start)),
+		street: GameStatusPreFlop.String(),
+	}
+	for _, seat := range hand.Start {
+		player := &ReplayPlayer{
+			PlayerID: seat.PlayerID,
+			Stack:    seat.Stack - seat.Posted,
+			Bet:      seat.Bet,
+			TotalBet: seat.Posted,
+		}
+		player.AllIn = player.Stack == 0 && seat.Posted > 0
+		r.players = append(r.players, player)
+		r.byID[seat.PlayerID] = player
+		r.pot += seat.Posted
+		if seat.Bet > r.highestBet {
+			r.highestBet = seat.Bet
+		}
+	}
+	return r
+}
+
+// apply moves chips for an action the way updatePlayerState did
+func (r *replay) apply(action HandAction) error {
+	player, ok := r.byID[action.PlayerID]
+	if !ok {
+		return fmt.Errorf("%s was not dealt in", action.PlayerID)
+	}
+
+	// Bets are per street; a new street starts from nothing
+	if action.Street != r.street {
+		r.street = action.Street
+		r.highestBet = 0
+		for _, p := range r.players {
+			p.Bet = 0
+		}
+	}
+
+	parsed, err := ParsePlayerAction(action.Action)
+	if err != nil {
+		return err
+	}
+	switch parsed {
+	case PlayerActionFold:
+		player.Folded = true
+
+	case PlayerActionCheck:
+
+	case PlayerActionCall:
+		amount := r.highestBet - player.Bet
+		if amount >= player.Stack {
+			amount = player.Stack
+			player.AllIn = true
+		}
+		r.put(player, amount)
+
+	case PlayerActionBet, PlayerActionRaise, PlayerActionAllIn:
+		to := action.Amount
+		if parsed == PlayerActionAllIn || to >= player.Bet+player.Stack {
+			to = player.Bet + player.Stack
+			player.AllIn = true
+		}
+		if to < player.Bet {
+			return fmt.Errorf("%s %s to %d is below their bet of %d", action.PlayerID, action.Action, to, player.Bet)
+		}
+		r.put(player, to-player.Bet)
+		if player.Bet > r.highestBet {
+			r.highestBet = player.Bet
+		}
+	}
+	return nil
+}
+
+func (r *replay) put(player *ReplayPlayer, amount int) {
+	player.Stack -= amount
+	player.Bet += amount
+	player.TotalBet += amount
+	r.pot += amount
+}
+
+func (r *replay) step(n int, action *HandAction) ReplayStep {
+	return ReplayStep{
+		Step:       n,
+		Action:     action,
+		Street:     r.street,
+		Board:      r.board(r.street),
+		Pot:        r.pot,
+		HighestBet: r.highestBet,
+		Players:    r.snapshot(),
+	}
+}
+
+// result pays out the hand's winnings and shows the hands shown
+func (r *replay) result(n int) ReplayStep {
+	for _, player := range r.players {
+		player.Won = r.hand.Winnings[player.PlayerID]
+		player.Stack += player.Won
+		player.Bet = 0
+		player.Cards = r.hand.Shown[player.PlayerID]
+	}
+
+	step := r.step(n, nil)
+	step.Street = GameStatusShowdown.String()
+	step.Board = r.hand.Board
+	step.Pot = 0
+	step.HighestBet = 0
+	step.Result = true
+	return step
+}
+
+// board is the part of the final board dealt by a street
+func (r *replay) board(street string) []string {
+	n := boardCards[street]
+	if n > len(r.hand.Board) {
+		n = len(r.hand.Board)
+	}
+	return append([]string{}, r.hand.Board[:n]...)
+}
+
+func (r *replay) snapshot() []ReplayPlayer {
+	players := make([]ReplayPlayer, len(r.players))
+	for i, player := range r.players {
+		players[i] = *player
+	}
+	return players
+}
@@ -0,0 +1,227 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/deck"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// RunItTwiceTimeout is how long players have to agree to run the board
+// twice; anyone who has not answered by then is taken as a no
+const RunItTwiceTimeout = 15 * time.Second
+
+// runItTwiceOffer asks the players left in an all-in hand whether to deal
+// the rest of the board twice
+type runItTwiceOffer struct {
+	players   map[string]bool
+	votes     map[string]bool
+	expiresAt time.Time
+}
+
+// VoteRunItTwice records a player's answer to an open run-it-twice offer.
+// Every player must accept; a single no runs the board once.
+func (g *Game) VoteRunItTwice(playerID string, accept bool) error {
+	return g.exec("run it twice", func() error {
+		if err := g.recordRunItTwiceVote(playerID, accept); err != nil {
+			return err
+		}
+
+		g.sendToPlayers(protocol.TypeRunItTwice, protocol.RunItTwicePayload{
+			Accept: accept,
+		}, g.getOtherPlayers()...)
+
+		g.checkRunItTwiceVote()
+		return nil
+	})
+}
+
+// RunItTwiceOffer returns the players asked and their answers so far, if an offer is open
+func (g *Game) RunItTwiceOffer() (players []string, votes map[string]bool, expiresAt time.Time, ok bool) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	offer := g.runOffer
+	if offer == nil {
+		return nil, nil, time.Time{}, false
+	}
+
+	votes = make(map[string]bool, len(offer.votes))
+	for addr, accept := range offer.votes {
+		votes[addr] = accept
+	}
+	return offer.playerList(), votes, offer.expiresAt, true
+}
+
+func (g *Game) handleMessageRunItTwice(from string, payload protocol.RunItTwicePayload) error {
+	return g.exec("run it twice message from "+from, func() error {
+		if err := g.recordRunItTwiceVote(from, payload.Accept); err != nil {
+			return err
+		}
+
+		g.checkRunItTwiceVote()
+		return nil
+	})
+}
+
+// bettingClosed reports whether at least two players are left in the hand
+// and at most one of them still has chips to bet with
+func (g *Game) bettingClosed() bool {
+	live, canAct := 0, 0
+	for _, state := range g.playerStates {
+		if !state.IsActive || state.IsFolded {
+			continue
+		}
+		live++
+		if !state.IsAllIn {
+			canAct++
+		}
+	}
+	return live >= 2 && canAct <= 1
+}
+
+// beginRunout deals the rest of the board once betting is closed before the
+// river, asking the players first whether to run it twice if the table allows it
+func (g *Game) beginRunout() {
+	if !g.tableConfig.RunItTwice {
+		g.runOut(1)
+		return
+	}
+
+	offer := &runItTwiceOffer{
+		players:   make(map[string]bool),
+		votes:     make(map[string]bool),
+		expiresAt: time.Now().Add(RunItTwiceTimeout),
+	}
+	for id := 0; id < g.nextRotationID; id++ {
+		addr := g.rotationMap[id]
+		if state, ok := g.playerStates[addr]; ok && state.IsActive && !state.IsFolded {
+			offer.players[addr] = true
+		}
+	}
+	g.runOffer = offer
+
+	players := offer.playerList()
+	g.log().WithField("players", players).Info("Offering to run it twice")
+
+	g.publishEvent(protocol.EventRunItTwiceOffer, protocol.RunItTwiceOfferEvent{
+		Players:   players,
+		ExpiresAt: formatEventTime(offer.expiresAt),
+	}, players...)
+
+	g.afterFunc(RunItTwiceTimeout, "run it twice timeout", func() {
+		if g.runOffer == offer {
+			g.log().Info("Run it twice offer expired")
+			g.finishRunItTwice(1)
+		}
+	})
+}
+
+func (g *Game) recordRunItTwiceVote(playerID string, accept bool) error {
+	offer := g.runOffer
+	if offer == nil {
+		return fmt.Errorf("no run it twice offer in progress")
+	}
+	if !offer.players[playerID] {
+		return fmt.Errorf("player %s is not part of the run it twice offer", playerID)
+	}
+	if _, voted := offer.votes[playerID]; voted {
+		return fmt.Errorf("player %s already answered", playerID)
+	}
+
+	offer.votes[playerID] = accept
+	g.log().WithFields(logrus.Fields{
+		"player": playerID,
+		"accept": accept,
+	}).Info("Run it twice vote received")
+	return nil
+}
+
+// checkRunItTwiceVote runs the board once on any refusal, and twice once everyone accepted
+func (g *Game) checkRunItTwiceVote() {
+	offer := g.runOffer
+	if offer == nil {
+		return
+	}
+
+	for _, accept := range offer.votes {
+		if !accept {
+			g.finishRunItTwice(1)
+			return
+		}
+	}
+	if len(offer.votes) == len(offer.players) {
+		g.finishRunItTwice(2)
+	}
+}
+
+func (g *Game) finishRunItTwice(runs int) {
+	g.runOffer = nil
+
+	g.publishEvent(protocol.EventRunItTwice, protocol.RunItTwiceEvent{
+		Agreed: runs == 2,
+		Runs:   runs,
+	})
+	g.runOut(runs)
+	g.publishStateUpdate()
+}
+
+// runOut deals the rest of the board without further betting and resolves
+// the hand. A second run uses the deck cards after the first run's.
+func (g *Game) runOut(runs int) {
+	if runs == 2 {
+		g.secondBoard = g.dealSecondBoard()
+	}
+
+	streets := 0
+	switch g.currentStatus {
+	case GameStatusPreFlop:
+		streets = 3
+	case GameStatusFlop:
+		streets = 2
+	case GameStatusTurn:
+		streets = 1
+	}
+	for i := 0; i < streets; i++ {
+		g.advanceToNextRound()
+	}
+
+	if len(g.secondBoard) > 0 {
+		g.log().Infof("Second run: %v", g.secondBoard)
+		g.publishEvent(protocol.EventCommunityCard, protocol.CommunityCardEvent{
+			Stage: "second_run",
+			Cards: toCardData(g.secondBoard),
+		})
+	}
+
+	// River to showdown
+	g.advanceToNextRound()
+}
+
+// dealSecondBoard deals the second run: the cards already on the board plus
+// fresh cards taken after the ones the first run will use
+func (g *Game) dealSecondBoard() []deck.Card {
+	startIdx := g.boardStart() + 5
+
+	board := append([]deck.Card{}, g.communityCards...)
+	for i := 0; len(board) < 5; i++ {
+		card, ok := g.decryptBoardCard(startIdx + i)
+		if !ok {
+			return nil
+		}
+		board = append(board, card)
+	}
+	return board
+}
+
+func (offer *runItTwiceOffer) playerList() []string {
+	players := make([]string, 0, len(offer.players))
+	for addr := range offer.players {
+		players = append(players, addr)
+	}
+	sort.Strings(players)
+	return players
+}
@@ -0,0 +1,336 @@
+package game
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// SeatDraw is the outcome of the table-start seat and button draw.
+//
+// Every player commits to a random seed, then reveals it once all commitments
+// are in. The beacon is the hash of all seeds, so no single player (and no
+// choice of address) can steer the button or the seating as long as one seed
+// is honest. Players who pick a seat keep it; the rest fill free seats in the
+// drawn order. The commitments and seeds are the proof: anyone can check
+// the draw with VerifySeatDraw.
+type SeatDraw struct {
+	Beacon      string            `json:"beacon"`
+	Commitments map[string]string `json:"commitments"`
+	Seeds       map[string]string `json:"seeds"`
+	Seats       []string          `json:"seats"`
+	Button      string            `json:"button"`
+	DrawnAt     time.Time         `json:"drawn_at"`
+}
+
+// seatDrawRound tracks an in-progress commit/reveal exchange
+type seatDrawRound struct {
+	participants map[string]bool
+	seed         []byte
+	commitments  map[string][]byte
+	seeds        map[string][]byte
+	revealed     bool
+}
+
+// SeatDraw returns the table's seat draw, if one has completed
+func (g *Game) SeatDraw() (SeatDraw, bool) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if g.seatDraw == nil {
+		return SeatDraw{}, false
+	}
+	return *g.seatDraw, true
+}
+
+// beginSeatDraw commits to our seed and asks the other players for theirs
+func (g *Game) beginSeatDraw(players []string) {
+	if g.pendingDraw != nil {
+		return
+	}
+
+	round := &seatDrawRound{
+		participants: make(map[string]bool, len(players)),
+		commitments:  make(map[string][]byte),
+		seeds:        make(map[string][]byte),
+	}
+	for _, addr := range players {
+		round.participants[addr] = true
+	}
+	g.pendingDraw = round
+
+	g.log().WithField("players", len(players)).Info("Starting seat draw")
+
+	if !round.participants[g.listenAddr] {
+		return
+	}
+
+	seed := make([]byte, 32)
+	if _, err := rand.Read(seed); err != nil {
+		g.log().Errorf("Failed to generate seat draw seed: %v", err)
+		g.pendingDraw = nil
+		return
+	}
+	commitment := sha256.Sum256(seed)
+	round.seed = seed
+	round.commitments[g.listenAddr] = commitment[:]
+
+	g.sendToPlayers(protocol.TypeSeatDrawCommit, protocol.SeatDrawCommitPayload{
+		Commitment: hex.EncodeToString(commitment[:]),
+	}, g.getOtherPlayers()...)
+
+	g.advanceSeatDraw()
+}
+
+func (g *Game) handleMessageSeatDrawCommit(from string, payload protocol.SeatDrawCommitPayload) error {
+	return g.exec("seat draw commit message from "+from, func() error {
+		if g.seatDraw != nil {
+			return nil
+		}
+		if _, ok := g.playerStates[from]; !ok {
+			return fmt.Errorf("seat draw commitment from unknown player %s", from)
+		}
+
+		commitment, err := hex.DecodeString(payload.Commitment)
+		if err != nil || len(commitment) != sha256.Size {
+			return fmt.Errorf("invalid seat draw commitment from %s", from)
+		}
+
+		// Another player started the draw first; join in
+		if g.pendingDraw == nil {
+			g.beginSeatDraw(g.getReadyActivePlayers())
+			if g.pendingDraw == nil {
+				return fmt.Errorf("failed to join seat draw")
+			}
+		}
+
+		round := g.pendingDraw
+		if round.revealed {
+			return fmt.Errorf("late seat draw commitment from %s", from)
+		}
+		if _, ok := round.commitments[from]; ok {
+			return fmt.Errorf("duplicate seat draw commitment from %s", from)
+		}
+
+		round.participants[from] = true
+		round.commitments[from] = commitment
+		g.advanceSeatDraw()
+		return nil
+	})
+}
+
+func (g *Game) handleMessageSeatDrawReveal(from string, payload protocol.SeatDrawRevealPayload) error {
+	return g.exec("seat draw reveal message from "+from, func() error {
+		round := g.pendingDraw
+		if round == nil {
+			return nil
+		}
+
+		commitment, ok := round.commitments[from]
+		if !ok {
+			return fmt.Errorf("seat draw reveal from %s without commitment", from)
+		}
+
+		seed, err := hex.DecodeString(payload.Seed)
+		if err != nil {
+			return fmt.Errorf("invalid seat draw seed from %s", from)
+		}
+		if sum := sha256.Sum256(seed); !bytes.Equal(sum[:], commitment) {
+			return fmt.Errorf("seat draw seed from %s does not match its commitment", from)
+		}
+
+		round.seeds[from] = seed
+		g.advanceSeatDraw()
+		return nil
+	})
+}
+
+// advanceSeatDraw reveals once every commitment is in, and finishes once every seed is
+func (g *Game) advanceSeatDraw() {
+	round := g.pendingDraw
+
+	for addr := range round.participants {
+		if _, ok := round.commitments[addr]; !ok {
+			return
+		}
+	}
+
+	if !round.revealed {
+		round.revealed = true
+		if round.seed != nil {
+			round.seeds[g.listenAddr] = round.seed
+			g.sendToPlayers(protocol.TypeSeatDrawReveal, protocol.SeatDrawRevealPayload{
+				Seed: hex.EncodeToString(round.seed),
+			}, g.getOtherPlayers()...)
+		}
+	}
+
+	for addr := range round.participants {
+		if _, ok := round.seeds[addr]; !ok {
+			return
+		}
+	}
+
+	g.finishSeatDraw()
+}
+
+func (g *Game) finishSeatDraw() {
+	round := g.pendingDraw
+	g.pendingDraw = nil
+
+	players := make([]string, 0, len(round.participants))
+	for addr := range round.participants {
+		players = append(players, addr)
+	}
+	// Canonical order for hashing only; seating comes from the beacon
+	sort.Strings(players)
+
+	h := sha256.New()
+	seeds := make(map[string]string, len(players))
+	commitments := make(map[string]string, len(players))
+	for _, addr := range players {
+		h.Write(round.seeds[addr])
+		seeds[addr] = hex.EncodeToString(round.seeds[addr])
+		commitments[addr] = hex.EncodeToString(round.commitments[addr])
+	}
+	beacon := h.Sum(nil)
+
+	seats := seatOrder(beacon, players)
+	draw := &SeatDraw{
+		Beacon:      hex.EncodeToString(beacon),
+		Commitments: commitments,
+		Seeds:       seeds,
+		Seats:       seats,
+		Button:      drawButton(beacon, seats),
+		DrawnAt:     time.Now(),
+	}
+
+	g.seatDraw = draw
+	g.buttonPending = true
+
+	g.log().WithFields(logrus.Fields{
+		"beacon": draw.Beacon,
+		"seats":  draw.Seats,
+		"button": draw.Button,
+	}).Info("Seat draw complete")
+
+	g.audit("seat_draw", "table", map[string]interface{}{
+		"beacon": draw.Beacon,
+		"seats":  draw.Seats,
+		"button": draw.Button,
+	})
+	g.publishEvent(protocol.EventSeatDraw, protocol.SeatDrawEvent{
+		Beacon:      draw.Beacon,
+		Commitments: draw.Commitments,
+		Seeds:       draw.Seeds,
+		Seats:       draw.Seats,
+		Button:      draw.Button,
+	})
+
+	if g.currentStatus == GameStatusWaiting && len(g.getReadyActivePlayers()) >= 2 {
+		g.startNewHand()
+	}
+}
+
+// drawButton picks the button from the drawn seating, by the beacon
+func drawButton(beacon []byte, seats []string) string {
+	i := new(big.Int).Mod(new(big.Int).SetBytes(beacon), big.NewInt(int64(len(seats))))
+	return seats[i.Int64()]
+}
+
+// drawnButton is the player the seat draw gives the first button: the drawn
+// button, or when they have left since, the next dealt-in player in drawn
+// order. Players must have their rotation IDs for the hand.
+func (g *Game) drawnButton() (string, bool) {
+	if g.seatDraw == nil || len(g.seatDraw.Seats) == 0 {
+		return "", false
+	}
+	seats := g.seatDraw.Seats
+
+	start := 0
+	for i, addr := range seats {
+		if addr == g.seatDraw.Button {
+			start = i
+			break
+		}
+	}
+	for i := range seats {
+		addr := seats[(start+i)%len(seats)]
+		if state, ok := g.playerStates[addr]; ok && g.rotationMap[state.RotationID] == addr {
+			return addr, true
+		}
+	}
+	return "", false
+}
+
+// VerifySeatDraw checks a seat draw against its proof: every seed matches
+// its commitment, the beacon is the hash of the seeds, and the seating and
+// button follow from the beacon
+func VerifySeatDraw(draw SeatDraw) error {
+	if len(draw.Seeds) == 0 {
+		return fmt.Errorf("seat draw has no seeds")
+	}
+	players := make([]string, 0, len(draw.Seeds))
+	for addr := range draw.Seeds {
+		players = append(players, addr)
+	}
+	sort.Strings(players)
+
+	h := sha256.New()
+	for _, addr := range players {
+		seed, err := hex.DecodeString(draw.Seeds[addr])
+		if err != nil {
+			return fmt.Errorf("seed from %s is not hex", addr)
+		}
+		commitment, err := hex.DecodeString(draw.Commitments[addr])
+		if err != nil || len(commitment) != sha256.Size {
+			return fmt.Errorf("no valid commitment from %s", addr)
+		}
+		if sum := sha256.Sum256(seed); !bytes.Equal(sum[:], commitment) {
+			return fmt.Errorf("seed from %s does not match its commitment", addr)
+		}
+		h.Write(seed)
+	}
+	beacon := h.Sum(nil)
+	if hex.EncodeToString(beacon) != draw.Beacon {
+		return fmt.Errorf("beacon is not the hash of the seeds")
+	}
+
+	seats := seatOrder(beacon, players)
+	if len(seats) != len(draw.Seats) {
+		return fmt.Errorf("seating has %d players, the seeds %d", len(draw.Seats), len(seats))
+	}
+	for i := range seats {
+		if seats[i] != draw.Seats[i] {
+			return fmt.Errorf("seating does not follow from the beacon")
+		}
+	}
+	if drawButton(beacon, seats) != draw.Button {
+		return fmt.Errorf("button does not follow from the beacon")
+	}
+	return nil
+}
+
+// seatOrder orders players by H(beacon || address). Players who join after
+// the draw get a seat from the same beacon, so every node agrees on it.
+func seatOrder(beacon []byte, players []string) []string {
+	keys := make(map[string][]byte, len(players))
+	for _, addr := range players {
+		key := sha256.Sum256(append(append([]byte{}, beacon...), addr...))
+		keys[addr] = key[:]
+	}
+
+	ordered := append([]string{}, players...)
+	sort.Slice(ordered, func(i, j int) bool {
+		return bytes.Compare(keys[ordered[i]], keys[ordered[j]]) < 0
+	})
+	return ordered
+}
@@ -0,0 +1,315 @@
+package game
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultSeatHold is how long a reserved seat is held before it frees up
+const DefaultSeatHold = 60 * time.Second
+
+// seatReservation holds a seat for a player who has not sat down yet
+type seatReservation struct {
+	playerID  string
+	expiresAt time.Time
+}
+
+// SeatInfo describes one numbered seat at the table
+type SeatInfo struct {
+	Seat          int        `json:"seat"`
+	PlayerID      string     `json:"player_id,omitempty"`
+	ReservedBy    string     `json:"reserved_by,omitempty"`
+	ReservedUntil *time.Time `json:"reserved_until,omitempty"`
+}
+
+// SetSeating sets the number of seats and how long reservations are held
+func (g *Game) SetSeating(maxSeats int, hold time.Duration) {
+	g.do("set seating", func() {
+		g.maxSeats = maxSeats
+		g.seatHold = hold
+	})
+}
+
+// SeatHold returns how long a reservation is held
+func (g *Game) SeatHold() time.Duration {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.seatHold
+}
+
+// Seats returns every seat with its occupant or reservation, numbered from 1
+func (g *Game) Seats() []SeatInfo {
+	var seats []SeatInfo
+	g.do("seats", func() {
+		seats = make([]SeatInfo, g.maxSeats)
+		for i := range seats {
+			seats[i].Seat = i + 1
+			occupant, reservation := g.seatHolder(i + 1)
+			seats[i].PlayerID = occupant
+			if reservation != nil {
+				expiresAt := reservation.expiresAt
+				seats[i].ReservedBy = reservation.playerID
+				seats[i].ReservedUntil = &expiresAt
+			}
+		}
+	})
+	return seats
+}
+
+// ReserveSeat holds a seat for a player until they sit down or the hold expires
+func (g *Game) ReserveSeat(playerID string, seat int) (expiresAt time.Time, err error) {
+	err = g.exec("reserve seat", func() error {
+		if g.closing {
+			return errTableClosing
+		}
+
+		expiresAt, err = g.reserveSeat(playerID, seat)
+		if err != nil {
+			return err
+		}
+
+		g.sendToPlayers(protocol.TypeSeat, protocol.SeatPayload{
+			Seat:   seat,
+			Action: protocol.SeatActionReserve,
+		}, g.getOtherPlayers()...)
+		return nil
+	})
+	return expiresAt, err
+}
+
+// TakeSeat sits a player down in a numbered seat, joining the table if needed
+func (g *Game) TakeSeat(playerID string, seat int) error {
+	return g.exec("take seat", func() error {
+		if g.closing {
+			return errTableClosing
+		}
+
+		if err := g.takeSeat(playerID, seat); err != nil {
+			return err
+		}
+
+		g.sendToPlayers(protocol.TypeSeat, protocol.SeatPayload{
+			Seat:   seat,
+			Action: protocol.SeatActionTake,
+		}, g.getOtherPlayers()...)
+		return nil
+	})
+}
+
+// LeaveSeat gives up a player's reservation or chosen seat
+func (g *Game) LeaveSeat(playerID string) error {
+	return g.exec("leave seat", func() error {
+		if err := g.leaveSeat(playerID); err != nil {
+			return err
+		}
+
+		g.sendToPlayers(protocol.TypeSeat, protocol.SeatPayload{
+			Action: protocol.SeatActionLeave,
+		}, g.getOtherPlayers()...)
+		return nil
+	})
+}
+
+func (g *Game) handleMessageSeat(from string, payload protocol.SeatPayload) error {
+	return g.exec("seat message from "+from, func() error {
+		switch payload.Action {
+		case protocol.SeatActionReserve:
+			_, err := g.reserveSeat(from, payload.Seat)
+			return err
+		case protocol.SeatActionTake:
+			return g.takeSeat(from, payload.Seat)
+		case protocol.SeatActionLeave:
+			return g.leaveSeat(from)
+		default:
+			return fmt.Errorf("invalid seat action: %s", payload.Action)
+		}
+	})
+}
+
+func (g *Game) reserveSeat(playerID string, seat int) (time.Time, error) {
+	if err := g.checkSeatFree(playerID, seat); err != nil {
+		return time.Time{}, err
+	}
+	if state, ok := g.playerStates[playerID]; ok && state.Seat != 0 {
+		return time.Time{}, fmt.Errorf("player %s is already in seat %d", playerID, state.Seat)
+	}
+
+	g.releaseReservation(playerID)
+	reservation := &seatReservation{
+		playerID:  playerID,
+		expiresAt: time.Now().Add(g.seatHold),
+	}
+	g.seatReservations[seat] = reservation
+
+	g.log().WithFields(logrus.Fields{
+		"player": playerID,
+		"seat":   seat,
+		"until":  reservation.expiresAt.Format(time.RFC3339),
+	}).Info("Seat reserved")
+
+	g.publishEvent(protocol.EventSeatChanged, protocol.SeatChangedEvent{
+		PlayerID:      playerID,
+		Seat:          seat,
+		Action:        protocol.SeatActionReserve,
+		ReservedUntil: formatEventTime(reservation.expiresAt),
+	})
+	return reservation.expiresAt, nil
+}
+
+func (g *Game) takeSeat(playerID string, seat int) error {
+	if err := g.checkSeatFree(playerID, seat); err != nil {
+		return err
+	}
+
+	state, ok := g.playerStates[playerID]
+	if ok && state.Seat == seat {
+		return nil
+	}
+	if ok && g.inHand(playerID) {
+		return fmt.Errorf("cannot change seats during a hand")
+	}
+	if !ok {
+		g.addPlayer(playerID)
+		state = g.playerStates[playerID]
+	}
+
+	g.releaseReservation(playerID)
+	state.Seat = seat
+
+	g.log().WithFields(logrus.Fields{
+		"player": playerID,
+		"seat":   seat,
+	}).Info("Seat taken")
+
+	g.publishEvent(protocol.EventSeatChanged, protocol.SeatChangedEvent{
+		PlayerID: playerID,
+		Seat:     seat,
+		Action:   protocol.SeatActionTake,
+	})
+	g.publishStateUpdate()
+	return nil
+}
+
+func (g *Game) leaveSeat(playerID string) error {
+	seat := g.releaseReservation(playerID)
+
+	if state, ok := g.playerStates[playerID]; ok && state.Seat != 0 {
+		if g.inHand(playerID) {
+			return fmt.Errorf("cannot leave a seat during a hand")
+		}
+		seat = state.Seat
+		state.Seat = 0
+	}
+
+	if seat == 0 {
+		return fmt.Errorf("player %s has no seat or reservation", playerID)
+	}
+
+	g.publishEvent(protocol.EventSeatChanged, protocol.SeatChangedEvent{
+		PlayerID: playerID,
+		Seat:     seat,
+		Action:   protocol.SeatActionLeave,
+	})
+	return nil
+}
+
+// checkSeatFree reports why a player cannot have a seat, if they can't
+func (g *Game) checkSeatFree(playerID string, seat int) error {
+	if seat < 1 || seat > g.maxSeats {
+		return fmt.Errorf("seat must be between 1 and %d", g.maxSeats)
+	}
+
+	occupant, reservation := g.seatHolder(seat)
+	if occupant != "" && occupant != playerID {
+		return fmt.Errorf("seat %d is taken", seat)
+	}
+	if reservation != nil && reservation.playerID != playerID {
+		return fmt.Errorf("seat %d is reserved until %s", seat, reservation.expiresAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// seatHolder returns who sits in or holds a seat, dropping expired reservations
+func (g *Game) seatHolder(seat int) (string, *seatReservation) {
+	occupant := ""
+	for addr, state := range g.playerStates {
+		if state.Seat == seat {
+			occupant = addr
+			break
+		}
+	}
+
+	reservation, ok := g.seatReservations[seat]
+	if ok && time.Now().After(reservation.expiresAt) {
+		delete(g.seatReservations, seat)
+		reservation = nil
+	}
+	return occupant, reservation
+}
+
+// releaseReservation drops a player's reservation and returns its seat (0 if none)
+func (g *Game) releaseReservation(playerID string) int {
+	for seat, reservation := range g.seatReservations {
+		if reservation.playerID == playerID {
+			delete(g.seatReservations, seat)
+			return seat
+		}
+	}
+	return 0
+}
+
+// inHand reports whether a player was dealt into the hand in progress
+func (g *Game) inHand(playerID string) bool {
+	if g.currentStatus == GameStatusWaiting {
+		return false
+	}
+	state, ok := g.playerStates[playerID]
+	return ok && g.rotationMap[state.RotationID] == playerID
+}
+
+// assignSeats gives players who never chose a seat the lowest free seats, in
+// seat draw order so every node agrees, and returns the players ordered by seat.
+func (g *Game) assignSeats(players []string) []string {
+	beacon, _ := hex.DecodeString(g.seatDraw.Beacon)
+
+	taken := make(map[int]bool)
+	for _, state := range g.playerStates {
+		if state.Seat != 0 {
+			taken[state.Seat] = true
+		}
+	}
+
+	for _, addr := range seatOrder(beacon, players) {
+		state := g.playerStates[addr]
+		if state.Seat != 0 {
+			continue
+		}
+
+		// Skip seats reserved for someone else, unless every free seat is held
+		seat := 1
+		for ; seat <= g.maxSeats; seat++ {
+			if _, reservation := g.seatHolder(seat); !taken[seat] && (reservation == nil || reservation.playerID == addr) {
+				break
+			}
+		}
+		if seat > g.maxSeats {
+			for seat = 1; taken[seat]; seat++ {
+			}
+		}
+
+		g.releaseReservation(addr)
+		state.Seat = seat
+		taken[seat] = true
+	}
+
+	ordered := append([]string{}, players...)
+	sort.Slice(ordered, func(i, j int) bool {
+		return g.playerStates[ordered[i]].Seat < g.playerStates[ordered[j]].Seat
+	})
+	return ordered
+}
@@ -0,0 +1,135 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+)
+
+// SessionAccount is a player's standing over the session: what they brought
+// to the table, what they have now and the difference
+type SessionAccount struct {
+	PlayerID   string `json:"player_id"`
+	BuyIn      int    `json:"buy_in"`
+	Rebuys     int    `json:"rebuys"`
+	RebuyCount int    `json:"rebuy_count"`
+	Invested   int    `json:"invested"` // buy-in and rebuys
+	Stack      int    `json:"stack"`    // including chips in the hand in progress
+	Net        int    `json:"net"`
+	Active     bool   `json:"active"`
+}
+
+func (state *PlayerState) sessionAccount() SessionAccount {
+	invested := state.BuyIn + state.Rebuys
+	stack := state.Stack + state.TotalBetThisHand
+	return SessionAccount{
+		PlayerID:   state.ListenAddr,
+		BuyIn:      state.BuyIn,
+		Rebuys:     state.Rebuys,
+		RebuyCount: state.RebuyCount,
+		Invested:   invested,
+		Stack:      stack,
+		Net:        stack - invested,
+		Active:     state.IsActive,
+	}
+}
+
+// SessionAccounts returns every player's session accounting, by player
+func (g *Game) SessionAccounts() []SessionAccount {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	accounts := make([]SessionAccount, 0, len(g.playerStates))
+	for _, state := range g.playerStates {
+		accounts = append(accounts, state.sessionAccount())
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].PlayerID < accounts[j].PlayerID
+	})
+	return accounts
+}
+
+// SessionAccount returns one player's session accounting
+func (g *Game) SessionAccount(playerID string) (SessionAccount, bool) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	state, ok := g.playerStates[playerID]
+	if !ok {
+		return SessionAccount{}, false
+	}
+	return state.sessionAccount(), true
+}
+
+// forfeitSession settles the session of a player who abandoned it: the hand
+// in progress is voided, the chips they have left are split evenly between
+// the players still active, and they leave the table. It returns their
+// account as it stood, the remaining players' addresses, and each one's
+// share and stack once paid, read on the loop so callers need not touch
+// the players.
+func (g *Game) forfeitSession(playerID string) (account SessionAccount, remaining []string, shares, stacks map[string]int, err error) {
+	err = g.exec("forfeit session", func() error {
+		var players []*PlayerState
+		account, players, shares, err = g.forfeit(playerID)
+		if err != nil {
+			return err
+		}
+		stacks = make(map[string]int, len(players))
+		for _, p := range players {
+			remaining = append(remaining, p.ListenAddr)
+			stacks[p.ListenAddr] = p.Stack
+		}
+		return nil
+	})
+	return account, remaining, shares, stacks, err
+}
+
+func (g *Game) forfeit(playerID string) (SessionAccount, []*PlayerState, map[string]int, error) {
+	state, ok := g.playerStates[playerID]
+	if !ok {
+		return SessionAccount{}, nil, nil, fmt.Errorf("player %s not found", playerID)
+	}
+
+	var remaining []*PlayerState
+	for addr, other := range g.playerStates {
+		if addr != playerID && other.IsActive {
+			remaining = append(remaining, other)
+		}
+	}
+	if len(remaining) == 0 {
+		return SessionAccount{}, nil, nil, fmt.Errorf("no remaining players to distribute penalty")
+	}
+	sort.Slice(remaining, func(i, j int) bool {
+		return remaining[i].ListenAddr < remaining[j].ListenAddr
+	})
+
+	if g.currentStatus != GameStatusWaiting {
+		g.abandonHand(playerID+" abandoned the game", "table")
+	}
+	account := state.sessionAccount()
+
+	forfeit := state.Stack
+	share, remainder := forfeit/len(remaining), forfeit%len(remaining)
+	shares := make(map[string]int, len(remaining))
+	for i, other := range remaining {
+		amount := share
+		if i < remainder {
+			amount++
+		}
+		other.Stack += amount
+		shares[other.ListenAddr] = amount
+		g.recordLedger(persistence.LedgerPenalty, other.ListenAddr, amount)
+	}
+	state.Stack = 0
+	g.recordLedger(persistence.LedgerPenalty, playerID, -forfeit)
+
+	g.audit("session_forfeited", "table", map[string]interface{}{
+		"player":   playerID,
+		"invested": account.Invested,
+		"forfeit":  forfeit,
+		"shares":   shares,
+	})
+	g.removePlayer(playerID, "abandoned")
+	return account, remaining, shares, nil
+}
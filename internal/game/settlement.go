@@ -0,0 +1,162 @@
+package game
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/blockchain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+)
+
+// FailedSettlement is an escrow payout that did not go through on-chain. For
+// a batch, Winners are the players whose chips moved and Amounts their net results.
+type FailedSettlement struct {
+	ID       int       `json:"id"`
+	HandID   int       `json:"hand_id"`
+	HandUID  string    `json:"hand_uid"`
+	Kind     string    `json:"kind"`
+	GameID   string    `json:"game_id"`
+	Winners  []string  `json:"winners"`
+	Amounts  []int     `json:"amounts"`
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failed_at"`
+
+	// Player signatures collected for the payout, resubmitted on retry
+	Attestations []Attestation `json:"attestations,omitempty"`
+
+	// Sequence number of a channel state (see channel.go)
+	Seq uint64 `json:"seq,omitempty"`
+
+	gameID [32]byte
+}
+
+// FailedSettlements returns payouts waiting to be retried
+func (g *Game) FailedSettlements() []FailedSettlement {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	list := make([]FailedSettlement, len(g.failedSettlements))
+	for i, s := range g.failedSettlements {
+		list[i] = *s
+	}
+	return list
+}
+
+// RetrySettlements resubmits failed payouts and returns how many remain stuck
+func (g *Game) RetrySettlements(actor string) (settled, remaining int, err error) {
+	err = g.exec("retry settlements", func() error {
+		settled, remaining, err = g.retrySettlements(actor)
+		return err
+	})
+	return settled, remaining, err
+}
+
+func (g *Game) retrySettlements(actor string) (settled, remaining int, err error) {
+	if !g.blockchainEnabled {
+		return 0, 0, fmt.Errorf("blockchain is not enabled")
+	}
+
+	stuck := g.failedSettlements[:0]
+	for _, s := range g.failedSettlements {
+		s.Attempts++
+		var receipt *blockchain.TxReceipt
+		var err error
+		var channelState *ChannelState
+		switch s.Kind {
+		case ChainTxBatch:
+			receipt, err = g.settleBatchOnChain(s.gameID, s.Winners, s.Amounts)
+		case ChainTxChannelSubmit:
+			channelState = s.channelState()
+			receipt, err = g.submitChannelState(s.gameID, channelState)
+		default:
+			receipt, err = g.endGame(s.gameID, s.Winners, s.Amounts, s.Attestations)
+		}
+
+		tx := newChainTx(s.Kind, receipt, err)
+		g.auditSettlement(s.Kind, s.gameID, s.Winners, s.Amounts, receipt, err)
+		g.storeSettlement(s.HandID, s.HandUID, s.Kind, s.gameID, s.Winners, s.Amounts, receipt, err)
+		if hand := g.handByID(s.HandID); hand != nil {
+			attachChainTx(hand, s.GameID, tx)
+		}
+
+		if err != nil {
+			s.Error = err.Error()
+			s.FailedAt = time.Now()
+			stuck = append(stuck, s)
+			g.log().Warnf("Settlement %d retry failed: %v", s.ID, err)
+			continue
+		}
+
+		if channelState != nil {
+			g.scheduleChannelFinalize(s.gameID, channelState)
+		}
+		settled++
+		g.log().WithFields(logrus.Fields{
+			"settlement": s.ID,
+			"hand":       s.HandID,
+			"hand_uid":   s.HandUID,
+			"attempts":   s.Attempts,
+		}).Info("✅ Stuck settlement completed")
+	}
+	g.failedSettlements = stuck
+
+	g.audit("settlements_retried", actor, map[string]interface{}{
+		"settled":   settled,
+		"remaining": len(stuck),
+	})
+	return settled, len(stuck), nil
+}
+
+// channelState rebuilds the signed channel state a failed submission carried
+func (s *FailedSettlement) channelState() *ChannelState {
+	return &ChannelState{
+		GameID:     s.GameID,
+		Seq:        s.Seq,
+		HandID:     s.HandID,
+		HandUID:    s.HandUID,
+		Players:    s.Winners,
+		Stacks:     s.Amounts,
+		Signatures: s.Attestations,
+	}
+}
+
+// recordFailedSettlement keeps a payout that failed on-chain, or was never
+// fully attested, for a later retry
+func (g *Game) recordFailedSettlement(p *pendingSettlement, err error) {
+	g.settlementCount++
+	g.failedSettlements = append(g.failedSettlements, &FailedSettlement{
+		ID:       g.settlementCount,
+		HandID:   p.handID,
+		HandUID:  p.handUID,
+		Kind:     p.kind,
+		GameID:   fmt.Sprintf("0x%x", p.gameID),
+		Winners:  append([]string{}, p.winners...),
+		Amounts:  append([]int{}, p.amounts...),
+		Error:    err.Error(),
+		Attempts: 1,
+		FailedAt: time.Now(),
+		gameID:   p.gameID,
+
+		Attestations: p.attestationList(),
+		Seq:          p.seq,
+	})
+}
+
+func toAddresses(addrs []string) []common.Address {
+	out := make([]common.Address, len(addrs))
+	for i, addr := range addrs {
+		out[i] = common.HexToAddress(addr)
+	}
+	return out
+}
+
+func toWei(amounts []int) []*big.Int {
+	out := make([]*big.Int, len(amounts))
+	for i, amount := range amounts {
+		out[i] = big.NewInt(int64(amount))
+	}
+	return out
+}
@@ -0,0 +1,214 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/RedPaladin7/peerpoker/internal/blockchain"
+	"github.com/sirupsen/logrus"
+)
+
+// ChainTxBatch is the escrow transaction settling the net result of a
+// batch of hands
+const ChainTxBatch = "batch_settle"
+
+// SettlementBatchSession as the table's SettlementBatch nets every hand of
+// the session into one settlement, made when the session ends or an
+// operator forces it
+const SettlementBatchSession = -1
+
+// SettlementBatch is the net result of hands not yet settled on-chain. The
+// hands share one escrow game, which stays open until the batch settles.
+type SettlementBatch struct {
+	GameID   string         `json:"game_id"`
+	HandIDs  []int          `json:"hand_ids"`
+	HandUIDs []string       `json:"hand_uids"`
+	Deltas   map[string]int `json:"deltas"` // chips each player won (+) or lost (-) across the hands
+
+	// Forced while a hand was in progress, so it settles when that hand ends
+	Settling bool `json:"settling,omitempty"`
+
+	gameID [32]byte
+}
+
+func (b *SettlementBatch) copy() *SettlementBatch {
+	copied := *b
+	copied.HandIDs = append([]int{}, b.HandIDs...)
+	copied.HandUIDs = append([]string{}, b.HandUIDs...)
+	copied.Deltas = make(map[string]int, len(b.Deltas))
+	for addr, delta := range b.Deltas {
+		copied.Deltas[addr] = delta
+	}
+	return &copied
+}
+
+// netDeltas lists the players whose chips moved across the batch, in
+// address order, with their net results
+func (b *SettlementBatch) netDeltas() ([]string, []int) {
+	players := make([]string, 0, len(b.Deltas))
+	for addr, delta := range b.Deltas {
+		if delta != 0 {
+			players = append(players, addr)
+		}
+	}
+	sort.Strings(players)
+
+	deltas := make([]int, len(players))
+	for i, addr := range players {
+		deltas[i] = b.Deltas[addr]
+	}
+	return players, deltas
+}
+
+// batchingSettlements reports whether hands settle in batches rather than
+// one by one
+func (g *Game) batchingSettlements() bool {
+	size := g.tableConfig.SettlementBatch
+	return size > 1 || size == SettlementBatchSession
+}
+
+// settleHand pays out a finished hand on-chain, or adds its result to the
+// open batch and settles the batch once it holds enough hands. With a state
+// channel nothing goes on-chain until the session ends.
+func (g *Game) settleHand(winners []string, amounts []int) {
+	if g.usingStateChannel() {
+		g.advanceChannel()
+		return
+	}
+	if !g.batchingSettlements() {
+		g.distributeWinningsOnChain(ChainTxSettle, winners, amounts)
+		return
+	}
+
+	// A batch only covers hands played in its own escrow game
+	if g.settlementBatch != nil && g.settlementBatch.gameID != g.blockchainGameID {
+		g.flushSettlementBatch()
+	}
+	b := g.settlementBatch
+	if b == nil {
+		b = &SettlementBatch{
+			GameID: fmt.Sprintf("0x%x", g.blockchainGameID),
+			Deltas: make(map[string]int),
+			gameID: g.blockchainGameID,
+		}
+		g.settlementBatch = b
+	}
+
+	for addr, state := range g.playerStates {
+		if state.TotalBetThisHand > 0 {
+			b.Deltas[addr] -= state.TotalBetThisHand
+		}
+	}
+	for i, winner := range winners {
+		b.Deltas[winner] += amounts[i]
+	}
+	b.HandIDs = append(b.HandIDs, g.currentHandID())
+	b.HandUIDs = append(b.HandUIDs, g.handUID)
+
+	g.log().WithFields(logrus.Fields{
+		"game_id": b.GameID,
+		"hands":   len(b.HandIDs),
+		"size":    g.tableConfig.SettlementBatch,
+	}).Debug("Hand added to settlement batch")
+
+	if b.Settling || (g.tableConfig.SettlementBatch > 0 && len(b.HandIDs) >= g.tableConfig.SettlementBatch) {
+		g.flushSettlementBatch()
+	}
+}
+
+// flushSettlementBatch submits the open batch's net deltas in one
+// transaction, keeping it for a retry if that fails. Each hand in the
+// batch is linked to the transaction.
+func (g *Game) flushSettlementBatch() {
+	b := g.settlementBatch
+	if b == nil {
+		return
+	}
+	g.settlementBatch = nil
+
+	players, deltas := b.netDeltas()
+	last := len(b.HandIDs) - 1
+	p := &pendingSettlement{
+		kind:    ChainTxBatch,
+		handID:  b.HandIDs[last],
+		handUID: b.HandUIDs[last],
+		gameID:  b.gameID,
+		winners: players,
+		amounts: deltas,
+	}
+
+	receipt, err := g.settleBatchOnChain(p.gameID, players, deltas)
+	tx := newChainTx(ChainTxBatch, receipt, err)
+	for _, id := range b.HandIDs {
+		if hand := g.handByID(id); hand != nil {
+			attachChainTx(hand, b.GameID, tx)
+		}
+	}
+	g.storeSettlement(p.handID, p.handUID, p.kind, p.gameID, players, deltas, receipt, err)
+	g.auditSettlement(p.kind, p.gameID, players, deltas, receipt, err)
+	if err != nil {
+		g.log().Errorf("Failed to settle batch of %d hands on blockchain: %v", len(b.HandIDs), err)
+		g.recordFailedSettlement(p, err)
+		return
+	}
+
+	g.log().WithFields(logrus.Fields{
+		"game_id": b.GameID,
+		"hands":   len(b.HandIDs),
+		"players": len(players),
+	}).Info("✅ Settlement batch submitted on blockchain")
+}
+
+func (g *Game) settleBatchOnChain(gameID [32]byte, players []string, deltas []int) (*blockchain.TxReceipt, error) {
+	return g.chain().SettleBatch(gameID, toAddresses(players), toWei(deltas))
+}
+
+// PendingSettlementBatch returns the hands waiting to be settled together, or nil
+func (g *Game) PendingSettlementBatch() *SettlementBatch {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if g.settlementBatch == nil {
+		return nil
+	}
+	return g.settlementBatch.copy()
+}
+
+// SettleBatch settles the open batch now, or as soon as the hand in
+// progress ends, and returns it as it stood. It returns nil when no hands
+// are waiting.
+func (g *Game) SettleBatch(actor string) (batch *SettlementBatch, err error) {
+	err = g.exec("settle batch", func() error {
+		batch, err = g.settleBatch(actor)
+		return err
+	})
+	return batch, err
+}
+
+func (g *Game) settleBatch(actor string) (*SettlementBatch, error) {
+	if !g.blockchainEnabled {
+		return nil, fmt.Errorf("blockchain is not enabled")
+	}
+	b := g.settlementBatch
+	if b == nil {
+		return nil, nil
+	}
+
+	g.audit("settlement_batch_forced", actor, map[string]interface{}{
+		"game_id": b.GameID,
+		"hands":   len(b.HandIDs),
+	})
+
+	if g.currentStatus != GameStatusWaiting {
+		b.Settling = true
+		return b.copy(), nil
+	}
+
+	settled := b.copy()
+	g.flushSettlementBatch()
+	// The batch's escrow game is closed; the next hand opens a new one
+	if g.blockchainGameID == settled.gameID {
+		g.blockchainGameID = [32]byte{}
+	}
+	return settled, nil
+}
@@ -1,14 +1,12 @@
 package game
 
 import (
-	"fmt"
-	"math/big"
+	"time"
 
 	"github.com/RedPaladin7/peerpoker/internal/blockchain"
 	"github.com/RedPaladin7/peerpoker/internal/crypto"
 	"github.com/RedPaladin7/peerpoker/internal/deck"
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/sirupsen/logrus"
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
 )
 
 type PlayerHand struct {
@@ -16,11 +14,26 @@ type PlayerHand struct {
 	Hand     []deck.Card
 	Rank     int32
 	HandName string
+
+	// Hi-lo tables only: the player's best eight-or-better low, if any
+	Low    deck.LowHand
+	HasLow bool
+}
+
+// potHalf is the share of a pot going to the high or the low hands
+type potHalf struct {
+	amount  int
+	winners []*PlayerHand
+	low     bool
 }
 
-// ResolveWinner determines the winner(s) and distributes pots
-func (g *Game) ResolveWinner() {
-	logrus.Info("=== RESOLVING WINNER ===")
+// resolveWinner determines the winner(s) and distributes pots, on the
+// table's loop
+func (g *Game) resolveWinner() {
+	g.log().Info("=== RESOLVING WINNER ===")
+
+	stacksBefore := g.snapshotStacks()
+	potBefore := g.currentPot
 
 	activePlayers := g.getReadyActivePlayers()
 	nonFoldedPlayers := []string{}
@@ -35,151 +48,234 @@ func (g *Game) ResolveWinner() {
 	// Only one player left (everyone else folded)
 	if len(nonFoldedPlayers) == 1 {
 		winnerAddr := nonFoldedPlayers[0]
-		winAmount := g.currentPot
+		pot := []SidePot{{Amount: g.currentPot}}
+		g.collectRake(pot)
+		winAmount := pot[0].Amount
 		g.playerStates[winnerAddr].Stack += winAmount
+		g.recordPotAward(winnerAddr, 1, winAmount)
 
-		logrus.Infof("🏆 WINNER BY DEFAULT: %s wins %d chips (everyone else folded)!",
+		g.log().Infof("🏆 WINNER BY DEFAULT: %s wins %d chips (everyone else folded)!",
 			winnerAddr, winAmount)
 
 		// Blockchain: Distribute winnings on-chain
 		if g.blockchainEnabled && g.blockchainGameID != [32]byte{} {
-			g.distributeWinningsOnChain([]string{winnerAddr}, []int{winAmount})
+			winners, amounts := g.rakePayout([]string{winnerAddr}, []int{winAmount})
+			g.settleHand(winners, amounts)
 		}
 
+		g.recordHandResult(stacksBefore, potBefore, nil)
+		g.offerRabbitHunt()
 		g.resetHandState()
 		return
 	}
 
-	// Multiple players - evaluate hands
-	playerHands := make([]PlayerHand, 0, len(nonFoldedPlayers))
-
-	for _, playerAddr := range nonFoldedPlayers {
-		// Decrypt player's hole cards using revealed keys
-		holeCards := g.decryptPlayerCards(playerAddr)
-
-		// Evaluate hand
-		rank, handName := deck.EvaluateBestHand(holeCards, g.communityCards)
-
-		logrus.Infof("Player %s: %v - %s (Rank: %d)",
-			playerAddr, holeCards, handName, rank)
-
-		playerHands = append(playerHands, PlayerHand{
-			Addr:     playerAddr,
-			Hand:     holeCards,
-			Rank:     rank,
-			HandName: handName,
-		})
+	// Multiple players - evaluate hands on each board. With run-it-twice,
+	// every pot is split between the two runs.
+	playerHands := g.evaluateHands(nonFoldedPlayers, g.communityCards)
+	runs := [][]PlayerHand{playerHands}
+	if len(g.secondBoard) > 0 {
+		g.secondRunHands = g.evaluateHands(nonFoldedPlayers, g.secondBoard)
+		runs = append(runs, g.secondRunHands)
 	}
 
-	// Calculate side pots
+	// Calculate side pots (a single main pot when there is nothing to split)
 	sidePots := g.calculateSidePots()
+	mainPotOnly := len(sidePots) == 0
+	if mainPotOnly {
+		sidePots = []SidePot{{Amount: g.currentPot, EligiblePlayers: nonFoldedPlayers}}
+	}
+	if err := g.checkPots(sidePots); err != nil {
+		g.freeze("showdown", err)
+		return
+	}
+	g.collectRake(sidePots)
 
 	// Track all winners and amounts for blockchain
 	allWinners := []string{}
 	allAmounts := []int{}
 
-	if len(sidePots) > 0 {
-		logrus.Infof("Distributing %d pot(s)...", len(sidePots))
+	g.log().Infof("Distributing %d pot(s) over %d run(s)...", len(sidePots), len(runs))
 
+	for run, hands := range runs {
 		for i, pot := range sidePots {
-			logrus.Infof("Pot #%d: %d chips (cap: %d)", i+1, pot.Amount, pot.Cap)
-
-			// Find best hand among eligible players
-			bestRank := int32(999999)
-			potWinners := []*PlayerHand{}
+			amount := runShare(pot.Amount, run, len(runs))
+			g.log().Infof("Pot #%d, run %d: %d chips (cap: %d)", i+1, run+1, amount, pot.Cap)
 
-			for idx := range playerHands {
-				ph := &playerHands[idx]
+			potWinners := bestHands(hands, pot.EligiblePlayers)
+			if len(potWinners) == 0 {
+				continue
+			}
 
-				// Check if player is eligible for this pot
-				isEligible := false
-				for _, eligibleAddr := range pot.EligiblePlayers {
-					if ph.Addr == eligibleAddr {
-						isEligible = true
-						break
-					}
-				}
+			potNum := i + 1
+			if mainPotOnly {
+				potNum = 0
+			}
 
-				if isEligible {
-					if ph.Rank < bestRank {
-						bestRank = ph.Rank
-						potWinners = []*PlayerHand{ph}
-					} else if ph.Rank == bestRank {
-						potWinners = append(potWinners, ph)
+			// Hi-lo: the best qualifying low takes half, the high any odd chip
+			halves := []potHalf{{amount: amount, winners: potWinners}}
+			if g.tableConfig.HiLo {
+				if lowWinners := bestLows(hands, pot.EligiblePlayers); len(lowWinners) > 0 {
+					halves = []potHalf{
+						{amount: amount - amount/2, winners: potWinners},
+						{amount: amount / 2, winners: lowWinners, low: true},
 					}
 				}
 			}
 
-			if len(potWinners) > 0 {
-				g.distributePot(pot.Amount, potWinners, i+1)
+			for _, half := range halves {
+				credited := g.distributePot(half.amount, half.winners, potNum)
 
-				// Collect for blockchain payout
-				for _, winner := range potWinners {
-					share := pot.Amount / len(potWinners)
+				// Collect for blockchain payout, odd chips included
+				for j, winner := range half.winners {
+					share := credited[j]
 					allWinners = append(allWinners, winner.Addr)
 					allAmounts = append(allAmounts, share)
+					if half.low {
+						g.lowWinnings[winner.Addr] += share
+					}
 				}
 			}
 		}
-	} else {
-		// Single main pot
-		bestRank := int32(999999)
-		winners := []*PlayerHand{}
-
-		for idx := range playerHands {
-			ph := &playerHands[idx]
-			if ph.Rank < bestRank {
-				bestRank = ph.Rank
-				winners = []*PlayerHand{ph}
-			} else if ph.Rank == bestRank {
-				winners = append(winners, ph)
-			}
+	}
+
+	// Blockchain: Distribute all winnings on-chain
+	if g.blockchainEnabled && g.blockchainGameID != [32]byte{} && len(allWinners) > 0 {
+		allWinners, allAmounts = g.rakePayout(allWinners, allAmounts)
+		g.settleHand(allWinners, allAmounts)
+	}
+
+	g.recordHandResult(stacksBefore, potBefore, playerHands)
+	g.resetHandState()
+}
+
+// evaluateHands decrypts and ranks each player's hole cards against a board
+func (g *Game) evaluateHands(players []string, board []deck.Card) []PlayerHand {
+	hands := make([]PlayerHand, 0, len(players))
+	for _, playerAddr := range players {
+		// Decrypt player's hole cards using revealed keys
+		holeCards := g.decryptPlayerCards(playerAddr)
+
+		// Evaluate hand
+		if g.tableConfig.HiLo {
+			hands = append(hands, g.evaluateHiLoHand(playerAddr, holeCards, board))
+			continue
 		}
+		rank, handName := g.evaluator.EvaluateBestHand(holeCards, board)
 
-		if len(winners) > 0 {
-			g.distributePot(g.currentPot, winners, 0)
+		g.log().Infof("Player %s: %v - %s (Rank: %d)",
+			playerAddr, holeCards, handName, rank)
 
-			// Collect for blockchain payout
-			for _, winner := range winners {
-				share := g.currentPot / len(winners)
-				allWinners = append(allWinners, winner.Addr)
-				allAmounts = append(allAmounts, share)
-			}
+		hands = append(hands, PlayerHand{
+			Addr:     playerAddr,
+			Hand:     holeCards,
+			Rank:     rank,
+			HandName: handName,
+		})
+	}
+	return hands
+}
+
+// evaluateHiLoHand scores a hand high and low under Omaha rules: exactly two
+// hole cards with exactly three from the board
+func (g *Game) evaluateHiLoHand(playerAddr string, holeCards, board []deck.Card) PlayerHand {
+	ph := PlayerHand{Addr: playerAddr, Hand: holeCards, Rank: deck.InvalidRank, HandName: "Invalid Hand"}
+	if high, ok := deck.EvaluateOmahaHigh(holeCards, board); ok {
+		ph.Rank = high.Rank()
+		ph.HandName = high.String()
+	}
+	ph.Low, ph.HasLow = deck.EvaluateOmahaLow(holeCards, board)
+
+	low := "no low"
+	if ph.HasLow {
+		low = ph.Low.String()
+	}
+	g.log().Infof("Player %s: %v - %s / %s (Rank: %d)",
+		playerAddr, holeCards, ph.HandName, low, ph.Rank)
+	return ph
+}
+
+// bestLows returns the best qualifying lows among the players eligible for a pot
+func bestLows(hands []PlayerHand, eligible []string) []*PlayerHand {
+	isEligible := make(map[string]bool, len(eligible))
+	for _, addr := range eligible {
+		isEligible[addr] = true
+	}
+
+	winners := []*PlayerHand{}
+	for idx := range hands {
+		ph := &hands[idx]
+		if !isEligible[ph.Addr] || !ph.HasLow {
+			continue
+		}
+		switch {
+		case len(winners) == 0 || ph.Low.Compare(winners[0].Low) > 0:
+			winners = []*PlayerHand{ph}
+		case ph.Low.Compare(winners[0].Low) == 0:
+			winners = append(winners, ph)
 		}
 	}
+	return winners
+}
 
-	// Blockchain: Distribute all winnings on-chain
-	if g.blockchainEnabled && g.blockchainGameID != [32]byte{} && len(allWinners) > 0 {
-		g.distributeWinningsOnChain(allWinners, allAmounts)
+// bestHands returns the strongest hands among the players eligible for a pot
+func bestHands(hands []PlayerHand, eligible []string) []*PlayerHand {
+	isEligible := make(map[string]bool, len(eligible))
+	for _, addr := range eligible {
+		isEligible[addr] = true
 	}
 
-	g.resetHandState()
+	bestRank := deck.InvalidRank
+	winners := []*PlayerHand{}
+	for idx := range hands {
+		ph := &hands[idx]
+		if !isEligible[ph.Addr] {
+			continue
+		}
+		if ph.Rank > bestRank {
+			bestRank = ph.Rank
+			winners = []*PlayerHand{ph}
+		} else if ph.Rank == bestRank {
+			winners = append(winners, ph)
+		}
+	}
+	return winners
+}
+
+// runShare is the part of a pot played for on one run; the first run takes any odd chip
+func runShare(amount, run, runs int) int {
+	share := amount / runs
+	if run == 0 {
+		share += amount % runs
+	}
+	return share
 }
 
 // decryptPlayerCards decrypts a player's hole cards using all revealed keys
 func (g *Game) decryptPlayerCards(playerAddr string) []deck.Card {
-	// Get player's card indices (first two cards for this player)
+	// A player's cards are the HoleCards in a row at their rotation ID
 	state := g.playerStates[playerAddr]
-	cardIndices := []int{state.RotationID * 2, state.RotationID*2 + 1}
+	holeCards := g.tableConfig.HoleCards()
 
-	cards := make([]deck.Card, 0, 2)
+	cards := make([]deck.Card, 0, holeCards)
 
-	for _, idx := range cardIndices {
+	for idx := state.RotationID * holeCards; idx < (state.RotationID+1)*holeCards; idx++ {
 		if idx >= len(g.currentDeck) {
-			logrus.Warnf("Card index %d out of bounds", idx)
+			g.log().Warnf("Card index %d out of bounds", idx)
+			continue
+		}
+
+		if g.sim != nil {
+			if card, ok := g.simCard(idx); ok {
+				cards = append(cards, card)
+			}
 			continue
 		}
 
 		encryptedCard := g.currentDeck[idx]
 
-		// Decrypt using all revealed keys (from folded players and this player)
+		// Decrypt using all revealed keys
 		decryptedCard := encryptedCard
 
-		// Apply folded player keys
-		for _, keys := range g.foldedPlayerKeys {
-			decryptedCard = keys.Decrypt(decryptedCard)
-		}
-
 		// Apply revealed keys
 		for _, keys := range g.revealedKeys {
 			decryptedCard = keys.Decrypt(decryptedCard)
@@ -195,50 +291,33 @@ func (g *Game) decryptPlayerCards(playerAddr string) []deck.Card {
 	return cards
 }
 
-// distributeWinningsOnChain sends payout transaction to smart contract
-func (g *Game) distributeWinningsOnChain(winners []string, amounts []int) {
-	winnerAddrs := make([]common.Address, len(winners))
-	winnerAmounts := make([]*big.Int, len(amounts))
-
-	for i := range winners {
-		winnerAddrs[i] = common.HexToAddress(winners[i])
-		winnerAmounts[i] = big.NewInt(int64(amounts[i]))
+// InitiateShuffleAndDeal starts the mental poker protocol
+func (g *Game) InitiateShuffleAndDeal() {
+	if g.replayDeal != nil {
+		g.dealReplayedDeck()
+		return
 	}
-
-	logrus.WithFields(logrus.Fields{
-		"game_id": fmt.Sprintf("0x%x", g.blockchainGameID),
-		"winners": len(winners),
-	}).Info("Distributing winnings on blockchain...")
-
-	err := g.blockchain.EndGame(g.blockchainGameID, winnerAddrs, winnerAmounts)
-	if err != nil {
-		logrus.Errorf("Failed to distribute winnings on blockchain: %v", err)
-		logrus.Warn("Winnings distributed in-game only (blockchain transaction failed)")
-	} else {
-		logrus.WithFields(logrus.Fields{
-			"game_id": fmt.Sprintf("0x%x", g.blockchainGameID),
-			"winners": len(winners),
-		}).Info("✅ Winnings distributed on blockchain successfully")
+	if g.sim != nil {
+		g.simShuffleAndDeal()
+		return
 	}
-}
 
-// InitiateShuffleAndDeal starts the mental poker protocol
-func (g *Game) InitiateShuffleAndDeal() {
-	logrus.Info("Initiating shuffle and deal protocol...")
+	g.log().Info("Initiating shuffle and deal protocol...")
 
 	// Step 1: Create initial deck
 	initialDeck := deck.NewDeck()
 	g.currentDeck = initialDeck.ToBytes()
 
-	logrus.Infof("Created initial deck with %d cards", len(g.currentDeck))
-
-	// Step 2: Encrypt deck with our keys
-	g.currentDeck = crypto.EncryptDeck(g.currentDeck, g.deckKeys)
-	logrus.Info("Encrypted deck with our keys")
+	g.log().Infof("Created initial deck with %d cards", len(g.currentDeck))
+	g.beginShuffleAudit(g.currentDeck)
 
-	// Step 3: Shuffle the deck
-	g.currentDeck = crypto.ShuffleDeck(g.currentDeck)
-	logrus.Info("Shuffled deck")
+	// Steps 2 and 3: Encrypt deck with fresh keys for this hand and shuffle it
+	if err := g.rotateDeckKeys(); err != nil {
+		g.freeze("generating deck keys", err)
+		return
+	}
+	g.shufflePass(g.listenAddr, g.deckKeys)
+	g.log().Info("Encrypted and shuffled deck with our keys")
 
 	// Step 4: In a real P2P game, each player would:
 	// - Receive the deck
@@ -254,94 +333,131 @@ func (g *Game) InitiateShuffleAndDeal() {
 		}
 
 		// Simulate other players encrypting (in real implementation, this happens via P2P)
-		logrus.Infof("Simulating encryption by player %d (%s)", i, playerAddr)
+		g.log().Infof("Simulating encryption by player %d (%s)", i, playerAddr)
 		
 		// Generate temporary keys for this player (in reality, they would use their own)
 		tempKeys, _ := crypto.GenerateCardKeys()
-		g.currentDeck = crypto.EncryptDeck(g.currentDeck, tempKeys)
-		g.currentDeck = crypto.ShuffleDeck(g.currentDeck)
+		g.shufflePass(playerAddr, tempKeys)
 		
 		// Store keys for later decryption
 		g.revealedKeys[playerAddr] = tempKeys
 	}
 
-	logrus.Infof("Deck fully encrypted and shuffled by %d players", len(activePlayers))
+	g.log().Infof("Deck fully encrypted and shuffled by %d players", len(activePlayers))
+	g.finishShuffleAudit()
+	g.logDeal(dealSecrets{Deck: g.currentDeck, Keys: g.revealedKeys, Own: g.deckKeys})
 
 	// Step 5: Deal cards (encrypt indices are known to all players)
 	g.dealHoleCards()
 	
 	// Update game status
 	g.setStatus(GameStatusPreFlop)
-	logrus.Info("Cards dealt, starting pre-flop betting")
+	g.turnStartedAt = time.Now()
+	g.log().Info("Cards dealt, starting pre-flop betting")
 }
 
-// dealHoleCards deals 2 cards to each player
+// dealHoleCards deals each player their hole cards: two in Hold'em, four in Omaha
 func (g *Game) dealHoleCards() {
 	activePlayers := g.getReadyActivePlayers()
+	holeCards := g.tableConfig.HoleCards()
 	
 	for i, playerAddr := range activePlayers {
-		card1Idx := i * 2
-		card2Idx := i*2 + 1
+		first := i * holeCards
+		last := first + holeCards - 1
 
-		logrus.Infof("Player %s assigned cards at indices [%d, %d]", playerAddr, card1Idx, card2Idx)
+		g.log().Infof("Player %s assigned cards at indices [%d..%d]", playerAddr, first, last)
 
 		// If this is us, decrypt our cards
 		if playerAddr == g.listenAddr {
 			g.myHand = g.decryptPlayerCards(g.listenAddr)
-			logrus.Infof("Our hand: %v", g.myHand)
+			g.log().Infof("Our hand: %v", g.myHand)
 		}
 	}
 
-	logrus.Info("Hole cards dealt to all players")
+	g.log().Info("Hole cards dealt to all players")
 }
 
 // dealCommunityCards deals community cards (flop, turn, or river)
 func (g *Game) dealCommunityCards(count int) {
-	numPlayers := len(g.getReadyActivePlayers())
-	startIdx := numPlayers*2 + len(g.communityCards)
+	startIdx := g.boardStart() + len(g.communityCards)
 
 	for i := 0; i < count; i++ {
-		cardIdx := startIdx + i
-		if cardIdx >= len(g.currentDeck) {
-			logrus.Warnf("Not enough cards in deck for community card %d", i)
+		card, ok := g.decryptBoardCard(startIdx + i)
+		if !ok {
 			continue
 		}
+		g.communityCards = append(g.communityCards, card)
+		g.log().Infof("Dealt community card: %s", card.String())
+	}
 
-		encryptedCard := g.currentDeck[cardIdx]
-		decryptedCard := encryptedCard
+	board := make([]byte, len(g.communityCards))
+	for i, card := range g.communityCards {
+		board[i] = card.ToByte()
+	}
+	g.logWAL(persistence.WALReveal, walReveal{Board: board})
+}
 
-		// Decrypt using all player keys
-		for _, keys := range g.revealedKeys {
-			decryptedCard = keys.Decrypt(decryptedCard)
-		}
+// boardStart is the deck index of the first board card, after every
+// player's hole cards
+func (g *Game) boardStart() int {
+	return len(g.getReadyActivePlayers()) * g.tableConfig.HoleCards()
+}
 
-		// Decrypt with our keys
-		decryptedCard = g.deckKeys.Decrypt(decryptedCard)
+// decryptBoardCard decrypts a community card at a deck index using every player's keys
+func (g *Game) decryptBoardCard(cardIdx int) (deck.Card, bool) {
+	if cardIdx >= len(g.currentDeck) {
+		g.log().Warnf("Not enough cards in deck for community card at index %d", cardIdx)
+		return deck.Card{}, false
+	}
+	if g.sim != nil {
+		return g.simCard(cardIdx)
+	}
 
-		if len(decryptedCard) > 0 {
-			card := deck.NewCardFromByte(decryptedCard[0])
-			g.communityCards = append(g.communityCards, card)
-			logrus.Infof("Dealt community card: %s", card.String())
+	decryptedCard, shared := g.foldShareFor(cardIdx)
+	if decryptedCard == nil {
+		return deck.Card{}, false
+	}
+
+	// Decrypt using all player keys, bar the one whose share we started from
+	for addr, keys := range g.revealedKeys {
+		if addr == shared {
+			continue
 		}
+		decryptedCard = keys.Decrypt(decryptedCard)
 	}
+
+	// Decrypt with our keys
+	decryptedCard = g.deckKeys.Decrypt(decryptedCard)
+
+	if len(decryptedCard) == 0 {
+		return deck.Card{}, false
+	}
+	return deck.NewCardFromByte(decryptedCard[0]), true
 }
 
 // resetHandState resets the game state for a new hand
 func (g *Game) resetHandState() {
-	logrus.Info("=== Resetting for new hand ===")
+	g.log().Info("=== Resetting for new hand ===")
 
 	g.currentPot = 0
 	g.highestBet = 0
+	g.fullRaiseTo = 0
 	g.lastRaiseAmount = BigBlind
 	g.myHand = make([]deck.Card, 0, 2)
 	g.communityCards = make([]deck.Card, 0, 5)
+	g.runOffer = nil
+	g.showdown = nil
+	g.secondBoard = nil
+	g.secondRunHands = nil
+	g.lowWinnings = make(map[string]int)
 	g.currentDeck = nil
-	g.sidePots = []SidePot{}
-	g.revealedKeys = make(map[string]*crypto.CardKeys)
-	g.foldedPlayerKeys = make(map[string]*crypto.CardKeys)
+	g.pot = NewPotLedger()
+	g.closeFoldShares()
+	g.retireDeckKeys()
 
-	// Reset blockchain game ID for next hand
-	if g.blockchainEnabled {
+	// Reset blockchain game ID for next hand, unless an open settlement
+	// batch or state channel keeps its escrow game going
+	if g.blockchainEnabled && g.settlementBatch == nil && g.channel == nil {
 		g.blockchainGameID = [32]byte{}
 	}
 
@@ -349,7 +465,7 @@ func (g *Game) resetHandState() {
 	for addr, state := range g.playerStates {
 		if state.Stack <= 0 {
 			state.IsActive = false
-			logrus.Infof("Player %s eliminated (no chips)", addr)
+			g.log().Infof("Player %s eliminated (no chips)", addr)
 		}
 	}
 
@@ -358,6 +474,8 @@ func (g *Game) resetHandState() {
 		g.setStatus(GameStatusWaiting)
 	} else {
 		g.setStatus(GameStatusWaiting)
-		logrus.Info("Not enough players, waiting for more")
+		g.log().Info("Not enough players, waiting for more")
 	}
+
+	g.publishStateUpdate()
 }
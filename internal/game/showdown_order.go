@@ -0,0 +1,226 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// ShowdownTimeout is how long each player has to show or muck; a player who
+// has not answered by then shows unless a hand already shown beats theirs
+const ShowdownTimeout = 15 * time.Second
+
+// showdownOrder tracks who still has to show or muck after the river.
+// Players answer in order; the first must show.
+type showdownOrder struct {
+	order     []string
+	next      int
+	shown     []string
+	expiresAt time.Time
+}
+
+// ShowHand turns the player's hole cards face up when it is their turn at the showdown
+func (g *Game) ShowHand(playerID string) error {
+	return g.showdownChoice(playerID, true)
+}
+
+// MuckHand gives up the player's claim at the showdown without showing their cards
+func (g *Game) MuckHand(playerID string) error {
+	return g.showdownChoice(playerID, false)
+}
+
+func (g *Game) showdownChoice(playerID string, show bool) error {
+	return g.exec("showdown", func() error {
+		handUID := g.handUID
+		if err := g.recordShowdownChoice(playerID, show); err != nil {
+			return err
+		}
+
+		if show {
+			g.sendToPlayers(protocol.TypeShowHand, protocol.ShowHandPayload{HandUID: handUID}, g.getOtherPlayers()...)
+		} else {
+			g.sendToPlayers(protocol.TypeMuckHand, protocol.MuckHandPayload{HandUID: handUID}, g.getOtherPlayers()...)
+		}
+
+		g.advanceShowdown()
+		return nil
+	})
+}
+
+func (g *Game) handleMessageShowdownChoice(from, handUID string, show bool) error {
+	return g.exec("showdown choice message from "+from, func() error {
+		if handUID != g.handUID {
+			return fmt.Errorf("showdown choice for hand %s, not the current hand", handUID)
+		}
+		if err := g.recordShowdownChoice(from, show); err != nil {
+			return err
+		}
+
+		g.advanceShowdown()
+		return nil
+	})
+}
+
+// ShowdownTurn returns who must show or muck next, if a showdown is under way
+func (g *Game) ShowdownTurn() (playerID string, mustShow bool, expiresAt time.Time, ok bool) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	sd := g.showdown
+	if sd == nil || sd.next >= len(sd.order) {
+		return "", false, time.Time{}, false
+	}
+	return sd.order[sd.next], len(sd.shown) == 0, sd.expiresAt, true
+}
+
+// showdownActions lists "show" and, unless they must show, "muck" for the
+// player whose turn it is at the showdown
+func (g *Game) showdownActions(playerID string) []string {
+	sd := g.showdown
+	if sd == nil || sd.next >= len(sd.order) || sd.order[sd.next] != playerID {
+		return nil
+	}
+	if len(sd.shown) == 0 {
+		return []string{"show"}
+	}
+	return []string{"show", "muck"}
+}
+
+// beginShowdown starts showing hands after betting on the river closed.
+// aggressor is the rotation ID of whoever made the last bet or raise on the
+// river, or -1 if it was checked through; without one, the first player left
+// of the button shows first.
+func (g *Game) beginShowdown(aggressor int) {
+	start := aggressor
+	if start < 0 || !g.liveAtShowdown(g.rotationMap[start]) {
+		start = g.getNextPlayerID(g.currentDealerID)
+	}
+
+	sd := &showdownOrder{}
+	for i := 0; i < g.nextRotationID; i++ {
+		addr := g.rotationMap[(start+i)%g.nextRotationID]
+		if g.liveAtShowdown(addr) {
+			sd.order = append(sd.order, addr)
+		}
+	}
+	if len(sd.order) < 2 {
+		g.resolveWinner()
+		return
+	}
+	g.showdown = sd
+
+	g.log().WithField("order", sd.order).Info("Showdown begins")
+	g.promptShowdown()
+}
+
+func (g *Game) liveAtShowdown(addr string) bool {
+	state, ok := g.playerStates[addr]
+	return ok && state.IsActive && !state.IsFolded && g.inHand(addr)
+}
+
+// promptShowdown asks the next player to show or muck, and answers for them
+// if they take too long
+func (g *Game) promptShowdown() {
+	sd := g.showdown
+	sd.expiresAt = time.Now().Add(ShowdownTimeout)
+	playerID := sd.order[sd.next]
+
+	g.publishEvent(protocol.EventShowdownTurn, protocol.ShowdownTurnEvent{
+		PlayerID:  playerID,
+		Order:     append([]string{}, sd.order...),
+		MustShow:  len(sd.shown) == 0,
+		ExpiresAt: formatEventTime(sd.expiresAt),
+	})
+
+	turn := sd.next
+	g.afterFunc(ShowdownTimeout, "showdown timeout", func() {
+		if g.showdown != sd || sd.next != turn {
+			return
+		}
+		show := g.showdownDefault(playerID)
+		g.log().WithFields(logrus.Fields{
+			"player": playerID,
+			"show":   show,
+		}).Info("Showdown choice timed out")
+		if err := g.recordShowdownChoice(playerID, show); err != nil {
+			g.log().Errorf("Failed to apply showdown timeout: %v", err)
+			return
+		}
+		g.advanceShowdown()
+	})
+}
+
+// showdownDefault is the choice made for a player who did not answer: show,
+// unless a hand already shown beats theirs outright
+func (g *Game) showdownDefault(playerID string) bool {
+	if len(g.showdown.shown) == 0 || g.tableConfig.HiLo {
+		return true
+	}
+
+	rank, _ := g.evaluator.EvaluateBestHand(g.decryptPlayerCards(playerID), g.communityCards)
+	for _, addr := range g.showdown.shown {
+		shownRank, _ := g.evaluator.EvaluateBestHand(g.decryptPlayerCards(addr), g.communityCards)
+		if shownRank > rank {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *Game) recordShowdownChoice(playerID string, show bool) error {
+	sd := g.showdown
+	if sd == nil || sd.next >= len(sd.order) {
+		return fmt.Errorf("no showdown in progress")
+	}
+	if sd.order[sd.next] != playerID {
+		return fmt.Errorf("it is %s's turn to show or muck", sd.order[sd.next])
+	}
+	if !show && len(sd.shown) == 0 {
+		return fmt.Errorf("the first player at the showdown must show")
+	}
+
+	sd.next++
+	if show {
+		sd.shown = append(sd.shown, playerID)
+		hole := g.decryptPlayerCards(playerID)
+		_, handName := g.evaluator.EvaluateBestHand(hole, g.communityCards)
+		g.log().WithFields(logrus.Fields{
+			"player": playerID,
+			"hand":   handName,
+		}).Info("Hand shown")
+
+		g.publishEvent(protocol.EventHandShown, protocol.HandShownEvent{
+			PlayerID: playerID,
+			Hand:     toCardData(hole),
+			HandRank: handName,
+		})
+		return nil
+	}
+
+	// A mucked hand is dead: it keeps no claim on any pot
+	g.playerStates[playerID].IsFolded = true
+	g.pot.Fold(playerID)
+	g.log().WithField("player", playerID).Info("Hand mucked")
+
+	g.publishEvent(protocol.EventHandMucked, protocol.HandMuckedEvent{
+		PlayerID: playerID,
+	})
+	return nil
+}
+
+// advanceShowdown prompts the next player, or settles the pots once
+// everyone has shown or mucked
+func (g *Game) advanceShowdown() {
+	sd := g.showdown
+	if sd.next < len(sd.order) {
+		g.promptShowdown()
+		g.publishStateUpdate()
+		return
+	}
+
+	g.showdown = nil
+	g.resolveWinner()
+	g.publishStateUpdate()
+}
@@ -0,0 +1,229 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/crypto"
+	"github.com/RedPaladin7/peerpoker/internal/deck"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+)
+
+// ShuffleAudit records how a hand's deck was built: every player's pass
+// over it, each committed to before a card was dealt, with fingerprints
+// that let anyone replay the shuffle once the keys are revealed
+type ShuffleAudit struct {
+	HandID int    `json:"hand_id"`
+	UID    string `json:"uid"`
+
+	// Fingerprints of the ordered plaintext deck the shuffle starts from
+	// and of the deck the cards were dealt from
+	InitialDeck string `json:"initial_deck"`
+	FinalDeck   string `json:"final_deck"`
+
+	Passes []ShufflePass `json:"passes"`
+
+	// Keys and permutations are only included once the hand is over and
+	// the table publishes them, since together they reveal every card.
+	// A revealed audit is replayed to check it.
+	Revealed    bool   `json:"revealed"`
+	Verified    bool   `json:"verified"`
+	VerifyError string `json:"verify_error,omitempty"`
+}
+
+// ShufflePass is one player's turn at the deck: encrypt every card with
+// their key, then permute the deck
+type ShufflePass struct {
+	PlayerID      string `json:"player_id"`
+	KeyCommitment string `json:"key_commitment"`
+	Deck          string `json:"deck"` // fingerprint of the deck after the pass
+
+	Keys        *crypto.SerializedKeys `json:"keys,omitempty"`
+	Permutation []int                  `json:"permutation,omitempty"`
+}
+
+// shuffleRecord is a hand's audit with the secrets behind each pass. They
+// are only kept when the table publishes audits; otherwise the keys are
+// zeroed with the hand (see hand_keys.go) and nothing can reveal them.
+type shuffleRecord struct {
+	audit ShuffleAudit
+	keys  []*crypto.SerializedKeys
+	perms [][]int
+}
+
+// SetShuffleAuditPublish publishes each hand's full shuffle audit, keys and
+// permutations included, when the hand ends
+func (g *Game) SetShuffleAuditPublish(publish bool) {
+	g.do("set shuffle audit publish", func() {
+		g.publishShuffleAudit = publish
+	})
+}
+
+// beginShuffleAudit starts the current hand's audit from the plaintext deck
+func (g *Game) beginShuffleAudit(initial [][]byte) {
+	if len(g.handHistory) == 0 {
+		return
+	}
+	hand := g.handHistory[len(g.handHistory)-1]
+	hand.shuffle = &shuffleRecord{audit: ShuffleAudit{
+		HandID:      hand.ID,
+		UID:         hand.UID,
+		InitialDeck: crypto.DeckFingerprint(initial),
+	}}
+}
+
+// shufflePass encrypts and permutes the current deck, recording the pass
+func (g *Game) shufflePass(playerID string, keys *crypto.CardKeys) {
+	defer g.timeStage(StageShuffle, time.Now())
+
+	perm := crypto.ShuffleIndices(len(g.currentDeck))
+	g.currentDeck = crypto.ApplyPermutation(crypto.EncryptDeck(g.currentDeck, keys), perm)
+
+	record := g.currentShuffle()
+	if record == nil {
+		return
+	}
+	record.audit.Passes = append(record.audit.Passes, ShufflePass{
+		PlayerID:      playerID,
+		KeyCommitment: keys.Commitment(),
+		Deck:          crypto.DeckFingerprint(g.currentDeck),
+	})
+	if !g.publishShuffleAudit {
+		record.keys = append(record.keys, nil)
+		record.perms = append(record.perms, nil)
+		return
+	}
+	serialized := keys.Serialize()
+	record.keys = append(record.keys, &serialized)
+	record.perms = append(record.perms, perm)
+}
+
+// finishShuffleAudit fingerprints the deck the hand is dealt from
+func (g *Game) finishShuffleAudit() {
+	if record := g.currentShuffle(); record != nil {
+		record.audit.FinalDeck = crypto.DeckFingerprint(g.currentDeck)
+	}
+}
+
+func (g *Game) currentShuffle() *shuffleRecord {
+	if len(g.handHistory) == 0 {
+		return nil
+	}
+	return g.handHistory[len(g.handHistory)-1].shuffle
+}
+
+// publishShuffle announces a finished hand's revealed audit when the table
+// publishes them
+func (g *Game) publishShuffle(hand *HandHistory) {
+	if !g.publishShuffleAudit || hand.shuffle == nil {
+		return
+	}
+	audit := hand.shuffle.revealed()
+	data, err := json.Marshal(audit)
+	if err != nil {
+		g.log().Warnf("Failed to encode shuffle audit for hand %d: %v", hand.ID, err)
+		return
+	}
+	g.publishEvent(protocol.EventShuffleAudit, protocol.ShuffleAuditEvent{
+		HandID:   hand.ID,
+		Verified: audit.Verified,
+		Audit:    data,
+	})
+}
+
+// revealed returns the audit with every revealable key and permutation,
+// replayed to check it
+func (r *shuffleRecord) revealed() ShuffleAudit {
+	audit := r.audit
+	audit.Passes = append([]ShufflePass(nil), r.audit.Passes...)
+	for i := range audit.Passes {
+		if r.keys[i] == nil {
+			continue
+		}
+		keys := *r.keys[i]
+		audit.Passes[i].Keys = &keys
+		audit.Passes[i].Permutation = append([]int(nil), r.perms[i]...)
+	}
+	audit.Revealed = true
+
+	if err := VerifyShuffleAudit(audit); err != nil {
+		audit.VerifyError = err.Error()
+	} else {
+		audit.Verified = true
+	}
+	return audit
+}
+
+// ShuffleAudit returns a hand's shuffle audit: commitments and fingerprints
+// while the hand runs or when the table does not publish audits, and the
+// full, verified record once a published hand is over
+func (g *Game) ShuffleAudit(id int) (ShuffleAudit, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	hand := g.handByID(id)
+	if hand == nil {
+		return ShuffleAudit{}, fmt.Errorf("hand %d not found", id)
+	}
+	if hand.shuffle == nil {
+		return ShuffleAudit{}, fmt.Errorf("hand %d has no shuffle audit", id)
+	}
+	if g.publishShuffleAudit && !hand.EndedAt.IsZero() {
+		return hand.shuffle.revealed(), nil
+	}
+
+	audit := hand.shuffle.audit
+	audit.Passes = append([]ShufflePass(nil), audit.Passes...)
+	return audit, nil
+}
+
+// VerifyShuffleAudit replays a revealed audit from the ordered deck,
+// checking every pass's keys against its commitment and every fingerprint
+// along the way
+func VerifyShuffleAudit(audit ShuffleAudit) error {
+	cards := deck.NewDeck().ToBytes()
+	if crypto.DeckFingerprint(cards) != audit.InitialDeck {
+		return fmt.Errorf("initial deck is not the ordered deck")
+	}
+
+	for i, pass := range audit.Passes {
+		if pass.Keys == nil || len(pass.Permutation) == 0 {
+			return fmt.Errorf("pass %d by %s is not revealed", i, pass.PlayerID)
+		}
+		keys, err := crypto.DeserializeKeys(*pass.Keys)
+		if err != nil {
+			return fmt.Errorf("pass %d by %s: %w", i, pass.PlayerID, err)
+		}
+		if keys.Commitment() != pass.KeyCommitment {
+			return fmt.Errorf("pass %d by %s: keys do not match their commitment", i, pass.PlayerID)
+		}
+		if !isPermutation(pass.Permutation, len(cards)) {
+			return fmt.Errorf("pass %d by %s: not a permutation of %d cards", i, pass.PlayerID, len(cards))
+		}
+
+		cards = crypto.ApplyPermutation(crypto.EncryptDeck(cards, keys), pass.Permutation)
+		if crypto.DeckFingerprint(cards) != pass.Deck {
+			return fmt.Errorf("pass %d by %s: deck does not match its fingerprint", i, pass.PlayerID)
+		}
+	}
+
+	if crypto.DeckFingerprint(cards) != audit.FinalDeck {
+		return fmt.Errorf("final deck does not match its fingerprint")
+	}
+	return nil
+}
+
+func isPermutation(perm []int, n int) bool {
+	if len(perm) != n {
+		return false
+	}
+	seen := make([]bool, n)
+	for _, i := range perm {
+		if i < 0 || i >= n || seen[i] {
+			return false
+		}
+		seen[i] = true
+	}
+	return true
+}
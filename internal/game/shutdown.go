@@ -0,0 +1,59 @@
+package game
+
+import (
+	"fmt"
+)
+
+// errTableClosing is returned to players trying to sit down during shutdown
+var errTableClosing = fmt.Errorf("table is closing")
+
+// BeginShutdown stops seating players and dealing new hands. A hand in
+// progress plays on, so the node can wait for it before exiting.
+func (g *Game) BeginShutdown() {
+	g.do("begin shutdown", func() {
+		if g.closing {
+			return
+		}
+		g.closing = true
+
+		g.audit("table_closing", "server", map[string]interface{}{
+			"status": g.currentStatus.String(),
+		})
+		g.log().Info("Table closing: no new players or hands")
+	})
+}
+
+// HandInProgress reports whether a hand is being played
+func (g *Game) HandInProgress() bool {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.currentStatus != GameStatusWaiting
+}
+
+// VoidHand abandons the hand in progress and returns every bet, for when it
+// cannot be finished. It returns the refunds.
+func (g *Game) VoidHand(reason, actor string) (refunds map[string]int, err error) {
+	err = g.exec("void hand", func() error {
+		if g.currentStatus == GameStatusWaiting {
+			return fmt.Errorf("no hand in progress")
+		}
+		refunds = g.abandonHand(reason, actor)
+		return nil
+	})
+	return refunds, err
+}
+
+// abandonHand voids the current hand, audits it and waits for the next one
+func (g *Game) abandonHand(reason, actor string) map[string]int {
+	status := g.currentStatus.String()
+	refunds := g.voidHand(reason)
+	g.setStatus(GameStatusWaiting)
+
+	g.audit("hand_voided", actor, map[string]interface{}{
+		"reason":  reason,
+		"status":  status,
+		"refunds": refunds,
+	})
+	g.publishStateUpdate()
+	return refunds
+}
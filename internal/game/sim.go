@@ -0,0 +1,118 @@
+package game
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/deck"
+)
+
+// SimConfig sets up deterministic simulation: every shuffle and the seat
+// draw come from Seed, and Decks are dealt in order before falling back to
+// seeded shuffles. A scripted deck lists cards in deal order (two per
+// player by rotation, then the board) and may be partial; the rest of the
+// deck follows in seeded order.
+type SimConfig struct {
+	Seed  int64
+	Decks [][]deck.Card
+}
+
+// simulation replaces mental poker with plaintext, reproducible decks.
+// Enabling it needs the sim build tag (see sim_enabled.go), and it refuses
+// to run with on-chain settlement.
+type simulation struct {
+	rng   *rand.Rand
+	decks [][]deck.Card
+	hands int
+}
+
+func newSimulation(cfg SimConfig) (*simulation, error) {
+	for i, scripted := range cfg.Decks {
+		seen := make(map[deck.Card]bool, len(scripted))
+		for _, card := range scripted {
+			if !card.IsValid() || seen[card] {
+				return nil, fmt.Errorf("scripted deck %d has an invalid or repeated card %v", i, card)
+			}
+			seen[card] = true
+		}
+	}
+	return &simulation{
+		rng:   rand.New(rand.NewSource(cfg.Seed)),
+		decks: cfg.Decks,
+	}, nil
+}
+
+// Simulating reports whether the game deals from simulation decks
+func (g *Game) Simulating() bool {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.sim != nil
+}
+
+// nextDeck returns the next hand's deck in deal order
+func (s *simulation) nextDeck() []deck.Card {
+	cards := deck.NewDeck().Cards
+	s.rng.Shuffle(len(cards), func(i, j int) { cards[i], cards[j] = cards[j], cards[i] })
+
+	if s.hands < len(s.decks) {
+		scripted := s.decks[s.hands]
+		used := make(map[deck.Card]bool, len(scripted))
+		for _, card := range scripted {
+			used[card] = true
+		}
+		rest := cards
+		cards = append([]deck.Card{}, scripted...)
+		for _, card := range rest {
+			if !used[card] {
+				cards = append(cards, card)
+			}
+		}
+	}
+	s.hands++
+	return cards
+}
+
+// seatDraw draws seats and the button from the seed instead of commit/reveal
+func (s *simulation) seatDraw(players []string) *SeatDraw {
+	beacon := make([]byte, 32)
+	s.rng.Read(beacon)
+
+	sorted := append([]string{}, players...)
+	sort.Strings(sorted)
+	seats := seatOrder(beacon, sorted)
+	return &SeatDraw{
+		Beacon:      hex.EncodeToString(beacon),
+		Commitments: map[string]string{},
+		Seeds:       map[string]string{},
+		Seats:       seats,
+		Button:      seats[s.rng.Intn(len(seats))],
+		DrawnAt:     time.Now(),
+	}
+}
+
+// simShuffleAndDeal deals a plaintext simulation deck in place of the
+// encrypted shuffle
+func (g *Game) simShuffleAndDeal() {
+	cards := g.sim.nextDeck()
+	g.currentDeck = make([][]byte, len(cards))
+	for i, card := range cards {
+		g.currentDeck[i] = card.ToBytes()
+	}
+	g.log().WithField("hand", g.sim.hands).Debug("Dealt simulation deck")
+	g.logDeal(dealSecrets{Deck: g.currentDeck})
+
+	g.dealHoleCards()
+	g.setStatus(GameStatusPreFlop)
+	g.turnStartedAt = time.Now()
+}
+
+// simCard reads a card straight from the plaintext simulation deck
+func (g *Game) simCard(idx int) (deck.Card, bool) {
+	if idx >= len(g.currentDeck) || len(g.currentDeck[idx]) == 0 {
+		return deck.Card{}, false
+	}
+	return deck.NewCardFromByte(g.currentDeck[idx][0]), true
+}
@@ -0,0 +1,10 @@
+//go:build !sim
+
+package game
+
+import "fmt"
+
+// EnableSimulation is unavailable in production builds; build with -tags sim
+func (g *Game) EnableSimulation(cfg SimConfig) error {
+	return fmt.Errorf("simulation mode is not compiled in; build with -tags sim")
+}
@@ -0,0 +1,26 @@
+//go:build sim
+
+package game
+
+import "fmt"
+
+// EnableSimulation switches the game to deterministic simulation decks. It
+// is only compiled into builds with the sim tag, and refuses tables that
+// settle on-chain.
+func (g *Game) EnableSimulation(cfg SimConfig) error {
+	return g.exec("enable simulation", func() error {
+		if g.blockchainEnabled {
+			return fmt.Errorf("simulation mode cannot be used with on-chain settlement")
+		}
+		if g.currentStatus != GameStatusWaiting {
+			return fmt.Errorf("simulation mode can only be enabled between hands")
+		}
+
+		sim, err := newSimulation(cfg)
+		if err != nil {
+			return err
+		}
+		g.sim = sim
+		return nil
+	})
+}
@@ -0,0 +1,180 @@
+package game
+
+import (
+	"math"
+	"sort"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+)
+
+// potAward records chips paid to a player from a numbered pot
+type potAward struct {
+	addr   string
+	pot    int
+	amount int
+}
+
+// potShare is the part of a contribution that lands in one pot
+type potShare struct {
+	pot    int
+	amount int
+}
+
+// stateDiff describes how the table moved from prev to cur
+func (g *Game) stateDiff(prev, cur protocol.GameStateUpdateEvent) *protocol.StateDiff {
+	diff := &protocol.StateDiff{
+		PotDelta:  cur.Pot - prev.Pot,
+		ChipMoves: []protocol.ChipMove{},
+	}
+	if prev.Status != cur.Status {
+		diff.PreviousStatus = prev.Status
+	}
+	diff.NewCommunityCards = newCards(prev.CommunityCards, cur.CommunityCards)
+
+	awards := make(map[string][]potAward)
+	for _, award := range g.potAwards {
+		awards[award.addr] = append(awards[award.addr], award)
+	}
+
+	before := make(map[string]protocol.PlayerData, len(prev.Players))
+	for _, p := range prev.Players {
+		before[p.PlayerID] = p
+	}
+
+	levels := g.potLevels()
+	for _, p := range cur.Players {
+		old, ok := before[p.PlayerID]
+		if !ok {
+			continue
+		}
+		moves := g.chipMoves(old, p, levels, awards[p.PlayerID], g.buyIns[p.PlayerID])
+		diff.ChipMoves = append(diff.ChipMoves, moves...)
+	}
+	return diff
+}
+
+// chipMoves explains one player's stack and bet changes as chip movements:
+// stack to bet for new bets, bet to pot when a street's bets are collected,
+// pot to stack for winnings and refunds, and buy-in to stack for rebuys
+func (g *Game) chipMoves(prev, cur protocol.PlayerData, levels []int, awards []potAward, bought int) []protocol.ChipMove {
+	total := 0
+	if state, ok := g.playerStates[cur.PlayerID]; ok {
+		total = state.TotalBetThisHand
+	}
+
+	awarded := 0
+	for _, award := range awards {
+		awarded += award.amount
+	}
+
+	// Winnings and rebuys can arrive in the same update as bets, so net them out
+	stackDelta := cur.Stack - prev.Stack
+	pushed, returned := 0, 0
+	if change := stackDelta - awarded - bought; change < 0 {
+		pushed = -change
+	} else {
+		returned = change
+	}
+
+	moves := []protocol.ChipMove{}
+	move := func(amount int, from, to string, pot int) {
+		moves = append(moves, protocol.ChipMove{
+			PlayerID: cur.PlayerID,
+			Amount:   amount,
+			From:     from,
+			To:       to,
+			Pot:      pot,
+		})
+	}
+
+	for _, share := range splitContribution(levels, total-pushed, total) {
+		move(share.amount, protocol.ChipLocationStack, protocol.ChipLocationBet, share.pot)
+	}
+
+	// Collected bets are the oldest chips in front of the player
+	betBefore := prev.CurrentBet + pushed
+	if swept := betBefore - cur.CurrentBet; swept > 0 {
+		from := total - betBefore
+		for _, share := range splitContribution(levels, from, from+swept) {
+			move(share.amount, protocol.ChipLocationBet, protocol.ChipLocationPot, share.pot)
+		}
+	}
+
+	for _, award := range awards {
+		move(award.amount, protocol.ChipLocationPot, protocol.ChipLocationStack, award.pot)
+	}
+	if bought > 0 {
+		move(bought, protocol.ChipLocationBuyIn, protocol.ChipLocationStack, 0)
+	}
+	if returned > 0 {
+		move(returned, protocol.ChipLocationPot, protocol.ChipLocationStack, 0)
+	}
+	return moves
+}
+
+// potLevels returns the contribution caps that separate the main pot from
+// side pots: one per distinct all-in total this hand
+func (g *Game) potLevels() []int {
+	seen := make(map[int]bool)
+	levels := []int{}
+	for _, state := range g.playerStates {
+		if !state.IsAllIn || state.TotalBetThisHand <= 0 || seen[state.TotalBetThisHand] {
+			continue
+		}
+		seen[state.TotalBetThisHand] = true
+		levels = append(levels, state.TotalBetThisHand)
+	}
+	sort.Ints(levels)
+	return levels
+}
+
+// splitContribution splits the chips a player put in between two running
+// totals across the pots they land in (1 = main pot)
+func splitContribution(levels []int, from, to int) []potShare {
+	if to <= from {
+		return nil
+	}
+	if from < 0 {
+		to -= from
+		from = 0
+	}
+
+	shares := []potShare{}
+	lower := 0
+	for i := 0; i <= len(levels); i++ {
+		upper := math.MaxInt
+		if i < len(levels) {
+			upper = levels[i]
+		}
+
+		lo, hi := from, to
+		if lo < lower {
+			lo = lower
+		}
+		if hi > upper {
+			hi = upper
+		}
+		if hi > lo {
+			shares = append(shares, potShare{pot: i + 1, amount: hi - lo})
+		}
+		if upper >= to {
+			break
+		}
+		lower = upper
+	}
+	return shares
+}
+
+// newCards returns the community cards dealt since prev, or all of cur when a
+// new hand started in between
+func newCards(prev, cur []protocol.CardData) []protocol.CardData {
+	if len(cur) <= len(prev) {
+		return nil
+	}
+	for i := range prev {
+		if prev[i] != cur[i] {
+			return cur
+		}
+	}
+	return cur[len(prev):]
+}
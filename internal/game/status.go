@@ -10,6 +10,10 @@ const (
 	GameStatusTurn
 	GameStatusRiver
 	GameStatusShowdown
+
+	// Reported while the table is on hold (see pause.go); the hand keeps
+	// its own status underneath
+	GameStatusPaused
 )
 
 func (gs GameStatus) String() string {
@@ -28,7 +32,19 @@ func (gs GameStatus) String() string {
 		return "RIVER"
 	case GameStatusShowdown:
 		return "SHOWDOWN"
+	case GameStatusPaused:
+		return "PAUSED"
 	default:
 		return "UNKNOWN"
 	}
 }
+
+// ParseGameStatus is the inverse of GameStatus.String
+func ParseGameStatus(s string) (GameStatus, bool) {
+	for status := GameStatusWaiting; status <= GameStatusPaused; status++ {
+		if status.String() == s {
+			return status, true
+		}
+	}
+	return GameStatusWaiting, false
+}
@@ -0,0 +1,152 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/blockchain"
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+)
+
+// SetStore records players, hands, actions and settlements in a store, and
+// keeps the table's latest between-hands snapshot there, under tableID
+func (g *Game) SetStore(store persistence.Store, tableID string) {
+	g.do("set store", func() {
+		g.store = store
+		g.storeTableID = tableID
+	})
+}
+
+// currentHandID is the ID of the hand in progress or just finished
+func (g *Game) currentHandID() int {
+	if len(g.handHistory) == 0 {
+		return 0
+	}
+	return g.handHistory[len(g.handHistory)-1].ID
+}
+
+// storeAction numbers an action within its hand and records it. Actions
+// are numbered even without a store, and while the WAL is replayed, so the
+// sequence matches across restarts.
+func (g *Game) storeAction(playerID, action string, value int) {
+	defer g.timeStage(StagePersist, time.Now())
+
+	g.handActionSeq++
+	if len(g.handHistory) > 0 {
+		hand := g.handHistory[len(g.handHistory)-1]
+		hand.Actions = append(hand.Actions, HandAction{
+			Seq:      g.handActionSeq,
+			PlayerID: playerID,
+			Action:   action,
+			Amount:   value,
+			Street:   g.currentStatus.String(),
+		})
+	}
+	if g.store == nil || g.replaying {
+		return
+	}
+
+	err := g.store.AppendAction(persistence.ActionRecord{
+		TableID:  g.storeTableID,
+		HandID:   g.currentHandID(),
+		HandUID:  g.handUID,
+		Seq:      g.handActionSeq,
+		PlayerID: playerID,
+		Action:   action,
+		Amount:   value,
+		Street:   g.currentStatus.String(),
+		At:       time.Now(),
+	})
+	if err != nil {
+		g.log().Warnf("Failed to store action: %v", err)
+	}
+}
+
+// storeHand records a finished or voided hand and the stacks it left behind
+func (g *Game) storeHand(hand *HandHistory) {
+	if g.store == nil || g.replaying {
+		return
+	}
+
+	data, err := json.Marshal(hand)
+	if err != nil {
+		g.log().Warnf("Failed to marshal hand %d: %v", hand.ID, err)
+		return
+	}
+
+	err = g.store.SaveHand(persistence.HandRecord{
+		TableID:    g.storeTableID,
+		HandID:     hand.ID,
+		HandUID:    hand.UID,
+		StartedAt:  hand.StartedAt,
+		EndedAt:    hand.EndedAt,
+		Dealer:     hand.Dealer,
+		Pot:        hand.Pot,
+		Players:    hand.Seats,
+		Winnings:   hand.Winnings,
+		Voided:     hand.Voided,
+		VoidReason: hand.VoidReason,
+		Data:       data,
+	})
+	if err != nil {
+		g.log().Warnf("Failed to store hand %d: %v", hand.ID, err)
+	}
+
+	for _, addr := range hand.Seats {
+		state, ok := g.playerStates[addr]
+		if !ok {
+			continue
+		}
+
+		player, _, err := g.store.GetPlayer(addr)
+		if err != nil {
+			g.log().Warnf("Failed to load stored player %s: %v", addr, err)
+			continue
+		}
+		player.PlayerID = addr
+		player.Stack = state.Stack
+		player.HandsPlayed++
+		player.LastSeen = time.Now()
+		if err := g.store.SavePlayer(player); err != nil {
+			g.log().Warnf("Failed to store player %s: %v", addr, err)
+		}
+	}
+}
+
+func (g *Game) storeSettlement(handID int, handUID, kind string, gameID [32]byte, winners []string, amounts []int, receipt *blockchain.TxReceipt, txErr error) {
+	if g.store == nil {
+		return
+	}
+
+	record := persistence.SettlementRecord{
+		TableID: g.storeTableID,
+		HandID:  handID,
+		HandUID: handUID,
+		Kind:    kind,
+		GameID:  fmt.Sprintf("0x%x", gameID),
+		Winners: winners,
+		Amounts: amounts,
+		At:      time.Now(),
+	}
+	if receipt != nil {
+		record.TxHash = receipt.TxHash
+	}
+	if txErr != nil {
+		record.Error = txErr.Error()
+	}
+	if err := g.store.SaveSettlement(record); err != nil {
+		g.log().Warnf("Failed to store settlement: %v", err)
+	}
+}
+
+// storeSnapshot keeps the between-hands snapshot in the store, where
+// another node sharing the database can restore the table from
+func (g *Game) storeSnapshot(snap *persistence.GameSnapshot) {
+	if g.store == nil || g.currentStatus != GameStatusWaiting {
+		return
+	}
+	if err := g.store.SaveSnapshot(g.storeTableID, snap); err != nil {
+		g.log().Warnf("Failed to store snapshot: %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// TableConfig holds per-table betting options. Every node at a table must
+// run with the same options, just like the blinds.
+type TableConfig struct {
+	Ante     int  `json:"ante"`     // posted by every dealt-in player before the blinds
+	Straddle bool `json:"straddle"` // allow a live straddle left of the big blind
+
+	// Offer to deal the rest of the board twice when everyone left is all-in
+	RunItTwice bool `json:"run_it_twice"`
+
+	// Reveal the board cards that were never dealt when a hand ends early
+	RabbitHunt bool `json:"rabbit_hunt"`
+
+	// Split every pot between the best high and the best eight-or-better
+	// low, scoring hands by Omaha rules
+	HiLo bool `json:"hi_lo"`
+
+	// Seconds the player to act has before their time bank starts (0 for
+	// no action timer), and the extra seconds each player gets per session
+	ActionSeconds   int `json:"action_seconds"`
+	TimeBankSeconds int `json:"time_bank_seconds"`
+
+	// The house's cut of each pot (see rake.go)
+	Rake RakeConfig `json:"rake"`
+
+	// Hands netted into one on-chain settlement: 0 or 1 settles every hand,
+	// and SettlementBatchSession the whole session (see settlement_batch.go)
+	SettlementBatch int `json:"settlement_batch"`
+
+	// Settle the session from stacks every player signs after each hand,
+	// submitting only the last on-chain (see channel.go); needs message signing
+	StateChannel bool `json:"state_channel"`
+}
+
+// Variant is the game variant peers must agree on
+func (cfg TableConfig) Variant() string {
+	if cfg.HiLo {
+		return protocol.GameVariantOmahaHiLo
+	}
+	return protocol.GameVariantTexasHoldem
+}
+
+// HoleCards is how many cards each player is dealt: four in Omaha, two in
+// Hold'em. The board is dealt from the deck after every player's cards.
+func (cfg TableConfig) HoleCards() int {
+	if cfg.HiLo {
+		return 4
+	}
+	return 2
+}
+
+// SetTableConfig sets the table's betting options; they apply from the next hand
+func (g *Game) SetTableConfig(cfg TableConfig) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	return g.exec("set table config", func() error {
+		g.setTableConfig(cfg)
+		return nil
+	})
+}
+
+func (cfg TableConfig) validate() error {
+	if cfg.Ante < 0 {
+		return fmt.Errorf("ante cannot be negative")
+	}
+	if cfg.Ante >= BigBlind {
+		return fmt.Errorf("ante %d must be smaller than the big blind (%d)", cfg.Ante, BigBlind)
+	}
+	if cfg.ActionSeconds < 0 || cfg.TimeBankSeconds < 0 {
+		return fmt.Errorf("action timer and time bank cannot be negative")
+	}
+	if cfg.SettlementBatch < SettlementBatchSession {
+		return fmt.Errorf("invalid settlement batch size %d", cfg.SettlementBatch)
+	}
+	return cfg.Rake.validate()
+}
+
+func (g *Game) setTableConfig(cfg TableConfig) {
+	g.tableConfig = cfg
+
+	g.log().WithFields(logrus.Fields{
+		"ante":         cfg.Ante,
+		"straddle":     cfg.Straddle,
+		"run_it_twice": cfg.RunItTwice,
+		"rabbit_hunt":  cfg.RabbitHunt,
+		"hi_lo":        cfg.HiLo,
+		"action_time":  cfg.ActionSeconds,
+		"time_bank":    cfg.TimeBankSeconds,
+		"rake":         cfg.Rake.Percent,
+		"rake_cap":     cfg.Rake.Cap,
+		"settle_batch": cfg.SettlementBatch,
+		"channel":      cfg.StateChannel,
+	}).Info("Table config set")
+}
+
+// TableConfig returns the table's betting options
+func (g *Game) TableConfig() TableConfig {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.tableConfig
+}
+
+// postAntes collects every dealt-in player's ante into the pot. Antes are
+// dead money: they count toward side pots but not toward the bet to call.
+func (g *Game) postAntes() map[string]int {
+	ante := g.tableConfig.Ante
+	if ante <= 0 {
+		return nil
+	}
+
+	antes := make(map[string]int, g.nextRotationID)
+	for id := 0; id < g.nextRotationID; id++ {
+		addr, ok := g.rotationMap[id]
+		if !ok {
+			continue
+		}
+		state := g.playerStates[addr]
+		// A seat with no chips has nothing to post and is not all in
+		if state.Stack == 0 {
+			continue
+		}
+
+		paid := ante
+		if paid >= state.Stack {
+			paid = state.Stack
+			state.IsAllIn = true
+			g.log().Infof("Player %s is ALL-IN posting the ante!", addr)
+		}
+		state.Stack -= paid
+		state.TotalBetThisHand += paid
+		g.pot.Add(addr, paid)
+		g.currentPot += paid
+		antes[addr] = paid
+	}
+
+	g.log().WithFields(logrus.Fields{
+		"ante":    ante,
+		"players": len(antes),
+		"pot":     g.currentPot,
+	}).Info("Antes posted")
+	return antes
+}
+
+// SetStraddle sets whether a player straddles whenever they are first to act
+// after the big blind. The choice stands until changed and applies from the
+// next hand dealt.
+func (g *Game) SetStraddle(playerID string, enabled bool) error {
+	return g.exec("set straddle", func() error {
+		if err := g.setStraddle(playerID, enabled); err != nil {
+			return err
+		}
+
+		g.sendToPlayers(protocol.TypeStraddle, protocol.StraddlePayload{
+			Enabled: enabled,
+		}, g.getOtherPlayers()...)
+		return nil
+	})
+}
+
+// Straddling reports whether a player has opted in to straddle
+func (g *Game) Straddling(playerID string) bool {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.straddlers[playerID]
+}
+
+func (g *Game) handleMessageStraddle(from string, payload protocol.StraddlePayload) error {
+	return g.exec("straddle message from "+from, func() error {
+		return g.setStraddle(from, payload.Enabled)
+	})
+}
+
+func (g *Game) setStraddle(playerID string, enabled bool) error {
+	if !g.tableConfig.Straddle {
+		return fmt.Errorf("straddles are not allowed at this table")
+	}
+	if _, ok := g.playerStates[playerID]; !ok {
+		return fmt.Errorf("player %s is not at the table", playerID)
+	}
+
+	if enabled {
+		g.straddlers[playerID] = true
+	} else {
+		delete(g.straddlers, playerID)
+	}
+	g.log().WithFields(logrus.Fields{
+		"player":  playerID,
+		"enabled": enabled,
+	}).Info("Straddle preference set")
+	return nil
+}
+
+// postStraddle posts a live straddle of twice the big blind for the player
+// left of the big blind, if they opted in and can cover it. The straddler
+// acts last pre-flop, and the straddle sets the minimum raise.
+func (g *Game) postStraddle(bbID int) (string, int) {
+	if !g.tableConfig.Straddle || len(g.getReadyActivePlayers()) < 3 {
+		return "", 0
+	}
+
+	straddleID := g.getNextActivePlayerID(bbID)
+	addr := g.rotationMap[straddleID]
+	amount := 2 * BigBlind
+	if !g.straddlers[addr] || g.playerStates[addr].Stack < amount {
+		return "", 0
+	}
+
+	g.updatePlayerState(addr, PlayerActionBet, amount)
+	g.lastRaiserID = straddleID
+	g.lastRaiseAmount = amount
+	g.currentPlayerTurn = g.getNextActivePlayerID(straddleID)
+
+	g.log().Infof("Player %s posted a straddle: %d", addr, amount)
+	return addr, amount
+}
@@ -0,0 +1,144 @@
+package game
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// turnClock times the player to act: first the table's action timer, then
+// whatever is left of their time bank. When both run out they check if they
+// can and fold otherwise.
+type turnClock struct {
+	player    string
+	started   time.Time
+	deadline  time.Time
+	usingBank bool
+}
+
+// remaining is the time left on the clock, rounded up to whole seconds
+func (c *turnClock) remaining() int {
+	left := time.Until(c.deadline)
+	if left <= 0 {
+		return 0
+	}
+	return int((left + time.Second - 1) / time.Second)
+}
+
+// timeBankLeft is the extra decision time a player has left this session
+func (g *Game) timeBankLeft(addr string) time.Duration {
+	if left, ok := g.timeBanks[addr]; ok {
+		return left
+	}
+	return time.Duration(g.tableConfig.TimeBankSeconds) * time.Second
+}
+
+// TimeBank returns how much extra decision time a player has left this session
+func (g *Game) TimeBank(addr string) time.Duration {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.timeBankLeft(addr)
+}
+
+// startTurnClock starts the action timer for the player to act, replacing
+// any clock still running; nil when the table has no action timer
+func (g *Game) startTurnClock(addr string) *turnClock {
+	g.stopTurnClock()
+	if g.tableConfig.ActionSeconds <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	clock := &turnClock{
+		player:   addr,
+		started:  now,
+		deadline: now.Add(time.Duration(g.tableConfig.ActionSeconds) * time.Second),
+	}
+	g.turnClock = clock
+	g.armTurnClock(clock)
+	return clock
+}
+
+// stopTurnClock stops the running clock, charging any time bank it used
+func (g *Game) stopTurnClock() {
+	clock := g.turnClock
+	if clock == nil {
+		return
+	}
+	g.turnClock = nil
+
+	if clock.usingBank {
+		left := g.timeBankLeft(clock.player) - time.Since(clock.started)
+		if left < 0 {
+			left = 0
+		}
+		g.timeBanks[clock.player] = left
+	}
+}
+
+func (g *Game) armTurnClock(clock *turnClock) {
+	g.afterFunc(time.Until(clock.deadline), "turn timeout", func() {
+		if g.turnClock != clock {
+			return
+		}
+		g.turnClockExpired(clock)
+	})
+}
+
+// turnClockExpired dips into the player's time bank when the action timer
+// runs out, and acts for them once that is spent too
+func (g *Game) turnClockExpired(clock *turnClock) {
+	if !clock.usingBank {
+		if bank := g.timeBankLeft(clock.player); bank > 0 {
+			now := time.Now()
+			clock.usingBank = true
+			clock.started = now
+			clock.deadline = now.Add(bank)
+			g.log().WithFields(logrus.Fields{
+				"player":    clock.player,
+				"time_bank": bank,
+			}).Info("Action timer expired, using time bank")
+
+			g.armTurnClock(clock)
+			g.publishTurnEvent(clock.player, clock)
+			return
+		}
+	}
+
+	g.stopTurnClock()
+	if !g.isBettingRound() || g.currentTurnPlayer() != clock.player {
+		return
+	}
+
+	action := g.timeoutAction(clock.player)
+	g.audit("turn_timed_out", "table", map[string]interface{}{
+		"player": clock.player,
+		"action": action.String(),
+		"status": g.currentStatus.String(),
+	})
+	g.log().WithFields(logrus.Fields{
+		"player": clock.player,
+		"action": action.String(),
+	}).Warn("Player ran out of time")
+
+	if clock.player == g.listenAddr {
+		if err := g.handlePlayerAction(clock.player, "", action.String(), 0); err != nil {
+			g.log().Errorf("Failed to act for timed out player: %v", err)
+		}
+	} else {
+		g.forceRemoteAction(clock.player, action)
+	}
+	// Until they act again, the host may kick them (see host.go)
+	g.timedOut[clock.player] = true
+}
+
+// timeoutAction is what a player who does not act is made to do: check if
+// they can, fold otherwise
+func (g *Game) timeoutAction(player string) PlayerAction {
+	for _, valid := range g.getValidActions(player) {
+		if valid == PlayerActionCheck {
+			return PlayerActionCheck
+		}
+	}
+	return PlayerActionFold
+}
@@ -0,0 +1,50 @@
+package game
+
+import (
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+)
+
+// GameView is a copy of the table taken after every command on the loop.
+// Read paths load the latest one instead of taking the game lock, so they
+// never wait on the action path or see a command half applied. A view is
+// not changed once published, and callers must not change it either.
+type GameView struct {
+	Status  GameStatus
+	Public  protocol.GameStateUpdateEvent
+	Players []PlayerStateResponse
+	TakenAt time.Time
+
+	// Each seated player's own view of the table
+	seats map[string]TableStateResponse
+}
+
+// TableState returns the table as a client sees it
+func (v *GameView) TableState(clientID string) TableStateResponse {
+	if state, ok := v.seats[clientID]; ok {
+		return state
+	}
+	return TableStateResponse{Status: v.Status.String()}
+}
+
+// View returns the latest view of the table
+func (g *Game) View() *GameView {
+	return g.view.Load().(*GameView)
+}
+
+// refreshView replaces the table's view. Called with the lock held, after
+// every command.
+func (g *Game) refreshView() {
+	seats := make(map[string]TableStateResponse, len(g.playerStates))
+	for addr := range g.playerStates {
+		seats[addr] = g.tableState(addr)
+	}
+	g.view.Store(&GameView{
+		Status:  g.status(),
+		Public:  g.publicState(),
+		Players: g.allPlayers(),
+		TakenAt: time.Now(),
+		seats:   seats,
+	})
+}
@@ -0,0 +1,155 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// WaitlistEntry is a player waiting for a seat at a full table
+type WaitlistEntry struct {
+	PlayerID string    `json:"player_id"`
+	Position int       `json:"position"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// JoinWaitlist queues a player for the next free seat and returns their position
+func (g *Game) JoinWaitlist(playerID string) (position int, err error) {
+	err = g.exec("join waitlist", func() error {
+		position, err = g.joinWaitlist(playerID)
+		return err
+	})
+	return position, err
+}
+
+func (g *Game) joinWaitlist(playerID string) (int, error) {
+	if state, ok := g.playerStates[playerID]; ok && state.IsActive {
+		return 0, fmt.Errorf("player %s is already seated", playerID)
+	}
+	if !g.tableFull() {
+		return 0, fmt.Errorf("table has a free seat")
+	}
+
+	for i, entry := range g.waitlist {
+		if entry.PlayerID == playerID {
+			return i + 1, nil
+		}
+	}
+
+	g.waitlist = append(g.waitlist, WaitlistEntry{
+		PlayerID: playerID,
+		JoinedAt: time.Now(),
+	})
+
+	g.log().WithFields(logrus.Fields{
+		"player":   playerID,
+		"position": len(g.waitlist),
+	}).Info("Player joined waitlist")
+	return len(g.waitlist), nil
+}
+
+// LeaveWaitlist removes a player from the waitlist
+func (g *Game) LeaveWaitlist(playerID string) error {
+	return g.exec("leave waitlist", func() error {
+		for i, entry := range g.waitlist {
+			if entry.PlayerID == playerID {
+				g.waitlist = append(g.waitlist[:i], g.waitlist[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("player %s is not on the waitlist", playerID)
+	})
+}
+
+// Waitlist returns waiting players in the order they will be seated
+func (g *Game) Waitlist() []WaitlistEntry {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	list := make([]WaitlistEntry, len(g.waitlist))
+	for i, entry := range g.waitlist {
+		list[i] = entry
+		list[i].Position = i + 1
+	}
+	return list
+}
+
+// WaitlistLength returns how many players are waiting for a seat
+func (g *Game) WaitlistLength() int {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return len(g.waitlist)
+}
+
+func (g *Game) tableFull() bool {
+	seated := 0
+	for _, state := range g.playerStates {
+		if state.IsActive {
+			seated++
+		}
+	}
+	return seated >= g.maxSeats
+}
+
+// freeSeat returns the lowest seat nobody sits in or holds, or 0 if none
+func (g *Game) freeSeat() int {
+	for seat := 1; seat <= g.maxSeats; seat++ {
+		if occupant, reservation := g.seatHolder(seat); occupant == "" && reservation == nil {
+			return seat
+		}
+	}
+	return 0
+}
+
+// seatFromWaitlist seats waiting players while there is room. Each is prompted
+// to ready up and loses the seat to the next in line if they don't in time.
+func (g *Game) seatFromWaitlist() {
+	for len(g.waitlist) > 0 && !g.tableFull() {
+		seat := g.freeSeat()
+		if seat == 0 {
+			return
+		}
+
+		entry := g.waitlist[0]
+		g.waitlist = g.waitlist[1:]
+
+		if err := g.takeSeat(entry.PlayerID, seat); err != nil {
+			g.log().Warnf("Failed to seat %s from waitlist: %v", entry.PlayerID, err)
+			continue
+		}
+
+		readyBy := time.Now().Add(g.seatHold)
+		g.log().WithFields(logrus.Fields{
+			"player":   entry.PlayerID,
+			"seat":     seat,
+			"ready_by": readyBy.Format(time.RFC3339),
+		}).Info("Seated player from waitlist")
+
+		g.publishEvent(protocol.EventSeatAvailable, protocol.SeatAvailableEvent{
+			PlayerID: entry.PlayerID,
+			Seat:     seat,
+			ReadyBy:  formatEventTime(readyBy),
+			Message:  fmt.Sprintf("Seat %d is yours, ready up to play", seat),
+		}, entry.PlayerID)
+
+		playerID := entry.PlayerID
+		time.AfterFunc(g.seatHold, func() {
+			g.expireWaitlistSeat(playerID, seat)
+		})
+	}
+}
+
+// expireWaitlistSeat frees a seat given from the waitlist that was never readied
+func (g *Game) expireWaitlistSeat(playerID string, seat int) {
+	g.do("expire waitlist seat", func() {
+		state, ok := g.playerStates[playerID]
+		if !ok || !state.IsActive || state.IsReady || state.Seat != seat {
+			return
+		}
+
+		g.log().Infof("Player %s did not ready up, releasing seat %d", playerID, seat)
+		g.removePlayer(playerID, "missed seat")
+	})
+}
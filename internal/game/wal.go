@@ -0,0 +1,207 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/crypto"
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultWALSnapshotEvery is how many logged events may build up mid-hand
+// before a snapshot is taken and the log compacted
+const DefaultWALSnapshotEvery = 50
+
+// Write-ahead log payloads. Actions and deals are the inputs a hand is
+// built from and are replayed on recovery; reveals and payouts follow from
+// them and are kept for the record.
+type walAction struct {
+	Player string `json:"player"`
+	Action string `json:"action"`
+	Value  int    `json:"value"`
+}
+
+// walDeal carries the deck and keys sealed with the session key, like a
+// snapshot's hand secrets, so the log never holds them in the clear
+type walDeal struct {
+	Secrets *persistence.SealedSecrets `json:"secrets"`
+}
+
+type dealSecrets struct {
+	Deck [][]byte                    `json:"deck"`
+	Keys map[string]*crypto.CardKeys `json:"keys,omitempty"`
+	Own  *crypto.CardKeys            `json:"own,omitempty"` // our keys for the hand
+}
+
+type walReveal struct {
+	Board []byte `json:"board"`
+}
+
+type walPayout struct {
+	Player string `json:"player"`
+	Pot    int    `json:"pot"`
+	Amount int    `json:"amount"`
+}
+
+// SetWAL turns on the write-ahead log. With it, state changes it records
+// no longer need a snapshot each; one is taken every snapshotEvery events
+// and between hands, and the log is compacted behind it.
+func (g *Game) SetWAL(wal *persistence.WAL, snapshotEvery int) {
+	g.do("set WAL", func() {
+		if snapshotEvery <= 0 {
+			snapshotEvery = DefaultWALSnapshotEvery
+		}
+		g.wal = wal
+		g.walSnapshotEvery = snapshotEvery
+	})
+}
+
+// logWAL appends an event before it is applied. If the append fails the
+// change is left to the next snapshot instead.
+func (g *Game) logWAL(recordType string, data interface{}) {
+	if g.wal == nil || g.replaying {
+		return
+	}
+	defer g.timeStage(StagePersist, time.Now())
+
+	if _, err := g.wal.Append(recordType, data); err != nil {
+		g.log().Errorf("Failed to write %s to the WAL: %v", recordType, err)
+		g.walCovered = false
+		return
+	}
+	g.walCovered = true
+	g.walSinceSnapshot++
+}
+
+// logDeal seals the deck and keys a hand is dealt from and logs them
+func (g *Game) logDeal(deal dealSecrets) {
+	if g.wal == nil || g.replaying {
+		return
+	}
+	sealed, err := persistence.SealSecrets(deal)
+	if err != nil {
+		g.log().Errorf("Failed to seal the deal for the WAL: %v", err)
+		g.walCovered = false
+		return
+	}
+	g.logWAL(persistence.WALDeal, walDeal{Secrets: sealed})
+}
+
+// ReplayWAL re-applies logged events on top of a restored snapshot, before
+// any peers connect. Nothing is settled on-chain while replaying; those
+// calls were made the first time round.
+func (g *Game) ReplayWAL(records []persistence.WALRecord) (replayed int, err error) {
+	err = g.exec("WAL replay", func() error {
+		replayed, err = g.replayWAL(records)
+		return err
+	})
+	return replayed, err
+}
+
+func (g *Game) replayWAL(records []persistence.WALRecord) (replayed int, err error) {
+	chainEnabled := g.blockchainEnabled
+	g.blockchainEnabled = false
+	g.replaying = true
+	defer func() {
+		g.blockchainEnabled = chainEnabled
+		g.replaying = false
+		g.replayDeal = nil
+	}()
+
+	for _, record := range records {
+		if err := g.replayRecord(record); err != nil {
+			return replayed, fmt.Errorf("WAL record %d (%s): %w", record.Seq, record.Type, err)
+		}
+		replayed++
+	}
+
+	if replayed > 0 {
+		g.log().WithFields(logrus.Fields{
+			"records": replayed,
+			"status":  g.currentStatus.String(),
+			"pot":     g.currentPot,
+		}).Info("Replayed write-ahead log")
+		g.audit("wal_replayed", "table", map[string]interface{}{
+			"records":  replayed,
+			"last_seq": records[len(records)-1].Seq,
+		})
+	}
+	return replayed, nil
+}
+
+func (g *Game) replayRecord(record persistence.WALRecord) error {
+	switch record.Type {
+	case persistence.WALAction:
+		var rec walAction
+		if err := json.Unmarshal(record.Data, &rec); err != nil {
+			return err
+		}
+		action, err := ParsePlayerAction(rec.Action)
+		if err != nil {
+			return err
+		}
+		if _, ok := g.playerStates[rec.Player]; !ok {
+			return fmt.Errorf("player %s not found", rec.Player)
+		}
+		g.applyPlayerAction(rec.Player, action, rec.Value)
+		g.advanceTurnAndCheckRoundEnd()
+
+	case persistence.WALDeal:
+		var rec walDeal
+		if err := json.Unmarshal(record.Data, &rec); err != nil {
+			return err
+		}
+		var deal dealSecrets
+		if rec.Secrets == nil {
+			// Logged before deals were sealed
+			if err := json.Unmarshal(record.Data, &deal); err != nil {
+				return err
+			}
+		} else {
+			opened, err := persistence.OpenSecrets(rec.Secrets, &deal)
+			if err != nil {
+				return err
+			}
+			if !opened {
+				return fmt.Errorf("the deal was sealed by another session")
+			}
+		}
+		g.replayDeal = &deal
+		g.startNewHand()
+		if g.replayDeal != nil {
+			return fmt.Errorf("the logged hand could not be started")
+		}
+
+	case persistence.WALReveal, persistence.WALPayout:
+		// Derived from the actions and deck replayed before them
+
+	default:
+		return fmt.Errorf("unknown record type")
+	}
+	return nil
+}
+
+// dealReplayedDeck deals a hand from the deck and keys in its WAL record
+// instead of shuffling a new one
+func (g *Game) dealReplayedDeck() {
+	if g.replayDeal.Own != nil {
+		g.deckKeys = g.replayDeal.Own
+		g.keyEpoch = g.handCount
+	} else {
+		// Logged before keys were made per hand: the deck was encrypted
+		// with keys this process no longer has
+		g.log().Warn("Replayed hand has none of our keys; our cards cannot be decrypted")
+		if err := g.rotateDeckKeys(); err != nil {
+			g.log().Errorf("Failed to replace keys for the replayed hand: %v", err)
+		}
+	}
+	g.currentDeck = g.replayDeal.Deck
+	g.revealedKeys = copyKeys(g.replayDeal.Keys)
+	g.replayDeal = nil
+
+	g.dealHoleCards()
+	g.setStatus(GameStatusPreFlop)
+	g.log().Info("Dealt hand from the write-ahead log")
+}
@@ -0,0 +1,342 @@
+package insurance
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/blockchain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+)
+
+// How a payout reached the player
+const (
+	MethodChain  = "chain"  // transferred from the operator wallet by the node
+	MethodManual = "manual" // paid by the operator elsewhere, referenced by tx hash
+)
+
+// Payer sends compensation on-chain from the operator's wallet
+type Payer interface {
+	Transfer(to common.Address, value *big.Int) (*blockchain.TxReceipt, error)
+}
+
+// Deposit is operator money added to the pool
+type Deposit struct {
+	ID     int       `json:"id"`
+	Amount int       `json:"amount"`
+	Actor  string    `json:"actor"`
+	Note   string    `json:"note,omitempty"`
+	TxHash string    `json:"tx_hash,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// Claim asks the pool to compensate a player for a specific incident and hand
+type Claim struct {
+	PlayerID   string `json:"player_id"`
+	Amount     int    `json:"amount"`
+	IncidentID string `json:"incident_id"`
+	HandID     int    `json:"hand_id"`
+	TableID    string `json:"table_id,omitempty"`
+	Reason     string `json:"reason"`
+
+	// Set when the operator already paid the player outside the node
+	TxHash string `json:"tx_hash,omitempty"`
+}
+
+// Payout is a completed compensation
+type Payout struct {
+	ID int `json:"id"`
+	Claim
+	Actor   string                `json:"actor"`
+	Method  string                `json:"method"`
+	Receipt *blockchain.TxReceipt `json:"receipt,omitempty"`
+	PaidAt  time.Time             `json:"paid_at"`
+}
+
+// Summary is the pool's running totals
+type Summary struct {
+	Balance   int `json:"balance"`
+	Deposited int `json:"deposited"`
+	PaidOut   int `json:"paid_out"`
+	Payouts   int `json:"payouts"`
+}
+
+// state is everything persisted to disk
+type state struct {
+	Deposits []Deposit `json:"deposits"`
+	Payouts  []Payout  `json:"payouts"`
+}
+
+// Fund is an operator-funded pool used to make players whole after bugs or
+// failed settlements. Every deposit and payout is kept, and the balance is
+// always derived from them.
+type Fund struct {
+	deposits []Deposit
+	payouts  []Payout
+	reserved int // claims being paid out right now
+	nextID   int
+
+	payer Payer
+	path  string
+	mu    sync.RWMutex
+}
+
+// NewFund creates a fund persisted to path (empty for memory only). Without a
+// payer, only manual payouts can be recorded.
+func NewFund(path string, payer Payer) *Fund {
+	f := &Fund{
+		deposits: []Deposit{},
+		payouts:  []Payout{},
+		payer:    payer,
+		path:     path,
+	}
+
+	if err := f.load(); err != nil {
+		logrus.Warnf("Failed to load insurance fund: %v", err)
+	}
+	return f
+}
+
+// Summary returns the pool's balance and totals
+func (f *Fund) Summary() Summary {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.summaryLocked()
+}
+
+// Deposits returns every deposit, oldest first
+func (f *Fund) Deposits() []Deposit {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return append([]Deposit{}, f.deposits...)
+}
+
+// Payouts returns payouts, oldest first, optionally for one incident and/or hand
+func (f *Fund) Payouts(incidentID string, handID int) []Payout {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	payouts := []Payout{}
+	for _, p := range f.payouts {
+		if incidentID != "" && p.IncidentID != incidentID {
+			continue
+		}
+		if handID != 0 && p.HandID != handID {
+			continue
+		}
+		payouts = append(payouts, p)
+	}
+	return payouts
+}
+
+// Deposit adds operator money to the pool
+func (f *Fund) Deposit(amount int, actor, note, txHash string) (Deposit, error) {
+	if amount <= 0 {
+		return Deposit{}, fmt.Errorf("deposit amount must be positive")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.nextID++
+	deposit := Deposit{
+		ID:     f.nextID,
+		Amount: amount,
+		Actor:  actor,
+		Note:   note,
+		TxHash: txHash,
+		At:     time.Now(),
+	}
+	f.deposits = append(f.deposits, deposit)
+
+	if err := f.saveLocked(); err != nil {
+		logrus.Errorf("Failed to save insurance fund: %v", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"amount":  amount,
+		"actor":   actor,
+		"balance": f.summaryLocked().Balance,
+	}).Info("Insurance fund deposit")
+	return deposit, nil
+}
+
+// Compensate pays a claim out of the pool. Claims with a tx hash are
+// recorded as paid manually; the rest are transferred on-chain.
+func (f *Fund) Compensate(claim Claim, actor string) (Payout, error) {
+	if err := claim.Validate(); err != nil {
+		return Payout{}, err
+	}
+
+	method := MethodManual
+	if claim.TxHash == "" {
+		if f.payer == nil {
+			return Payout{}, fmt.Errorf("blockchain is not enabled; pay the player manually and pass tx_hash")
+		}
+		if !blockchain.IsValidAddress(claim.PlayerID) {
+			return Payout{}, fmt.Errorf("player %s is not a wallet address; pay manually and pass tx_hash", claim.PlayerID)
+		}
+		method = MethodChain
+	}
+
+	// Reserve the amount so concurrent claims cannot overdraw the pool
+	f.mu.Lock()
+	if f.alreadyPaidLocked(claim) {
+		f.mu.Unlock()
+		return Payout{}, fmt.Errorf("player %s was already compensated for incident %s, hand %d",
+			claim.PlayerID, claim.IncidentID, claim.HandID)
+	}
+	if balance := f.summaryLocked().Balance; claim.Amount > balance {
+		f.mu.Unlock()
+		return Payout{}, fmt.Errorf("insufficient insurance funds: balance %d, claim %d", balance, claim.Amount)
+	}
+	f.reserved += claim.Amount
+	f.mu.Unlock()
+
+	var receipt *blockchain.TxReceipt
+	var err error
+	if method == MethodChain {
+		receipt, err = f.payer.Transfer(common.HexToAddress(claim.PlayerID), big.NewInt(int64(claim.Amount)))
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reserved -= claim.Amount
+
+	if err != nil {
+		return Payout{}, fmt.Errorf("failed to pay compensation: %w", err)
+	}
+
+	f.nextID++
+	payout := Payout{
+		ID:      f.nextID,
+		Claim:   claim,
+		Actor:   actor,
+		Method:  method,
+		Receipt: receipt,
+		PaidAt:  time.Now(),
+	}
+	if receipt != nil {
+		payout.TxHash = receipt.TxHash
+	}
+	f.payouts = append(f.payouts, payout)
+
+	if err := f.saveLocked(); err != nil {
+		logrus.Errorf("Failed to save insurance fund: %v", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"player":   claim.PlayerID,
+		"amount":   claim.Amount,
+		"incident": claim.IncidentID,
+		"hand":     claim.HandID,
+		"method":   method,
+		"actor":    actor,
+	}).Warn("Insurance payout made")
+	return payout, nil
+}
+
+// Validate checks a claim names a player, an amount, and what it compensates for
+func (claim Claim) Validate() error {
+	if claim.PlayerID == "" {
+		return fmt.Errorf("player_id is required")
+	}
+	if claim.Amount <= 0 {
+		return fmt.Errorf("compensation amount must be positive")
+	}
+	if claim.IncidentID == "" {
+		return fmt.Errorf("incident_id is required")
+	}
+	if claim.HandID <= 0 {
+		return fmt.Errorf("hand_id is required")
+	}
+	if claim.Reason == "" {
+		return fmt.Errorf("a reason is required")
+	}
+	return nil
+}
+
+func (f *Fund) alreadyPaidLocked(claim Claim) bool {
+	for _, p := range f.payouts {
+		if p.PlayerID == claim.PlayerID && p.IncidentID == claim.IncidentID && p.HandID == claim.HandID {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Fund) summaryLocked() Summary {
+	summary := Summary{Payouts: len(f.payouts)}
+	for _, d := range f.deposits {
+		summary.Deposited += d.Amount
+	}
+	for _, p := range f.payouts {
+		summary.PaidOut += p.Amount
+	}
+	summary.Balance = summary.Deposited - summary.PaidOut - f.reserved
+	return summary
+}
+
+func (f *Fund) load() error {
+	if f.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read insurance fund file: %w", err)
+	}
+
+	var saved state
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("failed to unmarshal insurance fund: %w", err)
+	}
+
+	f.deposits = append(f.deposits, saved.Deposits...)
+	f.payouts = append(f.payouts, saved.Payouts...)
+	for _, d := range f.deposits {
+		if d.ID > f.nextID {
+			f.nextID = d.ID
+		}
+	}
+	for _, p := range f.payouts {
+		if p.ID > f.nextID {
+			f.nextID = p.ID
+		}
+	}
+	return nil
+}
+
+func (f *Fund) saveLocked() error {
+	if f.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(state{Deposits: f.deposits, Payouts: f.payouts}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal insurance fund: %w", err)
+	}
+
+	if dir := filepath.Dir(f.path); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write insurance fund: %w", err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return fmt.Errorf("failed to replace insurance fund: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package lobby
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/RedPaladin7/peerpoker/internal/game"
+	"github.com/sirupsen/logrus"
+)
+
+// Filter selects tables by stakes and format; zero values match anything
+type Filter struct {
+	Variant     string `json:"variant,omitempty"`
+	MinBigBlind int    `json:"min_big_blind,omitempty"`
+	MaxBigBlind int    `json:"max_big_blind,omitempty"`
+	MaxBuyIn    int    `json:"max_buy_in,omitempty"`
+	OpenOnly    bool   `json:"open_only,omitempty"`
+}
+
+// Matches reports whether a table passes the filter
+func (f Filter) Matches(t TableInfo) bool {
+	if f.Variant != "" && f.Variant != t.Variant {
+		return false
+	}
+	if f.MinBigBlind > 0 && t.BigBlind < f.MinBigBlind {
+		return false
+	}
+	if f.MaxBigBlind > 0 && t.BigBlind > f.MaxBigBlind {
+		return false
+	}
+	if f.MaxBuyIn > 0 && t.BuyIn > f.MaxBuyIn {
+		return false
+	}
+	if f.OpenOnly && t.SeatsFree == 0 {
+		return false
+	}
+	return true
+}
+
+// Lobby lists open tables and seats players through the TableManager
+type Lobby struct {
+	tables *TableManager
+
+	// Serializes quick-seat so two players can't take the last seat at once
+	seatMu sync.Mutex
+}
+
+func NewLobby(tables *TableManager) *Lobby {
+	return &Lobby{tables: tables}
+}
+
+// Tables lists tables matching the filter, fullest first so games fill up
+func (l *Lobby) Tables(filter Filter) []TableInfo {
+	list := make([]TableInfo, 0)
+	for _, t := range l.tables.Tables() {
+		if info := t.Info(); filter.Matches(info) {
+			list = append(list, info)
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Seated > list[j].Seated
+	})
+	return list
+}
+
+// QuickSeat places a player at the first matching table with a free seat
+func (l *Lobby) QuickSeat(playerID string, filter Filter) (TableInfo, error) {
+	if playerID == "" {
+		return TableInfo{}, fmt.Errorf("player ID required")
+	}
+
+	l.seatMu.Lock()
+	defer l.seatMu.Unlock()
+
+	// Already seated somewhere: send them back to that table
+	for _, t := range l.tables.Tables() {
+		if t.Game.GetPlayer(playerID) != nil {
+			return t.Info(), nil
+		}
+	}
+
+	filter.OpenOnly = true
+	for _, info := range l.Tables(filter) {
+		t, ok := l.tables.Get(info.ID)
+		if !ok {
+			continue
+		}
+
+		t.Game.AddPlayer(playerID)
+		logrus.WithFields(logrus.Fields{
+			"player": playerID,
+			"table":  info.ID,
+		}).Info("Player quick-seated")
+		return t.Info(), nil
+	}
+
+	return TableInfo{}, fmt.Errorf("no open table matches")
+}
+
+// JoinWaitlist queues a player for a seat at a full table
+func (l *Lobby) JoinWaitlist(playerID, tableID string) (int, error) {
+	if playerID == "" {
+		return 0, fmt.Errorf("player ID required")
+	}
+
+	t, ok := l.tables.Get(tableID)
+	if !ok {
+		return 0, fmt.Errorf("table %s not found", tableID)
+	}
+
+	position, err := t.Game.JoinWaitlist(playerID)
+	if err != nil {
+		return 0, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"player":   playerID,
+		"table":    tableID,
+		"position": position,
+	}).Info("Player waiting for a seat")
+	return position, nil
+}
+
+// LeaveWaitlist removes a player from a table's waitlist
+func (l *Lobby) LeaveWaitlist(playerID, tableID string) error {
+	t, ok := l.tables.Get(tableID)
+	if !ok {
+		return fmt.Errorf("table %s not found", tableID)
+	}
+	return t.Game.LeaveWaitlist(playerID)
+}
+
+// Waitlist returns a table's waiting players in seating order
+func (l *Lobby) Waitlist(tableID string) ([]game.WaitlistEntry, error) {
+	t, ok := l.tables.Get(tableID)
+	if !ok {
+		return nil, fmt.Errorf("table %s not found", tableID)
+	}
+	return t.Game.Waitlist(), nil
+}
@@ -0,0 +1,200 @@
+package lobby
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/game"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultGCInterval is how often idle tables are looked for
+const DefaultGCInterval = time.Minute
+
+// TableSettings are the fixed stakes and format of a table
+type TableSettings struct {
+	Name       string `json:"name"`
+	Variant    string `json:"variant"`
+	SmallBlind int    `json:"small_blind"`
+	BigBlind   int    `json:"big_blind"`
+	Ante       int    `json:"ante,omitempty"`
+	BuyIn      int    `json:"buy_in"`
+	MinBuyIn   int    `json:"min_buy_in,omitempty"`
+	MaxBuyIn   int    `json:"max_buy_in,omitempty"`
+	MaxSeats   int    `json:"max_seats"`
+}
+
+// TableInfo is a table as shown in the lobby
+type TableInfo struct {
+	ID string `json:"id"`
+	TableSettings
+	Seated    int    `json:"seated"`
+	SeatsFree int    `json:"seats_free"`
+	Waitlist  int    `json:"waitlist"`
+	Status    string `json:"status"`
+	Host      string `json:"host,omitempty"`
+	JoinURL   string `json:"join_url"`
+}
+
+// ManagedTable is a table hosted by this node
+type ManagedTable struct {
+	ID       string
+	Settings TableSettings
+	Game     *game.Game
+	JoinURL  string
+
+	// Never torn down when idle, like the node's own table
+	Pinned bool
+}
+
+// Info returns the table's current lobby listing
+func (t *ManagedTable) Info() TableInfo {
+	seated := t.Game.ActivePlayerCount()
+	free := t.Settings.MaxSeats - seated
+	if free < 0 {
+		free = 0
+	}
+
+	return TableInfo{
+		ID:            t.ID,
+		TableSettings: t.Settings,
+		Seated:        seated,
+		SeatsFree:     free,
+		Waitlist:      t.Game.WaitlistLength(),
+		Status:        t.Game.GetStatus().String(),
+		Host:          t.Game.Host().Host,
+		JoinURL:       t.JoinURL,
+	}
+}
+
+// TableManager tracks the tables hosted by this node, tearing down those
+// left empty for the idle timeout
+type TableManager struct {
+	tables      map[string]*ManagedTable
+	idleTimeout time.Duration
+	mu          sync.RWMutex
+}
+
+func NewTableManager() *TableManager {
+	return &TableManager{
+		tables: make(map[string]*ManagedTable),
+	}
+}
+
+// Register adds a table
+func (tm *TableManager) Register(id string, settings TableSettings, g *game.Game, joinURL string) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if _, exists := tm.tables[id]; exists {
+		return fmt.Errorf("table %s already registered", id)
+	}
+
+	tm.tables[id] = &ManagedTable{
+		ID:       id,
+		Settings: settings,
+		Game:     g,
+		JoinURL:  joinURL,
+	}
+	return nil
+}
+
+// Unregister removes a table
+func (tm *TableManager) Unregister(id string) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	delete(tm.tables, id)
+}
+
+// Get returns a table by ID
+func (tm *TableManager) Get(id string) (*ManagedTable, bool) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	t, ok := tm.tables[id]
+	return t, ok
+}
+
+// Tables returns all tables sorted by ID
+func (tm *TableManager) Tables() []*ManagedTable {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	tables := make([]*ManagedTable, 0, len(tm.tables))
+	for _, t := range tm.tables {
+		tables = append(tables, t)
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].ID < tables[j].ID
+	})
+	return tables
+}
+
+// SetIdleTimeout sets how long a table may sit empty before it is torn
+// down; 0 keeps idle tables for good
+func (tm *TableManager) SetIdleTimeout(timeout time.Duration) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.idleTimeout = timeout
+}
+
+// Pin keeps a table from ever being torn down for being idle
+func (tm *TableManager) Pin(id string) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	t, ok := tm.tables[id]
+	if !ok {
+		return fmt.Errorf("table %s not registered", id)
+	}
+	t.Pinned = true
+	return nil
+}
+
+// CollectIdle closes and unregisters every table that has been empty for
+// the idle timeout, returning their IDs. Closing a table stops its
+// goroutines and writes out its state and history.
+func (tm *TableManager) CollectIdle() []string {
+	tm.mu.Lock()
+	timeout := tm.idleTimeout
+	var idle []*ManagedTable
+	if timeout > 0 {
+		for id, t := range tm.tables {
+			if t.Pinned {
+				continue
+			}
+			if since, ok := t.Game.IdleSince(); ok && time.Since(since) >= timeout {
+				idle = append(idle, t)
+				delete(tm.tables, id)
+			}
+		}
+	}
+	tm.mu.Unlock()
+
+	ids := make([]string, 0, len(idle))
+	for _, t := range idle {
+		t.Game.Close("gc")
+		ids = append(ids, t.ID)
+		logrus.WithField("table", t.ID).Infof("Closed table idle for over %v", timeout)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// RunGC collects idle tables every interval until ctx is done
+func (tm *TableManager) RunGC(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			tm.CollectIdle()
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Package logging sets up structured logs. Each table logs through an
+// entry carrying its table ID, extended with the hand and player a message
+// is about, so one hand or player can be followed across a node's logs
+// once they are aggregated.
+package logging
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Correlation fields shared by every component
+const (
+	FieldTable  = "table_id"
+	FieldHand   = "hand_id"
+	FieldPlayer = "player_id"
+)
+
+// Log output formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// SetFormat switches the standard logger between human-readable text and
+// one JSON object per line for log aggregation
+func SetFormat(format string) error {
+	switch format {
+	case "", FormatText:
+		logrus.SetFormatter(&logrus.TextFormatter{
+			FullTimestamp:   true,
+			TimestampFormat: "2006-01-02 15:04:05",
+			ForceColors:     true,
+		})
+	case FormatJSON:
+		logrus.SetFormatter(&logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339Nano,
+		})
+	default:
+		return fmt.Errorf("unknown log format %q (want %s or %s)", format, FormatText, FormatJSON)
+	}
+	return nil
+}
+
+// ForTable returns the logger for a table's components
+func ForTable(tableID string) *logrus.Entry {
+	return logrus.WithField(FieldTable, tableID)
+}
+
+// Default returns the standard logger as an entry, for components that
+// were not given one
+func Default() *logrus.Entry {
+	return logrus.NewEntry(logrus.StandardLogger())
+}
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets run from 50µs to 5s, fine enough to tell a slow
+// broadcast from a slow disk
+var DefaultLatencyBuckets = []time.Duration{
+	50 * time.Microsecond, 100 * time.Microsecond, 250 * time.Microsecond, 500 * time.Microsecond,
+	time.Millisecond, 2500 * time.Microsecond, 5 * time.Millisecond, 10 * time.Millisecond,
+	25 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond, 250 * time.Millisecond,
+	500 * time.Millisecond, time.Second, 2500 * time.Millisecond, 5 * time.Second,
+}
+
+// Histogram counts durations into fixed buckets. It is cheap enough to
+// observe on every action and safe to use from any goroutine.
+type Histogram struct {
+	mu     sync.Mutex
+	bounds []time.Duration
+	counts []uint64 // one per bound, then one for anything slower
+	count  uint64
+	sum    time.Duration
+	max    time.Duration
+}
+
+// Bucket is how many observations took at most UpperMs
+type Bucket struct {
+	UpperMs float64 `json:"le_ms"`
+	Count   uint64  `json:"count"`
+}
+
+// HistogramSnapshot is a histogram as it stood, with buckets cumulative like
+// Prometheus's and quantiles estimated from the bucket bounds
+type HistogramSnapshot struct {
+	Count   uint64   `json:"count"`
+	SumMs   float64  `json:"sum_ms"`
+	MaxMs   float64  `json:"max_ms"`
+	P50Ms   float64  `json:"p50_ms"`
+	P95Ms   float64  `json:"p95_ms"`
+	P99Ms   float64  `json:"p99_ms"`
+	Buckets []Bucket `json:"buckets"`
+}
+
+// NewHistogram creates a histogram with the given ascending bucket bounds
+func NewHistogram(bounds []time.Duration) *Histogram {
+	return &Histogram{
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+// Observe records one duration
+func (h *Histogram) Observe(d time.Duration) {
+	i := 0
+	for i < len(h.bounds) && d > h.bounds[i] {
+		i++
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts[i]++
+	h.count++
+	h.sum += d
+	if d > h.max {
+		h.max = d
+	}
+}
+
+// Snapshot returns the histogram as it stands
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	snap := HistogramSnapshot{
+		Count:   h.count,
+		SumMs:   ms(h.sum),
+		MaxMs:   ms(h.max),
+		Buckets: make([]Bucket, len(h.bounds)),
+	}
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		snap.Buckets[i] = Bucket{UpperMs: ms(bound), Count: cumulative}
+	}
+	snap.P50Ms = h.quantile(0.50)
+	snap.P95Ms = h.quantile(0.95)
+	snap.P99Ms = h.quantile(0.99)
+	return snap
+}
+
+// quantile is the upper bound of the bucket holding the q-th observation,
+// or the slowest one seen when that is past the last bound
+func (h *Histogram) quantile(q float64) float64 {
+	if h.count == 0 {
+		return 0
+	}
+	rank := uint64(q * float64(h.count))
+	if rank == 0 {
+		rank = 1
+	}
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		if cumulative >= rank {
+			if bound > h.max {
+				return ms(h.max)
+			}
+			return ms(bound)
+		}
+	}
+	return ms(h.max)
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,289 @@
+package persistence
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// auditGenesisHash is the previous hash of the first record
+var auditGenesisHash = strings.Repeat("0", sha256.Size*2)
+
+// AuditRecord is one entry in the audit log. Each record's hash covers its
+// content and the previous record's hash, so editing, dropping or
+// reordering records breaks the chain from that point on.
+type AuditRecord struct {
+	Seq      uint64          `json:"seq"`
+	Time     time.Time       `json:"time"`
+	TableID  string          `json:"table_id,omitempty"`
+	Action   string          `json:"action"`
+	Actor    string          `json:"actor"`
+	HandUID  string          `json:"hand_uid,omitempty"`
+	Details  json.RawMessage `json:"details,omitempty"`
+	PrevHash string          `json:"prev_hash"`
+	Hash     string          `json:"hash"`
+}
+
+// computeHash hashes the record with its Hash field cleared
+func (r AuditRecord) computeHash() (string, error) {
+	r.Hash = ""
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// AuditQuery filters audit records; zero fields match everything
+type AuditQuery struct {
+	TableID  string
+	Action   string
+	Actor    string
+	HandUID  string
+	AfterSeq uint64
+	Limit    int
+}
+
+func (q AuditQuery) matches(r AuditRecord) bool {
+	return r.Seq > q.AfterSeq &&
+		(q.TableID == "" || r.TableID == q.TableID) &&
+		(q.Action == "" || r.Action == q.Action) &&
+		(q.Actor == "" || r.Actor == q.Actor) &&
+		(q.HandUID == "" || r.HandUID == q.HandUID)
+}
+
+// AuditLog is an append-only, hash-chained log of privileged operations,
+// kept as one JSON record per line
+type AuditLog struct {
+	path     string
+	file     *os.File
+	lastSeq  uint64
+	lastHash string
+	mu       sync.Mutex
+}
+
+// OpenAuditLog opens or creates the log and verifies the existing chain. A
+// torn final line left by a crash mid-write is cut off; any other damage
+// is an error, since appending to a broken chain would hide it.
+func OpenAuditLog(path string) (*AuditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	log := &AuditLog{path: path, lastHash: auditGenesisHash}
+	validSize, err := log.verify(func(r AuditRecord) {
+		log.lastSeq = r.Seq
+		log.lastHash = r.Hash
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if err := file.Truncate(validSize); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to trim audit log: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	log.file = file
+	return log, nil
+}
+
+// Append adds a record to the chain and syncs it to disk
+func (l *AuditLog) Append(tableID, action, actor, handUID string, details interface{}) (AuditRecord, error) {
+	var raw json.RawMessage
+	if details != nil {
+		data, err := json.Marshal(details)
+		if err != nil {
+			return AuditRecord{}, fmt.Errorf("failed to marshal audit details: %w", err)
+		}
+		raw = data
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	record := AuditRecord{
+		Seq:      l.lastSeq + 1,
+		Time:     time.Now().UTC(),
+		TableID:  tableID,
+		Action:   action,
+		Actor:    actor,
+		HandUID:  handUID,
+		Details:  raw,
+		PrevHash: l.lastHash,
+	}
+	hash, err := record.computeHash()
+	if err != nil {
+		return AuditRecord{}, fmt.Errorf("failed to hash audit record: %w", err)
+	}
+	record.Hash = hash
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return AuditRecord{}, fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return AuditRecord{}, fmt.Errorf("failed to write audit record: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return AuditRecord{}, fmt.Errorf("failed to sync audit log: %w", err)
+	}
+
+	l.lastSeq = record.Seq
+	l.lastHash = record.Hash
+	return record, nil
+}
+
+// Head returns the sequence number and hash of the latest record; an
+// operator who keeps the head can later show nothing before it changed
+func (l *AuditLog) Head() (uint64, string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lastSeq, l.lastHash
+}
+
+// Query returns matching records, oldest first
+func (l *AuditLog) Query(q AuditQuery) ([]AuditRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	records := []AuditRecord{}
+	err := l.scan(func(r AuditRecord) error {
+		if q.matches(r) {
+			records = append(records, r)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if q.Limit > 0 && len(records) > q.Limit {
+		records = records[len(records)-q.Limit:]
+	}
+	return records, nil
+}
+
+// Verify re-checks the whole chain on disk and returns how many records it holds
+func (l *AuditLog) Verify() (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	count := 0
+	if _, err := l.verify(func(AuditRecord) { count++ }); err != nil {
+		return count, err
+	}
+	return count, nil
+}
+
+// Close closes the log file
+func (l *AuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// verify walks the chain, calling fn for each good record, and returns the
+// size of the file up to the last complete line
+func (l *AuditLog) verify(fn func(AuditRecord)) (int64, error) {
+	prevHash := auditGenesisHash
+	var prevSeq uint64
+	var size int64
+
+	err := l.readLines(func(line []byte, complete bool) error {
+		var record AuditRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			if !complete {
+				return io.EOF // torn write
+			}
+			return fmt.Errorf("audit log record after seq %d is unreadable: %w", prevSeq, err)
+		}
+		if !complete {
+			return io.EOF
+		}
+
+		if record.Seq != prevSeq+1 {
+			return fmt.Errorf("audit log seq %d follows %d", record.Seq, prevSeq)
+		}
+		if record.PrevHash != prevHash {
+			return fmt.Errorf("audit log record %d does not link to the record before it", record.Seq)
+		}
+		hash, err := record.computeHash()
+		if err != nil {
+			return err
+		}
+		if hash != record.Hash {
+			return fmt.Errorf("audit log record %d has been altered", record.Seq)
+		}
+
+		fn(record)
+		prevSeq = record.Seq
+		prevHash = record.Hash
+		size += int64(len(line)) + 1
+		return nil
+	})
+	return size, err
+}
+
+// scan reads every complete record without checking the chain
+func (l *AuditLog) scan(fn func(AuditRecord) error) error {
+	return l.readLines(func(line []byte, complete bool) error {
+		if !complete {
+			return io.EOF
+		}
+		var record AuditRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return fmt.Errorf("failed to read audit record: %w", err)
+		}
+		return fn(record)
+	})
+}
+
+// readLines calls fn for each line; complete is false for a final line
+// without a newline. fn may return io.EOF to stop early.
+func (l *AuditLog) readLines(fn func(line []byte, complete bool) error) error {
+	file, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			complete := err == nil
+			if ferr := fn(bytes.TrimSuffix(line, []byte("\n")), complete); ferr != nil {
+				if ferr == io.EOF {
+					return nil
+				}
+				return ferr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read audit log: %w", err)
+		}
+	}
+}
@@ -1,216 +1,354 @@
 package persistence
 
 import (
+	"bytes"
 	"compress/gzip"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
+// BackupObject is one stored backup
+type BackupObject struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// BackupBackend is where backups are kept: a local directory, or an object
+// store such as S3 or MinIO
+type BackupBackend interface {
+	Name() string
+	Put(name string, data []byte) error
+	Get(name string) ([]byte, error)
+	List() ([]BackupObject, error)
+	Delete(name string) error
+}
+
+// BackupStats counts scheduled and manual backups, for monitoring
+type BackupStats struct {
+	Backend     string    `json:"backend"`
+	Successes   int       `json:"successes"`
+	Failures    int       `json:"failures"`
+	LastBackup  string    `json:"last_backup,omitempty"`
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	LastFailure time.Time `json:"last_failure,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
 // BackupManager handles backup operations
 type BackupManager struct {
-	backupDir      string
-	maxBackups     int
-	compressionOn  bool
+	backend       BackupBackend
+	maxBackups    int
+	maxAge        time.Duration
+	compressionOn bool
+
+	mu    sync.Mutex
+	stats BackupStats
+	stop  chan struct{}
 }
 
-// NewBackupManager creates a new backup manager
+// NewBackupManager creates a new backup manager writing to a local directory
 func NewBackupManager(backupDir string, maxBackups int, compression bool) *BackupManager {
+	return NewBackupManagerWithBackend(NewLocalBackend(backupDir), maxBackups, compression)
+}
+
+// NewBackupManagerWithBackend creates a backup manager for any backend
+func NewBackupManagerWithBackend(backend BackupBackend, maxBackups int, compression bool) *BackupManager {
 	return &BackupManager{
-		backupDir:     backupDir,
+		backend:       backend,
 		maxBackups:    maxBackups,
 		compressionOn: compression,
+		stats:         BackupStats{Backend: backend.Name()},
 	}
 }
 
+// Backend returns where the backups are kept
+func (bm *BackupManager) Backend() BackupBackend {
+	return bm.backend
+}
+
+// SetMaxAge also removes backups older than maxAge when cleaning up (0 keeps them)
+func (bm *BackupManager) SetMaxAge(maxAge time.Duration) {
+	bm.maxAge = maxAge
+}
+
 // CreateBackup creates a backup of a snapshot file
 func (bm *BackupManager) CreateBackup(snapshotFile string) error {
-	// Ensure backup directory exists
-	if err := os.MkdirAll(bm.backupDir, 0755); err != nil {
-		return fmt.Errorf("failed to create backup directory: %w", err)
+	name, err := bm.createBackup(snapshotFile)
+	bm.recordResult(name, err)
+	return err
+}
+
+func (bm *BackupManager) createBackup(snapshotFile string) (string, error) {
+	data, err := os.ReadFile(snapshotFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read snapshot: %w", err)
 	}
 
 	// Generate backup filename
 	timestamp := time.Now().Format("20060102_150405")
-	baseFilename := filepath.Base(snapshotFile)
-	backupFilename := fmt.Sprintf("backup_%s_%s", timestamp, baseFilename)
-	
+	name := fmt.Sprintf("backup_%s_%s", timestamp, filepath.Base(snapshotFile))
+
 	if bm.compressionOn {
-		backupFilename += ".gz"
+		name += ".gz"
+		if data, err = compress(data); err != nil {
+			return "", fmt.Errorf("failed to compress backup: %w", err)
+		}
 	}
-	
-	backupPath := filepath.Join(bm.backupDir, backupFilename)
 
-	// Copy file
-	if bm.compressionOn {
-		return bm.compressFile(snapshotFile, backupPath)
+	if err := bm.backend.Put(name, data); err != nil {
+		return "", fmt.Errorf("failed to store backup in %s: %w", bm.backend.Name(), err)
 	}
-	return bm.copyFile(snapshotFile, backupPath)
+
+	logrus.Infof("Backup created: %s (%s)", name, bm.backend.Name())
+	return name, nil
 }
 
-// copyFile copies a file from src to dst
-func (bm *BackupManager) copyFile(src, dst string) error {
-	sourceFile, err := os.Open(src)
-	if err != nil {
-		return fmt.Errorf("failed to open source file: %w", err)
-	}
-	defer sourceFile.Close()
+func (bm *BackupManager) recordResult(name string, err error) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
 
-	destFile, err := os.Create(dst)
 	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
-	}
-	defer destFile.Close()
-
-	if _, err := io.Copy(destFile, sourceFile); err != nil {
-		return fmt.Errorf("failed to copy file: %w", err)
+		bm.stats.Failures++
+		bm.stats.LastFailure = time.Now()
+		bm.stats.LastError = err.Error()
+		return
 	}
+	bm.stats.Successes++
+	bm.stats.LastSuccess = time.Now()
+	bm.stats.LastBackup = name
+}
 
-	logrus.Infof("Backup created: %s", dst)
-	return nil
+// Stats returns backup success and failure counts
+func (bm *BackupManager) Stats() BackupStats {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	return bm.stats
 }
 
-// compressFile compresses a file using gzip
-func (bm *BackupManager) compressFile(src, dst string) error {
-	sourceFile, err := os.Open(src)
-	if err != nil {
-		return fmt.Errorf("failed to open source file: %w", err)
+// Start backs up the file returned by source every interval, then cleans
+// up old backups. An empty path means there is nothing to back up yet.
+func (bm *BackupManager) Start(interval time.Duration, source func() (string, error)) {
+	if interval <= 0 {
+		logrus.Warnf("Scheduled backups not started: invalid interval %v", interval)
+		return
 	}
-	defer sourceFile.Close()
 
-	destFile, err := os.Create(dst)
-	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
+	bm.mu.Lock()
+	if bm.stop != nil {
+		bm.mu.Unlock()
+		return
 	}
-	defer destFile.Close()
+	bm.stop = make(chan struct{})
+	stop := bm.stop
+	bm.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				bm.runScheduled(source)
+			}
+		}
+	}()
+}
 
-	gzipWriter := gzip.NewWriter(destFile)
-	defer gzipWriter.Close()
+// Stop ends scheduled backups
+func (bm *BackupManager) Stop() {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
 
-	if _, err := io.Copy(gzipWriter, sourceFile); err != nil {
-		return fmt.Errorf("failed to compress file: %w", err)
+	if bm.stop != nil {
+		close(bm.stop)
+		bm.stop = nil
 	}
-
-	logrus.Infof("Compressed backup created: %s", dst)
-	return nil
 }
 
-// decompressFile decompresses a gzip file
-func (bm *BackupManager) decompressFile(src, dst string) error {
-	sourceFile, err := os.Open(src)
+func (bm *BackupManager) runScheduled(source func() (string, error)) {
+	file, err := source()
 	if err != nil {
-		return fmt.Errorf("failed to open source file: %w", err)
+		bm.recordResult("", fmt.Errorf("failed to find file to back up: %w", err))
+		logrus.Errorf("Scheduled backup failed: %v", err)
+		return
 	}
-	defer sourceFile.Close()
-
-	gzipReader, err := gzip.NewReader(sourceFile)
-	if err != nil {
-		return fmt.Errorf("failed to create gzip reader: %w", err)
+	if file == "" {
+		return
 	}
-	defer gzipReader.Close()
 
-	destFile, err := os.Create(dst)
-	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
+	if err := bm.CreateBackup(file); err != nil {
+		logrus.Errorf("Scheduled backup failed: %v", err)
+		return
+	}
+	if err := bm.CleanOldBackups(); err != nil {
+		logrus.Warnf("Failed to clean old backups: %v", err)
 	}
-	defer destFile.Close()
+}
 
-	if _, err := io.Copy(destFile, gzipReader); err != nil {
-		return fmt.Errorf("failed to decompress file: %w", err)
+// compress gzips data in memory
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	if _, err := gzipWriter.Write(data); err != nil {
+		return nil, err
 	}
+	if err := gzipWriter.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
 
-	logrus.Infof("File decompressed: %s", dst)
-	return nil
+// decompress gunzips data in memory
+func decompress(data []byte) ([]byte, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	defer gzipReader.Close()
+	return io.ReadAll(gzipReader)
 }
 
-// CleanOldBackups removes old backups keeping only the specified maximum
+// CleanOldBackups removes backups beyond the maximum count, oldest first,
+// and any older than the maximum age
 func (bm *BackupManager) CleanOldBackups() error {
-	files, err := os.ReadDir(bm.backupDir)
+	backups, err := bm.backend.List()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
-		return fmt.Errorf("failed to read backup directory: %w", err)
+		return fmt.Errorf("failed to list backups: %w", err)
 	}
 
-	if len(files) <= bm.maxBackups {
-		return nil
-	}
+	// Sort by modification time (oldest first)
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ModTime.Before(backups[j].ModTime)
+	})
 
-	// Sort files by modification time
-	type fileInfo struct {
-		name    string
-		modTime time.Time
+	deleteCount := 0
+	if bm.maxBackups > 0 && len(backups) > bm.maxBackups {
+		deleteCount = len(backups) - bm.maxBackups
 	}
-
-	fileInfos := make([]fileInfo, 0)
-	for _, file := range files {
-		if file.IsDir() {
-			continue
+	if bm.maxAge > 0 {
+		cutoff := time.Now().Add(-bm.maxAge)
+		for deleteCount < len(backups) && backups[deleteCount].ModTime.Before(cutoff) {
+			deleteCount++
 		}
+	}
 
-		fullPath := filepath.Join(bm.backupDir, file.Name())
-		info, err := os.Stat(fullPath)
-		if err != nil {
-			continue
+	// Delete oldest files
+	for _, backup := range backups[:deleteCount] {
+		if err := bm.backend.Delete(backup.Name); err != nil {
+			logrus.Warnf("Failed to delete old backup %s: %v", backup.Name, err)
+		} else {
+			logrus.Infof("Deleted old backup: %s", backup.Name)
 		}
+	}
 
-		fileInfos = append(fileInfos, fileInfo{
-			name:    fullPath,
-			modTime: info.ModTime(),
-		})
+	return nil
+}
+
+// RestoreBackup restores a backup to destFile
+func (bm *BackupManager) RestoreBackup(backupName, destFile string) error {
+	data, err := bm.backend.Get(filepath.Base(backupName))
+	if err != nil {
+		return fmt.Errorf("failed to fetch backup: %w", err)
 	}
 
-	// Sort by modification time (oldest first)
-	for i := 0; i < len(fileInfos)-1; i++ {
-		for j := i + 1; j < len(fileInfos); j++ {
-			if fileInfos[i].modTime.After(fileInfos[j].modTime) {
-				fileInfos[i], fileInfos[j] = fileInfos[j], fileInfos[i]
-			}
+	if strings.HasSuffix(backupName, ".gz") {
+		if data, err = decompress(data); err != nil {
+			return fmt.Errorf("failed to decompress backup: %w", err)
 		}
 	}
 
-	// Delete oldest files
-	deleteCount := len(fileInfos) - bm.maxBackups
-	for i := 0; i < deleteCount; i++ {
-		if err := os.Remove(fileInfos[i].name); err != nil {
-			logrus.Warnf("Failed to delete old backup %s: %v", fileInfos[i].name, err)
-		} else {
-			logrus.Infof("Deleted old backup: %s", fileInfos[i].name)
+	if dir := filepath.Dir(destFile); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
 		}
 	}
+	if err := os.WriteFile(destFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write restored file: %w", err)
+	}
 
+	logrus.Infof("Backup %s restored to %s", backupName, destFile)
 	return nil
 }
 
-// RestoreBackup restores a backup file
-func (bm *BackupManager) RestoreBackup(backupFile, destFile string) error {
-	if filepath.Ext(backupFile) == ".gz" {
-		return bm.decompressFile(backupFile, destFile)
+// ListBackups returns the names of all backups
+func (bm *BackupManager) ListBackups() ([]string, error) {
+	backups, err := bm.backend.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	names := make([]string, len(backups))
+	for i, backup := range backups {
+		names[i] = backup.Name
+	}
+	return names, nil
+}
+
+// LocalBackend keeps backups in a directory
+type LocalBackend struct {
+	dir string
+}
+
+// NewLocalBackend creates a directory backend
+func NewLocalBackend(dir string) *LocalBackend {
+	return &LocalBackend{dir: dir}
+}
+
+func (lb *LocalBackend) Name() string {
+	return "local:" + lb.dir
+}
+
+func (lb *LocalBackend) Put(name string, data []byte) error {
+	// Ensure backup directory exists
+	if err := os.MkdirAll(lb.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
 	}
-	return bm.copyFile(backupFile, destFile)
+	return os.WriteFile(filepath.Join(lb.dir, name), data, 0600)
 }
 
-// ListBackups returns a list of all backup files
-func (bm *BackupManager) ListBackups() ([]string, error) {
-	files, err := os.ReadDir(bm.backupDir)
+func (lb *LocalBackend) Get(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(lb.dir, name))
+}
+
+func (lb *LocalBackend) List() ([]BackupObject, error) {
+	files, err := os.ReadDir(lb.dir)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return []string{}, nil
+			return []BackupObject{}, nil
 		}
 		return nil, fmt.Errorf("failed to read backup directory: %w", err)
 	}
 
-	backups := make([]string, 0)
+	backups := make([]BackupObject, 0, len(files))
 	for _, file := range files {
-		if !file.IsDir() {
-			backups = append(backups, filepath.Join(bm.backupDir, file.Name()))
+		if file.IsDir() {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
 		}
+		backups = append(backups, BackupObject{
+			Name:    file.Name(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
 	}
-
 	return backups, nil
 }
+
+func (lb *LocalBackend) Delete(name string) error {
+	return os.Remove(filepath.Join(lb.dir, name))
+}
@@ -0,0 +1,354 @@
+package persistence
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// FileStore keeps records as JSON files in a directory, for single-node
+// deployments. Players are one file rewritten on change; hands, actions and
+// settlements are append-only JSON lines, where a later line for the same
+// hand replaces an earlier one.
+type FileStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewFileStore opens a file store in dir
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "snapshots"), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (fs *FileStore) SavePlayer(player PlayerRecord) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	players, err := fs.loadPlayers()
+	if err != nil {
+		return err
+	}
+	players[player.PlayerID] = player
+
+	data, err := json.Marshal(players)
+	if err != nil {
+		return fmt.Errorf("failed to marshal players: %w", err)
+	}
+	return replaceFile(filepath.Join(fs.dir, "players.json"), data)
+}
+
+func (fs *FileStore) GetPlayer(playerID string) (PlayerRecord, bool, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	players, err := fs.loadPlayers()
+	if err != nil {
+		return PlayerRecord{}, false, err
+	}
+	player, ok := players[playerID]
+	return player, ok, nil
+}
+
+func (fs *FileStore) loadPlayers() (map[string]PlayerRecord, error) {
+	players := map[string]PlayerRecord{}
+	data, err := os.ReadFile(filepath.Join(fs.dir, "players.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return players, nil
+		}
+		return nil, fmt.Errorf("failed to read players: %w", err)
+	}
+	if err := json.Unmarshal(data, &players); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal players: %w", err)
+	}
+	return players, nil
+}
+
+func (fs *FileStore) SaveHand(hand HandRecord) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.appendLine("hands.jsonl", hand)
+}
+
+func (fs *FileStore) GetHand(tableID string, handID int) (HandRecord, bool, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	hands, err := fs.loadHands()
+	if err != nil {
+		return HandRecord{}, false, err
+	}
+	for _, hand := range hands {
+		if hand.TableID == tableID && hand.HandID == handID {
+			return hand, true, nil
+		}
+	}
+	return HandRecord{}, false, nil
+}
+
+func (fs *FileStore) HandsForPlayer(playerID string, limit int) ([]HandRecord, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	hands, err := fs.loadHands()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []HandRecord{}
+	for _, hand := range hands {
+		for _, p := range hand.Players {
+			if p == playerID {
+				result = append(result, hand)
+				break
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].StartedAt.After(result[j].StartedAt)
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// loadHands reads every hand, keeping the last line written for each
+func (fs *FileStore) loadHands() ([]HandRecord, error) {
+	type key struct {
+		table string
+		id    int
+	}
+	index := map[key]int{}
+	hands := []HandRecord{}
+
+	err := fs.readLines("hands.jsonl", func(line []byte) error {
+		var hand HandRecord
+		if err := json.Unmarshal(line, &hand); err != nil {
+			return err
+		}
+		k := key{hand.TableID, hand.HandID}
+		if i, ok := index[k]; ok {
+			hands[i] = hand
+			return nil
+		}
+		index[k] = len(hands)
+		hands = append(hands, hand)
+		return nil
+	})
+	return hands, err
+}
+
+func (fs *FileStore) AppendAction(action ActionRecord) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.appendLine("actions.jsonl", action)
+}
+
+func (fs *FileStore) HandActions(tableID string, handID int) ([]ActionRecord, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	actions := []ActionRecord{}
+	err := fs.readLines("actions.jsonl", func(line []byte) error {
+		var action ActionRecord
+		if err := json.Unmarshal(line, &action); err != nil {
+			return err
+		}
+		if action.TableID == tableID && action.HandID == handID {
+			actions = append(actions, action)
+		}
+		return nil
+	})
+	return actions, err
+}
+
+func (fs *FileStore) SaveSettlement(settlement SettlementRecord) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.appendLine("settlements.jsonl", settlement)
+}
+
+func (fs *FileStore) Settlements(tableID string, handID int) ([]SettlementRecord, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	settlements := []SettlementRecord{}
+	err := fs.readLines("settlements.jsonl", func(line []byte) error {
+		var settlement SettlementRecord
+		if err := json.Unmarshal(line, &settlement); err != nil {
+			return err
+		}
+		if settlement.TableID == tableID && settlement.HandID == handID {
+			settlements = append(settlements, settlement)
+		}
+		return nil
+	})
+	return settlements, err
+}
+
+func (fs *FileStore) AppendLedgerEntry(entry LedgerEntry) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.appendLine("ledger.jsonl", entry)
+}
+
+func (fs *FileStore) LedgerEntries(playerID string) ([]LedgerEntry, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	entries := []LedgerEntry{}
+	err := fs.readLines("ledger.jsonl", func(line []byte) error {
+		var entry LedgerEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return err
+		}
+		if playerID == "" || entry.PlayerID == playerID {
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+func (fs *FileStore) SaveCursor(name string, block uint64) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	cursors, err := fs.loadCursors()
+	if err != nil {
+		return err
+	}
+	cursors[name] = block
+
+	data, err := json.Marshal(cursors)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cursors: %w", err)
+	}
+	return replaceFile(filepath.Join(fs.dir, "cursors.json"), data)
+}
+
+func (fs *FileStore) Cursor(name string) (uint64, bool, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	cursors, err := fs.loadCursors()
+	if err != nil {
+		return 0, false, err
+	}
+	block, ok := cursors[name]
+	return block, ok, nil
+}
+
+func (fs *FileStore) loadCursors() (map[string]uint64, error) {
+	cursors := map[string]uint64{}
+	data, err := os.ReadFile(filepath.Join(fs.dir, "cursors.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cursors, nil
+		}
+		return nil, fmt.Errorf("failed to read cursors: %w", err)
+	}
+	if err := json.Unmarshal(data, &cursors); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cursors: %w", err)
+	}
+	return cursors, nil
+}
+
+func (fs *FileStore) SaveSnapshot(tableID string, snapshot *GameSnapshot) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	data, err := sealSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+	return replaceFile(fs.snapshotPath(tableID), data)
+}
+
+func (fs *FileStore) LatestSnapshot(tableID string) (*GameSnapshot, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	path := fs.snapshotPath(tableID)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return LoadSnapshot(path)
+}
+
+func (fs *FileStore) snapshotPath(tableID string) string {
+	return filepath.Join(fs.dir, "snapshots", url.PathEscape(tableID)+".json")
+}
+
+// Ping writes and removes a scratch file in the store directory
+func (fs *FileStore) Ping() error {
+	file, err := os.CreateTemp(fs.dir, ".ping-*")
+	if err != nil {
+		return fmt.Errorf("store directory is not writable: %w", err)
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+func (fs *FileStore) Close() error {
+	return nil
+}
+
+func (fs *FileStore) appendLine(name string, record interface{}) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s record: %w", name, err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(fs.dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to append to %s: %w", name, err)
+	}
+	return nil
+}
+
+func (fs *FileStore) readLines(name string, fn func([]byte) error) error {
+	file, err := os.Open(filepath.Join(fs.dir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if err := fn(scanner.Bytes()); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// replaceFile atomically replaces a file's contents
+func replaceFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package persistence
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LedgerLock is what a player has locked at one table
+type LedgerLock struct {
+	TableID string    `json:"table_id"`
+	Amount  int       `json:"amount"`
+	BuyIns  int       `json:"buy_ins"` // more than one after rebuys
+	At      time.Time `json:"at"`      // the most recent entry
+}
+
+// LedgerBalance is a player's standing in the local ledger, shaped like the
+// on-chain escrow: chips locked at each table and chips cashed out and free
+// to withdraw
+type LedgerBalance struct {
+	PlayerID    string       `json:"player_id"`
+	Locked      []LedgerLock `json:"locked"`
+	TotalLocked int          `json:"total_locked"`
+	Available   int          `json:"available"`
+}
+
+// ledgerMu serialises withdrawals, so two cannot both spend the same balance
+var ledgerMu sync.Mutex
+
+// PlayerLedger adds up a player's ledger entries
+func PlayerLedger(store Store, playerID string) (*LedgerBalance, error) {
+	entries, err := store.LedgerEntries(playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := &LedgerBalance{PlayerID: playerID, Locked: []LedgerLock{}}
+	tables := make(map[string]*LedgerLock)
+	for _, entry := range entries {
+		switch entry.Kind {
+		case LedgerChainLock, LedgerChainRelease:
+			continue // in wei, and answered by the chain
+		case LedgerWithdrawal:
+			balance.Available -= entry.Amount
+			continue
+		}
+
+		lock, ok := tables[entry.TableID]
+		if !ok {
+			lock = &LedgerLock{TableID: entry.TableID}
+			tables[entry.TableID] = lock
+		}
+		lock.At = entry.At
+		switch entry.Kind {
+		case LedgerBuyIn:
+			lock.Amount += entry.Amount
+			lock.BuyIns++
+		case LedgerHand, LedgerPenalty:
+			lock.Amount += entry.Amount
+		case LedgerCashOut:
+			lock.Amount -= entry.Amount
+			balance.Available += entry.Amount
+		}
+	}
+
+	for _, lock := range tables {
+		if lock.Amount == 0 {
+			continue // cashed out in full
+		}
+		balance.Locked = append(balance.Locked, *lock)
+		balance.TotalLocked += lock.Amount
+	}
+	sort.Slice(balance.Locked, func(i, j int) bool {
+		return balance.Locked[i].At.After(balance.Locked[j].At)
+	})
+	return balance, nil
+}
+
+// WithdrawFromLedger pays out part of a player's available balance
+func WithdrawFromLedger(store Store, playerID string, amount int, actor, note string) (*LedgerBalance, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("withdrawal amount must be positive")
+	}
+
+	ledgerMu.Lock()
+	defer ledgerMu.Unlock()
+
+	balance, err := PlayerLedger(store, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if amount > balance.Available {
+		return nil, fmt.Errorf("player %s has %d chips available, less than the %d requested", playerID, balance.Available, amount)
+	}
+
+	err = store.AppendLedgerEntry(LedgerEntry{
+		PlayerID: playerID,
+		Kind:     LedgerWithdrawal,
+		Amount:   amount,
+		Actor:    actor,
+		Note:     note,
+		At:       time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	balance.Available -= amount
+	return balance, nil
+}
@@ -64,6 +64,24 @@ func (rm *RecoveryManager) RecoverFromCrash() (*GameSnapshot, error) {
 	return snapshot, nil
 }
 
+// LoadLatest returns the snapshot to resume from at boot: the last one
+// written before a crash, or the one saved at a graceful shutdown. It
+// returns nil when there is nothing to restore.
+func (rm *RecoveryManager) LoadLatest() (*GameSnapshot, error) {
+	files, err := ListSnapshots(rm.snapshotDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	if rm.HasCrashed() {
+		return rm.RecoverFromCrash()
+	}
+	return GetLatestSnapshot(rm.snapshotDir)
+}
+
 // PerformGracefulShutdown performs a graceful shutdown
 func (rm *RecoveryManager) PerformGracefulShutdown(snapshot *GameSnapshot) error {
 	logrus.Info("Performing graceful shutdown...")
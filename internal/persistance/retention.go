@@ -0,0 +1,226 @@
+package persistence
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RetentionPolicy decides which snapshots and backups to keep: the newest
+// KeepLast, plus the newest in each of the last KeepHourly hours, KeepDaily
+// days and KeepWeekly weeks that have one. Everything else is deleted.
+type RetentionPolicy struct {
+	KeepLast   int `json:"keep_last"`
+	KeepHourly int `json:"keep_hourly"`
+	KeepDaily  int `json:"keep_daily"`
+	KeepWeekly int `json:"keep_weekly"`
+}
+
+// Enabled reports whether the policy keeps anything; an empty policy would
+// delete everything, so it is treated as "retention off"
+func (p RetentionPolicy) Enabled() bool {
+	return p.KeepLast > 0 || p.KeepHourly > 0 || p.KeepDaily > 0 || p.KeepWeekly > 0
+}
+
+// Expired returns the objects the policy does not keep
+func (p RetentionPolicy) Expired(objects []BackupObject) []BackupObject {
+	sorted := make([]BackupObject, len(objects))
+	copy(sorted, objects)
+	// Newest first, so the first object seen in a period is the one kept
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ModTime.After(sorted[j].ModTime)
+	})
+
+	keep := make(map[string]bool, len(sorted))
+	for i := 0; i < p.KeepLast && i < len(sorted); i++ {
+		keep[sorted[i].Name] = true
+	}
+	p.keepPerPeriod(sorted, p.KeepHourly, keep, func(t time.Time) string {
+		return t.Format("2006-01-02T15")
+	})
+	p.keepPerPeriod(sorted, p.KeepDaily, keep, func(t time.Time) string {
+		return t.Format("2006-01-02")
+	})
+	p.keepPerPeriod(sorted, p.KeepWeekly, keep, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	})
+
+	expired := []BackupObject{}
+	for _, object := range sorted {
+		if !keep[object.Name] {
+			expired = append(expired, object)
+		}
+	}
+	return expired
+}
+
+// keepPerPeriod keeps the newest object in each of the first n periods
+func (p RetentionPolicy) keepPerPeriod(sorted []BackupObject, n int, keep map[string]bool, period func(time.Time) string) {
+	seen := make(map[string]bool, n)
+	for _, object := range sorted {
+		if len(seen) >= n {
+			return
+		}
+		key := period(object.ModTime.UTC())
+		if !seen[key] {
+			seen[key] = true
+			keep[object.Name] = true
+		}
+	}
+}
+
+// RetentionTarget is a set of files retention applies to. Every
+// BackupBackend is one.
+type RetentionTarget interface {
+	Name() string
+	List() ([]BackupObject, error)
+	Delete(name string) error
+}
+
+// SnapshotDirTarget applies retention to the snapshots in a directory
+type SnapshotDirTarget struct {
+	dir string
+}
+
+// NewSnapshotDirTarget creates a retention target for a snapshot directory
+func NewSnapshotDirTarget(dir string) *SnapshotDirTarget {
+	return &SnapshotDirTarget{dir: dir}
+}
+
+func (st *SnapshotDirTarget) Name() string {
+	return "snapshots:" + st.dir
+}
+
+func (st *SnapshotDirTarget) List() ([]BackupObject, error) {
+	files, err := ListSnapshots(st.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]BackupObject, 0, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		objects = append(objects, BackupObject{
+			Name:    filepath.Base(file),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+	return objects, nil
+}
+
+func (st *SnapshotDirTarget) Delete(name string) error {
+	return os.Remove(filepath.Join(st.dir, name))
+}
+
+// RetentionScheduler applies a retention policy to its targets on a schedule
+type RetentionScheduler struct {
+	policy   RetentionPolicy
+	interval time.Duration
+
+	mu      sync.Mutex
+	targets []RetentionTarget
+	stop    chan struct{}
+}
+
+// NewRetentionScheduler creates a scheduler that runs every interval
+func NewRetentionScheduler(policy RetentionPolicy, interval time.Duration) *RetentionScheduler {
+	return &RetentionScheduler{
+		policy:   policy,
+		interval: interval,
+	}
+}
+
+// AddTarget adds a set of files to apply the policy to
+func (rs *RetentionScheduler) AddTarget(target RetentionTarget) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.targets = append(rs.targets, target)
+}
+
+// Start runs retention now and then every interval until Stop
+func (rs *RetentionScheduler) Start() error {
+	if !rs.policy.Enabled() {
+		return fmt.Errorf("retention policy keeps nothing")
+	}
+	if rs.interval <= 0 {
+		return fmt.Errorf("invalid retention interval %v", rs.interval)
+	}
+
+	rs.mu.Lock()
+	if rs.stop != nil {
+		rs.mu.Unlock()
+		return nil
+	}
+	rs.stop = make(chan struct{})
+	stop := rs.stop
+	rs.mu.Unlock()
+
+	go func() {
+		rs.RunOnce()
+
+		ticker := time.NewTicker(rs.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				rs.RunOnce()
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop ends scheduled retention runs
+func (rs *RetentionScheduler) Stop() {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.stop != nil {
+		close(rs.stop)
+		rs.stop = nil
+	}
+}
+
+// RunOnce applies the policy to every target and returns how many files
+// were deleted. A failing target is logged and does not stop the others.
+func (rs *RetentionScheduler) RunOnce() int {
+	rs.mu.Lock()
+	targets := make([]RetentionTarget, len(rs.targets))
+	copy(targets, rs.targets)
+	rs.mu.Unlock()
+
+	deleted := 0
+	for _, target := range targets {
+		objects, err := target.List()
+		if err != nil {
+			logrus.Warnf("Retention: failed to list %s: %v", target.Name(), err)
+			continue
+		}
+
+		for _, object := range rs.policy.Expired(objects) {
+			if err := target.Delete(object.Name); err != nil {
+				logrus.Warnf("Retention: failed to delete %s from %s: %v", object.Name, target.Name(), err)
+				continue
+			}
+			deleted++
+		}
+	}
+
+	if deleted > 0 {
+		logrus.Infof("Retention removed %d old snapshots and backups", deleted)
+	}
+	return deleted
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/RedPaladin7/peerpoker/internal/crypto"
 	"github.com/sirupsen/logrus"
 )
 
@@ -22,12 +23,58 @@ type GameSnapshot struct {
 	Players        []PlayerSnapshot       `json:"players"`
 	CommunityCards []byte                 `json:"community_cards,omitempty"`
 	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+
+	// Hand state needed to resume mid-hand after a restart
+	RotationMap     map[int]string  `json:"rotation_map,omitempty"`
+	NextRotationID  int             `json:"next_rotation_id"`
+	LastRaiserID    int             `json:"last_raiser_id"`
+	LastRaiseAmount int             `json:"last_raise_amount"`
+	FullRaiseTo     int             `json:"full_raise_to,omitempty"`
+	SidePots        []PotSnapshot   `json:"side_pots,omitempty"`
+	Deck            [][]byte        `json:"-"` // a hand secret, see below
+	HandCount       int             `json:"hand_count"`
+	HandUID         string          `json:"hand_uid,omitempty"`
+	HandActionSeq   int             `json:"hand_action_seq,omitempty"`
+	RakeCollected   int             `json:"rake_collected,omitempty"`
+	ButtonSeat      int             `json:"button_seat"`
+	SmallBlindSeat  int             `json:"small_blind_seat"`
+	BigBlindSeat    int             `json:"big_blind_seat"`
+	DeadButton      bool            `json:"dead_button"`
+	SeatDraw        json.RawMessage `json:"seat_draw,omitempty"`
+	ChainGameID     string          `json:"chain_game_id,omitempty"`
+	SettlementBatch json.RawMessage `json:"settlement_batch,omitempty"`
+	ChannelState    json.RawMessage `json:"channel_state,omitempty"`
+
+	// The last write-ahead log record the snapshot includes; recovery
+	// replays the records after it
+	WALSeq uint64 `json:"wal_seq,omitempty"`
+
+	// Hand secrets, only present in snapshots written for restore. They
+	// are never written in the clear: on disk they live in Secrets, sealed
+	// with the session key (see snapshot_secrets.go).
+	Hand         []byte                      `json:"-"`
+	DeckKeys     *crypto.CardKeys            `json:"-"`
+	RevealedKeys map[string]*crypto.CardKeys `json:"-"`
+
+	Secrets *SealedSecrets `json:"secrets,omitempty"`
+
+	// Set on a loaded snapshot whose secrets were sealed with a session
+	// key this node no longer has
+	SecretsRedacted bool `json:"-"`
+}
+
+// PotSnapshot is one side pot in a snapshot
+type PotSnapshot struct {
+	Amount   int      `json:"amount"`
+	Cap      int      `json:"cap"`
+	Eligible []string `json:"eligible"`
 }
 
 // PlayerSnapshot represents a player's state in a snapshot
 type PlayerSnapshot struct {
 	PlayerID         string `json:"player_id"`
 	RotationID       int    `json:"rotation_id"`
+	Seat             int    `json:"seat"`
 	Stack            int    `json:"stack"`
 	CurrentBet       int    `json:"current_bet"`
 	TotalBetThisHand int    `json:"total_bet_this_hand"`
@@ -35,6 +82,15 @@ type PlayerSnapshot struct {
 	IsFolded         bool   `json:"is_folded"`
 	IsAllIn          bool   `json:"is_all_in"`
 	IsReady          bool   `json:"is_ready"`
+	SittingOut       bool   `json:"sitting_out,omitempty"`
+	WaitForBB        bool   `json:"wait_for_bb,omitempty"`
+	MissedSmallBlind bool   `json:"missed_small_blind,omitempty"`
+	MissedBigBlind   bool   `json:"missed_big_blind,omitempty"`
+	ActedThisRound   bool   `json:"acted_this_round,omitempty"`
+	ActedAtRaiseTo   int    `json:"acted_at_raise_to,omitempty"`
+	BuyIn            int    `json:"buy_in,omitempty"`
+	Rebuys           int    `json:"rebuys,omitempty"`
+	RebuyCount       int    `json:"rebuy_count,omitempty"`
 }
 
 // SaveSnapshot saves a game snapshot to a file
@@ -47,14 +103,14 @@ func SaveSnapshot(snapshot *GameSnapshot, filename string) error {
 		}
 	}
 
-	// Marshal to JSON
-	data, err := json.MarshalIndent(snapshot, "", "  ")
+	// Marshal to JSON, encrypted and checksummed
+	data, err := sealSnapshot(snapshot)
 	if err != nil {
-		return fmt.Errorf("failed to marshal snapshot: %w", err)
+		return err
 	}
 
 	// Write to file
-	if err := os.WriteFile(filename, data, 0644); err != nil {
+	if err := os.WriteFile(filename, data, 0600); err != nil {
 		return fmt.Errorf("failed to write snapshot: %w", err)
 	}
 
@@ -69,13 +125,13 @@ func LoadSnapshot(filename string) (*GameSnapshot, error) {
 		return nil, fmt.Errorf("failed to read snapshot: %w", err)
 	}
 
-	var snapshot GameSnapshot
-	if err := json.Unmarshal(data, &snapshot); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal snapshot: %w", err)
+	snapshot, err := openSnapshot(data)
+	if err != nil {
+		return nil, fmt.Errorf("refusing snapshot %s: %w", filename, err)
 	}
 
 	logrus.Infof("Game snapshot loaded from %s", filename)
-	return &snapshot, nil
+	return snapshot, nil
 }
 
 // SaveSnapshotWithTimestamp saves a snapshot with a timestamp in the filename
@@ -0,0 +1,187 @@
+package persistence
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// SnapshotKeySize is the length of the server key that encrypts snapshots
+const SnapshotKeySize = 32
+
+// snapshotFormat identifies sealed snapshot files
+const snapshotFormat = "peerpoker-snapshot/1"
+
+var (
+	snapshotKeyMu sync.RWMutex
+	snapshotEnc   []byte // AES-256-GCM key
+	snapshotMAC   []byte // HMAC-SHA256 key
+	sessionKey    []byte // AES-256-GCM key for hand secrets
+)
+
+// sealedSnapshot is the on-disk form of a snapshot. Without a server key the
+// payload is plain JSON and the checksum is its SHA-256, which catches
+// corruption and casual edits. With a key the payload is AES-GCM encrypted
+// and the checksum is an HMAC only the key holder can produce.
+type sealedSnapshot struct {
+	Format    string `json:"format"`
+	Encrypted bool   `json:"encrypted"`
+	Nonce     []byte `json:"nonce,omitempty"`
+	Payload   []byte `json:"payload"`
+	Checksum  string `json:"checksum"`
+}
+
+// SetSnapshotKey encrypts and authenticates every snapshot written from now
+// on with key, and refuses to load snapshots not sealed with it. A nil key
+// goes back to plaintext snapshots with a SHA-256 checksum.
+func SetSnapshotKey(key []byte) error {
+	snapshotKeyMu.Lock()
+	defer snapshotKeyMu.Unlock()
+
+	if key == nil {
+		snapshotEnc, snapshotMAC, sessionKey = nil, nil, nil
+		return nil
+	}
+	if len(key) != SnapshotKeySize {
+		return fmt.Errorf("snapshot key must be %d bytes, got %d", SnapshotKeySize, len(key))
+	}
+
+	// Separate keys for encryption and the checksum, derived from the one
+	// the operator configures
+	snapshotEnc = deriveSnapshotKey(key, "encrypt")
+	snapshotMAC = deriveSnapshotKey(key, "checksum")
+	sessionKey = deriveSnapshotKey(key, "session")
+	return nil
+}
+
+// ParseSnapshotKey decodes a hex-encoded snapshot key
+func ParseSnapshotKey(hexKey string) ([]byte, error) {
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot key is not hex: %w", err)
+	}
+	if len(key) != SnapshotKeySize {
+		return nil, fmt.Errorf("snapshot key must be %d bytes (%d hex characters)", SnapshotKeySize, SnapshotKeySize*2)
+	}
+	return key, nil
+}
+
+func deriveSnapshotKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("peerpoker snapshot " + purpose))
+	return mac.Sum(nil)
+}
+
+// sealSnapshot marshals a snapshot into its sealed on-disk form
+func sealSnapshot(snapshot *GameSnapshot) ([]byte, error) {
+	secrets, err := sealHandSecrets(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	withSecrets := *snapshot
+	withSecrets.Secrets = secrets
+
+	data, err := json.Marshal(&withSecrets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	snapshotKeyMu.RLock()
+	encKey, macKey := snapshotEnc, snapshotMAC
+	snapshotKeyMu.RUnlock()
+
+	sealed := sealedSnapshot{Format: snapshotFormat, Payload: data}
+	if encKey != nil {
+		gcm, err := newSnapshotGCM(encKey)
+		if err != nil {
+			return nil, err
+		}
+		sealed.Nonce = make([]byte, gcm.NonceSize())
+		if _, err := rand.Read(sealed.Nonce); err != nil {
+			return nil, fmt.Errorf("failed to generate nonce: %w", err)
+		}
+		sealed.Encrypted = true
+		sealed.Payload = gcm.Seal(nil, sealed.Nonce, data, []byte(snapshotFormat))
+	}
+	sealed.Checksum = snapshotChecksum(macKey, sealed.Nonce, sealed.Payload)
+
+	return json.MarshalIndent(sealed, "", "  ")
+}
+
+// openSnapshot verifies and decodes a sealed snapshot, refusing any whose
+// checksum does not match
+func openSnapshot(data []byte) (*GameSnapshot, error) {
+	var sealed sealedSnapshot
+	if err := json.Unmarshal(data, &sealed); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal snapshot: %w", err)
+	}
+	if sealed.Format != snapshotFormat {
+		return nil, fmt.Errorf("snapshot has no integrity checksum (unsupported format %q)", sealed.Format)
+	}
+
+	snapshotKeyMu.RLock()
+	encKey, macKey := snapshotEnc, snapshotMAC
+	snapshotKeyMu.RUnlock()
+
+	if sealed.Encrypted && encKey == nil {
+		return nil, fmt.Errorf("snapshot is encrypted but no snapshot key is configured")
+	}
+	if !sealed.Encrypted && encKey != nil {
+		return nil, fmt.Errorf("snapshot is not encrypted with the configured key")
+	}
+
+	expected := snapshotChecksum(macKey, sealed.Nonce, sealed.Payload)
+	if !hmac.Equal([]byte(expected), []byte(sealed.Checksum)) {
+		return nil, fmt.Errorf("snapshot checksum mismatch: file has been modified or is corrupt")
+	}
+
+	payload := sealed.Payload
+	if sealed.Encrypted {
+		gcm, err := newSnapshotGCM(encKey)
+		if err != nil {
+			return nil, err
+		}
+		if len(sealed.Nonce) != gcm.NonceSize() {
+			return nil, fmt.Errorf("snapshot nonce has the wrong size")
+		}
+		if payload, err = gcm.Open(nil, sealed.Nonce, sealed.Payload, []byte(snapshotFormat)); err != nil {
+			return nil, fmt.Errorf("failed to decrypt snapshot: %w", err)
+		}
+	}
+
+	var snapshot GameSnapshot
+	if err := json.Unmarshal(payload, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal snapshot: %w", err)
+	}
+	if err := openHandSecrets(&snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// snapshotChecksum is an HMAC of the nonce and payload under macKey, or a
+// plain SHA-256 when there is no key
+func snapshotChecksum(macKey, nonce, payload []byte) string {
+	if macKey == nil {
+		sum := sha256.Sum256(payload)
+		return "sha256:" + hex.EncodeToString(sum[:])
+	}
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(nonce)
+	mac.Write(payload)
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newSnapshotGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
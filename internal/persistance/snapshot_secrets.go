@@ -0,0 +1,175 @@
+package persistence
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/RedPaladin7/peerpoker/internal/crypto"
+	"github.com/sirupsen/logrus"
+)
+
+// Hand secrets are the snapshot fields that would expose live cards: the
+// deck, this node's hole cards and the keys that open them. Whatever the
+// snapshot key, they only reach disk sealed with the session key:
+//
+//   - snapshots written for restore seal them into Secrets
+//   - any other snapshot, an incident or admin one, leaves them out
+//   - a snapshot whose secrets cannot be opened, because another run of the
+//     node sealed them, loads with them redacted and the hand in progress
+//     cannot be resumed
+//
+// The write-ahead log's deal records carry the same secrets, and are sealed
+// with SealSecrets the same way.
+//
+// The session key is random for each run of the node, or derived from the
+// snapshot key when one is configured so a restarted node can still finish
+// its hand.
+
+// secretsFormat identifies sealed hand secrets, and is bound to them
+const secretsFormat = "peerpoker-hand-secrets/1"
+
+// SealedSecrets are a snapshot's hand secrets encrypted with the session key
+type SealedSecrets struct {
+	Format string `json:"format"`
+	KeyID  string `json:"key_id"` // which session key sealed them
+	Nonce  []byte `json:"nonce"`
+	Data   []byte `json:"data"`
+}
+
+type handSecrets struct {
+	Deck         [][]byte                    `json:"deck,omitempty"`
+	Hand         []byte                      `json:"hand,omitempty"`
+	DeckKeys     *crypto.CardKeys            `json:"deck_keys,omitempty"`
+	RevealedKeys map[string]*crypto.CardKeys `json:"revealed_keys,omitempty"`
+}
+
+func (s *handSecrets) empty() bool {
+	return len(s.Deck) == 0 && len(s.Hand) == 0 && s.DeckKeys == nil && len(s.RevealedKeys) == 0
+}
+
+// currentSessionKey returns the session key, making one for this run of the
+// node if there is none yet
+func currentSessionKey() ([]byte, error) {
+	snapshotKeyMu.Lock()
+	defer snapshotKeyMu.Unlock()
+
+	if sessionKey == nil {
+		key := make([]byte, SnapshotKeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate session key: %w", err)
+		}
+		sessionKey = key
+	}
+	return sessionKey, nil
+}
+
+func sessionKeyID(key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(secretsFormat))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// sealHandSecrets encrypts a snapshot's hand secrets, or returns nil when
+// it has none
+func sealHandSecrets(snapshot *GameSnapshot) (*SealedSecrets, error) {
+	secrets := handSecrets{
+		Deck:         snapshot.Deck,
+		Hand:         snapshot.Hand,
+		DeckKeys:     snapshot.DeckKeys,
+		RevealedKeys: snapshot.RevealedKeys,
+	}
+	if secrets.empty() {
+		return nil, nil
+	}
+	return SealSecrets(secrets)
+}
+
+// SealSecrets encrypts v, marshalled to JSON, with the session key
+func SealSecrets(v interface{}) (*SealedSecrets, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal hand secrets: %w", err)
+	}
+	key, err := currentSessionKey()
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newSnapshotGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := &SealedSecrets{
+		Format: secretsFormat,
+		KeyID:  sessionKeyID(key),
+		Nonce:  make([]byte, gcm.NonceSize()),
+	}
+	if _, err := rand.Read(sealed.Nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed.Data = gcm.Seal(nil, sealed.Nonce, data, []byte(secretsFormat))
+	return sealed, nil
+}
+
+// openHandSecrets decrypts a loaded snapshot's hand secrets into its fields.
+// Secrets from another session are redacted; secrets that fail to decrypt
+// under this session's key have been tampered with.
+func openHandSecrets(snapshot *GameSnapshot) error {
+	sealed := snapshot.Secrets
+	snapshot.Secrets = nil
+	if sealed == nil {
+		return nil
+	}
+
+	var secrets handSecrets
+	opened, err := OpenSecrets(sealed, &secrets)
+	if err != nil {
+		return err
+	}
+	if !opened {
+		logrus.Warn("Snapshot hand secrets were sealed by another session, leaving them out")
+		snapshot.SecretsRedacted = true
+		return nil
+	}
+	snapshot.Deck = secrets.Deck
+	snapshot.Hand = secrets.Hand
+	snapshot.DeckKeys = secrets.DeckKeys
+	snapshot.RevealedKeys = secrets.RevealedKeys
+	return nil
+}
+
+// OpenSecrets decrypts secrets sealed by SealSecrets into v. It reports
+// false, leaving v alone, for secrets another session sealed.
+func OpenSecrets(sealed *SealedSecrets, v interface{}) (bool, error) {
+	if sealed.Format != secretsFormat {
+		return false, fmt.Errorf("unsupported hand secrets format %q", sealed.Format)
+	}
+
+	key, err := currentSessionKey()
+	if err != nil {
+		return false, err
+	}
+	if sealed.KeyID != sessionKeyID(key) {
+		return false, nil
+	}
+
+	gcm, err := newSnapshotGCM(key)
+	if err != nil {
+		return false, err
+	}
+	if len(sealed.Nonce) != gcm.NonceSize() {
+		return false, fmt.Errorf("hand secrets nonce has the wrong size")
+	}
+	data, err := gcm.Open(nil, sealed.Nonce, sealed.Data, []byte(secretsFormat))
+	if err != nil {
+		return false, fmt.Errorf("failed to decrypt hand secrets: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to unmarshal hand secrets: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,558 @@
+package persistence
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sqlDrivers maps store drivers to database/sql driver names. Drivers are
+// compiled in with build tags (see cmd/server).
+var sqlDrivers = map[string]string{
+	StoreDriverSQLite:   "sqlite",
+	StoreDriverPostgres: "postgres",
+}
+
+// sqlSchema works on both SQLite and Postgres. Times are unix milliseconds.
+var sqlSchema = []string{
+	`CREATE TABLE IF NOT EXISTS players (
+		player_id    TEXT PRIMARY KEY,
+		stack        BIGINT NOT NULL,
+		hands_played BIGINT NOT NULL,
+		last_seen    BIGINT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS hands (
+		table_id    TEXT NOT NULL,
+		hand_id     BIGINT NOT NULL,
+		hand_uid    TEXT NOT NULL DEFAULT '',
+		started_at  BIGINT NOT NULL,
+		ended_at    BIGINT NOT NULL,
+		dealer      TEXT NOT NULL,
+		pot         BIGINT NOT NULL,
+		voided      BOOLEAN NOT NULL,
+		void_reason TEXT NOT NULL,
+		data        TEXT NOT NULL,
+		PRIMARY KEY (table_id, hand_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS hand_players (
+		table_id  TEXT NOT NULL,
+		hand_id   BIGINT NOT NULL,
+		player_id TEXT NOT NULL,
+		winnings  BIGINT NOT NULL,
+		PRIMARY KEY (table_id, hand_id, player_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS hand_players_by_player ON hand_players (player_id)`,
+	`CREATE TABLE IF NOT EXISTS actions (
+		table_id   TEXT NOT NULL,
+		hand_id    BIGINT NOT NULL,
+		hand_uid   TEXT NOT NULL DEFAULT '',
+		seq        BIGINT NOT NULL,
+		player_id  TEXT NOT NULL,
+		action     TEXT NOT NULL,
+		amount     BIGINT NOT NULL,
+		street     TEXT NOT NULL,
+		created_at BIGINT NOT NULL,
+		PRIMARY KEY (table_id, hand_id, seq)
+	)`,
+	`CREATE TABLE IF NOT EXISTS settlements (
+		table_id   TEXT NOT NULL,
+		hand_id    BIGINT NOT NULL,
+		hand_uid   TEXT NOT NULL DEFAULT '',
+		kind       TEXT NOT NULL,
+		game_id    TEXT NOT NULL,
+		winners    TEXT NOT NULL,
+		amounts    TEXT NOT NULL,
+		tx_hash    TEXT NOT NULL,
+		error      TEXT NOT NULL,
+		created_at BIGINT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS settlements_by_hand ON settlements (table_id, hand_id)`,
+	`CREATE TABLE IF NOT EXISTS ledger (
+		table_id   TEXT NOT NULL,
+		player_id  TEXT NOT NULL,
+		kind       TEXT NOT NULL,
+		amount     BIGINT NOT NULL,
+		hand_id    BIGINT NOT NULL,
+		hand_uid   TEXT NOT NULL,
+		actor      TEXT NOT NULL,
+		note       TEXT NOT NULL,
+		created_at BIGINT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS ledger_by_player ON ledger (player_id, created_at)`,
+	`CREATE TABLE IF NOT EXISTS cursors (
+		name  TEXT PRIMARY KEY,
+		block BIGINT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS snapshots (
+		table_id TEXT PRIMARY KEY,
+		taken_at BIGINT NOT NULL,
+		data     TEXT NOT NULL
+	)`,
+}
+
+// sqlAddedColumns are columns added after the first release; databases
+// created before them are altered on open
+var sqlAddedColumns = []struct {
+	table, column, definition string
+}{
+	{"hands", "hand_uid", "TEXT NOT NULL DEFAULT ''"},
+	{"actions", "hand_uid", "TEXT NOT NULL DEFAULT ''"},
+	{"settlements", "hand_uid", "TEXT NOT NULL DEFAULT ''"},
+	{"ledger", "game_id", "TEXT NOT NULL DEFAULT ''"},
+	{"ledger", "tx_hash", "TEXT NOT NULL DEFAULT ''"},
+	{"ledger", "block", "BIGINT NOT NULL DEFAULT 0"},
+	{"ledger", "wei", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// SQLStore keeps records in SQLite or Postgres, so several nodes can share
+// one database
+type SQLStore struct {
+	db       *sql.DB
+	postgres bool
+}
+
+// OpenSQLStore connects to the database and creates the schema if needed
+func OpenSQLStore(driver, dsn string) (*SQLStore, error) {
+	driverName, ok := sqlDrivers[driver]
+	if !ok {
+		return nil, fmt.Errorf("unknown SQL store driver %q", driver)
+	}
+	if !hasSQLDriver(driverName) {
+		return nil, fmt.Errorf("the %s driver is not compiled in; build with -tags %s", driver, driver)
+	}
+
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s store: %w", driver, err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to %s store: %w", driver, err)
+	}
+
+	store := &SQLStore{db: db, postgres: driver == StoreDriverPostgres}
+	for _, stmt := range sqlSchema {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to create schema: %w", err)
+		}
+	}
+	if err := store.addMissingColumns(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// addMissingColumns brings a database created by an older version up to
+// the current schema. Probing with a query works on SQLite and Postgres alike.
+func (s *SQLStore) addMissingColumns() error {
+	for _, c := range sqlAddedColumns {
+		rows, err := s.db.Query(fmt.Sprintf(`SELECT %s FROM %s LIMIT 0`, c.column, c.table))
+		if err == nil {
+			rows.Close()
+			continue
+		}
+		if _, err := s.db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, c.table, c.column, c.definition)); err != nil {
+			return fmt.Errorf("failed to add %s.%s: %w", c.table, c.column, err)
+		}
+	}
+	return nil
+}
+
+func hasSQLDriver(name string) bool {
+	for _, driver := range sql.Drivers() {
+		if driver == name {
+			return true
+		}
+	}
+	return false
+}
+
+// rebind turns ? placeholders into $1, $2... for Postgres
+func (s *SQLStore) rebind(query string) string {
+	if !s.postgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (s *SQLStore) exec(query string, args ...interface{}) error {
+	_, err := s.db.Exec(s.rebind(query), args...)
+	return err
+}
+
+func (s *SQLStore) SavePlayer(player PlayerRecord) error {
+	err := s.exec(`INSERT INTO players (player_id, stack, hands_played, last_seen) VALUES (?, ?, ?, ?)
+		ON CONFLICT (player_id) DO UPDATE SET stack = excluded.stack,
+			hands_played = excluded.hands_played, last_seen = excluded.last_seen`,
+		player.PlayerID, player.Stack, player.HandsPlayed, toMillis(player.LastSeen))
+	if err != nil {
+		return fmt.Errorf("failed to save player: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) GetPlayer(playerID string) (PlayerRecord, bool, error) {
+	player := PlayerRecord{PlayerID: playerID}
+	var lastSeen int64
+	err := s.db.QueryRow(s.rebind(`SELECT stack, hands_played, last_seen FROM players WHERE player_id = ?`), playerID).
+		Scan(&player.Stack, &player.HandsPlayed, &lastSeen)
+	if err == sql.ErrNoRows {
+		return PlayerRecord{}, false, nil
+	}
+	if err != nil {
+		return PlayerRecord{}, false, fmt.Errorf("failed to load player: %w", err)
+	}
+	player.LastSeen = fromMillis(lastSeen)
+	return player, true, nil
+}
+
+// SaveHand writes the hand and who was dealt in, replacing any earlier
+// record of it (a hand voided after it was saved, say)
+func (s *SQLStore) SaveHand(hand HandRecord) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to save hand: %w", err)
+	}
+	defer tx.Rollback()
+
+	data := string(hand.Data)
+	if data == "" {
+		data = "{}"
+	}
+
+	_, err = tx.Exec(s.rebind(`INSERT INTO hands (table_id, hand_id, hand_uid, started_at, ended_at, dealer, pot, voided, void_reason, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (table_id, hand_id) DO UPDATE SET hand_uid = excluded.hand_uid, started_at = excluded.started_at,
+			ended_at = excluded.ended_at, dealer = excluded.dealer, pot = excluded.pot,
+			voided = excluded.voided, void_reason = excluded.void_reason, data = excluded.data`),
+		hand.TableID, hand.HandID, hand.HandUID, toMillis(hand.StartedAt), toMillis(hand.EndedAt),
+		hand.Dealer, hand.Pot, hand.Voided, hand.VoidReason, data)
+	if err != nil {
+		return fmt.Errorf("failed to save hand: %w", err)
+	}
+
+	_, err = tx.Exec(s.rebind(`DELETE FROM hand_players WHERE table_id = ? AND hand_id = ?`), hand.TableID, hand.HandID)
+	if err != nil {
+		return fmt.Errorf("failed to save hand players: %w", err)
+	}
+	for _, player := range hand.Players {
+		_, err = tx.Exec(s.rebind(`INSERT INTO hand_players (table_id, hand_id, player_id, winnings) VALUES (?, ?, ?, ?)`),
+			hand.TableID, hand.HandID, player, hand.Winnings[player])
+		if err != nil {
+			return fmt.Errorf("failed to save hand players: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save hand: %w", err)
+	}
+	return nil
+}
+
+const handColumns = `h.table_id, h.hand_id, h.hand_uid, h.started_at, h.ended_at, h.dealer, h.pot, h.voided, h.void_reason, h.data`
+
+func (s *SQLStore) GetHand(tableID string, handID int) (HandRecord, bool, error) {
+	hands, err := s.queryHands(`SELECT `+handColumns+` FROM hands h WHERE h.table_id = ? AND h.hand_id = ?`, tableID, handID)
+	if err != nil {
+		return HandRecord{}, false, err
+	}
+	if len(hands) == 0 {
+		return HandRecord{}, false, nil
+	}
+	return hands[0], true, nil
+}
+
+func (s *SQLStore) HandsForPlayer(playerID string, limit int) ([]HandRecord, error) {
+	query := `SELECT ` + handColumns + ` FROM hands h
+		JOIN hand_players p ON p.table_id = h.table_id AND p.hand_id = h.hand_id
+		WHERE p.player_id = ? ORDER BY h.started_at DESC`
+	args := []interface{}{playerID}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	return s.queryHands(query, args...)
+}
+
+// queryHands loads hands and fills in their players and winnings
+func (s *SQLStore) queryHands(query string, args ...interface{}) ([]HandRecord, error) {
+	rows, err := s.db.Query(s.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query hands: %w", err)
+	}
+	defer rows.Close()
+
+	hands := []HandRecord{}
+	for rows.Next() {
+		var hand HandRecord
+		var startedAt, endedAt int64
+		var data string
+		if err := rows.Scan(&hand.TableID, &hand.HandID, &hand.HandUID, &startedAt, &endedAt, &hand.Dealer,
+			&hand.Pot, &hand.Voided, &hand.VoidReason, &data); err != nil {
+			return nil, fmt.Errorf("failed to read hand: %w", err)
+		}
+		hand.StartedAt = fromMillis(startedAt)
+		hand.EndedAt = fromMillis(endedAt)
+		hand.Data = json.RawMessage(data)
+		hands = append(hands, hand)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query hands: %w", err)
+	}
+	rows.Close()
+
+	for i := range hands {
+		if err := s.loadHandPlayers(&hands[i]); err != nil {
+			return nil, err
+		}
+	}
+	return hands, nil
+}
+
+func (s *SQLStore) loadHandPlayers(hand *HandRecord) error {
+	rows, err := s.db.Query(s.rebind(`SELECT player_id, winnings FROM hand_players
+		WHERE table_id = ? AND hand_id = ? ORDER BY player_id`), hand.TableID, hand.HandID)
+	if err != nil {
+		return fmt.Errorf("failed to query hand players: %w", err)
+	}
+	defer rows.Close()
+
+	hand.Players = []string{}
+	hand.Winnings = map[string]int{}
+	for rows.Next() {
+		var player string
+		var winnings int
+		if err := rows.Scan(&player, &winnings); err != nil {
+			return fmt.Errorf("failed to read hand player: %w", err)
+		}
+		hand.Players = append(hand.Players, player)
+		if winnings != 0 {
+			hand.Winnings[player] = winnings
+		}
+	}
+	return rows.Err()
+}
+
+func (s *SQLStore) AppendAction(action ActionRecord) error {
+	err := s.exec(`INSERT INTO actions (table_id, hand_id, hand_uid, seq, player_id, action, amount, street, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		action.TableID, action.HandID, action.HandUID, action.Seq, action.PlayerID, action.Action, action.Amount,
+		action.Street, toMillis(action.At))
+	if err != nil {
+		return fmt.Errorf("failed to save action: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) HandActions(tableID string, handID int) ([]ActionRecord, error) {
+	rows, err := s.db.Query(s.rebind(`SELECT hand_uid, seq, player_id, action, amount, street, created_at FROM actions
+		WHERE table_id = ? AND hand_id = ? ORDER BY seq`), tableID, handID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query actions: %w", err)
+	}
+	defer rows.Close()
+
+	actions := []ActionRecord{}
+	for rows.Next() {
+		action := ActionRecord{TableID: tableID, HandID: handID}
+		var at int64
+		if err := rows.Scan(&action.HandUID, &action.Seq, &action.PlayerID, &action.Action, &action.Amount, &action.Street, &at); err != nil {
+			return nil, fmt.Errorf("failed to read action: %w", err)
+		}
+		action.At = fromMillis(at)
+		actions = append(actions, action)
+	}
+	return actions, rows.Err()
+}
+
+func (s *SQLStore) SaveSettlement(settlement SettlementRecord) error {
+	winners, err := json.Marshal(settlement.Winners)
+	if err != nil {
+		return fmt.Errorf("failed to marshal winners: %w", err)
+	}
+	amounts, err := json.Marshal(settlement.Amounts)
+	if err != nil {
+		return fmt.Errorf("failed to marshal amounts: %w", err)
+	}
+
+	err = s.exec(`INSERT INTO settlements (table_id, hand_id, hand_uid, kind, game_id, winners, amounts, tx_hash, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		settlement.TableID, settlement.HandID, settlement.HandUID, settlement.Kind, settlement.GameID, string(winners),
+		string(amounts), settlement.TxHash, settlement.Error, toMillis(settlement.At))
+	if err != nil {
+		return fmt.Errorf("failed to save settlement: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) Settlements(tableID string, handID int) ([]SettlementRecord, error) {
+	rows, err := s.db.Query(s.rebind(`SELECT hand_uid, kind, game_id, winners, amounts, tx_hash, error, created_at
+		FROM settlements WHERE table_id = ? AND hand_id = ? ORDER BY created_at`), tableID, handID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query settlements: %w", err)
+	}
+	defer rows.Close()
+
+	settlements := []SettlementRecord{}
+	for rows.Next() {
+		settlement := SettlementRecord{TableID: tableID, HandID: handID}
+		var winners, amounts string
+		var at int64
+		if err := rows.Scan(&settlement.HandUID, &settlement.Kind, &settlement.GameID, &winners, &amounts,
+			&settlement.TxHash, &settlement.Error, &at); err != nil {
+			return nil, fmt.Errorf("failed to read settlement: %w", err)
+		}
+		if err := json.Unmarshal([]byte(winners), &settlement.Winners); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal winners: %w", err)
+		}
+		if err := json.Unmarshal([]byte(amounts), &settlement.Amounts); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal amounts: %w", err)
+		}
+		settlement.At = fromMillis(at)
+		settlements = append(settlements, settlement)
+	}
+	return settlements, rows.Err()
+}
+
+func (s *SQLStore) AppendLedgerEntry(entry LedgerEntry) error {
+	err := s.exec(`INSERT INTO ledger (table_id, player_id, kind, amount, hand_id, hand_uid, actor, note,
+		game_id, tx_hash, block, wei, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.TableID, entry.PlayerID, entry.Kind, entry.Amount, entry.HandID, entry.HandUID,
+		entry.Actor, entry.Note, entry.GameID, entry.TxHash, int64(entry.Block), entry.Wei, toMillis(entry.At))
+	if err != nil {
+		return fmt.Errorf("failed to append ledger entry: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) LedgerEntries(playerID string) ([]LedgerEntry, error) {
+	query := `SELECT table_id, player_id, kind, amount, hand_id, hand_uid, actor, note, game_id, tx_hash, block, wei, created_at
+		FROM ledger`
+	var args []interface{}
+	if playerID != "" {
+		query += ` WHERE player_id = ?`
+		args = append(args, playerID)
+	}
+	rows, err := s.db.Query(s.rebind(query+` ORDER BY created_at`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ledger: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []LedgerEntry{}
+	for rows.Next() {
+		var entry LedgerEntry
+		var block, at int64
+		if err := rows.Scan(&entry.TableID, &entry.PlayerID, &entry.Kind, &entry.Amount, &entry.HandID, &entry.HandUID,
+			&entry.Actor, &entry.Note, &entry.GameID, &entry.TxHash, &block, &entry.Wei, &at); err != nil {
+			return nil, fmt.Errorf("failed to read ledger entry: %w", err)
+		}
+		entry.Block = uint64(block)
+		entry.At = fromMillis(at)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (s *SQLStore) SaveCursor(name string, block uint64) error {
+	err := s.exec(`INSERT INTO cursors (name, block) VALUES (?, ?)
+		ON CONFLICT (name) DO UPDATE SET block = excluded.block`, name, int64(block))
+	if err != nil {
+		return fmt.Errorf("failed to save cursor: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) Cursor(name string) (uint64, bool, error) {
+	var block int64
+	err := s.db.QueryRow(s.rebind(`SELECT block FROM cursors WHERE name = ?`), name).Scan(&block)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read cursor: %w", err)
+	}
+	return uint64(block), true, nil
+}
+
+func (s *SQLStore) SaveSnapshot(tableID string, snapshot *GameSnapshot) error {
+	data, err := sealSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+
+	err = s.exec(`INSERT INTO snapshots (table_id, taken_at, data) VALUES (?, ?, ?)
+		ON CONFLICT (table_id) DO UPDATE SET taken_at = excluded.taken_at, data = excluded.data`,
+		tableID, toMillis(snapshot.Timestamp), string(data))
+	if err != nil {
+		return fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) LatestSnapshot(tableID string) (*GameSnapshot, error) {
+	var data string
+	err := s.db.QueryRow(s.rebind(`SELECT data FROM snapshots WHERE table_id = ?`), tableID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load snapshot: %w", err)
+	}
+
+	snapshot, err := openSnapshot([]byte(data))
+	if err != nil {
+		return nil, fmt.Errorf("refusing stored snapshot for %s: %w", tableID, err)
+	}
+	return snapshot, nil
+}
+
+// Ping writes a cursor inside a transaction and rolls it back
+func (s *SQLStore) Ping() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to reach database: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(s.rebind(`INSERT INTO cursors (name, block) VALUES (?, ?)
+		ON CONFLICT (name) DO UPDATE SET block = excluded.block`), "ping", 0)
+	if err != nil {
+		return fmt.Errorf("database is not writable: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+func toMillis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+func fromMillis(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
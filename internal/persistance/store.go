@@ -0,0 +1,152 @@
+package persistence
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Store drivers selectable in config
+const (
+	StoreDriverFile     = "file"
+	StoreDriverSQLite   = "sqlite"
+	StoreDriverPostgres = "postgres"
+)
+
+// PlayerRecord is a player's standing as of their last hand
+type PlayerRecord struct {
+	PlayerID    string    `json:"player_id"`
+	Stack       int       `json:"stack"`
+	HandsPlayed int       `json:"hands_played"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// HandRecord is a finished (or voided) hand. Data holds the table's full
+// hand history entry as JSON.
+type HandRecord struct {
+	TableID    string          `json:"table_id"`
+	HandID     int             `json:"hand_id"`
+	HandUID    string          `json:"hand_uid,omitempty"`
+	StartedAt  time.Time       `json:"started_at"`
+	EndedAt    time.Time       `json:"ended_at"`
+	Dealer     string          `json:"dealer"`
+	Pot        int             `json:"pot"`
+	Players    []string        `json:"players"`
+	Winnings   map[string]int  `json:"winnings,omitempty"`
+	Voided     bool            `json:"voided,omitempty"`
+	VoidReason string          `json:"void_reason,omitempty"`
+	Data       json.RawMessage `json:"data,omitempty"`
+}
+
+// ActionRecord is one betting action within a hand
+type ActionRecord struct {
+	TableID  string    `json:"table_id"`
+	HandID   int       `json:"hand_id"`
+	HandUID  string    `json:"hand_uid,omitempty"`
+	Seq      int       `json:"seq"`
+	PlayerID string    `json:"player_id"`
+	Action   string    `json:"action"`
+	Amount   int       `json:"amount"`
+	Street   string    `json:"street"`
+	At       time.Time `json:"at"`
+}
+
+// SettlementRecord is an escrow payout submitted for a hand
+type SettlementRecord struct {
+	TableID string    `json:"table_id"`
+	HandID  int       `json:"hand_id"`
+	HandUID string    `json:"hand_uid,omitempty"`
+	Kind    string    `json:"kind"`
+	GameID  string    `json:"game_id"`
+	Winners []string  `json:"winners"`
+	Amounts []int     `json:"amounts"`
+	TxHash  string    `json:"tx_hash,omitempty"`
+	Error   string    `json:"error,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// Ledger entry kinds. A buy-in locks chips at a table, a hand moves them by
+// its net result, a penalty moves an abandoned session's chips to the
+// players left, a cash-out frees what is left for withdrawal and a
+// withdrawal pays it out. Chain entries mirror the escrow contract's
+// FundsLocked and GameEnded events, in wei.
+const (
+	LedgerBuyIn        = "buy_in"
+	LedgerHand         = "hand"
+	LedgerPenalty      = "penalty"
+	LedgerCashOut      = "cash_out"
+	LedgerWithdrawal   = "withdrawal"
+	LedgerChainLock    = "chain_lock"
+	LedgerChainRelease = "chain_release"
+)
+
+// LedgerEntry is one movement of a player's chips in the local escrow
+// ledger, kept when the blockchain is not enabled, or an escrow event seen
+// on-chain when it is
+type LedgerEntry struct {
+	TableID  string    `json:"table_id,omitempty"`
+	PlayerID string    `json:"player_id"`
+	Kind     string    `json:"kind"`
+	Amount   int       `json:"amount"` // negative for a lost hand
+	HandID   int       `json:"hand_id,omitempty"`
+	HandUID  string    `json:"hand_uid,omitempty"`
+	Actor    string    `json:"actor,omitempty"`
+	Note     string    `json:"note,omitempty"`
+	GameID   string    `json:"game_id,omitempty"`
+	TxHash   string    `json:"tx_hash,omitempty"`
+	Block    uint64    `json:"block,omitempty"`
+	Wei      string    `json:"wei,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// Store keeps players, hands, actions, settlements and table snapshots.
+// The file store suits a single node; the SQL stores let several nodes
+// share one database and answer queries across tables.
+type Store interface {
+	SavePlayer(player PlayerRecord) error
+	GetPlayer(playerID string) (PlayerRecord, bool, error)
+
+	SaveHand(hand HandRecord) error
+	GetHand(tableID string, handID int) (HandRecord, bool, error)
+	// HandsForPlayer returns the hands a player was dealt into, newest first
+	HandsForPlayer(playerID string, limit int) ([]HandRecord, error)
+
+	AppendAction(action ActionRecord) error
+	HandActions(tableID string, handID int) ([]ActionRecord, error)
+
+	SaveSettlement(settlement SettlementRecord) error
+	Settlements(tableID string, handID int) ([]SettlementRecord, error)
+
+	AppendLedgerEntry(entry LedgerEntry) error
+	// LedgerEntries returns a player's ledger entries, or every entry when
+	// playerID is empty, oldest first
+	LedgerEntries(playerID string) ([]LedgerEntry, error)
+
+	// SaveCursor records how far a reader of the chain has got, by name
+	SaveCursor(name string, block uint64) error
+	Cursor(name string) (block uint64, ok bool, err error)
+
+	// SaveSnapshot replaces the table's stored snapshot
+	SaveSnapshot(tableID string, snapshot *GameSnapshot) error
+	LatestSnapshot(tableID string) (*GameSnapshot, error)
+
+	// Ping checks that the store can still be written to, without
+	// changing anything
+	Ping() error
+
+	Close() error
+}
+
+// NewStore opens the store for a driver. The file driver takes a directory;
+// the SQL drivers take a data source name.
+func NewStore(driver, source string) (Store, error) {
+	switch driver {
+	case StoreDriverFile:
+		return NewFileStore(source)
+	case StoreDriverSQLite, StoreDriverPostgres:
+		return OpenSQLStore(driver, source)
+	default:
+		return nil, fmt.Errorf("unknown store driver %q (available: %s, %s, %s)",
+			driver, StoreDriverFile, StoreDriverSQLite, StoreDriverPostgres)
+	}
+}
@@ -0,0 +1,247 @@
+package persistence
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Write-ahead log record types
+const (
+	WALAction = "action" // a player's betting action
+	WALDeal   = "deal"   // the shuffled deck and keys a hand was dealt from
+	WALReveal = "reveal" // community cards turned face up
+	WALPayout = "payout" // chips awarded from a pot
+)
+
+// WALRecord is one state-mutating event in the write-ahead log
+type WALRecord struct {
+	Seq      uint64          `json:"seq"`
+	Time     time.Time       `json:"time"`
+	Type     string          `json:"type"`
+	Data     json.RawMessage `json:"data"`
+	Checksum uint32          `json:"crc"`
+}
+
+func (r WALRecord) checksum() uint32 {
+	h := crc32.NewIEEE()
+	fmt.Fprintf(h, "%d:%s:", r.Seq, r.Type)
+	h.Write(r.Data)
+	return h.Sum32()
+}
+
+// WAL is an append-only, fsynced log of hand events, one JSON record per
+// line. Recovery replays the records after the latest snapshot, and
+// compaction drops the ones a snapshot already covers.
+type WAL struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	lastSeq uint64
+}
+
+// OpenWAL opens or creates a write-ahead log. A torn record left by a crash
+// mid-write is cut off, along with anything after it.
+func OpenWAL(path string) (*WAL, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create WAL directory: %w", err)
+	}
+
+	records, valid, err := readWAL(path)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() > valid {
+		logrus.Warnf("Truncating %d bytes of torn WAL records in %s", info.Size()-valid, path)
+		if err := os.Truncate(path, valid); err != nil {
+			return nil, fmt.Errorf("failed to truncate WAL: %w", err)
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open WAL: %w", err)
+	}
+
+	wal := &WAL{path: path, file: file}
+	if len(records) > 0 {
+		wal.lastSeq = records[len(records)-1].Seq
+	}
+	return wal, nil
+}
+
+// Append writes an event and syncs it to disk before returning its sequence number
+func (w *WAL) Append(recordType string, data interface{}) (uint64, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal %s record: %w", recordType, err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	record := WALRecord{
+		Seq:  w.lastSeq + 1,
+		Time: time.Now(),
+		Type: recordType,
+		Data: payload,
+	}
+	record.Checksum = record.checksum()
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal %s record: %w", recordType, err)
+	}
+	if _, err := w.file.Write(append(line, '\n')); err != nil {
+		return 0, fmt.Errorf("failed to append %s record: %w", recordType, err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return 0, fmt.Errorf("failed to sync WAL: %w", err)
+	}
+
+	w.lastSeq = record.Seq
+	return record.Seq, nil
+}
+
+// LastSeq returns the sequence number of the newest record
+func (w *WAL) LastSeq() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.lastSeq
+}
+
+// Records returns the records after seq, oldest first
+func (w *WAL) Records(after uint64) ([]WALRecord, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.recordsAfter(after)
+}
+
+func (w *WAL) recordsAfter(after uint64) ([]WALRecord, error) {
+	records, _, err := readWAL(w.path)
+	if err != nil {
+		return nil, err
+	}
+
+	i := 0
+	for i < len(records) && records[i].Seq <= after {
+		i++
+	}
+	return records[i:], nil
+}
+
+// Compact drops the records up to and including seq, once a snapshot
+// covers them. The log is rewritten to a temporary file and swapped in, so
+// a crash during compaction leaves either the old or the new log.
+func (w *WAL) Compact(upTo uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	records, err := w.recordsAfter(upTo)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to marshal WAL record %d: %w", record.Seq, err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	tmp := w.path + ".compact"
+	if err := writeSynced(tmp, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write compacted WAL: %w", err)
+	}
+
+	w.file.Close()
+	if err := os.Rename(tmp, w.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to swap in compacted WAL: %w", err)
+	}
+
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to reopen WAL: %w", err)
+	}
+	w.file = file
+	return nil
+}
+
+// Close closes the log file
+func (w *WAL) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// readWAL parses a log up to the first torn or corrupt record, returning the
+// good records and how many bytes they span
+func readWAL(path string) ([]WALRecord, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, 0, fmt.Errorf("failed to open WAL: %w", err)
+	}
+	defer file.Close()
+
+	records := []WALRecord{}
+	var valid int64
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// A final line without a newline was never fully written
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read WAL: %w", err)
+		}
+
+		var record WALRecord
+		if err := json.Unmarshal(line, &record); err != nil || record.Checksum != record.checksum() {
+			logrus.Warnf("Corrupt WAL record after seq %d in %s", lastSeq(records), path)
+			break
+		}
+		records = append(records, record)
+		valid += int64(len(line))
+	}
+	return records, valid, nil
+}
+
+func lastSeq(records []WALRecord) uint64 {
+	if len(records) == 0 {
+		return 0
+	}
+	return records[len(records)-1].Seq
+}
+
+func writeSynced(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,58 @@
+package presence
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileStore persists presence as a JSON file for single-instance deployments
+type FileStore struct {
+	path string
+}
+
+// NewFileStore creates a file-backed presence store
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Load reads saved presence; a missing file is an empty store
+func (fs *FileStore) Load() ([]Presence, error) {
+	data, err := os.ReadFile(fs.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Presence{}, nil
+		}
+		return nil, fmt.Errorf("failed to read presence file: %w", err)
+	}
+
+	var entries []Presence
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal presence: %w", err)
+	}
+	return entries, nil
+}
+
+// Save atomically replaces the presence file
+func (fs *FileStore) Save(entries []Presence) error {
+	if dir := filepath.Dir(fs.path); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal presence: %w", err)
+	}
+
+	tmp := fs.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write presence: %w", err)
+	}
+	if err := os.Rename(tmp, fs.path); err != nil {
+		return fmt.Errorf("failed to replace presence file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package presence
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Status is a player's availability
+type Status string
+
+const (
+	StatusOnline  Status = "online"
+	StatusAway    Status = "away"
+	StatusOffline Status = "offline"
+)
+
+const (
+	// DefaultAwayAfter is how long a connected player can be idle before showing as away
+	DefaultAwayAfter = 5 * time.Minute
+
+	sweepInterval = 15 * time.Second
+)
+
+// Presence is the availability of one player across all tables
+type Presence struct {
+	PlayerID string    `json:"player_id"`
+	Status   Status    `json:"status"`
+	Tables   []string  `json:"tables,omitempty"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Store persists presence so it survives restarts. In multi-instance
+// deployments a shared store lets every node see the same state.
+type Store interface {
+	Load() ([]Presence, error)
+	Save(entries []Presence) error
+}
+
+type entry struct {
+	tables     map[string]int // table ID -> open connections
+	lastSeen   time.Time
+	manualAway bool
+	status     Status
+}
+
+// Service tracks presence from hub connection state and player activity
+type Service struct {
+	entries   map[string]*entry
+	store     Store
+	awayAfter time.Duration
+	dirty     bool
+	stop      chan struct{}
+	mu        sync.RWMutex
+}
+
+// NewService creates a presence service, restoring previous state from the store.
+// Restored players start offline since their connections did not survive.
+func NewService(store Store, awayAfter time.Duration) *Service {
+	if awayAfter <= 0 {
+		awayAfter = DefaultAwayAfter
+	}
+
+	s := &Service{
+		entries:   make(map[string]*entry),
+		store:     store,
+		awayAfter: awayAfter,
+	}
+
+	if store != nil {
+		saved, err := store.Load()
+		if err != nil {
+			logrus.Warnf("Failed to load presence: %v", err)
+		}
+		for _, p := range saved {
+			s.entries[p.PlayerID] = &entry{
+				tables:   make(map[string]int),
+				lastSeen: p.LastSeen,
+				status:   StatusOffline,
+			}
+		}
+	}
+
+	return s
+}
+
+// Connected records a new connection from a player at a table
+func (s *Service) Connected(playerID, tableID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.entryLocked(playerID)
+	e.tables[tableID]++
+	e.lastSeen = time.Now()
+	e.manualAway = false
+	s.dirty = true
+	s.refreshLocked(playerID, e)
+}
+
+// Disconnected records a closed connection from a player at a table
+func (s *Service) Disconnected(playerID, tableID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[playerID]
+	if !ok {
+		return
+	}
+
+	if e.tables[tableID] > 1 {
+		e.tables[tableID]--
+	} else {
+		delete(e.tables, tableID)
+	}
+	e.lastSeen = time.Now()
+	s.dirty = true
+	s.refreshLocked(playerID, e)
+}
+
+// Touch records player activity, bringing an idle player back online
+func (s *Service) Touch(playerID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[playerID]
+	if !ok {
+		return
+	}
+
+	e.lastSeen = time.Now()
+	e.manualAway = false
+	s.refreshLocked(playerID, e)
+}
+
+// SetAway lets a connected player mark themselves away until their next activity
+func (s *Service) SetAway(playerID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[playerID]
+	if !ok {
+		return
+	}
+
+	e.manualAway = true
+	s.refreshLocked(playerID, e)
+}
+
+// Get returns a player's presence; unknown players are offline
+func (s *Service) Get(playerID string) Presence {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, ok := s.entries[playerID]
+	if !ok {
+		return Presence{PlayerID: playerID, Status: StatusOffline}
+	}
+	return e.snapshot(playerID)
+}
+
+// GetMany returns presence for a list of players, keyed by player ID
+func (s *Service) GetMany(playerIDs []string) map[string]Presence {
+	result := make(map[string]Presence, len(playerIDs))
+	for _, id := range playerIDs {
+		result[id] = s.Get(id)
+	}
+	return result
+}
+
+// Online returns every player that is online or away, most recently seen first
+func (s *Service) Online() []Presence {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	online := make([]Presence, 0)
+	for id, e := range s.entries {
+		if e.status != StatusOffline {
+			online = append(online, e.snapshot(id))
+		}
+	}
+
+	sort.Slice(online, func(i, j int) bool {
+		return online[i].LastSeen.After(online[j].LastSeen)
+	})
+	return online
+}
+
+// Start runs the idle sweeper and periodic persistence in the background
+func (s *Service) Start() {
+	s.mu.Lock()
+	if s.stop != nil {
+		s.mu.Unlock()
+		return
+	}
+	s.stop = make(chan struct{})
+	stop := s.stop
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(sweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.sweep()
+				s.flush()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts the sweeper and writes the final state to the store
+func (s *Service) Stop() {
+	s.mu.Lock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	s.mu.Unlock()
+
+	s.flush()
+}
+
+// sweep moves idle connected players to away
+func (s *Service) sweep() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, e := range s.entries {
+		s.refreshLocked(id, e)
+	}
+}
+
+func (s *Service) flush() {
+	if s.store == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return
+	}
+	entries := make([]Presence, 0, len(s.entries))
+	for id, e := range s.entries {
+		entries = append(entries, e.snapshot(id))
+	}
+	s.dirty = false
+	s.mu.Unlock()
+
+	if err := s.store.Save(entries); err != nil {
+		logrus.Warnf("Failed to persist presence: %v", err)
+		s.mu.Lock()
+		s.dirty = true
+		s.mu.Unlock()
+	}
+}
+
+func (s *Service) entryLocked(playerID string) *entry {
+	e, ok := s.entries[playerID]
+	if !ok {
+		e = &entry{
+			tables: make(map[string]int),
+			status: StatusOffline,
+		}
+		s.entries[playerID] = e
+	}
+	return e
+}
+
+// refreshLocked recomputes a player's status, marking state dirty when it changes
+func (s *Service) refreshLocked(playerID string, e *entry) {
+	status := StatusOffline
+	if len(e.tables) > 0 {
+		status = StatusOnline
+		if e.manualAway || time.Since(e.lastSeen) > s.awayAfter {
+			status = StatusAway
+		}
+	}
+
+	if status != e.status {
+		logrus.WithFields(logrus.Fields{
+			"player": playerID,
+			"from":   e.status,
+			"to":     status,
+		}).Debug("Presence changed")
+		e.status = status
+		s.dirty = true
+	}
+}
+
+func (e *entry) snapshot(playerID string) Presence {
+	tables := make([]string, 0, len(e.tables))
+	for table := range e.tables {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	return Presence{
+		PlayerID: playerID,
+		Status:   e.status,
+		Tables:   tables,
+		LastSeen: e.lastSeen,
+	}
+}
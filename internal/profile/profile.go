@@ -0,0 +1,115 @@
+// Package profile keeps players' public profiles, keyed by the address that
+// signs their messages, so a table can show names rather than addresses.
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// Store holds the profiles this node has seen, its own player's included
+type Store struct {
+	profiles map[string]protocol.PlayerProfile
+
+	path string
+	mu   sync.RWMutex
+}
+
+// NewStore creates a profile store persisted to path (empty for memory only)
+func NewStore(path string) *Store {
+	s := &Store{
+		profiles: make(map[string]protocol.PlayerProfile),
+		path:     path,
+	}
+
+	if err := s.load(); err != nil {
+		logrus.Warnf("Failed to load profiles: %v", err)
+	}
+	return s
+}
+
+// Get returns the profile kept for an address
+func (s *Store) Get(address string) (protocol.PlayerProfile, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	profile, ok := s.profiles[key(address)]
+	return profile, ok
+}
+
+// Put validates and keeps an address's profile, replacing any earlier one
+func (s *Store) Put(address string, profile protocol.PlayerProfile) error {
+	if address == "" {
+		return fmt.Errorf("a profile needs an address")
+	}
+	profile.Name = strings.TrimSpace(profile.Name)
+	if err := protocol.ValidateProfile(profile); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.profiles[key(address)] = profile
+	return s.saveLocked()
+}
+
+// Addresses are hex and compared without regard to case
+func key(address string) string {
+	return strings.ToLower(address)
+}
+
+func (s *Store) load() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read profiles file: %w", err)
+	}
+
+	var saved map[string]protocol.PlayerProfile
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("failed to unmarshal profiles: %w", err)
+	}
+	for address, profile := range saved {
+		s.profiles[key(address)] = profile
+	}
+	return nil
+}
+
+func (s *Store) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(s.profiles)
+	if err != nil {
+		return fmt.Errorf("failed to marshal profiles: %w", err)
+	}
+
+	if dir := filepath.Dir(s.path); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write profiles: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to replace profiles file: %w", err)
+	}
+	return nil
+}
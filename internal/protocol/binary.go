@@ -0,0 +1,396 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Encoding identifies how messages are serialized on the wire
+type Encoding string
+
+const (
+	EncodingJSON     Encoding = "json"
+	EncodingProtobuf Encoding = "protobuf"
+)
+
+// Protobuf wire types used by the schema in message.proto
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// Envelope field numbers (see message.proto)
+const (
+	envType        = 1
+	envFrom        = 2
+	envTimestamp   = 3
+	envSender      = 4
+	envSeq         = 5
+	envSignature   = 6
+	envJSONPayload = 7
+	envDeck        = 8
+	envGetRPC      = 9
+	envRPCResponse = 10
+	envChunk       = 11
+	envEpoch       = 12
+)
+
+// EncodeMessage serializes a message with the given encoding
+func EncodeMessage(msg *Message, enc Encoding) ([]byte, error) {
+	switch enc {
+	case EncodingJSON:
+		return json.Marshal(msg)
+	case EncodingProtobuf:
+		return MarshalBinary(msg)
+	default:
+		return nil, fmt.Errorf("unknown encoding: %s", enc)
+	}
+}
+
+// DecodeMessage parses a message serialized with the given encoding
+func DecodeMessage(data []byte, enc Encoding) (*Message, error) {
+	switch enc {
+	case EncodingJSON:
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return nil, err
+		}
+		if msg.Type == "" {
+			return nil, fmt.Errorf("message has no type")
+		}
+		return &msg, nil
+	case EncodingProtobuf:
+		return UnmarshalBinary(data)
+	default:
+		return nil, fmt.Errorf("unknown encoding: %s", enc)
+	}
+}
+
+// MarshalBinary encodes a message as a protobuf Envelope. Deck-carrying
+// payloads are stored as raw bytes instead of base64 JSON; everything else
+// is carried as the original JSON payload.
+func MarshalBinary(msg *Message) ([]byte, error) {
+	buf := make([]byte, 0, len(msg.Payload)+64)
+	buf = appendStringField(buf, envType, string(msg.Type))
+	buf = appendStringField(buf, envFrom, msg.From)
+	if !msg.Timestamp.IsZero() {
+		buf = appendVarintField(buf, envTimestamp, uint64(msg.Timestamp.UnixNano()))
+	}
+	buf = appendStringField(buf, envSender, msg.Sender)
+	if msg.Seq != 0 {
+		buf = appendVarintField(buf, envSeq, msg.Seq)
+	}
+	if msg.Epoch != 0 {
+		buf = appendVarintField(buf, envEpoch, msg.Epoch)
+	}
+	buf = appendBytesField(buf, envSignature, msg.Signature)
+
+	field, payload := encodeBinaryPayload(msg)
+	buf = appendTag(buf, field, wireBytes)
+	buf = binary.AppendUvarint(buf, uint64(len(payload)))
+	buf = append(buf, payload...)
+
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a protobuf Envelope back into a message with a JSON payload
+func UnmarshalBinary(data []byte) (*Message, error) {
+	msg := &Message{}
+	for len(data) > 0 {
+		num, wt, v, b, rest, err := readField(data)
+		if err != nil {
+			return nil, err
+		}
+		data = rest
+
+		switch num {
+		case envType:
+			msg.Type = MessageType(b)
+		case envFrom:
+			msg.From = string(b)
+		case envTimestamp:
+			msg.Timestamp = time.Unix(0, int64(v))
+		case envSender:
+			msg.Sender = string(b)
+		case envSeq:
+			msg.Seq = v
+		case envEpoch:
+			msg.Epoch = v
+		case envSignature:
+			msg.Signature = append([]byte(nil), b...)
+		case envJSONPayload, envDeck, envGetRPC, envRPCResponse, envChunk:
+			if wt != wireBytes {
+				return nil, fmt.Errorf("payload field %d has wire type %d", num, wt)
+			}
+			payload, err := decodeBinaryPayload(num, msg.Type, b)
+			if err != nil {
+				return nil, err
+			}
+			msg.Payload = payload
+		}
+	}
+
+	if msg.Type == "" {
+		return nil, fmt.Errorf("binary message has no type")
+	}
+	return msg, nil
+}
+
+// encodeBinaryPayload picks the most compact envelope field for the payload.
+// Signatures cover the JSON payload bytes, so a typed encoding is only used
+// when decoding it reproduces those bytes exactly.
+func encodeBinaryPayload(msg *Message) (int, []byte) {
+	var field int
+	var payload []byte
+
+	switch msg.Type {
+	case TypeEncDeck, TypeShuffleStatus:
+		var p EncDeckPayload
+		if err := json.Unmarshal(msg.Payload, &p); err != nil {
+			return envJSONPayload, msg.Payload
+		}
+		field, payload = envDeck, appendRepeatedBytes(nil, 1, p.Deck)
+	case TypeGetRPC:
+		var p GetRPCPayload
+		if err := json.Unmarshal(msg.Payload, &p); err != nil {
+			return envJSONPayload, msg.Payload
+		}
+		payload = appendPackedInts(nil, 1, p.CardIndices)
+		payload = appendRepeatedBytes(payload, 2, p.EncryptedData)
+		payload = appendStringField(payload, 3, p.OriginalOwner)
+		field = envGetRPC
+	case TypeRPCResponse:
+		var p RPCResponsePayload
+		if err := json.Unmarshal(msg.Payload, &p); err != nil {
+			return envJSONPayload, msg.Payload
+		}
+		payload = appendPackedInts(nil, 1, p.CardIndices)
+		payload = appendRepeatedBytes(payload, 2, p.DecryptedData)
+		field = envRPCResponse
+	case TypeChunk:
+		var p ChunkPayload
+		if err := json.Unmarshal(msg.Payload, &p); err != nil {
+			return envJSONPayload, msg.Payload
+		}
+		payload = appendStringField(nil, 1, p.ChunkID)
+		payload = appendVarintField(payload, 2, uint64(p.Index))
+		payload = appendVarintField(payload, 3, uint64(p.Total))
+		payload = appendStringField(payload, 4, string(p.Encoding))
+		payload = appendBytesField(payload, 5, p.Data)
+		field = envChunk
+	default:
+		return envJSONPayload, msg.Payload
+	}
+
+	roundTrip, err := decodeBinaryPayload(field, msg.Type, payload)
+	if err != nil || !bytes.Equal(roundTrip, msg.Payload) {
+		return envJSONPayload, msg.Payload
+	}
+	return field, payload
+}
+
+// decodeBinaryPayload converts an envelope payload field back into JSON
+func decodeBinaryPayload(field int, msgType MessageType, data []byte) (json.RawMessage, error) {
+	switch field {
+	case envJSONPayload:
+		return append(json.RawMessage(nil), data...), nil
+	case envDeck:
+		var deck [][]byte
+		err := forEachField(data, func(num, wt int, v uint64, b []byte) error {
+			if num == 1 {
+				deck = append(deck, append([]byte(nil), b...))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if msgType == TypeShuffleStatus {
+			return json.Marshal(ShuffleStatusPayload{Deck: deck})
+		}
+		return json.Marshal(EncDeckPayload{Deck: deck})
+	case envGetRPC:
+		var p GetRPCPayload
+		err := forEachField(data, func(num, wt int, v uint64, b []byte) error {
+			switch num {
+			case 1:
+				indices, err := readInts(wt, v, b)
+				if err != nil {
+					return err
+				}
+				p.CardIndices = append(p.CardIndices, indices...)
+			case 2:
+				p.EncryptedData = append(p.EncryptedData, append([]byte(nil), b...))
+			case 3:
+				p.OriginalOwner = string(b)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(p)
+	case envRPCResponse:
+		var p RPCResponsePayload
+		err := forEachField(data, func(num, wt int, v uint64, b []byte) error {
+			switch num {
+			case 1:
+				indices, err := readInts(wt, v, b)
+				if err != nil {
+					return err
+				}
+				p.CardIndices = append(p.CardIndices, indices...)
+			case 2:
+				p.DecryptedData = append(p.DecryptedData, append([]byte(nil), b...))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(p)
+	case envChunk:
+		var p ChunkPayload
+		err := forEachField(data, func(num, wt int, v uint64, b []byte) error {
+			switch num {
+			case 1:
+				p.ChunkID = string(b)
+			case 2:
+				p.Index = int(v)
+			case 3:
+				p.Total = int(v)
+			case 4:
+				p.Encoding = Encoding(b)
+			case 5:
+				p.Data = append([]byte(nil), b...)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(p)
+	default:
+		return nil, fmt.Errorf("unknown payload field %d", field)
+	}
+}
+
+// Protobuf varints are the same LEB128 encoding used by encoding/binary
+
+func appendTag(buf []byte, num, wt int) []byte {
+	return binary.AppendUvarint(buf, uint64(num)<<3|uint64(wt))
+}
+
+func appendVarintField(buf []byte, num int, v uint64) []byte {
+	buf = appendTag(buf, num, wireVarint)
+	return binary.AppendUvarint(buf, v)
+}
+
+func appendBytesField(buf []byte, num int, b []byte) []byte {
+	if len(b) == 0 {
+		return buf
+	}
+	buf = appendTag(buf, num, wireBytes)
+	buf = binary.AppendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+func appendStringField(buf []byte, num int, s string) []byte {
+	return appendBytesField(buf, num, []byte(s))
+}
+
+// appendRepeatedBytes writes every element, including empty ones, to keep indices aligned
+func appendRepeatedBytes(buf []byte, num int, items [][]byte) []byte {
+	for _, item := range items {
+		buf = appendTag(buf, num, wireBytes)
+		buf = binary.AppendUvarint(buf, uint64(len(item)))
+		buf = append(buf, item...)
+	}
+	return buf
+}
+
+func appendPackedInts(buf []byte, num int, values []int) []byte {
+	if len(values) == 0 {
+		return buf
+	}
+	packed := make([]byte, 0, len(values))
+	for _, v := range values {
+		packed = binary.AppendUvarint(packed, uint64(int64(v)))
+	}
+	return appendBytesField(buf, num, packed)
+}
+
+// readField reads one field, returning its varint value or bytes and the remaining data
+func readField(data []byte) (int, int, uint64, []byte, []byte, error) {
+	tag, n := binary.Uvarint(data)
+	if n <= 0 {
+		return 0, 0, 0, nil, nil, fmt.Errorf("malformed field tag")
+	}
+	data = data[n:]
+	num, wt := int(tag>>3), int(tag&7)
+
+	switch wt {
+	case wireVarint:
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return 0, 0, 0, nil, nil, fmt.Errorf("malformed varint in field %d", num)
+		}
+		return num, wt, v, nil, data[n:], nil
+	case wireBytes:
+		length, n := binary.Uvarint(data)
+		if n <= 0 || length > uint64(len(data)-n) {
+			return 0, 0, 0, nil, nil, fmt.Errorf("malformed length in field %d", num)
+		}
+		end := n + int(length)
+		return num, wt, 0, data[n:end], data[end:], nil
+	case wireFixed64:
+		if len(data) < 8 {
+			return 0, 0, 0, nil, nil, fmt.Errorf("truncated fixed64 in field %d", num)
+		}
+		return num, wt, binary.LittleEndian.Uint64(data), nil, data[8:], nil
+	case wireFixed32:
+		if len(data) < 4 {
+			return 0, 0, 0, nil, nil, fmt.Errorf("truncated fixed32 in field %d", num)
+		}
+		return num, wt, uint64(binary.LittleEndian.Uint32(data)), nil, data[4:], nil
+	default:
+		return 0, 0, 0, nil, nil, fmt.Errorf("unsupported wire type %d in field %d", wt, num)
+	}
+}
+
+func forEachField(data []byte, fn func(num, wt int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		num, wt, v, b, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		if err := fn(num, wt, v, b); err != nil {
+			return err
+		}
+		data = rest
+	}
+	return nil
+}
+
+// readInts accepts both packed and unpacked encodings of a repeated int32
+func readInts(wt int, v uint64, b []byte) ([]int, error) {
+	if wt == wireVarint {
+		return []int{int(int32(v))}, nil
+	}
+
+	values := []int{}
+	for len(b) > 0 {
+		x, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, fmt.Errorf("malformed packed varint")
+		}
+		values = append(values, int(int32(x)))
+		b = b[n:]
+	}
+	return values, nil
+}
@@ -2,8 +2,9 @@ package protocol
 
 // BroadcastMessage represents a message to be broadcast
 type BroadcastMessage struct {
-	Data []byte   // The message data to broadcast
-	To   []string // Target client IDs (empty means broadcast to all)
+	Data        []byte   // The message data to broadcast
+	To          []string // Target client IDs (empty means broadcast to all)
+	ClientsOnly bool     // Skip peer connections (client-facing events)
 }
 
 // NewBroadcast creates a new broadcast message for specific targets
@@ -5,19 +5,26 @@ const (
 	GameVariantTexasHoldem = "TEXAS_HOLDEM"
 	GameVariantOmaha       = "OMAHA"
 	GameVariantSevenCard   = "SEVEN_CARD_STUD"
+	GameVariantOmahaHiLo   = "OMAHA_HI_LO"
 )
 
 // Error codes
 const (
-	ErrCodeInvalidMessage    = "INVALID_MESSAGE"
-	ErrCodeInvalidAction     = "INVALID_ACTION"
-	ErrCodeNotYourTurn       = "NOT_YOUR_TURN"
-	ErrCodeInsufficientFunds = "INSUFFICIENT_FUNDS"
-	ErrCodeGameNotStarted    = "GAME_NOT_STARTED"
-	ErrCodePlayerNotFound    = "PLAYER_NOT_FOUND"
-	ErrCodeAlreadyInGame     = "ALREADY_IN_GAME"
-	ErrCodeGameFull          = "GAME_FULL"
-	ErrCodeInternalError     = "INTERNAL_ERROR"
+	ErrCodeInvalidMessage      = "INVALID_MESSAGE"
+	ErrCodeInvalidAction       = "INVALID_ACTION"
+	ErrCodeNotYourTurn         = "NOT_YOUR_TURN"
+	ErrCodeInsufficientFunds   = "INSUFFICIENT_FUNDS"
+	ErrCodeGameNotStarted      = "GAME_NOT_STARTED"
+	ErrCodePlayerNotFound      = "PLAYER_NOT_FOUND"
+	ErrCodeAlreadyInGame       = "ALREADY_IN_GAME"
+	ErrCodeGameFull            = "GAME_FULL"
+	ErrCodeInternalError       = "INTERNAL_ERROR"
+	ErrCodeInvalidSignature    = "INVALID_SIGNATURE"
+	ErrCodeReplayedMessage     = "REPLAYED_MESSAGE"
+	ErrCodeIncompatibleVersion = "INCOMPATIBLE_VERSION"
+	ErrCodeUnsupportedVariant  = "UNSUPPORTED_VARIANT"
+	ErrCodeMissingCapability   = "MISSING_CAPABILITY"
+	ErrCodeRateLimited         = "RATE_LIMITED"
 )
 
 // Action types
@@ -30,6 +37,20 @@ const (
 	ActionAllIn = "all_in"
 )
 
+// Seat actions
+const (
+	SeatActionReserve = "reserve"
+	SeatActionTake    = "take"
+	SeatActionLeave   = "leave"
+)
+
+// Host powers (see HostActionPayload)
+const (
+	HostActionKick   = "kick"
+	HostActionConfig = "config"
+	HostActionClose  = "close"
+)
+
 // Game states
 const (
 	StateWaiting  = "WAITING"
@@ -39,6 +60,7 @@ const (
 	StateTurn     = "TURN"
 	StateRiver    = "RIVER"
 	StateShowdown = "SHOWDOWN"
+	StatePaused   = "PAUSED"
 )
 
 // Default values
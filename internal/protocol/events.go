@@ -2,6 +2,7 @@ package protocol
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -10,6 +11,7 @@ type EventType string
 
 const (
 	EventGameStateUpdate EventType = "game_state_update"
+	EventGameStateDelta  EventType = "game_state_delta"
 	EventPlayerJoined    EventType = "player_joined"
 	EventPlayerLeft      EventType = "player_left"
 	EventPlayerAction    EventType = "player_action"
@@ -20,6 +22,28 @@ const (
 	EventError           EventType = "error"
 	EventTurnChange      EventType = "turn_change"
 	EventBlindsPosted    EventType = "blinds_posted"
+	EventSeatDraw        EventType = "seat_draw"
+	EventAbortVote       EventType = "abort_vote"
+	EventIncident        EventType = "incident"
+	EventChat            EventType = "chat"
+	EventSeatChanged     EventType = "seat_changed"
+	EventSeatAvailable   EventType = "seat_available"
+	EventRebuy           EventType = "rebuy"
+	EventRunItTwiceOffer EventType = "run_it_twice_offer"
+	EventRunItTwice      EventType = "run_it_twice"
+	EventSitOut          EventType = "sit_out"
+	EventShowdownTurn    EventType = "showdown_turn"
+	EventHandShown       EventType = "hand_shown"
+	EventHandMucked      EventType = "hand_mucked"
+	EventRabbitHunt      EventType = "rabbit_hunt"
+	EventTablePaused     EventType = "table_paused"
+	EventTableResumed    EventType = "table_resumed"
+	EventPauseVote       EventType = "pause_vote"
+	EventHostAction      EventType = "host_action"
+	EventHostVote        EventType = "host_vote"
+
+	// Sent to players only, not spectators
+	EventSuspiciousActivity EventType = "suspicious_activity"
 
 	// NEW: Disconnect and penalty events
 	EventPlayerDisconnected EventType = "player_disconnected"
@@ -27,13 +51,68 @@ const (
 	EventPlayerAbandoned    EventType = "player_abandoned"
 	EventGameAborted        EventType = "game_aborted"
 	EventPenaltyApplied     EventType = "penalty_applied"
+
+	// The chain circuit breaker tripped or reset
+	EventChainHealth EventType = "chain_health"
+
+	// A finished hand's revealed shuffle; it holds key material, so it goes
+	// to players only
+	EventShuffleAudit EventType = "shuffle_audit"
 )
 
+// publicEvents are the events spectators may see: table state, actions and
+// showdown results. Nothing here carries hole cards or key material.
+var publicEvents = map[EventType]bool{
+	EventGameStateUpdate:    true,
+	EventGameStateDelta:     true,
+	EventPlayerJoined:       true,
+	EventPlayerLeft:         true,
+	EventPlayerAction:       true,
+	EventNewHand:            true,
+	EventCommunityCard:      true,
+	EventShowdown:           true,
+	EventWinner:             true,
+	EventTurnChange:         true,
+	EventBlindsPosted:       true,
+	EventSeatDraw:           true,
+	EventAbortVote:          true,
+	EventIncident:           true,
+	EventChat:               true,
+	EventSeatChanged:        true,
+	EventRebuy:              true,
+	EventRunItTwice:         true,
+	EventSitOut:             true,
+	EventShowdownTurn:       true,
+	EventHandShown:          true,
+	EventHandMucked:         true,
+	EventRabbitHunt:         true,
+	EventTablePaused:        true,
+	EventTableResumed:       true,
+	EventPauseVote:          true,
+	EventHostAction:         true,
+	EventHostVote:           true,
+	EventPlayerDisconnected: true,
+	EventPlayerReconnected:  true,
+	EventPlayerAbandoned:    true,
+	EventGameAborted:        true,
+	EventPenaltyApplied:     true,
+}
+
+// IsPublicEvent reports whether an event type is safe for spectators
+func IsPublicEvent(eventType EventType) bool {
+	return publicEvents[eventType]
+}
+
 // Event represents a real-time event sent to clients
 type Event struct {
 	Type      EventType       `json:"type"`
 	Data      json.RawMessage `json:"data"`
 	Timestamp time.Time       `json:"timestamp"`
+
+	// The hand the event belongs to and the number of actions taken in it
+	// so far; empty before the first hand
+	HandUID string `json:"hand_uid,omitempty"`
+	Seq     int    `json:"seq,omitempty"`
 }
 
 // NewEvent creates a new event with the given type and data
@@ -62,6 +141,37 @@ func (e *Event) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// UnmarshalJSON custom unmarshaller for Event; like a message's, a
+// malformed timestamp is dropped rather than failing the event
+func (e *Event) UnmarshalJSON(data []byte) error {
+	type Alias Event
+	aux := &struct {
+		*Alias
+		Timestamp string `json:"timestamp"`
+	}{
+		Alias: (*Alias)(e),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if timestamp, err := time.Parse(time.RFC3339, aux.Timestamp); err == nil {
+		e.Timestamp = timestamp
+	}
+	return nil
+}
+
+// DecodeEvent parses an event, rejecting one without a type
+func DecodeEvent(data []byte) (*Event, error) {
+	var event Event
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+	if event.Type == "" {
+		return nil, fmt.Errorf("event has no type")
+	}
+	return &event, nil
+}
+
 // GameStateUpdateEvent contains full game state
 type GameStateUpdateEvent struct {
 	Status         string       `json:"status"`
@@ -70,13 +180,48 @@ type GameStateUpdateEvent struct {
 	CurrentTurn    string       `json:"current_turn"`
 	CommunityCards []CardData   `json:"community_cards"`
 	Players        []PlayerData `json:"players"`
+
+	// Version numbers the table's state updates; it only goes up
+	Version uint64 `json:"version"`
+
+	// Diff describes how the table got here from the previous update; it is
+	// omitted from the first update and from state fetched on demand
+	Diff *StateDiff `json:"diff,omitempty"`
+}
+
+// Places chips can move between in a StateDiff
+const (
+	ChipLocationStack = "stack"
+	ChipLocationBet   = "bet"
+	ChipLocationPot   = "pot"
+	ChipLocationBuyIn = "buy_in" // chips bought in from outside the table
+)
+
+// StateDiff lists what changed since the previous game_state_update so
+// clients can animate chips without comparing snapshots themselves
+type StateDiff struct {
+	PreviousStatus    string     `json:"previous_status,omitempty"`
+	PotDelta          int        `json:"pot_delta"`
+	NewCommunityCards []CardData `json:"new_community_cards,omitempty"`
+	ChipMoves         []ChipMove `json:"chip_moves,omitempty"`
+}
+
+// ChipMove is an amount moving between a player's stack, bet and a pot.
+// Pot numbers start at 1 for the main pot; higher numbers are side pots.
+type ChipMove struct {
+	PlayerID string `json:"player_id"`
+	Amount   int    `json:"amount"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Pot      int    `json:"pot,omitempty"`
 }
 
 // PlayerJoinedEvent notifies when a player joins
 type PlayerJoinedEvent struct {
-	PlayerID   string `json:"player_id"`
-	PlayerName string `json:"player_name,omitempty"`
-	Stack      int    `json:"stack"`
+	PlayerID   string         `json:"player_id"`
+	PlayerName string         `json:"player_name,omitempty"`
+	Stack      int            `json:"stack"`
+	Profile    *PlayerProfile `json:"profile,omitempty"`
 }
 
 // PlayerLeftEvent notifies when a player leaves
@@ -99,19 +244,29 @@ type NewHandEvent struct {
 	DealerID    int      `json:"dealer_id"`
 	SmallBlind  int      `json:"small_blind"`
 	BigBlind    int      `json:"big_blind"`
+	Ante        int      `json:"ante,omitempty"`
 	PlayerCount int      `json:"player_count"`
 	Players     []string `json:"players"`
 }
 
 // CommunityCardEvent notifies when community cards are dealt
 type CommunityCardEvent struct {
-	Stage string     `json:"stage"` // "flop", "turn", "river"
+	Stage string     `json:"stage"` // "flop", "turn", "river", "second_run"
 	Cards []CardData `json:"cards"`
 }
 
 // ShowdownEvent notifies when showdown occurs
 type ShowdownEvent struct {
 	Results []ShowdownPlayerResult `json:"results"`
+
+	// Set when the board was run twice: each run's board and hands
+	Runs []ShowdownRunResult `json:"runs,omitempty"`
+}
+
+// ShowdownRunResult is one board of a hand that was run twice
+type ShowdownRunResult struct {
+	Board   []CardData             `json:"board"`
+	Results []ShowdownPlayerResult `json:"results"`
 }
 
 // WinnerEvent notifies of hand winner(s)
@@ -126,6 +281,11 @@ type TurnChangeEvent struct {
 	RotationID    int      `json:"rotation_id"`
 	ValidActions  []string `json:"valid_actions"`
 	TimeRemaining int      `json:"time_remaining,omitempty"`
+
+	// Seconds of extra decision time the player has left this session, and
+	// whether TimeRemaining is already counting it down
+	TimeBank      int  `json:"time_bank,omitempty"`
+	UsingTimeBank bool `json:"using_time_bank,omitempty"`
 }
 
 // BlindsPostedEvent notifies when blinds are posted
@@ -134,6 +294,20 @@ type BlindsPostedEvent struct {
 	BigBlindPlayer   string `json:"big_blind_player"`
 	SmallBlindAmount int    `json:"small_blind_amount"`
 	BigBlindAmount   int    `json:"big_blind_amount"`
+
+	// Antes actually paid, which can be short for a player who went all-in
+	Ante  int            `json:"ante,omitempty"`
+	Antes map[string]int `json:"antes,omitempty"`
+
+	StraddlePlayer string `json:"straddle_player,omitempty"`
+	StraddleAmount int    `json:"straddle_amount,omitempty"`
+
+	// Where the button sits, whether the button or small blind is dead, and
+	// what returning players posted for blinds they missed
+	ButtonSeat     int            `json:"button_seat,omitempty"`
+	DeadButton     bool           `json:"dead_button,omitempty"`
+	DeadSmallBlind bool           `json:"dead_small_blind,omitempty"`
+	MissedBlinds   map[string]int `json:"missed_blinds,omitempty"`
 }
 
 // NEW: PlayerDisconnectedEvent notifies when a player disconnects
@@ -188,6 +362,7 @@ type CardData struct {
 // PlayerData represents player state in events
 type PlayerData struct {
 	PlayerID      string `json:"player_id"`
+	Seat          int    `json:"seat"`
 	Stack         int    `json:"stack"`
 	CurrentBet    int    `json:"current_bet"`
 	IsActive      bool   `json:"is_active"`
@@ -203,6 +378,7 @@ type ShowdownPlayerResult struct {
 	Hand     []CardData `json:"hand"`
 	HandRank string     `json:"hand_rank"`
 	Rank     int32      `json:"rank"`
+	LowHand  string     `json:"low_hand,omitempty"` // hi-lo tables, when the hand qualifies
 }
 
 // WinnerData represents a winner's information
@@ -211,6 +387,188 @@ type WinnerData struct {
 	Amount   int    `json:"amount"`
 	HandName string `json:"hand_name,omitempty"`
 	NewStack int    `json:"new_stack"`
+
+	// Hi-lo tables: the winner's low and how much of Amount it won
+	LowHand string `json:"low_hand,omitempty"`
+	LowWon  int    `json:"low_won,omitempty"`
+}
+
+// SeatDrawEvent announces the seating order and button drawn at table
+// start, with the commitments and seeds that prove them
+type SeatDrawEvent struct {
+	Beacon      string            `json:"beacon"`
+	Commitments map[string]string `json:"commitments"`
+	Seeds       map[string]string `json:"seeds"`
+	Seats       []string          `json:"seats"`
+	Button      string            `json:"button"`
+}
+
+// AbortVoteEvent reports progress of a vote to abort the session
+type AbortVoteEvent struct {
+	PlayerID string   `json:"player_id"`
+	Approve  bool     `json:"approve"`
+	Reason   string   `json:"reason"`
+	Approved []string `json:"approved"`
+	Pending  []string `json:"pending"`
+}
+
+// TablePausedEvent reports the table put on hold, by an operator or a
+// vote. Status is what the hand was doing, WAITING between hands.
+type TablePausedEvent struct {
+	By        string `json:"by"`
+	Reason    string `json:"reason"`
+	Status    string `json:"status"`
+	Timestamp string `json:"timestamp"`
+}
+
+// TableResumedEvent reports play carrying on after a pause
+type TableResumedEvent struct {
+	By            string `json:"by"`
+	PausedSeconds int    `json:"paused_seconds"`
+	Timestamp     string `json:"timestamp"`
+}
+
+// PauseVoteEvent reports progress of a vote to pause or resume the table
+type PauseVoteEvent struct {
+	PlayerID string   `json:"player_id"`
+	Pause    bool     `json:"pause"`
+	Approve  bool     `json:"approve"`
+	Reason   string   `json:"reason"`
+	Approved []string `json:"approved"`
+	Rejected []string `json:"rejected"`
+	Pending  []string `json:"pending"`
+}
+
+// HostActionEvent reports a host power used, by the host or by a vote
+// while the host was away
+type HostActionEvent struct {
+	By       string `json:"by"`
+	Action   string `json:"action"`
+	PlayerID string `json:"player_id,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// HostVoteEvent reports progress of a vote to use a host power
+type HostVoteEvent struct {
+	PlayerID string   `json:"player_id"`
+	Action   string   `json:"action"`
+	Target   string   `json:"target,omitempty"`
+	Approve  bool     `json:"approve"`
+	Approved []string `json:"approved"`
+	Rejected []string `json:"rejected"`
+	Pending  []string `json:"pending"`
+}
+
+// IncidentEvent reports a hand voided after an internal error
+type IncidentEvent struct {
+	IncidentID string         `json:"incident_id"`
+	HandID     int            `json:"hand_id"`
+	Message    string         `json:"message"`
+	Refunds    map[string]int `json:"refunds"`
+	Timestamp  string         `json:"timestamp"`
+}
+
+// SeatChangedEvent reports a seat being reserved, taken or given up
+type SeatChangedEvent struct {
+	PlayerID      string `json:"player_id"`
+	Seat          int    `json:"seat"`
+	Action        string `json:"action"`
+	ReservedUntil string `json:"reserved_until,omitempty"`
+}
+
+// RebuyEvent announces chips added to a player's stack between hands
+type RebuyEvent struct {
+	PlayerID string `json:"player_id"`
+	Amount   int    `json:"amount"`
+	NewStack int    `json:"new_stack"`
+	AddOn    bool   `json:"add_on"` // topped up a stack that was not busted
+	TxHash   string `json:"tx_hash,omitempty"`
+}
+
+// RunItTwiceOfferEvent asks the all-in players whether to run the board twice
+type RunItTwiceOfferEvent struct {
+	Players   []string `json:"players"`
+	ExpiresAt string   `json:"expires_at"`
+}
+
+// RunItTwiceEvent announces how many times the rest of the board is dealt
+type RunItTwiceEvent struct {
+	Agreed bool `json:"agreed"`
+	Runs   int  `json:"runs"`
+}
+
+// ShowdownTurnEvent asks the next player at the showdown to show or muck.
+// The last aggressor, or the first player left of the button, must show.
+type ShowdownTurnEvent struct {
+	PlayerID  string   `json:"player_id"`
+	Order     []string `json:"order"`
+	MustShow  bool     `json:"must_show"`
+	ExpiresAt string   `json:"expires_at"`
+}
+
+// HandShownEvent reveals a player's hole cards at the showdown
+type HandShownEvent struct {
+	PlayerID string     `json:"player_id"`
+	Hand     []CardData `json:"hand"`
+	HandRank string     `json:"hand_rank"`
+}
+
+// HandMuckedEvent announces a player mucked at the showdown
+type HandMuckedEvent struct {
+	PlayerID string `json:"player_id"`
+}
+
+// RabbitHuntEvent shows the board a hand that ended early would have had
+type RabbitHuntEvent struct {
+	HandUID string     `json:"hand_uid"`
+	Board   []CardData `json:"board"`  // the cards dealt before the hand ended
+	Rabbit  []CardData `json:"rabbit"` // the cards that would have followed
+}
+
+// SuspiciousActivityEvent tells the table that play between some players
+// was flagged for an operator's review; the evidence stays on the admin API
+type SuspiciousActivityEvent struct {
+	Kind    string   `json:"kind"`
+	Players []string `json:"players"`
+	Message string   `json:"message"`
+}
+
+// ChainHealthEvent tells players whether new hands are escrowed on-chain
+type ChainHealthEvent struct {
+	Tripped bool   `json:"tripped"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message"`
+}
+
+// ShuffleAuditEvent publishes a finished hand's shuffle audit (a
+// game.ShuffleAudit) and whether replaying it checked out
+type ShuffleAuditEvent struct {
+	HandID   int             `json:"hand_id"`
+	Verified bool            `json:"verified"`
+	Audit    json.RawMessage `json:"audit"`
+}
+
+// SitOutEvent announces a player leaving or rejoining the deal
+type SitOutEvent struct {
+	PlayerID   string `json:"player_id"`
+	SittingOut bool   `json:"sitting_out"`
+	WaitForBB  bool   `json:"wait_for_bb,omitempty"`
+	OwedBlinds int    `json:"owed_blinds,omitempty"`
+}
+
+// SeatAvailableEvent tells a waitlisted player they have been seated and must ready up
+type SeatAvailableEvent struct {
+	PlayerID string `json:"player_id"`
+	Seat     int    `json:"seat"`
+	ReadyBy  string `json:"ready_by"`
+	Message  string `json:"message"`
+}
+
+// ChatEvent delivers a table chat message
+type ChatEvent struct {
+	PlayerID  string `json:"player_id"`
+	Text      string `json:"text"`
+	Timestamp string `json:"timestamp"`
 }
 
 // ErrorEvent represents an error event
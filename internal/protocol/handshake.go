@@ -0,0 +1,204 @@
+package protocol
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ProtocolVersion is the wire protocol version spoken by this node.
+// Peers are compatible when the major versions match.
+const ProtocolVersion = "2.0"
+
+// Capability is a bitmap of optional protocol features a peer supports
+type Capability uint32
+
+const (
+	CapVariantTexasHoldem Capability = 1 << iota
+	CapVariantOmaha
+	CapVariantSevenCard
+	CapCompression
+	CapSignedMessages
+	CapBinaryEncoding
+	CapThrottledEvents // client-only: coalesce state updates for mobile
+	CapVariantOmahaHiLo
+	CapStateDeltas // client-only: send state updates as deltas
+	CapRelay       // accepts messages relayed through another peer
+)
+
+// variantCapabilities maps game variants to their capability flag
+var variantCapabilities = map[string]Capability{
+	GameVariantTexasHoldem: CapVariantTexasHoldem,
+	GameVariantOmaha:       CapVariantOmaha,
+	GameVariantSevenCard:   CapVariantSevenCard,
+	GameVariantOmahaHiLo:   CapVariantOmahaHiLo,
+}
+
+// Has reports whether all flags in other are set
+func (c Capability) Has(other Capability) bool {
+	return c&other == other
+}
+
+// capabilityNames lists capabilities in bit order with their wire names
+var capabilityNames = []struct {
+	flag Capability
+	name string
+}{
+	{CapVariantTexasHoldem, "texas_holdem"},
+	{CapVariantOmaha, "omaha"},
+	{CapVariantSevenCard, "seven_card_stud"},
+	{CapCompression, "compression"},
+	{CapSignedMessages, "signed_messages"},
+	{CapBinaryEncoding, "binary_encoding"},
+	{CapThrottledEvents, "throttled_events"},
+	{CapVariantOmahaHiLo, "omaha_hi_lo"},
+	{CapStateDeltas, "state_deltas"},
+	{CapRelay, "relay"},
+}
+
+// String lists the enabled capability names
+func (c Capability) String() string {
+	names := []string{}
+	for _, entry := range capabilityNames {
+		if c.Has(entry.flag) {
+			names = append(names, entry.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
+
+// ParseCapabilities parses a comma-separated list of capability names
+func ParseCapabilities(names string) (Capability, error) {
+	var c Capability
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		found := false
+		for _, entry := range capabilityNames {
+			if entry.name == name {
+				c |= entry.flag
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown capability: %s", name)
+		}
+	}
+	return c, nil
+}
+
+// VariantCapability returns the capability flag for a game variant
+func VariantCapability(variant string) (Capability, error) {
+	capability, ok := variantCapabilities[variant]
+	if !ok {
+		return 0, fmt.Errorf("invalid game variant: %s", variant)
+	}
+	return capability, nil
+}
+
+// ParseProtocolVersion parses "major.minor[.patch]"
+func ParseProtocolVersion(version string) (int, int, error) {
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("malformed protocol version: %q", version)
+	}
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed protocol major version: %q", version)
+	}
+
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed protocol minor version: %q", version)
+	}
+
+	return major, minor, nil
+}
+
+// NegotiatedSession is the agreed feature set with a remote peer
+type NegotiatedSession struct {
+	PeerAddr     string     `json:"peer_addr"`
+	PeerVersion  string     `json:"peer_version"`
+	GameVariant  string     `json:"game_variant"`
+	Capabilities Capability `json:"capabilities"`
+
+	// The peer forwards messages between us and its other peers
+	Relay bool `json:"relay,omitempty"`
+}
+
+// Encoding returns the wire encoding to use for messages to this peer
+func (ns *NegotiatedSession) Encoding() Encoding {
+	if ns.Capabilities.Has(CapBinaryEncoding) {
+		return EncodingProtobuf
+	}
+	return EncodingJSON
+}
+
+// NegotiateHandshake checks a remote handshake against our own and returns the
+// shared capability set, or an ErrorPayload to send back when incompatible.
+// requireSigned rejects peers that cannot sign their messages.
+func NegotiateHandshake(local, remote HandshakePayload, requireSigned bool) (*NegotiatedSession, *ErrorPayload) {
+	localMajor, _, err := ParseProtocolVersion(local.Version)
+	if err != nil {
+		return nil, &ErrorPayload{Code: ErrCodeInternalError, Message: err.Error()}
+	}
+
+	remoteMajor, _, err := ParseProtocolVersion(remote.Version)
+	if err != nil {
+		return nil, &ErrorPayload{
+			Code:    ErrCodeIncompatibleVersion,
+			Message: "malformed protocol version",
+			Details: err.Error(),
+		}
+	}
+
+	if localMajor != remoteMajor {
+		return nil, &ErrorPayload{
+			Code:    ErrCodeIncompatibleVersion,
+			Message: "incompatible protocol version",
+			Details: fmt.Sprintf("local %s, remote %s", local.Version, remote.Version),
+		}
+	}
+
+	if remote.GameVariant != local.GameVariant {
+		return nil, &ErrorPayload{
+			Code:    ErrCodeUnsupportedVariant,
+			Message: "game variant mismatch",
+			Details: fmt.Sprintf("table plays %s, peer requested %s", local.GameVariant, remote.GameVariant),
+		}
+	}
+
+	variantCap, err := VariantCapability(remote.GameVariant)
+	if err != nil || !remote.Capabilities.Has(variantCap) {
+		return nil, &ErrorPayload{
+			Code:    ErrCodeUnsupportedVariant,
+			Message: "peer does not support the table variant",
+			Details: remote.GameVariant,
+		}
+	}
+
+	shared := local.Capabilities & remote.Capabilities
+	if requireSigned && !shared.Has(CapSignedMessages) {
+		return nil, &ErrorPayload{
+			Code:    ErrCodeMissingCapability,
+			Message: "signed messages are required on this table",
+			Details: CapSignedMessages.String(),
+		}
+	}
+
+	return &NegotiatedSession{
+		PeerAddr:     remote.ListenAddr,
+		PeerVersion:  remote.Version,
+		GameVariant:  remote.GameVariant,
+		Capabilities: shared,
+		Relay:        remote.Relay && shared.Has(CapRelay),
+	}, nil
+}
@@ -22,6 +22,50 @@ const (
 	TypeError           MessageType = "error"
 	TypePing            MessageType = "ping"
 	TypePong            MessageType = "pong"
+	TypeChunk           MessageType = "chunk"
+	TypeTableInvite     MessageType = "table_invite"
+	TypeSeatDrawCommit  MessageType = "seat_draw_commit"
+	TypeSeatDrawReveal  MessageType = "seat_draw_reveal"
+	TypeAbortVote       MessageType = "abort_vote"
+	TypeChat            MessageType = "chat"
+	TypeSeat            MessageType = "seat"
+	TypeRebuy           MessageType = "rebuy"
+	TypeStraddle        MessageType = "straddle"
+	TypeRunItTwice      MessageType = "run_it_twice"
+	TypeSitOut          MessageType = "sit_out"
+	TypeShowHand        MessageType = "show_hand"
+	TypeMuckHand        MessageType = "muck_hand"
+	TypeRabbitHunt      MessageType = "rabbit_hunt"
+
+	// A folded player's partial decryptions of the board cards still to
+	// come; their keys follow once the hand is over (see game/fold_shares.go)
+	TypeFoldShares MessageType = "fold_shares"
+
+	// Signed agreement to a hand's escrow payout (see game/attestation.go)
+	TypeResultAttestation MessageType = "result_attestation"
+
+	// Signed session stacks after each hand (see game/channel.go)
+	TypeChannelState MessageType = "channel_state"
+
+	// A client that missed a state delta asks for the full state
+	TypeStateResync MessageType = "state_resync"
+
+	// A message forwarded by a relay between peers that cannot dial each other
+	TypeRelay MessageType = "relay"
+
+	// An operator paused or resumed the table, and a player's vote to do so
+	// (see game/pause.go)
+	TypeTablePause MessageType = "table_pause"
+	TypePauseVote  MessageType = "pause_vote"
+
+	// The table host used one of their powers, and a player's vote to use
+	// one while the host is away (see game/host.go)
+	TypeHostAction MessageType = "host_action"
+	TypeHostVote   MessageType = "host_vote"
+
+	// The sender's PlayerProfile, kept by peers under the address that
+	// signed it
+	TypeProfile MessageType = "profile"
 )
 
 // Message is the base message structure for all communications
@@ -30,6 +74,12 @@ type Message struct {
 	From      string          `json:"from"`
 	Payload   json.RawMessage `json:"payload"`
 	Timestamp time.Time       `json:"timestamp"`
+
+	// Optional signature envelope (see signing.go)
+	Sender    string `json:"sender,omitempty"`
+	Seq       uint64 `json:"seq,omitempty"`
+	Epoch     uint64 `json:"epoch,omitempty"` // the sender's process; seq restarts with it
+	Signature []byte `json:"signature,omitempty"`
 }
 
 // NewMessage creates a new message with the given type and payload
@@ -47,6 +97,17 @@ func NewMessage(from string, msgType MessageType, payload interface{}) (*Message
 	}, nil
 }
 
+// PeekType returns the type of a JSON-encoded message without decoding the payload
+func PeekType(data []byte) MessageType {
+	var head struct {
+		Type MessageType `json:"type"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return ""
+	}
+	return head.Type
+}
+
 // MarshalJSON custom marshaller to format timestamp
 func (m *Message) MarshalJSON() ([]byte, error) {
 	type Alias Message
@@ -85,9 +146,29 @@ func (m *Message) UnmarshalJSON(data []byte) error {
 
 // HandshakePayload represents the handshake message
 type HandshakePayload struct {
-	Version     string `json:"version"`
-	GameVariant string `json:"game_variant"`
-	ListenAddr  string `json:"listen_addr"`
+	Version      string     `json:"version"`
+	GameVariant  string     `json:"game_variant"`
+	ListenAddr   string     `json:"listen_addr"`
+	Capabilities Capability `json:"capabilities"`
+
+	// Set by nodes that forward messages between their peers (see RelayPayload)
+	Relay bool `json:"relay,omitempty"`
+
+	// The table's host, when the sender knows it; a node that has none
+	// yet takes it from the first peer that does
+	Host string `json:"host,omitempty"`
+
+	// The sender's player profile, if they have one
+	Profile *PlayerProfile `json:"profile,omitempty"`
+}
+
+// RelayPayload wraps a message for a peer reached through a relay. The
+// sender sets To; the relay sets From to the sender before forwarding, and
+// the inner message keeps the sender's own signature.
+type RelayPayload struct {
+	To      string          `json:"to"`
+	From    string          `json:"from,omitempty"`
+	Message json.RawMessage `json:"message"`
 }
 
 // PeerListPayload contains a list of connected peers
@@ -100,6 +181,9 @@ type PlayerActionPayload struct {
 	Action            string `json:"action"`
 	Value             int    `json:"value,omitempty"`
 	CurrentGameStatus string `json:"current_game_status"`
+
+	// Optional ID; an action is applied once per player and ID in a hand
+	ActionID string `json:"action_id,omitempty"`
 }
 
 // PlayerReadyPayload indicates a player is ready
@@ -141,11 +225,22 @@ type RPCResponsePayload struct {
 	DecryptedData [][]byte `json:"decrypted_data"`
 }
 
-// RevealKeysPayload contains encryption keys for verification
+// RevealKeysPayload contains encryption keys for verification, sent by
+// players who folded once the hand is over. Keys are generated per hand;
+// KeyEpoch is the hand number they were made for.
 type RevealKeysPayload struct {
 	EncryptionKey string `json:"encryption_key"`
 	DecryptionKey string `json:"decryption_key"`
 	Prime         string `json:"prime"`
+	KeyEpoch      int    `json:"key_epoch"`
+}
+
+// FoldSharesPayload is a folded player's layer taken off each board card
+// still to be dealt in hand KeyEpoch. Hole cards are never included.
+type FoldSharesPayload struct {
+	KeyEpoch      int      `json:"key_epoch"`
+	CardIndices   []int    `json:"card_indices"`
+	DecryptedData [][]byte `json:"decrypted_data"`
 }
 
 // ShowdownResultPayload contains showdown results
@@ -163,6 +258,158 @@ type ErrorPayload struct {
 	Details string `json:"details,omitempty"`
 }
 
+// ChunkPayload carries one piece of a message too large for a single frame
+type ChunkPayload struct {
+	ChunkID  string   `json:"chunk_id"`
+	Index    int      `json:"index"`
+	Total    int      `json:"total"`
+	Encoding Encoding `json:"encoding"`
+	Data     []byte   `json:"data"`
+}
+
+// TableInvitePayload notifies a player that a friend invited them to a table
+type TableInvitePayload struct {
+	InvitationID string `json:"invitation_id"`
+	From         string `json:"from"`
+	TableID      string `json:"table_id"`
+	JoinLink     string `json:"join_link"`
+	ExpiresAt    int64  `json:"expires_at"`
+}
+
+// SeatDrawCommitPayload commits to a player's seed for the seat draw (hex SHA-256)
+type SeatDrawCommitPayload struct {
+	Commitment string `json:"commitment"`
+}
+
+// SeatDrawRevealPayload reveals the seed behind a seat draw commitment (hex)
+type SeatDrawRevealPayload struct {
+	Seed string `json:"seed"`
+}
+
+// AbortVotePayload is a player's vote to void the session and refund escrow
+type AbortVotePayload struct {
+	Approve bool   `json:"approve"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// TablePausePayload pauses the table on the sender's operator's behalf, or
+// resumes the pause they started
+type TablePausePayload struct {
+	Paused bool   `json:"paused"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// PauseVotePayload is a player's vote to pause the table (Pause) or to
+// resume it
+type PauseVotePayload struct {
+	Pause   bool   `json:"pause"`
+	Approve bool   `json:"approve"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// HostActionPayload is a host power used by the sender, the table's host
+// (see HostAction*). Config is the new game.TableConfig.
+type HostActionPayload struct {
+	Action   string          `json:"action"`
+	PlayerID string          `json:"player_id,omitempty"`
+	Config   json.RawMessage `json:"config,omitempty"`
+}
+
+// HostVotePayload is a player's vote to use a host power while the host
+// is away
+type HostVotePayload struct {
+	HostActionPayload
+	Approve bool `json:"approve"`
+}
+
+// PlayerProfile is what a player shows of themselves instead of their
+// address: a display name, an avatar and the stakes they like to play
+type PlayerProfile struct {
+	Name            string  `json:"name"`
+	AvatarURL       string  `json:"avatar_url,omitempty"`
+	PreferredStakes *Stakes `json:"preferred_stakes,omitempty"`
+}
+
+// Stakes are a table's blinds
+type Stakes struct {
+	SmallBlind int `json:"small_blind"`
+	BigBlind   int `json:"big_blind"`
+}
+
+// ChatPayload is a table chat message
+type ChatPayload struct {
+	Text string `json:"text"`
+}
+
+// SeatPayload reserves, takes or gives up a numbered seat (see SeatAction*)
+type SeatPayload struct {
+	Seat   int    `json:"seat,omitempty"`
+	Action string `json:"action"`
+}
+
+// RebuyPayload adds chips to the sender's stack between hands, optionally
+// backed by the transaction that locked the funds on-chain
+type RebuyPayload struct {
+	Amount int    `json:"amount"`
+	TxHash string `json:"tx_hash,omitempty"`
+}
+
+// StraddlePayload sets whether the sender straddles when left of the big blind
+type StraddlePayload struct {
+	Enabled bool `json:"enabled"`
+}
+
+// RunItTwicePayload is the sender's answer to an offer to run the board twice
+type RunItTwicePayload struct {
+	Accept bool `json:"accept"`
+}
+
+// SitOutPayload takes the sender out of the deal or brings them back,
+// optionally waiting for the big blind instead of posting missed blinds
+type SitOutPayload struct {
+	SittingOut bool `json:"sitting_out"`
+	WaitForBB  bool `json:"wait_for_bb,omitempty"`
+}
+
+// ShowHandPayload turns the sender's hole cards face up at the showdown
+type ShowHandPayload struct {
+	HandUID string `json:"hand_uid"`
+}
+
+// MuckHandPayload gives up the sender's claim at the showdown without
+// showing their cards
+type MuckHandPayload struct {
+	HandUID string `json:"hand_uid"`
+}
+
+// RabbitHuntPayload is the sender's part in decrypting the board cards a
+// hand never dealt
+type RabbitHuntPayload struct {
+	HandUID     string `json:"hand_uid"`
+	CardIndices []int  `json:"card_indices"`
+}
+
+// ResultAttestationPayload carries the sender's signature over a hand's
+// escrow payout (see blockchain.SettlementDigest)
+type ResultAttestationPayload struct {
+	HandUID   string   `json:"hand_uid"`
+	GameID    string   `json:"game_id"`
+	Winners   []string `json:"winners"`
+	Amounts   []int    `json:"amounts"`
+	Signature string   `json:"signature"` // hex
+}
+
+// ChannelStatePayload carries the sender's signature over the session's
+// stacks after a hand (see blockchain.ChannelStateDigest)
+type ChannelStatePayload struct {
+	GameID    string   `json:"game_id"`
+	Seq       uint64   `json:"seq"`
+	HandUID   string   `json:"hand_uid"`
+	Players   []string `json:"players"`
+	Stacks    []int    `json:"stacks"`
+	Signature string   `json:"signature"` // hex
+}
+
 // PingPayload for connection health check
 type PingPayload struct {
 	Timestamp int64 `json:"timestamp"`
@@ -0,0 +1,210 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Signer signs message envelopes on behalf of the local node
+type Signer interface {
+	GetAddressHex() string
+	SignMessage(message []byte) ([]byte, error)
+}
+
+// VerifyFunc checks a signature over message against the claimed sender address
+type VerifyFunc func(message, signature []byte, sender string) bool
+
+// IsSigned returns true if the message carries a signature envelope
+func (m *Message) IsSigned() bool {
+	return m.Sender != "" && len(m.Signature) > 0
+}
+
+// SigningBytes returns the canonical bytes covered by the signature (type +
+// payload + seq, and the epoch when there is one)
+func (m *Message) SigningBytes() []byte {
+	data := make([]byte, 0, len(m.Type)+len(m.Payload)+18)
+	data = append(data, []byte(m.Type)...)
+	data = append(data, 0)
+	data = append(data, m.Payload...)
+	data = append(data, 0)
+
+	var seq [8]byte
+	binary.BigEndian.PutUint64(seq[:], m.Seq)
+	data = append(data, seq[:]...)
+	if m.Epoch != 0 {
+		var epoch [8]byte
+		binary.BigEndian.PutUint64(epoch[:], m.Epoch)
+		data = append(data, epoch[:]...)
+	}
+	return data
+}
+
+// MessageSigner attaches signature envelopes with a monotonic sequence
+// number. The sequence lives in memory, so each signer also stamps an epoch
+// taken when it is created: a restarted node starts a later epoch, and
+// peers accept its sequence starting again from 1.
+type MessageSigner struct {
+	signer Signer
+	epoch  uint64
+	seq    uint64
+	mu     sync.Mutex
+}
+
+// NewMessageSigner creates a signer for outbound messages
+func NewMessageSigner(signer Signer) *MessageSigner {
+	return &MessageSigner{signer: signer, epoch: uint64(time.Now().UnixNano())}
+}
+
+// Address returns the address messages are signed with
+func (ms *MessageSigner) Address() string {
+	return ms.signer.GetAddressHex()
+}
+
+// SignData signs arbitrary bytes with the same key as message envelopes
+func (ms *MessageSigner) SignData(data []byte) ([]byte, error) {
+	signature, err := ms.signer.SignMessage(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign data: %w", err)
+	}
+	return signature, nil
+}
+
+// Sign assigns the next sequence number and signs the message in place
+func (ms *MessageSigner) Sign(msg *Message) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	msg.Sender = ms.signer.GetAddressHex()
+	msg.Seq = ms.seq + 1
+	msg.Epoch = ms.epoch
+
+	signature, err := ms.signer.SignMessage(msg.SigningBytes())
+	if err != nil {
+		return fmt.Errorf("failed to sign message: %w", err)
+	}
+
+	msg.Signature = signature
+	ms.seq++
+	return nil
+}
+
+// ReplayGuard tracks the latest epoch and highest sequence number seen per sender
+type ReplayGuard struct {
+	last map[string]seqMark
+	mu   sync.Mutex
+}
+
+// seqMark is where a sender's messages have got to
+type seqMark struct {
+	epoch uint64
+	seq   uint64
+}
+
+// NewReplayGuard creates an empty replay guard
+func NewReplayGuard() *ReplayGuard {
+	return &ReplayGuard{
+		last: make(map[string]seqMark),
+	}
+}
+
+// Check accepts seq only if it is strictly greater than the last one seen
+// from sender in the same epoch. A later epoch starts the sequence afresh;
+// an earlier one is a replay from before the sender restarted.
+func (rg *ReplayGuard) Check(sender string, epoch, seq uint64) error {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	if last, ok := rg.last[sender]; ok {
+		if epoch < last.epoch {
+			return fmt.Errorf("replayed message from %s: epoch %d < %d", sender, epoch, last.epoch)
+		}
+		if epoch == last.epoch && seq <= last.seq {
+			return fmt.Errorf("replayed message from %s: seq %d <= %d", sender, seq, last.seq)
+		}
+	}
+
+	rg.last[sender] = seqMark{epoch: epoch, seq: seq}
+	return nil
+}
+
+// LastSeq returns the highest sequence number accepted from sender in their latest epoch
+func (rg *ReplayGuard) LastSeq(sender string) uint64 {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+	return rg.last[sender].seq
+}
+
+// MessageVerifier validates signature envelopes on inbound messages
+type MessageVerifier struct {
+	verify        VerifyFunc
+	guard         *ReplayGuard
+	requireSigned bool
+	bindings      map[string]string // connection ID -> signing address
+	mu            sync.Mutex
+}
+
+// NewMessageVerifier creates a verifier; if requireSigned is set unsigned messages are rejected
+func NewMessageVerifier(verify VerifyFunc, requireSigned bool) *MessageVerifier {
+	return &MessageVerifier{
+		verify:        verify,
+		guard:         NewReplayGuard(),
+		requireSigned: requireSigned,
+		bindings:      make(map[string]string),
+	}
+}
+
+// Verify checks the envelope of a message received over connection `from`.
+// The first signed message on a connection binds it to the signing address,
+// so a peer cannot later submit messages signed by somebody else.
+func (mv *MessageVerifier) Verify(from string, msg *Message) error {
+	if !msg.IsSigned() {
+		if mv.requireSigned {
+			return fmt.Errorf("%s: unsigned message from %s", ErrCodeInvalidSignature, from)
+		}
+		return nil
+	}
+
+	if !mv.verify(msg.SigningBytes(), msg.Signature, msg.Sender) {
+		return fmt.Errorf("%s: bad signature from %s", ErrCodeInvalidSignature, msg.Sender)
+	}
+
+	mv.mu.Lock()
+	bound, ok := mv.bindings[from]
+	if !ok {
+		mv.bindings[from] = msg.Sender
+		bound = msg.Sender
+	}
+	mv.mu.Unlock()
+
+	if bound != msg.Sender {
+		return fmt.Errorf("%s: connection %s is bound to %s, got %s", ErrCodeInvalidSignature, from, bound, msg.Sender)
+	}
+
+	if err := mv.guard.Check(msg.Sender, msg.Epoch, msg.Seq); err != nil {
+		return fmt.Errorf("%s: %v", ErrCodeReplayedMessage, err)
+	}
+
+	return nil
+}
+
+// RequiresSigned reports whether unsigned messages are rejected
+func (mv *MessageVerifier) RequiresSigned() bool {
+	return mv.requireSigned
+}
+
+// Unbind forgets the signing address bound to a connection
+func (mv *MessageVerifier) Unbind(from string) {
+	mv.mu.Lock()
+	defer mv.mu.Unlock()
+	delete(mv.bindings, from)
+}
+
+// SenderFor returns the signing address bound to a connection, if any
+func (mv *MessageVerifier) SenderFor(from string) (string, bool) {
+	mv.mu.Lock()
+	defer mv.mu.Unlock()
+	sender, ok := mv.bindings[from]
+	return sender, ok
+}
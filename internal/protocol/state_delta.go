@@ -0,0 +1,135 @@
+package protocol
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// GameStateDeltaEvent carries only what changed between two versions of
+// the table state. A client applies it to the state it holds at
+// BaseVersion; one holding any other version has missed an update and
+// should send a state_resync message for the full state.
+type GameStateDeltaEvent struct {
+	Version     uint64 `json:"version"`
+	BaseVersion uint64 `json:"base_version"`
+
+	Status         *string     `json:"status,omitempty"`
+	Pot            *int        `json:"pot,omitempty"`
+	HighestBet     *int        `json:"highest_bet,omitempty"`
+	CurrentTurn    *string     `json:"current_turn,omitempty"`
+	CommunityCards *[]CardData `json:"community_cards,omitempty"` // the whole board, when it changed
+
+	Players        []PlayerData `json:"players,omitempty"` // players that joined or changed
+	RemovedPlayers []string     `json:"removed_players,omitempty"`
+	PlayerOrder    []string     `json:"player_order,omitempty"` // when players joined, left or moved
+
+	Diff *StateDiff `json:"diff,omitempty"`
+}
+
+// NewStateDelta describes how to get from base to cur
+func NewStateDelta(base, cur GameStateUpdateEvent) GameStateDeltaEvent {
+	delta := GameStateDeltaEvent{
+		Version:     cur.Version,
+		BaseVersion: base.Version,
+		Diff:        cur.Diff,
+	}
+	if cur.Status != base.Status {
+		delta.Status = &cur.Status
+	}
+	if cur.Pot != base.Pot {
+		delta.Pot = &cur.Pot
+	}
+	if cur.HighestBet != base.HighestBet {
+		delta.HighestBet = &cur.HighestBet
+	}
+	if cur.CurrentTurn != base.CurrentTurn {
+		delta.CurrentTurn = &cur.CurrentTurn
+	}
+	if !reflect.DeepEqual(cur.CommunityCards, base.CommunityCards) {
+		board := cur.CommunityCards
+		if board == nil {
+			board = []CardData{}
+		}
+		delta.CommunityCards = &board
+	}
+
+	before := make(map[string]PlayerData, len(base.Players))
+	for _, p := range base.Players {
+		before[p.PlayerID] = p
+	}
+	order := make([]string, len(cur.Players))
+	reordered := len(cur.Players) != len(base.Players)
+	for i, p := range cur.Players {
+		order[i] = p.PlayerID
+		if !reordered && base.Players[i].PlayerID != p.PlayerID {
+			reordered = true
+		}
+		if old, ok := before[p.PlayerID]; !ok || old != p {
+			delta.Players = append(delta.Players, p)
+		}
+		delete(before, p.PlayerID)
+	}
+	for _, p := range base.Players {
+		if _, gone := before[p.PlayerID]; gone {
+			delta.RemovedPlayers = append(delta.RemovedPlayers, p.PlayerID)
+		}
+	}
+	if reordered {
+		delta.PlayerOrder = order
+	}
+	return delta
+}
+
+// Apply returns the state the delta leads to from base, which must be at
+// the delta's base version
+func (d GameStateDeltaEvent) Apply(base GameStateUpdateEvent) (GameStateUpdateEvent, error) {
+	if base.Version != d.BaseVersion {
+		return base, fmt.Errorf("state is at version %d, delta applies to %d", base.Version, d.BaseVersion)
+	}
+
+	state := base
+	state.Version = d.Version
+	state.Diff = d.Diff
+	if d.Status != nil {
+		state.Status = *d.Status
+	}
+	if d.Pot != nil {
+		state.Pot = *d.Pot
+	}
+	if d.HighestBet != nil {
+		state.HighestBet = *d.HighestBet
+	}
+	if d.CurrentTurn != nil {
+		state.CurrentTurn = *d.CurrentTurn
+	}
+	if d.CommunityCards != nil {
+		state.CommunityCards = append([]CardData{}, (*d.CommunityCards)...)
+	}
+
+	players := make(map[string]PlayerData, len(base.Players)+len(d.Players))
+	order := make([]string, 0, len(base.Players))
+	for _, p := range base.Players {
+		players[p.PlayerID] = p
+		order = append(order, p.PlayerID)
+	}
+	for _, p := range d.Players {
+		if _, ok := players[p.PlayerID]; !ok {
+			order = append(order, p.PlayerID)
+		}
+		players[p.PlayerID] = p
+	}
+	for _, id := range d.RemovedPlayers {
+		delete(players, id)
+	}
+	if d.PlayerOrder != nil {
+		order = d.PlayerOrder
+	}
+
+	state.Players = make([]PlayerData, 0, len(order))
+	for _, id := range order {
+		if p, ok := players[id]; ok {
+			state.Players = append(state.Players, p)
+		}
+	}
+	return state, nil
+}
@@ -2,8 +2,59 @@ package protocol
 
 import (
 	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"unicode"
 )
 
+// MaxChipAmount bounds any single chip value a player or operator sends, so
+// that sums over a table cannot overflow
+const MaxChipAmount = math.MaxInt32
+
+// MaxPlayerIDLength bounds player IDs (listen addresses or wallet addresses)
+const MaxPlayerIDLength = 255
+
+// MaxProfileNameLength and MaxAvatarURLLength bound a player's profile
+const (
+	MaxProfileNameLength = 32
+	MaxAvatarURLLength   = 512
+)
+
+// ValidateProfile checks a player's profile: a printable name, an http(s)
+// avatar URL and sensible stakes
+func ValidateProfile(profile PlayerProfile) error {
+	name := strings.TrimSpace(profile.Name)
+	if name == "" {
+		return fmt.Errorf("a display name is required")
+	}
+	if len([]rune(name)) > MaxProfileNameLength {
+		return fmt.Errorf("display name is longer than %d characters", MaxProfileNameLength)
+	}
+	for _, r := range name {
+		if !unicode.IsPrint(r) {
+			return fmt.Errorf("display name has unprintable characters")
+		}
+	}
+
+	if profile.AvatarURL != "" {
+		if len(profile.AvatarURL) > MaxAvatarURLLength {
+			return fmt.Errorf("avatar URL is longer than %d characters", MaxAvatarURLLength)
+		}
+		u, err := url.Parse(profile.AvatarURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("avatar URL must be an http or https URL")
+		}
+	}
+
+	if stakes := profile.PreferredStakes; stakes != nil {
+		if stakes.SmallBlind <= 0 || stakes.BigBlind < stakes.SmallBlind || stakes.BigBlind > MaxChipAmount {
+			return fmt.Errorf("preferred stakes %d/%d are invalid", stakes.SmallBlind, stakes.BigBlind)
+		}
+	}
+	return nil
+}
+
 // ValidateMessage validates a message structure
 func ValidateMessage(msg *Message) error {
 	if msg == nil {
@@ -38,7 +89,7 @@ func ValidatePlayerAction(action string) error {
 // ValidateGameVariant validates a game variant
 func ValidateGameVariant(variant string) error {
 	switch variant {
-	case GameVariantTexasHoldem, GameVariantOmaha, GameVariantSevenCard:
+	case GameVariantTexasHoldem, GameVariantOmaha, GameVariantOmahaHiLo, GameVariantSevenCard:
 		return nil
 	default:
 		return fmt.Errorf("invalid game variant: %s", variant)
@@ -58,15 +109,35 @@ func ValidateBetAmount(amount, minBet, maxBet int) error {
 	return nil
 }
 
-// ValidatePlayerID validates a player ID
+// ValidateChipAmount rejects negative amounts and amounts above MaxChipAmount
+func ValidateChipAmount(amount int) error {
+	if amount < 0 {
+		return fmt.Errorf("amount %d is negative", amount)
+	}
+
+	if amount > MaxChipAmount {
+		return fmt.Errorf("amount %d exceeds maximum %d", amount, MaxChipAmount)
+	}
+
+	return nil
+}
+
+// ValidatePlayerID validates a player ID: printable ASCII without spaces,
+// since IDs end up in logs, headers and file names
 func ValidatePlayerID(playerID string) error {
 	if playerID == "" {
 		return fmt.Errorf("player ID is empty")
 	}
 
-	if len(playerID) > 255 {
+	if len(playerID) > MaxPlayerIDLength {
 		return fmt.Errorf("player ID too long")
 	}
 
+	for i := 0; i < len(playerID); i++ {
+		if c := playerID[i]; c <= ' ' || c > '~' {
+			return fmt.Errorf("player ID contains invalid character at position %d", i)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/api"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// A client's send buffer counts as saturated from this fill level, in
+// percent, until it drains below it
+const saturatedPercent = 75
+
+// outboundQueue counts what happens to a client's outbound messages and
+// holds its latest state update. Only one state update waits in the send
+// buffer at a time; newer ones replace it, so a slow client skips straight
+// to the current table state instead of working through stale ones.
+type outboundQueue struct {
+	mu             sync.Mutex
+	enqueued       uint64
+	dropped        uint64
+	coalesced      uint64
+	highWater      int
+	saturatedSince time.Time
+
+	pendingState []byte
+	stateQueued  bool // a marker for pendingState is in the send buffer
+}
+
+// isStateUpdate reports whether a message only carries the table's state,
+// which a later one supersedes
+func isStateUpdate(data []byte) bool {
+	switch protocol.PeekType(data) {
+	case protocol.TypeGameState, protocol.MessageType(protocol.EventGameStateUpdate):
+		return true
+	}
+	return false
+}
+
+// coalesce keeps data as the pending state update. It reports true when a
+// marker is already queued and nothing more needs to go in the buffer.
+func (q *outboundQueue) coalesce(data []byte) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.pendingState = data
+	if q.stateQueued {
+		q.coalesced++
+		return true
+	}
+	q.stateQueued = true
+	return false
+}
+
+// takeState returns the state update a marker stands for
+func (q *outboundQueue) takeState() []byte {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	data := q.pendingState
+	q.pendingState = nil
+	q.stateQueued = false
+	return data
+}
+
+// queued notes a message that made it into the buffer, now holding depth
+// of capacity messages
+func (q *outboundQueue) queued(depth, capacity int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.enqueued++
+	if depth > q.highWater {
+		q.highWater = depth
+	}
+	if depth*100 < capacity*saturatedPercent {
+		q.saturatedSince = time.Time{}
+	} else if q.saturatedSince.IsZero() {
+		q.saturatedSince = time.Now()
+	}
+}
+
+// drop notes a message lost to a full buffer
+func (q *outboundQueue) drop(marker bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.dropped++
+	if marker {
+		q.pendingState = nil
+		q.stateQueued = false
+	}
+	if q.saturatedSince.IsZero() {
+		q.saturatedSince = time.Now()
+	}
+}
+
+// saturatedFor returns how long the buffer has stayed saturated, clearing
+// the mark if it has since drained
+func (q *outboundQueue) saturatedFor(depth, capacity int) time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if depth*100 < capacity*saturatedPercent {
+		q.saturatedSince = time.Time{}
+	}
+	if q.saturatedSince.IsZero() {
+		return 0
+	}
+	return time.Since(q.saturatedSince)
+}
+
+func (q *outboundQueue) stats(c *Client) api.ClientQueue {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := api.ClientQueue{
+		ClientID:  c.ID,
+		Peer:      c.IsPeer,
+		Spectator: c.IsSpectator,
+		Depth:     len(c.send),
+		Capacity:  cap(c.send),
+		HighWater: q.highWater,
+		Enqueued:  q.enqueued,
+		Dropped:   q.dropped,
+		Coalesced: q.coalesced,
+	}
+	if !q.saturatedSince.IsZero() {
+		stats.SaturatedSince = &q.saturatedSince
+	}
+	return stats
+}
+
+// SetSlowClientTimeout disconnects clients whose send buffer stays
+// saturated for longer than timeout (0 never does), so they go through
+// the disconnect flow and resync on reconnect rather than drift
+func (h *WebSocketHub) SetSlowClientTimeout(timeout time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.slowClientTimeout = timeout
+}
+
+// evictSlowClients closes the connections of clients saturated for too long
+func (h *WebSocketHub) evictSlowClients() {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.slowClientTimeout <= 0 {
+		return
+	}
+	for client := range h.clients {
+		saturated := client.queue.saturatedFor(len(client.send), cap(client.send))
+		if saturated < h.slowClientTimeout {
+			continue
+		}
+
+		h.log().WithFields(logrus.Fields{
+			"client_id": client.ID,
+			"peer":      client.IsPeer,
+			"saturated": saturated.Round(time.Second),
+			"dropped":   client.queue.stats(client).Dropped,
+		}).Warn("Disconnecting client that cannot keep up")
+		client.Close()
+	}
+}
+
+// ClientQueues returns the outbound queue of every connection
+func (h *WebSocketHub) ClientQueues() []api.ClientQueue {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	queues := make([]api.ClientQueue, 0, len(h.clients))
+	for client := range h.clients {
+		queues = append(queues, client.queue.stats(client))
+	}
+	return queues
+}
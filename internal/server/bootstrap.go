@@ -0,0 +1,223 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/api"
+	"github.com/RedPaladin7/peerpoker/internal/game"
+	"github.com/RedPaladin7/peerpoker/internal/transport"
+	"github.com/sirupsen/logrus"
+)
+
+// Redial delays for a bootstrap peer double from bootstrapMinBackoff up to
+// the configured maximum, and start over once a connection has held for
+// bootstrapStableAfter
+const (
+	bootstrapMinBackoff  = time.Second
+	bootstrapStableAfter = time.Minute
+)
+
+var errPeerDisconnected = errors.New("connection closed")
+
+// bootstrapper keeps the node connected to its bootstrap peers, redialling
+// with exponential backoff whenever a connection drops
+type bootstrapper struct {
+	hub        *WebSocketHub
+	game       *game.Game
+	localID    string
+	tlsConfig  *tls.Config // nil dials ws://
+	maxBackoff time.Duration
+	transport  transport.Transport // nil dials WebSocket peers into the hub
+
+	mu    sync.Mutex
+	peers map[string]*api.BootstrapPeer
+	order []string
+	stop  chan struct{}
+	wg    sync.WaitGroup
+}
+
+func newBootstrapper(addrs []string, hub *WebSocketHub, g *game.Game, localID string, tlsConfig *tls.Config, maxBackoff time.Duration) *bootstrapper {
+	b := &bootstrapper{
+		hub:        hub,
+		game:       g,
+		localID:    localID,
+		tlsConfig:  tlsConfig,
+		maxBackoff: maxBackoff,
+		peers:      make(map[string]*api.BootstrapPeer),
+		stop:       make(chan struct{}),
+	}
+	if b.maxBackoff < bootstrapMinBackoff {
+		b.maxBackoff = bootstrapMinBackoff
+	}
+	for _, addr := range addrs {
+		if _, dup := b.peers[addr]; dup {
+			continue
+		}
+		b.peers[addr] = &api.BootstrapPeer{Addr: addr}
+		b.order = append(b.order, addr)
+	}
+	return b
+}
+
+func (b *bootstrapper) Start() {
+	for _, addr := range b.order {
+		b.wg.Add(1)
+		go b.maintain(addr)
+	}
+	logrus.Infof("Connecting to %d bootstrap peer(s)", len(b.order))
+}
+
+func (b *bootstrapper) Stop() {
+	close(b.stop)
+	b.wg.Wait()
+}
+
+// maintain dials a bootstrap peer and redials it until stopped
+func (b *bootstrapper) maintain(addr string) {
+	defer b.wg.Done()
+
+	backoff := bootstrapMinBackoff
+	for {
+		connectedAt := time.Now()
+		err := b.connect(addr)
+		if time.Since(connectedAt) >= bootstrapStableAfter {
+			backoff = bootstrapMinBackoff
+		}
+
+		select {
+		case <-b.stop:
+			return
+		default:
+		}
+
+		b.update(addr, func(p *api.BootstrapPeer) {
+			p.Connected = false
+			p.ConnectedSince = nil
+			if err != nil {
+				p.LastError = err.Error()
+			}
+			next := time.Now().Add(backoff)
+			p.NextAttempt = &next
+		})
+		logrus.WithFields(logrus.Fields{
+			"peer":  addr,
+			"retry": backoff,
+		}).Warnf("Lost bootstrap peer: %v", err)
+
+		select {
+		case <-b.stop:
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > b.maxBackoff {
+			backoff = b.maxBackoff
+		}
+	}
+}
+
+// connect dials a peer and serves the connection until it drops
+func (b *bootstrapper) connect(addr string) error {
+	b.update(addr, func(p *api.BootstrapPeer) {
+		p.Attempts++
+		p.NextAttempt = nil
+	})
+
+	if b.transport != nil {
+		return b.connectTransport(addr)
+	}
+
+	conn, err := transport.DialPeerAs(peerURL(addr, b.tlsConfig != nil), b.tlsConfig, b.localID)
+	if err != nil {
+		return err
+	}
+
+	client := NewPeerClient(conn, b.hub, b.game, addr)
+	now := time.Now()
+	b.update(addr, func(p *api.BootstrapPeer) {
+		p.Connected = true
+		p.ConnectedSince = &now
+		p.LastError = ""
+	})
+
+	b.hub.Register <- client
+	go client.WritePump()
+	if err := b.game.SendHandshake(client.ID); err != nil {
+		logrus.Errorf("Failed to send handshake to bootstrap peer %s: %v", addr, err)
+	}
+
+	// Close the connection on shutdown so ReadPump returns
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-b.stop:
+			client.Close()
+		case <-done:
+		}
+	}()
+	client.ReadPump()
+	close(done)
+	return errPeerDisconnected
+}
+
+// connectTransport dials a peer over the P2P transport and waits until
+// the connection drops
+func (b *bootstrapper) connectTransport(addr string) error {
+	peer, err := b.transport.Dial(addr)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	b.update(addr, func(p *api.BootstrapPeer) {
+		p.Connected = true
+		p.ConnectedSince = &now
+		p.LastError = ""
+	})
+	if err := b.game.SendHandshake(peer); err != nil {
+		logrus.Errorf("Failed to send handshake to bootstrap peer %s: %v", addr, err)
+	}
+
+	select {
+	case <-b.transport.Disconnected(peer):
+	case <-b.stop:
+	}
+	return errPeerDisconnected
+}
+
+func (b *bootstrapper) update(addr string, fn func(*api.BootstrapPeer)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	fn(b.peers[addr])
+}
+
+// BootstrapPeers reports each bootstrap peer and whether it is live
+func (b *bootstrapper) BootstrapPeers() []api.BootstrapPeer {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	peers := make([]api.BootstrapPeer, 0, len(b.order))
+	for _, addr := range b.order {
+		peers = append(peers, *b.peers[addr])
+	}
+	return peers
+}
+
+// peerURL turns a bootstrap address into the peer endpoint URL, e.g.
+// host:3000 -> ws://host:3000/p2p. Full URLs are used as given.
+func peerURL(addr string, secure bool) string {
+	if strings.Contains(addr, "://") {
+		return addr
+	}
+	scheme := "ws"
+	if secure {
+		scheme = "wss"
+	}
+	if strings.HasPrefix(addr, ":") {
+		addr = "localhost" + addr
+	}
+	return scheme + "://" + addr + "/p2p"
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/RedPaladin7/peerpoker/internal/game"
 	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/RedPaladin7/peerpoker/internal/transport"
 	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
 )
@@ -34,6 +35,27 @@ type Client struct {
 	game   *game.Game
 	send   chan []byte
 	IsPeer bool
+
+	// Spectators only receive public table events and cannot send messages
+	IsSpectator bool
+
+	// Set for clients that requested reduced event frequency
+	throttle *eventThrottle
+
+	// Set for clients that asked for state updates as deltas (see state_delta.go)
+	deltas *stateDeltas
+
+	// Reassembles chunked messages received from this connection
+	chunks *transport.Reassembler
+
+	// Where the connection came from, for collusion analysis
+	RemoteAddr string
+
+	// Outbound queue counters and the coalesced state update (see backpressure.go)
+	queue outboundQueue
+
+	// Set for peers: latency and liveness from pings (see peer_health.go)
+	health *transport.PeerHealth
 }
 
 func NewClientFromHTTP(w http.ResponseWriter, r *http.Request, hub *WebSocketHub, g *game.Game, isPeer bool) (*Client, error) {
@@ -42,7 +64,12 @@ func NewClientFromHTTP(w http.ResponseWriter, r *http.Request, hub *WebSocketHub
 		return nil, err
 	}
 
+	// Browsers cannot set headers on a WebSocket upgrade, so they pass
+	// their ID in the query string
 	clientID := r.Header.Get("X-Client-ID")
+	if clientID == "" {
+		clientID = r.URL.Query().Get("client_id")
+	}
 	if clientID == "" {
 		clientID = r.RemoteAddr + "-" + time.Now().Format("20060102150405")
 	}
@@ -54,18 +81,66 @@ func NewClientFromHTTP(w http.ResponseWriter, r *http.Request, hub *WebSocketHub
 		game:   g,
 		send:   make(chan []byte, 256),
 		IsPeer: isPeer,
+		chunks: transport.NewReassembler(transport.ChunkTimeout),
+
+		RemoteAddr: r.RemoteAddr,
+	}
+
+	if isPeer {
+		client.health = transport.NewPeerHealth()
+	} else {
+		client.throttle = throttleFromRequest(r)
+		client.deltas = deltasFromRequest(r)
 	}
 
 	return client, nil
 }
 
+// NewPeerClient wraps a connection this node dialed to a peer
+func NewPeerClient(conn *websocket.Conn, hub *WebSocketHub, g *game.Game, peerID string) *Client {
+	return &Client{
+		ID:     peerID,
+		conn:   conn,
+		hub:    hub,
+		game:   g,
+		send:   make(chan []byte, 256),
+		IsPeer: true,
+		chunks: transport.NewReassembler(transport.ChunkTimeout),
+		health: transport.NewPeerHealth(),
+
+		RemoteAddr: conn.RemoteAddr().String(),
+	}
+}
+
+// NewSpectatorFromHTTP upgrades a read-only spectator connection
+func NewSpectatorFromHTTP(w http.ResponseWriter, r *http.Request, hub *WebSocketHub) (*Client, error) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Never reuse a player's ID, so targeted messages can't reach a spectator
+	clientID := "spectator-" + r.RemoteAddr + "-" + time.Now().Format("20060102150405")
+
+	client := &Client{
+		ID:          clientID,
+		conn:        conn,
+		hub:         hub,
+		send:        make(chan []byte, 256),
+		IsSpectator: true,
+		throttle:    throttleFromRequest(r),
+		deltas:      deltasFromRequest(r),
+	}
+	return client, nil
+}
+
 func (c *Client) ReadPump() {
 	defer func() {
-		logrus.Warnf("⚠️  Client %s connection closed", c.ID)
+		c.log().Warnf("⚠️  Client %s connection closed", c.ID)
 
 		// NEW: Notify game of disconnect BEFORE unregistering
 		if c.game != nil {
-			logrus.Warnf("Notifying game of player %s disconnect", c.ID)
+			c.log().Warnf("Notifying game of player %s disconnect", c.ID)
 			c.game.MonitorPlayerConnection(c.ID)
 		}
 
@@ -85,17 +160,61 @@ func (c *Client) ReadPump() {
 	})
 
 	for {
-		_, message, err := c.conn.ReadMessage()
+		frameType, message, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				logrus.Errorf("WebSocket error: %v", err)
+				c.log().Errorf("WebSocket error: %v", err)
 			}
 			break
 		}
 
-		if err := c.handleMessage(message); err != nil {
-			logrus.Errorf("Message handling error: %v", err)
+		if c.health != nil {
+			c.health.Seen()
+		}
+
+		if !c.IsPeer && protocol.PeekType(message) == protocol.TypeStateResync {
+			c.requestResync()
+			continue
+		}
+
+		if c.IsSpectator {
+			continue
+		}
+
+		if !c.IsPeer {
+			if !c.hub.allowMessage(c.ID) {
+				c.rejectRateLimited()
+				continue
+			}
+			c.hub.recordActivity(c.ID)
+		}
+
+		// Binary frames carry protobuf envelopes from peers, text frames JSON
+		encoding := protocol.EncodingJSON
+		if frameType == websocket.BinaryMessage {
+			encoding = protocol.EncodingProtobuf
 		}
+
+		if err := c.handleMessage(message, encoding); err != nil {
+			c.log().Errorf("Message handling error: %v", err)
+		}
+	}
+}
+
+// rejectRateLimited tells a player their message was dropped for exceeding
+// the message rate limit
+func (c *Client) rejectRateLimited() {
+	c.log().Debugf("Dropped message from %s: rate limited", c.ID)
+
+	msg, err := protocol.NewMessage("", protocol.TypeError, protocol.ErrorPayload{
+		Code:    protocol.ErrCodeRateLimited,
+		Message: "too many messages, slow down",
+	})
+	if err != nil {
+		return
+	}
+	if data, err := protocol.EncodeMessage(msg, protocol.EncodingJSON); err == nil {
+		c.enqueue(data)
 	}
 }
 
@@ -115,6 +234,20 @@ func (c *Client) WritePump() {
 				return
 			}
 
+			// Peers get one frame per message so they can be re-encoded and chunked
+			if c.IsPeer {
+				if err := c.writePeerMessages(message); err != nil {
+					return
+				}
+				continue
+			}
+
+			if message == nil {
+				if message = c.takeState(); message == nil {
+					continue
+				}
+			}
+
 			w, err := c.conn.NextWriter(websocket.TextMessage)
 			if err != nil {
 				return
@@ -125,8 +258,14 @@ func (c *Client) WritePump() {
 			// Add queued messages to current websocket message
 			n := len(c.send)
 			for i := 0; i < n; i++ {
+				next := <-c.send
+				if next == nil {
+					if next = c.takeState(); next == nil {
+						continue
+					}
+				}
 				w.Write([]byte{'\n'})
-				w.Write(<-c.send)
+				w.Write(next)
 			}
 
 			if err := w.Close(); err != nil {
@@ -142,25 +281,118 @@ func (c *Client) WritePump() {
 	}
 }
 
-func (c *Client) handleMessage(data []byte) error {
-	var msg protocol.Message
-	if err := json.Unmarshal(data, &msg); err != nil {
+// peerEncoding returns the wire encoding negotiated with this peer
+func (c *Client) peerEncoding() protocol.Encoding {
+	if !c.IsPeer || c.game == nil {
+		return protocol.EncodingJSON
+	}
+	return c.game.PeerEncoding(c.ID)
+}
+
+// writePeerMessages writes a queued message, and anything queued behind it,
+// in the negotiated encoding. Oversized messages are split into chunks.
+func (c *Client) writePeerMessages(message []byte) error {
+	n := len(c.send)
+	for i := 0; ; i++ {
+		if err := c.writePeerMessage(message); err != nil {
+			return err
+		}
+		if i == n {
+			return nil
+		}
+		message = <-c.send
+	}
+}
+
+func (c *Client) writePeerMessage(message []byte) error {
+	encoding := c.peerEncoding()
+	frameType, data := websocket.TextMessage, message
+
+	// Messages that fail to transcode go out as JSON
+	if encoding == protocol.EncodingProtobuf {
+		encoding = protocol.EncodingJSON
+		if msg, err := protocol.DecodeMessage(message, protocol.EncodingJSON); err == nil {
+			if encoded, err := protocol.EncodeMessage(msg, protocol.EncodingProtobuf); err == nil {
+				encoding, frameType, data = protocol.EncodingProtobuf, websocket.BinaryMessage, encoded
+			}
+		}
+	}
+
+	if !transport.NeedsChunking(data) {
+		c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+		return c.conn.WriteMessage(frameType, data)
+	}
+
+	chunks, err := transport.SplitMessage(data, encoding)
+	if err != nil {
+		c.log().Errorf("Dropping oversized message to %s: %v", c.ID, err)
+		return nil
+	}
+
+	for _, chunk := range chunks {
+		chunkData, err := protocol.EncodeMessage(chunk, encoding)
+		if err != nil {
+			return err
+		}
+		c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+		if err := c.conn.WriteMessage(frameType, chunkData); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) handleMessage(data []byte, encoding protocol.Encoding) error {
+	msg, err := protocol.DecodeMessage(data, encoding)
+	if err != nil {
+		return err
+	}
+
+	if msg.Type == protocol.TypeChunk {
+		return c.handleChunk(msg)
+	}
+
+	if handled, err := c.handlePing(msg); handled {
 		return err
 	}
 
-	logrus.WithFields(logrus.Fields{
+	// Chat from a player's own browser is relayed to peers; chat from peers is not
+	if msg.Type == protocol.TypeChat && !c.IsPeer {
+		var payload protocol.ChatPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return err
+		}
+		return c.game.PostChat(c.ID, payload.Text)
+	}
+
+	c.log().WithFields(logrus.Fields{
 		"from":    c.ID,
 		"type":    msg.Type,
 		"payload": len(msg.Payload),
 	}).Debug("Received message")
 
-	return c.game.HandleMessage(c.ID, &msg)
+	return c.game.HandleMessage(c.ID, msg)
+}
+
+// handleChunk buffers a chunk and handles the original message once complete
+func (c *Client) handleChunk(msg *protocol.Message) error {
+	var chunk protocol.ChunkPayload
+	if err := json.Unmarshal(msg.Payload, &chunk); err != nil {
+		return err
+	}
+
+	data, encoding, complete, err := c.chunks.Add(c.ID, chunk)
+	if err != nil || !complete {
+		return err
+	}
+
+	return c.handleMessage(data, encoding)
 }
 
 // NEW: HandleReconnect handles a player reconnection
 func (c *Client) HandleReconnect() error {
 	if c.game != nil {
-		logrus.Infof("✅ Player %s reconnected, notifying game", c.ID)
+		c.log().Infof("✅ Player %s reconnected, notifying game", c.ID)
 		return c.game.NotifyPlayerReconnected(c.ID)
 	}
 	return nil
@@ -192,6 +424,52 @@ func (c *Client) Send(data []byte) error {
 	}
 }
 
+// deliver queues data for the client, applying its event throttle if any.
+// Called by the hub; never blocks.
+func (c *Client) deliver(data []byte) {
+	if c.throttle != nil {
+		c.throttle.offer(data, c.enqueue)
+		return
+	}
+	c.enqueue(data)
+}
+
+// takeState returns the state update a queued marker stands for, as a
+// delta if the client asked for them
+func (c *Client) takeState() []byte {
+	data := c.queue.takeState()
+	if data == nil || c.deltas == nil {
+		return data
+	}
+	return c.deltas.encode(data)
+}
+
+// enqueue adds data to the send buffer. A state update for a player or
+// spectator goes in as a nil marker that the write pump swaps for the
+// latest one, unless a marker is already waiting.
+func (c *Client) enqueue(data []byte) {
+	marker := !c.IsPeer && isStateUpdate(data)
+	if marker {
+		if c.queue.coalesce(data) {
+			return
+		}
+		data = nil
+	}
+
+	select {
+	case c.send <- data:
+		c.queue.queued(len(c.send), cap(c.send))
+	default:
+		c.queue.drop(marker)
+		c.log().Warnf("Client %s send buffer full, dropping message", c.ID)
+	}
+}
+
+// log returns the table's logger tagged with this connection
+func (c *Client) log() *logrus.Entry {
+	return c.hub.log().WithField("client_id", c.ID)
+}
+
 func (c *Client) Close() {
 	c.conn.Close()
 }
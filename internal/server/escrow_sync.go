@@ -0,0 +1,211 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/blockchain"
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+)
+
+// escrowCursor names the store cursor marking the last block whose escrow
+// events are all in the ledger
+const escrowCursor = "escrow_events"
+
+// escrowSync keeps the FundsLocked cache and the store's ledger in step
+// with the escrow contracts. Events read twice, live and by the backfill,
+// are recorded once.
+type escrowSync struct {
+	cache *blockchain.FundsLockedCache
+	store persistence.Store // nil keeps the cache in memory only
+
+	mu     sync.Mutex
+	cursor uint64
+	live   bool // the backfill has caught up, so live events move the cursor
+}
+
+// load fills the cache from the ledger and returns the block to backfill
+// from: the one after the stored cursor, or fromBlock on first boot
+func (es *escrowSync) load(fromBlock uint64) uint64 {
+	if es.store == nil {
+		return fromBlock
+	}
+
+	entries, err := es.store.LedgerEntries("")
+	if err != nil {
+		logrus.Errorf("Failed to load escrow events from the ledger: %v", err)
+		return fromBlock
+	}
+	loaded := 0
+	for _, entry := range entries {
+		gameID, err := blockchain.HexToGameID(entry.GameID)
+		if err != nil {
+			continue
+		}
+		switch entry.Kind {
+		case persistence.LedgerChainLock:
+			amount, ok := new(big.Int).SetString(entry.Wei, 10)
+			if !ok {
+				continue
+			}
+			es.cache.Add(&blockchain.FundsLockedEvent{
+				GameID:      gameID,
+				Player:      common.HexToAddress(entry.PlayerID),
+				Amount:      amount,
+				BlockNumber: entry.Block,
+				TxHash:      common.HexToHash(entry.TxHash),
+			})
+		case persistence.LedgerChainRelease:
+			es.cache.End(&blockchain.GameEndedEvent{
+				GameID:      gameID,
+				BlockNumber: entry.Block,
+				TxHash:      common.HexToHash(entry.TxHash),
+			})
+		default:
+			continue
+		}
+		loaded++
+	}
+
+	cursor, ok, err := es.store.Cursor(escrowCursor)
+	if err != nil {
+		logrus.Errorf("Failed to read escrow event cursor: %v", err)
+		return fromBlock
+	}
+	logrus.WithFields(logrus.Fields{
+		"events": loaded,
+		"cursor": cursor,
+	}).Info("Loaded escrow events from the ledger")
+	if !ok {
+		return fromBlock
+	}
+	es.cursor = cursor
+	return cursor + 1
+}
+
+// follow records escrow events as the listener sees them, until ctx is done
+func (es *escrowSync) follow(ctx context.Context, el *blockchain.EventListener) {
+	locked := make(chan interface{}, 10)
+	ended := make(chan interface{}, 10)
+	el.Subscribe("FundsLocked", locked)
+	el.Subscribe("GameEnded", ended)
+
+	go func() {
+		for {
+			select {
+			case event := <-locked:
+				if fundsLocked, ok := event.(*blockchain.FundsLockedEvent); ok {
+					es.fundsLocked(fundsLocked)
+					es.advance(fundsLocked.BlockNumber)
+				}
+			case event := <-ended:
+				if gameEnded, ok := event.(*blockchain.GameEndedEvent); ok {
+					es.gameEnded(gameEnded)
+					es.advance(gameEnded.BlockNumber)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// backfill records the events logged since fromBlock, which the node
+// missed while it was down, then moves the cursor to the head it read to
+func (es *escrowSync) backfill(el *blockchain.EventListener, fromBlock uint64) error {
+	backfill, err := el.Backfill(fromBlock)
+	if err != nil {
+		return err
+	}
+
+	added := 0
+	for i := range backfill.FundsLocked {
+		if es.fundsLocked(&backfill.FundsLocked[i]) {
+			added++
+		}
+	}
+	for i := range backfill.GameEnded {
+		if es.gameEnded(&backfill.GameEnded[i]) {
+			added++
+		}
+	}
+
+	es.mu.Lock()
+	es.live = true
+	es.mu.Unlock()
+	es.save(backfill.ToBlock)
+
+	logrus.WithFields(logrus.Fields{
+		"from_block": fromBlock,
+		"to_block":   backfill.ToBlock,
+		"locked":     len(backfill.FundsLocked),
+		"ended":      len(backfill.GameEnded),
+		"new":        added,
+	}).Info("Backfilled escrow events")
+	return nil
+}
+
+func (es *escrowSync) fundsLocked(event *blockchain.FundsLockedEvent) bool {
+	if !es.cache.Add(event) {
+		return false
+	}
+	es.record(persistence.LedgerEntry{
+		PlayerID: event.Player.Hex(),
+		Kind:     persistence.LedgerChainLock,
+		GameID:   fmt.Sprintf("0x%x", event.GameID),
+		TxHash:   event.TxHash.Hex(),
+		Block:    event.BlockNumber,
+		Wei:      event.Amount.String(),
+	})
+	return true
+}
+
+func (es *escrowSync) gameEnded(event *blockchain.GameEndedEvent) bool {
+	if !es.cache.End(event) {
+		return false
+	}
+	es.record(persistence.LedgerEntry{
+		Kind:   persistence.LedgerChainRelease,
+		GameID: fmt.Sprintf("0x%x", event.GameID),
+		TxHash: event.TxHash.Hex(),
+		Block:  event.BlockNumber,
+	})
+	return true
+}
+
+func (es *escrowSync) record(entry persistence.LedgerEntry) {
+	if es.store == nil {
+		return
+	}
+	entry.At = time.Now()
+	if err := es.store.AppendLedgerEntry(entry); err != nil {
+		logrus.Warnf("Failed to record %s event %s in ledger: %v", entry.Kind, entry.TxHash, err)
+	}
+}
+
+// advance moves the cursor up to the block before a live event's, since
+// more events from the event's own block may still be on their way
+func (es *escrowSync) advance(block uint64) {
+	if block == 0 {
+		return
+	}
+	es.save(block - 1)
+}
+
+func (es *escrowSync) save(block uint64) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	if !es.live || block <= es.cursor || es.store == nil {
+		return
+	}
+	es.cursor = block
+	if err := es.store.SaveCursor(escrowCursor, block); err != nil {
+		logrus.Warnf("Failed to save escrow event cursor: %v", err)
+	}
+}
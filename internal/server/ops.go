@@ -0,0 +1,71 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/blockchain"
+	"github.com/RedPaladin7/peerpoker/internal/config"
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+)
+
+// WALFileName is the write-ahead log's file in the state directory
+const WALFileName = "hands.wal"
+
+// ChainConfig returns the blockchain client settings from cfg
+func ChainConfig(cfg *config.Config) *blockchain.Config {
+	return &blockchain.Config{
+		RPCURL:                 cfg.BlockchainRPCURL,
+		PrivateKey:             cfg.BlockchainPrivateKey,
+		PokerTableAddress:      cfg.ContractPokerTable,
+		PotManagerAddress:      cfg.ContractPotManager,
+		PlayerRegistryAddress:  cfg.ContractPlayerRegistry,
+		DisputeResolverAddress: cfg.ContractDisputeResolver,
+	}
+}
+
+// OpenBackups returns a backup manager for the configured target, without
+// starting its schedule
+func OpenBackups(cfg *config.Config) (*persistence.BackupManager, error) {
+	var backend persistence.BackupBackend
+	switch cfg.BackupTarget {
+	case "local":
+		backend = persistence.NewLocalBackend(cfg.BackupDir)
+	case "s3":
+		s3, err := persistence.NewS3Backend(persistence.S3Config{
+			Endpoint:  cfg.BackupS3Endpoint,
+			Region:    cfg.BackupS3Region,
+			Bucket:    cfg.BackupS3Bucket,
+			Prefix:    cfg.BackupS3Prefix,
+			AccessKey: cfg.BackupS3AccessKey,
+			SecretKey: cfg.BackupS3SecretKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		backend = s3
+	case "":
+		return nil, fmt.Errorf("no backup target configured")
+	default:
+		return nil, fmt.Errorf("unknown backup target %q", cfg.BackupTarget)
+	}
+
+	// A retention policy replaces the simple keep count
+	keep := cfg.BackupKeep
+	if retentionPolicy(cfg).Enabled() {
+		keep = 0
+	}
+
+	backups := persistence.NewBackupManagerWithBackend(backend, keep, cfg.BackupCompress)
+	backups.SetMaxAge(time.Duration(cfg.BackupMaxAgeHours) * time.Hour)
+	return backups, nil
+}
+
+func retentionPolicy(cfg *config.Config) persistence.RetentionPolicy {
+	return persistence.RetentionPolicy{
+		KeepLast:   cfg.RetentionKeepLast,
+		KeepHourly: cfg.RetentionKeepHourly,
+		KeepDaily:  cfg.RetentionKeepDaily,
+		KeepWeekly: cfg.RetentionKeepWeekly,
+	}
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/json"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/RedPaladin7/peerpoker/internal/transport"
+	"github.com/sirupsen/logrus"
+)
+
+// setupTransport prepares the configured P2P transport. WebSocket peers
+// are served by the hub on /p2p, so only other transports need one.
+func (s *Server) setupTransport() {
+	name := s.config.P2PTransport
+	if name == "" || name == transport.TransportWebSocket {
+		return
+	}
+
+	opts := transport.Options{LocalID: s.listenAddr, ListenTLS: s.tlsConfig}
+	if s.tlsConfig != nil {
+		dialConfig, err := s.tlsSettings().DialConfig()
+		if err != nil {
+			logrus.Errorf("P2P transport %s disabled: %v", name, err)
+			return
+		}
+		opts.DialTLS = dialConfig
+	}
+
+	p2p, err := transport.New(name, opts)
+	if err != nil {
+		logrus.Errorf("P2P transport %s disabled, peers must use WebSocket: %v", name, err)
+		return
+	}
+	p2p.OnMessage(s.handleTransportMessage)
+	s.p2p = p2p
+}
+
+// handleTransportMessage passes a message from a transport peer to the game
+func (s *Server) handleTransportMessage(peer string, data []byte) {
+	encoding := protocol.EncodingJSON
+	if len(data) > 0 && data[0] != '{' {
+		encoding = protocol.EncodingProtobuf
+	}
+	msg, err := protocol.DecodeMessage(data, encoding)
+	if err != nil {
+		logrus.Warnf("Dropped malformed message from %s: %v", peer, err)
+		return
+	}
+
+	if msg.Type == protocol.TypePing {
+		var ping protocol.PingPayload
+		if err := json.Unmarshal(msg.Payload, &ping); err == nil {
+			s.sendTransport(peer, protocol.TypePong, transport.Pong(ping))
+		}
+		return
+	}
+	if msg.Type == protocol.TypePong {
+		return
+	}
+
+	if err := s.game.HandleMessage(peer, msg); err != nil {
+		logrus.Errorf("Message handling error: %v", err)
+	}
+}
+
+func (s *Server) sendTransport(peer string, msgType protocol.MessageType, payload interface{}) {
+	msg, err := protocol.NewMessage(s.listenAddr, msgType, payload)
+	if err != nil {
+		return
+	}
+	if data, err := protocol.EncodeMessage(msg, protocol.EncodingJSON); err == nil {
+		s.sendOverTransport(data, []string{peer})
+	}
+}
+
+// sendOverTransport sends a message to the targets connected over the P2P
+// transport, or to all of them when there are no targets, in the encoding
+// negotiated with each. It returns the targets it did not reach.
+func (s *Server) sendOverTransport(data []byte, targets []string) []string {
+	connected := make(map[string]bool)
+	for _, peer := range s.p2p.Peers() {
+		connected[peer] = true
+	}
+
+	if len(targets) == 0 {
+		for peer := range connected {
+			s.sendEncoded(peer, data)
+		}
+		return nil
+	}
+
+	remaining := make([]string, 0, len(targets))
+	for _, target := range targets {
+		if !connected[target] {
+			remaining = append(remaining, target)
+			continue
+		}
+		s.sendEncoded(target, data)
+	}
+	return remaining
+}
+
+func (s *Server) sendEncoded(peer string, data []byte) {
+	if s.game.PeerEncoding(peer) == protocol.EncodingProtobuf {
+		if msg, err := protocol.DecodeMessage(data, protocol.EncodingJSON); err == nil {
+			if encoded, err := protocol.EncodeMessage(msg, protocol.EncodingProtobuf); err == nil {
+				data = encoded
+			}
+		}
+	}
+	if err := s.p2p.Send(peer, data); err != nil {
+		logrus.Warnf("Failed to send to %s over %s: %v", peer, s.p2p.Name(), err)
+	}
+}
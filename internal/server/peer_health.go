@@ -0,0 +1,124 @@
+package server
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/api"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/RedPaladin7/peerpoker/internal/transport"
+	"github.com/sirupsen/logrus"
+)
+
+// SetPeerHealth pings every peer each interval (0 never does) and
+// disconnects peers not heard from for longer than timeout (0 never does)
+func (h *WebSocketHub) SetPeerHealth(interval, timeout time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.peerPingInterval = interval
+	h.peerTimeout = timeout
+}
+
+// checkPeers closes the connections of silent peers and pings the rest
+// when a ping is due
+func (h *WebSocketHub) checkPeers() {
+	h.mu.Lock()
+	ping := h.peerPingInterval > 0 && time.Since(h.lastPeerPing) >= h.peerPingInterval
+	if ping {
+		h.lastPeerPing = time.Now()
+	}
+	timeout := h.peerTimeout
+	peers := make([]*Client, 0)
+	for client := range h.clients {
+		if client.health != nil {
+			peers = append(peers, client)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, peer := range peers {
+		silent := peer.health.SilentFor()
+		if timeout > 0 && silent > timeout {
+			h.log().WithFields(logrus.Fields{
+				"peer_id": peer.ID,
+				"silent":  silent.Round(time.Second),
+			}).Warn("Disconnecting silent peer")
+			peer.Close()
+			continue
+		}
+		if ping {
+			peer.sendPing()
+		}
+	}
+}
+
+// PeerLinks returns the latency of every connected peer
+func (h *WebSocketHub) PeerLinks() []api.PeerLink {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	links := make([]api.PeerLink, 0)
+	for client := range h.clients {
+		if client.health == nil {
+			continue
+		}
+		stats := client.health.Stats()
+		links = append(links, api.PeerLink{
+			PeerID:    client.ID,
+			RTTMs:     float64(stats.RTT) / float64(time.Millisecond),
+			LastSeen:  stats.LastSeen,
+			PingsSent: stats.PingsSent,
+			Pongs:     stats.Pongs,
+		})
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].PeerID < links[j].PeerID })
+	return links
+}
+
+func (c *Client) sendPing() {
+	c.sendControl(protocol.TypePing, c.health.NewPing())
+}
+
+// handlePing answers a ping, or records the round trip of a pong to one of
+// our pings. It reports false for any other message.
+func (c *Client) handlePing(msg *protocol.Message) (bool, error) {
+	switch msg.Type {
+	case protocol.TypePing:
+		var ping protocol.PingPayload
+		if err := json.Unmarshal(msg.Payload, &ping); err != nil {
+			return true, err
+		}
+		c.sendControl(protocol.TypePong, transport.Pong(ping))
+		return true, nil
+
+	case protocol.TypePong:
+		if c.health == nil {
+			return true, nil
+		}
+		var pong protocol.PongPayload
+		if err := json.Unmarshal(msg.Payload, &pong); err != nil {
+			return true, err
+		}
+		rtt, err := c.health.RecordPong(pong)
+		if err != nil {
+			return true, err
+		}
+		c.log().WithFields(logrus.Fields{
+			"peer_id": c.ID,
+			"rtt":     rtt,
+		}).Debug("Peer pong")
+		return true, nil
+	}
+	return false, nil
+}
+
+func (c *Client) sendControl(msgType protocol.MessageType, payload interface{}) {
+	msg, err := protocol.NewMessage("", msgType, payload)
+	if err != nil {
+		return
+	}
+	if data, err := protocol.EncodeMessage(msg, protocol.EncodingJSON); err == nil {
+		c.enqueue(data)
+	}
+}
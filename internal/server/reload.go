@@ -0,0 +1,106 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/api"
+	"github.com/RedPaladin7/peerpoker/internal/config"
+	"github.com/RedPaladin7/peerpoker/internal/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// SetConfigLoader sets how ReloadConfig reads the configuration again,
+// e.g. the config file with the command line flags reapplied. Without one
+// the environment is read.
+func (s *Server) SetConfigLoader(load func() (*config.Config, error)) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	s.loadConfig = load
+}
+
+// ReloadConfig reads the configuration again and applies the settings that
+// can change at runtime: log level and format, rake, action timer,
+// disconnect timeout, rate limits and client and peer timeouts. Other
+// changes are reported but wait for a restart. An invalid config changes
+// nothing.
+func (s *Server) ReloadConfig(actor string) (api.ConfigReload, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	load := s.loadConfig
+	if load == nil {
+		load = func() (*config.Config, error) { return config.Load("") }
+	}
+	loaded, err := load()
+	if err != nil {
+		return api.ConfigReload{}, fmt.Errorf("config not reloaded: %w", err)
+	}
+
+	s.mu.RLock()
+	running := s.config
+	s.mu.RUnlock()
+
+	apply, restart := config.Changes(running, loaded)
+	result := api.ConfigReload{Applied: apply, Restart: restart}
+	if len(apply) > 0 {
+		next := running.WithReloadable(loaded)
+		if err := s.applyRuntimeConfig(next); err != nil {
+			return api.ConfigReload{}, fmt.Errorf("config not reloaded: %w", err)
+		}
+		s.mu.Lock()
+		s.config = next
+		s.mu.Unlock()
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"actor":            actor,
+		"applied":          apply,
+		"restart_required": restart,
+	}).Info("Configuration reloaded")
+	if s.auditLog != nil {
+		if _, err := s.auditLog.Append(s.listenAddr, "config_reload", actor, "", result); err != nil {
+			logrus.Errorf("Failed to audit config reload: %v", err)
+		}
+	}
+	return result, nil
+}
+
+// applyRuntimeConfig pushes the reloadable settings to the running
+// components. The table options are checked first, so a rejected change
+// leaves everything as it was.
+func (s *Server) applyRuntimeConfig(cfg *config.Config) error {
+	level, err := logrus.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return err
+	}
+
+	tableConfig := s.game.TableConfig()
+	tableConfig.Rake.Percent = cfg.RakePercent
+	tableConfig.Rake.Cap = cfg.RakeCap
+	tableConfig.Rake.NoFlopNoDrop = cfg.RakeNoFlopNoDrop
+	tableConfig.ActionSeconds = cfg.ActionSeconds
+	tableConfig.TimeBankSeconds = cfg.TimeBankSeconds
+	if err := s.game.SetTableConfig(tableConfig); err != nil {
+		return err
+	}
+
+	logrus.SetLevel(level)
+	if err := logging.SetFormat(cfg.LogFormat); err != nil {
+		logrus.Errorf("Log format not changed: %v", err)
+	}
+
+	s.game.DisconnectHandler.SetTimeout(time.Duration(cfg.DisconnectTimeout) * time.Second)
+	s.game.SetLatencyBudget(time.Duration(cfg.ActionLatencyBudget) * time.Millisecond)
+
+	s.hub.SetRateLimit(api.RateLimit{RPS: float64(cfg.RateLimitWSRPS), Burst: cfg.RateLimitWSBurst})
+	s.hub.SetSlowClientTimeout(time.Duration(cfg.WSSlowClientTimeout) * time.Second)
+	s.hub.SetPeerHealth(time.Duration(cfg.PingInterval)*time.Second, time.Duration(cfg.PeerTimeout)*time.Second)
+	if s.apiHandler != nil {
+		s.apiHandler.SetRateLimits(
+			api.RateLimit{RPS: float64(cfg.RateLimitActionRPS), Burst: cfg.RateLimitActionBurst},
+			api.RateLimit{RPS: float64(cfg.RateLimitReadRPS), Burst: cfg.RateLimitReadBurst},
+		)
+	}
+	return nil
+}
@@ -2,16 +2,32 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math/big"
 	"net/http"
-	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
 	"github.com/RedPaladin7/peerpoker/internal/api"
 	"github.com/RedPaladin7/peerpoker/internal/blockchain"
 	"github.com/RedPaladin7/peerpoker/internal/config"
+	"github.com/RedPaladin7/peerpoker/internal/deck"
+	"github.com/RedPaladin7/peerpoker/internal/detection"
+	"github.com/RedPaladin7/peerpoker/internal/friends"
 	"github.com/RedPaladin7/peerpoker/internal/game"
+	"github.com/RedPaladin7/peerpoker/internal/insurance"
+	"github.com/RedPaladin7/peerpoker/internal/lobby"
+	"github.com/RedPaladin7/peerpoker/internal/logging"
+	persistence "github.com/RedPaladin7/peerpoker/internal/persistance"
+	"github.com/RedPaladin7/peerpoker/internal/presence"
+	"github.com/RedPaladin7/peerpoker/internal/profile"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/RedPaladin7/peerpoker/internal/transport"
+	"github.com/RedPaladin7/peerpoker/internal/web"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 )
@@ -24,27 +40,47 @@ type Server struct {
 	peerManager *PeerManager
 	game        *game.Game
 	blockchain  *blockchain.BlockchainClient
+	presence    *presence.Service
+	friends     *friends.Service
+	insurance   *insurance.Fund
+	tables      *lobby.TableManager
+	lobby       *lobby.Lobby
+	recovery    *persistence.RecoveryManager
+	wal         *persistence.WAL
+	store       persistence.Store
+	snapshots   *persistence.SnapshotManager
+	backups     *persistence.BackupManager
+	retention   *persistence.RetentionScheduler
+	auditLog    *persistence.AuditLog
+	collusion   *detection.CollusionMonitor
+	escrow      *blockchain.FundsLockedCache
+	stopEscrow  context.CancelFunc
+	chainHealth *blockchain.HealthMonitor
+	bootstrap   *bootstrapper
+	p2p         transport.Transport // nil when peers use the hub's WebSocket endpoint
+	apiHandler  *api.Handler
+	loadConfig  func() (*config.Config, error)
+	reloadMu    sync.Mutex
 	mu          sync.RWMutex
+	wsServer    *http.Server
+	apiServer   *http.Server
+	tlsConfig   *tls.Config // nil serves plain http and ws
 	running     bool
+	stopping    bool
 }
 
+// listenerShutdownGrace is how long in-flight requests get when the
+// shutdown deadline has already passed
+const listenerShutdownGrace = 5 * time.Second
+
 func NewServer(cfg *config.Config) *Server {
 	// Initialize blockchain client if enabled
 	var bc *blockchain.BlockchainClient
-	if os.Getenv("BLOCKCHAIN_ENABLED") == "true" {
+	if cfg.BlockchainEnabled {
 		logrus.Info("Blockchain integration enabled, initializing client...")
 
-		bcConfig := &blockchain.Config{
-			RPCURL:                 os.Getenv("BLOCKCHAIN_RPC_URL"),
-			PrivateKey:             os.Getenv("BLOCKCHAIN_PRIVATE_KEY"),
-			PokerTableAddress:      os.Getenv("CONTRACT_POKER_TABLE"),
-			PotManagerAddress:      os.Getenv("CONTRACT_POT_MANAGER"),
-			PlayerRegistryAddress:  os.Getenv("CONTRACT_PLAYER_REGISTRY"),
-			DisputeResolverAddress: os.Getenv("CONTRACT_DISPUTE_RESOLVER"),
-		}
-
 		var err error
-		bc, err = blockchain.NewBlockchainClient(bcConfig)
+		bc, err = blockchain.NewBlockchainClient(ChainConfig(cfg))
 		if err != nil {
 			logrus.Warnf("Failed to initialize blockchain client: %v", err)
 			logrus.Warn("Continuing without blockchain integration")
@@ -69,22 +105,462 @@ func NewServer(cfg *config.Config) *Server {
 		blockchain: bc,
 	}
 
+	// Everything at this table logs with its table ID
+	tableLog := logging.ForTable(s.listenAddr)
+
 	s.hub = NewWebSocketHub(s)
+	s.hub.SetLogger(tableLog)
 	s.peerManager = NewPeerManager(s)
 
+	awayAfter := time.Duration(cfg.PresenceAwayAfter) * time.Second
+	s.presence = presence.NewService(presence.NewFileStore(cfg.PresenceFile), awayAfter)
+	s.hub.SetPresence(s.presence, s.listenAddr)
+	s.hub.SetRateLimit(api.RateLimit{RPS: float64(cfg.RateLimitWSRPS), Burst: cfg.RateLimitWSBurst})
+	s.hub.SetSlowClientTimeout(time.Duration(cfg.WSSlowClientTimeout) * time.Second)
+	s.hub.SetPeerHealth(time.Duration(cfg.PingInterval)*time.Second, time.Duration(cfg.PeerTimeout)*time.Second)
+	s.friends = friends.NewService(cfg.FriendsFile, s.deliverInvitation)
+
+	// Compensation is paid from the operator wallet when the chain is available
+	var payer insurance.Payer
+	if bc != nil {
+		payer = bc
+	}
+	s.insurance = insurance.NewFund(cfg.InsuranceFundFile, payer)
+
 	// Pass blockchain client to game
 	s.game = game.NewGame(cfg.ListenAddr, s.broadcastToPlayers, bc)
+	s.game.SetLogger(tableLog)
+
+	// Table events go to player WebSockets only
+	s.game.SetEventFunc(s.hub.BroadcastEvent)
+	s.game.SetIncidentDir(cfg.IncidentDir)
+	s.game.SetSeating(cfg.MaxPlayers, time.Duration(cfg.SeatReservationSeconds)*time.Second)
+	s.game.SetBuyInLimits(cfg.MinBuyIn, cfg.MaxBuyIn, cfg.RebuyRequireFundsLocked)
+	s.game.SetShuffleAuditPublish(cfg.ShuffleAuditPublish)
+	s.game.SetProfiles(profile.NewStore(cfg.ProfilesFile))
+	s.game.DisconnectHandler.SetTimeout(time.Duration(cfg.DisconnectTimeout) * time.Second)
+	s.game.SetLatencyBudget(time.Duration(cfg.ActionLatencyBudget) * time.Millisecond)
+	if err := s.game.SetTableConfig(game.TableConfig{
+		Ante:       cfg.Ante,
+		Straddle:   cfg.AllowStraddle,
+		RunItTwice: cfg.AllowRunItTwice,
+		RabbitHunt: cfg.RabbitHunt,
+		HiLo:       cfg.GameVariant == protocol.GameVariantOmahaHiLo,
+
+		ActionSeconds:   cfg.ActionSeconds,
+		TimeBankSeconds: cfg.TimeBankSeconds,
+		Rake: game.RakeConfig{
+			Percent:      cfg.RakePercent,
+			Cap:          cfg.RakeCap,
+			NoFlopNoDrop: cfg.RakeNoFlopNoDrop,
+			Recipient:    cfg.RakeRecipient,
+		},
+		SettlementBatch: cfg.SettlementBatchSize,
+		StateChannel:    cfg.StateChannel,
+	}); err != nil {
+		logrus.Errorf("Invalid table config: %v", err)
+	}
+
+	s.tables = lobby.NewTableManager()
+	err := s.tables.Register(s.listenAddr, lobby.TableSettings{
+		Name:       cfg.TableName,
+		Variant:    s.game.TableConfig().Variant(),
+		SmallBlind: game.SmallBlind,
+		BigBlind:   game.BigBlind,
+		Ante:       cfg.Ante,
+		BuyIn:      protocol.DefaultStack,
+		MinBuyIn:   cfg.MinBuyIn,
+		MaxBuyIn:   cfg.MaxBuyIn,
+		MaxSeats:   cfg.MaxPlayers,
+	}, s.game, cfg.PublicWSURL+"/ws")
+	if err != nil {
+		logrus.Errorf("Failed to register table: %v", err)
+	}
+	// The node's own table lives as long as the node
+	s.tables.Pin(s.listenAddr)
+	s.tables.SetIdleTimeout(time.Duration(cfg.TableIdleTimeout) * time.Second)
+	s.lobby = lobby.NewLobby(s.tables)
+
+	if cfg.SignMessages {
+		s.enableMessageSigning()
+	} else if cfg.StateChannel {
+		logrus.Warn("State channel needs SIGN_MESSAGES; settling each hand on-chain instead")
+	}
+
+	if cfg.BinaryP2P {
+		s.game.EnableBinaryEncoding()
+	}
+
+	if cfg.RelayEnabled {
+		s.game.EnableRelay()
+		logrus.Info("Relaying messages between peers")
+	}
+
+	if !cfg.AllowBots {
+		s.game.EnableBotDetection(detection.NewBotDetector(detection.DefaultMinSamples, detection.DefaultFlagThreshold))
+	}
+
+	s.configureEvaluator()
+
+	if cfg.SnapshotKey != "" {
+		// Writing plaintext snapshots when the operator asked for
+		// encrypted ones would be worse than not starting
+		key, err := persistence.ParseSnapshotKey(cfg.SnapshotKey)
+		if err == nil {
+			err = persistence.SetSnapshotKey(key)
+		}
+		if err != nil {
+			logrus.Fatalf("Invalid snapshot key: %v", err)
+		}
+	}
+
+	if cfg.EnableHTTPS {
+		tlsConfig, err := s.tlsSettings().ServerConfig()
+		if err != nil {
+			logrus.Fatalf("Invalid TLS configuration: %v", err)
+		}
+		s.tlsConfig = tlsConfig
+	}
+
+	if cfg.AuditLogFile != "" {
+		// A broken chain must be looked at, not appended to
+		auditLog, err := persistence.OpenAuditLog(cfg.AuditLogFile)
+		if err != nil {
+			logrus.Fatalf("Failed to open audit log: %v", err)
+		}
+		s.auditLog = auditLog
+		s.game.SetAuditLog(auditLog, s.listenAddr)
+	}
+
+	if cfg.StoreDriver != "" {
+		store, err := persistence.NewStore(cfg.StoreDriver, cfg.StoreSource)
+		if err != nil {
+			logrus.Errorf("Failed to open %s store, hands will not be recorded: %v", cfg.StoreDriver, err)
+		} else {
+			s.store = store
+			s.game.SetStore(store, s.listenAddr)
+			if s.blockchain == nil {
+				logrus.Info("Blockchain disabled; buy-ins, hand results and cash-outs are kept in the store's ledger")
+			}
+		}
+	}
+
+	if cfg.StateDir != "" {
+		s.restoreState()
+	}
+
+	if cfg.BackupTarget != "" && s.snapshots != nil {
+		s.startBackups()
+	}
+	s.startRetention()
+	s.startCollusionMonitor()
+	s.startEscrowCache()
+	s.startChainHealth()
+	s.setupTransport()
+	s.setupBootstrap()
+
+	if cfg.SimMode {
+		if err := s.game.EnableSimulation(game.SimConfig{Seed: int64(cfg.SimSeed)}); err != nil {
+			logrus.Errorf("Simulation mode not enabled: %v", err)
+		} else {
+			logrus.Warnf("Simulation mode enabled (seed %d): decks are dealt in plaintext", cfg.SimSeed)
+		}
+	}
 
 	return s
 }
 
-func (s *Server) Start() error {
+// restoreState resumes the table from the latest state snapshot and the
+// write-ahead log after it, then persists every change from here on. The
+// crash marker stays set until a graceful shutdown, so a crash is told
+// apart from a clean restart.
+func (s *Server) restoreState() {
+	s.recovery = persistence.NewRecoveryManager(s.config.StateDir, filepath.Join(s.config.StateDir, "crash.marker"))
+
+	if s.config.StateWAL {
+		wal, err := persistence.OpenWAL(filepath.Join(s.config.StateDir, WALFileName))
+		if err != nil {
+			logrus.Errorf("Failed to open WAL, relying on snapshots alone: %v", err)
+		} else {
+			s.wal = wal
+		}
+	}
+
+	restored := false
+	snap, err := s.recovery.LoadLatest()
+	if err == nil && snap == nil && s.store != nil {
+		// Nothing on local disk; another node may have run this table
+		snap, err = s.store.LatestSnapshot(s.listenAddr)
+	}
+	switch {
+	case err != nil:
+		logrus.Errorf("Failed to load state snapshot, starting a fresh table: %v", err)
+	case snap != nil:
+		if err := s.game.Restore(snap); err != nil {
+			logrus.Errorf("Failed to restore state snapshot, starting a fresh table: %v", err)
+		} else {
+			restored = true
+		}
+	}
+
+	if s.wal != nil {
+		s.replayWAL(snap, restored)
+		s.game.SetWAL(s.wal, s.config.WALSnapshotEvery)
+	}
+
+	s.snapshots = persistence.NewSnapshotManager(s.config.StateDir, s.config.StateSnapshotsKept)
+	s.game.SetSnapshotManager(s.snapshots)
+	if err := s.recovery.MarkCrash(); err != nil {
+		logrus.Warnf("Failed to write crash marker: %v", err)
+	}
+}
+
+// replayWAL applies the logged events the restored snapshot does not cover.
+// Without a snapshot to build on they cannot be applied and are dropped.
+func (s *Server) replayWAL(snap *persistence.GameSnapshot, restored bool) {
+	after := uint64(0)
+	if restored {
+		after = snap.WALSeq
+	}
+
+	records, err := s.wal.Records(after)
+	if err != nil {
+		logrus.Errorf("Failed to read WAL: %v", err)
+		return
+	}
+	if len(records) == 0 {
+		return
+	}
+
+	if restored {
+		if _, err := s.game.ReplayWAL(records); err != nil {
+			logrus.Errorf("WAL replay stopped early: %v", err)
+		}
+		return
+	}
+
+	logrus.Warnf("Dropping %d WAL records with no snapshot to replay them onto", len(records))
+	if err := s.wal.Compact(s.wal.LastSeq()); err != nil {
+		logrus.Warnf("Failed to compact WAL: %v", err)
+	}
+}
+
+// startBackups copies the latest state snapshot to the backup target on a
+// schedule, keeping BackupKeep copies
+func (s *Server) startBackups() {
+	backups, err := OpenBackups(s.config)
+	if err != nil {
+		logrus.Errorf("Backups disabled: %v", err)
+		return
+	}
+
+	s.backups = backups
+	s.backups.Start(time.Duration(s.config.BackupInterval)*time.Second, s.snapshots.LatestFile)
+	logrus.Infof("Backing up state snapshots to %s every %ds", backups.Backend().Name(), s.config.BackupInterval)
+}
+
+func (s *Server) retentionPolicy() persistence.RetentionPolicy {
+	return retentionPolicy(s.config)
+}
+
+// startRetention prunes admin snapshots and backups by the configured
+// hourly, daily and weekly rules
+func (s *Server) startRetention() {
+	policy := s.retentionPolicy()
+	if !policy.Enabled() {
+		return
+	}
+
+	s.retention = persistence.NewRetentionScheduler(policy, time.Duration(s.config.RetentionInterval)*time.Second)
+	if s.config.SnapshotDir != "" {
+		s.retention.AddTarget(persistence.NewSnapshotDirTarget(s.config.SnapshotDir))
+	}
+	if s.backups != nil {
+		s.retention.AddTarget(s.backups.Backend())
+	}
+
+	if err := s.retention.Start(); err != nil {
+		logrus.Errorf("Retention disabled: %v", err)
+		s.retention = nil
+	}
+}
+
+// startCollusionMonitor analyses the table's hand histories for players
+// working together, auditing and announcing each new pattern found
+func (s *Server) startCollusionMonitor() {
+	if s.config.CollusionInterval <= 0 {
+		return
+	}
+
+	analyzer := detection.NewCollusionAnalyzer(s.config.CollusionMinTransfer, s.config.CollusionMinFaced)
+	s.collusion = detection.NewCollusionMonitor(analyzer, time.Duration(s.config.CollusionInterval)*time.Second, func() ([]detection.HandSummary, map[string][]string) {
+		hands := s.game.CollusionHands()
+
+		// Players are known by their listen address, and by where their
+		// connections to this node come from
+		addresses := s.hub.ClientAddresses()
+		for _, hand := range hands {
+			for _, addr := range hand.Players {
+				addresses[addr] = append(addresses[addr], addr)
+			}
+		}
+		return hands, addresses
+	})
+	s.collusion.OnAlert(s.game.ReportCollusion)
+
+	if err := s.collusion.Start(); err != nil {
+		logrus.Errorf("Collusion analysis disabled: %v", err)
+		s.collusion = nil
+	}
+}
+
+// startEscrowCache keeps the escrow events seen on-chain, following new
+// ones and backfilling those logged while the node was down. With a store
+// the events are kept in its ledger and the backfill starts after the last
+// block recorded there; otherwise it starts from ESCROW_FROM_BLOCK.
+func (s *Server) startEscrowCache() {
+	if s.blockchain == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.escrow = blockchain.NewFundsLockedCache()
+	s.stopEscrow = cancel
+
+	events := &escrowSync{cache: s.escrow, store: s.store}
+	fromBlock := events.load(uint64(s.config.EscrowFromBlock))
+
+	listener := blockchain.NewEventListener(s.blockchain)
+	events.follow(ctx, listener)
+	if err := listener.ListenForEvents(ctx); err != nil {
+		logrus.Warnf("Escrow cache will not follow new escrow events: %v", err)
+	}
+
+	go func() {
+		if err := events.backfill(listener, fromBlock); err != nil {
+			logrus.Errorf("Failed to backfill escrow events from block %d: %v", fromBlock, err)
+		}
+	}()
+}
+
+// startChainHealth watches the RPC node, block progression and the node
+// wallet, blocking new on-chain games while the chain is unusable
+// setupBootstrap prepares the connections to bootstrap peers, dialled
+// once the server starts
+func (s *Server) setupBootstrap() {
+	if len(s.config.BootstrapPeers) == 0 {
+		// With nobody to join, this node opened the table and hosts it
+		s.game.SetHost(s.listenAddr)
+		return
+	}
+
+	var dialConfig *tls.Config
+	if s.tlsConfig != nil {
+		cfg, err := s.tlsSettings().DialConfig()
+		if err != nil {
+			logrus.Errorf("Bootstrap peers disabled: %v", err)
+			return
+		}
+		dialConfig = cfg
+	}
+	s.bootstrap = newBootstrapper(s.config.BootstrapPeers, s.hub, s.game, s.listenAddr, dialConfig,
+		time.Duration(s.config.PeerReconnectMax)*time.Second)
+	s.bootstrap.transport = s.p2p
+}
+
+func (s *Server) startChainHealth() {
+	if s.blockchain == nil || s.config.ChainHealthInterval <= 0 {
+		return
+	}
+
+	s.chainHealth = blockchain.NewHealthMonitor(s.blockchain,
+		time.Duration(s.config.ChainHealthInterval)*time.Second,
+		time.Duration(s.config.ChainStallTimeout)*time.Second,
+		big.NewInt(int64(s.config.ChainMinBalance)))
+	s.chainHealth.OnChange(s.game.ReportChainHealth)
+
+	if err := s.chainHealth.Start(); err != nil {
+		logrus.Errorf("Chain health monitoring disabled: %v", err)
+		s.chainHealth = nil
+	}
+}
+
+// configureEvaluator selects the hand evaluator engine, falling back to the built-in one
+func (s *Server) configureEvaluator() {
+	evaluator, err := deck.NewEvaluator(s.config.Evaluator)
+	if err != nil {
+		logrus.Errorf("Failed to load hand evaluator, using default: %v", err)
+		evaluator = deck.DefaultEvaluator()
+	}
+
+	if s.config.EvaluatorCrossCheck != "" {
+		reference, err := deck.NewEvaluator(s.config.EvaluatorCrossCheck)
+		if err != nil {
+			logrus.Errorf("Failed to load cross-check evaluator: %v", err)
+		} else {
+			rate := float64(s.config.EvaluatorCheckPercent) / 100
+			evaluator = deck.NewCrossCheckEvaluator(evaluator, reference, rate)
+			logrus.Infof("Cross-checking %d%% of hand evaluations against %s",
+				s.config.EvaluatorCheckPercent, reference.Name())
+		}
+	}
+
+	s.game.SetEvaluator(evaluator)
+	logrus.Infof("Hand evaluator: %s", evaluator.Name())
+}
+
+// enableMessageSigning loads the node's signing wallet and turns on signed protocol messages
+func (s *Server) enableMessageSigning() {
+	var wallet *blockchain.Wallet
+	var err error
+	if s.config.SigningKey != "" {
+		wallet, err = blockchain.LoadWallet(s.config.SigningKey)
+	} else {
+		logrus.Warn("No SIGNING_PRIVATE_KEY set, generating an ephemeral signing wallet")
+		wallet, err = blockchain.GenerateWallet()
+	}
+	if err != nil {
+		logrus.Errorf("Failed to load signing wallet, message signing disabled: %v", err)
+		return
+	}
+
+	s.game.EnableMessageSigning(wallet, blockchain.VerifyHexSignature, s.config.RequireSignedMessages)
+}
+
+// deliverInvitation pings the invited player over their WebSocket, if connected
+func (s *Server) deliverInvitation(inv friends.Invitation) {
+	msg, err := protocol.NewMessage(s.listenAddr, protocol.TypeTableInvite, protocol.TableInvitePayload{
+		InvitationID: inv.ID,
+		From:         inv.From,
+		TableID:      inv.TableID,
+		JoinLink:     inv.JoinLink,
+		ExpiresAt:    inv.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		logrus.Errorf("Failed to build invitation message: %v", err)
+		return
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		logrus.Errorf("Failed to encode invitation message: %v", err)
+		return
+	}
+
+	s.hub.Broadcast(data, inv.To)
+}
+
+// Start runs the node until ctx is cancelled or one of its listeners fails.
+// Call Stop afterwards to drain the table and shut the listeners down.
+func (s *Server) Start(ctx context.Context) error {
 	s.mu.Lock()
 	if s.running {
 		s.mu.Unlock()
 		return fmt.Errorf("server already running")
 	}
 	s.running = true
+	s.wsServer = s.newWebSocketServer()
+	s.apiServer = s.newAPIServer()
 	s.mu.Unlock()
 
 	logrus.WithFields(logrus.Fields{
@@ -93,66 +569,146 @@ func (s *Server) Start() error {
 	}).Info("Starting poker server")
 
 	// Start WebSocket hub
-	go s.hub.Run()
+	go s.hub.Run(ctx)
 
 	// Start peer manager
 	go s.peerManager.Run()
 
-	// Start WebSocket server
-	go s.startWebSocketServer()
+	// Start presence sweeper
+	s.presence.Start()
+
+	// Tear down tables left empty
+	go s.tables.RunGC(ctx, lobby.DefaultGCInterval)
+
+	if s.p2p != nil {
+		if err := s.p2p.Listen(s.config.P2PListenAddr); err != nil {
+			return err
+		}
+	}
+	if s.bootstrap != nil {
+		s.bootstrap.Start()
+	}
+
+	wsScheme, apiScheme := "ws", "http"
+	if s.tlsConfig != nil {
+		wsScheme, apiScheme = "wss", "https"
+	}
+
+	errCh := make(chan error, 2)
+	go func() {
+		logrus.Infof("WebSocket server listening on %s (%s)", s.wsServer.Addr, wsScheme)
+		errCh <- serve(s.wsServer, "WebSocket server")
+	}()
+	go func() {
+		logrus.Infof("HTTP API server listening on %s (%s)", s.apiServer.Addr, apiScheme)
+		errCh <- serve(s.apiServer, "HTTP API server")
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-errCh:
+		return err
+	}
+}
 
-	// Start HTTP API server
-	return s.startAPIServer()
+// serve runs an HTTP server until it fails or is shut down, over TLS when
+// the server has a TLS config
+func serve(server *http.Server, name string) error {
+	var err error
+	if server.TLSConfig != nil {
+		// Certificates come from the TLS config, not files
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%s failed: %w", name, err)
+	}
+	return nil
 }
 
-func (s *Server) startWebSocketServer() {
+func (s *Server) newWebSocketServer() *http.Server {
 	router := mux.NewRouter()
 
 	// WebSocket endpoint for clients
 	router.HandleFunc("/ws", s.handleWebSocket)
 
+	// Read-only WebSocket endpoint for spectators
+	router.HandleFunc("/ws/spectate", s.handleSpectatorWebSocket)
+
 	// WebSocket endpoint for peers
 	router.HandleFunc("/p2p", s.handlePeerConnection)
 
-	addr := s.listenAddr
-	logrus.Infof("WebSocket server listening on %s", addr)
-
-	server := &http.Server{
-		Addr:         addr,
+	return &http.Server{
+		Addr:         s.listenAddr,
 		Handler:      router,
+		TLSConfig:    s.tlsConfig,
 		ReadTimeout:  60 * time.Second,
 		WriteTimeout: 10 * time.Second,
 	}
-
-	if err := server.ListenAndServe(); err != nil {
-		logrus.Fatalf("WebSocket server failed: %v", err)
-	}
 }
 
-func (s *Server) startAPIServer() error {
+func (s *Server) newAPIServer() *http.Server {
 	router := mux.NewRouter()
 
 	// Create API handler
-	apiHandler := api.NewHandler(s.game)
-
-	// Setup routes
-	api.SetupRoutes(router, apiHandler)
-
-	// Add middleware
-	router.Use(api.LoggingMiddleware)
-	router.Use(api.CORSMiddleware)
-
-	addr := fmt.Sprintf(":%s", s.apiPort)
-	logrus.Infof("HTTP API server listening on %s", addr)
+	apiHandler := api.NewHandler(s.game, s.peerManager, s.hub)
+	apiHandler.SetPresence(s.presence)
+	apiHandler.SetTable(s.listenAddr, s.config.PublicWSURL)
+	apiHandler.SetVersion(s.config.Version)
+	apiHandler.SetReadyMinPeers(s.config.ReadyMinPeers)
+	apiHandler.SetFriends(s.friends)
+	apiHandler.SetLobby(s.lobby)
+	apiHandler.SetAdmin(s.tables, s.config.SnapshotDir)
+	apiHandler.SetAdminToken(s.config.AdminToken)
+	apiHandler.SetProfiling(s.config.Pprof)
+	if s.auditLog != nil {
+		apiHandler.SetAuditLog(s.auditLog)
+	}
+	if s.collusion != nil {
+		apiHandler.SetCollusionMonitor(s.collusion)
+	}
+	if s.blockchain != nil {
+		apiHandler.SetEscrow(s.blockchain, s.escrow)
+	}
+	if s.chainHealth != nil {
+		apiHandler.SetChainHealth(s.chainHealth)
+	}
+	if s.bootstrap != nil {
+		apiHandler.SetBootstrap(s.bootstrap)
+	}
+	apiHandler.SetInsurance(s.insurance)
+	apiHandler.SetRateLimits(
+		api.RateLimit{RPS: float64(s.config.RateLimitActionRPS), Burst: s.config.RateLimitActionBurst},
+		api.RateLimit{RPS: float64(s.config.RateLimitReadRPS), Burst: s.config.RateLimitReadBurst},
+	)
+	if s.store != nil {
+		apiHandler.SetStore(s.store)
+	}
+	if s.backups != nil {
+		apiHandler.SetBackups(s.backups)
+	}
+	apiHandler.SetConfigReloader(s)
+	s.apiHandler = apiHandler
+
+	// Routes come with their own middleware
+	routes := apiHandler.Routes()
+	router.PathPrefix("/api/").Handler(routes)
+	router.Handle("/graphql", routes)
+	router.Handle("/healthz", routes)
+	router.Handle("/readyz", routes)
+	if s.config.WebUI {
+		router.PathPrefix("/").Handler(web.Handler())
+	}
 
-	server := &http.Server{
-		Addr:         addr,
+	return &http.Server{
+		Addr:         fmt.Sprintf(":%s", s.apiPort),
 		Handler:      router,
+		TLSConfig:    s.tlsConfig,
 		ReadTimeout:  60 * time.Second,
 		WriteTimeout: 10 * time.Second,
 	}
-
-	return server.ListenAndServe()
 }
 
 func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
@@ -168,7 +724,28 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	go client.ReadPump()
 }
 
+func (s *Server) handleSpectatorWebSocket(w http.ResponseWriter, r *http.Request) {
+	client, err := NewSpectatorFromHTTP(w, r, s.hub)
+	if err != nil {
+		logrus.Errorf("Failed to create spectator: %v", err)
+		return
+	}
+
+	s.hub.Register <- client
+
+	go client.WritePump()
+	go client.ReadPump()
+}
+
 func (s *Server) handlePeerConnection(w http.ResponseWriter, r *http.Request) {
+	// With mutual TLS the listener only verifies certificates that are
+	// presented, since browsers share it; peers must present one
+	if s.tlsConfig != nil && s.config.PeerCAFile != "" && (r.TLS == nil || len(r.TLS.VerifiedChains) == 0) {
+		logrus.Warnf("Rejected peer connection from %s without a client certificate", r.RemoteAddr)
+		http.Error(w, "client certificate required", http.StatusForbidden)
+		return
+	}
+
 	peer, err := s.peerManager.HandleIncomingPeer(w, r)
 	if err != nil {
 		logrus.Errorf("Failed to handle peer connection: %v", err)
@@ -177,18 +754,84 @@ func (s *Server) handlePeerConnection(w http.ResponseWriter, r *http.Request) {
 
 	go peer.ReadPump()
 	go peer.WritePump()
+
+	if err := s.game.SendHandshake(peer.ID); err != nil {
+		logrus.Errorf("Failed to send handshake to peer %s: %v", peer.ID, err)
+	}
 }
 
-func (s *Server) Stop() {
+// Stop shuts the node down without losing money on the table: it stops
+// seating, lets the hand in progress finish until ctx is done (and voids it,
+// refunding every bet, if it has not), settles batched hands, retries failed
+// escrow payouts and writes a final snapshot before shutting down the HTTP
+// servers and closing stores and the chain client. It returns every error it met.
+func (s *Server) Stop(ctx context.Context) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if !s.running {
-		return
+	if !s.running || s.stopping {
+		s.mu.Unlock()
+		return nil
 	}
+	s.stopping = true
+	s.mu.Unlock()
 
 	logrus.Info("Stopping server...")
 
+	s.game.BeginShutdown()
+	s.finishHand(ctx)
+	s.flushSettlements()
+
+	s.presence.Stop()
+
+	if s.backups != nil {
+		s.backups.Stop()
+	}
+	if s.retention != nil {
+		s.retention.Stop()
+	}
+	if s.collusion != nil {
+		s.collusion.Stop()
+	}
+	if s.stopEscrow != nil {
+		s.stopEscrow()
+	}
+	if s.chainHealth != nil {
+		s.chainHealth.Stop()
+	}
+	if s.bootstrap != nil {
+		s.bootstrap.Stop()
+	}
+	if s.p2p != nil {
+		s.p2p.Close()
+	}
+
+	var errs []error
+	if s.recovery != nil {
+		if err := s.recovery.PerformGracefulShutdown(s.game.StateSnapshot()); err != nil {
+			logrus.Errorf("Failed to save shutdown snapshot: %v", err)
+			errs = append(errs, fmt.Errorf("shutdown snapshot: %w", err))
+		}
+	}
+	// Players could follow the hand until now; close the listeners
+	if err := s.shutdownListeners(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	if s.wal != nil {
+		if err := s.wal.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close WAL: %w", err))
+		}
+	}
+	if s.store != nil {
+		if err := s.store.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close store: %w", err))
+		}
+	}
+	if s.auditLog != nil {
+		if err := s.auditLog.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close audit log: %w", err))
+		}
+	}
+
 	// Close blockchain client
 	if s.blockchain != nil {
 		logrus.Info("Closing blockchain client...")
@@ -196,8 +839,107 @@ func (s *Server) Stop() {
 		logrus.Info("Blockchain client closed")
 	}
 
+	s.mu.Lock()
 	s.running = false
+	s.stopping = false
+	s.mu.Unlock()
+
 	logrus.Info("Server stopped")
+	return errors.Join(errs...)
+}
+
+// shutdownListeners stops both HTTP servers, waiting for in-flight requests
+// until ctx is done. If the hand used up the deadline they get a short grace
+// period of their own.
+func (s *Server) shutdownListeners(ctx context.Context) error {
+	if ctx.Err() != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), listenerShutdownGrace)
+		defer cancel()
+	}
+
+	var errs []error
+	for _, server := range []*http.Server{s.wsServer, s.apiServer} {
+		if server == nil {
+			continue
+		}
+		if err := server.Shutdown(ctx); err != nil {
+			server.Close()
+			errs = append(errs, fmt.Errorf("shut down %s: %w", server.Addr, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// finishHand waits for the hand in progress to end, voiding it when ctx is
+// done first
+func (s *Server) finishHand(ctx context.Context) {
+	if !s.game.HandInProgress() {
+		return
+	}
+	logrus.Info("Waiting for the current hand to finish...")
+
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	for s.game.HandInProgress() {
+		select {
+		case <-ctx.Done():
+			refunds, err := s.game.VoidHand("server shutting down", "server")
+			if err != nil {
+				// The hand ended as the deadline passed
+				return
+			}
+			logrus.WithField("refunds", refunds).Warn("Hand did not finish before shutdown; bets refunded")
+			return
+		case <-ticker.C:
+		}
+	}
+	logrus.Info("Current hand finished")
+}
+
+// flushSettlements closes the state channel, settles batched hands and
+// retries escrow payouts that failed on-chain; any still failing stay in the
+// shutdown snapshot's audit trail
+func (s *Server) flushSettlements() {
+	if s.blockchain == nil {
+		return
+	}
+
+	if _, open := s.game.ChannelStatus(); open {
+		if err := s.game.CloseChannel("server"); err != nil {
+			logrus.Errorf("Failed to close state channel: %v", err)
+		}
+	}
+	if batch, err := s.game.SettleBatch("server"); err != nil {
+		logrus.Errorf("Failed to settle batched hands: %v", err)
+	} else if batch != nil {
+		logrus.Infof("Settled batch of %d hand(s)", len(batch.HandIDs))
+	}
+	if len(s.game.FailedSettlements()) == 0 {
+		return
+	}
+
+	settled, remaining, err := s.game.RetrySettlements("server")
+	if err != nil {
+		logrus.Errorf("Failed to flush settlements: %v", err)
+		return
+	}
+	if remaining > 0 {
+		logrus.Errorf("%d settlement(s) still failing at shutdown; retry them with the admin API after restart", remaining)
+	}
+	logrus.Infof("Flushed %d pending settlement(s)", settled)
+}
+
+// tlsSettings collects the TLS configuration for listeners and peer dials
+func (s *Server) tlsSettings() transport.TLSConfig {
+	return transport.TLSConfig{
+		CertFile:         s.config.TLSCertFile,
+		KeyFile:          s.config.TLSKeyFile,
+		AutocertDomains:  s.config.TLSAutocertDomains,
+		AutocertCacheDir: s.config.TLSAutocertCacheDir,
+		PeerCAFile:       s.config.PeerCAFile,
+	}
 }
 
 func (s *Server) ConnectToPeer(peerAddr string) error {
@@ -205,6 +947,14 @@ func (s *Server) ConnectToPeer(peerAddr string) error {
 }
 
 func (s *Server) broadcastToPlayers(data []byte, targets ...string) {
+	if s.p2p != nil {
+		remaining := s.sendOverTransport(data, targets)
+		if len(targets) > 0 && len(remaining) == 0 {
+			return
+		}
+		targets = remaining
+	}
+
 	if len(targets) == 0 {
 		// Broadcast to all clients
 		s.hub.broadcast <- data
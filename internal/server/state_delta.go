@@ -0,0 +1,101 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// stateDeltas turns full state updates into deltas for a client that asked
+// for them, against the last state written to that client. Coalesced or
+// throttled updates never leave a gap, since the base is what was actually
+// sent rather than what the game published.
+type stateDeltas struct {
+	mu     sync.Mutex
+	base   *protocol.GameStateUpdateEvent
+	resync bool // send the next state in full
+}
+
+// deltasFromRequest reports whether the client asked for state deltas,
+// e.g. /ws?caps=state_deltas
+func deltasFromRequest(r *http.Request) *stateDeltas {
+	caps, err := protocol.ParseCapabilities(r.URL.Query().Get("caps"))
+	if err != nil || !caps.Has(protocol.CapStateDeltas) {
+		return nil
+	}
+	return &stateDeltas{}
+}
+
+// encode returns a state update event as a delta against the last state
+// sent, or unchanged when it must go in full
+func (d *stateDeltas) encode(data []byte) []byte {
+	event, err := protocol.DecodeEvent(data)
+	if err != nil || event.Type != protocol.EventGameStateUpdate {
+		return data
+	}
+	var state protocol.GameStateUpdateEvent
+	if err := json.Unmarshal(event.Data, &state); err != nil {
+		return data
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	base := d.base
+	d.base = &state
+	if base == nil || d.resync || state.Version <= base.Version {
+		d.resync = false
+		return data
+	}
+
+	payload, err := json.Marshal(protocol.NewStateDelta(*base, state))
+	if err != nil {
+		return data
+	}
+	event.Type = protocol.EventGameStateDelta
+	event.Data = payload
+	encoded, err := json.Marshal(event)
+	if err != nil {
+		return data
+	}
+	return encoded
+}
+
+// requestResync answers a client's state_resync: the last state it was
+// sent goes out again in full, and deltas continue from there
+func (c *Client) requestResync() {
+	if c.deltas == nil {
+		return
+	}
+
+	c.deltas.mu.Lock()
+	c.deltas.resync = true
+	var state protocol.GameStateUpdateEvent
+	if c.deltas.base != nil {
+		state = *c.deltas.base
+	}
+	c.deltas.mu.Unlock()
+
+	if state.Version == 0 {
+		return // nothing sent yet; the next update goes in full
+	}
+	state.Diff = nil
+
+	event, err := protocol.NewEvent(protocol.EventGameStateUpdate, state)
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	c.log().WithFields(logrus.Fields{
+		"client_id": c.ID,
+		"version":   state.Version,
+	}).Debug("Resending full state")
+	c.enqueue(data)
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultEventInterval = 1 * time.Second
+	minEventInterval     = 250 * time.Millisecond
+)
+
+// eventThrottle coalesces table state updates for clients that asked for
+// reduced event frequency (typically mobile). At most one state update is
+// delivered per interval, always the latest; shuffle progress is dropped.
+// Every other event is delivered immediately.
+type eventThrottle struct {
+	interval time.Duration
+	pending  []byte
+	lastSent time.Time
+	timer    *time.Timer
+	stopped  bool
+	mu       sync.Mutex
+}
+
+func newEventThrottle(interval time.Duration) *eventThrottle {
+	return &eventThrottle{interval: interval}
+}
+
+// throttleFromRequest reads the client's requested capabilities from the
+// connection URL, e.g. /ws?caps=throttled_events&event_interval_ms=500
+func throttleFromRequest(r *http.Request) *eventThrottle {
+	caps, err := protocol.ParseCapabilities(r.URL.Query().Get("caps"))
+	if err != nil {
+		logrus.Warnf("Ignoring client capabilities: %v", err)
+		return nil
+	}
+	if !caps.Has(protocol.CapThrottledEvents) {
+		return nil
+	}
+
+	interval := defaultEventInterval
+	if ms, err := strconv.Atoi(r.URL.Query().Get("event_interval_ms")); err == nil {
+		interval = time.Duration(ms) * time.Millisecond
+		if interval < minEventInterval {
+			interval = minEventInterval
+		}
+	}
+	return newEventThrottle(interval)
+}
+
+// offer passes data to send now, later, or never depending on its type
+func (t *eventThrottle) offer(data []byte, send func([]byte)) {
+	switch protocol.PeekType(data) {
+	case protocol.TypeShuffleStatus:
+		// Intermediate shuffle rounds only drive animations
+		return
+	case protocol.TypeGameState, protocol.MessageType(protocol.EventGameStateUpdate):
+	default:
+		send(data)
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stopped {
+		return
+	}
+
+	wait := t.interval - time.Since(t.lastSent)
+	if wait <= 0 && t.timer == nil {
+		t.lastSent = time.Now()
+		send(data)
+		return
+	}
+
+	t.pending = data
+	if t.timer == nil {
+		t.timer = time.AfterFunc(wait, func() { t.flush(send) })
+	}
+}
+
+func (t *eventThrottle) flush(send func([]byte)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.timer = nil
+	if t.stopped || t.pending == nil {
+		return
+	}
+
+	send(t.pending)
+	t.pending = nil
+	t.lastSent = time.Now()
+}
+
+// stop discards pending state; must be called before the client's send channel is closed
+func (t *eventThrottle) stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stopped = true
+	t.pending = nil
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
@@ -3,7 +3,11 @@ package server
 import (
 	"context"
 	"sync"
+	"time"
 
+	"github.com/RedPaladin7/peerpoker/internal/api"
+	"github.com/RedPaladin7/peerpoker/internal/logging"
+	"github.com/RedPaladin7/peerpoker/internal/presence"
 	"github.com/RedPaladin7/peerpoker/internal/protocol"
 	"github.com/sirupsen/logrus"
 )
@@ -15,23 +19,114 @@ type WebSocketHub struct {
 	unregister chan *Client
 	mu         sync.RWMutex
 	closed     bool
+
+	// Presence tracking for player connections at this table
+	presence *presence.Service
+	tableID  string
+
+	// Limits messages from player connections; peers are not limited
+	limiter *api.RateLimiter
+
+	// Read-only subscribers to public table events (SSE)
+	eventSubs map[chan []byte]bool
+
+	// How long a client's send buffer may stay saturated (see backpressure.go)
+	slowClientTimeout time.Duration
+
+	// Application-level pings to peers (see peer_health.go)
+	peerPingInterval time.Duration
+	peerTimeout      time.Duration
+	lastPeerPing     time.Time
+
+	// The table's logger (see SetLogger)
+	logger *logrus.Entry
+
+	// Set while Run is looping, and when its loop last ticked
+	running  bool
+	lastTick time.Time
 }
 
+// hubStallAfter is how long Run may go without ticking before the hub is
+// reported as not running
+const hubStallAfter = 5 * time.Second
+
 func NewWebSocketHub() *WebSocketHub {
 	return &WebSocketHub{
 		clients:    make(map[*Client]bool),
 		broadcast:  make(chan *protocol.BroadcastMessage, 256),
 		Register:   make(chan *Client, 10),
 		unregister: make(chan *Client, 10),
+		eventSubs:  make(map[chan []byte]bool),
+	}
+}
+
+// SetPresence feeds player connection state for this table into the presence service
+func (h *WebSocketHub) SetPresence(svc *presence.Service, tableID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.presence = svc
+	h.tableID = tableID
+}
+
+// SetLogger logs hub and connection events through the table's logger
+func (h *WebSocketHub) SetLogger(entry *logrus.Entry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.logger = entry
+}
+
+func (h *WebSocketHub) log() *logrus.Entry {
+	if h.logger == nil {
+		return logging.Default()
+	}
+	return h.logger
+}
+
+// SetRateLimit limits how fast each player connection may send messages
+func (h *WebSocketHub) SetRateLimit(limit api.RateLimit) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.limiter = api.NewRateLimiter(limit)
+}
+
+// allowMessage takes a token from the client's message budget
+func (h *WebSocketHub) allowMessage(clientID string) bool {
+	h.mu.RLock()
+	limiter := h.limiter
+	h.mu.RUnlock()
+
+	ok, _ := limiter.Allow(clientID)
+	return ok
+}
+
+// recordActivity marks a player as active for presence purposes
+func (h *WebSocketHub) recordActivity(clientID string) {
+	h.mu.RLock()
+	svc := h.presence
+	h.mu.RUnlock()
+
+	if svc != nil {
+		svc.Touch(clientID)
 	}
 }
 
 func (h *WebSocketHub) Run(ctx context.Context) {
+	evictTicker := time.NewTicker(time.Second)
+	defer evictTicker.Stop()
+
+	h.markRunning(true)
+	defer h.markRunning(false)
+
 	for {
 		select {
 		case <-ctx.Done():
 			h.shutdownAllClients()
 			return
+
+		case <-evictTicker.C:
+			h.markRunning(true)
+			h.evictSlowClients()
+			h.checkPeers()
 			
 		case client := <-h.Register:
 			h.registerClient(client)
@@ -45,12 +140,29 @@ func (h *WebSocketHub) Run(ctx context.Context) {
 	}
 }
 
+func (h *WebSocketHub) markRunning(running bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.running = running
+	h.lastTick = time.Now()
+}
+
+// Running reports whether the hub's loop is up and not stalled
+func (h *WebSocketHub) Running() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.running && time.Since(h.lastTick) < hubStallAfter
+}
+
 func (h *WebSocketHub) registerClient(client *Client) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	
 	h.clients[client] = true
-	logrus.WithFields(logrus.Fields{
+	if h.presence != nil && !client.IsPeer && !client.IsSpectator {
+		h.presence.Connected(client.ID, h.tableID)
+	}
+	h.log().WithFields(logrus.Fields{
 		"client_id": client.ID,
 		"peer":      client.IsPeer,
 		"total":     len(h.clients),
@@ -63,9 +175,15 @@ func (h *WebSocketHub) unregisterClient(client *Client) {
 	
 	if _, ok := h.clients[client]; ok {
 		delete(h.clients, client)
+		if client.throttle != nil {
+			client.throttle.stop()
+		}
 		close(client.send)
+		if h.presence != nil && !client.IsPeer && !client.IsSpectator {
+			h.presence.Disconnected(client.ID, h.tableID)
+		}
 		
-		logrus.WithFields(logrus.Fields{
+		h.log().WithFields(logrus.Fields{
 			"client_id": client.ID,
 			"total":     len(h.clients),
 		}).Info("Client unregistered")
@@ -77,24 +195,27 @@ func (h *WebSocketHub) broadcastMessage(msg *protocol.BroadcastMessage) {
 	defer h.mu.RUnlock()
 	
 	if len(msg.To) == 0 {
+		public := msg.ClientsOnly && isPublicEvent(msg.Data)
+
 		// Broadcast to all clients
 		for client := range h.clients {
-			select {
-			case client.send <- msg.Data:
-			default:
-				logrus.Warnf("Client %s send buffer full, dropping message", client.ID)
+			if msg.ClientsOnly && client.IsPeer {
+				continue
+			}
+			if client.IsSpectator && !public {
+				continue
 			}
+			client.deliver(msg.Data)
 		}
 	} else {
 		// Broadcast to specific targets
 		for client := range h.clients {
+			if (msg.ClientsOnly && client.IsPeer) || client.IsSpectator {
+				continue
+			}
 			for _, targetID := range msg.To {
 				if client.ID == targetID {
-					select {
-					case client.send <- msg.Data:
-					default:
-						logrus.Warnf("Client %s send buffer full, dropping message", client.ID)
-					}
+					client.deliver(msg.Data)
 					break
 				}
 			}
@@ -118,10 +239,95 @@ func (h *WebSocketHub) Broadcast(data []byte, targets ...string) {
 	select {
 	case h.broadcast <- msg:
 	default:
-		logrus.Warn("Broadcast channel full, dropping message")
+		h.log().Warn("Broadcast channel full, dropping message")
 	}
 }
 
+// BroadcastEvent sends a client-facing event to players' WebSockets, never to peers
+func (h *WebSocketHub) BroadcastEvent(data []byte, targets ...string) {
+	h.mu.RLock()
+	if h.closed {
+		h.mu.RUnlock()
+		return
+	}
+	h.mu.RUnlock()
+
+	msg := &protocol.BroadcastMessage{
+		Data:        data,
+		To:          targets,
+		ClientsOnly: true,
+	}
+
+	select {
+	case h.broadcast <- msg:
+	default:
+		h.log().Warn("Broadcast channel full, dropping event")
+	}
+
+	// Targeted events may carry private information
+	if len(targets) == 0 && isPublicEvent(data) {
+		h.publishToSubscribers(data)
+	}
+}
+
+// isPublicEvent reports whether an event may be shown to spectators
+func isPublicEvent(data []byte) bool {
+	return protocol.IsPublicEvent(protocol.EventType(protocol.PeekType(data)))
+}
+
+// SubscribeEvents returns a feed of public table events and a function to stop it
+func (h *WebSocketHub) SubscribeEvents() (<-chan []byte, func()) {
+	ch := make(chan []byte, 64)
+
+	h.mu.Lock()
+	h.eventSubs[ch] = true
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.eventSubs, ch)
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (h *WebSocketHub) publishToSubscribers(data []byte) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for ch := range h.eventSubs {
+		select {
+		case ch <- data:
+		default:
+			h.log().Debug("Event subscriber too slow, dropping event")
+		}
+	}
+}
+
+// SpectatorCount returns the number of connected spectators
+func (h *WebSocketHub) SpectatorCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	count := 0
+	for client := range h.clients {
+		if client.IsSpectator {
+			count++
+		}
+	}
+	return count
+}
+
+// SubscriberCount returns the number of read-only event subscribers
+func (h *WebSocketHub) SubscriberCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.eventSubs)
+}
+
 func (h *WebSocketHub) ClientCount() int {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
@@ -139,6 +345,21 @@ func (h *WebSocketHub) GetClientIDs() []string {
 	return ids
 }
 
+// ClientAddresses returns where each player and peer connection came from
+func (h *WebSocketHub) ClientAddresses() map[string][]string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	addresses := make(map[string][]string)
+	for client := range h.clients {
+		if client.IsSpectator || client.RemoteAddr == "" {
+			continue
+		}
+		addresses[client.ID] = append(addresses[client.ID], client.RemoteAddr)
+	}
+	return addresses
+}
+
 func (h *WebSocketHub) shutdownAllClients() {
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -0,0 +1,81 @@
+package testenv
+
+import "fmt"
+
+// Step is one action in a scripted hand, taken by Player on their own node
+// once every node agrees it is their turn
+type Step struct {
+	Player string
+	Action string // as the API takes it: fold, check, call, bet, raise, all_in
+	Value  int
+}
+
+// Hand is a scripted hand: its actions in turn order and, when set, each
+// player's stack once it is over
+type Hand struct {
+	Actions []Step
+	Stacks  map[string]int
+}
+
+// Play plays scripted hands one after another, dealing each one after the
+// first, and checks that every node ends each hand with the same stacks,
+// the expected ones when given, and nothing left to settle
+func (e *Env) Play(hands ...Hand) error {
+	for i, hand := range hands {
+		if i > 0 {
+			if err := e.Deal(); err != nil {
+				return fmt.Errorf("hand %d: %w", i+1, err)
+			}
+		}
+		if err := e.playHand(hand); err != nil {
+			return fmt.Errorf("hand %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (e *Env) playHand(hand Hand) error {
+	before := finishedHands(e.Nodes[0].Game)
+
+	for i, step := range hand.Actions {
+		node := e.Node(step.Player)
+		if node == nil {
+			return fmt.Errorf("step %d: no node for player %s", i+1, step.Player)
+		}
+		err := e.WaitFor(step.Player+"'s turn", func() bool {
+			for _, n := range e.Nodes {
+				if n.Game.PublicState().CurrentTurn != step.Player {
+					return false
+				}
+			}
+			return true
+		})
+		if err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+		if err := node.Game.HandlePlayerAction(step.Player, step.Action, step.Value); err != nil {
+			return fmt.Errorf("step %d: %s %s: %w", i+1, step.Player, step.Action, err)
+		}
+	}
+
+	err := e.WaitFor("the hand to end", func() bool {
+		for _, n := range e.Nodes {
+			if finishedHands(n.Game) <= before {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	stacks, err := e.Agree()
+	if err != nil {
+		return err
+	}
+	if hand.Stacks != nil && !sameStacks(stacks, hand.Stacks) {
+		return fmt.Errorf("stacks are %s, want %s", formatStacks(stacks), formatStacks(hand.Stacks))
+	}
+	return e.Settled()
+}
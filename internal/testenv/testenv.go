@@ -0,0 +1,331 @@
+// Package testenv runs several table nodes in one process, connected as
+// peers over an in-memory transport, so end-to-end tests can play scripted
+// hands through the real peer protocol and check the outcome on every node.
+//
+// A typical test:
+//
+//	env, err := testenv.New(testenv.Options{Nodes: 3})
+//	...
+//	defer env.Close()
+//	err = env.Start()
+//	...
+//	err = env.Play(testenv.Hand{Actions: []testenv.Step{...}, Stacks: map[string]int{...}})
+//
+// Decks are shuffled by the nodes' mental poker unless Options.Sim is set,
+// which deals the same plaintext decks on every node and needs the sim
+// build tag.
+package testenv
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/game"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/RedPaladin7/peerpoker/internal/transport"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultTimeout is how long the environment waits for the nodes to agree
+// on a step before failing it
+const DefaultTimeout = 10 * time.Second
+
+// pollInterval is how often waits look at the nodes again
+const pollInterval = 10 * time.Millisecond
+
+// Options configures an environment
+type Options struct {
+	Nodes   int
+	Table   *game.TableConfig // applied to every node when set
+	Sim     *game.SimConfig   // deal simulation decks; needs -tags sim
+	Timeout time.Duration
+}
+
+// Node is one table node: its game and the transport linking it to the
+// other nodes. The node's ID is also its player ID.
+type Node struct {
+	ID        string
+	Game      *game.Game
+	transport *transport.MemoryTransport
+}
+
+// Env is a set of nodes sitting at the same table
+type Env struct {
+	Nodes   []*Node
+	network *transport.MemoryNetwork
+	timeout time.Duration
+}
+
+// New starts opts.Nodes nodes and connects every pair of them
+func New(opts Options) (*Env, error) {
+	if opts.Nodes < 2 {
+		return nil, fmt.Errorf("an environment needs at least 2 nodes")
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	e := &Env{
+		network: transport.NewMemoryNetwork(),
+		timeout: opts.Timeout,
+	}
+	for i := 0; i < opts.Nodes; i++ {
+		node, err := e.newNode(fmt.Sprintf("node-%d", i), opts)
+		if err != nil {
+			e.Close()
+			return nil, err
+		}
+		e.Nodes = append(e.Nodes, node)
+	}
+
+	for i, node := range e.Nodes {
+		for _, peer := range e.Nodes[i+1:] {
+			if _, err := node.transport.Dial(peer.ID); err != nil {
+				e.Close()
+				return nil, fmt.Errorf("%s failed to dial %s: %w", node.ID, peer.ID, err)
+			}
+			if err := node.Game.SendHandshake(peer.ID); err != nil {
+				e.Close()
+				return nil, fmt.Errorf("%s failed to greet %s: %w", node.ID, peer.ID, err)
+			}
+		}
+	}
+
+	err := e.WaitFor("handshakes", func() bool {
+		for _, node := range e.Nodes {
+			for _, peer := range e.Nodes {
+				if _, ok := node.Game.PeerSession(peer.ID); peer != node && !ok {
+					return false
+				}
+			}
+		}
+		return true
+	})
+	if err != nil {
+		e.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *Env) newNode(id string, opts Options) (*Node, error) {
+	node := &Node{
+		ID:        id,
+		transport: e.network.Transport(transport.Options{LocalID: id}),
+	}
+	node.Game = game.NewGame(id, node.broadcast, nil)
+	node.Game.SetLogger(logrus.WithField("node", id))
+
+	if opts.Table != nil {
+		if err := node.Game.SetTableConfig(*opts.Table); err != nil {
+			return nil, fmt.Errorf("%s: %w", id, err)
+		}
+	}
+	if opts.Sim != nil {
+		if err := node.Game.EnableSimulation(*opts.Sim); err != nil {
+			return nil, fmt.Errorf("%s: %w", id, err)
+		}
+	}
+
+	node.transport.OnMessage(node.receive)
+	if err := node.transport.Listen(id); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// broadcast sends a node's message to the targets, or to every peer
+func (n *Node) broadcast(data []byte, targets ...string) {
+	if len(targets) == 0 {
+		targets = n.transport.Peers()
+	}
+	for _, target := range targets {
+		if target == n.ID {
+			continue
+		}
+		if err := n.transport.Send(target, data); err != nil {
+			logrus.WithField("node", n.ID).Warnf("Failed to send to %s: %v", target, err)
+		}
+	}
+}
+
+// receive hands a peer's message to the game, as the server does
+func (n *Node) receive(peer string, data []byte) {
+	msg, err := protocol.DecodeMessage(data, protocol.EncodingJSON)
+	if err != nil {
+		logrus.WithField("node", n.ID).Warnf("Dropped malformed message from %s: %v", peer, err)
+		return
+	}
+	if err := n.Game.HandleMessage(peer, msg); err != nil {
+		logrus.WithField("node", n.ID).Warnf("Message from %s failed: %v", peer, err)
+	}
+}
+
+// send sends a message from the node to one peer
+func (n *Node) send(peer string, msgType protocol.MessageType, payload interface{}) error {
+	msg, err := protocol.NewMessage(n.ID, msgType, payload)
+	if err != nil {
+		return err
+	}
+	data, err := protocol.EncodeMessage(msg, protocol.EncodingJSON)
+	if err != nil {
+		return err
+	}
+	return n.transport.Send(peer, data)
+}
+
+// Node returns the node with the given ID, or nil
+func (e *Env) Node(id string) *Node {
+	for _, node := range e.Nodes {
+		if node.ID == id {
+			return node
+		}
+	}
+	return nil
+}
+
+// Close shuts every node down
+func (e *Env) Close() {
+	for _, node := range e.Nodes {
+		node.transport.Close()
+		node.Game.Close("testenv")
+	}
+}
+
+// Start seats every node's player, in node order, tells the other nodes,
+// readies everyone and waits for the first hand to be dealt
+func (e *Env) Start() error {
+	for i, node := range e.Nodes {
+		if err := node.Game.TakeSeat(node.ID, i+1); err != nil {
+			return fmt.Errorf("%s failed to sit down: %w", node.ID, err)
+		}
+		for _, peer := range e.Nodes {
+			if peer == node {
+				continue
+			}
+			err := node.send(peer.ID, protocol.TypeSeat, protocol.SeatPayload{Seat: i + 1, Action: protocol.SeatActionTake})
+			if err != nil {
+				return fmt.Errorf("%s failed to announce its seat to %s: %w", node.ID, peer.ID, err)
+			}
+		}
+	}
+
+	err := e.WaitFor("everyone seated", func() bool {
+		for _, node := range e.Nodes {
+			if node.Game.PlayerCount() != len(e.Nodes) {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return e.Deal()
+}
+
+// Deal readies every player between hands and waits until every node is
+// in the new hand
+func (e *Env) Deal() error {
+	for _, node := range e.Nodes {
+		if err := node.Game.SetPlayerReady(node.ID); err != nil {
+			return fmt.Errorf("%s failed to get ready: %w", node.ID, err)
+		}
+	}
+	return e.WaitFor("hand dealt", func() bool {
+		for _, node := range e.Nodes {
+			if node.Game.PublicState().CurrentTurn == "" {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// WaitFor polls cond until it holds or the timeout passes
+func (e *Env) WaitFor(what string, cond func() bool) error {
+	deadline := time.Now().Add(e.timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for %s", e.timeout, what)
+		}
+		time.Sleep(pollInterval)
+	}
+	return nil
+}
+
+// Stacks returns each player's stack as one node sees it
+func (n *Node) Stacks() map[string]int {
+	stacks := make(map[string]int)
+	for _, player := range n.Game.GetAllPlayers() {
+		stacks[player.PlayerID] = player.Stack
+	}
+	return stacks
+}
+
+// Agree checks that every node sees the same stacks and hands played, and
+// returns them
+func (e *Env) Agree() (map[string]int, error) {
+	first := e.Nodes[0]
+	want := first.Stacks()
+	hands := finishedHands(first.Game)
+	for _, node := range e.Nodes[1:] {
+		if got := node.Stacks(); !sameStacks(got, want) {
+			return nil, fmt.Errorf("%s sees stacks %s, %s sees %s", node.ID, formatStacks(got), first.ID, formatStacks(want))
+		}
+		if got := finishedHands(node.Game); got != hands {
+			return nil, fmt.Errorf("%s has finished %d hands, %s has finished %d", node.ID, got, first.ID, hands)
+		}
+	}
+	return want, nil
+}
+
+// Settled checks that no node has a settlement waiting to be retried
+func (e *Env) Settled() error {
+	for _, node := range e.Nodes {
+		if failed := node.Game.FailedSettlements(); len(failed) > 0 {
+			return fmt.Errorf("%s has %d failed settlements, the first for hand %d: %s", node.ID, len(failed), failed[0].HandID, failed[0].Error)
+		}
+	}
+	return nil
+}
+
+func finishedHands(g *game.Game) int {
+	n := 0
+	for _, hand := range g.HandHistories() {
+		if !hand.EndedAt.IsZero() {
+			n++
+		}
+	}
+	return n
+}
+
+func sameStacks(a, b map[string]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for player, stack := range a {
+		if other, ok := b[player]; !ok || other != stack {
+			return false
+		}
+	}
+	return true
+}
+
+func formatStacks(stacks map[string]int) string {
+	players := make([]string, 0, len(stacks))
+	for player := range stacks {
+		players = append(players, player)
+	}
+	sort.Strings(players)
+
+	out := ""
+	for i, player := range players {
+		if i > 0 {
+			out += " "
+		}
+		out += fmt.Sprintf("%s=%d", player, stacks[player])
+	}
+	return "[" + out + "]"
+}
@@ -0,0 +1,41 @@
+package testenv
+
+import (
+	"testing"
+
+	"github.com/RedPaladin7/peerpoker/internal/game"
+)
+
+// TestFoldedHand plays a heads-up hand through the peer protocol: the small
+// blind, first to act, folds, and every node must agree the big blind took
+// both blinds
+func TestFoldedHand(t *testing.T) {
+	env, err := New(Options{Nodes: 2})
+	if err != nil {
+		t.Fatalf("failed to start nodes: %v", err)
+	}
+	defer env.Close()
+
+	if err := env.Start(); err != nil {
+		t.Fatalf("failed to deal the first hand: %v", err)
+	}
+
+	// The button is drawn, so who posted which blind is read off the table
+	smallBlind := env.Nodes[0].Game.PublicState().CurrentTurn
+	bigBlind := env.Nodes[0].ID
+	if bigBlind == smallBlind {
+		bigBlind = env.Nodes[1].ID
+	}
+	posted := env.Nodes[0].Stacks()
+
+	err = env.Play(Hand{
+		Actions: []Step{{Player: smallBlind, Action: "fold"}},
+		Stacks: map[string]int{
+			smallBlind: posted[smallBlind],
+			bigBlind:   posted[bigBlind] + game.SmallBlind + game.BigBlind,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
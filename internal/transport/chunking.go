@@ -0,0 +1,186 @@
+package transport
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ChunkSize is the largest slice of a message carried by one chunk. Chunks
+	// are sent as JSON in text frames, so base64 overhead must still fit in
+	// MaxMessageSize.
+	ChunkSize = 256 * 1024
+	// MaxChunks bounds the size of a reassembled message (16 MB)
+	MaxChunks = 64
+	// ChunkTimeout is how long a partial message is kept waiting for its remaining chunks
+	ChunkTimeout = 30 * time.Second
+
+	maxPartialsPerSender = 4
+)
+
+// NeedsChunking reports whether an encoded message is too large for a single frame
+func NeedsChunking(data []byte) bool {
+	return len(data) > ChunkSize
+}
+
+// SplitMessage splits an encoded message into chunk messages. Chunks carry no
+// sender; the receiver attributes them to the connection they arrive on and
+// the reassembled message keeps its own sender and signature.
+func SplitMessage(data []byte, encoding protocol.Encoding) ([]*protocol.Message, error) {
+	total := (len(data) + ChunkSize - 1) / ChunkSize
+	if total > MaxChunks {
+		return nil, fmt.Errorf("message of %d bytes exceeds %d chunks", len(data), MaxChunks)
+	}
+
+	chunkID, err := newChunkID()
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make([]*protocol.Message, 0, total)
+	for i := 0; i < total; i++ {
+		end := (i + 1) * ChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		msg, err := protocol.NewMessage("", protocol.TypeChunk, protocol.ChunkPayload{
+			ChunkID:  chunkID,
+			Index:    i,
+			Total:    total,
+			Encoding: encoding,
+			Data:     data[i*ChunkSize : end],
+		})
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, msg)
+	}
+
+	return chunks, nil
+}
+
+func newChunkID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate chunk ID: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// partialMessage collects the chunks of one message
+type partialMessage struct {
+	parts     [][]byte
+	received  int
+	encoding  protocol.Encoding
+	startedAt time.Time
+}
+
+// Reassembler rebuilds chunked messages per connection, dropping partial
+// messages whose chunks stop arriving within the timeout
+type Reassembler struct {
+	partials map[string]map[string]*partialMessage // connection -> chunk ID -> partial
+	timeout  time.Duration
+	mu       sync.Mutex
+}
+
+// NewReassembler creates a reassembler with the given partial-message timeout
+func NewReassembler(timeout time.Duration) *Reassembler {
+	if timeout <= 0 {
+		timeout = ChunkTimeout
+	}
+	return &Reassembler{
+		partials: make(map[string]map[string]*partialMessage),
+		timeout:  timeout,
+	}
+}
+
+// Add stores a chunk received on a connection. When the last chunk arrives it
+// returns the original encoded message and its encoding.
+func (r *Reassembler) Add(conn string, chunk protocol.ChunkPayload) ([]byte, protocol.Encoding, bool, error) {
+	if chunk.Total <= 0 || chunk.Total > MaxChunks {
+		return nil, "", false, fmt.Errorf("invalid chunk count %d", chunk.Total)
+	}
+	if chunk.Index < 0 || chunk.Index >= chunk.Total {
+		return nil, "", false, fmt.Errorf("chunk index %d out of range", chunk.Index)
+	}
+	if len(chunk.Data) > ChunkSize {
+		return nil, "", false, fmt.Errorf("chunk of %d bytes exceeds limit", len(chunk.Data))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expireLocked(conn)
+
+	pending, ok := r.partials[conn]
+	if !ok {
+		pending = make(map[string]*partialMessage)
+		r.partials[conn] = pending
+	}
+
+	partial, ok := pending[chunk.ChunkID]
+	if !ok {
+		if len(pending) >= maxPartialsPerSender {
+			return nil, "", false, fmt.Errorf("too many partial messages from %s", conn)
+		}
+		partial = &partialMessage{
+			parts:     make([][]byte, chunk.Total),
+			encoding:  chunk.Encoding,
+			startedAt: time.Now(),
+		}
+		pending[chunk.ChunkID] = partial
+	}
+
+	if len(partial.parts) != chunk.Total {
+		delete(pending, chunk.ChunkID)
+		return nil, "", false, fmt.Errorf("chunk %s changed total from %d to %d", chunk.ChunkID, len(partial.parts), chunk.Total)
+	}
+	if partial.parts[chunk.Index] != nil {
+		return nil, "", false, fmt.Errorf("duplicate chunk %d of %s", chunk.Index, chunk.ChunkID)
+	}
+
+	partial.parts[chunk.Index] = chunk.Data
+	partial.received++
+	if partial.received < chunk.Total {
+		return nil, "", false, nil
+	}
+
+	delete(pending, chunk.ChunkID)
+	if len(pending) == 0 {
+		delete(r.partials, conn)
+	}
+
+	size := 0
+	for _, part := range partial.parts {
+		size += len(part)
+	}
+	data := make([]byte, 0, size)
+	for _, part := range partial.parts {
+		data = append(data, part...)
+	}
+	return data, partial.encoding, true, nil
+}
+
+// Forget drops every partial message from a closed connection
+func (r *Reassembler) Forget(conn string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.partials, conn)
+}
+
+func (r *Reassembler) expireLocked(conn string) {
+	for id, partial := range r.partials[conn] {
+		if time.Since(partial.startedAt) > r.timeout {
+			logrus.Warnf("Dropping incomplete chunked message %s from %s (%d/%d chunks)",
+				id, conn, partial.received, len(partial.parts))
+			delete(r.partials[conn], id)
+		}
+	}
+}
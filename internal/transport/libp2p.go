@@ -0,0 +1,129 @@
+//go:build libp2p
+
+package transport
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	libp2pprotocol "github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/sirupsen/logrus"
+)
+
+// libp2pProtocol identifies peerpoker streams between libp2p hosts
+const libp2pProtocol = libp2pprotocol.ID("/peerpoker/p2p/2.0.0")
+
+// Libp2pTransport carries length-prefixed messages over a libp2p stream
+// per peer, so nodes get libp2p's NAT traversal and encrypted, multiplexed
+// connections. Addresses are multiaddrs: /ip4/0.0.0.0/tcp/4001 to listen,
+// /ip4/1.2.3.4/tcp/4001/p2p/<peer id> to dial. Peers still go by their
+// node ID from the hello, not their libp2p peer ID.
+type Libp2pTransport struct {
+	opts  Options
+	links *links
+
+	mu   sync.Mutex
+	host host.Host
+}
+
+func newLibp2pTransport(opts Options) (Transport, error) {
+	return &Libp2pTransport{
+		opts:  opts,
+		links: newLinks(opts.LocalID),
+	}, nil
+}
+
+func (t *Libp2pTransport) Name() string { return TransportLibp2p }
+
+// ensureHost starts the libp2p host, listening on addrs if any
+func (t *Libp2pTransport) ensureHost(addrs ...string) (host.Host, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.host != nil {
+		if len(addrs) > 0 {
+			return nil, fmt.Errorf("libp2p host already started")
+		}
+		return t.host, nil
+	}
+
+	options := []libp2p.Option{libp2p.NoListenAddrs}
+	if len(addrs) > 0 {
+		options = []libp2p.Option{libp2p.ListenAddrStrings(addrs...)}
+	}
+	h, err := libp2p.New(options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start libp2p host: %w", err)
+	}
+	h.SetStreamHandler(libp2pProtocol, func(s network.Stream) {
+		t.links.accept(newStreamConn(s))
+	})
+	t.host = h
+	return h, nil
+}
+
+func (t *Libp2pTransport) Listen(addr string) error {
+	h, err := t.ensureHost(addr)
+	if err != nil {
+		return err
+	}
+	for _, a := range h.Addrs() {
+		logrus.Infof("libp2p transport listening on %s/p2p/%s", a, h.ID())
+	}
+	return nil
+}
+
+func (t *Libp2pTransport) Dial(addr string) (string, error) {
+	info, err := peer.AddrInfoFromString(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid libp2p address %q: %w", addr, err)
+	}
+	h, err := t.ensureHost()
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), helloTimeout)
+	defer cancel()
+	if err := h.Connect(ctx, *info); err != nil {
+		return "", fmt.Errorf("failed to dial peer: %w", err)
+	}
+	s, err := h.NewStream(ctx, info.ID, libp2pProtocol)
+	if err != nil {
+		return "", fmt.Errorf("failed to open stream to peer: %w", err)
+	}
+	return t.links.dial(newStreamConn(s))
+}
+
+func (t *Libp2pTransport) Send(peer string, data []byte) error {
+	return t.links.send(peer, data)
+}
+
+func (t *Libp2pTransport) OnMessage(handler MessageHandler) {
+	t.links.setHandler(handler)
+}
+
+func (t *Libp2pTransport) Disconnected(peer string) <-chan struct{} {
+	return t.links.disconnected(peer)
+}
+
+func (t *Libp2pTransport) Peers() []string {
+	return t.links.peers()
+}
+
+func (t *Libp2pTransport) Close() error {
+	t.mu.Lock()
+	h := t.host
+	t.mu.Unlock()
+
+	t.links.closeAll()
+	if h != nil {
+		return h.Close()
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !libp2p
+
+package transport
+
+import "fmt"
+
+func newLibp2pTransport(opts Options) (Transport, error) {
+	return nil, fmt.Errorf("libp2p transport not available: rebuild with -tags libp2p")
+}
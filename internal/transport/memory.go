@@ -0,0 +1,150 @@
+package transport
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TransportMemory names the in-process transport. It is not selectable in
+// config: nodes only reach each other through a shared MemoryNetwork.
+const TransportMemory = "memory"
+
+// memoryBuffer is how many frames a memory connection holds unread
+const memoryBuffer = 256
+
+// MemoryNetwork connects memory transports in one process, addressed by
+// the names they listen on
+type MemoryNetwork struct {
+	mu        sync.Mutex
+	listeners map[string]*MemoryTransport
+}
+
+func NewMemoryNetwork() *MemoryNetwork {
+	return &MemoryNetwork{listeners: make(map[string]*MemoryTransport)}
+}
+
+// Transport returns a transport on the network for the node opts describes
+func (n *MemoryNetwork) Transport(opts Options) *MemoryTransport {
+	return &MemoryTransport{
+		network: n,
+		links:   newLinks(opts.LocalID),
+	}
+}
+
+// memoryConn is one end of an in-process connection. Closing either end
+// closes both, as a dropped connection would.
+type memoryConn struct {
+	in     <-chan []byte
+	out    chan<- []byte
+	closed chan struct{}
+	once   *sync.Once
+}
+
+func memoryPipe() (*memoryConn, *memoryConn) {
+	ab := make(chan []byte, memoryBuffer)
+	ba := make(chan []byte, memoryBuffer)
+	closed := make(chan struct{})
+	once := &sync.Once{}
+	return &memoryConn{in: ba, out: ab, closed: closed, once: once},
+		&memoryConn{in: ab, out: ba, closed: closed, once: once}
+}
+
+func (c *memoryConn) ReadFrame() ([]byte, error) {
+	select {
+	case data := <-c.in:
+		return data, nil
+	case <-c.closed:
+		return nil, fmt.Errorf("connection closed")
+	}
+}
+
+func (c *memoryConn) WriteFrame(data []byte) error {
+	frame := append([]byte(nil), data...)
+	select {
+	case c.out <- frame:
+		return nil
+	case <-c.closed:
+		return fmt.Errorf("connection closed")
+	}
+}
+
+// Deadlines do not apply in memory; a stuck peer is cut off by Close
+func (c *memoryConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *memoryConn) SetWriteDeadline(t time.Time) error { return nil }
+
+func (c *memoryConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+// MemoryTransport carries messages between nodes in the same process, for
+// tests and simulations that run several nodes at once
+type MemoryTransport struct {
+	network *MemoryNetwork
+	links   *links
+
+	mu   sync.Mutex
+	addr string
+}
+
+func (t *MemoryTransport) Name() string { return TransportMemory }
+
+func (t *MemoryTransport) Listen(addr string) error {
+	t.network.mu.Lock()
+	defer t.network.mu.Unlock()
+	if _, taken := t.network.listeners[addr]; taken {
+		return fmt.Errorf("failed to listen on %s: address in use", addr)
+	}
+	t.network.listeners[addr] = t
+
+	t.mu.Lock()
+	t.addr = addr
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *MemoryTransport) Dial(addr string) (string, error) {
+	t.network.mu.Lock()
+	remote, ok := t.network.listeners[addr]
+	t.network.mu.Unlock()
+	if !ok {
+		return "", fmt.Errorf("failed to dial peer: nothing listening on %s", addr)
+	}
+
+	local, accepted := memoryPipe()
+	go remote.links.accept(accepted)
+	return t.links.dial(local)
+}
+
+func (t *MemoryTransport) Send(peer string, data []byte) error {
+	return t.links.send(peer, data)
+}
+
+func (t *MemoryTransport) OnMessage(handler MessageHandler) {
+	t.links.setHandler(handler)
+}
+
+func (t *MemoryTransport) Disconnected(peer string) <-chan struct{} {
+	return t.links.disconnected(peer)
+}
+
+func (t *MemoryTransport) Peers() []string {
+	return t.links.peers()
+}
+
+func (t *MemoryTransport) Close() error {
+	t.mu.Lock()
+	addr := t.addr
+	t.mu.Unlock()
+
+	if addr != "" {
+		t.network.mu.Lock()
+		if t.network.listeners[addr] == t {
+			delete(t.network.listeners, addr)
+		}
+		t.network.mu.Unlock()
+	}
+	t.links.closeAll()
+	return nil
+}
@@ -1,7 +1,9 @@
 package transport
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
@@ -17,6 +19,9 @@ type PeerConnection struct {
 	isOutbound  bool
 	lastPing    time.Time
 	mu          sync.RWMutex
+
+	// Round trip time and liveness from application-level pings
+	*PeerHealth
 }
 
 func NewPeerConnection(id, remoteAddr string, conn *websocket.Conn, isOutbound bool) *PeerConnection {
@@ -27,6 +32,7 @@ func NewPeerConnection(id, remoteAddr string, conn *websocket.Conn, isOutbound b
 		send:       make(chan []byte, 256),
 		isOutbound: isOutbound,
 		lastPing:   time.Now(),
+		PeerHealth: NewPeerHealth(),
 	}
 }
 
@@ -46,6 +52,7 @@ func (pc *PeerConnection) UpdatePing() {
 	pc.mu.Lock()
 	defer pc.mu.Unlock()
 	pc.lastPing = time.Now()
+	pc.Seen()
 }
 
 func (pc *PeerConnection) Send(data []byte) error {
@@ -62,12 +69,26 @@ func (pc *PeerConnection) Close() error {
 	return pc.conn.Close()
 }
 
-func DialPeer(url string) (*websocket.Conn, error) {
+// DialPeer connects to a peer's ws:// or wss:// endpoint. tlsConfig is used
+// for wss:// (nil for the system defaults), e.g. from TLSConfig.DialConfig
+// for mutual TLS.
+func DialPeer(url string, tlsConfig *tls.Config) (*websocket.Conn, error) {
+	return DialPeerAs(url, tlsConfig, "")
+}
+
+// DialPeerAs dials like DialPeer, telling the peer the ID to know this
+// node by (its listen address)
+func DialPeerAs(url string, tlsConfig *tls.Config, clientID string) (*websocket.Conn, error) {
 	dialer := websocket.Dialer{
 		HandshakeTimeout: 10 * time.Second,
+		TLSClientConfig:  tlsConfig,
 	}
 
-	conn, _, err := dialer.Dial(url, nil)
+	header := http.Header{}
+	if clientID != "" {
+		header.Set("X-Client-ID", clientID)
+	}
+	conn, _, err := dialer.Dial(url, header)
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial peer: %w", err)
 	}
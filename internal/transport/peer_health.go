@@ -0,0 +1,100 @@
+package transport
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+)
+
+// PeerHealth tracks a peer link from application-level pings: the round
+// trip time of the last pong and when anything was last heard from the peer
+type PeerHealth struct {
+	mu        sync.RWMutex
+	rtt       time.Duration
+	lastSeen  time.Time
+	pingsSent uint64
+	pongs     uint64
+}
+
+// PeerStats is a snapshot of a peer link's health
+type PeerStats struct {
+	RTT       time.Duration `json:"rtt_ns"`
+	LastSeen  time.Time     `json:"last_seen"`
+	PingsSent uint64        `json:"pings_sent"`
+	Pongs     uint64        `json:"pongs"`
+}
+
+func NewPeerHealth() *PeerHealth {
+	return &PeerHealth{lastSeen: time.Now()}
+}
+
+// Seen marks the peer as heard from
+func (h *PeerHealth) Seen() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastSeen = time.Now()
+}
+
+// NewPing returns the payload of the next ping to send
+func (h *PeerHealth) NewPing() protocol.PingPayload {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pingsSent++
+	return protocol.PingPayload{Timestamp: time.Now().UnixNano()}
+}
+
+// RecordPong takes the round trip time from a pong echoing one of our pings
+func (h *PeerHealth) RecordPong(pong protocol.PongPayload) (time.Duration, error) {
+	now := time.Now()
+	rtt := now.Sub(time.Unix(0, pong.PingTimestamp))
+	if pong.PingTimestamp <= 0 || rtt < 0 {
+		return 0, fmt.Errorf("pong echoes invalid ping timestamp %d", pong.PingTimestamp)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rtt = rtt
+	h.lastSeen = now
+	h.pongs++
+	return rtt, nil
+}
+
+// RTT returns the round trip time of the last pong, 0 before the first
+func (h *PeerHealth) RTT() time.Duration {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.rtt
+}
+
+// LastSeen returns when the peer was last heard from
+func (h *PeerHealth) LastSeen() time.Time {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.lastSeen
+}
+
+// SilentFor returns how long it has been since the peer was heard from
+func (h *PeerHealth) SilentFor() time.Duration {
+	return time.Since(h.LastSeen())
+}
+
+func (h *PeerHealth) Stats() PeerStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return PeerStats{
+		RTT:       h.rtt,
+		LastSeen:  h.lastSeen,
+		PingsSent: h.pingsSent,
+		Pongs:     h.pongs,
+	}
+}
+
+// Pong answers a peer's ping
+func Pong(ping protocol.PingPayload) protocol.PongPayload {
+	return protocol.PongPayload{
+		Timestamp:     time.Now().UnixNano(),
+		PingTimestamp: ping.Timestamp,
+	}
+}
@@ -0,0 +1,172 @@
+package transport
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MaxFrameSize bounds a message on stream transports. Messages are never
+// chunked there, so it matches the largest reassembled message.
+const MaxFrameSize = ChunkSize * MaxChunks
+
+// streamConn frames messages over a byte stream with a 4-byte big-endian
+// length prefix
+type streamConn struct {
+	rw     io.ReadWriteCloser
+	reader *bufio.Reader
+	wmu    sync.Mutex
+	read   func(time.Time) error
+	write  func(time.Time) error
+}
+
+// deadlineConn is what net.Conn and libp2p streams have in common
+type deadlineConn interface {
+	io.ReadWriteCloser
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+}
+
+func newStreamConn(conn deadlineConn) *streamConn {
+	return &streamConn{
+		rw:     conn,
+		reader: bufio.NewReader(conn),
+		read:   conn.SetReadDeadline,
+		write:  conn.SetWriteDeadline,
+	}
+}
+
+func (sc *streamConn) ReadFrame() ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(sc.reader, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > MaxFrameSize {
+		return nil, fmt.Errorf("frame of %d bytes exceeds %d", n, MaxFrameSize)
+	}
+
+	data := make([]byte, n)
+	if _, err := io.ReadFull(sc.reader, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (sc *streamConn) WriteFrame(data []byte) error {
+	if len(data) > MaxFrameSize {
+		return fmt.Errorf("frame of %d bytes exceeds %d", len(data), MaxFrameSize)
+	}
+
+	sc.wmu.Lock()
+	defer sc.wmu.Unlock()
+
+	frame := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	copy(frame[4:], data)
+	_, err := sc.rw.Write(frame)
+	return err
+}
+
+func (sc *streamConn) SetReadDeadline(t time.Time) error  { return sc.read(t) }
+func (sc *streamConn) SetWriteDeadline(t time.Time) error { return sc.write(t) }
+func (sc *streamConn) Close() error                       { return sc.rw.Close() }
+
+// TCPTransport carries length-prefixed messages over raw TCP, or TLS when
+// configured
+type TCPTransport struct {
+	opts  Options
+	links *links
+
+	mu       sync.Mutex
+	listener net.Listener
+}
+
+func NewTCPTransport(opts Options) *TCPTransport {
+	return &TCPTransport{
+		opts:  opts,
+		links: newLinks(opts.LocalID),
+	}
+}
+
+func (t *TCPTransport) Name() string { return TransportTCP }
+
+func (t *TCPTransport) Listen(addr string) error {
+	var listener net.Listener
+	var err error
+	if t.opts.ListenTLS != nil {
+		listener, err = tls.Listen("tcp", addr, t.opts.ListenTLS)
+	} else {
+		listener, err = net.Listen("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	t.mu.Lock()
+	t.listener = listener
+	t.mu.Unlock()
+
+	logrus.Infof("TCP transport listening on %s", listener.Addr())
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go t.links.accept(newStreamConn(conn))
+		}
+	}()
+	return nil
+}
+
+func (t *TCPTransport) Dial(addr string) (string, error) {
+	dialer := &net.Dialer{Timeout: helloTimeout}
+
+	var conn net.Conn
+	var err error
+	if t.opts.DialTLS != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, t.opts.DialTLS)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to dial peer: %w", err)
+	}
+	return t.links.dial(newStreamConn(conn))
+}
+
+func (t *TCPTransport) Send(peer string, data []byte) error {
+	return t.links.send(peer, data)
+}
+
+func (t *TCPTransport) OnMessage(handler MessageHandler) {
+	t.links.setHandler(handler)
+}
+
+func (t *TCPTransport) Disconnected(peer string) <-chan struct{} {
+	return t.links.disconnected(peer)
+}
+
+func (t *TCPTransport) Peers() []string {
+	return t.links.peers()
+}
+
+func (t *TCPTransport) Close() error {
+	t.mu.Lock()
+	listener := t.listener
+	t.mu.Unlock()
+
+	t.links.closeAll()
+	if listener != nil {
+		return listener.Close()
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// TLSConfig describes how a node serves https:// and wss://, and how it
+// authenticates peers when mutual TLS is on
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+
+	// Obtain certificates from Let's Encrypt for these domains instead of
+	// using CertFile/KeyFile, caching them in AutocertCacheDir
+	AutocertDomains  []string
+	AutocertCacheDir string
+
+	// CA that peer certificates must chain to. When set, peers present
+	// their certificate on /p2p and the node presents its own when dialing.
+	PeerCAFile string
+}
+
+// Enabled reports whether a certificate source is configured
+func (c TLSConfig) Enabled() bool {
+	return len(c.AutocertDomains) > 0 || (c.CertFile != "" && c.KeyFile != "")
+}
+
+// ServerConfig builds the listener's TLS config. With a peer CA, client
+// certificates are requested and verified if given; browsers connect
+// without one, so the /p2p handler checks that peers did present one.
+func (c TLSConfig) ServerConfig() (*tls.Config, error) {
+	var cfg *tls.Config
+	if len(c.AutocertDomains) > 0 {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(c.AutocertDomains...),
+		}
+		if c.AutocertCacheDir != "" {
+			manager.Cache = autocert.DirCache(c.AutocertCacheDir)
+		}
+		cfg = manager.TLSConfig()
+	} else {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		cfg = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+	cfg.MinVersion = tls.VersionTLS12
+
+	if c.PeerCAFile != "" {
+		pool, err := loadCertPool(c.PeerCAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return cfg, nil
+}
+
+// DialConfig builds the TLS config for dialing wss:// peers: it trusts the
+// peer CA, if any, and presents the node's certificate for mutual TLS
+func (c TLSConfig) DialConfig() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.PeerCAFile == "" {
+		return cfg, nil
+	}
+
+	pool, err := loadCertPool(c.PeerCAFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg.RootCAs = pool
+
+	if c.CertFile != "" && c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", file)
+	}
+	return pool, nil
+}
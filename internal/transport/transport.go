@@ -0,0 +1,256 @@
+package transport
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Transport names accepted in config
+const (
+	TransportWebSocket = "websocket"
+	TransportTCP       = "tcp"
+	TransportLibp2p    = "libp2p"
+)
+
+// helloTimeout bounds how long a new connection may take to say who it is
+const helloTimeout = 10 * time.Second
+
+// Transport carries P2P messages between nodes, so the protocol layer is
+// not tied to WebSocket semantics. Messages arrive whole and in the order
+// they were sent. Each connection opens with both sides sending their
+// node ID, which is the peer ID the other side knows them by.
+type Transport interface {
+	// Name is the transport's config name
+	Name() string
+	// Listen accepts peer connections on addr until Close
+	Listen(addr string) error
+	// Dial connects to the peer at addr and returns its ID
+	Dial(addr string) (string, error)
+	// Send queues a message for a connected peer
+	Send(peer string, data []byte) error
+	// OnMessage sets the handler for messages from peers; set it before
+	// calling Listen or Dial
+	OnMessage(handler MessageHandler)
+	// Disconnected is closed when the connection to peer drops
+	Disconnected(peer string) <-chan struct{}
+	// Peers returns the IDs of connected peers
+	Peers() []string
+	Close() error
+}
+
+// MessageHandler receives a message from a peer
+type MessageHandler func(peer string, data []byte)
+
+// Options configures a transport
+type Options struct {
+	LocalID   string      // the ID peers know this node by
+	ListenTLS *tls.Config // nil listens in plaintext
+	DialTLS   *tls.Config // nil dials in plaintext
+}
+
+// New returns the transport with the given config name
+func New(name string, opts Options) (Transport, error) {
+	switch name {
+	case "", TransportWebSocket:
+		return NewWSTransport(opts), nil
+	case TransportTCP:
+		return NewTCPTransport(opts), nil
+	case TransportLibp2p:
+		return newLibp2pTransport(opts)
+	}
+	return nil, fmt.Errorf("unknown transport: %s", name)
+}
+
+// frameConn is a connection that carries whole messages
+type frameConn interface {
+	ReadFrame() ([]byte, error)
+	WriteFrame(data []byte) error
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+	Close() error
+}
+
+// link is a live connection to a peer
+type link struct {
+	conn frameConn
+	send chan []byte
+	done chan struct{}
+}
+
+// links runs the connections of a transport: the hello exchange, a writer
+// per connection and the read loop feeding the message handler
+type links struct {
+	localID string
+
+	mu      sync.RWMutex
+	conns   map[string]*link
+	handler MessageHandler
+}
+
+func newLinks(localID string) *links {
+	return &links{
+		localID: localID,
+		conns:   make(map[string]*link),
+	}
+}
+
+func (l *links) setHandler(handler MessageHandler) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.handler = handler
+}
+
+// hello trades node IDs over a new connection
+func (l *links) hello(conn frameConn) (string, error) {
+	conn.SetReadDeadline(time.Now().Add(helloTimeout))
+	conn.SetWriteDeadline(time.Now().Add(helloTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+
+	if err := conn.WriteFrame([]byte(l.localID)); err != nil {
+		return "", fmt.Errorf("failed to send hello: %w", err)
+	}
+	id, err := conn.ReadFrame()
+	if err != nil {
+		return "", fmt.Errorf("failed to read hello: %w", err)
+	}
+	if len(id) == 0 || len(id) > 256 {
+		return "", fmt.Errorf("invalid peer ID in hello")
+	}
+	return string(id), nil
+}
+
+// accept serves an inbound connection until it drops
+func (l *links) accept(conn frameConn) {
+	peer, err := l.hello(conn)
+	if err != nil {
+		logrus.Warnf("Rejected peer connection: %v", err)
+		conn.Close()
+		return
+	}
+	l.serve(peer, l.register(peer, conn))
+}
+
+// dial completes an outbound connection and serves it in the background
+func (l *links) dial(conn frameConn) (string, error) {
+	peer, err := l.hello(conn)
+	if err != nil {
+		conn.Close()
+		return "", err
+	}
+	go l.serve(peer, l.register(peer, conn))
+	return peer, nil
+}
+
+// register makes conn the peer's connection, replacing any older one
+func (l *links) register(peer string, conn frameConn) *link {
+	lk := &link{
+		conn: conn,
+		send: make(chan []byte, 256),
+		done: make(chan struct{}),
+	}
+
+	l.mu.Lock()
+	if old, ok := l.conns[peer]; ok {
+		old.conn.Close() // the newer connection wins
+	}
+	l.conns[peer] = lk
+	l.mu.Unlock()
+
+	logrus.WithField("peer", peer).Info("Peer connected")
+	go lk.writeLoop()
+	return lk
+}
+
+// serve feeds a peer's messages to the handler until the connection drops
+func (l *links) serve(peer string, lk *link) {
+	l.mu.RLock()
+	handler := l.handler
+	l.mu.RUnlock()
+
+	for {
+		data, err := lk.conn.ReadFrame()
+		if err != nil {
+			break
+		}
+		if handler != nil {
+			handler(peer, data)
+		}
+	}
+
+	l.mu.Lock()
+	if l.conns[peer] == lk {
+		delete(l.conns, peer)
+	}
+	l.mu.Unlock()
+	lk.conn.Close()
+	close(lk.done)
+	logrus.WithField("peer", peer).Info("Peer disconnected")
+}
+
+func (lk *link) writeLoop() {
+	for {
+		select {
+		case data := <-lk.send:
+			lk.conn.SetWriteDeadline(time.Now().Add(WriteWait))
+			if err := lk.conn.WriteFrame(data); err != nil {
+				lk.conn.Close()
+				return
+			}
+		case <-lk.done:
+			return
+		}
+	}
+}
+
+func (l *links) send(peer string, data []byte) error {
+	l.mu.RLock()
+	lk, ok := l.conns[peer]
+	l.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("peer %s is not connected", peer)
+	}
+
+	select {
+	case lk.send <- data:
+		return nil
+	case <-lk.done:
+		return fmt.Errorf("peer %s disconnected", peer)
+	default:
+		return fmt.Errorf("send buffer full for peer %s", peer)
+	}
+}
+
+// disconnected returns a channel closed when peer's connection drops,
+// closed already when it is not connected
+func (l *links) disconnected(peer string) <-chan struct{} {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if lk, ok := l.conns[peer]; ok {
+		return lk.done
+	}
+	done := make(chan struct{})
+	close(done)
+	return done
+}
+
+func (l *links) peers() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	ids := make([]string, 0, len(l.conns))
+	for id := range l.conns {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (l *links) closeAll() {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, lk := range l.conns {
+		lk.conn.Close()
+	}
+}
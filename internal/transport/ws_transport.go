@@ -0,0 +1,135 @@
+package transport
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+// wsFrameConn carries one message per WebSocket frame: text for JSON,
+// binary for anything else
+type wsFrameConn struct {
+	conn *websocket.Conn
+	wmu  sync.Mutex
+}
+
+func newWSFrameConn(conn *websocket.Conn) *wsFrameConn {
+	conn.SetReadLimit(MaxMessageSize)
+	return &wsFrameConn{conn: conn}
+}
+
+func (wc *wsFrameConn) ReadFrame() ([]byte, error) {
+	_, data, err := wc.conn.ReadMessage()
+	return data, err
+}
+
+func (wc *wsFrameConn) WriteFrame(data []byte) error {
+	wc.wmu.Lock()
+	defer wc.wmu.Unlock()
+
+	frameType := websocket.BinaryMessage
+	if len(data) > 0 && data[0] == '{' {
+		frameType = websocket.TextMessage
+	}
+	return wc.conn.WriteMessage(frameType, data)
+}
+
+func (wc *wsFrameConn) SetReadDeadline(t time.Time) error  { return wc.conn.SetReadDeadline(t) }
+func (wc *wsFrameConn) SetWriteDeadline(t time.Time) error { return wc.conn.SetWriteDeadline(t) }
+func (wc *wsFrameConn) Close() error                       { return wc.conn.Close() }
+
+// WSTransport carries messages over WebSocket, listening on /p2p. Messages
+// larger than MaxMessageSize must be chunked by the caller. The node itself
+// serves WebSocket peers through its hub, which handles chunking and
+// encodings; this is for running the protocol standalone.
+type WSTransport struct {
+	opts  Options
+	links *links
+
+	mu     sync.Mutex
+	server *http.Server
+}
+
+func NewWSTransport(opts Options) *WSTransport {
+	return &WSTransport{
+		opts:  opts,
+		links: newLinks(opts.LocalID),
+	}
+}
+
+func (t *WSTransport) Name() string { return TransportWebSocket }
+
+func (t *WSTransport) Listen(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/p2p", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := DefaultUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			logrus.Errorf("Failed to upgrade peer connection: %v", err)
+			return
+		}
+		t.links.accept(newWSFrameConn(conn))
+	})
+
+	server := &http.Server{
+		Addr:      addr,
+		Handler:   mux,
+		TLSConfig: t.opts.ListenTLS,
+	}
+	t.mu.Lock()
+	t.server = server
+	t.mu.Unlock()
+
+	go func() {
+		var err error
+		if server.TLSConfig != nil {
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			logrus.Errorf("WebSocket transport stopped: %v", err)
+		}
+	}()
+	logrus.Infof("WebSocket transport listening on %s", addr)
+	return nil
+}
+
+// Dial connects to a peer's ws:// or wss:// /p2p endpoint
+func (t *WSTransport) Dial(url string) (string, error) {
+	conn, err := DialPeerAs(url, t.opts.DialTLS, t.opts.LocalID)
+	if err != nil {
+		return "", err
+	}
+	return t.links.dial(newWSFrameConn(conn))
+}
+
+func (t *WSTransport) Send(peer string, data []byte) error {
+	return t.links.send(peer, data)
+}
+
+func (t *WSTransport) OnMessage(handler MessageHandler) {
+	t.links.setHandler(handler)
+}
+
+func (t *WSTransport) Disconnected(peer string) <-chan struct{} {
+	return t.links.disconnected(peer)
+}
+
+func (t *WSTransport) Peers() []string {
+	return t.links.peers()
+}
+
+func (t *WSTransport) Close() error {
+	t.mu.Lock()
+	server := t.server
+	t.mu.Unlock()
+
+	t.links.closeAll()
+	if server != nil {
+		return server.Close()
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+// Minimal PeerPoker client: follows the table over the player WebSocket and
+// acts through the HTTP API as this node's player.
+(function () {
+  'use strict';
+
+  const $ = (id) => document.getElementById(id);
+  const logLimit = 100;
+
+  let config = null;
+  let refreshing = false;
+  let refreshAgain = false;
+  let reconnectWait = 500;
+
+  // Every API call carries the player ID, as the node expects
+  async function api(method, path, body) {
+    const opts = { method, headers: { 'X-Client-ID': config.player_id } };
+    if (body !== undefined) {
+      opts.headers['Content-Type'] = 'application/json';
+      opts.body = JSON.stringify(body);
+    }
+    const resp = await fetch(path, opts);
+    const data = await resp.json().catch(() => ({}));
+    if (!resp.ok) {
+      throw new Error(data.error || resp.statusText);
+    }
+    return data;
+  }
+
+  function cardElement(card) {
+    const el = document.createElement('span');
+    el.className = 'card';
+    if (card.suit === 'Hearts' || card.suit === 'Diamonds') {
+      el.classList.add('red');
+    }
+    el.textContent = card.display;
+    return el;
+  }
+
+  function renderCards(el, cards) {
+    el.replaceChildren(...(cards || []).map(cardElement));
+  }
+
+  function renderPlayers(players, table) {
+    const rows = players.map((p) => {
+      const tr = document.createElement('tr');
+      if (p.is_current_turn) tr.classList.add('turn');
+      if (p.player_id === config.player_id) tr.classList.add('me');
+
+      const flags = [];
+      if (p.is_folded) flags.push('folded');
+      if (p.is_all_in) flags.push('all-in');
+      if (!p.is_active) flags.push('away');
+      if (p.is_ready && table.status === 'WAITING') flags.push('ready');
+
+      for (const text of [p.is_dealer ? 'D' : '', p.player_id, p.stack, p.current_bet, flags.join(' ')]) {
+        const td = document.createElement('td');
+        td.textContent = text;
+        tr.appendChild(td);
+      }
+      return tr;
+    });
+    $('players').tBodies[0].replaceChildren(...rows);
+  }
+
+  function renderActions(table) {
+    const valid = new Set(table.valid_actions || []);
+    const waiting = table.status === 'WAITING';
+    for (const button of document.querySelectorAll('#actions button')) {
+      const action = button.dataset.action;
+      button.disabled = action === 'ready' ? !waiting : !(table.is_my_turn && valid.has(action));
+    }
+    if (table.is_my_turn) {
+      $('turn').textContent = `Your turn: ${table.amount_to_call} to call, min raise ${table.min_raise}, max ${table.max_bet}`;
+      $('amount').min = table.min_raise;
+    } else {
+      $('turn').textContent = '';
+    }
+  }
+
+  // Events arrive in bursts; refreshes in flight absorb them into one more
+  async function refresh() {
+    if (refreshing) {
+      refreshAgain = true;
+      return;
+    }
+    refreshing = true;
+    try {
+      const [table, players] = await Promise.all([api('GET', '/api/v1/table'), api('GET', '/api/v1/players')]);
+      $('summary').textContent = `${table.status} · blinds ${table.small_blind}/${table.big_blind} · pot ${table.pot}`;
+      renderCards($('board'), table.community_cards);
+      renderCards($('hand'), table.my_hand);
+      renderPlayers(players.players || [], table);
+      renderActions(table);
+    } catch (err) {
+      $('error').textContent = err.message;
+    } finally {
+      refreshing = false;
+    }
+    if (refreshAgain) {
+      refreshAgain = false;
+      refresh();
+    }
+  }
+
+  function log(text) {
+    const li = document.createElement('li');
+    li.textContent = `${new Date().toLocaleTimeString()} ${text}`;
+    const list = $('log');
+    list.appendChild(li);
+    while (list.children.length > logLimit) list.removeChild(list.firstChild);
+    list.scrollTop = list.scrollHeight;
+  }
+
+  function describe(event) {
+    const d = event.data || {};
+    switch (event.type) {
+      case 'new_hand':
+        return '— new hand —';
+      case 'player_action':
+        return `${d.player_id} ${d.action}${d.amount ? ' ' + d.amount : ''}`;
+      case 'winner':
+        return (d.winners || [])
+          .map((w) => `${w.player_id} wins ${w.amount}${w.hand_name ? ' with ' + w.hand_name : ''}`)
+          .join(', ');
+      case 'chat':
+        return `<${d.player_id}> ${d.text}`;
+      case 'error':
+        return `error: ${d.message}`;
+      case 'player_disconnected':
+      case 'player_reconnected':
+      case 'player_abandoned':
+      case 'game_aborted':
+        return `${event.type.replace(/_/g, ' ')}${d.player_id ? ': ' + d.player_id : ''}`;
+      default:
+        return null;
+    }
+  }
+
+  function setConnection(text, up) {
+    const el = $('connection');
+    el.textContent = text;
+    el.classList.toggle('down', !up);
+  }
+
+  // The node batches queued events into one frame, one per line
+  function connect() {
+    const url = `${config.ws_url}?client_id=${encodeURIComponent(config.player_id)}`;
+    const ws = new WebSocket(url);
+
+    ws.onopen = () => {
+      reconnectWait = 500;
+      setConnection('connected', true);
+      refresh();
+    };
+    ws.onmessage = (msg) => {
+      for (const line of String(msg.data).split('\n')) {
+        if (!line.trim()) continue;
+        let event;
+        try {
+          event = JSON.parse(line);
+        } catch (e) {
+          continue;
+        }
+        const text = describe(event);
+        if (text) log(text);
+      }
+      refresh();
+    };
+    ws.onclose = () => {
+      setConnection('disconnected, reconnecting', false);
+      setTimeout(connect, reconnectWait);
+      reconnectWait = Math.min(reconnectWait * 2, 30000);
+    };
+  }
+
+  async function act(action) {
+    $('error').textContent = '';
+    try {
+      switch (action) {
+        case 'ready':
+        case 'show':
+        case 'muck':
+          await api('POST', `/api/v1/${action}`);
+          break;
+        default: {
+          const value = action === 'bet' || action === 'raise' ? parseInt($('amount').value, 10) || 0 : 0;
+          await api('POST', '/api/v1/action', { action, value });
+        }
+      }
+    } catch (err) {
+      $('error').textContent = err.message;
+    }
+    refresh();
+  }
+
+  async function start() {
+    const resp = await fetch('/api/v1/client-config');
+    config = await resp.json();
+    $('player').textContent = config.player_id;
+
+    for (const button of document.querySelectorAll('#actions button')) {
+      button.addEventListener('click', () => act(button.dataset.action));
+    }
+    $('chat').addEventListener('submit', async (e) => {
+      e.preventDefault();
+      const text = $('chat-text').value.trim();
+      if (!text) return;
+      try {
+        await api('POST', '/api/v1/chat', { text });
+        $('chat-text').value = '';
+      } catch (err) {
+        $('error').textContent = err.message;
+      }
+    });
+
+    connect();
+    refresh();
+  }
+
+  start().catch((err) => setConnection(`failed to start: ${err.message}`, false));
+})();
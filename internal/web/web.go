@@ -0,0 +1,22 @@
+// Package web holds the minimal browser client the API server serves at /,
+// so a fresh node is playable without a separate frontend build
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var static embed.FS
+
+// Handler serves the embedded client
+func Handler() http.Handler {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		// The directory is embedded at build time, so this cannot happen
+		panic(err)
+	}
+	return http.FileServer(http.FS(files))
+}
@@ -0,0 +1,256 @@
+// Package client is a Go SDK for playing a seat on a peerpoker node. It
+// submits actions over the node's HTTP API and follows the table over the
+// player WebSocket, reconnecting when the connection drops.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Config describes the node and the player a client acts for
+type Config struct {
+	APIURL   string // base URL of the node's HTTP API, e.g. http://localhost:8080
+	WSURL    string // player WebSocket URL, e.g. ws://localhost:3000/ws
+	PlayerID string // the player the client acts for (the node's listen address)
+
+	HTTPClient   *http.Client  // defaults to one with a 10s timeout
+	ReconnectMin time.Duration // first wait after the WebSocket drops
+	ReconnectMax time.Duration // longest wait between reconnect attempts
+}
+
+// Handler receives events from the player WebSocket
+type Handler func(event *Event)
+
+// Client talks to one node on behalf of one player. It is safe for
+// concurrent use.
+type Client struct {
+	cfg  Config
+	http *http.Client
+
+	mu           sync.RWMutex
+	handlers     map[EventType][]Handler
+	anyHandlers  []Handler
+	onConnect    func()
+	onDisconnect func(err error)
+
+	connMu sync.Mutex
+	conn   *websocket.Conn
+}
+
+// New creates a client; call Run to follow the table's events
+func New(cfg Config) (*Client, error) {
+	if cfg.APIURL == "" || cfg.PlayerID == "" {
+		return nil, fmt.Errorf("a client needs an API URL and a player ID")
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.ReconnectMin <= 0 {
+		cfg.ReconnectMin = 500 * time.Millisecond
+	}
+	if cfg.ReconnectMax < cfg.ReconnectMin {
+		cfg.ReconnectMax = 30 * time.Second
+	}
+	cfg.APIURL = strings.TrimRight(cfg.APIURL, "/")
+
+	return &Client{
+		cfg:      cfg,
+		http:     cfg.HTTPClient,
+		handlers: make(map[EventType][]Handler),
+	}, nil
+}
+
+// PlayerID returns the player the client acts for
+func (c *Client) PlayerID() string {
+	return c.cfg.PlayerID
+}
+
+// On registers a handler for one event type
+func (c *Client) On(eventType EventType, handler Handler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers[eventType] = append(c.handlers[eventType], handler)
+}
+
+// OnAny registers a handler for every event
+func (c *Client) OnAny(handler Handler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.anyHandlers = append(c.anyHandlers, handler)
+}
+
+// OnConnect is called each time the WebSocket (re)connects
+func (c *Client) OnConnect(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onConnect = fn
+}
+
+// OnDisconnect is called each time the WebSocket drops, with the reason
+func (c *Client) OnDisconnect(fn func(err error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onDisconnect = fn
+}
+
+// Table fetches the table as the player sees it, hole cards included
+func (c *Client) Table(ctx context.Context) (*TableState, error) {
+	var table TableState
+	if err := c.call(ctx, http.MethodGet, "/api/v1/table", nil, &table); err != nil {
+		return nil, err
+	}
+	return &table, nil
+}
+
+// Players fetches every player at the table
+func (c *Client) Players(ctx context.Context) ([]PlayerState, error) {
+	var resp struct {
+		Players []PlayerState `json:"players"`
+	}
+	if err := c.call(ctx, http.MethodGet, "/api/v1/players", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Players, nil
+}
+
+// Ready marks the player ready for the next hand
+func (c *Client) Ready(ctx context.Context) error {
+	return c.call(ctx, http.MethodPost, "/api/v1/ready", nil, nil)
+}
+
+// Act submits a betting action; value is the amount for bets and raises.
+// A request that fails in transit is retried once under the same
+// idempotency key, so the node never applies the action twice.
+func (c *Client) Act(ctx context.Context, action string, value int) error {
+	body := map[string]interface{}{
+		"action": action,
+		"value":  value,
+	}
+	key := newIdempotencyKey()
+	err := c.send(ctx, http.MethodPost, "/api/v1/action", key, body, nil)
+	if _, rejected := err.(*APIError); err != nil && !rejected && ctx.Err() == nil {
+		err = c.send(ctx, http.MethodPost, "/api/v1/action", key, body, nil)
+	}
+	return err
+}
+
+func (c *Client) Fold(ctx context.Context) error  { return c.Act(ctx, ActionFold, 0) }
+func (c *Client) Check(ctx context.Context) error { return c.Act(ctx, ActionCheck, 0) }
+func (c *Client) Call(ctx context.Context) error  { return c.Act(ctx, ActionCall, 0) }
+func (c *Client) AllIn(ctx context.Context) error { return c.Act(ctx, ActionAllIn, 0) }
+
+func (c *Client) Bet(ctx context.Context, amount int) error {
+	return c.Act(ctx, ActionBet, amount)
+}
+
+func (c *Client) Raise(ctx context.Context, amount int) error {
+	return c.Act(ctx, ActionRaise, amount)
+}
+
+// Show tables the player's hand at the showdown
+func (c *Client) Show(ctx context.Context) error {
+	return c.call(ctx, http.MethodPost, "/api/v1/show", nil, nil)
+}
+
+// Muck gives up the player's hand at the showdown without showing it
+func (c *Client) Muck(ctx context.Context) error {
+	return c.call(ctx, http.MethodPost, "/api/v1/muck", nil, nil)
+}
+
+// Chat posts a message to the table chat
+func (c *Client) Chat(ctx context.Context, text string) error {
+	return c.call(ctx, http.MethodPost, "/api/v1/chat", map[string]string{"text": text}, nil)
+}
+
+// SetProfile sets the player's display name, avatar and preferred stakes,
+// which the table shows in place of their address
+func (c *Client) SetProfile(ctx context.Context, profile Profile) (*Profile, error) {
+	var saved Profile
+	if err := c.call(ctx, http.MethodPut, "/api/v1/profile", profile, &saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// Do sends any other API request as the player, decoding the response
+// into out when it is not nil
+func (c *Client) Do(ctx context.Context, method, path string, body, out interface{}) error {
+	return c.call(ctx, method, path, body, out)
+}
+
+func (c *Client) call(ctx context.Context, method, path string, body, out interface{}) error {
+	return c.send(ctx, method, path, "", body, out)
+}
+
+func (c *Client) send(ctx context.Context, method, path, idempotencyKey string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.cfg.APIURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Client-ID", c.cfg.PlayerID)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return decodeError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// decodeError reads the node's {error, code} body, falling back to the raw
+// body for errors from proxies and the like
+func decodeError(resp *http.Response) error {
+	data, _ := io.ReadAll(resp.Body)
+	apiErr := &APIError{Status: resp.StatusCode}
+
+	var body struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	if json.Unmarshal(data, &body) == nil && body.Error != "" {
+		apiErr.Message, apiErr.Code = body.Error, body.Code
+	} else {
+		apiErr.Message = strings.TrimSpace(string(data))
+	}
+	return apiErr
+}
+
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
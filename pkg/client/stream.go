@@ -0,0 +1,157 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// readTimeout is how long the connection may go silent; the node pings
+// well within it
+const readTimeout = 90 * time.Second
+
+// Run connects to the player WebSocket and delivers events to the
+// registered handlers until the context is cancelled, reconnecting with
+// exponential backoff whenever the connection drops. Handlers run on
+// Run's goroutine, in the order the node sent the events.
+func (c *Client) Run(ctx context.Context) error {
+	if c.cfg.WSURL == "" {
+		return fmt.Errorf("a client needs a WebSocket URL to receive events")
+	}
+
+	wait := c.cfg.ReconnectMin
+	for {
+		conn, err := c.dial(ctx)
+		if err == nil {
+			wait = c.cfg.ReconnectMin
+			c.connected()
+			err = c.readLoop(ctx, conn)
+			c.disconnected(err)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > c.cfg.ReconnectMax {
+			wait = c.cfg.ReconnectMax
+		}
+	}
+}
+
+// Close drops the current WebSocket connection. Run reconnects unless its
+// context is cancelled as well.
+func (c *Client) Close() error {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// Connected reports whether the WebSocket is currently up
+func (c *Client) Connected() bool {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	return c.conn != nil
+}
+
+func (c *Client) dial(ctx context.Context) (*websocket.Conn, error) {
+	header := http.Header{}
+	header.Set("X-Client-ID", c.cfg.PlayerID)
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, c.cfg.WSURL, header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", c.cfg.WSURL, err)
+	}
+
+	c.connMu.Lock()
+	c.conn = conn
+	c.connMu.Unlock()
+	return conn, nil
+}
+
+// readLoop dispatches events until the connection fails or the context is
+// cancelled. The node batches queued events into one frame, one per line.
+func (c *Client) readLoop(ctx context.Context, conn *websocket.Conn) error {
+	defer func() {
+		c.connMu.Lock()
+		c.conn = nil
+		c.connMu.Unlock()
+		conn.Close()
+	}()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(readTimeout))
+	conn.SetPingHandler(func(data string) error {
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(10*time.Second))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+
+		for _, line := range bytes.Split(data, []byte{'\n'}) {
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			var event Event
+			if err := json.Unmarshal(line, &event); err != nil {
+				continue
+			}
+			c.dispatch(&event)
+		}
+	}
+}
+
+func (c *Client) dispatch(event *Event) {
+	c.mu.RLock()
+	handlers := append([]Handler(nil), c.handlers[event.Type]...)
+	handlers = append(handlers, c.anyHandlers...)
+	c.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+func (c *Client) connected() {
+	c.mu.RLock()
+	fn := c.onConnect
+	c.mu.RUnlock()
+	if fn != nil {
+		fn()
+	}
+}
+
+func (c *Client) disconnected(err error) {
+	c.mu.RLock()
+	fn := c.onDisconnect
+	c.mu.RUnlock()
+	if fn != nil {
+		fn(err)
+	}
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/RedPaladin7/peerpoker/internal/game"
+	"github.com/RedPaladin7/peerpoker/internal/protocol"
+)
+
+// The wire types are aliases of the node's own, so the SDK cannot drift
+// from what the server sends
+type (
+	Event       = protocol.Event
+	EventType   = protocol.EventType
+	GameState   = protocol.GameStateUpdateEvent
+	TableState  = game.TableStateResponse
+	PlayerState = game.PlayerStateResponse
+	Card        = game.CardResponse
+	Profile     = protocol.PlayerProfile
+	Stakes      = protocol.Stakes
+
+	// Event payloads, for Decode
+	PlayerJoinedEvent = protocol.PlayerJoinedEvent
+	PlayerActionEvent = protocol.PlayerActionEvent
+	NewHandEvent      = protocol.NewHandEvent
+	WinnerEvent       = protocol.WinnerEvent
+	ChatEvent         = protocol.ChatEvent
+	ErrorEvent        = protocol.ErrorEvent
+)
+
+// Event types a player connection receives
+const (
+	EventGameStateUpdate    = protocol.EventGameStateUpdate
+	EventPlayerJoined       = protocol.EventPlayerJoined
+	EventPlayerLeft         = protocol.EventPlayerLeft
+	EventPlayerAction       = protocol.EventPlayerAction
+	EventNewHand            = protocol.EventNewHand
+	EventCommunityCard      = protocol.EventCommunityCard
+	EventShowdown           = protocol.EventShowdown
+	EventWinner             = protocol.EventWinner
+	EventError              = protocol.EventError
+	EventTurnChange         = protocol.EventTurnChange
+	EventBlindsPosted       = protocol.EventBlindsPosted
+	EventSeatDraw           = protocol.EventSeatDraw
+	EventAbortVote          = protocol.EventAbortVote
+	EventIncident           = protocol.EventIncident
+	EventChat               = protocol.EventChat
+	EventSeatChanged        = protocol.EventSeatChanged
+	EventSeatAvailable      = protocol.EventSeatAvailable
+	EventRebuy              = protocol.EventRebuy
+	EventRunItTwiceOffer    = protocol.EventRunItTwiceOffer
+	EventRunItTwice         = protocol.EventRunItTwice
+	EventSitOut             = protocol.EventSitOut
+	EventShowdownTurn       = protocol.EventShowdownTurn
+	EventHandShown          = protocol.EventHandShown
+	EventHandMucked         = protocol.EventHandMucked
+	EventRabbitHunt         = protocol.EventRabbitHunt
+	EventTablePaused        = protocol.EventTablePaused
+	EventTableResumed       = protocol.EventTableResumed
+	EventPauseVote          = protocol.EventPauseVote
+	EventHostAction         = protocol.EventHostAction
+	EventHostVote           = protocol.EventHostVote
+	EventSuspiciousActivity = protocol.EventSuspiciousActivity
+	EventPlayerDisconnected = protocol.EventPlayerDisconnected
+	EventPlayerReconnected  = protocol.EventPlayerReconnected
+	EventPlayerAbandoned    = protocol.EventPlayerAbandoned
+	EventGameAborted        = protocol.EventGameAborted
+	EventPenaltyApplied     = protocol.EventPenaltyApplied
+	EventChainHealth        = protocol.EventChainHealth
+	EventShuffleAudit       = protocol.EventShuffleAudit
+)
+
+// StatusWaiting is TableState.Status between hands, while the table waits
+// for players to ready up
+const StatusWaiting = "WAITING"
+
+// StatusPaused is TableState.Status while the table is on hold; actions
+// are refused until it resumes
+const StatusPaused = "PAUSED"
+
+// Actions accepted by Act
+const (
+	ActionFold  = "fold"
+	ActionCheck = "check"
+	ActionCall  = "call"
+	ActionBet   = "bet"
+	ActionRaise = "raise"
+	ActionAllIn = "all_in"
+)
+
+// Decode unmarshals an event's payload, e.g. a GameState from a
+// game_state_update event
+func Decode(event *Event, v interface{}) error {
+	return json.Unmarshal(event.Data, v)
+}
+
+// APIError is a request the node rejected
+type APIError struct {
+	Status  int    // HTTP status code
+	Code    string // machine-readable error code, when the node sent one
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%d %s: %s", e.Status, e.Code, e.Message)
+	}
+	return fmt.Sprintf("%d: %s", e.Status, e.Message)
+}
@@ -84,4 +84,4 @@ echo "1. Edit .env file with your configuration"
 echo "2. Terminal 1: ${BLUE}npx hardhat node${NC}"
 echo "3. Terminal 2: ${BLUE}npx hardhat run scripts/deploy.js --network localhost${NC}"
 echo "4. Copy contract addresses to .env"
-echo "5. Terminal 3: ${BLUE}go run cmd/server/main.go${NC}"
+echo "5. Terminal 3: ${BLUE}go run ./cmd/server${NC}"